@@ -2,35 +2,52 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
 	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/cortex"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/notification"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
 	"github.com/nnnkkk7/snowflake-emulator/server/handlers"
+	emulatormiddleware "github.com/nnnkkk7/snowflake-emulator/server/middleware"
 )
 
 func main() {
+	// Subcommands for loading data and checking images; without one, serve
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed":
+			os.Exit(runSeed(os.Args[2:]))
+		case "sync":
+			os.Exit(runSync(os.Args[2:]))
+		case "healthcheck":
+			os.Exit(runHealthcheck())
+		}
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = ":memory:"
-	}
-
-	db, err := sql.Open("duckdb", dbPath)
+	db, err := openDatabase()
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
@@ -42,42 +59,57 @@ func main() {
 
 	connMgr := connection.NewManager(db)
 
-	repo, err := metadata.NewRepository(connMgr)
+	repoOpts := []metadata.RepositoryOption{metadata.WithLookupCacheTTL(metadataCacheTTL())}
+	if readOnly() {
+		repoOpts = append(repoOpts, metadata.WithReadOnly())
+	}
+	repo, err := metadata.NewRepository(connMgr, repoOpts...)
 	if err != nil {
 		log.Printf("Failed to create repository: %v", err)
 		return
 	}
 
-	sessionMgr := session.NewManager(24 * time.Hour)
-	stmtMgr := query.NewStatementManager(1 * time.Hour)
+	sessionMgr := session.NewManager(24*time.Hour, sessionManagerOptions(connMgr)...)
+	stmtMgr := query.NewStatementManager(1*time.Hour, statementManagerOptions()...)
+	executor := newExecutor(connMgr, repo)
 
-	executor := query.NewExecutor(connMgr, repo)
+	// SHOW WAREHOUSES lists the warehouses of the REST API
+	warehouseMgr := warehouse.NewManager()
+	executor.Configure(query.WithWarehouseManager(warehouseMgr))
 
-	// Initialize stage manager for COPY INTO support
-	stageDir := os.Getenv("STAGE_DIR")
-	if stageDir == "" {
-		stageDir = "./stages"
-	}
-	stageMgr := stage.NewManager(repo, stageDir)
+	// USE statements change the current database, schema, warehouse, and role of their session
+	executor.Configure(query.WithSessionUpdater(sessionMgr))
 
-	// Initialize processors and wire to executor.
-	// Due to circular dependency (processors need executor, executor needs processors),
-	// we create processors first, then configure executor with them.
-	copyProcessor := query.NewCopyProcessor(stageMgr, repo, executor)
-	mergeProcessor := query.NewMergeProcessor(executor)
-	executor.Configure(
-		query.WithCopyProcessor(copyProcessor),
-		query.WithMergeProcessor(mergeProcessor),
-	)
+	// Release the executor state of sessions that log out or expire
+	sessionMgr.OnClose(func(sess *session.Session) {
+		if err := executor.EndSession(context.Background(), strconv.FormatInt(sess.ID, 10)); err != nil {
+			log.Printf("Failed to end session %d: %v", sess.ID, err)
+		}
+	})
 
-	sessionHandler := handlers.NewSessionHandler(sessionMgr, repo)
-	queryHandler := handlers.NewQueryHandler(executor, sessionMgr)
-	restAPIHandler := handlers.NewRestAPIv2Handler(executor, stmtMgr, repo)
+	sessionHandler := handlers.NewSessionHandler(sessionMgr, repo, sessionHandlerOptions()...)
+	queryHandler := handlers.NewQueryHandler(executor, sessionMgr, queryHandlerOptions()...)
+	restAPIOpts := []handlers.RestAPIv2Option{handlers.WithSessionManager(sessionMgr)}
+	if os.Getenv("JSON_NUMBERS") == "true" {
+		restAPIOpts = append(restAPIOpts, handlers.WithJSONNumbers())
+	}
+	restAPIHandler := handlers.NewRestAPIv2HandlerWithWarehouse(executor, stmtMgr, repo, warehouseMgr, restAPIOpts...)
+	var adminOpts []handlers.AdminHandlerOption
+	if root := os.Getenv("EXPORT_DIR"); root != "" {
+		adminOpts = append(adminOpts, handlers.WithExportRoot(root))
+	}
+	adminHandler := handlers.NewAdminHandler(executor, adminOpts...)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	if level := compressionLevel(); level > 0 {
+		r.Use(emulatormiddleware.Compress(level))
+	}
+	if id := sessionMgr.InstanceID(); id != "" {
+		r.Use(emulatormiddleware.Instance(id))
+	}
 
 	r.Post("/session/v1/login-request", sessionHandler.Login)
 	r.Post("/session/token-request", sessionHandler.TokenRequest)
@@ -89,6 +121,7 @@ func main() {
 
 	r.Post("/queries/v1/query-request", queryHandler.ExecuteQuery)
 	r.Post("/queries/v1/abort-request", queryHandler.AbortQuery)
+	r.Get("/queries/v1/chunks/{queryId}/{index}", queryHandler.DownloadChunk)
 
 	// REST API v2 endpoints
 	r.Route("/api/v2", func(r chi.Router) {
@@ -96,6 +129,15 @@ func main() {
 		r.Post("/statements", restAPIHandler.SubmitStatement)
 		r.Get("/statements/{handle}", restAPIHandler.GetStatement)
 		r.Post("/statements/{handle}/cancel", restAPIHandler.CancelStatement)
+		r.Get("/statements/{handle}/download", restAPIHandler.DownloadStatement)
+
+		// Session introspection endpoints
+		r.Get("/sessions", restAPIHandler.ListSessions)
+		r.Get("/sessions/{sessionId}", restAPIHandler.GetSession)
+
+		// Query history endpoints
+		r.Get("/queries", restAPIHandler.ListQueries)
+		r.Get("/queries/{queryId}", restAPIHandler.GetQuery)
 
 		// Database endpoints
 		r.Get("/databases", restAPIHandler.ListDatabases)
@@ -116,6 +158,21 @@ func main() {
 		r.Get("/databases/{database}/schemas/{schema}/tables/{table}", restAPIHandler.GetTable)
 		r.Put("/databases/{database}/schemas/{schema}/tables/{table}", restAPIHandler.AlterTable)
 		r.Delete("/databases/{database}/schemas/{schema}/tables/{table}", restAPIHandler.DeleteTable)
+		r.Post("/databases/{database}/schemas/{schema}/tables/{table}/rows", restAPIHandler.LoadRows)
+		r.Get("/databases/{database}/schemas/{schema}/tables/{table}/preview", restAPIHandler.PreviewTable)
+
+		// Stage file endpoints
+		r.Get("/databases/{database}/schemas/{schema}/stages/{stage}/files", restAPIHandler.ListStageFiles)
+		r.Get("/databases/{database}/schemas/{schema}/stages/{stage}/files/*", restAPIHandler.GetStageFile)
+		r.Put("/databases/{database}/schemas/{schema}/stages/{stage}/files/*", restAPIHandler.PutStageFile)
+		r.Delete("/databases/{database}/schemas/{schema}/stages/{stage}/files/*", restAPIHandler.DeleteStageFile)
+		r.Get("/stages/{stage}/files", restAPIHandler.ListStageFiles)
+		r.Get("/stages/{stage}/files/*", restAPIHandler.GetStageFile)
+		r.Put("/stages/{stage}/files/*", restAPIHandler.PutStageFile)
+		r.Delete("/stages/{stage}/files/*", restAPIHandler.DeleteStageFile)
+
+		// Catalog endpoint
+		r.Get("/catalog", restAPIHandler.GetCatalog)
 
 		// Warehouse endpoints
 		r.Get("/warehouses", restAPIHandler.ListWarehouses)
@@ -126,6 +183,16 @@ func main() {
 		r.Post("/warehouses/{warehouse}:suspend", restAPIHandler.SuspendWarehouse)
 	})
 
+	// Emulator introspection endpoints
+	r.Get("/admin/transactions", adminHandler.ListTransactions)
+	r.Get("/admin/capabilities", adminHandler.Capabilities)
+	r.Post("/admin/databases/{database}/export", adminHandler.ExportDatabase)
+	r.Post("/admin/data-metrics/evaluate", adminHandler.EvaluateDataMetrics)
+	r.Get("/admin/notifications", adminHandler.ListSentEmails)
+	r.Get("/admin/metadata-cache", adminHandler.MetadataCache)
+	r.Post("/admin/diff", adminHandler.DiffResults)
+	r.Get("/admin/compat-report", adminHandler.CompatReport)
+
 	// Telemetry endpoint - accept and ignore (gosnowflake sends telemetry data)
 	r.Post("/telemetry/send", func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -148,8 +215,345 @@ func main() {
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Stop serving on SIGINT or SIGTERM, letting requests in flight finish
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		<-stop.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Failed to shut down the server: %v", err)
+		}
+	}()
+
 	log.Printf("Starting Snowflake Emulator on port %s", port)
-	if err := server.ListenAndServe(); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Server failed: %v", err) //nolint:gocritic // exitAfterDefer: intentional - OS cleans up on exit
 	}
+	writeCompatReport(executor)
+}
+
+// writeCompatReport writes the compatibility report to the file COMPAT_REPORT
+// names, as Markdown for a .md file and as JSON otherwise. A COMPAT_REPORT of
+// true only serves the report from /admin/compat-report.
+func writeCompatReport(executor *query.Executor) {
+	path := os.Getenv("COMPAT_REPORT")
+	report := executor.CompatReport()
+	if report == nil || path == "" || path == "true" {
+		return
+	}
+	if err := report.WriteFile(path); err != nil {
+		log.Printf("Failed to write the compatibility report: %v", err)
+		return
+	}
+	log.Printf("Wrote the compatibility report to %s", path)
+}
+
+// statementManagerOptions configures the REST API v2 statement manager from
+// the environment: STATEMENT_WORKERS statements submitted with async=true run
+// at once, and results of more than RESULT_SPILL_ROWS rows are written to
+// RESULTS_DIR in partitions of RESULT_PARTITION_ROWS rows.
+func statementManagerOptions() []query.StatementManagerOption {
+	var opts []query.StatementManagerOption
+	if value := os.Getenv("STATEMENT_WORKERS"); value != "" {
+		if workers, err := strconv.Atoi(value); err == nil && workers > 0 {
+			opts = append(opts, query.WithStatementWorkers(workers, query.DefaultStatementQueueSize))
+		} else {
+			log.Printf("Ignoring STATEMENT_WORKERS %q: must be a positive number of workers", value)
+		}
+	}
+
+	value := os.Getenv("RESULT_SPILL_ROWS")
+	if value == "" {
+		return opts
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		log.Printf("Ignoring RESULT_SPILL_ROWS %q: must be a number of rows", value)
+		return opts
+	}
+	partitionRows := 10000
+	if value := os.Getenv("RESULT_PARTITION_ROWS"); value != "" {
+		if rows, err := strconv.Atoi(value); err == nil && rows > 0 {
+			partitionRows = rows
+		} else {
+			log.Printf("Ignoring RESULT_PARTITION_ROWS %q: must be a positive number of rows", value)
+		}
+	}
+	dir := os.Getenv("RESULTS_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "snowflake-emulator-results")
+	}
+	return append(opts, query.WithResultSpill(dir, threshold, partitionRows))
+}
+
+// queryHandlerOptions configures the chunking of large driver results from the
+// environment: results are split into chunks of at most RESULT_CHUNK_ROWS rows
+// and RESULT_CHUNK_BYTES bytes. A RESULT_CHUNK_ROWS of 0 disables chunking.
+func queryHandlerOptions() []handlers.QueryHandlerOption {
+	rows, bytes := query.DefaultChunkRows, int64(query.DefaultChunkBytes)
+	if value := os.Getenv("RESULT_CHUNK_ROWS"); value != "" {
+		n, err := strconv.Atoi(value)
+		switch {
+		case err != nil || n < 0:
+			log.Printf("Ignoring RESULT_CHUNK_ROWS %q: must be a number of rows", value)
+		case n == 0:
+			return nil
+		default:
+			rows = n
+		}
+	}
+	if value := os.Getenv("RESULT_CHUNK_BYTES"); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+			bytes = n
+		} else {
+			log.Printf("Ignoring RESULT_CHUNK_BYTES %q: must be a positive number of bytes", value)
+		}
+	}
+	store := query.NewChunkStore(1*time.Hour, query.WithChunkLimits(rows, bytes))
+	return []handlers.QueryHandlerOption{handlers.WithResultChunks(store)}
+}
+
+// metadataCacheTTL returns how long databases and schemas looked up by name
+// are cached, from METADATA_CACHE_TTL. 0 disables the cache.
+func metadataCacheTTL() time.Duration {
+	value := os.Getenv("METADATA_CACHE_TTL")
+	if value == "" {
+		return metadata.DefaultLookupCacheTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		log.Printf("Ignoring METADATA_CACHE_TTL %q: must be a duration such as 30s", value)
+		return metadata.DefaultLookupCacheTTL
+	}
+	return ttl
+}
+
+// copyWorkers returns the maximum number of files COPY INTO loads
+// concurrently, from COPY_WORKERS.
+func copyWorkers() int {
+	value := os.Getenv("COPY_WORKERS")
+	if value == "" {
+		return query.DefaultCopyWorkers
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		log.Printf("Ignoring COPY_WORKERS %q: must be a positive integer", value)
+		return query.DefaultCopyWorkers
+	}
+	return workers
+}
+
+// maxBindings returns the maximum number of bindings of a statement, from
+// MAX_BINDINGS. 0 removes the limit.
+func maxBindings() int {
+	value := os.Getenv("MAX_BINDINGS")
+	if value == "" {
+		return query.DefaultMaxBindings
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Ignoring MAX_BINDINGS %q: must be a non-negative integer", value)
+		return query.DefaultMaxBindings
+	}
+	return n
+}
+
+// compressionLevel returns the level of the response compression from
+// COMPRESSION_LEVEL, from 1 (fastest) to 9 (smallest). 0 disables compression.
+func compressionLevel() int {
+	value := os.Getenv("COMPRESSION_LEVEL")
+	if value == "" {
+		return emulatormiddleware.DefaultCompressionLevel
+	}
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 || level > 9 {
+		log.Printf("Ignoring COMPRESSION_LEVEL %q: must be from 0 to 9", value)
+		return emulatormiddleware.DefaultCompressionLevel
+	}
+	return level
+}
+
+// sessionHandlerOptions returns the session handler options from the
+// environment: MIN_CLIENT_VERSIONS rejects logins from older drivers.
+func sessionHandlerOptions() []handlers.SessionHandlerOption {
+	value := os.Getenv("MIN_CLIENT_VERSIONS")
+	if value == "" {
+		return nil
+	}
+	versions, err := handlers.ParseMinClientVersions(value)
+	if err != nil {
+		log.Printf("Ignoring MIN_CLIENT_VERSIONS: %v", err)
+		return nil
+	}
+	return []handlers.SessionHandlerOption{handlers.WithMinClientVersions(versions)}
+}
+
+// sessionManagerOptions returns the session manager options from the
+// environment: INSTANCE_ID prefixes tokens with the replica's ID, and
+// SHARED_SESSIONS keeps sessions in the DuckDB target, shared by the replicas
+// using it. READ_ONLY replicas cannot write sessions, so they keep them in
+// memory.
+func sessionManagerOptions(connMgr *connection.Manager) []session.ManagerOption {
+	var opts []session.ManagerOption
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		opts = append(opts, session.WithInstanceID(id))
+	}
+	if os.Getenv("SHARED_SESSIONS") != "true" {
+		return opts
+	}
+	if readOnly() {
+		log.Printf("Ignoring SHARED_SESSIONS: sessions cannot be stored with READ_ONLY")
+		return opts
+	}
+	store, err := session.NewStore(connMgr)
+	if err != nil {
+		log.Printf("Ignoring SHARED_SESSIONS: %v", err)
+		return opts
+	}
+	return append(opts, session.WithStore(store))
+}
+
+// databasePath returns the DuckDB database path from DB_PATH, in memory by default.
+func databasePath() string {
+	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
+		return dbPath
+	}
+	return ":memory:"
+}
+
+// readOnly reports whether READ_ONLY is set, to run a replica serving reads
+// from a seeded database file.
+func readOnly() bool {
+	return os.Getenv("READ_ONLY") == "true"
+}
+
+// openDatabase opens the DuckDB database at DB_PATH, which may be a MotherDuck
+// database (md:name). DUCKDB_INIT_SQL runs on every connection, and
+// DUCKDB_ATTACH names a database to keep all state in instead, such as one
+// shared by emulator replicas. With READ_ONLY, the database is opened
+// read-only. DATABASE_MEMORY_LIMIT and TEMP_DIRECTORY_LIMIT bound the memory
+// and spill space the database's statements share, so that a runaway statement
+// fails instead of taking the instance down, and TEMP_DIRECTORY sets where
+// they spill.
+func openDatabase() (*sql.DB, error) {
+	var opts []connection.OpenOption
+	if readOnly() {
+		opts = append(opts, connection.WithReadOnly())
+	}
+	if initSQL := os.Getenv("DUCKDB_INIT_SQL"); initSQL != "" {
+		opts = append(opts, connection.WithInitSQL(query.SplitStatements(initSQL)...))
+	}
+	if target := os.Getenv("DUCKDB_ATTACH"); target != "" {
+		opts = append(opts, connection.WithAttach(target))
+	}
+	if limit := os.Getenv("DATABASE_MEMORY_LIMIT"); limit != "" {
+		opts = append(opts, connection.WithDatabaseMemoryLimit(limit))
+	}
+	if dir := os.Getenv("TEMP_DIRECTORY"); dir != "" {
+		opts = append(opts, connection.WithTempDirectory(dir))
+	}
+	if limit := os.Getenv("TEMP_DIRECTORY_LIMIT"); limit != "" {
+		opts = append(opts, connection.WithTempDirectoryLimit(limit))
+	}
+	return connection.Open(databasePath(), opts...)
+}
+
+// newExecutor creates the query executor configured from the environment, with
+// the COPY and MERGE processors wired in.
+func newExecutor(connMgr *connection.Manager, repo *metadata.Repository) *query.Executor {
+	var translatorOpts []query.TranslatorOption
+	if os.Getenv("IMPLICIT_CASTING") == "true" {
+		translatorOpts = append(translatorOpts, query.WithImplicitCasting())
+	}
+	orderingCheck, err := query.ParseOrderingCheck(os.Getenv("ORDERING_CHECK"))
+	if err != nil {
+		log.Printf("Ignoring ORDERING_CHECK: %v", err)
+	}
+	dropProtection, err := query.ParseDropProtection(os.Getenv("DROP_PROTECTION"))
+	if err != nil {
+		log.Printf("Ignoring DROP_PROTECTION: %v", err)
+	}
+	statementMemoryLimit, err := query.ParseStatementMemoryLimit(os.Getenv("STATEMENT_MEMORY_LIMIT"))
+	if err != nil {
+		log.Printf("Ignoring STATEMENT_MEMORY_LIMIT: %v", err)
+	}
+	executorOpts := []query.ExecutorOption{
+		query.WithTranslator(query.NewTranslator(translatorOpts...)),
+		query.WithOrderingCheck(orderingCheck),
+		query.WithDropProtection(dropProtection),
+		query.WithMaxBindings(maxBindings()),
+		query.WithStatementMemoryLimit(statementMemoryLimit),
+	}
+	if readOnly() {
+		executorOpts = append(executorOpts, query.WithReadOnly())
+	}
+	if backend := cortexBackend(); backend != nil {
+		executorOpts = append(executorOpts, query.WithCortexBackend(backend))
+	}
+	if sink := notificationSink(); sink != nil {
+		executorOpts = append(executorOpts, query.WithNotificationSink(sink))
+	}
+	if os.Getenv("COMPAT_REPORT") != "" {
+		executorOpts = append(executorOpts, query.WithCompatReport(query.NewCompatReport()))
+	}
+	executor := query.NewExecutor(connMgr, repo, executorOpts...)
+
+	// Initialize stage manager for COPY INTO support
+	stageDir := os.Getenv("STAGE_DIR")
+	if stageDir == "" {
+		stageDir = "./stages"
+	}
+	stageMgr := stage.NewManager(repo, stageDir)
+
+	// Initialize processors and wire to executor.
+	// Due to circular dependency (processors need executor, executor needs processors),
+	// we create processors first, then configure executor with them.
+	copyProcessor := query.NewCopyProcessor(stageMgr, repo, executor, query.WithCopyWorkers(copyWorkers()))
+	mergeProcessor := query.NewMergeProcessor(executor)
+	executor.Configure(
+		query.WithCopyProcessor(copyProcessor),
+		query.WithMergeProcessor(mergeProcessor),
+		query.WithStageManager(stageMgr),
+	)
+	return executor
+}
+
+// cortexBackend returns the backend of the Cortex LLM functions: an
+// OpenAI-compatible endpoint at CORTEX_URL, or the stub with the canned
+// responses of CORTEX_RESPONSES. It returns nil for the plain stub.
+func cortexBackend() cortex.Backend {
+	if url := os.Getenv("CORTEX_URL"); url != "" {
+		return cortex.NewOpenAIBackend(url,
+			cortex.WithAPIKey(os.Getenv("CORTEX_API_KEY")),
+			cortex.WithModel(os.Getenv("CORTEX_MODEL")),
+		)
+	}
+	if path := os.Getenv("CORTEX_RESPONSES"); path != "" {
+		backend, err := cortex.LoadStubBackend(path)
+		if err != nil {
+			log.Printf("Ignoring CORTEX_RESPONSES: %v", err)
+			return nil
+		}
+		return backend
+	}
+	return nil
+}
+
+// notificationSink returns the sink of the emails sent with SYSTEM$SEND_EMAIL:
+// a webhook at NOTIFICATION_WEBHOOK_URL, or an SMTP server at
+// NOTIFICATION_SMTP_ADDR. It returns nil when emails are only captured.
+func notificationSink() notification.Sink {
+	if url := os.Getenv("NOTIFICATION_WEBHOOK_URL"); url != "" {
+		return notification.NewWebhookSink(url)
+	}
+	if addr := os.Getenv("NOTIFICATION_SMTP_ADDR"); addr != "" {
+		from := os.Getenv("NOTIFICATION_SMTP_FROM")
+		if from == "" {
+			from = "snowflake-emulator@localhost"
+		}
+		return notification.NewSMTPSink(addr, from)
+	}
+	return nil
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+)
+
+// runSeed runs the SQL scripts named by paths into the emulator's database and
+// returns the process exit code. Directories contribute their *.sql files in
+// name order. Pre-seeded images run it at build time, so that containers start
+// with their data already loaded.
+func runSeed(paths []string) int {
+	if len(paths) == 0 {
+		log.Print("Usage: snowflake-emulator seed <file.sql | directory>...")
+		return 2
+	}
+	if databasePath() == ":memory:" && os.Getenv("DUCKDB_ATTACH") == "" {
+		log.Print("Seeding requires DB_PATH or DUCKDB_ATTACH to name a persistent database")
+		return 2
+	}
+
+	files, err := seedFiles(paths)
+	if err != nil {
+		log.Printf("Failed to list seed scripts: %v", err)
+		return 1
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		log.Printf("Failed to open database: %v", err)
+		return 1
+	}
+	// Closing checkpoints the database, so the seeded file needs no WAL replay
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+	}()
+
+	connMgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		log.Printf("Failed to create repository: %v", err)
+		return 1
+	}
+	executor := newExecutor(connMgr, repo)
+
+	ctx := context.Background()
+	for _, file := range files {
+		if err := seedScript(ctx, executor, file); err != nil {
+			log.Printf("Seeding failed: %v", err)
+			return 1
+		}
+	}
+	log.Printf("Seeded %d scripts", len(files))
+	return 0
+}
+
+// seedFiles expands paths to the SQL scripts to run, in order.
+func seedFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// seedScript runs the statements of one SQL script.
+func seedScript(ctx context.Context, executor *query.Executor, file string) error {
+	script, err := os.ReadFile(file) //nolint:gosec // G304: seed scripts are named by the image builder
+	if err != nil {
+		return err
+	}
+	for _, statement := range query.SplitStatements(string(script)) {
+		if query.ClassifySQL(statement).IsQuery {
+			_, err = executor.Query(ctx, statement)
+		} else {
+			_, err = executor.Execute(ctx, statement)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %q: %w", file, strings.TrimSpace(statement), err)
+		}
+	}
+	log.Printf("Ran %s", file)
+	return nil
+}
+
+// runHealthcheck checks the /health endpoint of the server on PORT and returns
+// the process exit code, for images without a shell or curl.
+func runHealthcheck() int {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://127.0.0.1:" + port + "/health")
+	if err != nil {
+		log.Printf("Health check failed: %v", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Health check failed: %s", resp.Status)
+		return 1
+	}
+	return 0
+}
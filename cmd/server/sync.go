@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	_ "github.com/snowflakedb/gosnowflake" // registers the snowflake driver
+)
+
+// runSync replicates databases of the Snowflake account named by SNOWFLAKE_DSN
+// into the emulator's database and returns the process exit code. The DSN
+// holds the credentials, so that they stay out of the process's arguments.
+func runSync(args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	sample := flags.Int("sample", 0, "copy up to this many rows of each table")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	dsn := os.Getenv("SNOWFLAKE_DSN")
+	if flags.NArg() == 0 || dsn == "" {
+		log.Print("Usage: SNOWFLAKE_DSN=user:password@account/?warehouse=wh snowflake-emulator sync [-sample rows] <database>...")
+		return 2
+	}
+	if databasePath() == ":memory:" && os.Getenv("DUCKDB_ATTACH") == "" {
+		log.Print("Syncing requires DB_PATH or DUCKDB_ATTACH to name a persistent database")
+		return 2
+	}
+
+	remote, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		log.Printf("Failed to connect to Snowflake: %v", err)
+		return 1
+	}
+	defer func() { _ = remote.Close() }()
+
+	db, err := openDatabase()
+	if err != nil {
+		log.Printf("Failed to open database: %v", err)
+		return 1
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+	}()
+
+	connMgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		log.Printf("Failed to create repository: %v", err)
+		return 1
+	}
+	executor := newExecutor(connMgr, repo)
+
+	ctx := context.Background()
+	source := query.NewSnowflakeSource(remote)
+	for _, database := range flags.Args() {
+		result, err := executor.ImportDatabase(ctx, source, database, *sample)
+		if err != nil {
+			log.Printf("Syncing %s failed: %v", database, err)
+			return 1
+		}
+		for _, skipped := range result.Skipped {
+			log.Printf("Skipped %s", skipped)
+		}
+		for _, table := range result.Tables {
+			log.Printf("Copied %d rows of %s.%s.%s", table.Rows, result.Database, table.Schema, table.Name)
+		}
+		log.Printf("Synced %s: %d statements applied, %d skipped", result.Database, result.Statements, len(result.Skipped))
+	}
+	return 0
+}
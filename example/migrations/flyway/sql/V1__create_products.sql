@@ -0,0 +1,5 @@
+CREATE TABLE products (
+    id INTEGER,
+    name VARCHAR(100),
+    price NUMBER(10, 2)
+);
@@ -0,0 +1 @@
+INSERT INTO products VALUES (1, 'Widget', 9.99), (2, 'Gadget', 24.50);
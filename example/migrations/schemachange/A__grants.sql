@@ -0,0 +1,3 @@
+-- Always scripts run on every deploy
+CREATE TABLE IF NOT EXISTS deploy_log (deployed_at TIMESTAMP_NTZ);
+INSERT INTO deploy_log VALUES (CURRENT_TIMESTAMP());
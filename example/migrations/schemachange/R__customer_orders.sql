@@ -0,0 +1,5 @@
+CREATE OR REPLACE VIEW customer_orders AS
+SELECT c.name, COUNT(o.id) AS order_count, SUM(o.amount) AS total
+FROM customers c
+LEFT JOIN orders o ON o.customer_id = c.id
+GROUP BY c.name;
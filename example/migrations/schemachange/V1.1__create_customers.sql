@@ -0,0 +1,7 @@
+CREATE TABLE customers (
+    id INTEGER,
+    name VARCHAR(100),
+    created_at TIMESTAMP_NTZ DEFAULT CURRENT_TIMESTAMP()
+);
+
+INSERT INTO customers (id, name) VALUES (1, 'Alice'), (2, 'Bob');
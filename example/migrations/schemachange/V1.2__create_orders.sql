@@ -0,0 +1,15 @@
+CREATE TABLE orders (
+    id INTEGER,
+    customer_id INTEGER,
+    amount NUMBER(10, 2)
+);
+
+-- Seed one order per customer with an anonymous block
+EXECUTE IMMEDIATE $$
+DECLARE
+    next_id INTEGER DEFAULT 100;
+BEGIN
+    INSERT INTO orders SELECT :next_id + id, id, 10.50 FROM customers;
+    RETURN 'seeded';
+END;
+$$;
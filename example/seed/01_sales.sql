@@ -0,0 +1,29 @@
+-- Sample seed script for pre-seeded images (docker build --target seeded).
+-- Scripts in the seed directory run in name order into DB_PATH at build time.
+CREATE DATABASE IF NOT EXISTS SALES;
+
+CREATE SCHEMA IF NOT EXISTS SALES.RAW;
+
+CREATE TABLE IF NOT EXISTS SALES.RAW.CUSTOMERS (
+    ID INTEGER,
+    NAME VARCHAR,
+    REGION VARCHAR
+);
+
+CREATE TABLE IF NOT EXISTS SALES.RAW.ORDERS (
+    ID INTEGER,
+    CUSTOMER_ID INTEGER,
+    AMOUNT NUMBER(10, 2),
+    ORDERED_AT TIMESTAMP_NTZ
+);
+
+INSERT INTO SALES.RAW.CUSTOMERS VALUES
+    (1, 'Alice', 'EMEA'),
+    (2, 'Bob', 'AMER'),
+    (3, 'Carol', 'APAC');
+
+INSERT INTO SALES.RAW.ORDERS VALUES
+    (1, 1, 120.50, '2024-01-05 10:00:00'),
+    (2, 1, 35.00, '2024-01-09 14:30:00'),
+    (3, 2, 980.25, '2024-02-01 09:15:00'),
+    (4, 3, 12.75, '2024-02-11 17:45:00');
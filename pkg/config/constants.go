@@ -1,10 +1,13 @@
 // Package config provides configuration constants for the Snowflake emulator.
 package config
 
-// Default database and schema settings.
+// Default database, schema, and role settings.
 const (
 	DefaultDatabase = "TEST_DB"
 	DefaultSchema   = "PUBLIC"
+	// DefaultRole is the session role when the client does not request one.
+	// It owns every object the session creates.
+	DefaultRole = "SYSADMIN"
 )
 
 // StatementTypeID represents Snowflake statement type identifiers.
@@ -28,8 +31,15 @@ const (
 const (
 	DefaultTimezone               = "UTC"
 	DefaultTimestampOutputFormat  = "YYYY-MM-DD HH24:MI:SS"
+	DefaultDateOutputFormat       = "YYYY-MM-DD"
+	DefaultTimeOutputFormat       = "HH24:MI:SS"
 	DefaultClientSessionKeepAlive = "false"
 	DefaultQueryTag               = ""
+	DefaultWeekStart              = "0"
+	DefaultWeekOfYearPolicy       = "0"
+	DefaultLockTimeout            = "43200"
+	DefaultAutocommit             = "true"
+	DefaultAbortDetachedQuery     = "false"
 )
 
 // SessionParameter represents a session parameter name.
@@ -37,11 +47,19 @@ type SessionParameter string
 
 // Session parameter names.
 const (
-	ParamTimezone               SessionParameter = "TIMEZONE"
-	ParamTimestampOutputFormat  SessionParameter = "TIMESTAMP_OUTPUT_FORMAT"
-	ParamClientSessionKeepAlive SessionParameter = "CLIENT_SESSION_KEEP_ALIVE"
-	ParamQueryTag               SessionParameter = "QUERY_TAG"
-	ParamGoQueryResultFormat    SessionParameter = "GO_QUERY_RESULT_FORMAT"
+	ParamTimezone                 SessionParameter = "TIMEZONE"
+	ParamTimestampOutputFormat    SessionParameter = "TIMESTAMP_OUTPUT_FORMAT"
+	ParamTimestampNTZOutputFormat SessionParameter = "TIMESTAMP_NTZ_OUTPUT_FORMAT"
+	ParamDateOutputFormat         SessionParameter = "DATE_OUTPUT_FORMAT"
+	ParamTimeOutputFormat         SessionParameter = "TIME_OUTPUT_FORMAT"
+	ParamClientSessionKeepAlive   SessionParameter = "CLIENT_SESSION_KEEP_ALIVE"
+	ParamQueryTag                 SessionParameter = "QUERY_TAG"
+	ParamGoQueryResultFormat      SessionParameter = "GO_QUERY_RESULT_FORMAT"
+	ParamWeekStart                SessionParameter = "WEEK_START"
+	ParamWeekOfYearPolicy         SessionParameter = "WEEK_OF_YEAR_POLICY"
+	ParamLockTimeout              SessionParameter = "LOCK_TIMEOUT"
+	ParamAutocommit               SessionParameter = "AUTOCOMMIT"
+	ParamAbortDetachedQuery       SessionParameter = "ABORT_DETACHED_QUERY"
 )
 
 // DefaultSessionParameters returns the default session parameters.
@@ -49,8 +67,15 @@ func DefaultSessionParameters() map[SessionParameter]string {
 	return map[SessionParameter]string{
 		ParamTimezone:               DefaultTimezone,
 		ParamTimestampOutputFormat:  DefaultTimestampOutputFormat,
+		ParamDateOutputFormat:       DefaultDateOutputFormat,
+		ParamTimeOutputFormat:       DefaultTimeOutputFormat,
 		ParamClientSessionKeepAlive: DefaultClientSessionKeepAlive,
 		ParamQueryTag:               DefaultQueryTag,
 		ParamGoQueryResultFormat:    QueryResultFormatJSON,
+		ParamWeekStart:              DefaultWeekStart,
+		ParamWeekOfYearPolicy:       DefaultWeekOfYearPolicy,
+		ParamLockTimeout:            DefaultLockTimeout,
+		ParamAutocommit:             DefaultAutocommit,
+		ParamAbortDetachedQuery:     DefaultAbortDetachedQuery,
 	}
 }
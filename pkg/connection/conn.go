@@ -0,0 +1,57 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+)
+
+// connKey is the context key for a pinned connection.
+type connKey struct{}
+
+// ContextWithConn returns a copy of ctx whose queries and writes run on conn
+// instead of a pooled connection, such as the connection of a session's open
+// transaction.
+func ContextWithConn(ctx context.Context, conn *sql.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// connFromContext returns the pinned connection carried by ctx, if any.
+func connFromContext(ctx context.Context) (*sql.Conn, bool) {
+	conn, ok := ctx.Value(connKey{}).(*sql.Conn)
+	return conn, ok && conn != nil
+}
+
+// querier runs statements on a pooled or pinned connection.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// querier returns the connection pinned by ctx, or the pool.
+func (m *Manager) querier(ctx context.Context) querier {
+	if conn, ok := connFromContext(ctx); ok {
+		return conn
+	}
+	return m.db
+}
+
+// Conn returns a dedicated connection from the pool, e.g. to hold a
+// transaction open across statements. The caller must close it.
+func (m *Manager) Conn(ctx context.Context) (*sql.Conn, error) {
+	return m.db.Conn(ctx)
+}
+
+// Raw runs fn with the driver connection pinned by ctx, or with a pooled
+// connection, e.g. to use DuckDB's Arrow interface.
+func (m *Manager) Raw(ctx context.Context, fn func(driverConn any) error) error {
+	if conn, ok := connFromContext(ctx); ok {
+		return conn.Raw(fn)
+	}
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.Raw(fn)
+}
@@ -0,0 +1,44 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LockTimeoutError is returned when a write keeps conflicting with a concurrent
+// transaction until its lock wait runs out. It matches ErrTransactionConflict.
+type LockTimeoutError struct {
+	// Waited is how long the statement waited for the conflicting transaction.
+	Waited time.Duration
+	// Attempts is the number of times the statement was executed.
+	Attempts int
+	// Err is the last conflict error reported by DuckDB.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("statement timed out after waiting %s for lock (%d attempts): %v", e.Waited.Round(time.Millisecond), e.Attempts, e.Err)
+}
+
+// Unwrap returns ErrTransactionConflict and the underlying DuckDB error.
+func (e *LockTimeoutError) Unwrap() []error {
+	return []error{ErrTransactionConflict, e.Err}
+}
+
+// lockTimeoutKey is the context key for the lock wait timeout.
+type lockTimeoutKey struct{}
+
+// ContextWithLockTimeout returns a copy of ctx that limits how long writes wait
+// for conflicting transactions, like Snowflake's LOCK_TIMEOUT parameter.
+// A timeout of 0 fails on the first conflict. Without it, the Manager's retry policy applies.
+func ContextWithLockTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, lockTimeoutKey{}, timeout)
+}
+
+// lockTimeoutFromContext returns the lock wait timeout carried by ctx, if any.
+func lockTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(lockTimeoutKey{}).(time.Duration)
+	return timeout, ok
+}
@@ -4,62 +4,171 @@ package connection
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// Default retry settings for write operations that hit a DuckDB transaction conflict.
+const (
+	DefaultMaxAttempts  = 5
+	DefaultRetryBackoff = 10 * time.Millisecond
+	maxRetryBackoff     = time.Second
 )
 
+// ErrTransactionConflict is matched by errors returned when a write still conflicts
+// with a concurrent transaction after all retry attempts. Callers can report it as a
+// lock timeout; see LockTimeoutError for details.
+var ErrTransactionConflict = errors.New("transaction conflict")
+
 // Manager manages DuckDB connections with proper locking.
 //
 // The Manager ensures thread-safe access to the DuckDB database:
 //   - Query operations can be concurrent (reads)
 //   - Exec operations are serialized using a mutex (writes)
 //   - Transactions are also serialized to maintain consistency
+//   - DDL goes through Exec/ExecTx, so catalog changes never run concurrently
+//   - Writes that conflict with an open transaction are retried with exponential backoff
 type Manager struct {
 	db      *sql.DB
 	writeMu sync.Mutex
+
+	maxAttempts  int
+	retryBackoff time.Duration
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithRetryPolicy sets how many times a conflicting write is attempted and the
+// initial backoff between attempts. The backoff doubles after each attempt.
+// A maxAttempts of 1 disables retries.
+func WithRetryPolicy(maxAttempts int, backoff time.Duration) ManagerOption {
+	return func(m *Manager) {
+		if maxAttempts > 0 {
+			m.maxAttempts = maxAttempts
+		}
+		if backoff >= 0 {
+			m.retryBackoff = backoff
+		}
+	}
 }
 
 // NewManager creates a new connection manager for the given database.
-func NewManager(db *sql.DB) *Manager {
-	return &Manager{db: db}
+func NewManager(db *sql.DB, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		db:           db,
+		maxAttempts:  DefaultMaxAttempts,
+		retryBackoff: DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Query executes a read query (can be concurrent).
 // Multiple goroutines can call Query simultaneously.
+// It runs on the connection pinned by ctx, if any.
 func (m *Manager) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return m.db.QueryContext(ctx, query, args...)
+	return m.querier(ctx).QueryContext(ctx, query, args...)
 }
 
 // QueryRow executes a query that is expected to return at most one row.
 func (m *Manager) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
-	return m.db.QueryRowContext(ctx, query, args...)
+	return m.querier(ctx).QueryRowContext(ctx, query, args...)
 }
 
 // Exec executes a write operation (serialized).
 // Write operations are serialized using a mutex to prevent conflicts.
+// Statements that conflict with another open transaction are retried.
+// It runs on the connection pinned by ctx, if any.
 func (m *Manager) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	m.writeMu.Lock()
-	defer m.writeMu.Unlock()
-	return m.db.ExecContext(ctx, query, args...)
+	var result sql.Result
+	err := m.withRetry(ctx, func() error {
+		var err error
+		result, err = m.querier(ctx).ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
 }
 
 // ExecTx executes multiple statements in a transaction.
 // The transaction is serialized using the same write mutex.
 // If the provided function returns an error, the transaction is rolled back.
+// If the transaction fails with a conflict, fn is run again in a new transaction.
+// It always runs on a pooled connection, since a pinned connection may already
+// be in a transaction.
 func (m *Manager) ExecTx(ctx context.Context, fn func(*sql.Tx) error) error {
-	m.writeMu.Lock()
-	defer m.writeMu.Unlock()
+	return m.withRetry(ctx, func() error {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
 
-	tx, err := m.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
 
-	if err := fn(tx); err != nil {
-		_ = tx.Rollback()
-		return err
+		return tx.Commit()
+	})
+}
+
+// withRetry runs op under the write mutex, retrying transaction conflicts with
+// exponential backoff. The mutex is released while waiting so that the
+// conflicting transaction can finish. Retries stop after the Manager's maximum
+// attempts, or once the lock timeout carried by ctx has elapsed.
+func (m *Manager) withRetry(ctx context.Context, op func() error) error {
+	start := time.Now()
+	timeout, hasTimeout := lockTimeoutFromContext(ctx)
+	backoff := m.retryBackoff
+
+	for attempt := 1; ; attempt++ {
+		m.writeMu.Lock()
+		err := op()
+		m.writeMu.Unlock()
+
+		if err == nil || !IsConflictError(err) {
+			return err
+		}
+
+		wait := backoff
+		if hasTimeout {
+			remaining := timeout - time.Since(start)
+			if remaining <= 0 {
+				return &LockTimeoutError{Waited: time.Since(start), Attempts: attempt, Err: err}
+			}
+			wait = min(wait, remaining)
+		} else if attempt >= m.maxAttempts {
+			return &LockTimeoutError{Waited: time.Since(start), Attempts: attempt, Err: err}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &LockTimeoutError{Waited: time.Since(start), Attempts: attempt, Err: err}
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
 	}
+}
 
-	return tx.Commit()
+// IsConflictError reports whether err is a DuckDB write-write conflict between
+// concurrent transactions, which can succeed when retried.
+func IsConflictError(err error) bool {
+	if errors.Is(err, ErrTransactionConflict) {
+		return true
+	}
+	var duckErr *duckdb.Error
+	if !errors.As(err, &duckErr) || duckErr.Type != duckdb.ErrorTypeTransaction {
+		return false
+	}
+	return strings.Contains(strings.ToLower(duckErr.Msg), "conflict")
 }
 
 // DB returns the underlying database connection.
@@ -3,10 +3,12 @@ package connection
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
-	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/duckdb/duckdb-go/v2"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -250,3 +252,173 @@ func TestManager_ExecContext(t *testing.T) {
 		t.Error("expected error with canceled context, got nil")
 	}
 }
+
+// holdConflictingUpdate opens a transaction on a dedicated connection that updates
+// the row with id 1 in table t and leaves it uncommitted.
+func holdConflictingUpdate(t *testing.T, db *sql.DB) *sql.Conn {
+	t.Helper()
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	if _, err := conn.ExecContext(context.Background(), "BEGIN TRANSACTION"); err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	if _, err := conn.ExecContext(context.Background(), "UPDATE t SET v = 2 WHERE id = 1"); err != nil {
+		t.Fatalf("failed to update row: %v", err)
+	}
+	return conn
+}
+
+// TestManager_Exec_ConflictRetry tests that conflicting writes are retried and
+// reported as ErrTransactionConflict once retries are exhausted.
+func TestManager_Exec_ConflictRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		commitHeld  bool
+		wantErr     bool
+	}{
+		{name: "ConflictPersists", maxAttempts: 3, commitHeld: false, wantErr: true},
+		{name: "ConflictResolved", maxAttempts: 20, commitHeld: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDuckDB(t)
+			mgr := NewManager(db, WithRetryPolicy(tt.maxAttempts, 5*time.Millisecond))
+
+			if _, err := db.Exec("CREATE TABLE t (id INTEGER, v INTEGER)"); err != nil {
+				t.Fatalf("failed to create table: %v", err)
+			}
+			if _, err := db.Exec("INSERT INTO t VALUES (1, 1)"); err != nil {
+				t.Fatalf("failed to insert data: %v", err)
+			}
+
+			held := holdConflictingUpdate(t, db)
+			if tt.commitHeld {
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					_, _ = held.ExecContext(context.Background(), "COMMIT")
+				}()
+			}
+
+			_, err := mgr.Exec(context.Background(), "UPDATE t SET v = 3 WHERE id = 1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrTransactionConflict) {
+				t.Errorf("Exec() error = %v, want ErrTransactionConflict", err)
+			}
+			if !tt.wantErr {
+				var v int
+				if err := db.QueryRow("SELECT v FROM t WHERE id = 1").Scan(&v); err != nil {
+					t.Fatalf("failed to query row: %v", err)
+				}
+				if v != 3 {
+					t.Errorf("v = %d, want 3", v)
+				}
+			}
+		})
+	}
+}
+
+// TestManager_ExecTx_ConflictRetry tests that a conflicting transaction is not retried
+// when retries are disabled.
+func TestManager_ExecTx_ConflictRetry(t *testing.T) {
+	db := setupTestDuckDB(t)
+	mgr := NewManager(db, WithRetryPolicy(1, 0))
+
+	if _, err := db.Exec("CREATE TABLE t (id INTEGER, v INTEGER)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO t VALUES (1, 1)"); err != nil {
+		t.Fatalf("failed to insert data: %v", err)
+	}
+	holdConflictingUpdate(t, db)
+
+	calls := 0
+	err := mgr.ExecTx(context.Background(), func(tx *sql.Tx) error {
+		calls++
+		_, err := tx.Exec("UPDATE t SET v = 3 WHERE id = 1")
+		return err
+	})
+	if !errors.Is(err, ErrTransactionConflict) {
+		t.Errorf("ExecTx() error = %v, want ErrTransactionConflict", err)
+	}
+	if calls != 1 {
+		t.Errorf("ExecTx() ran fn %d times, want 1", calls)
+	}
+}
+
+// TestIsConflictError tests conflict error classification.
+func TestIsConflictError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Nil", err: nil, want: false},
+		{name: "Plain", err: errors.New("Conflict on update!"), want: false},
+		{name: "Sentinel", err: fmt.Errorf("wrapped: %w", ErrTransactionConflict), want: true},
+		{name: "DuckDBConflict", err: &duckdb.Error{Type: duckdb.ErrorTypeTransaction, Msg: "TransactionContext Error: Conflict on update!"}, want: true},
+		{name: "DuckDBAborted", err: &duckdb.Error{Type: duckdb.ErrorTypeTransaction, Msg: "TransactionContext Error: Current transaction is aborted"}, want: false},
+		{name: "DuckDBCatalog", err: &duckdb.Error{Type: duckdb.ErrorTypeCatalog, Msg: "Catalog Error: conflict"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsConflictError(tt.err); got != tt.want {
+				t.Errorf("IsConflictError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestManager_Exec_LockTimeout tests that the lock timeout carried by the context
+// bounds how long a conflicting write waits.
+func TestManager_Exec_LockTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantAttempts int
+	}{
+		{name: "NoWait", timeout: 0, wantAttempts: 1},
+		{name: "Wait", timeout: 50 * time.Millisecond, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDuckDB(t)
+			mgr := NewManager(db, WithRetryPolicy(1, 5*time.Millisecond))
+
+			if _, err := db.Exec("CREATE TABLE t (id INTEGER, v INTEGER)"); err != nil {
+				t.Fatalf("failed to create table: %v", err)
+			}
+			if _, err := db.Exec("INSERT INTO t VALUES (1, 1)"); err != nil {
+				t.Fatalf("failed to insert data: %v", err)
+			}
+			holdConflictingUpdate(t, db)
+
+			ctx := ContextWithLockTimeout(context.Background(), tt.timeout)
+			_, err := mgr.Exec(ctx, "UPDATE t SET v = 3 WHERE id = 1")
+
+			var lockErr *LockTimeoutError
+			if !errors.As(err, &lockErr) {
+				t.Fatalf("Exec() error = %v, want LockTimeoutError", err)
+			}
+			if !errors.Is(err, ErrTransactionConflict) {
+				t.Errorf("Exec() error = %v, want ErrTransactionConflict", err)
+			}
+			if lockErr.Attempts < tt.wantAttempts {
+				t.Errorf("Attempts = %d, want at least %d", lockErr.Attempts, tt.wantAttempts)
+			}
+			if lockErr.Waited < tt.timeout {
+				t.Errorf("Waited = %s, want at least %s", lockErr.Waited, tt.timeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// attachedCatalog is the catalog name of a database attached with WithAttach.
+const attachedCatalog = "emulator_state"
+
+// motherDuckPrefix starts the paths of MotherDuck databases.
+const motherDuckPrefix = "md:"
+
+// openConfig holds the settings applied by OpenOptions.
+type openConfig struct {
+	initSQL  []string
+	attach   string
+	readOnly bool
+	// settings are DuckDB configuration options passed in the database path.
+	settings [][2]string
+}
+
+// OpenOption configures how Open sets up each DuckDB connection.
+type OpenOption func(*openConfig)
+
+// WithInitSQL runs statements on every new connection before anything else,
+// e.g. to load extensions or create the secrets a remote target needs. The
+// statements run once per connection, so instance-wide ones such as CREATE
+// SECRET should be written to tolerate running again.
+func WithInitSQL(statements ...string) OpenOption {
+	return func(c *openConfig) {
+		for _, statement := range statements {
+			if strings.TrimSpace(statement) != "" {
+				c.initSQL = append(c.initSQL, statement)
+			}
+		}
+	}
+}
+
+// WithAttach attaches target, any path or URL DuckDB's ATTACH accepts, and
+// makes it the default catalog of every connection, so that the emulator keeps
+// its metadata and data there instead of in the opened database. Replicas
+// attaching the same MotherDuck database share one dataset while handling the
+// Snowflake protocol locally. Unless opened with WithReadOnly, the target must
+// be writable, since the metadata store and query history are written on
+// startup and per query.
+func WithAttach(target string) OpenOption {
+	return func(c *openConfig) {
+		c.attach = target
+	}
+}
+
+// WithReadOnly opens the database, or the target of WithAttach, read-only, so
+// that several emulator processes can serve reads from one seeded DuckDB file.
+// DuckDB lets a file be opened by one writing process or by any number of
+// read-only ones, not both at once. MotherDuck databases are not supported.
+func WithReadOnly() OpenOption {
+	return func(c *openConfig) {
+		c.readOnly = true
+	}
+}
+
+// WithDatabaseMemoryLimit sets DuckDB's memory_limit, e.g. "2GB", so that a
+// statement needing more memory than the database has left fails with an
+// out-of-memory error instead of the process being killed. Operators that can
+// spill to disk, such as sorts and joins, do so before failing. The limit is
+// database-wide, not per statement: DuckDB has no per-statement limit, so
+// statements running at once share it.
+func WithDatabaseMemoryLimit(limit string) OpenOption {
+	return func(c *openConfig) {
+		c.settings = append(c.settings, [2]string{"memory_limit", limit})
+	}
+}
+
+// WithTempDirectoryLimit sets DuckDB's max_temp_directory_size, e.g. "10GB",
+// bounding the disk space statements may spill to before failing.
+func WithTempDirectoryLimit(limit string) OpenOption {
+	return func(c *openConfig) {
+		c.settings = append(c.settings, [2]string{"max_temp_directory_size", limit})
+	}
+}
+
+// WithTempDirectory sets DuckDB's temp_directory, where statements spill. By
+// default DuckDB spills to .tmp in the working directory, or, for a database
+// file, next to it.
+func WithTempDirectory(dir string) OpenOption {
+	return func(c *openConfig) {
+		c.settings = append(c.settings, [2]string{"temp_directory", dir})
+	}
+}
+
+// Open opens the DuckDB database at path, which DuckDB resolves, so besides a
+// local file or ":memory:" it may name a MotherDuck database as md:name, with
+// the token taken from motherduck_token or the MOTHERDUCK_TOKEN environment
+// variable.
+func Open(path string, opts ...OpenOption) (*sql.DB, error) {
+	var cfg openConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	statements := cfg.initSQL
+	if cfg.readOnly {
+		target := path
+		if cfg.attach != "" {
+			target = cfg.attach
+		}
+		if strings.HasPrefix(target, motherDuckPrefix) {
+			return nil, fmt.Errorf("read-only mode is not supported for MotherDuck database %s", target)
+		}
+		if cfg.attach == "" {
+			path = withSetting(path, "access_mode", "read_only")
+		}
+	}
+	for _, setting := range cfg.settings {
+		path = withSetting(path, setting[0], setting[1])
+	}
+	if cfg.attach != "" {
+		statements = append(statements, attachStatements(cfg.attach, cfg.readOnly)...)
+	}
+	if len(statements) == 0 {
+		return sql.Open("duckdb", path)
+	}
+
+	connector, err := duckdb.NewConnector(path, func(execer driver.ExecerContext) error {
+		for _, statement := range statements {
+			if _, err := execer.ExecContext(context.Background(), statement, nil); err != nil {
+				return fmt.Errorf("connection setup %q failed: %w", statement, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// withSetting returns path with the DuckDB configuration option name set to value.
+func withSetting(path, name, value string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + name + "=" + url.QueryEscape(value)
+}
+
+// attachStatements returns the statements that attach target, read-only if
+// readOnly is set, and make it the default catalog. The attachment is shared
+// by all connections of a database, so only the first connection attaches it.
+// MotherDuck names its attached databases itself.
+func attachStatements(target string, readOnly bool) []string {
+	literal := "'" + strings.ReplaceAll(target, "'", "''") + "'"
+	if name, ok := strings.CutPrefix(target, motherDuckPrefix); ok {
+		name, _, _ = strings.Cut(name, "?")
+		return []string{
+			"ATTACH IF NOT EXISTS " + literal,
+			`USE "` + strings.ReplaceAll(name, `"`, `""`) + `"`,
+		}
+	}
+	attach := "ATTACH IF NOT EXISTS " + literal + " AS " + attachedCatalog
+	if readOnly {
+		attach += " (READ_ONLY)"
+	}
+	return []string{attach, "USE " + attachedCatalog}
+}
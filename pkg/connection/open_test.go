@@ -0,0 +1,190 @@
+package connection
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestOpen_Attach tests that replicas attaching the same target share its
+// data, on every pooled connection.
+func TestOpen_Attach(t *testing.T) {
+	ctx := context.Background()
+	target := filepath.Join(t.TempDir(), "shared.duckdb")
+
+	// The first replica seeds the shared database
+	seeder, err := Open(":memory:", WithAttach(target), WithInitSQL("SET threads = 2", " "))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, statement := range []string{
+		"CREATE SCHEMA sales",
+		"CREATE TABLE sales.orders (id INTEGER)",
+		"INSERT INTO sales.orders VALUES (1), (2)",
+	} {
+		if _, err := seeder.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("Exec(%q) error = %v", statement, err)
+		}
+	}
+	if err := seeder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// A later replica sees the data through several connections at once
+	replica, err := Open(":memory:", WithAttach(target))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer replica.Close()
+
+	var conns []*sql.Conn
+	for range 3 {
+		conn, err := replica.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn() error = %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var catalog string
+		var count int
+		if err := conn.QueryRowContext(ctx, "SELECT current_database(), COUNT(*) FROM sales.orders").Scan(&catalog, &count); err != nil {
+			t.Fatalf("connection %d: Query() error = %v", i, err)
+		}
+		if catalog != attachedCatalog || count != 2 {
+			t.Errorf("connection %d: catalog, count = %q, %d, want %q, 2", i, catalog, count, attachedCatalog)
+		}
+	}
+}
+
+// TestOpen_InitSQLError tests that connections fail when setup fails.
+func TestOpen_InitSQLError(t *testing.T) {
+	db, err := Open(":memory:", WithInitSQL("SELECT * FROM missing_table"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.PingContext(context.Background()); err == nil {
+		t.Error("Ping() succeeded despite failing setup")
+	}
+}
+
+// TestOpen_ReadOnly tests that read-only replicas of a seeded file read its
+// data and reject writes.
+func TestOpen_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seed.duckdb")
+
+	seeder, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := seeder.ExecContext(ctx, "CREATE TABLE orders AS SELECT 1 AS id"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if err := seeder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, opts := range [][]OpenOption{
+		{WithReadOnly()},
+		{WithReadOnly(), WithAttach(path)},
+	} {
+		target := path
+		if len(opts) > 1 {
+			target = ":memory:"
+		}
+		db, err := Open(target, opts...)
+		if err != nil {
+			t.Fatalf("Open(%q) error = %v", target, err)
+		}
+		var count int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders").Scan(&count); err != nil || count != 1 {
+			t.Errorf("Open(%q): count = %d, err = %v, want 1", target, count, err)
+		}
+		if _, err := db.ExecContext(ctx, "INSERT INTO orders VALUES (2)"); err == nil {
+			t.Errorf("Open(%q): INSERT succeeded on a read-only database", target)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	if _, err := Open("md:shared", WithReadOnly()); err == nil {
+		t.Error("Open() of a read-only MotherDuck database succeeded, want error")
+	}
+}
+
+// TestOpen_DatabaseMemoryLimit tests that a statement exceeding the memory
+// limit fails without leaving the database unusable.
+func TestOpen_DatabaseMemoryLimit(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	db, err := Open(":memory:", WithDatabaseMemoryLimit("64MB"), WithTempDirectory(tempDir), WithTempDirectoryLimit("1MB"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	var limit string
+	if err := db.QueryRowContext(ctx, "SELECT current_setting('memory_limit')").Scan(&limit); err != nil {
+		t.Fatalf("current_setting() error = %v", err)
+	}
+	if limit != "61.0 MiB" {
+		t.Errorf("memory_limit = %q, want 61.0 MiB", limit)
+	}
+	var dir string
+	if err := db.QueryRowContext(ctx, "SELECT current_setting('temp_directory')").Scan(&dir); err != nil {
+		t.Fatalf("current_setting() error = %v", err)
+	}
+	if dir != tempDir {
+		t.Errorf("temp_directory = %q, want %q", dir, tempDir)
+	}
+
+	_, err = db.ExecContext(ctx, "SELECT length(string_agg(repeat('x', 1000), ',')) FROM range(1000000)")
+	if !IsOutOfMemoryError(err) {
+		t.Errorf("Exec() error = %v, want an out-of-memory error", err)
+	}
+	var one int
+	if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil || one != 1 {
+		t.Errorf("SELECT 1 after the failure = %d, %v", one, err)
+	}
+}
+
+func TestAttachStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		readOnly bool
+		want     []string
+	}{
+		{
+			name:   "File",
+			target: "/data/it's.duckdb",
+			want:   []string{"ATTACH IF NOT EXISTS '/data/it''s.duckdb' AS emulator_state", "USE emulator_state"},
+		},
+		{
+			name:     "ReadOnlyFile",
+			target:   "/data/seed.duckdb",
+			readOnly: true,
+			want:     []string{"ATTACH IF NOT EXISTS '/data/seed.duckdb' AS emulator_state (READ_ONLY)", "USE emulator_state"},
+		},
+		{
+			name:   "MotherDuck",
+			target: "md:shared?motherduck_token=abc",
+			want:   []string{"ATTACH IF NOT EXISTS 'md:shared?motherduck_token=abc'", `USE "shared"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, attachStatements(tt.target, tt.readOnly)); diff != "" {
+				t.Errorf("attachStatements() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package connection
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// ErrStatementMemoryLimit is matched by errors of statements stopped for
+// using more memory than a per-statement memory limit allows.
+var ErrStatementMemoryLimit = errors.New("statement memory limit exceeded")
+
+// IsOutOfMemoryError reports whether err is DuckDB failing a statement that
+// needed more memory than the database's memory_limit allows, or a statement
+// stopped by a per-statement memory limit.
+func IsOutOfMemoryError(err error) bool {
+	if errors.Is(err, ErrStatementMemoryLimit) {
+		return true
+	}
+	var duckErr *duckdb.Error
+	return errors.As(err, &duckErr) && duckErr.Type == duckdb.ErrorTypeOutOfMemory && !isDiskFull(duckErr)
+}
+
+// IsDiskFullError reports whether err is DuckDB failing to write, e.g. when
+// spilling a statement's intermediate results to its temporary directory,
+// because the disk is full or the max_temp_directory_size limit was reached.
+func IsDiskFullError(err error) bool {
+	var duckErr *duckdb.Error
+	return errors.As(err, &duckErr) && isDiskFull(duckErr)
+}
+
+// IsResourceError reports whether err is DuckDB running out of memory or disk
+// space. The statement fails, but the database and its connections stay usable.
+func IsResourceError(err error) bool {
+	return IsOutOfMemoryError(err) || IsDiskFullError(err)
+}
+
+// isDiskFull reports whether a DuckDB error was caused by a lack of disk space.
+// DuckDB reports an exhausted temporary directory as running out of memory.
+func isDiskFull(err *duckdb.Error) bool {
+	msg := strings.ToLower(err.Msg)
+	switch err.Type {
+	case duckdb.ErrorTypeOutOfMemory:
+		return strings.Contains(msg, "max_temp_directory_size")
+	case duckdb.ErrorTypeIO:
+		return strings.Contains(msg, "no space left on device") || strings.Contains(msg, "disk quota exceeded")
+	}
+	return false
+}
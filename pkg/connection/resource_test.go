@@ -0,0 +1,53 @@
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/duckdb/duckdb-go/v2"
+)
+
+// TestResourceErrors tests out-of-memory and disk-full error classification.
+func TestResourceErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantMemory   bool
+		wantDiskFull bool
+	}{
+		{name: "Nil", err: nil},
+		{name: "Plain", err: errors.New("Out of Memory Error: failed to allocate data")},
+		{
+			name:       "OutOfMemory",
+			err:        fmt.Errorf("execution error: %w", &duckdb.Error{Type: duckdb.ErrorTypeOutOfMemory, Msg: "Out of Memory Error: failed to allocate data of size 32.0 MiB (32.0 MiB/61.0 MiB used)"}),
+			wantMemory: true,
+		},
+		{
+			name:         "TempDirectoryLimit",
+			err:          &duckdb.Error{Type: duckdb.ErrorTypeOutOfMemory, Msg: "Out of Memory Error: failed to offload data block of size 256.0 KiB (928.0 KiB/976.5 KiB used).\nThis limit was set by the 'max_temp_directory_size' setting."},
+			wantDiskFull: true,
+		},
+		{
+			name:         "NoSpaceLeft",
+			err:          &duckdb.Error{Type: duckdb.ErrorTypeIO, Msg: "IO Error: Could not write file \"/tmp/duckdb_temp_storage-0.tmp\": No space left on device"},
+			wantDiskFull: true,
+		},
+		{name: "StatementMemoryLimit", err: fmt.Errorf("query: %w", ErrStatementMemoryLimit), wantMemory: true},
+		{name: "OtherIO", err: &duckdb.Error{Type: duckdb.ErrorTypeIO, Msg: "IO Error: No files found that match the pattern"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOutOfMemoryError(tt.err); got != tt.wantMemory {
+				t.Errorf("IsOutOfMemoryError() = %v, want %v", got, tt.wantMemory)
+			}
+			if got := IsDiskFullError(tt.err); got != tt.wantDiskFull {
+				t.Errorf("IsDiskFullError() = %v, want %v", got, tt.wantDiskFull)
+			}
+			if got := IsResourceError(tt.err); got != (tt.wantMemory || tt.wantDiskFull) {
+				t.Errorf("IsResourceError() = %v, want %v", got, tt.wantMemory || tt.wantDiskFull)
+			}
+		})
+	}
+}
@@ -9,17 +9,18 @@ import (
 
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
 )
 
 // Repository manages actual table data in DuckDB.
 // It is separate from metadata.Repository which only manages schema metadata.
 type Repository struct {
 	mgr      *connection.Manager
-	metaRepo *metadata.Repository
+	metaRepo metadata.Store
 }
 
 // NewRepository creates a new content data repository.
-func NewRepository(mgr *connection.Manager, metaRepo *metadata.Repository) *Repository {
+func NewRepository(mgr *connection.Manager, metaRepo metadata.Store) *Repository {
 	return &Repository{
 		mgr:      mgr,
 		metaRepo: metaRepo,
@@ -189,55 +190,7 @@ func (r *Repository) resolveTableName(ctx context.Context, databaseName, schemaN
 
 // snowflakeToDuckDBType maps Snowflake data types to DuckDB types.
 func snowflakeToDuckDBType(snowflakeType string) string {
-	// Normalize to uppercase
-	sfType := strings.ToUpper(snowflakeType)
-
-	// Map Snowflake types to DuckDB types
-	mapping := map[string]string{
-		// Numeric types
-		"NUMBER":   "DECIMAL",
-		"INT":      "INTEGER",
-		"INTEGER":  "INTEGER",
-		"BIGINT":   "BIGINT",
-		"SMALLINT": "SMALLINT",
-		"TINYINT":  "TINYINT",
-		"BYTEINT":  "TINYINT",
-		"FLOAT":    "DOUBLE",
-		"FLOAT4":   "FLOAT",
-		"FLOAT8":   "DOUBLE",
-		"DOUBLE":   "DOUBLE",
-		"REAL":     "FLOAT",
-		"DECIMAL":  "DECIMAL",
-		"NUMERIC":  "DECIMAL",
-
-		// String types
-		"VARCHAR":   "VARCHAR",
-		"CHAR":      "VARCHAR",
-		"CHARACTER": "VARCHAR",
-		"STRING":    "VARCHAR",
-		"TEXT":      "VARCHAR",
-		"BINARY":    "BLOB",
-		"VARBINARY": "BLOB",
-
-		// Boolean
-		"BOOLEAN": "BOOLEAN",
-
-		// Date/Time types
-		"DATE":          "DATE",
-		"DATETIME":      "TIMESTAMP",
-		"TIME":          "TIME",
-		"TIMESTAMP":     "TIMESTAMP",
-		"TIMESTAMP_LTZ": "TIMESTAMP WITH TIME ZONE",
-		"TIMESTAMP_NTZ": "TIMESTAMP",
-		"TIMESTAMP_TZ":  "TIMESTAMP WITH TIME ZONE",
-
-		// Semi-structured types (DuckDB doesn't have native JSON, use VARCHAR)
-		"VARIANT": "VARCHAR",
-		"OBJECT":  "VARCHAR",
-		"ARRAY":   "VARCHAR",
-	}
-
-	if duckType, ok := mapping[sfType]; ok {
+	if duckType, ok := sftypes.DuckDBTypeFor(snowflakeType); ok {
 		return duckType
 	}
 
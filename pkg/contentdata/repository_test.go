@@ -278,8 +278,9 @@ func TestSnowflakeToDuckDBType(t *testing.T) {
 		snowflakeType string
 		expectedDuck  string
 	}{
-		{"NUMBER", "DECIMAL"},
-		{"INTEGER", "INTEGER"},
+		{"NUMBER", "DECIMAL(38,0)"},
+		{"NUMBER(10,2)", "DECIMAL(10,2)"},
+		{"INTEGER", "BIGINT"},
 		{"VARCHAR", "VARCHAR"},
 		{"TEXT", "VARCHAR"},
 		{"BOOLEAN", "BOOLEAN"},
@@ -287,7 +288,9 @@ func TestSnowflakeToDuckDBType(t *testing.T) {
 		{"TIMESTAMP", "TIMESTAMP"},
 		{"TIMESTAMP_NTZ", "TIMESTAMP"},
 		{"FLOAT", "DOUBLE"},
-		{"VARIANT", "VARCHAR"},
+		{"VARIANT", "JSON"},
+		{"TIMESTAMP_LTZ", "TIMESTAMPTZ"},
+		{"BINARY", "BLOB"},
 		{"UNKNOWN_TYPE", "VARCHAR"}, // Default
 	}
 
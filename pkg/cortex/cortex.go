@@ -0,0 +1,258 @@
+// Package cortex provides the backends behind the emulator's Snowflake Cortex
+// LLM functions: a stub returning canned, deterministic responses for offline
+// tests, and a client for OpenAI-compatible chat completion endpoints such as
+// Ollama's.
+package cortex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Backend answers the Cortex functions COMPLETE, SUMMARIZE, and SENTIMENT.
+type Backend interface {
+	// Complete returns the model's response to a prompt.
+	Complete(ctx context.Context, model, prompt string) (string, error)
+	// Summarize returns a summary of text.
+	Summarize(ctx context.Context, text string) (string, error)
+	// Sentiment scores the sentiment of text from -1 (negative) to 1 (positive).
+	Sentiment(ctx context.Context, text string) (float64, error)
+}
+
+// StubBackend is a Backend returning canned responses. Inputs without a canned
+// response get a deterministic one, so tests can assert on the results.
+type StubBackend struct {
+	responses map[string]string
+}
+
+// NewStubBackend creates a StubBackend. responses maps COMPLETE prompts and
+// SUMMARIZE texts to the responses returned for them.
+func NewStubBackend(responses map[string]string) *StubBackend {
+	return &StubBackend{responses: responses}
+}
+
+// LoadStubBackend creates a StubBackend with the canned responses of a JSON
+// file holding an object of input to response.
+func LoadStubBackend(path string) (*StubBackend, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: the responses file is named by the operator
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Cortex responses: %w", err)
+	}
+	var responses map[string]string
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return nil, fmt.Errorf("failed to parse Cortex responses %s: %w", path, err)
+	}
+	return NewStubBackend(responses), nil
+}
+
+// Complete implements Backend. Without a canned response, the prompt is echoed
+// with the model's name.
+func (b *StubBackend) Complete(_ context.Context, model, prompt string) (string, error) {
+	if response, ok := b.responses[prompt]; ok {
+		return response, nil
+	}
+	return fmt.Sprintf("[%s] %s", model, prompt), nil
+}
+
+// maxStubSummary is the length at which stub summaries are cut.
+const maxStubSummary = 200
+
+// Summarize implements Backend. Without a canned response, the summary is the
+// text's first sentence.
+func (b *StubBackend) Summarize(_ context.Context, text string) (string, error) {
+	if response, ok := b.responses[text]; ok {
+		return response, nil
+	}
+	summary := strings.TrimSpace(text)
+	if end := strings.IndexAny(summary, ".!?"); end >= 0 {
+		summary = summary[:end+1]
+	}
+	if len(summary) > maxStubSummary {
+		summary = strings.TrimSpace(summary[:maxStubSummary]) + "..."
+	}
+	return summary, nil
+}
+
+// Words scored by the stub's sentiment analysis.
+var (
+	positiveWords = wordSet("good", "great", "excellent", "amazing", "awesome", "love", "loved", "like", "liked",
+		"happy", "best", "wonderful", "fantastic", "perfect", "nice", "recommend", "fast", "easy")
+	negativeWords = wordSet("bad", "terrible", "awful", "horrible", "hate", "hated", "poor", "worst", "disappointing",
+		"disappointed", "sad", "broken", "slow", "angry", "useless", "refund", "problem", "difficult")
+)
+
+// Sentiment implements Backend by counting positive and negative words: the
+// score is their difference over their total, or 0 when there are none.
+func (b *StubBackend) Sentiment(_ context.Context, text string) (float64, error) {
+	var positive, negative int
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		switch {
+		case positiveWords[word]:
+			positive++
+		case negativeWords[word]:
+			negative++
+		}
+	}
+	if positive+negative == 0 {
+		return 0, nil
+	}
+	return float64(positive-negative) / float64(positive+negative), nil
+}
+
+// wordSet returns a set of words.
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// Prompts sent by OpenAIBackend for the functions without a prompt of their own.
+const (
+	summarizePrompt = "Summarize the following text in a few sentences. Reply with the summary only.\n\n"
+	sentimentPrompt = "Rate the sentiment of the following text as a number from -1 (most negative) " +
+		"to 1 (most positive). Reply with the number only.\n\n"
+)
+
+// OpenAIBackend is a Backend calling an OpenAI-compatible chat completions
+// endpoint, such as Ollama's http://localhost:11434/v1.
+type OpenAIBackend struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// OpenAIOption configures an OpenAIBackend.
+type OpenAIOption func(*OpenAIBackend)
+
+// WithAPIKey sets the bearer token sent to the endpoint.
+func WithAPIKey(key string) OpenAIOption {
+	return func(b *OpenAIBackend) {
+		b.apiKey = key
+	}
+}
+
+// WithModel sets the model that answers every call, in place of the Snowflake
+// model named by COMPLETE. SUMMARIZE and SENTIMENT, which name no model, need it.
+func WithModel(model string) OpenAIOption {
+	return func(b *OpenAIBackend) {
+		b.model = model
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to call the endpoint.
+func WithHTTPClient(client *http.Client) OpenAIOption {
+	return func(b *OpenAIBackend) {
+		b.client = client
+	}
+}
+
+// NewOpenAIBackend creates an OpenAIBackend for the endpoint at baseURL.
+func NewOpenAIBackend(baseURL string, opts ...OpenAIOption) *OpenAIBackend {
+	b := &OpenAIBackend{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Complete implements Backend.
+func (b *OpenAIBackend) Complete(ctx context.Context, model, prompt string) (string, error) {
+	if b.model != "" {
+		model = b.model
+	}
+	return b.chat(ctx, model, prompt)
+}
+
+// Summarize implements Backend.
+func (b *OpenAIBackend) Summarize(ctx context.Context, text string) (string, error) {
+	return b.chat(ctx, b.model, summarizePrompt+text)
+}
+
+// Sentiment implements Backend. The model's answer is clamped to [-1, 1].
+func (b *OpenAIBackend) Sentiment(ctx context.Context, text string) (float64, error) {
+	answer, err := b.chat(ctx, b.model, sentimentPrompt+text)
+	if err != nil {
+		return 0, err
+	}
+	score, err := strconv.ParseFloat(strings.TrimSpace(answer), 64)
+	if err != nil {
+		return 0, fmt.Errorf("model answered %q instead of a sentiment score", answer)
+	}
+	return math.Max(-1, math.Min(1, score)), nil
+}
+
+// chatRequest is the body of a chat completions request.
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+// chatMessage is a message of a chat completions request or response.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponse is the body of a chat completions response.
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// chat sends a prompt as a single user message and returns the first choice.
+// The temperature is 0, so that answers vary as little as the model allows.
+func (b *OpenAIBackend) chat(ctx context.Context, model, prompt string) (string, error) {
+	if model == "" {
+		return "", fmt.Errorf("no model configured for the Cortex endpoint")
+	}
+	body, err := json.Marshal(chatRequest{Model: model, Messages: []chatMessage{{Role: "user", Content: prompt}}})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cortex endpoint request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("cortex endpoint returned %s with an invalid body: %w", resp.Status, err)
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("cortex endpoint returned %s: %s", resp.Status, result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK || len(result.Choices) == 0 {
+		return "", fmt.Errorf("cortex endpoint returned %s without a completion", resp.Status)
+	}
+	return result.Choices[0].Message.Content, nil
+}
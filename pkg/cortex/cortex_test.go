@@ -0,0 +1,145 @@
+package cortex
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStubBackend(t *testing.T) {
+	ctx := context.Background()
+	backend := NewStubBackend(map[string]string{"What is 2+2?": "4"})
+
+	tests := []struct {
+		name string
+		call func() (interface{}, error)
+		want interface{}
+	}{
+		{
+			name: "CompleteCanned",
+			call: func() (interface{}, error) { return backend.Complete(ctx, "llama3", "What is 2+2?") },
+			want: "4",
+		},
+		{
+			name: "CompleteEcho",
+			call: func() (interface{}, error) { return backend.Complete(ctx, "llama3", "Hello") },
+			want: "[llama3] Hello",
+		},
+		{
+			name: "SummarizeFirstSentence",
+			call: func() (interface{}, error) { return backend.Summarize(ctx, " Shipping was fast. The box was dented. ") },
+			want: "Shipping was fast.",
+		},
+		{
+			name: "SentimentPositive",
+			call: func() (interface{}, error) { return backend.Sentiment(ctx, "Great product, I love it!") },
+			want: 1.0,
+		},
+		{
+			name: "SentimentMixed",
+			call: func() (interface{}, error) { return backend.Sentiment(ctx, "Good price, slow and broken delivery") },
+			want: -1.0 / 3.0,
+		},
+		{
+			name: "SentimentNeutral",
+			call: func() (interface{}, error) { return backend.Sentiment(ctx, "The package arrived on Tuesday") },
+			want: 0.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadStubBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.json")
+	if err := os.WriteFile(path, []byte(`{"Summarize me": "short"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backend, err := LoadStubBackend(path)
+	if err != nil {
+		t.Fatalf("LoadStubBackend() error = %v", err)
+	}
+	if got, _ := backend.Summarize(context.Background(), "Summarize me"); got != "short" {
+		t.Errorf("Summarize() = %q, want %q", got, "short")
+	}
+
+	if _, err := LoadStubBackend(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadStubBackend() of a missing file succeeded")
+	}
+}
+
+func TestOpenAIBackend(t *testing.T) {
+	var requests []chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		requests = append(requests, req)
+
+		answer := "a reply"
+		if len(req.Messages) > 0 && req.Messages[0].Content == sentimentPrompt+"so good" {
+			answer = " 1.5\n"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": answer}}},
+		})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	backend := NewOpenAIBackend(server.URL+"/v1/", WithAPIKey("secret"), WithModel("llama3.2"))
+
+	completion, err := backend.Complete(ctx, "mistral-large", "Hello")
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if completion != "a reply" {
+		t.Errorf("Complete() = %q, want %q", completion, "a reply")
+	}
+	score, err := backend.Sentiment(ctx, "so good")
+	if err != nil {
+		t.Fatalf("Sentiment() error = %v", err)
+	}
+	if score != 1 {
+		t.Errorf("Sentiment() = %v, want the answer clamped to 1", score)
+	}
+
+	want := []chatRequest{
+		{Model: "llama3.2", Messages: []chatMessage{{Role: "user", Content: "Hello"}}},
+		{Model: "llama3.2", Messages: []chatMessage{{Role: "user", Content: sentimentPrompt + "so good"}}},
+	}
+	if diff := cmp.Diff(want, requests); diff != "" {
+		t.Errorf("requests mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewOpenAIBackend(server.URL+"/v1").Summarize(ctx, "text"); err == nil {
+		t.Error("Summarize() without a model succeeded")
+	}
+	if _, err := NewOpenAIBackend(server.URL+"/other", WithModel("llama3.2")).Complete(ctx, "m", "p"); err == nil {
+		t.Error("Complete() against a missing endpoint succeeded")
+	}
+}
@@ -0,0 +1,466 @@
+package emulator
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// conn is a connection with a session of its own, so that USE, ALTER
+// SESSION, SQL variables, and transactions apply to it alone.
+type conn struct {
+	connector *connector
+	sessionID int64
+	token     string
+}
+
+var (
+	_ driver.Conn               = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+)
+
+// Prepare returns a statement that runs sql when executed. Statements are
+// translated on each execution.
+func (c *conn) Prepare(sql string) (driver.Stmt, error) {
+	return &stmt{conn: c, sql: sql}, nil
+}
+
+// PrepareContext is Prepare.
+func (c *conn) PrepareContext(_ context.Context, sql string) (driver.Stmt, error) {
+	return c.Prepare(sql)
+}
+
+// Close ends the connection's session, rolling back its open transaction.
+func (c *conn) Close() error {
+	return c.connector.sessions.CloseSession(context.Background(), c.token)
+}
+
+// Begin starts a transaction.
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction with BEGIN, as Snowflake drivers do. Only the
+// default isolation level is supported, and read-only transactions are not.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, fmt.Errorf("isolation level %d is not supported", opts.Isolation)
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("read-only transactions are not supported")
+	}
+	if _, err := c.exec(ctx, "BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return &tx{conn: c}, nil
+}
+
+// ExecContext runs a statement, which may be a query, and returns the number
+// of rows it affected. A mock's expectation it meets may answer it instead.
+func (c *conn) ExecContext(ctx context.Context, sql string, args []driver.NamedValue) (driver.Result, error) {
+	if c.connector.mock != nil {
+		if e := c.connector.mock.match(kindExec, sql, args); e != nil {
+			switch {
+			case e.err != nil:
+				return nil, e.err
+			case e.hasResult:
+				return result{rowsAffected: e.rowsAffected}, nil
+			}
+		}
+	}
+	return c.exec(ctx, sql, args)
+}
+
+// exec runs a statement on the emulator.
+func (c *conn) exec(ctx context.Context, sql string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, sql, err := c.prepare(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	sessionID := strconv.FormatInt(c.sessionID, 10)
+	if query.ClassifySQL(sql).IsQuery {
+		if _, err := c.connector.executor.QueryWithHistory(ctx, sessionID, newQueryID(), sql); err != nil {
+			return nil, err
+		}
+		return result{}, nil
+	}
+	res, err := c.connector.executor.ExecuteWithHistory(ctx, sessionID, newQueryID(), sql)
+	if err != nil {
+		return nil, err
+	}
+	return result{rowsAffected: res.RowsAffected}, nil
+}
+
+// QueryContext runs a statement and returns its rows. Statements other than
+// queries return no rows. A mock's expectation it meets may answer it
+// instead.
+func (c *conn) QueryContext(ctx context.Context, sql string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.connector.mock != nil {
+		if e := c.connector.mock.match(kindQuery, sql, args); e != nil {
+			switch {
+			case e.err != nil:
+				return nil, e.err
+			case e.table != "":
+				return c.query(ctx, "SELECT * FROM "+e.table, nil)
+			}
+		}
+	}
+	return c.query(ctx, sql, args)
+}
+
+// query runs a statement on the emulator and returns its rows.
+func (c *conn) query(ctx context.Context, sql string, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, sql, err := c.prepare(ctx, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	sessionID := strconv.FormatInt(c.sessionID, 10)
+	if !query.ClassifySQL(sql).IsQuery {
+		if _, err := c.connector.executor.ExecuteWithHistory(ctx, sessionID, newQueryID(), sql); err != nil {
+			return nil, err
+		}
+		return &rows{result: &query.Result{}}, nil
+	}
+	res, err := c.connector.executor.QueryWithHistory(ctx, sessionID, newQueryID(), sql)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{result: res}, nil
+}
+
+// prepare returns the context of a statement, carrying the connection's
+// session as it is now, and the statement with args bound.
+func (c *conn) prepare(ctx context.Context, sql string, args []driver.NamedValue) (context.Context, string, error) {
+	sess, err := c.connector.sessions.ValidateSession(ctx, c.token)
+	if err != nil {
+		return nil, "", driver.ErrBadConn
+	}
+	ctx = withSession(ctx, sess)
+	if len(args) == 0 {
+		return ctx, sql, nil
+	}
+	bindings, err := namedBindings(args)
+	if err != nil {
+		return nil, "", err
+	}
+	sql, err = c.connector.executor.BindParameters(sql, bindings)
+	if err != nil {
+		return nil, "", err
+	}
+	return ctx, sql, nil
+}
+
+// withSession returns ctx carrying a session's parameters, identity, and
+// current context, as the server's handlers build it for each request.
+func withSession(ctx context.Context, sess *session.Session) context.Context {
+	params := make(map[string]string, len(sess.Parameters))
+	for name, value := range sess.Parameters {
+		params[name] = fmt.Sprint(value)
+	}
+	role := strings.ToUpper(sess.Role)
+	if role == "" {
+		role = config.DefaultRole
+	}
+	ctx = query.ContextWithSessionParameters(ctx, query.ParseSessionParameters(params))
+	ctx = query.ContextWithSessionInfo(ctx, query.SessionInfo{
+		ID:         strconv.FormatInt(sess.ID, 10),
+		User:       sess.Username,
+		Role:       role,
+		Database:   sess.Database,
+		Schema:     sess.CurrentSchema,
+		Warehouse:  sess.Warehouse,
+		Parameters: params,
+	})
+	return metadata.ContextWithOwner(ctx, role)
+}
+
+// namedBindings converts the arguments of a statement to bindings of its ?
+// and :N placeholders, typed as gosnowflake binds Go values.
+func namedBindings(args []driver.NamedValue) (map[string]*query.BindingValue, error) {
+	bindings := make(map[string]*query.BindingValue, len(args))
+	for _, arg := range args {
+		if arg.Name != "" {
+			return nil, fmt.Errorf("named argument %s is not supported; use ? or :N placeholders", arg.Name)
+		}
+		binding, err := bindingValue(arg.Value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", arg.Ordinal, err)
+		}
+		bindings[strconv.Itoa(arg.Ordinal)] = binding
+	}
+	return bindings, nil
+}
+
+// bindingValue converts a driver value to a binding.
+func bindingValue(v driver.Value) (*query.BindingValue, error) {
+	switch val := v.(type) {
+	case nil:
+		return &query.BindingValue{Type: query.ValueNull}, nil
+	case int64:
+		return &query.BindingValue{Type: "FIXED", Value: strconv.FormatInt(val, 10)}, nil
+	case float64:
+		return &query.BindingValue{Type: "REAL", Value: strconv.FormatFloat(val, 'g', -1, 64)}, nil
+	case bool:
+		return &query.BindingValue{Type: "BOOLEAN", Value: strconv.FormatBool(val)}, nil
+	case string:
+		return &query.BindingValue{Type: "TEXT", Value: val}, nil
+	case []byte:
+		return &query.BindingValue{Type: "BINARY", Value: hex.EncodeToString(val)}, nil
+	case time.Time:
+		return &query.BindingValue{Type: "TIMESTAMP_NTZ", Value: val.UTC().Format("2006-01-02 15:04:05.000000000")}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// newQueryID returns a query ID in the form the server gives them.
+func newQueryID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("01%d-%s", time.Now().Unix(), hex.EncodeToString(b))
+}
+
+// stmt is a prepared statement.
+type stmt struct {
+	conn *conn
+	sql  string
+}
+
+var (
+	_ driver.StmtExecContext  = (*stmt)(nil)
+	_ driver.StmtQueryContext = (*stmt)(nil)
+)
+
+// Close does nothing, since statements hold no resources.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1, since placeholders are counted when bound.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec runs the statement.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query runs the statement and returns its rows.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext runs the statement.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.sql, args)
+}
+
+// QueryContext runs the statement and returns its rows.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.sql, args)
+}
+
+// namedValues numbers positional arguments from 1.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// tx is a transaction of a connection's session.
+type tx struct {
+	conn *conn
+}
+
+// Commit commits the transaction.
+func (t *tx) Commit() error {
+	_, err := t.conn.exec(context.Background(), "COMMIT", nil)
+	return err
+}
+
+// Rollback rolls the transaction back.
+func (t *tx) Rollback() error {
+	_, err := t.conn.exec(context.Background(), "ROLLBACK", nil)
+	return err
+}
+
+// result is the result of a statement. Snowflake has no auto-increment IDs
+// to return.
+type result struct {
+	rowsAffected int64
+}
+
+// LastInsertId is not supported.
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported")
+}
+
+// RowsAffected returns the number of rows the statement inserted, updated,
+// or deleted.
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// rows iterates over the rows of a query result, which the executor has
+// already read in full.
+type rows struct {
+	result *query.Result
+	next   int
+}
+
+var (
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+	_ driver.RowsColumnTypePrecisionScale   = (*rows)(nil)
+	_ driver.RowsColumnTypeLength           = (*rows)(nil)
+)
+
+// Columns returns the names of the result's columns.
+func (r *rows) Columns() []string {
+	return r.result.Columns
+}
+
+// Close does nothing, since the rows are in memory.
+func (r *rows) Close() error {
+	return nil
+}
+
+// Next reads the next row into dest, with values converted to the types
+// database/sql supports.
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.Rows) {
+		return io.EOF
+	}
+	row := r.result.Rows[r.next]
+	r.next++
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = driverValue(row[i])
+		}
+	}
+	return nil
+}
+
+// column returns the metadata of column i, if the result has it.
+func (r *rows) column(i int) (types.ColumnMetadata, bool) {
+	if i >= len(r.result.ColumnTypes) {
+		return types.ColumnMetadata{}, false
+	}
+	return r.result.ColumnTypes[i], true
+}
+
+// ColumnTypeDatabaseTypeName returns the Snowflake type of a column, such as
+// FIXED or TEXT, as gosnowflake names them.
+func (r *rows) ColumnTypeDatabaseTypeName(i int) string {
+	column, _ := r.column(i)
+	return strings.ToUpper(column.Type)
+}
+
+// ColumnTypeNullable reports whether a column may hold NULLs.
+func (r *rows) ColumnTypeNullable(i int) (nullable, ok bool) {
+	column, ok := r.column(i)
+	return column.Nullable, ok
+}
+
+// ColumnTypePrecisionScale returns the precision and scale of a FIXED
+// column.
+func (r *rows) ColumnTypePrecisionScale(i int) (precision, scale int64, ok bool) {
+	column, ok := r.column(i)
+	if !ok || !strings.EqualFold(column.Type, "fixed") {
+		return 0, 0, false
+	}
+	return column.Precision, column.Scale, true
+}
+
+// ColumnTypeLength returns the length of a TEXT or BINARY column.
+func (r *rows) ColumnTypeLength(i int) (length int64, ok bool) {
+	column, ok := r.column(i)
+	if !ok || column.Length == 0 {
+		return 0, false
+	}
+	return column.Length, true
+}
+
+// driverValue converts a DuckDB value to a driver value. Integers that fit
+// in int64 stay integers, and other numbers become exact decimal strings, as
+// gosnowflake returns NUMBER columns. VARIANT, OBJECT, and ARRAY values
+// become their JSON text.
+func driverValue(v interface{}) driver.Value {
+	switch val := v.(type) {
+	case nil, int64, float64, bool, string, []byte, time.Time:
+		return val
+	case float32:
+		return float64(val)
+	case *big.Int:
+		if val.IsInt64() {
+			return val.Int64()
+		}
+		return val.String()
+	case duckdb.Decimal:
+		if val.Scale == 0 && val.Value != nil && val.Value.IsInt64() {
+			return val.Value.Int64()
+		}
+		return formatDecimal(val)
+	case duckdb.UUID:
+		return val.String()
+	case map[string]interface{}, []interface{}:
+		if encoded, err := json.Marshal(val); err == nil {
+			return string(encoded)
+		}
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= 1<<63-1 {
+			return int64(u)
+		}
+		return strconv.FormatUint(rv.Uint(), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatDecimal renders a DuckDB decimal with exactly its scale's fractional
+// digits.
+func formatDecimal(d duckdb.Decimal) string {
+	if d.Value == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(d.Value).String()
+	scale := int(d.Scale)
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if d.Value.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
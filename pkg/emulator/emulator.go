@@ -0,0 +1,200 @@
+// Package emulator runs the Snowflake emulator in-process behind a
+// database/sql handle, for unit tests that need Snowflake SQL semantics but
+// not the HTTP protocol. Statements go through the same translator and
+// executor as the server's, without gosnowflake or an HTTP listener.
+package emulator
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
+)
+
+// sessionTimeout is how long the session of an idle connection lives.
+const sessionTimeout = 24 * time.Hour
+
+// Config configures an embedded emulator.
+type Config struct {
+	// DBPath is the DuckDB file holding the emulator's data. Empty keeps the
+	// data in memory, private to the returned DB.
+	DBPath string
+	// Database and Schema are the current database and schema each
+	// connection starts in. They default to TEST_DB and PUBLIC, and the
+	// database is created if it does not exist.
+	Database string
+	Schema   string
+	// User and Role are those of each connection's session. They default to
+	// EMULATOR and SYSADMIN.
+	User string
+	Role string
+	// StageDir is the directory of internal stage files. Empty uses a
+	// temporary directory removed when the DB is closed.
+	StageDir string
+	// ImplicitCasting makes the translator cast strings compared with or
+	// assigned to numbers, as IMPLICIT_CASTING does for the server.
+	ImplicitCasting bool
+	// Parameters are the session parameters each connection starts with,
+	// such as TIMEZONE, as ALTER SESSION SET would give them.
+	Parameters map[string]string
+	// ExecutorOptions further configure the executor, e.g. with
+	// query.WithDropProtection.
+	ExecutorOptions []query.ExecutorOption
+}
+
+// OpenDB starts an emulator in-process and returns a *sql.DB whose
+// connections each have a Snowflake session of their own, as gosnowflake
+// connections do. Closing the DB stops the emulator.
+func OpenDB(cfg Config) (*sql.DB, error) {
+	c, err := newConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(c), nil
+}
+
+// connector creates the connections of an embedded emulator. database/sql
+// closes it when the DB is closed.
+type connector struct {
+	cfg      Config
+	db       *sql.DB
+	executor *query.Executor
+	sessions *session.Manager
+	// tempDir is the stage directory OpenDB created, removed on Close.
+	tempDir string
+	// mock holds the expectations of a DB opened with OpenDBWithMock.
+	mock *Mock
+}
+
+var (
+	_ driver.Connector = (*connector)(nil)
+	_ driver.Driver    = (*connector)(nil)
+)
+
+// newConnector opens the DuckDB database and wires the executor as the
+// server does.
+func newConnector(cfg Config) (*connector, error) {
+	if cfg.Database == "" {
+		cfg.Database = config.DefaultDatabase
+	}
+	if cfg.Schema == "" {
+		cfg.Schema = config.DefaultSchema
+	}
+	if cfg.User == "" {
+		cfg.User = "EMULATOR"
+	}
+	if cfg.Role == "" {
+		cfg.Role = config.DefaultRole
+	}
+
+	db, err := connection.Open(cfg.DBPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	c := &connector{cfg: cfg, db: db}
+	if err := c.init(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// init creates the metadata repository, executor, and session manager, and
+// the configured database.
+func (c *connector) init() error {
+	connMgr := connection.NewManager(c.db)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		return fmt.Errorf("failed to create repository: %w", err)
+	}
+
+	var translatorOpts []query.TranslatorOption
+	if c.cfg.ImplicitCasting {
+		translatorOpts = append(translatorOpts, query.WithImplicitCasting())
+	}
+	executorOpts := append([]query.ExecutorOption{query.WithTranslator(query.NewTranslator(translatorOpts...))}, c.cfg.ExecutorOptions...)
+	c.executor = query.NewExecutor(connMgr, repo, executorOpts...)
+
+	stageDir := c.cfg.StageDir
+	if stageDir == "" {
+		if stageDir, err = os.MkdirTemp("", "snowflake-emulator-stages-"); err != nil {
+			return fmt.Errorf("failed to create stage directory: %w", err)
+		}
+		c.tempDir = stageDir
+	}
+	stageMgr := stage.NewManager(repo, stageDir)
+	c.sessions = session.NewManager(sessionTimeout)
+	c.executor.Configure(
+		query.WithCopyProcessor(query.NewCopyProcessor(stageMgr, repo, c.executor)),
+		query.WithMergeProcessor(query.NewMergeProcessor(c.executor)),
+		query.WithStageManager(stageMgr),
+		query.WithWarehouseManager(warehouse.NewManager()),
+		query.WithSessionUpdater(c.sessions),
+	)
+	c.sessions.OnClose(func(sess *session.Session) {
+		_ = c.executor.EndSession(context.Background(), strconv.FormatInt(sess.ID, 10))
+	})
+
+	ctx := metadata.ContextWithOwner(context.Background(), c.cfg.Role)
+	if _, err := repo.GetDatabaseByName(ctx, c.cfg.Database); err != nil {
+		if _, err := repo.CreateDatabase(ctx, c.cfg.Database, "Auto-created database"); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", c.cfg.Database, err)
+		}
+	}
+	return nil
+}
+
+// Connect starts a session for a new connection.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	sess, err := c.sessions.CreateSession(ctx, c.cfg.User, c.cfg.Database, c.cfg.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.sessions.UpdateSessionRole(ctx, sess.Token, c.cfg.Role); err != nil {
+		return nil, err
+	}
+	if len(c.cfg.Parameters) > 0 {
+		params := make(map[string]interface{}, len(c.cfg.Parameters))
+		for name, value := range c.cfg.Parameters {
+			params[name] = value
+		}
+		if err := c.sessions.AlterSessionParameters(ctx, sess.ID, params, nil); err != nil {
+			return nil, err
+		}
+	}
+	return &conn{connector: c, sessionID: sess.ID, token: sess.Token}, nil
+}
+
+// Driver returns the connector itself, since an embedded emulator has no
+// data source names to open.
+func (c *connector) Driver() driver.Driver {
+	return c
+}
+
+// Open is not supported: embedded emulators are opened with OpenDB.
+func (c *connector) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("embedded emulator connections are opened with emulator.OpenDB")
+}
+
+// Close closes the DuckDB database and removes the temporary stage
+// directory.
+func (c *connector) Close() error {
+	err := c.db.Close()
+	if c.tempDir != "" {
+		if rmErr := os.RemoveAll(c.tempDir); err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,241 @@
+package emulator
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// openTestDB opens an in-memory emulator closed when the test ends.
+func openTestDB(t *testing.T, cfg Config) *sql.DB {
+	t.Helper()
+	db, err := OpenDB(cfg)
+	if err != nil {
+		t.Fatalf("OpenDB() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+	return db
+}
+
+func TestOpenDB_SnowflakeSQL(t *testing.T) {
+	db := openTestDB(t, Config{})
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER, name VARCHAR, score NUMBER(10,2), active BOOLEAN, created TIMESTAMP_NTZ)",
+		"INSERT INTO users VALUES (1, 'Alice', 9.5, TRUE, '2024-01-15 10:30:00')",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("ExecContext(%q) error = %v", stmt, err)
+		}
+	}
+
+	res, err := db.ExecContext(ctx, "INSERT INTO users VALUES (?, ?, ?, ?, ?)",
+		2, "Bob", 7.25, false, time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ExecContext() with bindings error = %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		t.Errorf("RowsAffected() = %d, %v, want 1", n, err)
+	}
+
+	type user struct {
+		ID      int64
+		Name    string
+		Score   string
+		Active  bool
+		Created time.Time
+		Initial string
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id, name, score, active, created, IFF(id = 1, LEFT(name, 1), NVL(NULL, 'x')) FROM users WHERE id >= :1 ORDER BY id", 1)
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatalf("ColumnTypes() error = %v", err)
+	}
+	var typeNames []string
+	for _, ct := range types {
+		typeNames = append(typeNames, ct.DatabaseTypeName())
+	}
+	if diff := cmp.Diff([]string{"FIXED", "TEXT", "FIXED", "BOOLEAN", "TIMESTAMP_NTZ", "TEXT"}, typeNames); diff != "" {
+		t.Errorf("column types mismatch (-want +got):\n%s", diff)
+	}
+	if precision, scale, ok := types[2].DecimalSize(); !ok || precision != 10 || scale != 2 {
+		t.Errorf("DecimalSize() = %d, %d, %v, want 10, 2, true", precision, scale, ok)
+	}
+	var got []user
+	for rows.Next() {
+		var u user
+		if err := rows.Scan(&u.ID, &u.Name, &u.Score, &u.Active, &u.Created, &u.Initial); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		got = append(got, u)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows.Err() = %v", err)
+	}
+	want := []user{
+		{ID: 1, Name: "Alice", Score: "9.50", Active: true, Created: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), Initial: "A"},
+		{ID: 2, Name: "Bob", Score: "7.25", Active: false, Created: time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC), Initial: "x"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOpenDB_Sessions(t *testing.T) {
+	db := openTestDB(t, Config{Database: "APP", Parameters: map[string]string{"TIMEZONE": "Asia/Tokyo"}})
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	defer conn.Close()
+
+	var database, schema, role string
+	if err := conn.QueryRowContext(ctx, "SELECT CURRENT_DATABASE(), CURRENT_SCHEMA(), CURRENT_ROLE()").Scan(&database, &schema, &role); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"APP", "PUBLIC", "SYSADMIN"}, []string{database, schema, role}); diff != "" {
+		t.Errorf("session context mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, stmt := range []string{
+		"CREATE SCHEMA app.staging",
+		"USE SCHEMA staging",
+		"SET threshold = 10",
+		"ALTER SESSION SET QUERY_TAG = 'embedded'",
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("ExecContext(%q) error = %v", stmt, err)
+		}
+	}
+	var threshold int64
+	if err := conn.QueryRowContext(ctx, "SELECT CURRENT_SCHEMA(), $threshold").Scan(&schema, &threshold); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+	if schema != "STAGING" || threshold != 10 {
+		t.Errorf("CURRENT_SCHEMA(), $threshold = %s, %d, want STAGING, 10", schema, threshold)
+	}
+
+	parameters := map[string]string{}
+	rows, err := conn.QueryContext(ctx, "SHOW PARAMETERS")
+	if err != nil {
+		t.Fatalf("SHOW PARAMETERS error = %v", err)
+	}
+	for rows.Next() {
+		columns, _ := rows.Columns()
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		parameters[values[0].String] = values[1].String
+	}
+	_ = rows.Close()
+	if parameters["TIMEZONE"] != "Asia/Tokyo" || parameters["QUERY_TAG"] != "embedded" {
+		t.Errorf("TIMEZONE, QUERY_TAG = %q, %q, want Asia/Tokyo, embedded", parameters["TIMEZONE"], parameters["QUERY_TAG"])
+	}
+
+	// Another connection has a session of its own
+	other, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	defer other.Close()
+	if err := other.QueryRowContext(ctx, "SELECT CURRENT_SCHEMA()").Scan(&schema); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+	if schema != "PUBLIC" {
+		t.Errorf("other connection's CURRENT_SCHEMA() = %s, want PUBLIC", schema)
+	}
+}
+
+func TestOpenDB_Transactions(t *testing.T) {
+	db := openTestDB(t, Config{})
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "CREATE TABLE accounts (id INTEGER, balance INTEGER)"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		commit bool
+		want   int64
+	}{
+		{name: "rollback", commit: false, want: 0},
+		{name: "commit", commit: true, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatalf("BeginTx() error = %v", err)
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT INTO accounts VALUES (1, 100)"); err != nil {
+				t.Fatalf("ExecContext() error = %v", err)
+			}
+			if tt.commit {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil {
+				t.Fatalf("ending transaction error = %v", err)
+			}
+			var got int64
+			if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM accounts").Scan(&got); err != nil {
+				t.Fatalf("QueryRowContext() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("COUNT(*) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenDB_Merge(t *testing.T) {
+	db := openTestDB(t, Config{})
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"CREATE TABLE target (id INTEGER, name VARCHAR)",
+		"CREATE TABLE source (id INTEGER, name VARCHAR)",
+		"INSERT INTO target VALUES (1, 'old')",
+		"INSERT INTO source VALUES (1, 'new'), (2, 'added')",
+		"MERGE INTO target t USING source s ON t.id = s.id WHEN MATCHED THEN UPDATE SET name = s.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name)",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("ExecContext(%q) error = %v", stmt, err)
+		}
+	}
+	var names []string
+	rows, err := db.QueryContext(ctx, "SELECT name FROM target ORDER BY id")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		names = append(names, name)
+	}
+	if diff := cmp.Diff([]string{"new", "added"}, names); diff != "" {
+		t.Errorf("names mismatch (-want +got):\n%s", diff)
+	}
+}
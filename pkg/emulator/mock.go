@@ -0,0 +1,184 @@
+package emulator
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// statementKind is the database/sql call an expectation matches.
+type statementKind string
+
+const (
+	kindQuery statementKind = "ExpectQuery"
+	kindExec  statementKind = "ExpectExec"
+)
+
+// Mock records expectations of the statements a test issues, in the style of
+// go-sqlmock, on top of an embedded emulator. A statement that meets an
+// expectation counts towards ExpectationsWereMet and returns the response the
+// expectation gives, if any; other statements, and those of expectations
+// without a response, run on the emulator. It is safe for concurrent use.
+type Mock struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// Expectation is an expected statement, met by the first statement that
+// matches it after it was added.
+type Expectation struct {
+	mock      *Mock
+	kind      statementKind
+	expr      string
+	pattern   *regexp.Regexp
+	args      []driver.Value
+	checkArgs bool
+	// table is the table whose rows a query returns instead of its own.
+	table string
+	err   error
+	// rowsAffected is the result an exec returns, if hasResult is set.
+	rowsAffected int64
+	hasResult    bool
+	met          bool
+}
+
+// OpenDBWithMock opens an embedded emulator like OpenDB, and returns a Mock
+// that records expectations of the statements issued through the DB.
+func OpenDBWithMock(cfg Config) (*sql.DB, *Mock, error) {
+	c, err := newConnector(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.mock = &Mock{}
+	return sql.OpenDB(c), c.mock, nil
+}
+
+// ExpectQuery expects a statement run with Query, QueryRow, or their Context
+// variants whose SQL matches the regular expression expr.
+func (m *Mock) ExpectQuery(expr string) *Expectation {
+	return m.expect(kindQuery, expr)
+}
+
+// ExpectExec expects a statement run with Exec or ExecContext whose SQL
+// matches the regular expression expr.
+func (m *Mock) ExpectExec(expr string) *Expectation {
+	return m.expect(kindExec, expr)
+}
+
+// expect adds an expectation. An invalid expression panics, as regexp's Must
+// functions do, since expectations are written by tests.
+func (m *Mock) expect(kind statementKind, expr string) *Expectation {
+	e := &Expectation{mock: m, kind: kind, expr: expr, pattern: regexp.MustCompile(expr)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// ExpectationsWereMet returns an error listing the expectations no statement
+// met.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var unmet []string
+	for _, e := range m.expectations {
+		if !e.met {
+			unmet = append(unmet, e.String())
+		}
+	}
+	if len(unmet) > 0 {
+		return fmt.Errorf("there are unfulfilled expectations: %s", strings.Join(unmet, ", "))
+	}
+	return nil
+}
+
+// WithArgs makes the expectation match only statements run with args, which
+// are compared after database/sql's conversion, so that 1 matches int64(1).
+func (e *Expectation) WithArgs(args ...driver.Value) *Expectation {
+	converted := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if v, err := driver.DefaultParameterConverter.ConvertValue(arg); err == nil {
+			arg = v
+		}
+		converted[i] = arg
+	}
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.args = converted
+	e.checkArgs = true
+	return e
+}
+
+// WillReturnRowsFromTable makes the query meeting the expectation return the
+// rows of table, read on the emulator with SELECT * in the statement's
+// session, instead of its own.
+func (e *Expectation) WillReturnRowsFromTable(table string) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.table = table
+	return e
+}
+
+// WillReturnError makes the statement meeting the expectation fail with err
+// without running.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.err = err
+	return e
+}
+
+// WillReturnResult makes the exec meeting the expectation report
+// rowsAffected without running.
+func (e *Expectation) WillReturnResult(rowsAffected int64) *Expectation {
+	e.mock.mu.Lock()
+	defer e.mock.mu.Unlock()
+	e.rowsAffected = rowsAffected
+	e.hasResult = true
+	return e
+}
+
+// String describes the expectation as it was written.
+func (e *Expectation) String() string {
+	s := fmt.Sprintf("%s(%q)", e.kind, e.expr)
+	if e.checkArgs {
+		s += fmt.Sprintf(".WithArgs(%v)", e.args)
+	}
+	return s
+}
+
+// match returns a copy of the first expectation of kind that sql and args
+// meet, and marks it met, or nil if there is none.
+func (m *Mock) match(kind statementKind, sql string, args []driver.NamedValue) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		if e.met || e.kind != kind || !e.pattern.MatchString(sql) || !e.argsMatch(args) {
+			continue
+		}
+		e.met = true
+		matched := *e
+		return &matched
+	}
+	return nil
+}
+
+// argsMatch reports whether args are those the expectation was given.
+func (e *Expectation) argsMatch(args []driver.NamedValue) bool {
+	if !e.checkArgs {
+		return true
+	}
+	if len(args) != len(e.args) {
+		return false
+	}
+	for i, arg := range args {
+		if !reflect.DeepEqual(arg.Value, e.args[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package emulator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// openTestMock opens an in-memory emulator with a mock, closed when the test
+// ends.
+func openTestMock(t *testing.T) (*sql.DB, *Mock) {
+	t.Helper()
+	db, mock, err := OpenDBWithMock(Config{})
+	if err != nil {
+		t.Fatalf("OpenDBWithMock() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+	return db, mock
+}
+
+// queryNames returns the name column of a query's rows.
+func queryNames(t *testing.T, db *sql.DB, sql string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), sql, args...)
+	if err != nil {
+		t.Fatalf("QueryContext(%q) error = %v", sql, err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows.Err() = %v", err)
+	}
+	return names
+}
+
+func TestMock(t *testing.T) {
+	db, mock := openTestMock(t)
+	ctx := context.Background()
+	for _, stmt := range []string{
+		"CREATE TABLE users (name VARCHAR)",
+		"CREATE TABLE fixture_users (name VARCHAR)",
+		"INSERT INTO users VALUES ('real')",
+		"INSERT INTO fixture_users VALUES ('fixture')",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("ExecContext(%q) error = %v", stmt, err)
+		}
+	}
+
+	t.Run("FallsBackToExecution", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT name FROM users WHERE name = \?`).WithArgs("real")
+		if diff := cmp.Diff([]string{"real"}, queryNames(t, db, "SELECT name FROM users WHERE name = ?", "real")); diff != "" {
+			t.Errorf("rows mismatch (-want +got):\n%s", diff)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("ExpectationsWereMet() = %v", err)
+		}
+	})
+
+	t.Run("RowsFromTable", func(t *testing.T) {
+		mock.ExpectQuery("FROM users").WillReturnRowsFromTable("fixture_users")
+		if diff := cmp.Diff([]string{"fixture"}, queryNames(t, db, "SELECT name FROM users")); diff != "" {
+			t.Errorf("rows mismatch (-want +got):\n%s", diff)
+		}
+		// The expectation is met once; later queries run on the emulator
+		if diff := cmp.Diff([]string{"real"}, queryNames(t, db, "SELECT name FROM users")); diff != "" {
+			t.Errorf("rows mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		want := errors.New("connection reset")
+		mock.ExpectExec("^DELETE FROM users").WillReturnError(want)
+		if _, err := db.ExecContext(ctx, "DELETE FROM users"); !errors.Is(err, want) {
+			t.Errorf("ExecContext() error = %v, want %v", err, want)
+		}
+		if diff := cmp.Diff([]string{"real"}, queryNames(t, db, "SELECT name FROM users")); diff != "" {
+			t.Errorf("rows were deleted (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Result", func(t *testing.T) {
+		mock.ExpectExec("^UPDATE users").WithArgs(1).WillReturnResult(42)
+		res, err := db.ExecContext(ctx, "UPDATE users SET name = 'x' WHERE 1 = ?", 1)
+		if err != nil {
+			t.Fatalf("ExecContext() error = %v", err)
+		}
+		if n, _ := res.RowsAffected(); n != 42 {
+			t.Errorf("RowsAffected() = %d, want 42", n)
+		}
+	})
+
+	t.Run("Unmet", func(t *testing.T) {
+		mock.ExpectExec("^INSERT INTO audit").WithArgs("x")
+		// Queries don't meet exec expectations, nor statements with other args
+		if _, err := db.QueryContext(ctx, "INSERT INTO audit VALUES (?)", "x"); err == nil {
+			t.Fatal("QueryContext() of a missing table succeeded")
+		}
+		if _, err := db.ExecContext(ctx, "INSERT INTO audit VALUES (?)", "y"); err == nil {
+			t.Fatal("ExecContext() of a missing table succeeded")
+		}
+		err := mock.ExpectationsWereMet()
+		if err == nil || !strings.Contains(err.Error(), `ExpectExec("^INSERT INTO audit").WithArgs([x])`) {
+			t.Errorf("ExpectationsWereMet() = %v, want the INSERT INTO audit expectation", err)
+		}
+	})
+}
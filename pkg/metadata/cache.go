@@ -0,0 +1,191 @@
+package metadata
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultLookupCacheTTL is how long a database or schema looked up by name is
+// cached by default.
+const DefaultLookupCacheTTL = time.Minute
+
+// RepositoryOption configures a Repository.
+type RepositoryOption func(*Repository)
+
+// WithLookupCacheTTL sets how long databases and schemas looked up by name are
+// cached. Entries are invalidated when the repository drops or updates them,
+// so the TTL only bounds how long changes made by another process sharing the
+// database go unnoticed. A TTL of 0 disables the cache.
+func WithLookupCacheTTL(ttl time.Duration) RepositoryOption {
+	return func(r *Repository) {
+		r.cache.ttl = ttl
+	}
+}
+
+// CacheStats reports the use of the repository's lookup cache.
+type CacheStats struct {
+	Hits          int64
+	Misses        int64
+	Invalidations int64
+	Entries       int
+}
+
+// HitRate returns the share of lookups answered from the cache, from 0 to 1.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// schemaKey is the key of a schema looked up by name.
+type schemaKey struct {
+	databaseID string
+	name       string
+}
+
+// cacheEntry is a cached lookup, valid until expires.
+type cacheEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// lookupCache caches GetDatabaseByName and GetSchemaByName, which are called
+// for most requests. Lookups that find nothing are not cached, so objects
+// created by another process are found immediately.
+type lookupCache struct {
+	ttl time.Duration
+
+	mu            sync.Mutex
+	databases     map[string]cacheEntry[Database] // Keyed by normalized name
+	schemas       map[schemaKey]cacheEntry[Schema]
+	hits, misses  int64
+	invalidations int64
+	// generation counts invalidations, so that a lookup racing with an
+	// invalidation does not cache what it read before it.
+	generation uint64
+}
+
+// database returns a copy of the cached database with a normalized name. On a
+// miss, it returns the generation to pass to putDatabase.
+func (c *lookupCache) database(name string) (*Database, uint64, bool) {
+	if c.ttl <= 0 {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.databases[name]
+	if !ok || time.Now().After(entry.expires) {
+		c.misses++
+		return nil, c.generation, false
+	}
+	c.hits++
+	db := entry.value
+	return &db, 0, true
+}
+
+// putDatabase caches a database looked up by name, unless the cache was
+// invalidated since generation.
+func (c *lookupCache) putDatabase(db *Database, generation uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if c.databases == nil {
+		c.databases = make(map[string]cacheEntry[Database])
+	}
+	c.databases[db.Name] = cacheEntry[Database]{value: *db, expires: time.Now().Add(c.ttl)}
+}
+
+// schema returns a copy of the cached schema of a database with a normalized
+// name. On a miss, it returns the generation to pass to putSchema.
+func (c *lookupCache) schema(databaseID, name string) (*Schema, uint64, bool) {
+	if c.ttl <= 0 {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.schemas[schemaKey{databaseID, name}]
+	if !ok || time.Now().After(entry.expires) {
+		c.misses++
+		return nil, c.generation, false
+	}
+	c.hits++
+	schema := entry.value
+	return &schema, 0, true
+}
+
+// putSchema caches a schema looked up by name, unless the cache was
+// invalidated since generation.
+func (c *lookupCache) putSchema(schema *Schema, generation uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if c.schemas == nil {
+		c.schemas = make(map[schemaKey]cacheEntry[Schema])
+	}
+	c.schemas[schemaKey{schema.DatabaseID, schema.Name}] = cacheEntry[Schema]{value: *schema, expires: time.Now().Add(c.ttl)}
+}
+
+// invalidateDatabase removes a database from the cache, and its schemas as
+// well if withSchemas is set.
+func (c *lookupCache) invalidateDatabase(id string, withSchemas bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for name, entry := range c.databases {
+		if entry.value.ID == id {
+			delete(c.databases, name)
+			c.invalidations++
+		}
+	}
+	if !withSchemas {
+		return
+	}
+	for key := range c.schemas {
+		if key.databaseID == id {
+			delete(c.schemas, key)
+			c.invalidations++
+		}
+	}
+}
+
+// invalidateSchema removes a schema from the cache.
+func (c *lookupCache) invalidateSchema(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key, entry := range c.schemas {
+		if entry.value.ID == id {
+			delete(c.schemas, key)
+			c.invalidations++
+		}
+	}
+}
+
+// stats returns the cache's statistics.
+func (c *lookupCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Invalidations: c.invalidations,
+		Entries:       len(c.databases) + len(c.schemas),
+	}
+}
+
+// CacheStats returns the statistics of the cache of databases and schemas
+// looked up by name.
+func (r *Repository) CacheStats() CacheStats {
+	return r.cache.stats()
+}
@@ -0,0 +1,100 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+)
+
+func TestRepository_LookupCache(t *testing.T) {
+	repo := setupTestRepository(t)
+	ctx := context.Background()
+
+	db, err := repo.CreateDatabase(ctx, "CACHE_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	schema, err := repo.CreateSchema(ctx, db.ID, "CACHE_SCHEMA", "")
+	if err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+
+	// The first lookups miss, the next ones hit
+	for range 3 {
+		if _, err := repo.GetDatabaseByName(ctx, "cache_db"); err != nil {
+			t.Fatalf("GetDatabaseByName() error = %v", err)
+		}
+		if _, err := repo.GetSchemaByName(ctx, db.ID, "cache_schema"); err != nil {
+			t.Fatalf("GetSchemaByName() error = %v", err)
+		}
+	}
+	if diff := cmp.Diff(CacheStats{Hits: 4, Misses: 2, Entries: 2}, repo.CacheStats()); diff != "" {
+		t.Errorf("CacheStats() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Cached lookups return copies
+	got, _ := repo.GetDatabaseByName(ctx, "CACHE_DB")
+	got.Comment = "changed"
+	if again, _ := repo.GetDatabaseByName(ctx, "CACHE_DB"); again.Comment != "" {
+		t.Errorf("cached database comment = %q, want it unchanged", again.Comment)
+	}
+
+	// Updates and drops invalidate the cache
+	if err := repo.UpdateDatabaseComment(ctx, db.ID, "sales"); err != nil {
+		t.Fatalf("UpdateDatabaseComment() error = %v", err)
+	}
+	if got, _ := repo.GetDatabaseByName(ctx, "CACHE_DB"); got.Comment != "sales" {
+		t.Errorf("database comment after update = %q, want %q", got.Comment, "sales")
+	}
+	if err := repo.DropSchema(ctx, schema.ID); err != nil {
+		t.Fatalf("DropSchema() error = %v", err)
+	}
+	if _, err := repo.GetSchemaByName(ctx, db.ID, "CACHE_SCHEMA"); err == nil {
+		t.Error("GetSchemaByName() after DropSchema found the dropped schema")
+	}
+	if err := repo.DropDatabase(ctx, db.ID); err != nil {
+		t.Fatalf("DropDatabase() error = %v", err)
+	}
+	if _, err := repo.GetDatabaseByName(ctx, "CACHE_DB"); err == nil {
+		t.Error("GetDatabaseByName() after DropDatabase found the dropped database")
+	}
+
+	// Lookups that find nothing are not cached
+	if _, err := repo.CreateDatabase(ctx, "CACHE_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	if _, err := repo.GetDatabaseByName(ctx, "CACHE_DB"); err != nil {
+		t.Errorf("GetDatabaseByName() after re-creating error = %v", err)
+	}
+	if stats := repo.CacheStats(); stats.Invalidations != 3 {
+		t.Errorf("CacheStats().Invalidations = %d, want 3", stats.Invalidations)
+	}
+}
+
+func TestRepository_LookupCacheDisabled(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	repo, err := NewRepository(connection.NewManager(db), WithLookupCacheTTL(0))
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := repo.CreateDatabase(ctx, "UNCACHED_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for range 2 {
+		if _, err := repo.GetDatabaseByName(ctx, "UNCACHED_DB"); err != nil {
+			t.Fatalf("GetDatabaseByName() error = %v", err)
+		}
+	}
+	if diff := cmp.Diff(CacheStats{}, repo.CacheStats()); diff != "" {
+		t.Errorf("CacheStats() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,191 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// CatalogDatabase is a database with its schemas, as returned by GetCatalog.
+type CatalogDatabase struct {
+	Database
+	Schemas []*CatalogSchema
+}
+
+// CatalogSchema is a schema with its tables, as returned by GetCatalog.
+type CatalogSchema struct {
+	Schema
+	Tables []*CatalogTable
+}
+
+// CatalogTable is a table with its parsed column definitions, as returned by GetCatalog.
+type CatalogTable struct {
+	Table
+	Columns []ColumnDef
+}
+
+// GetCatalog returns every database with its schemas, tables, and columns,
+// ordered by name at each level. The whole tree is read with a single query.
+func (r *Repository) GetCatalog(ctx context.Context) ([]*CatalogDatabase, error) {
+	query := `SELECT d.id, d.name, d.account_id, d.comment, d.created_at, d.owner,
+	                 s.id, s.name, s.comment, s.created_at, s.owner,
+	                 t.id, t.name, t.table_type, t.comment, t.created_at, t.owner, t.clustering_key, t.column_definitions
+	          FROM _metadata_databases d
+	          LEFT JOIN _metadata_schemas s ON s.database_id = d.id
+	          LEFT JOIN _metadata_tables t ON t.schema_id = s.id
+	          ORDER BY d.name, s.name, t.name`
+
+	rows, err := r.mgr.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var catalog []*CatalogDatabase
+	var currentDB *CatalogDatabase
+	var currentSchema *CatalogSchema
+	for rows.Next() {
+		var db Database
+		var dbAccountID, dbComment, dbOwner sql.NullString
+		var dbCreatedAt sql.NullTime
+		var schemaID, schemaName, schemaComment, schemaOwner sql.NullString
+		var schemaCreatedAt sql.NullTime
+		var tableID, tableName, tableType, tableComment, tableOwner, clusteringKey, columnDefinitions sql.NullString
+		var tableCreatedAt sql.NullTime
+
+		if err := rows.Scan(
+			&db.ID, &db.Name, &dbAccountID, &dbComment, &dbCreatedAt, &dbOwner,
+			&schemaID, &schemaName, &schemaComment, &schemaCreatedAt, &schemaOwner,
+			&tableID, &tableName, &tableType, &tableComment, &tableCreatedAt, &tableOwner, &clusteringKey, &columnDefinitions,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog row: %w", err)
+		}
+
+		if currentDB == nil || currentDB.ID != db.ID {
+			db.AccountID = dbAccountID.String
+			db.Comment = dbComment.String
+			db.Owner = dbOwner.String
+			if dbCreatedAt.Valid {
+				db.CreatedAt = dbCreatedAt.Time
+			}
+			currentDB = &CatalogDatabase{Database: db, Schemas: []*CatalogSchema{}}
+			currentSchema = nil
+			catalog = append(catalog, currentDB)
+		}
+
+		if !schemaID.Valid {
+			continue
+		}
+		if currentSchema == nil || currentSchema.ID != schemaID.String {
+			currentSchema = &CatalogSchema{
+				Schema: Schema{
+					ID:         schemaID.String,
+					DatabaseID: db.ID,
+					Name:       schemaName.String,
+					Comment:    schemaComment.String,
+					Owner:      schemaOwner.String,
+				},
+				Tables: []*CatalogTable{},
+			}
+			if schemaCreatedAt.Valid {
+				currentSchema.CreatedAt = schemaCreatedAt.Time
+			}
+			currentDB.Schemas = append(currentDB.Schemas, currentSchema)
+		}
+
+		if !tableID.Valid {
+			continue
+		}
+		table := Table{
+			ID:                tableID.String,
+			SchemaID:          schemaID.String,
+			Name:              tableName.String,
+			TableType:         tableType.String,
+			Comment:           tableComment.String,
+			Owner:             tableOwner.String,
+			ClusteringKey:     clusteringKey.String,
+			ColumnDefinitions: columnDefinitions.String,
+		}
+		if tableCreatedAt.Valid {
+			table.CreatedAt = tableCreatedAt.Time
+		}
+		currentSchema.Tables = append(currentSchema.Tables, &CatalogTable{
+			Table:   table,
+			Columns: ParseColumnDefs(table.ColumnDefinitions),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating catalog: %w", err)
+	}
+
+	return catalog, nil
+}
+
+// GetCatalog returns every database with its schemas, tables, and columns,
+// ordered by name at each level.
+func (s *MemoryStore) GetCatalog(ctx context.Context) ([]*CatalogDatabase, error) {
+	databases, err := s.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make([]*CatalogDatabase, 0, len(databases))
+	for _, db := range databases {
+		schemas, err := s.ListSchemas(ctx, db.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		catalogDB := &CatalogDatabase{Database: *db, Schemas: make([]*CatalogSchema, 0, len(schemas))}
+		for _, schema := range schemas {
+			tables, err := s.ListTables(ctx, schema.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			catalogSchema := &CatalogSchema{Schema: *schema, Tables: make([]*CatalogTable, 0, len(tables))}
+			for _, table := range tables {
+				catalogSchema.Tables = append(catalogSchema.Tables, &CatalogTable{
+					Table:   *table,
+					Columns: ParseColumnDefs(table.ColumnDefinitions),
+				})
+			}
+			catalogDB.Schemas = append(catalogDB.Schemas, catalogSchema)
+		}
+		catalog = append(catalog, catalogDB)
+	}
+
+	return catalog, nil
+}
+
+// ParseColumnDefs parses column definitions stored in Table.ColumnDefinitions.
+// It is the inverse of the format written by CreateTable.
+func ParseColumnDefs(s string) []ColumnDef {
+	if s == "" {
+		return nil
+	}
+
+	parts := strings.Split(s, ";")
+	columns := make([]ColumnDef, 0, len(parts))
+	for _, part := range parts {
+		// name:type:nullable:primarykey:default, where the default may contain colons
+		fields := strings.SplitN(part, ":", 5)
+		if len(fields) < 4 {
+			continue
+		}
+		col := ColumnDef{
+			Name:       fields[0],
+			Type:       fields[1],
+			Nullable:   fields[2] == "true",
+			PrimaryKey: fields[3] == "true",
+		}
+		if len(fields) == 5 && fields[4] != "" {
+			defaultVal := fields[4]
+			col.Default = &defaultVal
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
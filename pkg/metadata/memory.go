@@ -0,0 +1,738 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-memory Store for unit tests.
+//
+// It mirrors Repository's naming, uniqueness, and error behavior, but only
+// records metadata: no DuckDB schemas or tables are created or dropped.
+// All methods are safe for concurrent use and return copies of stored values.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	databases   map[string]*Database
+	schemas     map[string]*Schema
+	tables      map[string]*Table
+	stages      map[string]*Stage
+	fileFormats map[string]*FileFormat
+	history     map[string]*QueryHistoryEntry
+}
+
+// NewMemoryStore creates an empty in-memory metadata store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		databases:   make(map[string]*Database),
+		schemas:     make(map[string]*Schema),
+		tables:      make(map[string]*Table),
+		stages:      make(map[string]*Stage),
+		fileFormats: make(map[string]*FileFormat),
+		history:     make(map[string]*QueryHistoryEntry),
+	}
+}
+
+// CreateDatabase creates a new database.
+func (s *MemoryStore) CreateDatabase(ctx context.Context, name, comment string) (*Database, error) {
+	if name == "" {
+		return nil, fmt.Errorf("database name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, db := range s.databases {
+		if db.Name == normalizedName {
+			return nil, fmt.Errorf("database %s already exists", normalizedName)
+		}
+	}
+
+	db := &Database{ID: uuid.New().String(), Name: normalizedName, Comment: comment, CreatedAt: time.Now(), Owner: OwnerFromContext(ctx)}
+	s.databases[db.ID] = db
+	clone := *db
+	return &clone, nil
+}
+
+// GetDatabase retrieves a database by ID.
+func (s *MemoryStore) GetDatabase(_ context.Context, id string) (*Database, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	db, ok := s.databases[id]
+	if !ok {
+		return nil, fmt.Errorf("database with ID %s not found", id)
+	}
+	clone := *db
+	return &clone, nil
+}
+
+// GetDatabaseByName retrieves a database by name.
+func (s *MemoryStore) GetDatabaseByName(_ context.Context, name string) (*Database, error) {
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, db := range s.databases {
+		if db.Name == normalizedName {
+			clone := *db
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("database %s not found", normalizedName)
+}
+
+// ListDatabases retrieves all databases ordered by name.
+func (s *MemoryStore) ListDatabases(_ context.Context) ([]*Database, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var databases []*Database
+	for _, db := range s.databases {
+		clone := *db
+		databases = append(databases, &clone)
+	}
+	sort.Slice(databases, func(i, j int) bool { return databases[i].Name < databases[j].Name })
+	return databases, nil
+}
+
+// DropDatabase deletes a database and all its schemas.
+func (s *MemoryStore) DropDatabase(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.databases[id]; !ok {
+		return fmt.Errorf("database with ID %s not found", id)
+	}
+	for schemaID, schema := range s.schemas {
+		if schema.DatabaseID == id {
+			s.dropSchemaLocked(schemaID)
+		}
+	}
+	delete(s.databases, id)
+	return nil
+}
+
+// UpdateDatabaseComment updates the comment of a database.
+func (s *MemoryStore) UpdateDatabaseComment(_ context.Context, id, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db, ok := s.databases[id]
+	if !ok {
+		return fmt.Errorf("database with ID %s not found", id)
+	}
+	db.Comment = comment
+	return nil
+}
+
+// CreateSchema creates a new schema in a database.
+func (s *MemoryStore) CreateSchema(ctx context.Context, databaseID, name, comment string) (*Schema, error) {
+	if name == "" {
+		return nil, fmt.Errorf("schema name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, schema := range s.schemas {
+		if schema.DatabaseID == databaseID && schema.Name == normalizedName {
+			return nil, fmt.Errorf("schema %s already exists in database", normalizedName)
+		}
+	}
+
+	schema := &Schema{ID: uuid.New().String(), DatabaseID: databaseID, Name: normalizedName, Comment: comment, CreatedAt: time.Now(), Owner: OwnerFromContext(ctx)}
+	s.schemas[schema.ID] = schema
+	clone := *schema
+	return &clone, nil
+}
+
+// GetSchema retrieves a schema by ID.
+func (s *MemoryStore) GetSchema(_ context.Context, id string) (*Schema, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema, ok := s.schemas[id]
+	if !ok {
+		return nil, fmt.Errorf("schema with ID %s not found", id)
+	}
+	clone := *schema
+	return &clone, nil
+}
+
+// GetSchemaByName retrieves a schema by database ID and name.
+func (s *MemoryStore) GetSchemaByName(_ context.Context, databaseID, name string) (*Schema, error) {
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, schema := range s.schemas {
+		if schema.DatabaseID == databaseID && schema.Name == normalizedName {
+			clone := *schema
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("schema %s not found", name)
+}
+
+// ListSchemas retrieves all schemas in a database ordered by name.
+func (s *MemoryStore) ListSchemas(_ context.Context, databaseID string) ([]*Schema, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var schemas []*Schema
+	for _, schema := range s.schemas {
+		if schema.DatabaseID == databaseID {
+			clone := *schema
+			schemas = append(schemas, &clone)
+		}
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	return schemas, nil
+}
+
+// DropSchema deletes a schema and all its tables.
+func (s *MemoryStore) DropSchema(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.schemas[id]; !ok {
+		return fmt.Errorf("schema with ID %s not found", id)
+	}
+	s.dropSchemaLocked(id)
+	return nil
+}
+
+// dropSchemaLocked deletes a schema and its tables. The caller must hold s.mu.
+func (s *MemoryStore) dropSchemaLocked(id string) {
+	for tableID, table := range s.tables {
+		if table.SchemaID == id {
+			delete(s.tables, tableID)
+		}
+	}
+	delete(s.schemas, id)
+}
+
+// CreateTable creates a new table in a schema.
+func (s *MemoryStore) CreateTable(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error) {
+	if name == "" {
+		return nil, fmt.Errorf("table name cannot be empty")
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table must have at least one column")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schema, ok := s.schemas[schemaID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get schema: schema with ID %s not found", schemaID)
+	}
+	if _, ok := s.databases[schema.DatabaseID]; !ok {
+		return nil, fmt.Errorf("failed to get database: database with ID %s not found", schema.DatabaseID)
+	}
+	for _, table := range s.tables {
+		if table.SchemaID == schemaID && table.Name == normalizedName {
+			return nil, fmt.Errorf("table %s already exists in schema", normalizedName)
+		}
+	}
+
+	table := &Table{
+		ID:                uuid.New().String(),
+		SchemaID:          schemaID,
+		Name:              normalizedName,
+		TableType:         "BASE TABLE",
+		Comment:           comment,
+		CreatedAt:         time.Now(),
+		Owner:             OwnerFromContext(ctx),
+		ColumnDefinitions: serializeColumnDefs(columns),
+	}
+	s.tables[table.ID] = table
+	clone := *table
+	return &clone, nil
+}
+
+// RegisterTable records a table created with SQL and its columns. An existing
+// table registration with the same name is replaced.
+func (s *MemoryStore) RegisterTable(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error) {
+	if name == "" {
+		return nil, fmt.Errorf("table name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.schemas[schemaID]; !ok {
+		return nil, fmt.Errorf("failed to get schema: schema with ID %s not found", schemaID)
+	}
+	for id, table := range s.tables {
+		if table.SchemaID == schemaID && table.Name == normalizedName {
+			if table.TableType != "BASE TABLE" {
+				return nil, fmt.Errorf("%s %s already exists in schema", strings.ToLower(table.TableType), normalizedName)
+			}
+			delete(s.tables, id)
+		}
+	}
+
+	table := &Table{
+		ID:                uuid.New().String(),
+		SchemaID:          schemaID,
+		Name:              normalizedName,
+		TableType:         "BASE TABLE",
+		Comment:           comment,
+		CreatedAt:         time.Now(),
+		Owner:             OwnerFromContext(ctx),
+		ColumnDefinitions: serializeColumnDefs(columns),
+	}
+	s.tables[table.ID] = table
+	clone := *table
+	return &clone, nil
+}
+
+// CreateView records a view and the columns of its query. An existing view with
+// the same name is replaced.
+func (s *MemoryStore) CreateView(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error) {
+	if name == "" {
+		return nil, fmt.Errorf("view name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.schemas[schemaID]; !ok {
+		return nil, fmt.Errorf("failed to get schema: schema with ID %s not found", schemaID)
+	}
+	for id, table := range s.tables {
+		if table.SchemaID == schemaID && table.Name == normalizedName {
+			if table.TableType != "VIEW" {
+				return nil, fmt.Errorf("table %s already exists in schema", normalizedName)
+			}
+			delete(s.tables, id)
+		}
+	}
+
+	view := &Table{
+		ID:                uuid.New().String(),
+		SchemaID:          schemaID,
+		Name:              normalizedName,
+		TableType:         "VIEW",
+		Comment:           comment,
+		CreatedAt:         time.Now(),
+		Owner:             OwnerFromContext(ctx),
+		ColumnDefinitions: serializeColumnDefs(columns),
+	}
+	s.tables[view.ID] = view
+	clone := *view
+	return &clone, nil
+}
+
+// GetTable retrieves a table by ID.
+func (s *MemoryStore) GetTable(_ context.Context, id string) (*Table, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	table, ok := s.tables[id]
+	if !ok {
+		return nil, fmt.Errorf("table with ID %s not found", id)
+	}
+	clone := *table
+	return &clone, nil
+}
+
+// GetTableByName retrieves a table by schema ID and name.
+func (s *MemoryStore) GetTableByName(_ context.Context, schemaID, name string) (*Table, error) {
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, table := range s.tables {
+		if table.SchemaID == schemaID && table.Name == normalizedName {
+			clone := *table
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("table %s not found", name)
+}
+
+// ListTables retrieves all tables in a schema ordered by name.
+func (s *MemoryStore) ListTables(_ context.Context, schemaID string) ([]*Table, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tables []*Table
+	for _, table := range s.tables {
+		if table.SchemaID == schemaID {
+			clone := *table
+			tables = append(tables, &clone)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables, nil
+}
+
+// DropTable deletes a table.
+func (s *MemoryStore) DropTable(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tables[id]; !ok {
+		return fmt.Errorf("table with ID %s not found", id)
+	}
+	delete(s.tables, id)
+	return nil
+}
+
+// UpdateTableComment updates the comment of a table.
+func (s *MemoryStore) UpdateTableComment(_ context.Context, id, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[id]
+	if !ok {
+		return fmt.Errorf("table with ID %s not found", id)
+	}
+	table.Comment = comment
+	return nil
+}
+
+// UpdateTableDefinition updates the name and columns of a table, such as after
+// an ALTER TABLE.
+func (s *MemoryStore) UpdateTableDefinition(_ context.Context, id, name string, columns []ColumnDef) error {
+	if name == "" {
+		return fmt.Errorf("table name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[id]
+	if !ok {
+		return fmt.Errorf("table with ID %s not found", id)
+	}
+	table.Name = strings.ToUpper(name)
+	table.ColumnDefinitions = serializeColumnDefs(columns)
+	return nil
+}
+
+// SetViewFlags records whether a view is secure and whether it is materialized.
+func (s *MemoryStore) SetViewFlags(_ context.Context, id string, secure, materialized bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[id]
+	if !ok || table.TableType != "VIEW" {
+		return fmt.Errorf("view with ID %s not found", id)
+	}
+	table.Secure, table.Materialized = secure, materialized
+	return nil
+}
+
+// CreateStage creates a new stage in the specified schema.
+func (s *MemoryStore) CreateStage(ctx context.Context, schemaID, name, stageType, url, comment string) (*Stage, error) {
+	if name == "" {
+		return nil, fmt.Errorf("stage name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+	if stageType == "" {
+		stageType = "INTERNAL"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stage := range s.stages {
+		if stage.SchemaID == schemaID && stage.Name == normalizedName {
+			return nil, fmt.Errorf("stage %s already exists", normalizedName)
+		}
+	}
+
+	stage := &Stage{
+		ID:        uuid.New().String(),
+		SchemaID:  schemaID,
+		Name:      normalizedName,
+		StageType: stageType,
+		URL:       url,
+		Comment:   comment,
+		CreatedAt: time.Now(),
+		Owner:     OwnerFromContext(ctx),
+	}
+	s.stages[stage.ID] = stage
+	clone := *stage
+	return &clone, nil
+}
+
+// GetStage retrieves a stage by ID.
+func (s *MemoryStore) GetStage(_ context.Context, id string) (*Stage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stage, ok := s.stages[id]
+	if !ok {
+		return nil, fmt.Errorf("stage with ID %s not found", id)
+	}
+	clone := *stage
+	return &clone, nil
+}
+
+// GetStageByName retrieves a stage by schema ID and name.
+func (s *MemoryStore) GetStageByName(_ context.Context, schemaID, name string) (*Stage, error) {
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, stage := range s.stages {
+		if stage.SchemaID == schemaID && stage.Name == normalizedName {
+			clone := *stage
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("stage %s not found", normalizedName)
+}
+
+// ListStages returns all stages in a schema ordered by name.
+func (s *MemoryStore) ListStages(_ context.Context, schemaID string) ([]*Stage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stages []*Stage
+	for _, stage := range s.stages {
+		if stage.SchemaID == schemaID {
+			clone := *stage
+			stages = append(stages, &clone)
+		}
+	}
+	sort.Slice(stages, func(i, j int) bool { return stages[i].Name < stages[j].Name })
+	return stages, nil
+}
+
+// DropStage deletes a stage by ID.
+func (s *MemoryStore) DropStage(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.stages[id]; !ok {
+		return fmt.Errorf("stage with ID %s not found", id)
+	}
+	delete(s.stages, id)
+	return nil
+}
+
+// CreateFileFormat creates a new file format in the specified schema.
+func (s *MemoryStore) CreateFileFormat(ctx context.Context, schemaID, name, formatType, options, comment string) (*FileFormat, error) {
+	if name == "" {
+		return nil, fmt.Errorf("file format name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ff := range s.fileFormats {
+		if ff.SchemaID == schemaID && ff.Name == normalizedName {
+			return nil, fmt.Errorf("file format %s already exists", normalizedName)
+		}
+	}
+
+	ff := &FileFormat{
+		ID:         uuid.New().String(),
+		SchemaID:   schemaID,
+		Name:       normalizedName,
+		FormatType: formatType,
+		Options:    options,
+		Comment:    comment,
+		CreatedAt:  time.Now(),
+		Owner:      OwnerFromContext(ctx),
+	}
+	s.fileFormats[ff.ID] = ff
+	clone := *ff
+	return &clone, nil
+}
+
+// GetFileFormat retrieves a file format by ID.
+func (s *MemoryStore) GetFileFormat(_ context.Context, id string) (*FileFormat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ff, ok := s.fileFormats[id]
+	if !ok {
+		return nil, fmt.Errorf("file format with ID %s not found", id)
+	}
+	clone := *ff
+	return &clone, nil
+}
+
+// GetFileFormatByName retrieves a file format by schema ID and name.
+func (s *MemoryStore) GetFileFormatByName(_ context.Context, schemaID, name string) (*FileFormat, error) {
+	normalizedName := strings.ToUpper(name)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ff := range s.fileFormats {
+		if ff.SchemaID == schemaID && ff.Name == normalizedName {
+			clone := *ff
+			return &clone, nil
+		}
+	}
+	return nil, fmt.Errorf("file format %s not found", normalizedName)
+}
+
+// ListFileFormats returns all file formats in a schema ordered by name.
+func (s *MemoryStore) ListFileFormats(_ context.Context, schemaID string) ([]*FileFormat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var fileFormats []*FileFormat
+	for _, ff := range s.fileFormats {
+		if ff.SchemaID == schemaID {
+			clone := *ff
+			fileFormats = append(fileFormats, &clone)
+		}
+	}
+	sort.Slice(fileFormats, func(i, j int) bool { return fileFormats[i].Name < fileFormats[j].Name })
+	return fileFormats, nil
+}
+
+// DropFileFormat deletes a file format by ID.
+func (s *MemoryStore) DropFileFormat(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.fileFormats[id]; !ok {
+		return fmt.Errorf("file format with ID %s not found", id)
+	}
+	delete(s.fileFormats, id)
+	return nil
+}
+
+// RecordQueryStart records the start of a query execution.
+func (s *MemoryStore) RecordQueryStart(_ context.Context, sessionID, queryID, sqlText string) (*QueryHistoryEntry, error) {
+	entry := &QueryHistoryEntry{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		QueryID:   queryID,
+		SQLText:   sqlText,
+		Status:    "RUNNING",
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history[entry.ID] = entry
+	clone := *entry
+	return &clone, nil
+}
+
+// RecordQuerySuccess records a successful query completion.
+func (s *MemoryStore) RecordQuerySuccess(_ context.Context, id string, rowsAffected int64, executionTimeMs int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.history[id]; ok {
+		now := time.Now()
+		entry.Status = "SUCCESS"
+		entry.RowsAffected = rowsAffected
+		entry.ExecutionTimeMs = executionTimeMs
+		entry.CompletedAt = &now
+	}
+	return nil
+}
+
+// RecordQueryFailure records a failed query completion.
+func (s *MemoryStore) RecordQueryFailure(_ context.Context, id string, errorMessage string, executionTimeMs int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.history[id]; ok {
+		now := time.Now()
+		entry.Status = "FAILED"
+		entry.ErrorMessage = errorMessage
+		entry.ExecutionTimeMs = executionTimeMs
+		entry.CompletedAt = &now
+	}
+	return nil
+}
+
+// RecordQueryLabels records the QUERY_TAG and labels a query was submitted
+// with.
+func (s *MemoryStore) RecordQueryLabels(_ context.Context, id, queryTag string, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.history[id]; ok {
+		entry.QueryTag = queryTag
+		entry.Labels = maps.Clone(labels)
+	}
+	return nil
+}
+
+// GetQueryHistory retrieves query history, most recent first, with optional limit.
+func (s *MemoryStore) GetQueryHistory(_ context.Context, limit int) ([]*QueryHistoryEntry, error) {
+	return s.queryHistory(func(*QueryHistoryEntry) bool { return true }, limit), nil
+}
+
+// GetQueryHistoryBySession retrieves query history for a specific session.
+func (s *MemoryStore) GetQueryHistoryBySession(_ context.Context, sessionID string, limit int) ([]*QueryHistoryEntry, error) {
+	return s.queryHistory(func(e *QueryHistoryEntry) bool { return e.SessionID == sessionID }, limit), nil
+}
+
+// FindQueryHistory retrieves the query history entries the filter selects,
+// most recent first.
+func (s *MemoryStore) FindQueryHistory(_ context.Context, filter QueryHistoryFilter) ([]*QueryHistoryEntry, error) {
+	return s.queryHistory(filter.matches, filter.Limit), nil
+}
+
+// queryHistory returns copies of matching entries, most recent first.
+func (s *MemoryStore) queryHistory(match func(*QueryHistoryEntry) bool, limit int) []*QueryHistoryEntry {
+	if limit <= 0 {
+		limit = 100 // Default limit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []*QueryHistoryEntry
+	for _, entry := range s.history {
+		if match(entry) {
+			clone := *entry
+			entries = append(entries, &clone)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].StartedAt.After(entries[j].StartedAt) })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// ClearQueryHistory removes query history entries started before olderThan.
+func (s *MemoryStore) ClearQueryHistory(_ context.Context, olderThan time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int64
+	for id, entry := range s.history {
+		if entry.StartedAt.Before(olderThan) {
+			delete(s.history, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
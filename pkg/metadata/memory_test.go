@@ -0,0 +1,483 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// storeImplementations returns every Store implementation, so that the same
+// behavior is checked for the DuckDB repository and the in-memory store.
+func storeImplementations(t *testing.T) []struct {
+	name  string
+	store Store
+} {
+	t.Helper()
+
+	return []struct {
+		name  string
+		store Store
+	}{
+		{name: "Repository", store: setupTestRepository(t)},
+		{name: "MemoryStore", store: NewMemoryStore()},
+	}
+}
+
+// TestStore_DatabaseSchemaTable tests the database, schema, and table lifecycle.
+func TestStore_DatabaseSchemaTable(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := impl.store
+
+			db, err := store.CreateDatabase(ctx, "test_db", "comment")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			if db.Name != "TEST_DB" {
+				t.Errorf("Name = %s, want TEST_DB", db.Name)
+			}
+			if _, err := store.CreateDatabase(ctx, "TEST_DB", ""); err == nil {
+				t.Error("CreateDatabase() duplicate error = nil, want error")
+			}
+
+			schema, err := store.CreateSchema(ctx, db.ID, "public", "")
+			if err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+			got, err := store.GetSchemaByName(ctx, db.ID, "PUBLIC")
+			if err != nil {
+				t.Fatalf("GetSchemaByName() error = %v", err)
+			}
+			if got.ID != schema.ID {
+				t.Errorf("GetSchemaByName() ID = %s, want %s", got.ID, schema.ID)
+			}
+
+			columns := []ColumnDef{{Name: "ID", Type: "INTEGER", PrimaryKey: true}}
+			for _, name := range []string{"users", "orders"} {
+				if _, err := store.CreateTable(ctx, schema.ID, name, columns, ""); err != nil {
+					t.Fatalf("CreateTable(%s) error = %v", name, err)
+				}
+			}
+			if _, err := store.CreateTable(ctx, schema.ID, "users", columns, ""); err == nil {
+				t.Error("CreateTable() duplicate error = nil, want error")
+			}
+
+			tables, err := store.ListTables(ctx, schema.ID)
+			if err != nil {
+				t.Fatalf("ListTables() error = %v", err)
+			}
+			var names []string
+			for _, table := range tables {
+				names = append(names, table.Name)
+			}
+			if diff := cmp.Diff([]string{"ORDERS", "USERS"}, names); diff != "" {
+				t.Errorf("ListTables() mismatch (-want +got):\n%s", diff)
+			}
+
+			if err := store.UpdateTableComment(ctx, tables[0].ID, "updated"); err != nil {
+				t.Fatalf("UpdateTableComment() error = %v", err)
+			}
+			table, err := store.GetTable(ctx, tables[0].ID)
+			if err != nil {
+				t.Fatalf("GetTable() error = %v", err)
+			}
+			if table.Comment != "updated" {
+				t.Errorf("Comment = %q, want %q", table.Comment, "updated")
+			}
+
+			if err := store.DropTable(ctx, table.ID); err != nil {
+				t.Fatalf("DropTable() error = %v", err)
+			}
+			if _, err := store.GetTable(ctx, table.ID); err == nil {
+				t.Error("GetTable() after drop error = nil, want error")
+			}
+			if err := store.DropTable(ctx, table.ID); err == nil {
+				t.Error("DropTable() twice error = nil, want error")
+			}
+
+			if err := store.DropSchema(ctx, schema.ID); err != nil {
+				t.Fatalf("DropSchema() error = %v", err)
+			}
+			if tables, _ := store.ListTables(ctx, schema.ID); len(tables) != 0 {
+				t.Errorf("ListTables() after DropSchema = %d tables, want 0", len(tables))
+			}
+
+			if err := store.DropDatabase(ctx, db.ID); err != nil {
+				t.Fatalf("DropDatabase() error = %v", err)
+			}
+			if _, err := store.GetDatabaseByName(ctx, "TEST_DB"); err == nil {
+				t.Error("GetDatabaseByName() after drop error = nil, want error")
+			}
+		})
+	}
+}
+
+// TestStore_RegisterTable tests recording tables created with SQL.
+func TestStore_RegisterTable(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := impl.store
+
+			db, err := store.CreateDatabase(ctx, "test_db", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			schema, err := store.CreateSchema(ctx, db.ID, "public", "")
+			if err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+
+			defaultName := "'none'"
+			columns := []ColumnDef{
+				{Name: "ID", Type: "NUMBER(38,0)", PrimaryKey: true},
+				{Name: "NAME", Type: "VARCHAR", Nullable: true, Default: &defaultName},
+			}
+			if _, err := store.RegisterTable(ctx, schema.ID, "users", columns[:1], ""); err != nil {
+				t.Fatalf("RegisterTable() error = %v", err)
+			}
+			// Registering a table again replaces its columns
+			table, err := store.RegisterTable(ctx, schema.ID, "users", columns, "replaced")
+			if err != nil {
+				t.Fatalf("RegisterTable() replace error = %v", err)
+			}
+			if table.TableType != "BASE TABLE" || table.Comment != "replaced" {
+				t.Errorf("RegisterTable() = %+v, want a BASE TABLE with comment %q", table, "replaced")
+			}
+			if diff := cmp.Diff(columns, ParseColumnDefs(table.ColumnDefinitions)); diff != "" {
+				t.Errorf("ColumnDefinitions mismatch (-want +got):\n%s", diff)
+			}
+			if tables, _ := store.ListTables(ctx, schema.ID); len(tables) != 1 {
+				t.Errorf("ListTables() = %d tables, want 1", len(tables))
+			}
+
+			// ALTER TABLE renames tables and changes their columns
+			if err := store.UpdateTableDefinition(ctx, table.ID, "members", columns[1:]); err != nil {
+				t.Fatalf("UpdateTableDefinition() error = %v", err)
+			}
+			renamed, err := store.GetTableByName(ctx, schema.ID, "MEMBERS")
+			if err != nil {
+				t.Fatalf("GetTableByName() after rename error = %v", err)
+			}
+			if diff := cmp.Diff(columns[1:], ParseColumnDefs(renamed.ColumnDefinitions)); diff != "" {
+				t.Errorf("ColumnDefinitions after update mismatch (-want +got):\n%s", diff)
+			}
+			if err := store.UpdateTableDefinition(ctx, "missing", "members", columns); err == nil {
+				t.Error("UpdateTableDefinition() of a missing table error = nil, want error")
+			}
+
+			if _, err := store.CreateView(ctx, schema.ID, "active_users", columns, ""); err != nil {
+				t.Fatalf("CreateView() error = %v", err)
+			}
+			if _, err := store.RegisterTable(ctx, schema.ID, "active_users", columns, ""); err == nil {
+				t.Error("RegisterTable() over a view error = nil, want error")
+			}
+		})
+	}
+}
+
+// TestStore_CreateView tests recording views and their columns.
+func TestStore_CreateView(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := impl.store
+
+			db, err := store.CreateDatabase(ctx, "test_db", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			schema, err := store.CreateSchema(ctx, db.ID, "public", "")
+			if err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+			if _, err := store.CreateTable(ctx, schema.ID, "users", []ColumnDef{{Name: "ID", Type: "INTEGER"}}, ""); err != nil {
+				t.Fatalf("CreateTable() error = %v", err)
+			}
+
+			columns := []ColumnDef{{Name: "ID", Type: "NUMBER(38,0)", Nullable: true}}
+			if _, err := store.CreateView(ctx, schema.ID, "active_users", columns, ""); err != nil {
+				t.Fatalf("CreateView() error = %v", err)
+			}
+			// Replacing a view keeps a single entry with the new columns
+			columns = append(columns, ColumnDef{Name: "NAME", Type: "VARCHAR", Nullable: true})
+			view, err := store.CreateView(ctx, schema.ID, "active_users", columns, "replaced")
+			if err != nil {
+				t.Fatalf("CreateView() replace error = %v", err)
+			}
+			if view.TableType != "VIEW" || view.Comment != "replaced" {
+				t.Errorf("CreateView() = %+v, want a VIEW with comment %q", view, "replaced")
+			}
+			if diff := cmp.Diff(columns, ParseColumnDefs(view.ColumnDefinitions)); diff != "" {
+				t.Errorf("ColumnDefinitions mismatch (-want +got):\n%s", diff)
+			}
+			if tables, _ := store.ListTables(ctx, schema.ID); len(tables) != 2 {
+				t.Errorf("ListTables() = %d tables, want 2", len(tables))
+			}
+
+			if err := store.SetViewFlags(ctx, view.ID, true, true); err != nil {
+				t.Fatalf("SetViewFlags() error = %v", err)
+			}
+			flagged, err := store.GetTableByName(ctx, schema.ID, "ACTIVE_USERS")
+			if err != nil || !flagged.Secure || !flagged.Materialized {
+				t.Errorf("GetTableByName() = %+v, %v, want a secure materialized view", flagged, err)
+			}
+			users, err := store.GetTableByName(ctx, schema.ID, "USERS")
+			if err != nil {
+				t.Fatalf("GetTableByName() error = %v", err)
+			}
+			if err := store.SetViewFlags(ctx, users.ID, true, false); err == nil {
+				t.Error("SetViewFlags() of a table error = nil, want error")
+			}
+
+			if _, err := store.CreateView(ctx, schema.ID, "users", columns, ""); err == nil {
+				t.Error("CreateView() over a table error = nil, want error")
+			}
+
+			if err := store.DropTable(ctx, view.ID); err != nil {
+				t.Fatalf("DropTable() view error = %v", err)
+			}
+			if _, err := store.GetTableByName(ctx, schema.ID, "ACTIVE_USERS"); err == nil {
+				t.Error("GetTableByName() after drop error = nil, want error")
+			}
+		})
+	}
+}
+
+// TestStore_StagesAndFileFormats tests stage and file format lifecycle.
+func TestStore_StagesAndFileFormats(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := impl.store
+
+			stage, err := store.CreateStage(ctx, "schema-1", "my_stage", "", "", "")
+			if err != nil {
+				t.Fatalf("CreateStage() error = %v", err)
+			}
+			if stage.StageType != "INTERNAL" {
+				t.Errorf("StageType = %s, want INTERNAL", stage.StageType)
+			}
+			if _, err := store.GetStageByName(ctx, "schema-1", "MY_STAGE"); err != nil {
+				t.Errorf("GetStageByName() error = %v", err)
+			}
+			if err := store.DropStage(ctx, stage.ID); err != nil {
+				t.Errorf("DropStage() error = %v", err)
+			}
+
+			ff, err := store.CreateFileFormat(ctx, "schema-1", "csv_format", "CSV", `{"delimiter":","}`, "")
+			if err != nil {
+				t.Fatalf("CreateFileFormat() error = %v", err)
+			}
+			formats, err := store.ListFileFormats(ctx, "schema-1")
+			if err != nil {
+				t.Fatalf("ListFileFormats() error = %v", err)
+			}
+			if len(formats) != 1 || formats[0].ID != ff.ID {
+				t.Errorf("ListFileFormats() = %v, want [%s]", formats, ff.ID)
+			}
+		})
+	}
+}
+
+// TestStore_QueryHistory tests query history recording.
+func TestStore_QueryHistory(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := impl.store
+
+			first, err := store.RecordQueryStart(ctx, "session-1", "q1", "SELECT 1")
+			if err != nil {
+				t.Fatalf("RecordQueryStart() error = %v", err)
+			}
+			if err := store.RecordQuerySuccess(ctx, first.ID, 1, 5); err != nil {
+				t.Fatalf("RecordQuerySuccess() error = %v", err)
+			}
+			time.Sleep(time.Millisecond)
+			second, err := store.RecordQueryStart(ctx, "session-2", "q2", "SELECT x")
+			if err != nil {
+				t.Fatalf("RecordQueryStart() error = %v", err)
+			}
+			if err := store.RecordQueryFailure(ctx, second.ID, "column not found", 3); err != nil {
+				t.Fatalf("RecordQueryFailure() error = %v", err)
+			}
+			labels := map[string]string{"suite": "orders", "case.name": "it's"}
+			if err := store.RecordQueryLabels(ctx, second.ID, "nightly", labels); err != nil {
+				t.Fatalf("RecordQueryLabels() error = %v", err)
+			}
+
+			history, err := store.GetQueryHistory(ctx, 10)
+			if err != nil {
+				t.Fatalf("GetQueryHistory() error = %v", err)
+			}
+			var statuses []string
+			for _, entry := range history {
+				statuses = append(statuses, entry.QueryID+":"+entry.Status)
+			}
+			if diff := cmp.Diff([]string{"q2:FAILED", "q1:SUCCESS"}, statuses); diff != "" {
+				t.Errorf("GetQueryHistory() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(labels, history[0].Labels); history[0].QueryTag != "nightly" || diff != "" {
+				t.Errorf("GetQueryHistory() tag = %q, labels mismatch (-want +got):\n%s", history[0].QueryTag, diff)
+			}
+
+			bySession, err := store.GetQueryHistoryBySession(ctx, "session-1", 10)
+			if err != nil {
+				t.Fatalf("GetQueryHistoryBySession() error = %v", err)
+			}
+			if len(bySession) != 1 || bySession[0].QueryID != "q1" {
+				t.Errorf("GetQueryHistoryBySession() = %v, want [q1]", bySession)
+			}
+
+			for _, tt := range []struct {
+				name   string
+				filter QueryHistoryFilter
+				want   []string
+			}{
+				{name: "All", want: []string{"q2", "q1"}},
+				{name: "Status", filter: QueryHistoryFilter{Status: "failed"}, want: []string{"q2"}},
+				{name: "SQLContains", filter: QueryHistoryFilter{SQLContains: "select 1"}, want: []string{"q1"}},
+				{name: "QueryID", filter: QueryHistoryFilter{QueryID: "q1", SessionID: "session-1"}, want: []string{"q1"}},
+				{name: "StartedAfter", filter: QueryHistoryFilter{StartedAfter: first.StartedAt}, want: []string{"q2"}},
+				{name: "StartedBefore", filter: QueryHistoryFilter{StartedBefore: second.StartedAt}, want: []string{"q1"}},
+				{name: "Limit", filter: QueryHistoryFilter{Limit: 1}, want: []string{"q2"}},
+				{name: "NoMatch", filter: QueryHistoryFilter{SessionID: "session-3"}},
+				{name: "QueryTag", filter: QueryHistoryFilter{QueryTag: "nightly"}, want: []string{"q2"}},
+				{name: "Labels", filter: QueryHistoryFilter{Labels: labels}, want: []string{"q2"}},
+				{name: "LabelMismatch", filter: QueryHistoryFilter{Labels: map[string]string{"suite": "users"}}},
+			} {
+				found, err := store.FindQueryHistory(ctx, tt.filter)
+				if err != nil {
+					t.Fatalf("FindQueryHistory(%s) error = %v", tt.name, err)
+				}
+				var queryIDs []string
+				for _, entry := range found {
+					queryIDs = append(queryIDs, entry.QueryID)
+				}
+				if diff := cmp.Diff(tt.want, queryIDs); diff != "" {
+					t.Errorf("FindQueryHistory(%s) mismatch (-want +got):\n%s", tt.name, diff)
+				}
+			}
+
+			removed, err := store.ClearQueryHistory(ctx, time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatalf("ClearQueryHistory() error = %v", err)
+			}
+			if removed != 2 {
+				t.Errorf("ClearQueryHistory() = %d, want 2", removed)
+			}
+		})
+	}
+}
+
+// TestStore_GetCatalog tests reading the whole catalog tree.
+func TestStore_GetCatalog(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := impl.store
+
+			defaultVal := "'x:y'"
+			analytics, err := store.CreateDatabase(ctx, "ANALYTICS", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			if _, err := store.CreateDatabase(ctx, "EMPTY_DB", ""); err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			public, err := store.CreateSchema(ctx, analytics.ID, "PUBLIC", "")
+			if err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+			if _, err := store.CreateSchema(ctx, analytics.ID, "EMPTY_SCHEMA", ""); err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+			columns := []ColumnDef{
+				{Name: "ID", Type: "INTEGER", PrimaryKey: true},
+				{Name: "NAME", Type: "VARCHAR", Nullable: true, Default: &defaultVal},
+			}
+			if _, err := store.CreateTable(ctx, public.ID, "USERS", columns, ""); err != nil {
+				t.Fatalf("CreateTable() error = %v", err)
+			}
+
+			catalog, err := store.GetCatalog(ctx)
+			if err != nil {
+				t.Fatalf("GetCatalog() error = %v", err)
+			}
+
+			// Summarize the tree as paths for comparison
+			var got []string
+			for _, db := range catalog {
+				got = append(got, db.Name)
+				for _, schema := range db.Schemas {
+					got = append(got, db.Name+"."+schema.Name)
+					for _, table := range schema.Tables {
+						got = append(got, db.Name+"."+schema.Name+"."+table.Name)
+						if diff := cmp.Diff(columns, table.Columns); diff != "" {
+							t.Errorf("Columns mismatch (-want +got):\n%s", diff)
+						}
+					}
+				}
+			}
+			want := []string{
+				"ANALYTICS",
+				"ANALYTICS.EMPTY_SCHEMA",
+				"ANALYTICS.PUBLIC",
+				"ANALYTICS.PUBLIC.USERS",
+				"EMPTY_DB",
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("GetCatalog() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestStore_Owner tests that created objects record the owner carried by the context.
+func TestStore_Owner(t *testing.T) {
+	for _, impl := range storeImplementations(t) {
+		t.Run(impl.name, func(t *testing.T) {
+			ctx := ContextWithOwner(context.Background(), "ANALYST")
+			store := impl.store
+
+			db, err := store.CreateDatabase(ctx, "OWNED_DB", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			schema, err := store.CreateSchema(ctx, db.ID, "PUBLIC", "")
+			if err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+			table, err := store.CreateTable(ctx, schema.ID, "USERS", []ColumnDef{{Name: "ID", Type: "INTEGER"}}, "")
+			if err != nil {
+				t.Fatalf("CreateTable() error = %v", err)
+			}
+			stage, err := store.CreateStage(ctx, schema.ID, "MY_STAGE", "", "", "")
+			if err != nil {
+				t.Fatalf("CreateStage() error = %v", err)
+			}
+			ff, err := store.CreateFileFormat(ctx, schema.ID, "CSV_FORMAT", "CSV", "", "")
+			if err != nil {
+				t.Fatalf("CreateFileFormat() error = %v", err)
+			}
+
+			got := []string{db.Owner, schema.Owner, table.Owner, stage.Owner, ff.Owner}
+			want := []string{"ANALYST", "ANALYST", "ANALYST", "ANALYST", "ANALYST"}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Owner mismatch (-want +got):\n%s", diff)
+			}
+
+			unowned, err := store.CreateDatabase(context.Background(), "UNOWNED_DB", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			if unowned.Owner != "" {
+				t.Errorf("Owner without context = %q, want empty", unowned.Owner)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package metadata
+
+import "context"
+
+// ownerKey is the context key for the owner recorded on created objects.
+type ownerKey struct{}
+
+// ContextWithOwner returns a copy of ctx whose Create calls record owner
+// as the owning role of the new object.
+func ContextWithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerFromContext returns the owner carried by ctx, or "" if none was set.
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
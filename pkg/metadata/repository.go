@@ -4,6 +4,7 @@ package metadata
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -15,7 +16,10 @@ import (
 // Repository manages Snowflake metadata (databases, schemas, tables) in DuckDB.
 // Metadata is stored in special tables prefixed with _metadata_.
 type Repository struct {
-	mgr *connection.Manager
+	mgr   *connection.Manager
+	cache lookupCache
+	// readOnly skips creating the metadata tables and recording query history.
+	readOnly bool
 }
 
 // Database represents a Snowflake database.
@@ -48,7 +52,9 @@ type Table struct {
 	CreatedAt         time.Time
 	Owner             string
 	ClusteringKey     string
-	ColumnDefinitions string // JSON string
+	ColumnDefinitions string // Serialized column definitions, see ParseColumnDefs
+	Secure            bool   // CREATE SECURE VIEW
+	Materialized      bool   // CREATE MATERIALIZED VIEW
 }
 
 // ColumnDef represents a table column definition.
@@ -96,12 +102,73 @@ type QueryHistoryEntry struct {
 	ErrorMessage    string
 	StartedAt       time.Time
 	CompletedAt     *time.Time
+	// QueryTag and Labels are the QUERY_TAG and labels the statement was
+	// submitted with, for correlating queries with the tests that ran them.
+	QueryTag string
+	Labels   map[string]string
+}
+
+// QueryHistoryFilter selects query history entries. Zero fields match every
+// entry.
+type QueryHistoryFilter struct {
+	SessionID string
+	QueryID   string
+	// Status is RUNNING, SUCCESS, FAILED, or CANCELED, matched case-insensitively.
+	Status string
+	// SQLContains matches entries whose SQL text contains it, case-insensitively.
+	SQLContains string
+	// StartedAfter and StartedBefore bound the start time of entries.
+	StartedAfter  time.Time
+	StartedBefore time.Time
+	// QueryTag matches entries submitted with exactly this QUERY_TAG.
+	QueryTag string
+	// Labels matches entries that have every one of these labels.
+	Labels map[string]string
+	// Limit is the most entries returned, 100 when 0 or less.
+	Limit int
+}
+
+// matches reports whether the filter selects entry.
+func (f QueryHistoryFilter) matches(entry *QueryHistoryEntry) bool {
+	return (f.SessionID == "" || entry.SessionID == f.SessionID) &&
+		(f.QueryID == "" || entry.QueryID == f.QueryID) &&
+		(f.Status == "" || strings.EqualFold(entry.Status, f.Status)) &&
+		(f.SQLContains == "" || strings.Contains(strings.ToLower(entry.SQLText), strings.ToLower(f.SQLContains))) &&
+		(f.StartedAfter.IsZero() || entry.StartedAt.After(f.StartedAfter)) &&
+		(f.StartedBefore.IsZero() || entry.StartedAt.Before(f.StartedBefore)) &&
+		(f.QueryTag == "" || entry.QueryTag == f.QueryTag) &&
+		hasLabels(entry.Labels, f.Labels)
+}
+
+// hasLabels reports whether labels has every label in want.
+func hasLabels(labels, want map[string]string) bool {
+	for key, value := range want {
+		if got, ok := labels[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// WithReadOnly uses a repository over a database opened read-only, whose
+// metadata tables were created by the process that seeded it. Query history
+// is not recorded.
+func WithReadOnly() RepositoryOption {
+	return func(r *Repository) {
+		r.readOnly = true
+	}
 }
 
 // NewRepository creates a new metadata repository.
 // It initializes metadata tables if they don't exist.
-func NewRepository(mgr *connection.Manager) (*Repository, error) {
-	repo := &Repository{mgr: mgr}
+func NewRepository(mgr *connection.Manager, opts ...RepositoryOption) (*Repository, error) {
+	repo := &Repository{mgr: mgr, cache: lookupCache{ttl: DefaultLookupCacheTTL}}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	if repo.readOnly {
+		return repo, nil
+	}
 
 	// Initialize metadata tables
 	if err := repo.initMetadataTables(context.Background()); err != nil {
@@ -142,6 +209,8 @@ func (r *Repository) initMetadataTables(ctx context.Context) error {
 			owner VARCHAR,
 			clustering_key VARCHAR,
 			column_definitions VARCHAR,
+			is_secure BOOLEAN DEFAULT FALSE,
+			is_materialized BOOLEAN DEFAULT FALSE,
 			UNIQUE(schema_id, name)
 		)`,
 		`CREATE TABLE IF NOT EXISTS _metadata_stages (
@@ -176,8 +245,16 @@ func (r *Repository) initMetadataTables(ctx context.Context) error {
 			execution_time_ms BIGINT DEFAULT 0,
 			error_message TEXT,
 			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			completed_at TIMESTAMP
+			completed_at TIMESTAMP,
+			query_tag VARCHAR,
+			labels VARCHAR
 		)`,
+		// Query history tables created before statements had labels
+		`ALTER TABLE _metadata_query_history ADD COLUMN IF NOT EXISTS query_tag VARCHAR`,
+		`ALTER TABLE _metadata_query_history ADD COLUMN IF NOT EXISTS labels VARCHAR`,
+		// Table metadata created before views were secure or materialized
+		`ALTER TABLE _metadata_tables ADD COLUMN IF NOT EXISTS is_secure BOOLEAN DEFAULT FALSE`,
+		`ALTER TABLE _metadata_tables ADD COLUMN IF NOT EXISTS is_materialized BOOLEAN DEFAULT FALSE`,
 	}
 
 	for _, query := range queries {
@@ -213,7 +290,7 @@ func (r *Repository) CreateDatabase(ctx context.Context, name, comment string) (
 		query := `INSERT INTO _metadata_databases (id, name, account_id, comment, created_at, owner)
 		          VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
 		accountID := "" // TODO: Populate when multi-tenancy is implemented
-		if _, err := tx.ExecContext(ctx, query, id, normalizedName, accountID, comment, ""); err != nil {
+		if _, err := tx.ExecContext(ctx, query, id, normalizedName, accountID, comment, OwnerFromContext(ctx)); err != nil {
 			// Check if it's a duplicate
 			if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Constraint Error") {
 				return fmt.Errorf("database %s already exists", normalizedName)
@@ -272,6 +349,10 @@ func (r *Repository) GetDatabase(ctx context.Context, id string) (*Database, err
 func (r *Repository) GetDatabaseByName(ctx context.Context, name string) (*Database, error) {
 	// Normalize name
 	normalizedName := strings.ToUpper(name)
+	cached, generation, ok := r.cache.database(normalizedName)
+	if ok {
+		return cached, nil
+	}
 
 	query := `SELECT id, name, account_id, comment, created_at, owner
 	          FROM _metadata_databases WHERE name = ?`
@@ -305,6 +386,7 @@ func (r *Repository) GetDatabaseByName(ctx context.Context, name string) (*Datab
 		db.Owner = owner.String
 	}
 
+	r.cache.putDatabase(&db, generation)
 	return &db, nil
 }
 
@@ -388,6 +470,7 @@ func (r *Repository) DropDatabase(ctx context.Context, id string) error {
 
 		return nil
 	})
+	r.cache.invalidateDatabase(id, true)
 
 	return err
 }
@@ -396,6 +479,7 @@ func (r *Repository) DropDatabase(ctx context.Context, id string) error {
 func (r *Repository) UpdateDatabaseComment(ctx context.Context, id, comment string) error {
 	query := `UPDATE _metadata_databases SET comment = ? WHERE id = ?`
 	result, err := r.mgr.Exec(ctx, query, comment, id)
+	r.cache.invalidateDatabase(id, false)
 	if err != nil {
 		return fmt.Errorf("failed to update database comment: %w", err)
 	}
@@ -427,7 +511,7 @@ func (r *Repository) CreateSchema(ctx context.Context, databaseID, name, comment
 	// Insert metadata
 	query := `INSERT INTO _metadata_schemas (id, database_id, name, comment, created_at, owner)
 	          VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
-	if _, err := r.mgr.Exec(ctx, query, id, databaseID, normalizedName, comment, ""); err != nil {
+	if _, err := r.mgr.Exec(ctx, query, id, databaseID, normalizedName, comment, OwnerFromContext(ctx)); err != nil {
 		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Constraint Error") {
 			return nil, fmt.Errorf("schema %s already exists in database", normalizedName)
 		}
@@ -473,6 +557,11 @@ func (r *Repository) GetSchema(ctx context.Context, id string) (*Schema, error)
 
 // GetSchemaByName retrieves a schema by database ID and name.
 func (r *Repository) GetSchemaByName(ctx context.Context, databaseID, name string) (*Schema, error) {
+	cached, generation, ok := r.cache.schema(databaseID, strings.ToUpper(name))
+	if ok {
+		return cached, nil
+	}
+
 	query := `SELECT id, database_id, name, comment, created_at, owner
 	          FROM _metadata_schemas WHERE database_id = ? AND name = ?`
 
@@ -501,6 +590,7 @@ func (r *Repository) GetSchemaByName(ctx context.Context, databaseID, name strin
 		schema.Owner = owner.String
 	}
 
+	r.cache.putSchema(&schema, generation)
 	return &schema, nil
 }
 
@@ -563,6 +653,7 @@ func (r *Repository) DropSchema(ctx context.Context, id string) error {
 	// Delete schema metadata
 	query := `DELETE FROM _metadata_schemas WHERE id = ?`
 	result, err := r.mgr.Exec(ctx, query, id)
+	r.cache.invalidateSchema(id)
 	if err != nil {
 		return fmt.Errorf("failed to delete schema metadata: %w", err)
 	}
@@ -643,7 +734,7 @@ func (r *Repository) CreateTable(ctx context.Context, schemaID, name string, col
 		// Insert metadata
 		query := `INSERT INTO _metadata_tables (id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions)
 		          VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?, ?)`
-		if _, err := tx.ExecContext(ctx, query, id, schemaID, normalizedName, "BASE TABLE", comment, "", "", columnDefsJSON); err != nil {
+		if _, err := tx.ExecContext(ctx, query, id, schemaID, normalizedName, "BASE TABLE", comment, OwnerFromContext(ctx), "", columnDefsJSON); err != nil {
 			if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Constraint Error") {
 				return fmt.Errorf("table %s already exists in schema", normalizedName)
 			}
@@ -660,9 +751,97 @@ func (r *Repository) CreateTable(ctx context.Context, schemaID, name string, col
 	return r.GetTable(ctx, id)
 }
 
+// CreateView records a view and the columns of its query. The view itself is
+// created in DuckDB by the statement that defines it. An existing view with the
+// same name is replaced.
+func (r *Repository) CreateView(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error) {
+	if name == "" {
+		return nil, fmt.Errorf("view name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	if _, err := r.GetSchema(ctx, schemaID); err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+
+	id := uuid.New().String()
+	err := r.mgr.ExecTx(ctx, func(tx *sql.Tx) error {
+		var tableType string
+		err := tx.QueryRowContext(ctx, `SELECT table_type FROM _metadata_tables WHERE schema_id = ? AND name = ?`,
+			schemaID, normalizedName).Scan(&tableType)
+		switch {
+		case err == nil && tableType != "VIEW":
+			return fmt.Errorf("table %s already exists in schema", normalizedName)
+		case err == nil:
+			if _, err := tx.ExecContext(ctx, `DELETE FROM _metadata_tables WHERE schema_id = ? AND name = ?`,
+				schemaID, normalizedName); err != nil {
+				return fmt.Errorf("failed to replace view metadata: %w", err)
+			}
+		case err != sql.ErrNoRows:
+			return fmt.Errorf("failed to check existing view: %w", err)
+		}
+
+		query := `INSERT INTO _metadata_tables (id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions)
+		          VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, id, schemaID, normalizedName, "VIEW", comment, OwnerFromContext(ctx), "", serializeColumnDefs(columns)); err != nil {
+			return fmt.Errorf("failed to insert view metadata: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetTable(ctx, id)
+}
+
+// RegisterTable records a table created with SQL and its columns. The table
+// itself is created by the statement, so only metadata is recorded; an
+// existing table registration with the same name is replaced.
+func (r *Repository) RegisterTable(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error) {
+	if name == "" {
+		return nil, fmt.Errorf("table name cannot be empty")
+	}
+	normalizedName := strings.ToUpper(name)
+
+	if _, err := r.GetSchema(ctx, schemaID); err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+
+	id := uuid.New().String()
+	err := r.mgr.ExecTx(ctx, func(tx *sql.Tx) error {
+		var tableType string
+		err := tx.QueryRowContext(ctx, `SELECT table_type FROM _metadata_tables WHERE schema_id = ? AND name = ?`,
+			schemaID, normalizedName).Scan(&tableType)
+		switch {
+		case err == nil && tableType != "BASE TABLE":
+			return fmt.Errorf("%s %s already exists in schema", strings.ToLower(tableType), normalizedName)
+		case err == nil:
+			if _, err := tx.ExecContext(ctx, `DELETE FROM _metadata_tables WHERE schema_id = ? AND name = ?`,
+				schemaID, normalizedName); err != nil {
+				return fmt.Errorf("failed to replace table metadata: %w", err)
+			}
+		case err != sql.ErrNoRows:
+			return fmt.Errorf("failed to check existing table: %w", err)
+		}
+
+		query := `INSERT INTO _metadata_tables (id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions)
+		          VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, id, schemaID, normalizedName, "BASE TABLE", comment, OwnerFromContext(ctx), "", serializeColumnDefs(columns)); err != nil {
+			return fmt.Errorf("failed to insert table metadata: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetTable(ctx, id)
+}
+
 // GetTable retrieves a table by ID.
 func (r *Repository) GetTable(ctx context.Context, id string) (*Table, error) {
-	query := `SELECT id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions
+	query := `SELECT id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions, is_secure, is_materialized
 	          FROM _metadata_tables WHERE id = ?`
 
 	row := r.mgr.DB().QueryRowContext(ctx, query, id)
@@ -673,8 +852,9 @@ func (r *Repository) GetTable(ctx context.Context, id string) (*Table, error) {
 	var owner sql.NullString
 	var clusteringKey sql.NullString
 	var columnDefinitions sql.NullString
+	var secure, materialized sql.NullBool
 
-	err := row.Scan(&table.ID, &table.SchemaID, &table.Name, &table.TableType, &comment, &createdAt, &owner, &clusteringKey, &columnDefinitions)
+	err := row.Scan(&table.ID, &table.SchemaID, &table.Name, &table.TableType, &comment, &createdAt, &owner, &clusteringKey, &columnDefinitions, &secure, &materialized)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("table with ID %s not found", id)
 	}
@@ -697,13 +877,14 @@ func (r *Repository) GetTable(ctx context.Context, id string) (*Table, error) {
 	if columnDefinitions.Valid {
 		table.ColumnDefinitions = columnDefinitions.String
 	}
+	table.Secure, table.Materialized = secure.Bool, materialized.Bool
 
 	return &table, nil
 }
 
 // GetTableByName retrieves a table by schema ID and name.
 func (r *Repository) GetTableByName(ctx context.Context, schemaID, name string) (*Table, error) {
-	query := `SELECT id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions
+	query := `SELECT id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions, is_secure, is_materialized
 	          FROM _metadata_tables WHERE schema_id = ? AND name = ?`
 
 	row := r.mgr.DB().QueryRowContext(ctx, query, schemaID, strings.ToUpper(name))
@@ -714,8 +895,9 @@ func (r *Repository) GetTableByName(ctx context.Context, schemaID, name string)
 	var owner sql.NullString
 	var clusteringKey sql.NullString
 	var columnDefinitions sql.NullString
+	var secure, materialized sql.NullBool
 
-	err := row.Scan(&table.ID, &table.SchemaID, &table.Name, &table.TableType, &comment, &createdAt, &owner, &clusteringKey, &columnDefinitions)
+	err := row.Scan(&table.ID, &table.SchemaID, &table.Name, &table.TableType, &comment, &createdAt, &owner, &clusteringKey, &columnDefinitions, &secure, &materialized)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("table %s not found", name)
 	}
@@ -738,13 +920,14 @@ func (r *Repository) GetTableByName(ctx context.Context, schemaID, name string)
 	if columnDefinitions.Valid {
 		table.ColumnDefinitions = columnDefinitions.String
 	}
+	table.Secure, table.Materialized = secure.Bool, materialized.Bool
 
 	return &table, nil
 }
 
 // ListTables retrieves all tables in a schema.
 func (r *Repository) ListTables(ctx context.Context, schemaID string) ([]*Table, error) {
-	query := `SELECT id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions
+	query := `SELECT id, schema_id, name, table_type, comment, created_at, owner, clustering_key, column_definitions, is_secure, is_materialized
 	          FROM _metadata_tables WHERE schema_id = ? ORDER BY name`
 
 	rows, err := r.mgr.Query(ctx, query, schemaID)
@@ -761,8 +944,9 @@ func (r *Repository) ListTables(ctx context.Context, schemaID string) ([]*Table,
 		var owner sql.NullString
 		var clusteringKey sql.NullString
 		var columnDefinitions sql.NullString
+		var secure, materialized sql.NullBool
 
-		if err := rows.Scan(&table.ID, &table.SchemaID, &table.Name, &table.TableType, &comment, &createdAt, &owner, &clusteringKey, &columnDefinitions); err != nil {
+		if err := rows.Scan(&table.ID, &table.SchemaID, &table.Name, &table.TableType, &comment, &createdAt, &owner, &clusteringKey, &columnDefinitions, &secure, &materialized); err != nil {
 			return nil, fmt.Errorf("failed to scan table: %w", err)
 		}
 
@@ -781,6 +965,7 @@ func (r *Repository) ListTables(ctx context.Context, schemaID string) ([]*Table,
 		if columnDefinitions.Valid {
 			table.ColumnDefinitions = columnDefinitions.String
 		}
+		table.Secure, table.Materialized = secure.Bool, materialized.Bool
 
 		tables = append(tables, &table)
 	}
@@ -814,10 +999,13 @@ func (r *Repository) DropTable(ctx context.Context, id string) error {
 	// Execute table drop in a transaction for atomicity
 	fullyQualifiedName := fmt.Sprintf("%s.%s_%s", db.Name, schema.Name, table.Name)
 	err = r.mgr.ExecTx(ctx, func(tx *sql.Tx) error {
-		// Drop DuckDB table with schema prefix
-		dropTableSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", fullyQualifiedName)
-		if _, err := tx.ExecContext(ctx, dropTableSQL); err != nil {
-			return fmt.Errorf("failed to drop DuckDB table: %w", err)
+		// Drop DuckDB table with schema prefix. Views are dropped by the
+		// statement that drops them, so only their metadata is removed.
+		if table.TableType != "VIEW" {
+			dropTableSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", fullyQualifiedName)
+			if _, err := tx.ExecContext(ctx, dropTableSQL); err != nil {
+				return fmt.Errorf("failed to drop DuckDB table: %w", err)
+			}
 		}
 
 		// Delete metadata
@@ -862,6 +1050,50 @@ func (r *Repository) UpdateTableComment(ctx context.Context, id, comment string)
 	return nil
 }
 
+// UpdateTableDefinition updates the name and columns of a table, such as after
+// an ALTER TABLE.
+func (r *Repository) UpdateTableDefinition(ctx context.Context, id, name string, columns []ColumnDef) error {
+	if name == "" {
+		return fmt.Errorf("table name cannot be empty")
+	}
+	query := `UPDATE _metadata_tables SET name = ?, column_definitions = ? WHERE id = ?`
+	result, err := r.mgr.Exec(ctx, query, strings.ToUpper(name), serializeColumnDefs(columns), id)
+	if err != nil {
+		return fmt.Errorf("failed to update table definition: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("table with ID %s not found", id)
+	}
+
+	return nil
+}
+
+// SetViewFlags records whether a view is secure and whether it is materialized.
+func (r *Repository) SetViewFlags(ctx context.Context, id string, secure, materialized bool) error {
+	query := `UPDATE _metadata_tables SET is_secure = ?, is_materialized = ? WHERE id = ? AND table_type = 'VIEW'`
+	result, err := r.mgr.Exec(ctx, query, secure, materialized, id)
+	if err != nil {
+		return fmt.Errorf("failed to update view flags: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("view with ID %s not found", id)
+	}
+
+	return nil
+}
+
 // serializeColumnDefs converts column definitions to a simple string format.
 // For simplicity, we use a basic format: name:type:nullable:primarykey;...
 func serializeColumnDefs(columns []ColumnDef) string {
@@ -902,7 +1134,7 @@ func (r *Repository) CreateStage(ctx context.Context, schemaID, name, stageType,
 
 	query := `INSERT INTO _metadata_stages (id, schema_id, name, stage_type, url, comment, created_at, owner)
 	          VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
-	_, err := r.mgr.Exec(ctx, query, id, schemaID, normalizedName, stageType, url, comment, "")
+	_, err := r.mgr.Exec(ctx, query, id, schemaID, normalizedName, stageType, url, comment, OwnerFromContext(ctx))
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Constraint Error") {
 			return nil, fmt.Errorf("stage %s already exists", normalizedName)
@@ -1061,7 +1293,7 @@ func (r *Repository) CreateFileFormat(ctx context.Context, schemaID, name, forma
 
 	query := `INSERT INTO _metadata_fileformats (id, schema_id, name, format_type, options, comment, created_at, owner)
 	          VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)`
-	_, err := r.mgr.Exec(ctx, query, id, schemaID, normalizedName, formatType, options, comment, "")
+	_, err := r.mgr.Exec(ctx, query, id, schemaID, normalizedName, formatType, options, comment, OwnerFromContext(ctx))
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE") || strings.Contains(err.Error(), "Constraint Error") {
 			return nil, fmt.Errorf("file format %s already exists", normalizedName)
@@ -1209,8 +1441,12 @@ func (r *Repository) DropFileFormat(ctx context.Context, id string) error {
 
 // Query History Operations
 
-// RecordQueryStart records the start of a query execution.
+// RecordQueryStart records the start of a query execution. A read-only
+// repository records nothing and returns a nil entry.
 func (r *Repository) RecordQueryStart(ctx context.Context, sessionID, queryID, sqlText string) (*QueryHistoryEntry, error) {
+	if r.readOnly {
+		return nil, nil
+	}
 	id := uuid.New().String()
 	now := time.Now()
 
@@ -1259,14 +1495,33 @@ func (r *Repository) RecordQueryFailure(ctx context.Context, id string, errorMes
 	return nil
 }
 
+// RecordQueryLabels records the QUERY_TAG and labels a query was submitted
+// with.
+func (r *Repository) RecordQueryLabels(ctx context.Context, id, queryTag string, labels map[string]string) error {
+	var encoded interface{}
+	if len(labels) > 0 {
+		data, err := json.Marshal(labels)
+		if err != nil {
+			return fmt.Errorf("failed to encode query labels: %w", err)
+		}
+		encoded = string(data)
+	}
+	query := `UPDATE _metadata_query_history SET query_tag = ?, labels = ? WHERE id = ?`
+
+	if _, err := r.mgr.Exec(ctx, query, queryTag, encoded, id); err != nil {
+		return fmt.Errorf("failed to record query labels: %w", err)
+	}
+
+	return nil
+}
+
 // GetQueryHistory retrieves query history with optional limit.
 func (r *Repository) GetQueryHistory(ctx context.Context, limit int) ([]*QueryHistoryEntry, error) {
 	if limit <= 0 {
 		limit = 100 // Default limit
 	}
 
-	query := `SELECT id, session_id, query_id, sql_text, status, rows_affected,
-		execution_time_ms, error_message, started_at, completed_at
+	query := `SELECT ` + queryHistoryColumns + `
 		FROM _metadata_query_history
 		ORDER BY started_at DESC
 		LIMIT ?`
@@ -1279,34 +1534,11 @@ func (r *Repository) GetQueryHistory(ctx context.Context, limit int) ([]*QueryHi
 
 	var entries []*QueryHistoryEntry
 	for rows.Next() {
-		var entry QueryHistoryEntry
-		var sessionID, queryID, errorMessage sql.NullString
-		var completedAt sql.NullTime
-
-		err := rows.Scan(
-			&entry.ID,
-			&sessionID,
-			&queryID,
-			&entry.SQLText,
-			&entry.Status,
-			&entry.RowsAffected,
-			&entry.ExecutionTimeMs,
-			&errorMessage,
-			&entry.StartedAt,
-			&completedAt,
-		)
+		entry, err := scanQueryHistoryEntry(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan query history row: %w", err)
+			return nil, err
 		}
-
-		entry.SessionID = sessionID.String
-		entry.QueryID = queryID.String
-		entry.ErrorMessage = errorMessage.String
-		if completedAt.Valid {
-			entry.CompletedAt = &completedAt.Time
-		}
-
-		entries = append(entries, &entry)
+		entries = append(entries, entry)
 	}
 
 	return entries, nil
@@ -1318,8 +1550,7 @@ func (r *Repository) GetQueryHistoryBySession(ctx context.Context, sessionID str
 		limit = 100
 	}
 
-	query := `SELECT id, session_id, query_id, sql_text, status, rows_affected,
-		execution_time_ms, error_message, started_at, completed_at
+	query := `SELECT ` + queryHistoryColumns + `
 		FROM _metadata_query_history
 		WHERE session_id = ?
 		ORDER BY started_at DESC
@@ -1333,37 +1564,136 @@ func (r *Repository) GetQueryHistoryBySession(ctx context.Context, sessionID str
 
 	var entries []*QueryHistoryEntry
 	for rows.Next() {
-		var entry QueryHistoryEntry
-		var sessionIDVal, queryID, errorMessage sql.NullString
-		var completedAt sql.NullTime
-
-		err := rows.Scan(
-			&entry.ID,
-			&sessionIDVal,
-			&queryID,
-			&entry.SQLText,
-			&entry.Status,
-			&entry.RowsAffected,
-			&entry.ExecutionTimeMs,
-			&errorMessage,
-			&entry.StartedAt,
-			&completedAt,
-		)
+		entry, err := scanQueryHistoryEntry(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan query history row: %w", err)
+			return nil, err
 		}
+		entries = append(entries, entry)
+	}
 
-		entry.SessionID = sessionIDVal.String
-		entry.QueryID = queryID.String
-		entry.ErrorMessage = errorMessage.String
-		if completedAt.Valid {
-			entry.CompletedAt = &completedAt.Time
+	return entries, nil
+}
+
+// FindQueryHistory retrieves the query history entries the filter selects,
+// most recent first.
+func (r *Repository) FindQueryHistory(ctx context.Context, filter QueryHistoryFilter) ([]*QueryHistoryEntry, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.SessionID != "" {
+		conditions = append(conditions, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if filter.QueryID != "" {
+		conditions = append(conditions, "query_id = ?")
+		args = append(args, filter.QueryID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, strings.ToUpper(filter.Status))
+	}
+	if filter.SQLContains != "" {
+		conditions = append(conditions, "strpos(lower(sql_text), lower(?)) > 0")
+		args = append(args, filter.SQLContains)
+	}
+	if !filter.StartedAfter.IsZero() {
+		conditions = append(conditions, "started_at > ?")
+		args = append(args, filter.StartedAfter)
+	}
+	if !filter.StartedBefore.IsZero() {
+		conditions = append(conditions, "started_at < ?")
+		args = append(args, filter.StartedBefore)
+	}
+	if filter.QueryTag != "" {
+		conditions = append(conditions, "query_tag = ?")
+		args = append(args, filter.QueryTag)
+	}
+	for key, value := range filter.Labels {
+		conditions = append(conditions, "json_extract_string(labels, ?) = ?")
+		args = append(args, labelPath(key), value)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := `SELECT ` + queryHistoryColumns + `
+		FROM _metadata_query_history
+		` + where + `
+		ORDER BY started_at DESC
+		LIMIT ?`
+
+	rows, err := r.mgr.Query(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*QueryHistoryEntry
+	for rows.Next() {
+		entry, err := scanQueryHistoryEntry(rows)
+		if err != nil {
+			return nil, err
 		}
+		entries = append(entries, entry)
+	}
 
-		entries = append(entries, &entry)
+	return entries, rows.Err()
+}
+
+// queryHistoryColumns are the columns scanQueryHistoryEntry scans.
+const queryHistoryColumns = `id, session_id, query_id, sql_text, status, rows_affected,
+		execution_time_ms, error_message, started_at, completed_at, query_tag, labels`
+
+// scanQueryHistoryEntry scans a query history row selected with
+// queryHistoryColumns.
+func scanQueryHistoryEntry(rows *sql.Rows) (*QueryHistoryEntry, error) {
+	var entry QueryHistoryEntry
+	var sessionID, queryID, errorMessage, queryTag, labels sql.NullString
+	var completedAt sql.NullTime
+
+	err := rows.Scan(
+		&entry.ID,
+		&sessionID,
+		&queryID,
+		&entry.SQLText,
+		&entry.Status,
+		&entry.RowsAffected,
+		&entry.ExecutionTimeMs,
+		&errorMessage,
+		&entry.StartedAt,
+		&completedAt,
+		&queryTag,
+		&labels,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan query history row: %w", err)
 	}
 
-	return entries, nil
+	entry.SessionID = sessionID.String
+	entry.QueryID = queryID.String
+	entry.ErrorMessage = errorMessage.String
+	entry.QueryTag = queryTag.String
+	if completedAt.Valid {
+		entry.CompletedAt = &completedAt.Time
+	}
+	if labels.Valid {
+		if err := json.Unmarshal([]byte(labels.String), &entry.Labels); err != nil {
+			return nil, fmt.Errorf("failed to decode query labels: %w", err)
+		}
+	}
+	return &entry, nil
+}
+
+// labelPath returns the JSON path of a label in the labels column, quoting
+// the key so that any characters may appear in it.
+func labelPath(key string) string {
+	quoted, _ := json.Marshal(key)
+	return "$." + string(quoted)
 }
 
 // ClearQueryHistory removes old query history entries.
@@ -3,6 +3,7 @@ package metadata
 import (
 	"context"
 	"database/sql"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -482,3 +483,46 @@ func TestRepository_GetQueryHistory_Limit(t *testing.T) {
 		t.Errorf("expected 5 entries with default limit, got %d", len(history))
 	}
 }
+
+// TestRepository_ReadOnly tests a repository over a seeded database opened
+// read-only.
+func TestRepository_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seed.duckdb")
+
+	seed, err := connection.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	seedRepo, err := NewRepository(connection.NewManager(seed))
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	if _, err := seedRepo.CreateDatabase(ctx, "SEEDED_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	if err := seed.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	db, err := connection.Open(path, connection.WithReadOnly())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := NewRepository(connection.NewManager(db)); err == nil {
+		t.Fatal("NewRepository() over a read-only database succeeded without WithReadOnly")
+	}
+	repo, err := NewRepository(connection.NewManager(db), WithReadOnly())
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	if _, err := repo.GetDatabaseByName(ctx, "SEEDED_DB"); err != nil {
+		t.Errorf("GetDatabaseByName() error = %v", err)
+	}
+	entry, err := repo.RecordQueryStart(ctx, "1", "query-1", "SELECT 1")
+	if entry != nil || err != nil {
+		t.Errorf("RecordQueryStart() = %v, %v, want nil, nil", entry, err)
+	}
+}
@@ -0,0 +1,72 @@
+package metadata
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the metadata storage used by the executor, stage manager, and HTTP handlers.
+//
+// Repository is the DuckDB-backed implementation used by the emulator.
+// MemoryStore keeps metadata in memory for fast unit tests that do not need a database.
+type Store interface {
+	// Databases
+	CreateDatabase(ctx context.Context, name, comment string) (*Database, error)
+	GetDatabase(ctx context.Context, id string) (*Database, error)
+	GetDatabaseByName(ctx context.Context, name string) (*Database, error)
+	ListDatabases(ctx context.Context) ([]*Database, error)
+	DropDatabase(ctx context.Context, id string) error
+	UpdateDatabaseComment(ctx context.Context, id, comment string) error
+
+	// Schemas
+	CreateSchema(ctx context.Context, databaseID, name, comment string) (*Schema, error)
+	GetSchema(ctx context.Context, id string) (*Schema, error)
+	GetSchemaByName(ctx context.Context, databaseID, name string) (*Schema, error)
+	ListSchemas(ctx context.Context, databaseID string) ([]*Schema, error)
+	DropSchema(ctx context.Context, id string) error
+
+	// Tables
+	CreateTable(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error)
+	GetTable(ctx context.Context, id string) (*Table, error)
+	GetTableByName(ctx context.Context, schemaID, name string) (*Table, error)
+	ListTables(ctx context.Context, schemaID string) ([]*Table, error)
+	DropTable(ctx context.Context, id string) error
+	UpdateTableComment(ctx context.Context, id, comment string) error
+	UpdateTableDefinition(ctx context.Context, id, name string, columns []ColumnDef) error
+	RegisterTable(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error)
+	CreateView(ctx context.Context, schemaID, name string, columns []ColumnDef, comment string) (*Table, error)
+	SetViewFlags(ctx context.Context, id string, secure, materialized bool) error
+
+	// Catalog
+	GetCatalog(ctx context.Context) ([]*CatalogDatabase, error)
+
+	// Stages
+	CreateStage(ctx context.Context, schemaID, name, stageType, url, comment string) (*Stage, error)
+	GetStage(ctx context.Context, id string) (*Stage, error)
+	GetStageByName(ctx context.Context, schemaID, name string) (*Stage, error)
+	ListStages(ctx context.Context, schemaID string) ([]*Stage, error)
+	DropStage(ctx context.Context, id string) error
+
+	// File formats
+	CreateFileFormat(ctx context.Context, schemaID, name, formatType, options, comment string) (*FileFormat, error)
+	GetFileFormat(ctx context.Context, id string) (*FileFormat, error)
+	GetFileFormatByName(ctx context.Context, schemaID, name string) (*FileFormat, error)
+	ListFileFormats(ctx context.Context, schemaID string) ([]*FileFormat, error)
+	DropFileFormat(ctx context.Context, id string) error
+
+	// Query history
+	RecordQueryStart(ctx context.Context, sessionID, queryID, sqlText string) (*QueryHistoryEntry, error)
+	RecordQuerySuccess(ctx context.Context, id string, rowsAffected int64, executionTimeMs int64) error
+	RecordQueryFailure(ctx context.Context, id string, errorMessage string, executionTimeMs int64) error
+	RecordQueryLabels(ctx context.Context, id, queryTag string, labels map[string]string) error
+	GetQueryHistory(ctx context.Context, limit int) ([]*QueryHistoryEntry, error)
+	GetQueryHistoryBySession(ctx context.Context, sessionID string, limit int) ([]*QueryHistoryEntry, error)
+	FindQueryHistory(ctx context.Context, filter QueryHistoryFilter) ([]*QueryHistoryEntry, error)
+	ClearQueryHistory(ctx context.Context, olderThan time.Time) (int64, error)
+}
+
+// Compile-time checks that both implementations satisfy Store.
+var (
+	_ Store = (*Repository)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
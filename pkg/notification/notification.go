@@ -0,0 +1,94 @@
+// Package notification provides the sinks that receive the emails sent with
+// SYSTEM$SEND_EMAIL: a webhook receiving each email as JSON, and an SMTP
+// server such as a local Mailpit or MailHog.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email is an email sent through a notification integration.
+type Email struct {
+	Integration string   `json:"integration"`
+	Recipients  []string `json:"recipients"`
+	Subject     string   `json:"subject"`
+	Body        string   `json:"body"`
+	// ContentType is text/plain or text/html.
+	ContentType string    `json:"contentType"`
+	SentAt      time.Time `json:"sentAt"`
+}
+
+// Sink receives the emails sent by the emulator.
+type Sink interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// WebhookSink is a Sink posting each email as JSON to a URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a WebhookSink posting to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, email Email) error {
+	body, err := json.Marshal(email)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTPSink is a Sink delivering emails to an SMTP server without
+// authentication, as local test servers accept them.
+type SMTPSink struct {
+	addr string
+	from string
+}
+
+// NewSMTPSink creates an SMTPSink delivering to the server at addr, such as
+// localhost:1025, with from as the sender.
+func NewSMTPSink(addr, from string) *SMTPSink {
+	return &SMTPSink{addr: addr, from: from}
+}
+
+// Send implements Sink.
+func (s *SMTPSink) Send(_ context.Context, email Email) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.Recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", email.Subject)
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=UTF-8\r\n", email.ContentType)
+	msg.WriteString("\r\n")
+	msg.WriteString(email.Body)
+
+	if err := smtp.SendMail(s.addr, nil, s.from, email.Recipients, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email through %s: %w", s.addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var received Email
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode email: %v", err)
+		}
+		if received.Subject == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	sink := NewWebhookSink(server.URL)
+	email := Email{
+		Integration: "OPS_EMAIL",
+		Recipients:  []string{"ops@example.com"},
+		Subject:     "High reading",
+		Body:        "Sensor a read 120",
+		ContentType: "text/plain",
+		SentAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := sink.Send(context.Background(), email); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if diff := cmp.Diff(email, received); diff != "" {
+		t.Errorf("received email mismatch (-want +got):\n%s", diff)
+	}
+
+	email.Subject = "fail"
+	if err := sink.Send(context.Background(), email); err == nil {
+		t.Error("Send() to a failing webhook succeeded")
+	}
+}
@@ -0,0 +1,214 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// accountUsageSchema is the schema of the SNOWFLAKE database holding the
+// account usage views.
+const accountUsageSchema = "ACCOUNT_USAGE"
+
+// accountUsageHistoryLimit is the most recent queries QUERY_HISTORY lists.
+const accountUsageHistoryLimit = 10000
+
+// accountUsageViews are the SNOWFLAKE.ACCOUNT_USAGE views the emulator serves
+// from the metadata store, the query history, and the warehouse manager, with
+// Snowflake's columns. IDs are the metadata store's IDs rather than numbers.
+var accountUsageViews = map[string][]informationSchemaColumn{
+	"QUERY_HISTORY": {
+		{"QUERY_ID", "VARCHAR"}, {"QUERY_TEXT", "VARCHAR"}, {"DATABASE_NAME", "VARCHAR"}, {"SCHEMA_NAME", "VARCHAR"},
+		{"QUERY_TYPE", "VARCHAR"}, {"SESSION_ID", "VARCHAR"}, {"USER_NAME", "VARCHAR"}, {"ROLE_NAME", "VARCHAR"},
+		{"WAREHOUSE_NAME", "VARCHAR"}, {"WAREHOUSE_SIZE", "VARCHAR"}, {"QUERY_TAG", "VARCHAR"},
+		{"EXECUTION_STATUS", "VARCHAR"}, {"ERROR_CODE", "VARCHAR"}, {"ERROR_MESSAGE", "VARCHAR"},
+		{"START_TIME", "TIMESTAMP"}, {"END_TIME", "TIMESTAMP"}, {"TOTAL_ELAPSED_TIME", "BIGINT"},
+		{"EXECUTION_TIME", "BIGINT"}, {"BYTES_SCANNED", "BIGINT"}, {"ROWS_PRODUCED", "BIGINT"},
+		{"CREDITS_USED_CLOUD_SERVICES", "DOUBLE"},
+	},
+	"TABLES": {
+		{"TABLE_ID", "VARCHAR"}, {"TABLE_NAME", "VARCHAR"}, {"TABLE_SCHEMA_ID", "VARCHAR"}, {"TABLE_SCHEMA", "VARCHAR"},
+		{"TABLE_CATALOG_ID", "VARCHAR"}, {"TABLE_CATALOG", "VARCHAR"}, {"TABLE_OWNER", "VARCHAR"},
+		{"TABLE_TYPE", "VARCHAR"}, {"IS_TRANSIENT", "VARCHAR"}, {"CLUSTERING_KEY", "VARCHAR"},
+		{"ROW_COUNT", "BIGINT"}, {"BYTES", "BIGINT"}, {"RETENTION_TIME", "BIGINT"},
+		{"CREATED", "TIMESTAMP"}, {"LAST_ALTERED", "TIMESTAMP"}, {"LAST_DDL", "TIMESTAMP"},
+		{"DELETED", "TIMESTAMP"}, {"AUTO_CLUSTERING_ON", "VARCHAR"}, {"COMMENT", "VARCHAR"},
+	},
+	"DATABASES": {
+		{"DATABASE_ID", "VARCHAR"}, {"DATABASE_NAME", "VARCHAR"}, {"DATABASE_OWNER", "VARCHAR"},
+		{"IS_TRANSIENT", "VARCHAR"}, {"COMMENT", "VARCHAR"}, {"CREATED", "TIMESTAMP"},
+		{"LAST_ALTERED", "TIMESTAMP"}, {"DELETED", "TIMESTAMP"}, {"RETENTION_TIME", "BIGINT"},
+		{"TYPE", "VARCHAR"},
+	},
+	"WAREHOUSE_METERING_HISTORY": {
+		{"START_TIME", "TIMESTAMP"}, {"END_TIME", "TIMESTAMP"}, {"WAREHOUSE_ID", "VARCHAR"},
+		{"WAREHOUSE_NAME", "VARCHAR"}, {"CREDITS_USED", "DOUBLE"}, {"CREDITS_USED_COMPUTE", "DOUBLE"},
+		{"CREDITS_USED_CLOUD_SERVICES", "DOUBLE"},
+	},
+}
+
+// isAccountUsageView reports whether the parts of a name, as written, name an
+// emulated SNOWFLAKE.ACCOUNT_USAGE view.
+func isAccountUsageView(parts []string) bool {
+	if len(parts) != 3 {
+		return false
+	}
+	_, ok := accountUsageViews[strings.ToUpper(unquoteIdentifier(parts[2]))]
+	return ok && strings.EqualFold(unquoteIdentifier(parts[0]), "SNOWFLAKE") &&
+		strings.EqualFold(unquoteIdentifier(parts[1]), accountUsageSchema)
+}
+
+// accountUsageObject renders a SNOWFLAKE.ACCOUNT_USAGE view as a subquery. It
+// reports false if the view's rows cannot be listed, in which case the name is
+// kept.
+func (e *Executor) accountUsageObject(ctx context.Context, view string) (string, bool) {
+	view = strings.ToUpper(unquoteIdentifier(view))
+	var rows [][]interface{}
+	var err error
+	switch view {
+	case "QUERY_HISTORY":
+		rows, err = e.accountUsageQueryHistory(ctx)
+	case "TABLES":
+		rows, err = e.accountUsageTables(ctx)
+	case "DATABASES":
+		rows, err = e.accountUsageDatabases(ctx)
+	case "WAREHOUSE_METERING_HISTORY":
+		rows, err = e.accountUsageWarehouseMetering(ctx)
+	}
+	if err != nil {
+		log.Printf("Failed to list ACCOUNT_USAGE.%s: %v", view, err)
+		return "", false
+	}
+	return virtualViewSubquery(accountUsageViews[view], rows), true
+}
+
+// accountUsageQueryHistory lists the rows of ACCOUNT_USAGE.QUERY_HISTORY: the
+// most recent queries of the query history. Failed and canceled queries have
+// the EXECUTION_STATUS FAIL, and running ones RUNNING.
+func (e *Executor) accountUsageQueryHistory(ctx context.Context) ([][]interface{}, error) {
+	entries, err := e.repo.FindQueryHistory(ctx, metadata.QueryHistoryFilter{Limit: accountUsageHistoryLimit})
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		status := entry.Status
+		if status == "FAILED" || status == "CANCELED" {
+			status = "FAIL"
+		}
+		var end interface{}
+		if entry.CompletedAt != nil {
+			end = *entry.CompletedAt
+		}
+		rows = append(rows, []interface{}{
+			entry.QueryID, entry.SQLText, nil, nil, queryType(entry.SQLText), nullIfEmpty(entry.SessionID), nil, nil,
+			nil, nil, entry.QueryTag, status, nil, nullIfEmpty(entry.ErrorMessage),
+			entry.StartedAt, end, entry.ExecutionTimeMs, entry.ExecutionTimeMs, int64(0), entry.RowsAffected, float64(0),
+		})
+	}
+	return rows, nil
+}
+
+// queryType returns the QUERY_TYPE of a statement, e.g. SELECT or
+// CREATE_TABLE, or UNKNOWN if it has no keywords.
+func queryType(sql string) string {
+	keywords := statementKeywords(sql)
+	if len(keywords) == 0 {
+		return "UNKNOWN"
+	}
+	switch keywords[0] {
+	case "CREATE", "ALTER", "DROP":
+		for _, keyword := range keywords[1:] {
+			if keyword != "OR" && keyword != "REPLACE" && !createModifiers[keyword] {
+				return keywords[0] + "_" + keyword
+			}
+		}
+	case "WITH":
+		return "SELECT"
+	}
+	return keywords[0]
+}
+
+// accountUsageTables lists the rows of ACCOUNT_USAGE.TABLES: the tables and
+// views of every database, like INFORMATION_SCHEMA.TABLES does for one.
+func (e *Executor) accountUsageTables(ctx context.Context) ([][]interface{}, error) {
+	databases, err := e.repo.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]interface{}
+	for _, db := range databases {
+		schemaIDs := map[string]interface{}{}
+		schemaID := func(schema string) interface{} {
+			id, ok := schemaIDs[schema]
+			if !ok {
+				if registered, err := e.repo.GetSchemaByName(ctx, db.ID, schema); err == nil {
+					id = registered.ID
+				}
+				schemaIDs[schema] = id
+			}
+			return id
+		}
+		err := e.eachInformationSchemaObject(ctx, db, func(schema string, table *schemaTable, view *schemaView) error {
+			var id interface{}
+			switch {
+			case table != nil && table.Registered != nil:
+				id = table.Registered.ID
+			case view != nil && view.Registered != nil:
+				id = view.Registered.ID
+			}
+			info := informationSchemaTableRow(db, schema, table, view)
+			rows = append(rows, []interface{}{
+				id, info[2], schemaID(schema), schema, db.ID, db.Name, info[3],
+				info[4], info[5], info[6], info[7], info[8], info[9],
+				info[12], info[13], info[14], nil, info[15], info[16],
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// accountUsageDatabases lists the rows of ACCOUNT_USAGE.DATABASES.
+func (e *Executor) accountUsageDatabases(ctx context.Context) ([][]interface{}, error) {
+	databases, err := e.repo.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, 0, len(databases))
+	for _, db := range databases {
+		created := nullIfZero(db.CreatedAt)
+		rows = append(rows, []interface{}{
+			db.ID, db.Name, nullIfEmpty(db.Owner), "NO", nullIfEmpty(db.Comment), created, created, nil, int64(1), "STANDARD",
+		})
+	}
+	return rows, nil
+}
+
+// accountUsageWarehouseMetering lists the rows of
+// ACCOUNT_USAGE.WAREHOUSE_METERING_HISTORY. The emulator meters nothing, so
+// every warehouse has a row for the current hour that used no credits.
+func (e *Executor) accountUsageWarehouseMetering(ctx context.Context) ([][]interface{}, error) {
+	if e.warehouses == nil {
+		return nil, nil
+	}
+	warehouses, err := e.warehouses.ListWarehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list warehouses: %w", err)
+	}
+	start := time.Now().UTC().Truncate(time.Hour)
+	rows := make([][]interface{}, 0, len(warehouses))
+	for _, wh := range warehouses {
+		rows = append(rows, []interface{}{
+			start, start.Add(time.Hour), wh.ID, wh.Name, float64(0), float64(0), float64(0),
+		})
+	}
+	return rows, nil
+}
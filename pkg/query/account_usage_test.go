@@ -0,0 +1,89 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
+)
+
+// TestExecutor_AccountUsage tests that the SNOWFLAKE.ACCOUNT_USAGE views list
+// the query history and the objects of every database.
+func TestExecutor_AccountUsage(t *testing.T) {
+	warehouses := warehouse.NewManager()
+	if _, err := warehouses.CreateWarehouse(context.Background(), "COMPUTE_WH", "", ""); err != nil {
+		t.Fatalf("CreateWarehouse() error = %v", err)
+	}
+	executor, _ := setupTestExecutor(t, WithWarehouseManager(warehouses))
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		"CREATE DATABASE SALES COMMENT = 'Sales data'",
+		"CREATE DATABASE MARKETING",
+		"CREATE SCHEMA SALES.RAW",
+		"CREATE SCHEMA MARKETING.WEB",
+		"CREATE TABLE SALES.RAW.ORDERS (ID NUMBER)",
+		"INSERT INTO SALES.RAW.ORDERS VALUES (1), (2)",
+		"CREATE VIEW SALES.RAW.ORDER_IDS AS SELECT ID FROM SALES.RAW.ORDERS",
+		"CREATE TABLE MARKETING.WEB.CAMPAIGNS (NAME VARCHAR)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.QueryWithHistory(ctx, "1", "query-1", "SELECT COUNT(*) FROM SALES.RAW.ORDERS"); err != nil {
+		t.Fatalf("QueryWithHistory() error = %v", err)
+	}
+	if _, err := executor.QueryWithHistory(ctx, "1", "query-2", "SELECT * FROM missing_table"); err == nil {
+		t.Fatal("QueryWithHistory() of a missing table error = nil, want error")
+	}
+
+	tests := []struct {
+		name string
+		sql  string
+		want [][]interface{}
+	}{
+		{
+			name: "QueryHistory",
+			sql: `SELECT QUERY_ID, QUERY_TYPE, EXECUTION_STATUS, ROWS_PRODUCED, ERROR_MESSAGE IS NOT NULL
+				FROM SNOWFLAKE.ACCOUNT_USAGE.QUERY_HISTORY WHERE QUERY_ID LIKE 'query-%' ORDER BY QUERY_ID`,
+			want: [][]interface{}{
+				{"query-1", "SELECT", "SUCCESS", int64(1), false},
+				{"query-2", "SELECT", "FAIL", int64(0), true},
+			},
+		},
+		{
+			name: "Tables",
+			sql: `SELECT t.TABLE_CATALOG, t.TABLE_SCHEMA, t.TABLE_NAME, t.TABLE_TYPE, t.ROW_COUNT, t.DELETED
+				FROM snowflake.account_usage.tables t ORDER BY t.TABLE_CATALOG, t.TABLE_NAME`,
+			want: [][]interface{}{
+				{"MARKETING", "WEB", "CAMPAIGNS", "BASE TABLE", int64(0), nil},
+				{"SALES", "RAW", "ORDERS", "BASE TABLE", int64(2), nil},
+				{"SALES", "RAW", "ORDER_IDS", "VIEW", nil, nil},
+			},
+		},
+		{
+			name: "Databases",
+			sql:  "SELECT DATABASE_NAME, COMMENT, TYPE FROM SNOWFLAKE.ACCOUNT_USAGE.DATABASES ORDER BY DATABASE_NAME",
+			want: [][]interface{}{{"MARKETING", nil, "STANDARD"}, {"SALES", "Sales data", "STANDARD"}},
+		},
+		{
+			name: "WarehouseMeteringHistory",
+			sql:  "SELECT WAREHOUSE_NAME, SUM(CREDITS_USED) FROM SNOWFLAKE.ACCOUNT_USAGE.WAREHOUSE_METERING_HISTORY GROUP BY WAREHOUSE_NAME",
+			want: [][]interface{}{{"COMPUTE_WH", float64(0)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, result.Rows); diff != "" {
+				t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Alert states, as SHOW ALERTS reports them. Alerts are created suspended.
+const (
+	alertSuspended = "suspended"
+	alertStarted   = "started"
+)
+
+// alert is an alert created with CREATE ALERT.
+type alert struct {
+	Database, Schema, Name string
+	Owner                  string
+	Warehouse              string
+	// Schedule is the alert's SCHEDULE. It is recorded but not run.
+	Schedule string
+	Comment  string
+	// Condition is the query of IF (EXISTS (...)); the alert fires when it
+	// returns a row.
+	Condition string
+	// Action is the statement run when the alert fires.
+	Action    string
+	State     string
+	CreatedOn time.Time
+}
+
+// alertRegistry holds the alerts, keyed by their qualified name.
+type alertRegistry struct {
+	mu     sync.Mutex
+	byName map[string]*alert
+}
+
+// showAlertsNames are the columns of a SHOW ALERTS result.
+var showAlertsNames = []string{
+	"created_on", "name", "database_name", "schema_name", "owner", "comment",
+	"warehouse", "schedule", "state", "condition", "action",
+}
+
+// parseAlert parses the clauses of a CREATE ALERT statement:
+//
+//	CREATE ALERT name WAREHOUSE = wh SCHEDULE = '1 MINUTE'
+//	IF (EXISTS (condition)) THEN action
+func parseAlert(ctx context.Context, stmt *createStatement) (*alert, error) {
+	database, schema, name := splitObjectName(ctx, stmt.Name)
+	a := &alert{
+		Database: strings.ToUpper(database),
+		Schema:   strings.ToUpper(schema),
+		Name:     strings.ToUpper(name),
+		Owner:    SessionInfoFromContext(ctx).Role,
+		State:    alertSuspended,
+	}
+	if stmt.Comment != nil {
+		a.Comment = *stmt.Comment
+	}
+
+	s := strings.TrimRight(stmt.SQL, "; \t\r\n")
+	i := indexFold(s, "ALERT") + len("ALERT")
+	i += strings.Index(s[i:], stmt.Name) + len(stmt.Name)
+	rest := s[i:]
+
+	at := topLevelKeyword(rest, "IF")
+	if at < 0 {
+		return nil, fmt.Errorf("alert %s has no IF (EXISTS (...)) condition", stmt.Name)
+	}
+	header := rest[:at]
+	if value, ok := objectProperty(header, "WAREHOUSE"); ok {
+		a.Warehouse = strings.ToUpper(value)
+	}
+	if value, ok := objectProperty(header, "SCHEDULE"); ok {
+		a.Schedule = value
+	}
+
+	condition := strings.TrimSpace(rest[at+len("IF"):])
+	end := matchingParen(condition)
+	if !strings.HasPrefix(condition, "(") || end < 0 {
+		return nil, fmt.Errorf("alert %s has no IF (EXISTS (...)) condition", stmt.Name)
+	}
+	action := strings.TrimSpace(condition[end+1:])
+	condition = strings.TrimSpace(condition[1:end])
+	if !keywordAt(condition, 0, "EXISTS") {
+		return nil, fmt.Errorf("the condition of alert %s must be EXISTS (...)", stmt.Name)
+	}
+	condition = strings.TrimSpace(condition[len("EXISTS"):])
+	if !strings.HasPrefix(condition, "(") || matchingParen(condition) != len(condition)-1 {
+		return nil, fmt.Errorf("the condition of alert %s must be EXISTS (...)", stmt.Name)
+	}
+	a.Condition = strings.TrimSpace(condition[1 : len(condition)-1])
+
+	if !keywordAt(action, 0, "THEN") {
+		return nil, fmt.Errorf("alert %s has no THEN action", stmt.Name)
+	}
+	a.Action = strings.TrimSpace(action[len("THEN"):])
+	if a.Action == "" {
+		return nil, fmt.Errorf("alert %s has no THEN action", stmt.Name)
+	}
+	return a, nil
+}
+
+// objectProperty returns the value of a "NAME = value" property of a CREATE
+// statement, unquoting string literals. Lists are returned with their
+// parentheses.
+func objectProperty(header, name string) (string, bool) {
+	at := topLevelKeyword(header, name)
+	if at < 0 {
+		return "", false
+	}
+	value := strings.TrimSpace(header[at+len(name):])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	if end := matchingParen(value); strings.HasPrefix(value, "(") && end >= 0 {
+		return value[:end+1], true
+	}
+	if strings.HasPrefix(value, "'") {
+		end := skipQuoted(value, 0, '\'')
+		if end <= 0 {
+			return "", false
+		}
+		return strings.ReplaceAll(value[1:end], "''", "'"), true
+	}
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return fields[0], true
+	}
+	return "", false
+}
+
+// executeCreateAlert handles CREATE ALERT by registering the alert with the
+// executor.
+func (e *Executor) executeCreateAlert(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	a, err := parseAlert(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	a.CreatedOn = time.Now()
+	name := qualifiedName(ctx, stmt.Name)
+
+	e.alerts.mu.Lock()
+	defer e.alerts.mu.Unlock()
+	if _, exists := e.alerts.byName[name]; exists {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case !stmt.OrReplace:
+			return nil, fmt.Errorf("alert %s already exists", name)
+		}
+	}
+	if e.alerts.byName == nil {
+		e.alerts.byName = make(map[string]*alert)
+	}
+	e.alerts.byName[name] = a
+	return &ExecResult{}, nil
+}
+
+// alertStatement is a parsed EXECUTE ALERT, ALTER ALERT, or DROP ALERT statement.
+type alertStatement struct {
+	// Kind is EXECUTE, RESUME, SUSPEND, or DROP.
+	Kind     string
+	Name     string
+	IfExists bool
+}
+
+// parseAlertStatement parses EXECUTE ALERT name, ALTER ALERT [IF EXISTS] name
+// RESUME | SUSPEND, and DROP ALERT [IF EXISTS] name. It reports false for other
+// statements.
+func parseAlertStatement(sql string) (*alertStatement, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	keywords := statementKeywords(s)
+	if len(keywords) < 2 || keywords[1] != "ALERT" {
+		return nil, false, nil
+	}
+	stmt := &alertStatement{Kind: keywords[0]}
+	if stmt.Kind != "EXECUTE" && stmt.Kind != "ALTER" && stmt.Kind != "DROP" {
+		return nil, false, nil
+	}
+
+	fields := strings.Fields(s[indexFold(s, "ALERT")+len("ALERT"):])
+	if len(fields) >= 2 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") && stmt.Kind != "EXECUTE" {
+		stmt.IfExists = true
+		fields = fields[2:]
+	}
+	if len(fields) == 0 {
+		return nil, true, fmt.Errorf("%s ALERT needs an alert name", stmt.Kind)
+	}
+	stmt.Name = fields[0]
+	fields = fields[1:]
+
+	switch stmt.Kind {
+	case "ALTER":
+		if len(fields) != 1 || (!strings.EqualFold(fields[0], "RESUME") && !strings.EqualFold(fields[0], "SUSPEND")) {
+			return nil, true, fmt.Errorf("ALTER ALERT only supports RESUME and SUSPEND")
+		}
+		stmt.Kind = strings.ToUpper(fields[0])
+	default:
+		if len(fields) != 0 {
+			return nil, true, fmt.Errorf("unexpected %q after %s ALERT %s", strings.Join(fields, " "), stmt.Kind, stmt.Name)
+		}
+	}
+	return stmt, true, nil
+}
+
+// executeAlertStatement runs, resumes, suspends, or drops an alert.
+func (e *Executor) executeAlertStatement(ctx context.Context, stmt *alertStatement) (*ExecResult, error) {
+	name := qualifiedName(ctx, stmt.Name)
+
+	e.alerts.mu.Lock()
+	a, ok := e.alerts.byName[name]
+	if !ok {
+		e.alerts.mu.Unlock()
+		if stmt.IfExists {
+			return &ExecResult{}, nil
+		}
+		return nil, fmt.Errorf("alert %s does not exist", name)
+	}
+	switch stmt.Kind {
+	case "RESUME":
+		a.State = alertStarted
+	case "SUSPEND":
+		a.State = alertSuspended
+	case "DROP":
+		delete(e.alerts.byName, name)
+	}
+	condition, action := a.Condition, a.Action
+	e.alerts.mu.Unlock()
+
+	if stmt.Kind == "EXECUTE" {
+		if err := e.runAlert(ctx, name, condition, action); err != nil {
+			return nil, err
+		}
+	}
+	return &ExecResult{}, nil
+}
+
+// runAlert evaluates an alert's condition and runs its action when the
+// condition returns a row, as EXECUTE ALERT does whether the alert is started
+// or suspended.
+func (e *Executor) runAlert(ctx context.Context, name, condition, action string) error {
+	result, err := e.Query(ctx, condition)
+	if err != nil {
+		return fmt.Errorf("condition of alert %s failed: %w", name, err)
+	}
+	if len(result.Rows) == 0 {
+		return nil
+	}
+	if isScriptBlock(action) {
+		block, err := parseScriptBlock(action)
+		if err != nil {
+			return fmt.Errorf("invalid action of alert %s: %w", name, err)
+		}
+		_, err = e.runBlock(ctx, block, map[string]sessionVariable{})
+		if err != nil {
+			return fmt.Errorf("action of alert %s failed: %w", name, err)
+		}
+		return nil
+	}
+	if _, err := e.runStatement(ctx, action); err != nil {
+		return fmt.Errorf("action of alert %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// isShowAlerts reports whether sql is a SHOW ALERTS statement.
+func isShowAlerts(sql string) bool {
+	return keywordsHavePrefix(statementKeywords(sql), "SHOW", "ALERTS")
+}
+
+// queryShowAlerts lists the alerts, filtered by an optional LIKE pattern. The
+// IN clause is ignored, so alerts of all schemas are listed.
+func (e *Executor) queryShowAlerts(sql string) (*Result, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	rest := strings.TrimSpace(s[indexFold(s, "ALERTS")+len("ALERTS"):])
+	like := ""
+	if keywordAt(rest, 0, "LIKE") {
+		value, _, ok := commentValueAt(rest, len("LIKE"))
+		if !ok {
+			return nil, fmt.Errorf("SHOW ALERTS: invalid LIKE pattern")
+		}
+		like = value
+	}
+
+	timestamp := types.ColumnMetadata{Type: "timestamp_ltz", Nullable: true}
+	text := types.ColumnMetadata{Type: "text", Nullable: true}
+	result := &Result{Columns: showAlertsNames}
+	for _, name := range showAlertsNames {
+		col := text
+		if name == "created_on" {
+			col = timestamp
+		}
+		col.Name = name
+		result.ColumnTypes = append(result.ColumnTypes, col)
+	}
+
+	e.alerts.mu.Lock()
+	alerts := make([]*alert, 0, len(e.alerts.byName))
+	for _, a := range e.alerts.byName {
+		if like == "" || likePattern(like).MatchString(a.Name) {
+			alerts = append(alerts, a)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Database+"."+alerts[i].Schema+"."+alerts[i].Name < alerts[j].Database+"."+alerts[j].Schema+"."+alerts[j].Name
+	})
+	for _, a := range alerts {
+		result.Rows = append(result.Rows, []interface{}{
+			a.CreatedOn, a.Name, a.Database, a.Schema, a.Owner, a.Comment,
+			a.Warehouse, a.Schedule, a.State, a.Condition, a.Action,
+		})
+	}
+	e.alerts.mu.Unlock()
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseAlert(t *testing.T) {
+	sql := `CREATE OR REPLACE ALERT ops.late_orders
+		WAREHOUSE = compute_wh
+		SCHEDULE = 'USING CRON */5 * * * * UTC'
+		COMMENT = 'Orders waiting too long'
+		IF (EXISTS (SELECT id FROM orders WHERE status = 'late'))
+		THEN INSERT INTO notifications SELECT 'late orders', CURRENT_TIMESTAMP()`
+	stmt, ok := parseCreateStatement(sql)
+	if !ok || stmt.Kind != "ALERT" {
+		t.Fatalf("parseCreateStatement() = %+v, %v, want an alert", stmt, ok)
+	}
+	got, err := parseAlert(context.Background(), stmt)
+	if err != nil {
+		t.Fatalf("parseAlert() error = %v", err)
+	}
+	want := &alert{
+		Schema:    "OPS",
+		Name:      "LATE_ORDERS",
+		Warehouse: "COMPUTE_WH",
+		Schedule:  "USING CRON */5 * * * * UTC",
+		Comment:   "Orders waiting too long",
+		Condition: "SELECT id FROM orders WHERE status = 'late'",
+		Action:    "INSERT INTO notifications SELECT 'late orders', CURRENT_TIMESTAMP()",
+		State:     alertSuspended,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseAlert() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, invalid := range []string{
+		"CREATE ALERT a SCHEDULE = '1 MINUTE' THEN SELECT 1",
+		"CREATE ALERT a IF (SELECT 1) THEN SELECT 1",
+		"CREATE ALERT a IF (EXISTS (SELECT 1))",
+	} {
+		stmt, _ := parseCreateStatement(invalid)
+		if _, err := parseAlert(context.Background(), stmt); err == nil {
+			t.Errorf("parseAlert(%q) succeeded", invalid)
+		}
+	}
+}
+
+// TestExecutor_Alerts tests running alerts manually with EXECUTE ALERT and
+// listing them with SHOW ALERTS.
+func TestExecutor_Alerts(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		"CREATE TABLE readings (sensor VARCHAR, value INTEGER)",
+		"CREATE TABLE notifications (message VARCHAR)",
+		`CREATE ALERT high_reading WAREHOUSE = compute_wh SCHEDULE = '1 MINUTE'
+		IF (EXISTS (SELECT * FROM readings WHERE value > 100))
+		THEN INSERT INTO notifications VALUES ('high reading')`,
+		"EXECUTE ALERT high_reading",
+		"INSERT INTO readings VALUES ('a', 120)",
+		"EXECUTE ALERT high_reading",
+		"ALTER ALERT high_reading RESUME",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	notifications, err := executor.Query(ctx, "SELECT message FROM notifications")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"high reading"}}, notifications.Rows); diff != "" {
+		t.Errorf("notifications mismatch (-want +got):\n%s", diff)
+	}
+
+	show, err := executor.Query(ctx, "SHOW ALERTS LIKE 'HIGH%'")
+	if err != nil {
+		t.Fatalf("SHOW ALERTS error = %v", err)
+	}
+	if len(show.Rows) != 1 {
+		t.Fatalf("SHOW ALERTS returned %d rows, want 1", len(show.Rows))
+	}
+	got := map[string]interface{}{}
+	for i, column := range show.Columns {
+		got[column] = show.Rows[0][i]
+	}
+	for column, want := range map[string]interface{}{
+		"name": "HIGH_READING", "warehouse": "COMPUTE_WH", "schedule": "1 MINUTE", "state": alertStarted,
+		"condition": "SELECT * FROM readings WHERE value > 100",
+	} {
+		if got[column] != want {
+			t.Errorf("SHOW ALERTS %s = %v, want %v", column, got[column], want)
+		}
+	}
+
+	for _, sql := range []string{
+		"DROP ALERT high_reading",
+		"DROP ALERT IF EXISTS high_reading",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Execute(ctx, "EXECUTE ALERT high_reading"); err == nil {
+		t.Error("EXECUTE ALERT of a dropped alert succeeded")
+	}
+}
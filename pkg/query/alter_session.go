@@ -0,0 +1,133 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+)
+
+// alterSessionStatement is a parsed ALTER SESSION SET name = value [...] or
+// ALTER SESSION UNSET name [, ...].
+type alterSessionStatement struct {
+	// Set holds the values ALTER SESSION SET gives, by upper-cased parameter
+	// name.
+	Set map[string]string
+	// Unset holds the upper-cased names of the parameters ALTER SESSION UNSET
+	// resets to their defaults.
+	Unset []string
+}
+
+// parseAlterSession parses an ALTER SESSION statement. Values are string
+// literals, numbers, TRUE or FALSE, or bare words such as UTC, and pairs may
+// be separated by commas. It reports false for other statements.
+func parseAlterSession(sql string) (*alterSessionStatement, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	fields := strings.Fields(s)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "ALTER") || !strings.EqualFold(fields[1], "SESSION") {
+		return nil, false, nil
+	}
+	if len(fields) < 4 {
+		return nil, true, fmt.Errorf("invalid ALTER SESSION statement: %s", sql)
+	}
+	rest := afterFields(s, 3)
+	stmt := &alterSessionStatement{}
+
+	switch action := strings.ToUpper(fields[2]); action {
+	case "SET":
+		stmt.Set = make(map[string]string)
+		for rest != "" {
+			name, after := nextWord(rest)
+			if name == "" || !strings.HasPrefix(after, "=") {
+				return nil, true, fmt.Errorf("ALTER SESSION SET: expected name = value at '%s'", rest)
+			}
+			value, remainder, err := alterSessionValue(strings.TrimSpace(after[1:]))
+			if err != nil {
+				return nil, true, err
+			}
+			name = strings.ToUpper(name)
+			if value, err = checkParameterValue(name, value); err != nil {
+				return nil, true, err
+			}
+			stmt.Set[name] = value
+			rest = strings.TrimSpace(strings.TrimPrefix(remainder, ","))
+		}
+	case "UNSET":
+		for _, name := range strings.Split(rest, ",") {
+			name = strings.TrimSpace(name)
+			if word, remainder := nextWord(name); word == "" || remainder != "" {
+				return nil, true, fmt.Errorf("ALTER SESSION UNSET: invalid parameter name '%s'", name)
+			}
+			stmt.Unset = append(stmt.Unset, strings.ToUpper(name))
+		}
+	default:
+		return nil, true, fmt.Errorf("ALTER SESSION %s is not supported", action)
+	}
+	return stmt, true, nil
+}
+
+// alterSessionValue reads the value at the start of s, a string literal or a
+// word ending at a space or comma, and returns it with the rest of s.
+func alterSessionValue(s string) (string, string, error) {
+	if strings.HasPrefix(s, "'") {
+		value, end, ok := commentValueAt(s, 0)
+		if !ok || s[end-1] != '\'' {
+			return "", "", fmt.Errorf("ALTER SESSION SET: unterminated string at '%s'", s)
+		}
+		return value, strings.TrimSpace(s[end:]), nil
+	}
+	end := strings.IndexAny(s, " \t\r\n,")
+	if end < 0 {
+		end = len(s)
+	}
+	if end == 0 {
+		return "", "", fmt.Errorf("ALTER SESSION SET: missing value")
+	}
+	return s[:end], strings.TrimSpace(s[end:]), nil
+}
+
+// checkParameterValue checks a value given to a parameter the emulator knows
+// against the type of its default, and returns it as SHOW PARAMETERS lists
+// it. Values of other parameters are accepted as they are.
+func checkParameterValue(name, value string) (string, error) {
+	def, known := config.DefaultSessionParameters()[config.SessionParameter(name)]
+	if !known {
+		return value, nil
+	}
+	switch parameterType(def) {
+	case "NUMBER":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", fmt.Errorf("invalid value [%s] for parameter '%s'", value, name)
+		}
+	case "BOOLEAN":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value [%s] for parameter '%s'", value, name)
+		}
+		return strconv.FormatBool(b), nil
+	}
+	return value, nil
+}
+
+// executeAlterSession sets or unsets parameters of the statement's session.
+// Without a session updater or a session, the statement is checked but changes
+// nothing, as USE statements do.
+func (e *Executor) executeAlterSession(ctx context.Context, stmt *alterSessionStatement) (*ExecResult, error) {
+	if e.sessions == nil {
+		return &ExecResult{}, nil
+	}
+	id, err := strconv.ParseInt(SessionInfoFromContext(ctx).ID, 10, 64)
+	if err != nil {
+		return &ExecResult{}, nil
+	}
+	set := make(map[string]interface{}, len(stmt.Set))
+	for name, value := range stmt.Set {
+		set[name] = value
+	}
+	if err := e.sessions.AlterSessionParameters(ctx, id, set, stmt.Unset); err != nil {
+		return nil, fmt.Errorf("failed to alter session: %w", err)
+	}
+	return &ExecResult{}, nil
+}
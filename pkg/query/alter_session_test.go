@@ -0,0 +1,103 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseAlterSession tests parsing ALTER SESSION SET and UNSET statements.
+func TestParseAlterSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *alterSessionStatement
+		wantOK  bool
+		wantErr bool
+	}{
+		{
+			name:   "SetString",
+			sql:    "ALTER SESSION SET QUERY_TAG = 'nightly load';",
+			want:   &alterSessionStatement{Set: map[string]string{"QUERY_TAG": "nightly load"}},
+			wantOK: true,
+		},
+		{
+			name: "SetSeveral",
+			sql:  "alter session set timezone = 'America/Los_Angeles' week_start = 1, autocommit = FALSE",
+			want: &alterSessionStatement{Set: map[string]string{
+				"TIMEZONE": "America/Los_Angeles", "WEEK_START": "1", "AUTOCOMMIT": "false",
+			}},
+			wantOK: true,
+		},
+		{
+			name:   "SetWord",
+			sql:    "ALTER SESSION SET TIMEZONE=UTC",
+			want:   &alterSessionStatement{Set: map[string]string{"TIMEZONE": "UTC"}},
+			wantOK: true,
+		},
+		{
+			name:   "SetUnknownParameter",
+			sql:    "ALTER SESSION SET USE_CACHED_RESULT = FALSE",
+			want:   &alterSessionStatement{Set: map[string]string{"USE_CACHED_RESULT": "FALSE"}},
+			wantOK: true,
+		},
+		{
+			name:   "Unset",
+			sql:    "ALTER SESSION UNSET query_tag, TIMEZONE",
+			want:   &alterSessionStatement{Unset: []string{"QUERY_TAG", "TIMEZONE"}},
+			wantOK: true,
+		},
+		{name: "InvalidNumber", sql: "ALTER SESSION SET WEEK_START = 'monday'", wantOK: true, wantErr: true},
+		{name: "InvalidBoolean", sql: "ALTER SESSION SET AUTOCOMMIT = 2", wantOK: true, wantErr: true},
+		{name: "MissingValue", sql: "ALTER SESSION SET TIMEZONE =", wantOK: true, wantErr: true},
+		{name: "MissingEquals", sql: "ALTER SESSION SET TIMEZONE 'UTC'", wantOK: true, wantErr: true},
+		{name: "Unterminated", sql: "ALTER SESSION SET QUERY_TAG = 'x", wantOK: true, wantErr: true},
+		{name: "UnsupportedAction", sql: "ALTER SESSION RESET TIMEZONE", wantOK: true, wantErr: true},
+		{name: "AlterTable", sql: "ALTER TABLE t ADD COLUMN c INT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseAlterSession(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseAlterSession(%q) ok = %v, error = %v, want ok %v, wantErr %v", tt.sql, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseAlterSession(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_AlterSession tests that ALTER SESSION changes the parameters
+// of the statement's session.
+func TestExecutor_AlterSession(t *testing.T) {
+	sessions := &recordingSessions{}
+	executor, _ := setupTestExecutor(t, WithSessionUpdater(sessions))
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "7"})
+
+	for _, sql := range []string{"ALTER SESSION SET QUERY_TAG = 'etl'", "ALTER SESSION UNSET QUERY_TAG"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	want := [][]interface{}{
+		{int64(7), map[string]interface{}{"QUERY_TAG": "etl"}, []string(nil)},
+		{int64(7), map[string]interface{}{}, []string{"QUERY_TAG"}},
+	}
+	if diff := cmp.Diff(want, sessions.alters); diff != "" {
+		t.Errorf("session parameter changes mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without a session, the statement is checked but changes nothing
+	if _, err := executor.Execute(context.Background(), "ALTER SESSION SET TIMEZONE = 'UTC'"); err != nil {
+		t.Errorf("Execute() without a session error = %v", err)
+	}
+	if len(sessions.alters) != 2 {
+		t.Errorf("session parameter changes = %d, want 2", len(sessions.alters))
+	}
+}
@@ -0,0 +1,408 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// Kinds of alterTableAction.
+const (
+	alterAddColumn    = "ADD COLUMN"
+	alterDropColumn   = "DROP COLUMN"
+	alterSetNotNull   = "SET NOT NULL"
+	alterDropNotNull  = "DROP NOT NULL"
+	alterSetDataType  = "SET DATA TYPE"
+	alterSetDefault   = "SET DEFAULT"
+	alterDropDefault  = "DROP DEFAULT"
+	alterComment      = "COMMENT"
+	alterRenameColumn = "RENAME COLUMN"
+	alterRenameTable  = "RENAME TO"
+)
+
+// alterTableKeywords are the words that follow ADD or DROP in ALTER TABLE
+// statements that do not add or drop columns, such as ADD CONSTRAINT or
+// DROP CLUSTERING KEY. Those statements are passed to DuckDB as they are.
+var alterTableKeywords = map[string]bool{
+	"CONSTRAINT":  true,
+	"PRIMARY":     true,
+	"UNIQUE":      true,
+	"FOREIGN":     true,
+	"CHECK":       true,
+	"DATA":        true,
+	"ROW":         true,
+	"SEARCH":      true,
+	"AGGREGATION": true,
+	"PROJECTION":  true,
+	"CLUSTERING":  true,
+	"ALL":         true,
+}
+
+// alterTableStatement is an ALTER TABLE statement that changes columns or
+// renames a table. DuckDB runs one action per ALTER TABLE, so each of its
+// actions is run as a statement of its own.
+type alterTableStatement struct {
+	Table    string // Table name as written
+	IfExists bool
+	Actions  []alterTableAction
+}
+
+// alterTableAction is one change of an ALTER TABLE statement.
+type alterTableAction struct {
+	Kind string
+	// Column is the name of the changed column as written.
+	Column string
+	// IfExists is set for DROP COLUMN IF EXISTS and ADD COLUMN IF NOT EXISTS.
+	IfExists bool
+	// Definition is the column added by ADD COLUMN.
+	Definition metadata.ColumnDef
+	// Value is the type, default, comment, or new name the action sets.
+	Value string
+}
+
+// parseAlterTable parses an ALTER TABLE statement that adds, drops, alters, or
+// renames columns, or renames the table. It reports false for other ALTER
+// TABLE statements, such as ALTER TABLE ... SET or ADD CONSTRAINT, and an error
+// for column changes the emulator does not support.
+func parseAlterTable(sql string) (*alterTableStatement, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	if !keywordsHavePrefix(statementKeywords(s), "ALTER", "TABLE") {
+		return nil, false, nil
+	}
+	rest := strings.TrimSpace(s[indexFold(s, "TABLE")+len("TABLE"):])
+	stmt := &alterTableStatement{}
+	if next := skipKeywords(rest, 0, "IF EXISTS"); next > 0 {
+		stmt.IfExists = true
+		rest = strings.TrimSpace(rest[next:])
+	}
+	_, nameEnd := objectNameParts(rest, 0)
+	stmt.Table = rest[:nameEnd]
+	rest = strings.TrimSpace(rest[nameEnd:])
+
+	tokens := definitionTokens(rest)
+	if len(tokens) < 2 {
+		return nil, false, nil
+	}
+	var err error
+	switch strings.ToUpper(tokens[0]) {
+	case "ADD":
+		stmt.Actions, err = parseAddColumns(strings.TrimSpace(rest[len("ADD"):]))
+	case "DROP":
+		stmt.Actions, err = parseDropColumns(strings.TrimSpace(rest[len("DROP"):]))
+	case "ALTER", "MODIFY":
+		stmt.Actions, err = parseAlterColumns(strings.TrimSpace(rest[len(tokens[0]):]))
+	case "RENAME":
+		stmt.Actions, err = parseRename(tokens[1:])
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, fmt.Errorf("ALTER TABLE %s: %w", stmt.Table, err)
+	}
+	if stmt.Actions == nil {
+		return nil, false, nil
+	}
+	return stmt, true, nil
+}
+
+// parseAddColumns parses "[COLUMN] [IF NOT EXISTS] name type [options] [, ...]".
+func parseAddColumns(s string) ([]alterTableAction, error) {
+	column := skipKeywords(s, 0, "COLUMN") > 0
+	s = strings.TrimSpace(s[skipKeywords(s, 0, "COLUMN"):])
+	ifNotExists := false
+	if next := skipKeywords(s, 0, "IF NOT EXISTS"); next > 0 {
+		ifNotExists = true
+		s = strings.TrimSpace(s[next:])
+	}
+	if !column && alterTableKeywords[firstWord(s)] {
+		return nil, nil
+	}
+
+	var actions []alterTableAction
+	for _, segment := range splitFunctionArgs(s, 0) {
+		tokens := definitionTokens(segment)
+		if len(tokens) > 0 && strings.EqualFold(tokens[0], "COLUMN") {
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("missing column definition")
+		}
+		definition, ok := parseColumnDefinition(tokens)
+		if !ok {
+			return nil, fmt.Errorf("column %s has no type", tokens[0])
+		}
+		actions = append(actions, alterTableAction{Kind: alterAddColumn, Column: tokens[0], IfExists: ifNotExists, Definition: definition})
+		for i := 1; i+1 < len(tokens); i++ {
+			if strings.EqualFold(tokens[i], "COMMENT") {
+				actions = append(actions, alterTableAction{Kind: alterComment, Column: tokens[0], Value: tokens[i+1]})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// parseDropColumns parses "[COLUMN] [IF EXISTS] name [, ...]".
+func parseDropColumns(s string) ([]alterTableAction, error) {
+	column := skipKeywords(s, 0, "COLUMN") > 0
+	s = strings.TrimSpace(s[skipKeywords(s, 0, "COLUMN"):])
+	ifExists := false
+	if next := skipKeywords(s, 0, "IF EXISTS"); next > 0 {
+		ifExists = true
+		s = strings.TrimSpace(s[next:])
+	}
+	if !column && alterTableKeywords[firstWord(s)] {
+		return nil, nil
+	}
+
+	var actions []alterTableAction
+	for _, name := range splitFunctionArgs(s, 0) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("missing column name")
+		}
+		actions = append(actions, alterTableAction{Kind: alterDropColumn, Column: name, IfExists: ifExists})
+	}
+	return actions, nil
+}
+
+// parseAlterColumns parses "[COLUMN] name change [, [COLUMN] name change ...]",
+// which may be parenthesized, where a change sets or drops NOT NULL, the data
+// type, the default, or the comment of a column.
+func parseAlterColumns(s string) ([]alterTableAction, error) {
+	if strings.HasPrefix(s, "(") && matchingParen(s) == len(s)-1 {
+		s = s[1 : len(s)-1]
+	}
+	var actions []alterTableAction
+	for _, segment := range splitFunctionArgs(s, 0) {
+		tokens := definitionTokens(segment)
+		if len(tokens) > 0 && strings.EqualFold(tokens[0], "COLUMN") {
+			tokens = tokens[1:]
+		}
+		if len(tokens) < 2 {
+			return nil, fmt.Errorf("missing column change in %q", strings.TrimSpace(segment))
+		}
+		action := alterTableAction{Column: tokens[0]}
+		change := strings.ToUpper(strings.Join(tokens[1:], " "))
+		switch {
+		case change == "SET NOT NULL":
+			action.Kind = alterSetNotNull
+		case change == "DROP NOT NULL":
+			action.Kind = alterDropNotNull
+		case change == "DROP DEFAULT":
+			action.Kind = alterDropDefault
+		case change == "UNSET COMMENT":
+			action.Kind = alterComment
+		case strings.HasPrefix(change, "TYPE "):
+			action.Kind = alterSetDataType
+			action.Value = columnType(tokens[2:])
+		case strings.HasPrefix(change, "SET DATA TYPE "):
+			action.Kind = alterSetDataType
+			action.Value = columnType(tokens[4:])
+		case strings.HasPrefix(change, "SET DEFAULT "):
+			action.Kind = alterSetDefault
+			action.Value = strings.Join(tokens[3:], " ")
+		case len(tokens) == 3 && strings.EqualFold(tokens[1], "COMMENT"):
+			action.Kind = alterComment
+			action.Value = tokens[2]
+		default:
+			return nil, fmt.Errorf("unsupported change of column %s: %s", tokens[0], strings.Join(tokens[1:], " "))
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// parseRename parses "COLUMN name TO new_name" and "TO new_name".
+func parseRename(tokens []string) ([]alterTableAction, error) {
+	switch {
+	case len(tokens) == 4 && strings.EqualFold(tokens[0], "COLUMN") && strings.EqualFold(tokens[2], "TO"):
+		return []alterTableAction{{Kind: alterRenameColumn, Column: tokens[1], Value: tokens[3]}}, nil
+	case len(tokens) == 2 && strings.EqualFold(tokens[0], "TO"):
+		return []alterTableAction{{Kind: alterRenameTable, Value: tokens[1]}}, nil
+	case strings.EqualFold(tokens[0], "COLUMN") || strings.EqualFold(tokens[0], "TO"):
+		return nil, fmt.Errorf("invalid RENAME clause: %s", strings.Join(tokens, " "))
+	}
+	return nil, nil
+}
+
+// firstWord returns the first word of s, upper-cased.
+func firstWord(s string) string {
+	end := 0
+	for end < len(s) && isIdentChar(s[end]) && s[end] != '.' {
+		end++
+	}
+	return strings.ToUpper(s[:end])
+}
+
+// executeAlterTable runs the actions of an ALTER TABLE statement as DuckDB
+// ALTER TABLE statements, all or none of which take effect, and applies them
+// to the columns of the table's registration in the metadata store. A column
+// added with NOT NULL is added first and then set NOT NULL, as DuckDB does not
+// add columns with constraints, and a table may only be renamed within its
+// schema.
+func (e *Executor) executeAlterTable(ctx context.Context, stmt *alterTableStatement) (*ExecResult, error) {
+	table := stmt.Table
+	prefix := "ALTER TABLE "
+	if stmt.IfExists {
+		prefix += "IF EXISTS "
+	}
+	prefix += table + " "
+
+	var statements []string
+	for _, action := range stmt.Actions {
+		switch action.Kind {
+		case alterAddColumn:
+			add := prefix + "ADD COLUMN "
+			if action.IfExists {
+				add += "IF NOT EXISTS "
+			}
+			add += action.Column + " " + action.Definition.Type
+			if action.Definition.Default != nil {
+				add += " DEFAULT " + *action.Definition.Default
+			}
+			statements = append(statements, add)
+			if !action.Definition.Nullable {
+				statements = append(statements, prefix+"ALTER COLUMN "+action.Column+" SET NOT NULL")
+			}
+		case alterDropColumn:
+			drop := prefix + "DROP COLUMN "
+			if action.IfExists {
+				drop += "IF EXISTS "
+			}
+			statements = append(statements, drop+action.Column)
+		case alterSetDataType:
+			statements = append(statements, prefix+"ALTER COLUMN "+action.Column+" SET DATA TYPE "+action.Value)
+		case alterSetDefault:
+			statements = append(statements, prefix+"ALTER COLUMN "+action.Column+" SET DEFAULT "+action.Value)
+		case alterSetNotNull, alterDropNotNull, alterDropDefault:
+			statements = append(statements, prefix+"ALTER COLUMN "+action.Column+" "+action.Kind)
+		case alterComment:
+			// COMMENT ON goes to DuckDB as is, so database-qualified names are resolved first
+			value := action.Value
+			if value == "" {
+				value = "NULL"
+			}
+			statements = append(statements, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", e.resolveTableName(ctx, table), action.Column, value))
+			continue
+		case alterRenameColumn:
+			statements = append(statements, prefix+"RENAME COLUMN "+action.Column+" TO "+action.Value)
+		case alterRenameTable:
+			database, schema, _ := splitObjectName(ctx, table)
+			newDatabase, newSchema, _ := splitObjectName(ctx, action.Value)
+			if !strings.EqualFold(database, newDatabase) || !strings.EqualFold(schema, newSchema) {
+				return nil, fmt.Errorf("ALTER TABLE %s: renaming a table into another schema is not supported", table)
+			}
+			parts, _ := objectNameParts(action.Value, 0)
+			statements = append(statements, prefix+"RENAME TO "+parts[len(parts)-1])
+		}
+	}
+	for i, statement := range statements {
+		if strings.HasPrefix(statement, "ALTER ") {
+			translated, err := e.translate(ctx, statement)
+			if err != nil {
+				return nil, fmt.Errorf("translation error: %w", err)
+			}
+			statements[i] = translated
+		}
+	}
+
+	if err := e.execAll(ctx, statements); err != nil {
+		return nil, fmt.Errorf("alter table execution error: %w", err)
+	}
+	if err := e.alterRegisteredTable(ctx, table, stmt.Actions); err != nil {
+		return nil, err
+	}
+	return &ExecResult{}, nil
+}
+
+// execAll runs statements in a single transaction: the session's open
+// transaction, if any, or else one of their own.
+func (e *Executor) execAll(ctx context.Context, statements []string) error {
+	e.transactions.mu.Lock()
+	_, open := e.transactions.open[SessionInfoFromContext(ctx).ID]
+	e.transactions.mu.Unlock()
+
+	mgr := e.manager(ctx)
+	if open || len(statements) == 1 {
+		for _, statement := range statements {
+			if _, err := mgr.Exec(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return mgr.ExecTx(ctx, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// alterRegisteredTable applies the actions of an ALTER TABLE to the table's
+// registration in the metadata store, if the table was created with SQL and
+// registered (see registerTable).
+func (e *Executor) alterRegisteredTable(ctx context.Context, tableName string, actions []alterTableAction) error {
+	database, _, _ := splitObjectName(ctx, tableName)
+	schema, name, ok := e.lookupObjectSchema(ctx, tableName)
+	if !ok {
+		return nil
+	}
+	table, err := e.repo.GetTableByName(ctx, schema.ID, name)
+	if err != nil || table.TableType != "BASE TABLE" || e.tableExists(ctx, database, schema.Name+"_"+table.Name) {
+		return nil
+	}
+
+	newName := table.Name
+	columns := metadata.ParseColumnDefs(table.ColumnDefinitions)
+	for _, action := range actions {
+		i := registeredColumn(columns, action.Column)
+		switch {
+		case action.Kind == alterAddColumn:
+			if i < 0 {
+				columns = append(columns, action.Definition)
+			}
+		case action.Kind == alterRenameTable:
+			parts, _ := objectNameParts(action.Value, 0)
+			newName = columnName(parts[len(parts)-1])
+		case i < 0:
+			// Columns of tables registered before the column was added are not tracked
+		case action.Kind == alterDropColumn:
+			columns = append(columns[:i], columns[i+1:]...)
+		case action.Kind == alterSetNotNull:
+			columns[i].Nullable = false
+		case action.Kind == alterDropNotNull:
+			columns[i].Nullable = true
+		case action.Kind == alterSetDataType:
+			columns[i].Type = action.Value
+		case action.Kind == alterSetDefault:
+			value := action.Value
+			columns[i].Default = &value
+		case action.Kind == alterDropDefault:
+			columns[i].Default = nil
+		case action.Kind == alterRenameColumn:
+			columns[i].Name = columnName(action.Value)
+		}
+	}
+	if err := e.repo.UpdateTableDefinition(ctx, table.ID, newName, columns); err != nil {
+		return fmt.Errorf("failed to update table metadata: %w", err)
+	}
+	return nil
+}
+
+// registeredColumn returns the index of the registered column a column name as
+// written refers to, or -1. Unquoted names match case-insensitively.
+func registeredColumn(columns []metadata.ColumnDef, name string) int {
+	quoted := strings.HasPrefix(name, `"`)
+	for i, col := range columns {
+		if (quoted && col.Name == unquoteIdentifier(name)) || (!quoted && strings.EqualFold(col.Name, name)) {
+			return i
+		}
+	}
+	return -1
+}
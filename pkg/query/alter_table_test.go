@@ -0,0 +1,187 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// TestParseAlterTable tests parsing ALTER TABLE statements into their actions.
+func TestParseAlterTable(t *testing.T) {
+	zero := "0"
+	tests := []struct {
+		name    string
+		sql     string
+		want    *alterTableStatement
+		wantOK  bool
+		wantErr string
+	}{
+		{
+			name: "AddColumns",
+			sql:  "ALTER TABLE IF EXISTS db.s.t ADD COLUMN a NUMBER(10, 2) NOT NULL DEFAULT 0, b VARCHAR COMMENT 'note';",
+			want: &alterTableStatement{Table: "db.s.t", IfExists: true, Actions: []alterTableAction{
+				{Kind: alterAddColumn, Column: "a", Definition: metadata.ColumnDef{Name: "A", Type: "NUMBER(10,2)", Default: &zero}},
+				{Kind: alterAddColumn, Column: "b", Definition: metadata.ColumnDef{Name: "B", Type: "VARCHAR", Nullable: true}},
+				{Kind: alterComment, Column: "b", Value: "'note'"},
+			}},
+			wantOK: true,
+		},
+		{
+			name: "AddWithoutColumnKeyword",
+			sql:  "alter table t add if not exists c int",
+			want: &alterTableStatement{Table: "t", Actions: []alterTableAction{
+				{Kind: alterAddColumn, Column: "c", IfExists: true, Definition: metadata.ColumnDef{Name: "C", Type: "INT", Nullable: true}},
+			}},
+			wantOK: true,
+		},
+		{
+			name: "DropColumns",
+			sql:  `ALTER TABLE t DROP COLUMN IF EXISTS a, "B"`,
+			want: &alterTableStatement{Table: "t", Actions: []alterTableAction{
+				{Kind: alterDropColumn, Column: "a", IfExists: true},
+				{Kind: alterDropColumn, Column: `"B"`, IfExists: true},
+			}},
+			wantOK: true,
+		},
+		{
+			name: "AlterColumns",
+			sql: "ALTER TABLE t ALTER COLUMN a SET NOT NULL, COLUMN b DROP NOT NULL, c SET DATA TYPE NUMBER(12, 2), " +
+				"d TYPE VARCHAR(50), e SET DEFAULT 1, f DROP DEFAULT, g COMMENT 'x', h UNSET COMMENT",
+			want: &alterTableStatement{Table: "t", Actions: []alterTableAction{
+				{Kind: alterSetNotNull, Column: "a"},
+				{Kind: alterDropNotNull, Column: "b"},
+				{Kind: alterSetDataType, Column: "c", Value: "NUMBER(12,2)"},
+				{Kind: alterSetDataType, Column: "d", Value: "VARCHAR(50)"},
+				{Kind: alterSetDefault, Column: "e", Value: "1"},
+				{Kind: alterDropDefault, Column: "f"},
+				{Kind: alterComment, Column: "g", Value: "'x'"},
+				{Kind: alterComment, Column: "h"},
+			}},
+			wantOK: true,
+		},
+		{
+			name: "ModifyParenthesized",
+			sql:  "ALTER TABLE t MODIFY (COLUMN a DROP NOT NULL, COLUMN b SET NOT NULL)",
+			want: &alterTableStatement{Table: "t", Actions: []alterTableAction{
+				{Kind: alterDropNotNull, Column: "a"},
+				{Kind: alterSetNotNull, Column: "b"},
+			}},
+			wantOK: true,
+		},
+		{
+			name:   "RenameColumn",
+			sql:    "ALTER TABLE t RENAME COLUMN a TO b",
+			want:   &alterTableStatement{Table: "t", Actions: []alterTableAction{{Kind: alterRenameColumn, Column: "a", Value: "b"}}},
+			wantOK: true,
+		},
+		{
+			name:   "RenameTable",
+			sql:    "ALTER TABLE s.t RENAME TO s.u",
+			want:   &alterTableStatement{Table: "s.t", Actions: []alterTableAction{{Kind: alterRenameTable, Value: "s.u"}}},
+			wantOK: true,
+		},
+		{name: "UnsupportedChange", sql: "ALTER TABLE t ALTER COLUMN a SET MASKING POLICY p", wantOK: true, wantErr: "unsupported change of column a"},
+		{name: "AddConstraint", sql: "ALTER TABLE t ADD CONSTRAINT pk PRIMARY KEY (id)"},
+		{name: "DropClusteringKey", sql: "ALTER TABLE t DROP CLUSTERING KEY"},
+		{name: "Set", sql: "ALTER TABLE t SET DATA_RETENTION_TIME_IN_DAYS = 1"},
+		{name: "SwapWith", sql: "ALTER TABLE t SWAP WITH u"},
+		{name: "NotAlterTable", sql: "ALTER SCHEMA s RENAME TO u"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseAlterTable(tt.sql)
+			if ok != tt.wantOK {
+				t.Fatalf("parseAlterTable() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseAlterTable() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAlterTable() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseAlterTable() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_AlterTable tests that ALTER TABLE changes tables and the
+// columns of their registration in the metadata store.
+func TestExecutor_AlterTable(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	db, err := repo.CreateDatabase(ctx, "TEST_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{ID: "1", Database: "TEST_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE TABLE orders (id INTEGER, amount NUMBER(10,2), note VARCHAR NOT NULL, legacy VARCHAR)",
+		"INSERT INTO orders VALUES (1, 12.50, 'rush', 'x')",
+		"ALTER TABLE orders ADD COLUMN status VARCHAR NOT NULL DEFAULT 'new', placed DATE",
+		"ALTER TABLE orders DROP COLUMN legacy",
+		"ALTER TABLE orders ALTER COLUMN id SET NOT NULL, COLUMN note DROP NOT NULL, amount SET DATA TYPE NUMBER(12,2)",
+		"ALTER TABLE orders ALTER COLUMN placed SET DEFAULT CURRENT_DATE()",
+		"ALTER TABLE orders RENAME COLUMN note TO remark",
+		"ALTER TABLE orders RENAME TO public.purchases",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT id, amount::VARCHAR, remark, status FROM purchases")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(1), "12.50", "rush", "new"}}, result.Rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := executor.Execute(ctx, "INSERT INTO purchases (id, amount, status) VALUES (NULL, 1, 'new')"); err == nil {
+		t.Error("INSERT of NULL into a NOT NULL column error = nil, want error")
+	}
+
+	schema, err := repo.GetSchemaByName(ctx, db.ID, "PUBLIC")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	if _, err := repo.GetTableByName(ctx, schema.ID, "ORDERS"); err == nil {
+		t.Error("GetTableByName(ORDERS) after rename error = nil, want error")
+	}
+	table, err := repo.GetTableByName(ctx, schema.ID, "PURCHASES")
+	if err != nil {
+		t.Fatalf("GetTableByName() error = %v", err)
+	}
+	statusDefault, placedDefault := "'new'", "CURRENT_DATE()"
+	want := []metadata.ColumnDef{
+		{Name: "ID", Type: "INTEGER"},
+		{Name: "AMOUNT", Type: "NUMBER(12,2)", Nullable: true},
+		{Name: "REMARK", Type: "VARCHAR", Nullable: true},
+		{Name: "STATUS", Type: "VARCHAR", Default: &statusDefault},
+		{Name: "PLACED", Type: "DATE", Nullable: true, Default: &placedDefault},
+	}
+	if diff := cmp.Diff(want, metadata.ParseColumnDefs(table.ColumnDefinitions)); diff != "" {
+		t.Errorf("registered columns mismatch (-want +got):\n%s", diff)
+	}
+
+	// A failing action leaves the table unchanged
+	if _, err := executor.Execute(ctx, "ALTER TABLE purchases ADD COLUMN a INTEGER, status VARCHAR"); err == nil {
+		t.Error("ALTER TABLE adding an existing column error = nil, want error")
+	}
+	if _, err := executor.Query(ctx, "SELECT a FROM purchases"); err == nil {
+		t.Error("column of a failed ALTER TABLE exists, want it rolled back")
+	}
+	if _, err := executor.Execute(ctx, "ALTER TABLE purchases RENAME TO other_schema.purchases"); err == nil {
+		t.Error("ALTER TABLE renaming into another schema error = nil, want error")
+	}
+}
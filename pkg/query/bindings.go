@@ -0,0 +1,436 @@
+package query
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
+)
+
+// DefaultMaxBindings is the default maximum number of bindings of a statement,
+// Snowflake's limit on the number of expressions in a list.
+const DefaultMaxBindings = 16384
+
+// Binding validation regexes to prevent SQL injection
+var (
+	// Date format: YYYY-MM-DD
+	dateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	// Time format: HH:MM:SS or HH:MM:SS.fraction
+	timeRegex = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+	// Timestamp format: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS with optional timezone
+	timestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?([+-]\d{2}:?\d{2}|Z)?$`)
+	// Epoch format used by drivers for temporal bindings, with an optional TIMESTAMP_TZ offset
+	epochBindingRegex = regexp.MustCompile(`^-?\d+( \d+)?$`)
+)
+
+// BindingLimitError reports a statement with more bindings than the executor
+// allows, as Snowflake reports an IN list with too many expressions.
+type BindingLimitError struct {
+	Count int
+	Max   int
+}
+
+func (e *BindingLimitError) Error() string {
+	return fmt.Sprintf("maximum number of expressions in a list exceeded, expected at most %s, got %s",
+		groupThousands(e.Max), groupThousands(e.Count))
+}
+
+// groupThousands formats n with commas between groups of three digits.
+func groupThousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// bindingCoercion checks a binding value of a type and formats it as the SQL
+// expression it is bound as.
+type bindingCoercion func(m sftypes.TypeMapping, value string) (string, error)
+
+// bindingCoercions is the binding coercion matrix: the values each type of
+// binding accepts and the expressions they are bound as. Types are resolved
+// through the Snowflake type mapping table, so synonyms such as FIXED, INT, or
+// TIMESTAMP_LTZ use the row of their canonical type. Types without a row, such
+// as TEXT, are bound as string literals, which DuckDB casts where they are used.
+var bindingCoercions = map[sftypes.SnowflakeType]bindingCoercion{
+	sftypes.TypeInteger:      coerceInteger,
+	sftypes.TypeNumber:       coerceNumber,
+	sftypes.TypeFloat:        coerceFloat,
+	sftypes.TypeBoolean:      coerceBoolean,
+	sftypes.TypeDate:         formatTemporalBinding,
+	sftypes.TypeTime:         formatTemporalBinding,
+	sftypes.TypeTimestamp:    formatTemporalBinding,
+	sftypes.TypeTimestampNTZ: formatTemporalBinding,
+	sftypes.TypeTimestampLTZ: formatTemporalBinding,
+	sftypes.TypeTimestampTZ:  formatTemporalBinding,
+	sftypes.TypeBinary:       coerceBinary,
+	sftypes.TypeVariant:      coerceJSON,
+	sftypes.TypeObject:       coerceJSON,
+	sftypes.TypeArray:        coerceJSON,
+}
+
+// Boolean strings Snowflake accepts, case-insensitively.
+var (
+	trueStrings  = map[string]bool{"true": true, "t": true, "yes": true, "y": true, "on": true, "1": true}
+	falseStrings = map[string]bool{"false": true, "f": true, "no": true, "n": true, "off": true, "0": true}
+)
+
+// bindingFormatter returns the function formatting the values of a binding
+// type. It is resolved once per binding, and reused for every element of an
+// array binding.
+func bindingFormatter(typeName string) func(value string) (string, error) {
+	if strings.EqualFold(typeName, ValueNull) {
+		return func(string) (string, error) { return ValueNull, nil }
+	}
+	m, ok := sftypes.LookupType(typeName)
+	coerce := bindingCoercions[m.Type]
+	if !ok || coerce == nil {
+		return func(value string) (string, error) { return quoteBindingText(value), nil }
+	}
+	return func(value string) (string, error) { return coerce(m, value) }
+}
+
+// formatBindingValue formats a binding value for SQL substitution.
+func formatBindingValue(b *QueryBindingValue) (string, error) {
+	if b == nil {
+		return ValueNull, nil
+	}
+	return bindingFormatter(b.Type)(b.Value)
+}
+
+func coerceInteger(_ sftypes.TypeMapping, value string) (string, error) {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", fmt.Errorf("invalid integer value: %s", value)
+	}
+	return value, nil
+}
+
+func coerceNumber(_ sftypes.TypeMapping, value string) (string, error) {
+	if !numericLiteralRegex.MatchString(value) {
+		return "", fmt.Errorf("invalid number value: %s", value)
+	}
+	return value, nil
+}
+
+func coerceFloat(_ sftypes.TypeMapping, value string) (string, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid float value: %s", value)
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// Special values are not numeric literals
+		return "CAST(" + quoteBindingText(value) + " AS DOUBLE)", nil
+	}
+	return value, nil
+}
+
+func coerceBoolean(_ sftypes.TypeMapping, value string) (string, error) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case trueStrings[lower]:
+		return "TRUE", nil
+	case falseStrings[lower]:
+		return "FALSE", nil
+	}
+	return "", fmt.Errorf("Boolean value '%s' is not recognized", value) //nolint:staticcheck // Snowflake's message
+}
+
+func coerceBinary(_ sftypes.TypeMapping, value string) (string, error) {
+	if _, err := hex.DecodeString(value); err != nil {
+		return "", fmt.Errorf("invalid BINARY value: %s (expected hex)", value)
+	}
+	return "from_hex('" + value + "')", nil
+}
+
+func coerceJSON(m sftypes.TypeMapping, value string) (string, error) {
+	if !json.Valid([]byte(value)) {
+		return "", fmt.Errorf("invalid %s value: %s (expected JSON)", m.Type, value)
+	}
+	return "CAST(" + quoteBindingText(value) + " AS JSON)", nil
+}
+
+// formatTemporalBinding formats a DATE, TIME, or TIMESTAMP binding. Values may be
+// literals such as 2024-01-15 10:30:00, or the epoch forms drivers bind: milliseconds
+// for DATE, nanoseconds since midnight for TIME, and nanoseconds for timestamps,
+// followed by the offset in minutes plus 1440 for TIMESTAMP_TZ.
+func formatTemporalBinding(m sftypes.TypeMapping, value string) (string, error) {
+	if epochBindingRegex.MatchString(value) {
+		value = epochToLiteral(m.Type, value)
+	}
+
+	switch m.Type {
+	case sftypes.TypeDate:
+		// Validate date format to prevent SQL injection
+		if !dateRegex.MatchString(value) {
+			return "", fmt.Errorf("invalid DATE format: %s (expected YYYY-MM-DD)", value)
+		}
+	case sftypes.TypeTime:
+		// Validate time format to prevent SQL injection
+		if !timeRegex.MatchString(value) {
+			return "", fmt.Errorf("invalid TIME format: %s (expected HH:MM:SS)", value)
+		}
+	default:
+		// Validate timestamp format to prevent SQL injection
+		if !timestampRegex.MatchString(value) {
+			return "", fmt.Errorf("invalid TIMESTAMP format: %s (expected YYYY-MM-DD HH:MM:SS)", value)
+		}
+	}
+	return m.DuckDBType + " '" + value + "'", nil
+}
+
+// epochToLiteral converts a driver's epoch binding to a literal for the given type.
+// It returns the value unchanged when it cannot be parsed.
+func epochToLiteral(t sftypes.SnowflakeType, value string) string {
+	epoch, offset, hasOffset := strings.Cut(value, " ")
+	n, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return value
+	}
+
+	switch t {
+	case sftypes.TypeDate:
+		return time.UnixMilli(n).UTC().Format("2006-01-02")
+	case sftypes.TypeTime:
+		return time.Unix(0, n).UTC().Format("15:04:05.000000000")
+	case sftypes.TypeTimestampLTZ, sftypes.TypeTimestampTZ:
+		loc := time.UTC
+		if hasOffset {
+			minutes, err := strconv.Atoi(offset)
+			if err != nil {
+				return value
+			}
+			loc = time.FixedZone("", (minutes-1440)*60)
+		}
+		return time.Unix(0, n).In(loc).Format("2006-01-02 15:04:05.000000000-07:00")
+	default:
+		return time.Unix(0, n).UTC().Format("2006-01-02 15:04:05.000000000")
+	}
+}
+
+// quoteBindingText formats a binding value as a string literal. Backslashes start
+// escape sequences in Snowflake string literals, so they are escaped as well.
+func quoteBindingText(value string) string {
+	escaped := strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(escaped, "'", "''") + "'"
+}
+
+// applyBindings replaces the ? and :N placeholders of sql with the bindings,
+// keyed by 1-based position. Placeholders inside literals, quoted identifiers,
+// and comments are left alone, as are those without a binding. Array
+// bindings insert one row per element.
+func (e *Executor) applyBindings(sql string, bindings map[string]*QueryBindingValue) (string, error) {
+	if e.maxBindings > 0 && len(bindings) > e.maxBindings {
+		return "", &BindingLimitError{Count: len(bindings), Max: e.maxBindings}
+	}
+	for key := range bindings {
+		if _, err := strconv.Atoi(key); err != nil {
+			return "", fmt.Errorf("invalid binding key %q: must be a number", key)
+		}
+	}
+	if hasArrayBindings(bindings) {
+		return applyArrayBindings(sql, bindings)
+	}
+
+	values := make(map[string]string, len(bindings))
+	for key, binding := range bindings {
+		if binding == nil {
+			continue
+		}
+		value, err := formatBindingValue(binding)
+		if err != nil {
+			return "", fmt.Errorf("error formatting binding %s: %w", key, err)
+		}
+		values[key] = value
+	}
+	var b strings.Builder
+	b.Grow(len(sql))
+	parseBindingTemplate(sql).render(&b, func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	})
+	return b.String(), nil
+}
+
+// hasArrayBindings reports whether any binding is an array binding.
+func hasArrayBindings(bindings map[string]*QueryBindingValue) bool {
+	for _, binding := range bindings {
+		if binding != nil && binding.Values != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// applyArrayBindings binds the array bindings of a bulk INSERT ... VALUES
+// statement, repeating its row of placeholders once per element. Every binding
+// must be an array of the same length. The elements of a binding share its
+// type, so its formatter is resolved once.
+func applyArrayBindings(sql string, bindings map[string]*QueryBindingValue) (string, error) {
+	rows := -1
+	values := make(map[string][]string, len(bindings))
+	for key, binding := range bindings {
+		if binding == nil || binding.Values == nil {
+			return "", fmt.Errorf("binding %s is not an array; array bindings cannot be mixed with other bindings", key)
+		}
+		if rows >= 0 && len(binding.Values) != rows {
+			return "", fmt.Errorf("array bindings have different lengths: %d and %d", rows, len(binding.Values))
+		}
+		rows = len(binding.Values)
+		format := bindingFormatter(binding.Type)
+		formatted := make([]string, len(binding.Values))
+		for i, v := range binding.Values {
+			if v == nil {
+				formatted[i] = ValueNull
+				continue
+			}
+			value, err := format(*v)
+			if err != nil {
+				return "", fmt.Errorf("error formatting binding %s: %w", key, err)
+			}
+			formatted[i] = value
+		}
+		values[key] = formatted
+	}
+
+	start, end, ok := valuesRow(sql)
+	if !ok {
+		return "", fmt.Errorf("array bindings are only supported in INSERT ... VALUES statements with one row")
+	}
+	row := parseBindingTemplate(sql[start:end])
+	var b strings.Builder
+	b.WriteString(sql[:start])
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		row.render(&b, func(key string) (string, bool) {
+			if formatted, ok := values[key]; ok {
+				return formatted[i], true
+			}
+			return "", false
+		})
+	}
+	b.WriteString(sql[end:])
+	return b.String(), nil
+}
+
+// valuesRow returns the bounds of the only row of an INSERT ... VALUES
+// statement, parentheses included.
+func valuesRow(sql string) (start, end int, ok bool) {
+	if !keywordAt(strings.TrimSpace(sql), 0, "INSERT") {
+		return 0, 0, false
+	}
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '(':
+			// Column lists and subqueries come before VALUES
+			if closing := matchingParen(sql[i:]); closing > 0 {
+				i += closing
+			}
+		case keywordAt(sql, i, "VALUES"):
+			start = i + len("VALUES")
+			for start < len(sql) && isSpace(sql[start]) {
+				start++
+			}
+			if start == len(sql) || sql[start] != '(' {
+				return 0, 0, false
+			}
+			closing := matchingParen(sql[start:])
+			if closing < 0 {
+				return 0, 0, false
+			}
+			end = start + closing + 1
+			if rest := strings.TrimSpace(sql[end:]); rest != "" && rest != ";" {
+				return 0, 0, false
+			}
+			return start, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// bindingTemplate is a statement split into text and placeholders, so that
+// it can be bound many times in one pass each.
+type bindingTemplate []bindingSegment
+
+// bindingSegment is text of a statement, or a placeholder when key, the
+// 1-based position it binds, is set.
+type bindingSegment struct {
+	text string
+	key  string
+}
+
+// parseBindingTemplate splits sql at its ? and :N placeholders. ? placeholders
+// are numbered in order. Placeholders inside literals, quoted identifiers,
+// $$ bodies, and comments are text, as are :: casts and the digits of
+// variant paths.
+func parseBindingTemplate(sql string) bindingTemplate {
+	var template bindingTemplate
+	last, questionMarks := 0, 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+			continue
+		case strings.HasPrefix(sql[i:], "$$"):
+			if end := strings.Index(sql[i+2:], "$$"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+			continue
+		}
+		if end, ok := commentEnd(sql, i); ok {
+			i = end - 1
+			continue
+		}
+
+		var key string
+		end := i + 1
+		switch {
+		case c == '?':
+			questionMarks++
+			key = strconv.Itoa(questionMarks)
+		case c == ':' && (i == 0 || (sql[i-1] != ':' && !isIdentChar(sql[i-1]))):
+			for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+				end++
+			}
+			if end == i+1 {
+				continue
+			}
+			key = sql[i+1 : end]
+		default:
+			continue
+		}
+		template = append(template,
+			bindingSegment{text: sql[last:i]},
+			bindingSegment{text: sql[i:end], key: strings.TrimLeft(key, "0")})
+		last = end
+		i = end - 1
+	}
+	return append(template, bindingSegment{text: sql[last:]})
+}
+
+// render writes the template with its placeholders replaced by the values
+// value returns. Placeholders without a value are written as they are.
+func (t bindingTemplate) render(b *strings.Builder, value func(key string) (string, bool)) {
+	for _, segment := range t {
+		if segment.key != "" {
+			if v, ok := value(segment.key); ok {
+				b.WriteString(v)
+				continue
+			}
+		}
+		b.WriteString(segment.text)
+	}
+}
@@ -0,0 +1,185 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_ApplyBindings tests substituting bindings into statements.
+func TestExecutor_ApplyBindings(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	one, two := "1", "2"
+
+	tests := []struct {
+		name     string
+		sql      string
+		bindings map[string]*QueryBindingValue
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "QuestionMarks",
+			sql:      "SELECT * FROM t WHERE id IN (?, ?) AND name = ?",
+			bindings: map[string]*QueryBindingValue{"1": {Type: "FIXED", Value: "1"}, "2": {Type: "FIXED", Value: "2"}, "3": {Type: "TEXT", Value: "a"}},
+			expected: "SELECT * FROM t WHERE id IN (1, 2) AND name = 'a'",
+		},
+		{
+			name:     "NumberedTenAfterOne",
+			sql:      "SELECT :1, :10",
+			bindings: numberedBindings(10),
+			expected: "SELECT 1, 10",
+		},
+		{
+			name:     "PlaceholdersInLiteralsAndComments",
+			sql:      "SELECT '?', ':1', \"a?\" -- ?\n, :1 /* :1 */, $$ ? $$",
+			bindings: map[string]*QueryBindingValue{"1": {Type: "FIXED", Value: "7"}},
+			expected: "SELECT '?', ':1', \"a?\" -- ?\n, 7 /* :1 */, $$ ? $$",
+		},
+		{
+			name:     "CastsAndPaths",
+			sql:      "SELECT v:1, x::VARCHAR, :1",
+			bindings: map[string]*QueryBindingValue{"1": {Type: "FIXED", Value: "7"}},
+			expected: "SELECT v:1, x::VARCHAR, 7",
+		},
+		{
+			name:     "MissingBinding",
+			sql:      "SELECT ?, ?",
+			bindings: map[string]*QueryBindingValue{"1": {Type: "FIXED", Value: "7"}},
+			expected: "SELECT 7, ?",
+		},
+		{
+			name: "ArrayBindings",
+			sql:  "INSERT INTO t (id, name) VALUES (?, ?)",
+			bindings: map[string]*QueryBindingValue{
+				"1": {Type: "FIXED", Values: []*string{&one, &two}},
+				"2": {Type: "TEXT", Values: []*string{&one, nil}},
+			},
+			expected: "INSERT INTO t (id, name) VALUES (1, '1'), (2, NULL)",
+		},
+		{
+			name: "ArrayBindingsOfDifferentLengths",
+			sql:  "INSERT INTO t VALUES (?, ?)",
+			bindings: map[string]*QueryBindingValue{
+				"1": {Type: "FIXED", Values: []*string{&one, &two}},
+				"2": {Type: "FIXED", Values: []*string{&one}},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "ArrayBindingsOutsideInsert",
+			sql:      "SELECT * FROM t WHERE id = ?",
+			bindings: map[string]*QueryBindingValue{"1": {Type: "FIXED", Values: []*string{&one}}},
+			wantErr:  true,
+		},
+		{
+			name:     "InvalidKey",
+			sql:      "SELECT :a",
+			bindings: map[string]*QueryBindingValue{"a": {Type: "FIXED", Value: "1"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executor.applyBindings(tt.sql, tt.bindings)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("applyBindings() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyBindings() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("applyBindings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// numberedBindings returns n FIXED bindings, each binding its own key.
+func numberedBindings(n int) map[string]*QueryBindingValue {
+	bindings := make(map[string]*QueryBindingValue, n)
+	for i := 1; i <= n; i++ {
+		bindings[fmt.Sprint(i)] = &QueryBindingValue{Type: "FIXED", Value: fmt.Sprint(i)}
+	}
+	return bindings
+}
+
+// TestExecutor_MaxBindings tests the limit on the number of bindings.
+func TestExecutor_MaxBindings(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	n := DefaultMaxBindings + 1
+	sql := "SELECT COUNT(*) FROM range(20000) t(x) WHERE x IN (" + strings.TrimSuffix(strings.Repeat("?, ", n), ", ") + ")"
+
+	_, err := executor.QueryWithBindings(ctx, sql, numberedBindings(n))
+	var limit *BindingLimitError
+	if !errors.As(err, &limit) {
+		t.Fatalf("QueryWithBindings() error = %v, want BindingLimitError", err)
+	}
+	expected := "maximum number of expressions in a list exceeded, expected at most 16,384, got 16,385"
+	if limit.Error() != expected {
+		t.Errorf("Error() = %q, want %q", limit.Error(), expected)
+	}
+
+	executor.Configure(WithMaxBindings(0))
+	result, err := executor.QueryWithBindings(ctx, sql, numberedBindings(n))
+	if err != nil {
+		t.Fatalf("QueryWithBindings() without a limit error = %v", err)
+	}
+	if fmt.Sprint(result.Rows[0][0]) != "16385" {
+		t.Errorf("COUNT(*) = %v, want 16385", result.Rows[0][0])
+	}
+}
+
+// TestBindingCoercions tests the values the binding coercion matrix accepts.
+func TestBindingCoercions(t *testing.T) {
+	tests := []struct {
+		typeName string
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{typeName: "BOOLEAN", value: "yes", expected: "TRUE"},
+		{typeName: "BOOLEAN", value: "ON", expected: "TRUE"},
+		{typeName: "BOOLEAN", value: "1", expected: "TRUE"},
+		{typeName: "BOOLEAN", value: "f", expected: "FALSE"},
+		{typeName: "BOOLEAN", value: "off", expected: "FALSE"},
+		{typeName: "BOOLEAN", value: "maybe", wantErr: true},
+		{typeName: "INTEGER", value: "12", expected: "12"},
+		{typeName: "INTEGER", value: "1.5", wantErr: true},
+		{typeName: "FIXED", value: "-1.5e3", expected: "-1.5e3"},
+		{typeName: "FIXED", value: "1;DROP", wantErr: true},
+		{typeName: "REAL", value: "NaN", expected: "CAST('NaN' AS DOUBLE)"},
+		{typeName: "BINARY", value: "zz", wantErr: true},
+		{typeName: "VARIANT", value: `{"a":1}`, expected: `CAST('{"a":1}' AS JSON)`},
+		{typeName: "ARRAY", value: "[1,", wantErr: true},
+		{typeName: "TEXT", value: "1", expected: "'1'"},
+		{typeName: "UNKNOWN_TYPE", value: "x", expected: "'x'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typeName+"_"+tt.value, func(t *testing.T) {
+			got, err := bindingFormatter(tt.typeName)(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("bindingFormatter(%q)(%q) = %q, want error", tt.typeName, tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bindingFormatter(%q)(%q) error = %v", tt.typeName, tt.value, err)
+			}
+			if got != tt.expected {
+				t.Errorf("bindingFormatter(%q)(%q) = %q, want %q", tt.typeName, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
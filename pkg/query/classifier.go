@@ -19,7 +19,7 @@ const (
 	StatementTypeDDLAlter                         // ALTER TABLE, etc.
 	StatementTypeCopy                             // COPY INTO
 	StatementTypeMerge                            // MERGE INTO
-	StatementTypeTransaction                      // BEGIN, COMMIT, ROLLBACK
+	StatementTypeTransaction                      // BEGIN, COMMIT, ROLLBACK, SAVEPOINT
 	StatementTypeOther                            // Unknown or unsupported
 )
 
@@ -40,12 +40,27 @@ type ClassifyResult struct {
 	IsDML           bool
 }
 
+// maxStatementKeywords is the number of leading keywords classification looks
+// at, enough for e.g. CREATE OR REPLACE TABLE or the target of TRUNCATE TABLE
+// IF EXISTS.
+const maxStatementKeywords = 5
+
+// cteStatementKeywords start the statement that follows the common table
+// expressions of a WITH clause.
+var cteStatementKeywords = map[string]bool{
+	"SELECT": true,
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
 // Classify analyzes a SQL statement and returns its classification.
 func (c *Classifier) Classify(sql string) ClassifyResult {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
+	keywords := statementKeywords(sql)
 
 	// Check for query statements
-	if c.isQueryStatement(upperSQL) {
+	if c.isQueryStatement(keywords) {
 		return ClassifyResult{
 			Type:            StatementTypeQuery,
 			StatementTypeID: config.StatementTypeSelect,
@@ -56,7 +71,7 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 	}
 
 	// Check for DDL statements
-	if strings.HasPrefix(upperSQL, "CREATE") {
+	if keywordsHavePrefix(keywords, "CREATE") {
 		return ClassifyResult{
 			Type:            StatementTypeDDLCreate,
 			StatementTypeID: config.StatementTypeDDL,
@@ -66,7 +81,7 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 		}
 	}
 
-	if strings.HasPrefix(upperSQL, "DROP") {
+	if keywordsHavePrefix(keywords, "DROP") {
 		return ClassifyResult{
 			Type:            StatementTypeDDLDrop,
 			StatementTypeID: config.StatementTypeDrop,
@@ -76,7 +91,7 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 		}
 	}
 
-	if strings.HasPrefix(upperSQL, "ALTER") {
+	if keywordsHavePrefix(keywords, "ALTER") {
 		return ClassifyResult{
 			Type:            StatementTypeDDLAlter,
 			StatementTypeID: config.StatementTypeDDL,
@@ -87,7 +102,7 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 	}
 
 	// Check for COPY INTO statement
-	if strings.HasPrefix(upperSQL, "COPY") {
+	if keywordsHavePrefix(keywords, "COPY") {
 		return ClassifyResult{
 			Type:            StatementTypeCopy,
 			StatementTypeID: config.StatementTypeDML, // COPY is treated as DML
@@ -98,7 +113,7 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 	}
 
 	// Check for MERGE statement
-	if strings.HasPrefix(upperSQL, "MERGE") {
+	if keywordsHavePrefix(keywords, "MERGE") {
 		return ClassifyResult{
 			Type:            StatementTypeMerge,
 			StatementTypeID: config.StatementTypeDML, // MERGE is treated as DML
@@ -109,7 +124,7 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 	}
 
 	// Check for transaction control statements
-	if c.isTransactionStatement(upperSQL) {
+	if c.isTransactionStatement(keywords) {
 		return ClassifyResult{
 			Type:            StatementTypeTransaction,
 			StatementTypeID: config.StatementTypeDML, // Transaction control statements
@@ -129,39 +144,133 @@ func (c *Classifier) Classify(sql string) ClassifyResult {
 	}
 }
 
-// isQueryStatement checks if the SQL is a query (read-only) statement.
-func (c *Classifier) isQueryStatement(upperSQL string) bool {
-	return strings.HasPrefix(upperSQL, "SELECT") ||
-		strings.HasPrefix(upperSQL, "SHOW") ||
-		strings.HasPrefix(upperSQL, "DESCRIBE") ||
-		strings.HasPrefix(upperSQL, "DESC") ||
-		strings.HasPrefix(upperSQL, "EXPLAIN")
+// isQueryStatement checks if the leading keywords start a query (read-only) statement.
+func (c *Classifier) isQueryStatement(keywords []string) bool {
+	return keywordsHavePrefix(keywords, "SELECT") ||
+		keywordsHavePrefix(keywords, "SHOW") ||
+		keywordsHavePrefix(keywords, "DESCRIBE") ||
+		keywordsHavePrefix(keywords, "DESC") ||
+		keywordsHavePrefix(keywords, "EXPLAIN") ||
+		keywordsHavePrefix(keywords, "CALL") ||
+		keywordsHavePrefix(keywords, "LIST") ||
+		keywordsHavePrefix(keywords, "LS") ||
+		keywordsHavePrefix(keywords, "EXECUTE", "IMMEDIATE")
 }
 
-// isTransactionStatement checks if the SQL is a transaction control statement.
-func (c *Classifier) isTransactionStatement(upperSQL string) bool {
-	return strings.HasPrefix(upperSQL, "BEGIN") ||
-		strings.HasPrefix(upperSQL, "START TRANSACTION") ||
-		strings.HasPrefix(upperSQL, "COMMIT") ||
-		strings.HasPrefix(upperSQL, "ROLLBACK")
+// isTransactionStatement checks if the leading keywords start a transaction control statement.
+func (c *Classifier) isTransactionStatement(keywords []string) bool {
+	return keywordsHavePrefix(keywords, "BEGIN") ||
+		keywordsHavePrefix(keywords, "START", "TRANSACTION") ||
+		keywordsHavePrefix(keywords, "COMMIT") ||
+		keywordsHavePrefix(keywords, "ROLLBACK") ||
+		keywordsHavePrefix(keywords, "SAVEPOINT") ||
+		keywordsHavePrefix(keywords, "RELEASE")
 }
 
 // IsCreateTable checks if the SQL is a CREATE TABLE statement.
 func (c *Classifier) IsCreateTable(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "CREATE TABLE")
+	return keywordsHavePrefix(statementKeywords(sql), "CREATE", "TABLE")
 }
 
 // IsDropTable checks if the SQL is a DROP TABLE statement.
 func (c *Classifier) IsDropTable(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "DROP TABLE")
+	return keywordsHavePrefix(statementKeywords(sql), "DROP", "TABLE")
 }
 
 // IsCopy checks if the SQL is a COPY INTO statement.
 func (c *Classifier) IsCopy(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "COPY")
+	return keywordsHavePrefix(statementKeywords(sql), "COPY")
+}
+
+// statementKeywords returns the leading keywords of a statement in upper case,
+// e.g. [CREATE TABLE T] for "CREATE TABLE t (id INT)". Whitespace, comments,
+// and parentheses before the first keyword are skipped, and the keywords end at
+// the first token that is not a word, so that keywords inside string literals
+// and quoted identifiers are never seen. The common table expressions of a
+// WITH clause are skipped as well: the keywords of
+// "WITH x AS (...) INSERT INTO t ..." start at INSERT.
+func statementKeywords(sql string) []string {
+	var keywords []string
+	inWith := false
+	depth := 0
+	for i := 0; i < len(sql) && len(keywords) < maxStatementKeywords; {
+		if end, ok := commentEnd(sql, i); ok {
+			i = end
+			continue
+		}
+
+		switch c := sql[i]; {
+		case isSpace(c):
+			i++
+		case isIdentChar(c) && c != '.':
+			start := i
+			for i < len(sql) && isIdentChar(sql[i]) {
+				i++
+			}
+			word := strings.ToUpper(sql[start:i])
+			switch {
+			case inWith:
+				if depth == 0 && cteStatementKeywords[word] {
+					inWith = false
+					keywords = append(keywords, word)
+				}
+			case len(keywords) == 0 && word == "WITH":
+				inWith = true
+			default:
+				keywords = append(keywords, word)
+			}
+		case inWith:
+			switch c {
+			case '\'', '"':
+				i = skipQuoted(sql, i, c)
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			i++
+		case len(keywords) == 0 && c == '(':
+			i++
+		default:
+			return keywords
+		}
+	}
+	return keywords
+}
+
+// commentEnd reports whether a -- or // line comment or a /* */ block comment
+// starts at sql[i], and returns the index just past it. An unterminated block
+// comment runs to the end of sql.
+func commentEnd(sql string, i int) (int, bool) {
+	if i+1 >= len(sql) {
+		return 0, false
+	}
+	switch sql[i : i+2] {
+	case "--", "//":
+		if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+			return i + end + 1, true
+		}
+		return len(sql), true
+	case "/*":
+		if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2, true
+		}
+		return len(sql), true
+	}
+	return 0, false
+}
+
+// keywordsHavePrefix reports whether keywords starts with prefix.
+func keywordsHavePrefix(keywords []string, prefix ...string) bool {
+	if len(keywords) < len(prefix) {
+		return false
+	}
+	for i, keyword := range prefix {
+		if keywords[i] != keyword {
+			return false
+		}
+	}
+	return true
 }
 
 // DefaultClassifier is the default SQL classifier instance.
@@ -194,14 +303,12 @@ func IsCopy(sql string) bool {
 
 // IsMerge checks if the SQL is a MERGE INTO statement.
 func (c *Classifier) IsMerge(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "MERGE")
+	return keywordsHavePrefix(statementKeywords(sql), "MERGE")
 }
 
 // IsTransaction checks if the SQL is a transaction control statement.
 func (c *Classifier) IsTransaction(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return c.isTransactionStatement(upperSQL)
+	return c.isTransactionStatement(statementKeywords(sql))
 }
 
 // IsMerge is a convenience function to check if SQL is a MERGE statement.
@@ -216,18 +323,16 @@ func IsTransaction(sql string) bool {
 
 // IsBegin checks if the SQL is a BEGIN/START TRANSACTION statement.
 func IsBegin(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "BEGIN") || strings.HasPrefix(upperSQL, "START TRANSACTION")
+	keywords := statementKeywords(sql)
+	return keywordsHavePrefix(keywords, "BEGIN") || keywordsHavePrefix(keywords, "START", "TRANSACTION")
 }
 
 // IsCommit checks if the SQL is a COMMIT statement.
 func IsCommit(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "COMMIT")
+	return keywordsHavePrefix(statementKeywords(sql), "COMMIT")
 }
 
 // IsRollback checks if the SQL is a ROLLBACK statement.
 func IsRollback(sql string) bool {
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
-	return strings.HasPrefix(upperSQL, "ROLLBACK")
+	return keywordsHavePrefix(statementKeywords(sql), "ROLLBACK")
 }
@@ -0,0 +1,130 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// trickyStatements are statements that prefix-based classification misreads:
+// leading comments, common table expressions, and keywords inside literals.
+var trickyStatements = []struct {
+	name string
+	sql  string
+	want StatementType
+}{
+	{name: "plain select", sql: "SELECT 1", want: StatementTypeQuery},
+	{name: "line comment", sql: "-- COPY INTO t\nSELECT 1", want: StatementTypeQuery},
+	{name: "slash comment", sql: "// DROP TABLE t\nSELECT 1", want: StatementTypeQuery},
+	{name: "block comment", sql: "/* dbt: {\"node\": \"model.x\"} */ INSERT INTO t VALUES (1)", want: StatementTypeDML},
+	{name: "unterminated comment", sql: "/* SELECT 1", want: StatementTypeDML},
+	{name: "nested parentheses", sql: "((SELECT 1)) UNION ALL (SELECT 2)", want: StatementTypeQuery},
+	{name: "cte select", sql: "WITH x AS (SELECT 1 AS a) SELECT * FROM x", want: StatementTypeQuery},
+	{name: "recursive cte", sql: "WITH RECURSIVE r (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM r) SELECT n FROM r", want: StatementTypeQuery},
+	{name: "cte insert", sql: "WITH x AS (SELECT 1 AS a), y AS (SELECT 2) INSERT INTO t SELECT a FROM x", want: StatementTypeDML},
+	{name: "cte merge", sql: "WITH s AS (SELECT 1 AS id) MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE", want: StatementTypeMerge},
+	{name: "cte with literal parenthesis", sql: "WITH x AS (SELECT ')' AS a) DELETE FROM t", want: StatementTypeDML},
+	{name: "keyword in string", sql: "INSERT INTO t VALUES ('SELECT')", want: StatementTypeDML},
+	{name: "keyword in quoted identifier", sql: "\"SELECT\" 1", want: StatementTypeDML},
+	{name: "keyword prefix", sql: "SELECTED_ROWS", want: StatementTypeDML},
+	{name: "lowercase", sql: "  create table t (id int)", want: StatementTypeDDLCreate},
+	{name: "comment before drop", sql: "/* cleanup */ DROP TABLE t", want: StatementTypeDDLDrop},
+	{name: "comment before alter", sql: "--x\n  ALTER TABLE t ADD COLUMN c INT", want: StatementTypeDDLAlter},
+	{name: "comment before copy", sql: "/* load */ COPY INTO t FROM @stage", want: StatementTypeCopy},
+	{name: "comment before commit", sql: "-- done\nCOMMIT", want: StatementTypeTransaction},
+	{name: "start transaction", sql: "START TRANSACTION", want: StatementTypeTransaction},
+	{name: "start without transaction", sql: "START t", want: StatementTypeDML},
+	{name: "describe", sql: "DESC TABLE t", want: StatementTypeQuery},
+	{name: "empty", sql: "", want: StatementTypeDML},
+	{name: "only comment", sql: "-- nothing", want: StatementTypeDML},
+}
+
+func TestClassifier_TrickyStatements(t *testing.T) {
+	classifier := NewClassifier()
+	for _, tt := range trickyStatements {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.Classify(tt.sql).Type; got != tt.want {
+				t.Errorf("Classify(%q).Type = %v, want %v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementKeywords(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{name: "stops at quoted identifier", sql: "CREATE TABLE \"t\" (id INT)", want: []string{"CREATE", "TABLE"}},
+		{name: "stops at punctuation", sql: "DROP TABLE db.s.t;", want: []string{"DROP", "TABLE", "DB.S.T"}},
+		{name: "limited", sql: "CREATE OR REPLACE TEMPORARY TABLE IF NOT EXISTS t", want: []string{"CREATE", "OR", "REPLACE", "TEMPORARY", "TABLE"}},
+		{name: "comment between keywords", sql: "CREATE /* x */ TABLE -- y\n t", want: []string{"CREATE", "TABLE", "T"}},
+		{name: "cte", sql: "with a as (select 1) select * from a", want: []string{"SELECT"}},
+		{name: "unterminated cte", sql: "WITH a AS (SELECT 1", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, statementKeywords(tt.sql)); diff != "" {
+				t.Errorf("statementKeywords(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+func TestClassifier_Predicates(t *testing.T) {
+	classifier := NewClassifier()
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{name: "create table after comment", got: classifier.IsCreateTable("/* x */ CREATE TABLE t (id INT)"), want: true},
+		{name: "create table in string", got: classifier.IsCreateTable("SELECT 'CREATE TABLE t'"), want: false},
+		{name: "drop table after comment", got: classifier.IsDropTable("-- x\nDROP TABLE t"), want: true},
+		{name: "copy after comment", got: IsCopy("/* x */ COPY INTO t FROM @s"), want: true},
+		{name: "copy as identifier", got: IsCopy("COPY_HISTORY"), want: false},
+		{name: "merge after cte", got: IsMerge("WITH s AS (SELECT 1) MERGE INTO t USING s ON true WHEN MATCHED THEN DELETE"), want: true},
+		{name: "transaction after comment", got: IsTransaction("/* x */ ROLLBACK"), want: true},
+		{name: "begin", got: IsBegin("begin transaction"), want: true},
+		{name: "commit", got: IsCommit("-- x\ncommit"), want: true},
+		{name: "rollback in select", got: IsRollback("SELECT 'ROLLBACK'"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzClassify(f *testing.F) {
+	for _, tt := range trickyStatements {
+		f.Add(tt.sql)
+	}
+	f.Add("WITH a AS (SELECT '(' AS x), b AS (SELECT \")\" FROM a) UPDATE t SET x = 1")
+	f.Add("/* */ /**/ -- \n // \n (((SELECT")
+	f.Add("'unterminated")
+
+	f.Fuzz(func(t *testing.T, sql string) {
+		keywords := statementKeywords(sql)
+		if len(keywords) > maxStatementKeywords {
+			t.Fatalf("statementKeywords(%q) returned %d keywords", sql, len(keywords))
+		}
+		for _, keyword := range keywords {
+			if keyword == "" || keyword != strings.ToUpper(keyword) {
+				t.Fatalf("statementKeywords(%q) returned keyword %q", sql, keyword)
+			}
+		}
+
+		// A leading comment never changes the classification
+		want := ClassifySQL(sql)
+		if got := ClassifySQL("/* comment */ " + sql); got != want {
+			t.Errorf("ClassifySQL with leading comment = %+v, want %+v for %q", got, want, sql)
+		}
+	})
+}
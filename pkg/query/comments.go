@@ -0,0 +1,100 @@
+package query
+
+import "strings"
+
+// Comments in Snowflake SQL, such as the /* {"app": "dbt", ...} */ headers that
+// tools add for attribution, are kept wherever DuckDB accepts them: statements
+// are recorded in query history as submitted, and leading comments are carried
+// into the translated SQL. Only the parts DuckDB does not understand are
+// changed, such as // line comments, which are rewritten as -- comments.
+// Trailing semicolons and the comments after them are dropped before a
+// statement is run, since they would otherwise end up inside rewritten SQL.
+
+// splitLeadingComments splits sql into the whitespace and comments before its
+// first token, and the statement that follows them.
+func splitLeadingComments(sql string) (comments, statement string) {
+	i := 0
+	for i < len(sql) {
+		if isSpace(sql[i]) {
+			i++
+			continue
+		}
+		end, ok := commentEnd(sql, i)
+		if !ok {
+			break
+		}
+		i = end
+	}
+	return sql[:i], sql[i:]
+}
+
+// stripLeadingComments returns sql without the whitespace and comments before
+// its first token.
+func stripLeadingComments(sql string) string {
+	_, statement := splitLeadingComments(sql)
+	return statement
+}
+
+// normalizeStatement returns sql without the semicolons, whitespace, and
+// comments after its last token, so that every statement handler sees the
+// statement alone. Semicolons inside literals, quoted identifiers, $$ bodies,
+// and comments are kept. A statement with no tokens is returned unchanged.
+func normalizeStatement(sql string) string {
+	end := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case isSpace(c) || c == ';':
+			continue
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '$' && i+1 < len(sql) && sql[i+1] == '$':
+			closing := strings.Index(sql[i+2:], "$$")
+			if closing < 0 {
+				return sql
+			}
+			i += 2 + closing + 1
+		default:
+			if commentEnd, ok := commentEnd(sql, i); ok {
+				i = commentEnd - 1
+				continue
+			}
+		}
+		end = i + 1
+	}
+	if end == 0 {
+		return sql
+	}
+	return sql[:end]
+}
+
+// rewriteLineComments rewrites Snowflake's // line comments as -- comments,
+// which DuckDB accepts. Literals, quoted identifiers, $$ bodies, and other
+// comments are left unchanged.
+func rewriteLineComments(sql string) string {
+	if !strings.Contains(sql, "//") {
+		return sql
+	}
+
+	b := []byte(sql)
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '$' && i+1 < len(b) && b[i+1] == '$':
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				return string(b)
+			}
+			i += 2 + end + 1
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			b[i], b[i+1] = '-', '-'
+			fallthrough
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	return string(b)
+}
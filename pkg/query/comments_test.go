@@ -0,0 +1,213 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitLeadingComments(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		wantComments  string
+		wantStatement string
+	}{
+		{name: "no comments", sql: "SELECT 1", wantComments: "", wantStatement: "SELECT 1"},
+		{name: "block comment", sql: "/* dbt */ SELECT 1", wantComments: "/* dbt */ ", wantStatement: "SELECT 1"},
+		{name: "line comments", sql: "-- a\n// b\n  SELECT 1", wantComments: "-- a\n// b\n  ", wantStatement: "SELECT 1"},
+		{name: "trailing comment kept", sql: "SELECT 1 -- one", wantComments: "", wantStatement: "SELECT 1 -- one"},
+		{name: "only comments", sql: "/* a */ -- b", wantComments: "/* a */ -- b", wantStatement: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments, statement := splitLeadingComments(tt.sql)
+			if comments != tt.wantComments || statement != tt.wantStatement {
+				t.Errorf("splitLeadingComments(%q) = (%q, %q), want (%q, %q)", tt.sql, comments, statement, tt.wantComments, tt.wantStatement)
+			}
+		})
+	}
+}
+
+func TestRewriteLineComments(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "leading", sql: "// x\nSELECT 1", want: "-- x\nSELECT 1"},
+		{name: "trailing", sql: "SELECT 1 // one", want: "SELECT 1 -- one"},
+		{name: "in string", sql: "SELECT 's3://bucket/path'", want: "SELECT 's3://bucket/path'"},
+		{name: "in quoted identifier", sql: "SELECT 1 AS \"a//b\"", want: "SELECT 1 AS \"a//b\""},
+		{name: "in block comment", sql: "/* http://x */ SELECT 1", want: "/* http://x */ SELECT 1"},
+		{name: "in line comment", sql: "-- see http://x\nSELECT 1", want: "-- see http://x\nSELECT 1"},
+		{name: "in dollar body", sql: "CREATE FUNCTION f() RETURNS INT LANGUAGE JAVASCRIPT AS $$ // js\nreturn 1; $$", want: "CREATE FUNCTION f() RETURNS INT LANGUAGE JAVASCRIPT AS $$ // js\nreturn 1; $$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteLineComments(tt.sql); got != tt.want {
+				t.Errorf("rewriteLineComments(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "unchanged", sql: "SELECT 1", want: "SELECT 1"},
+		{name: "semicolon", sql: "SELECT 1;", want: "SELECT 1"},
+		{name: "semicolons and comment", sql: "SELECT 1 ;; -- done\n", want: "SELECT 1"},
+		{name: "block comment", sql: "SELECT 'a;' ; /* c */", want: "SELECT 'a;'"},
+		{name: "slash comment", sql: "SELECT 1 // one", want: "SELECT 1"},
+		{name: "inner comment kept", sql: "SELECT /* c */ 1;", want: "SELECT /* c */ 1"},
+		{name: "leading comment kept", sql: "/* dbt */ SELECT 1;", want: "/* dbt */ SELECT 1"},
+		{name: "quoted identifier", sql: `SELECT 1 AS "x;" -- y`, want: `SELECT 1 AS "x;"`},
+		{name: "dollar body", sql: "CREATE FUNCTION f() RETURNS INT AS $$ 1; -- x $$; ", want: "CREATE FUNCTION f() RETURNS INT AS $$ 1; -- x $$"},
+		{name: "only comments", sql: "-- nothing", want: "-- nothing"},
+		{name: "unterminated dollar body", sql: "SELECT $$ a; ", want: "SELECT $$ a; "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeStatement(tt.sql); got != tt.want {
+				t.Errorf("normalizeStatement(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslator_Comments(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "leading comment kept", sql: "/* {\"app\": \"dbt\"} */ SELECT IFF(a, 1, 2) FROM t", want: "/* {\"app\": \"dbt\"} */ select IF(a, 1, 2) from t"},
+		{name: "comment before ddl", sql: "-- model\nCREATE TABLE t (n NUMBER(10,2))", want: "-- model\nCREATE TABLE t (n DECIMAL(10,2))"},
+		{name: "slash comment before show", sql: "// listing\nSHOW TABLES", want: "-- listing\nSHOW TABLES"},
+	}
+
+	translator := NewTranslator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := translator.Translate(tt.sql)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExecutor_Comments(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"/* dbt */ CREATE TABLE commented (id INTEGER COMMENT 'key', v VARCHAR) COMMENT = 'tool generated'",
+		"// load\nINSERT INTO commented VALUES (1, 'a') // first row",
+		"/* upsert */ WITH s AS (SELECT 2 AS id, 'b' AS v) MERGE INTO commented USING s ON commented.id = s.id WHEN NOT MATCHED THEN INSERT (id, v) VALUES (s.id, s.v)",
+		"-- looker\nCREATE VIEW commented_view AS SELECT id FROM commented",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "/* dashboard */ SELECT v FROM commented ORDER BY v // trailing")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"a"}, {"b"}}, result.Rows); diff != "" {
+		t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+	}
+
+	columns, err := executor.Query(ctx, "-- columns\nSHOW COLUMNS IN TABLE commented")
+	if err != nil {
+		t.Fatalf("SHOW COLUMNS error = %v", err)
+	}
+	if len(columns.Rows) != 2 {
+		t.Errorf("SHOW COLUMNS returned %d rows, want 2", len(columns.Rows))
+	}
+
+	if _, err := executor.Execute(ctx, "/* cleanup */ DROP VIEW commented_view"); err != nil {
+		t.Fatalf("DROP VIEW error = %v", err)
+	}
+
+	// Query history keeps comments for attribution
+	sql := "/* {\"app\": \"dbt\", \"node_id\": \"model.x\"} */ SELECT 1"
+	if _, err := executor.QueryWithHistory(ctx, "1", "query-1", sql); err != nil {
+		t.Fatalf("QueryWithHistory() error = %v", err)
+	}
+	history, err := repo.GetQueryHistory(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetQueryHistory() error = %v", err)
+	}
+	if len(history) != 1 || history[0].SQLText != sql {
+		t.Errorf("GetQueryHistory() = %+v, want SQL text %q", history, sql)
+	}
+}
+
+func TestExecutor_TrailingSemicolons(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"CREATE TABLE semicolon_rows (id INTEGER, v VARCHAR);",
+		"INSERT INTO semicolon_rows VALUES (1, 'a;b'); /* first row */",
+		"CREATE PROCEDURE add_row() RETURNS VARCHAR LANGUAGE SQL AS $$ BEGIN INSERT INTO semicolon_rows VALUES (2, 'c'); RETURN 'ok'; END $$;",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Query(ctx, "CALL add_row(); -- load"); err != nil {
+		t.Fatalf("CALL error = %v", err)
+	}
+
+	queries := []string{
+		"SELECT v FROM semicolon_rows ORDER BY id;",
+		"SELECT v FROM semicolon_rows ORDER BY id ;; // done",
+	}
+	for _, sql := range queries {
+		result, err := executor.Query(ctx, sql)
+		if err != nil {
+			t.Fatalf("Query(%q) error = %v", sql, err)
+		}
+		if diff := cmp.Diff([][]interface{}{{"a;b"}, {"c"}}, result.Rows); diff != "" {
+			t.Errorf("Query(%q) mismatch (-want +got):\n%s", sql, diff)
+		}
+	}
+
+	columns, err := executor.Query(ctx, "SHOW COLUMNS IN TABLE semicolon_rows; -- columns")
+	if err != nil {
+		t.Fatalf("SHOW COLUMNS error = %v", err)
+	}
+	if len(columns.Rows) != 2 {
+		t.Errorf("SHOW COLUMNS returned %d rows, want 2", len(columns.Rows))
+	}
+
+	// Query history keeps the statement as sent
+	sql := "SELECT 1; -- probe"
+	if _, err := executor.QueryWithHistory(ctx, "1", "query-1", sql); err != nil {
+		t.Fatalf("QueryWithHistory() error = %v", err)
+	}
+	history, err := repo.GetQueryHistory(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetQueryHistory() error = %v", err)
+	}
+	if len(history) != 1 || history[0].SQLText != sql {
+		t.Errorf("GetQueryHistory() = %+v, want SQL text %q", history, sql)
+	}
+}
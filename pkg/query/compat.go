@@ -0,0 +1,243 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CompatKind is the way the emulator failed to handle a statement faithfully.
+type CompatKind string
+
+const (
+	// CompatFallback is a statement the translator could not parse, which was
+	// passed to DuckDB untranslated.
+	CompatFallback CompatKind = "translation_fallback"
+	// CompatUnsupported is a statement that failed on a feature the emulator
+	// does not support.
+	CompatUnsupported CompatKind = "unsupported_feature"
+)
+
+// Limits of a compatibility report, which bound its memory over a long run.
+const (
+	// maxCompatEntries is the most distinct statements a report lists. Later
+	// statements are only counted as dropped.
+	maxCompatEntries = 1000
+	// maxCompatStatementLength is the length statements are truncated to.
+	maxCompatStatementLength = 2000
+)
+
+// CompatEntry is a statement of a compatibility report.
+type CompatEntry struct {
+	Kind      CompatKind `json:"kind"`
+	Statement string     `json:"statement"`
+	// Feature is the unsupported function the statement called, when known.
+	Feature string `json:"feature,omitempty"`
+	// Detail is the parser's error of a fallback, or the error of a statement
+	// that failed, on its first occurrence.
+	Detail    string    `json:"detail"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// CompatSnapshot is the content of a compatibility report at a point in time.
+type CompatSnapshot struct {
+	StartedAt   time.Time `json:"startedAt"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Entries are ordered by kind, then most frequent first.
+	Entries []CompatEntry `json:"entries"`
+	// Dropped counts the occurrences of statements left out because the
+	// report was full.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// compatKey identifies the entry of a statement.
+type compatKey struct {
+	kind      CompatKind
+	statement string
+}
+
+// CompatReport accumulates, over the life of a process, the statements the
+// emulator did not handle faithfully: those passed to DuckDB untranslated
+// because the translator could not parse them, and those that failed on an
+// unsupported feature. Teams read it after a test run to see which parts of
+// their SQL the emulator did not cover. It is safe for concurrent use.
+type CompatReport struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	entries   map[compatKey]*CompatEntry
+	dropped   int
+}
+
+// NewCompatReport creates an empty compatibility report.
+func NewCompatReport() *CompatReport {
+	return &CompatReport{startedAt: time.Now(), entries: make(map[compatKey]*CompatEntry)}
+}
+
+// WithCompatReport makes the executor record the statements it does not
+// handle faithfully in report.
+func WithCompatReport(report *CompatReport) ExecutorOption {
+	return func(e *Executor) {
+		e.compat = report
+	}
+}
+
+// CompatReport returns the executor's compatibility report, or nil if it
+// keeps none.
+func (e *Executor) CompatReport() *CompatReport {
+	return e.compat
+}
+
+// recordCompat records a statement that failed on an unsupported feature in
+// the executor's compatibility report, if it keeps one.
+func (e *Executor) recordCompat(sql string, err error) {
+	if e.compat == nil || err == nil {
+		return
+	}
+	if feature, ok := unsupportedFeature(err); ok {
+		e.compat.record(CompatUnsupported, sql, feature, err)
+	}
+}
+
+// unsupportedFeature reports whether err is a failure on a feature the
+// emulator or DuckDB does not support, and the feature when it is known.
+func unsupportedFeature(err error) (string, bool) {
+	var function *UnsupportedFunctionError
+	if errors.As(err, &function) {
+		return function.Function.Name, true
+	}
+	message := err.Error()
+	return "", strings.Contains(message, "not supported") || strings.Contains(message, "Not implemented Error")
+}
+
+// record counts an occurrence of a statement.
+func (r *CompatReport) record(kind CompatKind, sql, feature string, err error) {
+	statement := strings.TrimSpace(sql)
+	if len(statement) > maxCompatStatementLength {
+		statement = statement[:maxCompatStatementLength] + "..."
+	}
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := compatKey{kind: kind, statement: statement}
+	entry, ok := r.entries[key]
+	if !ok {
+		if len(r.entries) >= maxCompatEntries {
+			r.dropped++
+			return
+		}
+		entry = &CompatEntry{Kind: kind, Statement: statement, Feature: feature, Detail: err.Error(), FirstSeen: now}
+		r.entries[key] = entry
+	}
+	entry.Count++
+	entry.LastSeen = now
+}
+
+// Snapshot returns the report's content.
+func (r *CompatReport) Snapshot() CompatSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot := CompatSnapshot{
+		StartedAt:   r.startedAt,
+		GeneratedAt: time.Now(),
+		Entries:     make([]CompatEntry, 0, len(r.entries)),
+		Dropped:     r.dropped,
+	}
+	for _, entry := range r.entries {
+		snapshot.Entries = append(snapshot.Entries, *entry)
+	}
+	sort.Slice(snapshot.Entries, func(i, j int) bool {
+		a, b := snapshot.Entries[i], snapshot.Entries[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.FirstSeen.Before(b.FirstSeen)
+	})
+	return snapshot
+}
+
+// WriteJSON writes the report as JSON.
+func (r *CompatReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(r.Snapshot())
+}
+
+// WriteMarkdown writes the report as a Markdown document with a table of the
+// statements of each kind.
+func (r *CompatReport) WriteMarkdown(w io.Writer) error {
+	snapshot := r.Snapshot()
+	var b strings.Builder
+	b.WriteString("# SQL compatibility report\n\n")
+	fmt.Fprintf(&b, "Statements the emulator did not handle faithfully from %s to %s.\n",
+		snapshot.StartedAt.Format(time.RFC3339), snapshot.GeneratedAt.Format(time.RFC3339))
+
+	sections := []struct {
+		kind  CompatKind
+		title string
+	}{
+		{CompatFallback, "Translation fallbacks"},
+		{CompatUnsupported, "Unsupported features"},
+	}
+	for _, section := range sections {
+		var entries []CompatEntry
+		for _, entry := range snapshot.Entries {
+			if entry.Kind == section.kind {
+				entries = append(entries, entry)
+			}
+		}
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", section.title, len(entries))
+		if len(entries) == 0 {
+			b.WriteString("None.\n")
+			continue
+		}
+		b.WriteString("| Count | Statement | Feature | Detail |\n|---:|---|---|---|\n")
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "| %d | `%s` | %s | %s |\n",
+				entry.Count, markdownCell(entry.Statement), markdownCell(entry.Feature), markdownCell(entry.Detail))
+		}
+	}
+	if snapshot.Dropped > 0 {
+		fmt.Fprintf(&b, "\n%d more occurrences of other statements were not listed.\n", snapshot.Dropped)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell renders text on one line of a Markdown table cell.
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "`", "'")
+}
+
+// WriteFile writes the report to path, as Markdown if its extension is .md
+// and as JSON otherwise.
+func (r *CompatReport) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create compatibility report: %w", err)
+	}
+	write := r.WriteJSON
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		write = r.WriteMarkdown
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write compatibility report: %w", err)
+	}
+	return f.Close()
+}
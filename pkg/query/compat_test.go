@@ -0,0 +1,97 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// TestExecutor_CompatReport tests that the compatibility report records the
+// statements that hit translation fallbacks or fail on unsupported features,
+// once per statement with a count.
+func TestExecutor_CompatReport(t *testing.T) {
+	report := NewCompatReport()
+	executor, _ := setupTestExecutor(t, WithCompatReport(report))
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := executor.Query(ctx, "SELECT * FROM range(3)"); err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+	}
+	if _, err := executor.Query(ctx, "SELECT PARSE_XML('<a/>')"); err == nil {
+		t.Fatal("Query() of an unsupported function succeeded")
+	}
+	if _, err := executor.Query(ctx, "SELECT 1"); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if _, err := executor.Query(ctx, "SELECT * FROM missing_table"); err == nil {
+		t.Fatal("Query() of a missing table succeeded")
+	}
+
+	got := report.Snapshot()
+	want := []CompatEntry{
+		{Kind: CompatFallback, Statement: "SELECT * FROM range(3)", Count: 2},
+		{Kind: CompatUnsupported, Statement: "SELECT PARSE_XML('<a/>')", Feature: "PARSE_XML", Count: 1},
+	}
+	if diff := cmp.Diff(want, got.Entries, cmpopts.IgnoreFields(CompatEntry{}, "Detail", "FirstSeen", "LastSeen")); diff != "" {
+		t.Errorf("report entries mismatch (-want +got):\n%s", diff)
+	}
+	for _, entry := range got.Entries {
+		if entry.Detail == "" || entry.LastSeen.Before(entry.FirstSeen) {
+			t.Errorf("entry %q: detail %q, first seen %v, last seen %v", entry.Statement, entry.Detail, entry.FirstSeen, entry.LastSeen)
+		}
+	}
+
+	var markdown bytes.Buffer
+	if err := report.WriteMarkdown(&markdown); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	for _, want := range []string{"## Translation fallbacks (1)", "| 2 | `SELECT * FROM range(3)` |", "## Unsupported features (1)", "| PARSE_XML |"} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("WriteMarkdown() = %s, want it to contain %q", markdown.String(), want)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "compat.json")
+	if err := report.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !strings.Contains(string(data), `"kind": "translation_fallback"`) {
+		t.Errorf("JSON report = %s, want the fallback entry", data)
+	}
+}
+
+// TestCompatReport_Limits tests that a full report counts the occurrences of
+// further statements as dropped, and truncates long statements.
+func TestCompatReport_Limits(t *testing.T) {
+	report := NewCompatReport()
+	for i := range maxCompatEntries + 2 {
+		report.record(CompatFallback, strings.Repeat("x", i+1), "", os.ErrInvalid)
+	}
+	report.record(CompatFallback, "x", "", os.ErrInvalid)
+
+	got := report.Snapshot()
+	if len(got.Entries) != maxCompatEntries || got.Dropped != 2 {
+		t.Errorf("entries = %d, dropped = %d, want %d and 2", len(got.Entries), got.Dropped, maxCompatEntries)
+	}
+	if got.Entries[0].Statement != "x" || got.Entries[0].Count != 2 {
+		t.Errorf("most frequent entry = %q x%d, want x x2", got.Entries[0].Statement, got.Entries[0].Count)
+	}
+
+	report = NewCompatReport()
+	report.record(CompatFallback, strings.Repeat("y", maxCompatStatementLength+10), "", os.ErrInvalid)
+	if statement := report.Snapshot().Entries[0].Statement; len(statement) != maxCompatStatementLength+len("...") {
+		t.Errorf("truncated statement length = %d, want %d", len(statement), maxCompatStatementLength+len("..."))
+	}
+}
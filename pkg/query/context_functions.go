@@ -0,0 +1,76 @@
+package query
+
+import (
+	"context"
+	"strings"
+)
+
+// contextFunctions maps the Snowflake context functions the emulator answers
+// from the session to the session value they return.
+var contextFunctions = map[string]func(SessionInfo) string{
+	"CURRENT_DATABASE":  func(info SessionInfo) string { return info.Database },
+	"CURRENT_SCHEMA":    func(info SessionInfo) string { return info.Schema },
+	"CURRENT_WAREHOUSE": func(info SessionInfo) string { return info.Warehouse },
+	"CURRENT_ROLE":      func(info SessionInfo) string { return info.Role },
+	"CURRENT_USER":      func(info SessionInfo) string { return strings.ToUpper(info.User) },
+	"CURRENT_SESSION":   func(info SessionInfo) string { return info.ID },
+}
+
+// bindContextFunctions replaces calls of the context functions, such as
+// CURRENT_DATABASE() and CURRENT_ROLE(), with the values of the session
+// running the statement, or NULL where the session has none, like Snowflake
+// without a current database. DuckDB has functions of the same names that
+// describe its own catalog, so the calls are never passed on. Literals,
+// comments, and $$ bodies are left alone.
+func bindContextFunctions(ctx context.Context, sql string) string {
+	if indexFold(sql, "CURRENT_") < 0 {
+		return sql
+	}
+	info := SessionInfoFromContext(ctx)
+
+	var b strings.Builder
+	copied := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '$' && strings.HasPrefix(sql[i:], "$$"):
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				i = len(sql)
+				continue
+			}
+			i += 2 + end + 1
+		case isVariableStart(c) && (i == 0 || (!isIdentChar(sql[i-1]) && sql[i-1] != '.' && sql[i-1] != ':')):
+			end := variableEnd(sql, i)
+			value, ok := contextFunctions[strings.ToUpper(sql[i:end])]
+			open := end
+			for open < len(sql) && isSpace(sql[open]) {
+				open++
+			}
+			closing := open + 1
+			for closing < len(sql) && isSpace(sql[closing]) {
+				closing++
+			}
+			if !ok || open >= len(sql) || sql[open] != '(' || closing >= len(sql) || sql[closing] != ')' {
+				i = end - 1
+				continue
+			}
+			b.WriteString(sql[copied:i])
+			if v := value(info); v != "" {
+				b.WriteString(quoteLiteral(v))
+			} else {
+				b.WriteString("NULL")
+			}
+			copied = closing + 1
+			i = closing
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	b.WriteString(sql[copied:])
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_ContextFunctions tests that the context functions return the
+// values of the session running the statement.
+func TestExecutor_ContextFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	session := ContextWithSessionInfo(context.Background(), SessionInfo{
+		ID: "42", User: "tester", Role: "SYSADMIN", Database: "SALES", Schema: "STAGING", Warehouse: "COMPUTE_WH",
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		sql  string
+		want [][]interface{}
+	}{
+		{
+			name: "Session",
+			ctx:  session,
+			sql:  "SELECT CURRENT_DATABASE(), current_schema(), CURRENT_WAREHOUSE( ), CURRENT_ROLE(), CURRENT_USER(), CURRENT_SESSION()",
+			want: [][]interface{}{{"SALES", "STAGING", "COMPUTE_WH", "SYSADMIN", "TESTER", "42"}},
+		},
+		{
+			name: "WithoutSession",
+			ctx:  context.Background(),
+			sql:  "SELECT CURRENT_DATABASE(), CURRENT_SCHEMA()",
+			want: [][]interface{}{{nil, nil}},
+		},
+		{
+			name: "InExpressions",
+			ctx:  session,
+			sql:  "SELECT CURRENT_DATABASE() = 'SALES', 'CURRENT_DATABASE()' -- CURRENT_ROLE()\n",
+			want: [][]interface{}{{true, "CURRENT_DATABASE()"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(tt.ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if diff := cmp.Diff(tt.want, result.Rows); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
@@ -29,6 +32,10 @@ type CopyStatement struct {
 	OnError        string   // CONTINUE, SKIP_FILE, ABORT
 	PurgeFiles     bool     // Whether to purge files after loading
 	ValidationMode bool     // Whether to validate only
+	// MatchByColumnName loads the fields of JSON and Parquet records into the
+	// target columns of the same name: CASE_SENSITIVE, CASE_INSENSITIVE, or
+	// NONE.
+	MatchByColumnName string
 }
 
 // FileFormatOptions contains file format settings for COPY.
@@ -52,6 +59,7 @@ type copyPatterns struct {
 	fileFormat      *regexp.Regexp
 	pattern         *regexp.Regexp
 	onError         *regexp.Regexp
+	matchByColumn   *regexp.Regexp
 	formatType      *regexp.Regexp
 	fieldDelimiter  *regexp.Regexp
 	recordDelimiter *regexp.Regexp
@@ -65,7 +73,8 @@ func newCopyPatterns() *copyPatterns {
 		fileFormat:      regexp.MustCompile(`(?i)FILE_FORMAT\s*=\s*\(([^)]+)\)`),
 		pattern:         regexp.MustCompile(`(?i)PATTERN\s*=\s*'([^']+)'`),
 		onError:         regexp.MustCompile(`(?i)ON_ERROR\s*=\s*(\w+)`),
-		formatType:      regexp.MustCompile(`(?i)TYPE\s*=\s*(\w+)`),
+		matchByColumn:   regexp.MustCompile(`(?i)MATCH_BY_COLUMN_NAME\s*=\s*'?(\w+)'?`),
+		formatType:      regexp.MustCompile(`(?i)TYPE\s*=\s*'?(\w+)'?`),
 		fieldDelimiter:  regexp.MustCompile(`(?i)FIELD_DELIMITER\s*=\s*'([^']*)'`),
 		recordDelimiter: regexp.MustCompile(`(?i)RECORD_DELIMITER\s*=\s*'([^']*)'`),
 		skipHeader:      regexp.MustCompile(`(?i)SKIP_HEADER\s*=\s*(\d+)`),
@@ -89,21 +98,42 @@ func extractMatchUpper(re *regexp.Regexp, input, defaultVal string) string {
 // CopyProcessor handles COPY INTO operations.
 type CopyProcessor struct {
 	stageMgr   *stage.Manager
-	repo       *metadata.Repository
+	repo       metadata.Store
 	executor   *Executor
 	tableNamer *DefaultTableNamer
 	patterns   *copyPatterns
+	workers    int // Maximum number of files loaded concurrently
+}
+
+// DefaultCopyWorkers is the default maximum number of files a COPY INTO
+// statement loads concurrently.
+const DefaultCopyWorkers = 4
+
+// CopyProcessorOption configures a CopyProcessor.
+type CopyProcessorOption func(*CopyProcessor)
+
+// WithCopyWorkers sets the maximum number of files a COPY INTO statement
+// loads concurrently. A value of 1 loads files one at a time.
+func WithCopyWorkers(n int) CopyProcessorOption {
+	return func(h *CopyProcessor) {
+		h.workers = n
+	}
 }
 
 // NewCopyProcessor creates a new COPY handler.
-func NewCopyProcessor(stageMgr *stage.Manager, repo *metadata.Repository, executor *Executor) *CopyProcessor {
-	return &CopyProcessor{
+func NewCopyProcessor(stageMgr *stage.Manager, repo metadata.Store, executor *Executor, opts ...CopyProcessorOption) *CopyProcessor {
+	h := &CopyProcessor{
 		stageMgr:   stageMgr,
 		repo:       repo,
 		executor:   executor,
 		tableNamer: NewTableNamer(),
 		patterns:   newCopyPatterns(),
+		workers:    DefaultCopyWorkers,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // ParseCopyStatement parses a COPY INTO SQL statement.
@@ -124,7 +154,8 @@ func (h *CopyProcessor) ParseCopyStatement(sql string) (*CopyStatement, error) {
 			RecordDelimiter: "\n",
 			SkipHeader:      0,
 		},
-		OnError: "ABORT",
+		OnError:           "ABORT",
+		MatchByColumnName: "NONE",
 	}
 
 	// Parse table name (may include database.schema.table)
@@ -161,6 +192,18 @@ func (h *CopyProcessor) ParseCopyStatement(sql string) (*CopyStatement, error) {
 	// Parse ON_ERROR
 	stmt.OnError = extractMatchUpper(h.patterns.onError, sql, "ABORT")
 
+	// Parse MATCH_BY_COLUMN_NAME
+	stmt.MatchByColumnName = extractMatchUpper(h.patterns.matchByColumn, sql, "NONE")
+	switch stmt.MatchByColumnName {
+	case "NONE":
+	case "CASE_SENSITIVE", "CASE_INSENSITIVE":
+		if stmt.FileFormat.Type != "JSON" && stmt.FileFormat.Type != "PARQUET" {
+			return nil, fmt.Errorf("MATCH_BY_COLUMN_NAME is not supported for %s files", stmt.FileFormat.Type)
+		}
+	default:
+		return nil, fmt.Errorf("invalid MATCH_BY_COLUMN_NAME: %s", stmt.MatchByColumnName)
+	}
+
 	// Parse PURGE
 	if strings.Contains(strings.ToUpper(sql), "PURGE = TRUE") {
 		stmt.PurgeFiles = true
@@ -211,9 +254,8 @@ func (h *CopyProcessor) parseFileFormatOptions(opts *FileFormatOptions, optStr s
 	}
 }
 
-// ExecuteCopyInto executes a COPY INTO statement.
-//
-//nolint:gocyclo // complex file loading logic with multiple format handlers
+// ExecuteCopyInto executes a COPY INTO statement. The matching files are
+// loaded concurrently, and the result reports each of them in file order.
 func (h *CopyProcessor) ExecuteCopyInto(ctx context.Context, stmt *CopyStatement, defaultSchemaID string) (*CopyResult, error) {
 	result := &CopyResult{}
 
@@ -253,41 +295,44 @@ func (h *CopyProcessor) ExecuteCopyInto(ctx context.Context, stmt *CopyStatement
 		return result, nil // No files to load
 	}
 
-	// Load each file
-	for _, file := range files {
-		var rowsLoaded int64
-		var loadErr error
-
-		switch strings.ToUpper(stmt.FileFormat.Type) {
-		case "CSV":
-			rowsLoaded, loadErr = h.loadCSVFile(ctx, stmt, schemaID, file.Name)
-		case "JSON":
-			rowsLoaded, loadErr = h.loadJSONFile(ctx, stmt, schemaID, file.Name)
-		default:
-			loadErr = fmt.Errorf("unsupported file format: %s", stmt.FileFormat.Type)
+	// Load the files concurrently, then aggregate their outcomes in file order.
+	// A canceled statement fails whatever its ON_ERROR
+	loads := h.loadFiles(ctx, stmt, schemaID, files)
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if stmt.OnError != "CONTINUE" && stmt.OnError != "SKIP_FILE" {
+		if failed := firstFailedLoad(loads); failed != nil {
+			return result, fmt.Errorf("error loading file %s: %w", failed.file, failed.err)
 		}
+	}
 
-		if loadErr != nil {
-			switch stmt.OnError {
-			case "CONTINUE":
-				result.Errors = append(result.Errors, fmt.Sprintf("File %s: %v", file.Name, loadErr))
-				continue
-			case "SKIP_FILE":
-				result.Errors = append(result.Errors, fmt.Sprintf("Skipped file %s: %v", file.Name, loadErr))
-				continue
-			default: // ABORT
-				return result, fmt.Errorf("error loading file %s: %w", file.Name, loadErr)
+	for _, load := range loads {
+		fileResult := CopyFileResult{File: load.file, Status: CopyFileLoaded, RowsLoaded: load.rowsLoaded}
+		if load.err != nil {
+			fileResult.Status = CopyFileLoadFailed
+			fileResult.Error = load.err.Error()
+			if stmt.OnError == "CONTINUE" {
+				if load.rowsLoaded > 0 {
+					fileResult.Status = CopyFilePartiallyLoaded
+				}
+				result.Errors = append(result.Errors, fmt.Sprintf("File %s: %v", load.file, load.err))
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("Skipped file %s: %v", load.file, load.err))
 			}
+			result.Files = append(result.Files, fileResult)
+			continue
 		}
+		result.Files = append(result.Files, fileResult)
 
-		result.RowsLoaded += rowsLoaded
-		result.RowsInserted += rowsLoaded
+		result.RowsLoaded += load.rowsLoaded
+		result.RowsInserted += load.rowsLoaded
 		result.FilesLoaded++
 
 		// Purge file if requested
 		if stmt.PurgeFiles && !stmt.ValidationMode {
-			if err := h.stageMgr.RemoveFile(ctx, schemaID, stmt.StageName, file.Name); err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("Failed to purge %s: %v", file.Name, err))
+			if err := h.stageMgr.RemoveFile(ctx, schemaID, stmt.StageName, load.file); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("Failed to purge %s: %v", load.file, err))
 			}
 		}
 	}
@@ -295,6 +340,84 @@ func (h *CopyProcessor) ExecuteCopyInto(ctx context.Context, stmt *CopyStatement
 	return result, nil
 }
 
+// fileLoad is the outcome of loading one staged file.
+type fileLoad struct {
+	file       string
+	rowsLoaded int64
+	err        error
+}
+
+// loadFiles loads staged files with a pool of at most h.workers goroutines
+// and returns their outcomes in file order. With ON_ERROR = ABORT, the first
+// failure cancels the files not yet loaded.
+func (h *CopyProcessor) loadFiles(ctx context.Context, stmt *CopyStatement, schemaID string, files []stage.StageFile) []fileLoad {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	abort := stmt.OnError != "CONTINUE" && stmt.OnError != "SKIP_FILE"
+
+	loads := make([]fileLoad, len(files))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(h.workers, 1), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				loads[i] = fileLoad{file: files[i].Name}
+				if err := ctx.Err(); err != nil {
+					loads[i].err = err
+					continue
+				}
+				loads[i].rowsLoaded, loads[i].err = h.loadFile(ctx, stmt, schemaID, files[i].Name)
+				if loads[i].err != nil && abort {
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return loads
+}
+
+// loadFile loads a staged file in the statement's file format.
+func (h *CopyProcessor) loadFile(ctx context.Context, stmt *CopyStatement, schemaID, fileName string) (int64, error) {
+	switch strings.ToUpper(stmt.FileFormat.Type) {
+	case "CSV":
+		return h.loadCSVFile(ctx, stmt, schemaID, fileName)
+	case "JSON":
+		if stmt.MatchByColumnName != "" && stmt.MatchByColumnName != "NONE" {
+			return h.loadFileWithDuckDB(ctx, stmt, schemaID, fileName, "read_json(%s, format = 'auto')")
+		}
+		return h.loadJSONFile(ctx, stmt, schemaID, fileName)
+	case "PARQUET":
+		return h.loadFileWithDuckDB(ctx, stmt, schemaID, fileName, "read_parquet(%s)")
+	default:
+		return 0, fmt.Errorf("unsupported file format: %s", stmt.FileFormat.Type)
+	}
+}
+
+// firstFailedLoad returns the first failed load in file order, preferring a
+// load that failed on its own over one canceled because another file failed.
+func firstFailedLoad(loads []fileLoad) *fileLoad {
+	var canceled *fileLoad
+	for i := range loads {
+		switch {
+		case loads[i].err == nil:
+		case errors.Is(loads[i].err, context.Canceled):
+			if canceled == nil {
+				canceled = &loads[i]
+			}
+		default:
+			return &loads[i]
+		}
+	}
+	return canceled
+}
+
 // loadCSVFile loads a CSV file into the target table.
 //
 //nolint:gocyclo // CSV parsing logic with multiple format options
@@ -338,6 +461,10 @@ func (h *CopyProcessor) loadCSVFile(ctx context.Context, stmt *CopyStatement, sc
 
 	var rowsInserted int64
 	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return rowsInserted, err
+		}
+
 		// Build VALUES clause
 		values := make([]string, len(record))
 		for i, val := range record {
@@ -425,6 +552,10 @@ func (h *CopyProcessor) loadJSONFile(ctx context.Context, stmt *CopyStatement, s
 
 	var rowsInserted int64
 	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return rowsInserted, err
+		}
+
 		// Convert record to JSON string for VARIANT column
 		jsonBytes, err := json.Marshal(record)
 		if err != nil {
@@ -443,3 +574,80 @@ func (h *CopyProcessor) loadJSONFile(ctx context.Context, stmt *CopyStatement, s
 
 	return rowsInserted, nil
 }
+
+// loadFileWithDuckDB loads a staged file with a DuckDB table function such as
+// read_parquet, whose format takes the file's quoted path. Without
+// MATCH_BY_COLUMN_NAME, each record is loaded as a JSON object into the
+// target's only column, as Snowflake loads semi-structured files into a
+// VARIANT column. With it, each target column is loaded from the record field
+// of the same name, or NULL when the file has none.
+func (h *CopyProcessor) loadFileWithDuckDB(ctx context.Context, stmt *CopyStatement, schemaID, fileName, tableFunction string) (int64, error) {
+	dir, err := h.stageMgr.GetStageDirectory(ctx, schemaID, stmt.StageName)
+	if err != nil {
+		return 0, err
+	}
+	cleanName := filepath.Clean(fileName)
+	if strings.HasPrefix(cleanName, "..") || filepath.IsAbs(cleanName) {
+		return 0, fmt.Errorf("invalid file name: %s", fileName)
+	}
+	source := fmt.Sprintf(tableFunction, quoteLiteral(filepath.Join(dir, cleanName)))
+
+	tableName := h.tableNamer.BuildDuckDBTableName(stmt.TargetDatabase, stmt.TargetSchema, stmt.TargetTable)
+	targetColumns, err := h.describeColumns(ctx, "SELECT * FROM "+tableName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe target table: %w", err)
+	}
+
+	var selectList []string
+	if stmt.MatchByColumnName == "" || stmt.MatchByColumnName == "NONE" {
+		if len(targetColumns) != 1 {
+			return 0, fmt.Errorf("%s file format can produce one and only one column of type variant, object, or array; "+
+				"use MATCH_BY_COLUMN_NAME to load separate columns", stmt.FileFormat.Type)
+		}
+		selectList = []string{"to_json(f)"}
+	} else {
+		fileColumns, err := h.describeColumns(ctx, "SELECT * FROM "+source)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s file: %w", stmt.FileFormat.Type, err)
+		}
+		for _, target := range targetColumns {
+			expr := ValueNull
+			for _, field := range fileColumns {
+				if field.name == target.name || (stmt.MatchByColumnName == "CASE_INSENSITIVE" && strings.EqualFold(field.name, target.name)) {
+					expr = "f." + quoteIdent(field.name)
+					if target.dataType == "JSON" {
+						expr = "to_json(" + expr + ")"
+					}
+					break
+				}
+			}
+			selectList = append(selectList, expr)
+		}
+	}
+
+	insertSQL := fmt.Sprintf("INSERT INTO %s SELECT %s FROM %s AS f", tableName, strings.Join(selectList, ", "), source)
+	result, err := h.executor.manager(ctx).Exec(ctx, insertSQL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s file: %w", stmt.FileFormat.Type, err)
+	}
+	return result.RowsAffected()
+}
+
+// copyColumn is a column of a COPY target or source file.
+type copyColumn struct {
+	name     string
+	dataType string
+}
+
+// describeColumns returns the columns of a DuckDB query.
+func (h *CopyProcessor) describeColumns(ctx context.Context, query string) ([]copyColumn, error) {
+	result, err := h.executor.queryRows(ctx, "DESCRIBE "+query)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]copyColumn, len(result.Rows))
+	for i, row := range result.Rows {
+		columns[i] = copyColumn{name: fmt.Sprint(row[0]), dataType: fmt.Sprint(row[1])}
+	}
+	return columns, nil
+}
@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
@@ -184,6 +189,32 @@ func TestCopyProcessor_ParseCopyStatement(t *testing.T) {
 				OnError: "ABORT",
 			},
 		},
+		{
+			name: "CopyParquetMatchByColumnName",
+			sql:  "COPY INTO my_table FROM @my_stage FILE_FORMAT = (TYPE = 'PARQUET') MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE",
+			want: &CopyStatement{
+				TargetTable: "MY_TABLE",
+				StageName:   "MY_STAGE",
+				FileFormat: FileFormatOptions{
+					Type:            "PARQUET",
+					FieldDelimiter:  ",",
+					RecordDelimiter: "\n",
+					SkipHeader:      0,
+				},
+				OnError:           "ABORT",
+				MatchByColumnName: "CASE_INSENSITIVE",
+			},
+		},
+		{
+			name:    "MatchByColumnNameCSV",
+			sql:     "COPY INTO my_table FROM @my_stage MATCH_BY_COLUMN_NAME = CASE_SENSITIVE",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidMatchByColumnName",
+			sql:     "COPY INTO my_table FROM @my_stage FILE_FORMAT = (TYPE = PARQUET) MATCH_BY_COLUMN_NAME = SOMETIMES",
+			wantErr: true,
+		},
 		{
 			name:    "InvalidSyntax",
 			sql:     "COPY FROM somewhere",
@@ -228,6 +259,9 @@ func TestCopyProcessor_ParseCopyStatement(t *testing.T) {
 			if got.OnError != tc.want.OnError {
 				t.Errorf("OnError: got %s, want %s", got.OnError, tc.want.OnError)
 			}
+			if tc.want.MatchByColumnName != "" && got.MatchByColumnName != tc.want.MatchByColumnName {
+				t.Errorf("MatchByColumnName: got %s, want %s", got.MatchByColumnName, tc.want.MatchByColumnName)
+			}
 			if got.PurgeFiles != tc.want.PurgeFiles {
 				t.Errorf("PurgeFiles: got %v, want %v", got.PurgeFiles, tc.want.PurgeFiles)
 			}
@@ -390,6 +424,115 @@ func TestCopyProcessor_ExecuteCopyJSON(t *testing.T) {
 	}
 }
 
+func TestCopyProcessor_ExecuteCopyParquetAndJSON(t *testing.T) {
+	handler, stageMgr, repo, tempDir, cleanup := setupCopyProcessorTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	db, _ := repo.CreateDatabase(ctx, "LOAD_DB", "")
+	schema, _ := repo.CreateSchema(ctx, db.ID, "PUBLIC", "")
+	_, _ = stageMgr.CreateStage(ctx, schema.ID, "LOAD_STAGE", "INTERNAL", "", "")
+
+	// Write a Parquet file with DuckDB and stage it
+	parquetPath := tempDir + "/users.parquet"
+	if _, err := handler.executor.mgr.Exec(ctx, fmt.Sprintf(
+		"COPY (SELECT * FROM (VALUES (1, 'Alice', {'tier': 'gold'}), (2, 'Bob', {'tier': 'silver'})) AS t(ID, Name, Attrs)) TO '%s' (FORMAT PARQUET)",
+		parquetPath)); err != nil {
+		t.Fatalf("Failed to write Parquet file: %v", err)
+	}
+	parquetData, err := os.ReadFile(parquetPath)
+	if err != nil {
+		t.Fatalf("Failed to read Parquet file: %v", err)
+	}
+	_ = stageMgr.PutFile(ctx, schema.ID, "LOAD_STAGE", "users.parquet", bytes.NewReader(parquetData))
+	_ = stageMgr.PutFile(ctx, schema.ID, "LOAD_STAGE", "users.json", bytes.NewReader([]byte(
+		"{\"id\": 1, \"name\": \"Alice\", \"attrs\": {\"tier\": \"gold\"}}\n{\"id\": 2, \"name\": \"Bob\", \"attrs\": {\"tier\": \"silver\"}}\n")))
+
+	tests := []struct {
+		name    string
+		columns string
+		sql     string
+		want    [][]interface{}
+		wantErr bool
+	}{
+		{
+			name:    "ParquetIntoVariant",
+			columns: "(v VARIANT)",
+			sql:     "COPY INTO LOAD_DB.PUBLIC.%s FROM @LOAD_STAGE FILE_FORMAT = (TYPE = PARQUET) PATTERN = 'users.parquet'",
+			want: [][]interface{}{
+				{map[string]interface{}{"ID": float64(1), "Name": "Alice", "Attrs": map[string]interface{}{"tier": "gold"}}},
+				{map[string]interface{}{"ID": float64(2), "Name": "Bob", "Attrs": map[string]interface{}{"tier": "silver"}}},
+			},
+		},
+		{
+			name:    "ParquetWithoutMatchByColumnName",
+			columns: "(id INTEGER, name VARCHAR)",
+			sql:     "COPY INTO LOAD_DB.PUBLIC.%s FROM @LOAD_STAGE FILE_FORMAT = (TYPE = PARQUET) PATTERN = 'users.parquet'",
+			wantErr: true,
+		},
+		{
+			name:    "ParquetCaseInsensitive",
+			columns: "(id INTEGER, name VARCHAR, attrs VARIANT, missing VARCHAR)",
+			sql:     "COPY INTO LOAD_DB.PUBLIC.%s FROM @LOAD_STAGE FILE_FORMAT = (TYPE = PARQUET) PATTERN = 'users.parquet' MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE",
+			want: [][]interface{}{
+				{int64(1), "Alice", map[string]interface{}{"tier": "gold"}, nil},
+				{int64(2), "Bob", map[string]interface{}{"tier": "silver"}, nil},
+			},
+		},
+		{
+			name:    "ParquetCaseSensitive",
+			columns: "(ID INTEGER, name VARCHAR)",
+			sql:     "COPY INTO LOAD_DB.PUBLIC.%s FROM @LOAD_STAGE FILE_FORMAT = (TYPE = PARQUET) PATTERN = 'users.parquet' MATCH_BY_COLUMN_NAME = CASE_SENSITIVE",
+			want:    [][]interface{}{{int64(1), nil}, {int64(2), nil}},
+		},
+		{
+			name:    "JSONCaseInsensitive",
+			columns: "(ID INTEGER, NAME VARCHAR, ATTRS VARIANT)",
+			sql:     "COPY INTO LOAD_DB.PUBLIC.%s FROM @LOAD_STAGE FILE_FORMAT = (TYPE = JSON) PATTERN = 'users.json' MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE",
+			want: [][]interface{}{
+				{int64(1), "Alice", map[string]interface{}{"tier": "gold"}},
+				{int64(2), "Bob", map[string]interface{}{"tier": "silver"}},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := fmt.Sprintf("USERS_%d", i)
+			if _, err := handler.executor.Execute(ctx, fmt.Sprintf("CREATE TABLE LOAD_DB.PUBLIC_%s %s", table, tt.columns)); err != nil {
+				t.Fatalf("Failed to create table: %v", err)
+			}
+			stmt, err := handler.ParseCopyStatement(fmt.Sprintf(tt.sql, table))
+			if err != nil {
+				t.Fatalf("ParseCopyStatement() error = %v", err)
+			}
+
+			result, err := handler.ExecuteCopyInto(ctx, stmt, schema.ID)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ExecuteCopyInto() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecuteCopyInto() error = %v", err)
+			}
+			if result.RowsLoaded != int64(len(tt.want)) {
+				t.Errorf("RowsLoaded = %d, want %d", result.RowsLoaded, len(tt.want))
+			}
+
+			got, err := handler.executor.Query(ctx, fmt.Sprintf("SELECT * FROM LOAD_DB.PUBLIC_%s ORDER BY 1", table))
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.Rows); diff != "" {
+				t.Errorf("loaded rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestCopyProcessor_ExecuteCopyWithPurge(t *testing.T) {
 	handler, stageMgr, repo, _, cleanup := setupCopyProcessorTest(t)
 	defer cleanup()
@@ -483,3 +626,178 @@ func TestCopyProcessor_ExecuteCopyNoFiles(t *testing.T) {
 		t.Errorf("Expected 0 files loaded from empty stage, got %d", result.FilesLoaded)
 	}
 }
+
+func TestCopyProcessor_ExecuteCopyManyFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		onError     string
+		workers     int
+		wantErr     bool
+		wantRows    int64
+		wantFiles   []CopyFileResult
+		wantInTable int
+	}{
+		{
+			name:     "Continue",
+			onError:  "CONTINUE",
+			workers:  4,
+			wantRows: 6,
+			wantFiles: []CopyFileResult{
+				{File: "part_0.csv", Status: CopyFileLoaded, RowsLoaded: 2},
+				{File: "part_1.csv", Status: CopyFileLoaded, RowsLoaded: 2},
+				{File: "part_2.csv", Status: CopyFilePartiallyLoaded, RowsLoaded: 1},
+				{File: "part_3.csv", Status: CopyFileLoaded, RowsLoaded: 2},
+			},
+			wantInTable: 7,
+		},
+		{
+			name:     "SkipFile",
+			onError:  "SKIP_FILE",
+			workers:  2,
+			wantRows: 6,
+			wantFiles: []CopyFileResult{
+				{File: "part_0.csv", Status: CopyFileLoaded, RowsLoaded: 2},
+				{File: "part_1.csv", Status: CopyFileLoaded, RowsLoaded: 2},
+				{File: "part_2.csv", Status: CopyFileLoadFailed, RowsLoaded: 1},
+				{File: "part_3.csv", Status: CopyFileLoaded, RowsLoaded: 2},
+			},
+			wantInTable: 7,
+		},
+		{
+			name:    "Abort",
+			onError: "ABORT",
+			workers: 1,
+			wantErr: true,
+			// Files are loaded in order by a single worker, so the file after
+			// the failing one is not loaded.
+			wantInTable: 5,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, stageMgr, repo, _, cleanup := setupCopyProcessorTest(t)
+			defer cleanup()
+			WithCopyWorkers(tc.workers)(handler)
+
+			ctx := context.Background()
+			db, _ := repo.CreateDatabase(ctx, "MANY_DB", "")
+			schema, _ := repo.CreateSchema(ctx, db.ID, "PUBLIC", "")
+			_, _ = stageMgr.CreateStage(ctx, schema.ID, "MANY_STAGE", "INTERNAL", "", "")
+			if _, err := handler.executor.Execute(ctx, "CREATE TABLE MANY_DB.PUBLIC_MANY_TABLE (id INTEGER, name VARCHAR)"); err != nil {
+				t.Fatalf("Failed to create table: %v", err)
+			}
+
+			files := []string{"1,a\n2,b", "3,c\n4,d", "5,e\nbad,f", "7,g\n8,h"}
+			for i, data := range files {
+				name := fmt.Sprintf("part_%d.csv", i)
+				if err := stageMgr.PutFile(ctx, schema.ID, "MANY_STAGE", name, bytes.NewReader([]byte(data))); err != nil {
+					t.Fatalf("Failed to put file: %v", err)
+				}
+			}
+
+			stmt := &CopyStatement{
+				TargetTable:    "MANY_TABLE",
+				TargetSchema:   "PUBLIC",
+				TargetDatabase: "MANY_DB",
+				StageName:      "MANY_STAGE",
+				FileFormat:     FileFormatOptions{Type: "CSV", FieldDelimiter: ","},
+				OnError:        tc.onError,
+			}
+			result, err := handler.ExecuteCopyInto(ctx, stmt, schema.ID)
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "part_2.csv") {
+					t.Fatalf("ExecuteCopyInto error = %v, want an error loading part_2.csv", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("ExecuteCopyInto failed: %v", err)
+				}
+				if result.RowsLoaded != tc.wantRows {
+					t.Errorf("RowsLoaded = %d, want %d", result.RowsLoaded, tc.wantRows)
+				}
+				if len(result.Errors) != 1 {
+					t.Errorf("Errors = %v, want 1 error", result.Errors)
+				}
+				if diff := cmp.Diff(tc.wantFiles, result.Files, cmpopts.IgnoreFields(CopyFileResult{}, "Error")); diff != "" {
+					t.Errorf("Files mismatch (-want +got):\n%s", diff)
+				}
+			}
+
+			queryResult, err := handler.executor.Query(ctx, "SELECT COUNT(*) FROM MANY_DB.PUBLIC_MANY_TABLE")
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			if got := fmt.Sprint(queryResult.Rows[0][0]); got != fmt.Sprint(tc.wantInTable) {
+				t.Errorf("rows in table = %s, want %d", got, tc.wantInTable)
+			}
+		})
+	}
+}
+
+// TestCopyProcessor_ExecuteCopyCanceled tests that canceling a COPY stops
+// loading its file and fails the statement, even with ON_ERROR = CONTINUE.
+func TestCopyProcessor_ExecuteCopyCanceled(t *testing.T) {
+	handler, stageMgr, repo, _, cleanup := setupCopyProcessorTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	db, _ := repo.CreateDatabase(ctx, "CANCEL_DB", "")
+	schema, _ := repo.CreateSchema(ctx, db.ID, "PUBLIC", "")
+	_, _ = stageMgr.CreateStage(ctx, schema.ID, "CANCEL_STAGE", "INTERNAL", "", "")
+	if _, err := handler.executor.Execute(ctx, "CREATE TABLE CANCEL_DB.PUBLIC_CANCEL_TABLE (id INTEGER)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	const total = 50000
+	var csvData strings.Builder
+	for i := range total {
+		fmt.Fprintf(&csvData, "%d\n", i)
+	}
+	if err := stageMgr.PutFile(ctx, schema.ID, "CANCEL_STAGE", "big.csv", strings.NewReader(csvData.String())); err != nil {
+		t.Fatalf("Failed to put file: %v", err)
+	}
+
+	stmt := &CopyStatement{
+		TargetTable:    "CANCEL_TABLE",
+		TargetSchema:   "PUBLIC",
+		TargetDatabase: "CANCEL_DB",
+		StageName:      "CANCEL_STAGE",
+		FileFormat:     FileFormatOptions{Type: "CSV"},
+		OnError:        "CONTINUE",
+	}
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := handler.ExecuteCopyInto(copyCtx, stmt, schema.ID)
+		errs <- err
+	}()
+
+	loaded := func() int64 {
+		result, err := handler.executor.Query(ctx, "SELECT COUNT(*) FROM CANCEL_DB.PUBLIC_CANCEL_TABLE")
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		return result.Rows[0][0].(int64)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for loaded() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("COPY loaded no rows")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ExecuteCopyInto() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("COPY was not canceled")
+	}
+	if n := loaded(); n >= total {
+		t.Errorf("rows loaded after cancel = %d, want fewer than %d", n, total)
+	}
+}
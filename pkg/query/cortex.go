@@ -0,0 +1,115 @@
+package query
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"strings"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/cortex"
+)
+
+// cortexFunctions maps the Snowflake Cortex functions the emulator supports to
+// the DuckDB functions registered for them.
+var cortexFunctions = map[string]string{
+	"SNOWFLAKE.CORTEX.COMPLETE":  "snowflake_cortex_complete",
+	"SNOWFLAKE.CORTEX.SUMMARIZE": "snowflake_cortex_summarize",
+	"SNOWFLAKE.CORTEX.SENTIMENT": "snowflake_cortex_sentiment",
+}
+
+// WithCortexBackend sets the backend answering the Cortex functions COMPLETE,
+// SUMMARIZE, and SENTIMENT. By default they return canned responses from
+// cortex.NewStubBackend.
+func WithCortexBackend(backend cortex.Backend) ExecutorOption {
+	return func(e *Executor) {
+		e.cortex = backend
+	}
+}
+
+// configureCortex registers the DuckDB functions behind the Cortex functions.
+// They call the executor's backend when run, so that WithCortexBackend may be
+// applied later with Configure.
+func (e *Executor) configureCortex() {
+	if e.cortex == nil {
+		e.cortex = cortex.NewStubBackend(nil)
+	}
+
+	ctx := context.Background()
+	conn, err := e.mgr.Conn(ctx)
+	if err != nil {
+		log.Printf("Failed to register Cortex functions: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	functions := map[string]duckdb.ScalarFunc{
+		"snowflake_cortex_complete": &cortexFunction{inputs: 2, result: duckdb.TYPE_VARCHAR, run: func(ctx context.Context, args []string) (any, error) {
+			return e.cortex.Complete(ctx, args[0], args[1])
+		}},
+		"snowflake_cortex_summarize": &cortexFunction{inputs: 1, result: duckdb.TYPE_VARCHAR, run: func(ctx context.Context, args []string) (any, error) {
+			return e.cortex.Summarize(ctx, args[0])
+		}},
+		"snowflake_cortex_sentiment": &cortexFunction{inputs: 1, result: duckdb.TYPE_DOUBLE, run: func(ctx context.Context, args []string) (any, error) {
+			return e.cortex.Sentiment(ctx, args[0])
+		}},
+	}
+	for name, function := range functions {
+		if err := duckdb.RegisterScalarUDF(conn, name, function); err != nil {
+			log.Printf("Failed to register Cortex function %s: %v", name, err)
+		}
+	}
+}
+
+// cortexFunction is a DuckDB scalar function with VARCHAR arguments that calls
+// a Cortex backend for each row.
+type cortexFunction struct {
+	inputs int
+	result duckdb.Type
+	run    func(ctx context.Context, args []string) (any, error)
+}
+
+// Config implements duckdb.ScalarFunc. The functions are volatile, since the
+// backend may answer the same input differently.
+func (f *cortexFunction) Config() duckdb.ScalarFuncConfig {
+	varchar, _ := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	result, _ := duckdb.NewTypeInfo(f.result)
+	inputs := make([]duckdb.TypeInfo, f.inputs)
+	for i := range inputs {
+		inputs[i] = varchar
+	}
+	return duckdb.ScalarFuncConfig{InputTypeInfos: inputs, ResultTypeInfo: result, Volatile: true}
+}
+
+// Executor implements duckdb.ScalarFunc.
+func (f *cortexFunction) Executor() duckdb.ScalarFuncExecutor {
+	return duckdb.ScalarFuncExecutor{RowContextExecutor: func(ctx context.Context, values []driver.Value) (any, error) {
+		args := make([]string, len(values))
+		for i, value := range values {
+			args[i] = fmt.Sprint(value)
+		}
+		return f.run(ctx, args)
+	}}
+}
+
+// rewriteCortexFunctions replaces calls of the supported Cortex functions with
+// calls of the DuckDB functions registered for them. Names inside literals,
+// quoted identifiers, and comments are left alone.
+func rewriteCortexFunctions(sql string) string {
+	if !strings.Contains(strings.ToUpper(sql), "CORTEX.") {
+		return sql
+	}
+	var b strings.Builder
+	copied := 0
+	scanFunctionCalls(sql, func(name string, start, end int) bool {
+		if function, ok := cortexFunctions[name]; ok {
+			b.WriteString(sql[copied:start])
+			b.WriteString(function)
+			copied = end
+		}
+		return true
+	})
+	b.WriteString(sql[copied:])
+	return b.String()
+}
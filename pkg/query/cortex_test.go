@@ -0,0 +1,131 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/cortex"
+)
+
+// fakeCortexBackend is a cortex.Backend recording its calls.
+type fakeCortexBackend struct {
+	calls []string
+}
+
+func (b *fakeCortexBackend) Complete(_ context.Context, model, prompt string) (string, error) {
+	b.calls = append(b.calls, "COMPLETE "+model+" "+prompt)
+	if prompt == "fail" {
+		return "", errors.New("model unavailable")
+	}
+	return "answer to " + prompt, nil
+}
+
+func (b *fakeCortexBackend) Summarize(_ context.Context, text string) (string, error) {
+	b.calls = append(b.calls, "SUMMARIZE "+text)
+	return "summary", nil
+}
+
+func (b *fakeCortexBackend) Sentiment(_ context.Context, text string) (float64, error) {
+	b.calls = append(b.calls, "SENTIMENT "+text)
+	return 0.5, nil
+}
+
+func TestRewriteCortexFunctions(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "Complete",
+			sql:  "SELECT SNOWFLAKE.CORTEX.COMPLETE('llama3', prompt) FROM t",
+			want: "SELECT snowflake_cortex_complete('llama3', prompt) FROM t",
+		},
+		{
+			name: "LowerCaseAndSpace",
+			sql:  "select snowflake.cortex.sentiment (review), snowflake.cortex.summarize(review) from reviews",
+			want: "select snowflake_cortex_sentiment (review), snowflake_cortex_summarize(review) from reviews",
+		},
+		{
+			name: "LiteralUnchanged",
+			sql:  "SELECT 'SNOWFLAKE.CORTEX.COMPLETE(x)'",
+			want: "SELECT 'SNOWFLAKE.CORTEX.COMPLETE(x)'",
+		},
+		{
+			name: "UnsupportedFunctionUnchanged",
+			sql:  "SELECT SNOWFLAKE.CORTEX.TRANSLATE(t, 'en', 'de')",
+			want: "SELECT SNOWFLAKE.CORTEX.TRANSLATE(t, 'en', 'de')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, rewriteCortexFunctions(tt.sql)); diff != "" {
+				t.Errorf("rewriteCortexFunctions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_CortexFunctions tests the Cortex functions with the default stub backend.
+func TestExecutor_CortexFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	result, err := executor.Query(ctx, "SELECT SNOWFLAKE.CORTEX.COMPLETE('mistral-large', 'Hello'), "+
+		"SNOWFLAKE.CORTEX.SUMMARIZE('The product works well. It arrived late.'), "+
+		"SNOWFLAKE.CORTEX.SENTIMENT('Great product, fast delivery, but the box was broken'), "+
+		"SNOWFLAKE.CORTEX.SENTIMENT(NULL)")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	expected := [][]interface{}{{"[mistral-large] Hello", "The product works well.", 1.0 / 3.0, nil}}
+	if diff := cmp.Diff(expected, result.Rows); diff != "" {
+		t.Errorf("Cortex functions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_CortexBackend tests that Cortex functions call a backend set
+// after the executor was created, and that backend errors fail the statement.
+func TestExecutor_CortexBackend(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	backend := &fakeCortexBackend{}
+	executor.Configure(WithCortexBackend(backend))
+
+	for _, sql := range []string{
+		"CREATE TABLE reviews (id INTEGER, body VARCHAR)",
+		"INSERT INTO reviews VALUES (1, 'first'), (2, 'second')",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT id, SNOWFLAKE.CORTEX.COMPLETE('llama3', body) FROM reviews ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	expected := [][]interface{}{{int64(1), "answer to first"}, {int64(2), "answer to second"}}
+	if diff := cmp.Diff(expected, result.Rows); diff != "" {
+		t.Errorf("COMPLETE results mismatch (-want +got):\n%s", diff)
+	}
+	if len(backend.calls) != 2 {
+		t.Errorf("backend calls = %q, want one per row", backend.calls)
+	}
+
+	if _, err := executor.Query(ctx, "SELECT SNOWFLAKE.CORTEX.COMPLETE('llama3', 'fail')"); err == nil {
+		t.Error("Query() succeeded despite the backend failing")
+	}
+
+	capabilities := executor.Capabilities()
+	for _, function := range capabilities.UnsupportedFunctions {
+		if _, ok := cortexFunctions[function.Name]; ok {
+			t.Errorf("supported Cortex function %s is listed as unsupported", function.Name)
+		}
+	}
+}
+
+var _ cortex.Backend = (*fakeCortexBackend)(nil)
@@ -0,0 +1,377 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// createModifiers are keywords that may appear between CREATE [OR REPLACE]
+// and the object type.
+var createModifiers = map[string]bool{
+	"TRANSIENT":    true,
+	"HYBRID":       true,
+	"TEMPORARY":    true,
+	"TEMP":         true,
+	"VOLATILE":     true,
+	"LOCAL":        true,
+	"GLOBAL":       true,
+	"SECURE":       true,
+	"MATERIALIZED": true,
+	"RECURSIVE":    true,
+}
+
+// multiwordKinds are the object types of more than one word, keyed by their
+// first word.
+var multiwordKinds = map[string][]string{
+	"DATA":         {"METRIC", "FUNCTION"},
+	"NOTIFICATION": {"INTEGRATION"},
+	"EXTERNAL":     {"ACCESS", "INTEGRATION"},
+	"NETWORK":      {"RULE"},
+}
+
+// createStatement is a CREATE statement with its Snowflake COMMENT clauses,
+// and SECURE and MATERIALIZED modifiers, removed.
+type createStatement struct {
+	// SQL is the statement without COMMENT clauses and the SECURE and
+	// MATERIALIZED modifiers, which DuckDB does not accept.
+	SQL         string
+	Kind        string // Object type, such as TABLE, VIEW, SCHEMA, DATABASE, or DATA METRIC FUNCTION
+	Name        string // Object name as written
+	OrReplace   bool
+	IfNotExists bool
+	Hybrid      bool // CREATE HYBRID TABLE
+	Temporary   bool // CREATE TEMPORARY, TEMP, or VOLATILE TABLE
+	Secure      bool // CREATE SECURE VIEW
+	// Materialized is set by CREATE MATERIALIZED VIEW. DuckDB has no
+	// materialized views, so they are created as views, which are never stale.
+	Materialized bool
+	// Comment is the object's COMMENT = '...' value, or nil if it has none.
+	Comment *string
+	// ColumnComments are the COMMENT '...' values in the column list, in order.
+	ColumnComments []columnComment
+	// Query is the text after the top-level AS, such as the query of a view, or
+	// "" if there is none.
+	Query string
+}
+
+// columnComment is a comment on one column of a CREATE TABLE or CREATE VIEW.
+type columnComment struct {
+	Column  string
+	Comment string
+}
+
+// parseCreateStatement parses the header of a CREATE statement and extracts its
+// COMMENT clauses. It reports false if sql is not a CREATE statement.
+//
+// Only the part before a top-level AS is scanned, so the query of a view or a
+// CREATE TABLE ... AS SELECT and the body of a function are left untouched.
+func parseCreateStatement(sql string) (*createStatement, bool) {
+	s := strings.TrimSpace(sql)
+	pos := 0
+	next := func() string {
+		for pos < len(s) && isSpace(s[pos]) {
+			pos++
+		}
+		start := pos
+		for pos < len(s) && isIdentChar(s[pos]) && s[pos] != '.' {
+			pos++
+		}
+		return strings.ToUpper(s[start:pos])
+	}
+	peek := func() string {
+		saved := pos
+		word := next()
+		pos = saved
+		return word
+	}
+
+	if next() != "CREATE" {
+		return nil, false
+	}
+	stmt := &createStatement{}
+	if peek() == "OR" {
+		next()
+		if next() != "REPLACE" {
+			return nil, false
+		}
+		stmt.OrReplace = true
+	}
+	// Spans of the modifiers removed from the statement
+	var removed [][2]int
+	for createModifiers[peek()] {
+		start := pos
+		switch next() {
+		case "HYBRID":
+			stmt.Hybrid = true
+		case "TEMPORARY", "TEMP", "VOLATILE":
+			stmt.Temporary = true
+		case "SECURE":
+			stmt.Secure = true
+			removed = append(removed, [2]int{start, pos})
+		case "MATERIALIZED":
+			stmt.Materialized = true
+			removed = append(removed, [2]int{start, pos})
+		}
+	}
+	stmt.Kind = next()
+	if stmt.Kind == "" {
+		return nil, false
+	}
+	if words, ok := multiwordKinds[stmt.Kind]; ok && peek() == words[0] {
+		for _, word := range words {
+			if next() != word {
+				return nil, false
+			}
+		}
+		stmt.Kind += " " + strings.Join(words, " ")
+	}
+	if peek() == "IF" {
+		next()
+		if next() != "NOT" || next() != "EXISTS" {
+			return nil, false
+		}
+		stmt.IfNotExists = true
+	}
+
+	// The name is a dotted path whose parts may be quoted identifiers
+	for pos < len(s) && isSpace(s[pos]) {
+		pos++
+	}
+	nameStart := pos
+	for pos < len(s) && (isIdentChar(s[pos]) || s[pos] == '"') {
+		if s[pos] == '"' {
+			pos = skipQuoted(s, pos, '"')
+		}
+		pos++
+	}
+	stmt.Name = s[nameStart:pos]
+
+	var b strings.Builder
+	copied := 0
+	for _, span := range removed {
+		b.WriteString(s[copied:span[0]])
+		copied = span[1]
+	}
+	b.WriteString(s[copied:pos])
+	copied = pos
+	depth := 0
+	columnList := false
+	segmentStart := pos
+	for i := pos; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(s, i, c)
+		case c == '(':
+			depth++
+			if depth == 1 && !columnList && segmentStart == pos {
+				// The first parenthesized group after the name is the column list
+				columnList = true
+				segmentStart = i + 1
+			}
+		case c == ')':
+			depth--
+		case c == ',' && depth == 1 && columnList:
+			segmentStart = i + 1
+		case depth == 0 && keywordAt(s, i, "AS"):
+			stmt.Query = strings.TrimSpace(s[i+len("AS"):])
+			i = len(s)
+		case (depth == 0 || (depth == 1 && columnList)) && keywordAt(s, i, "COMMENT"):
+			value, end, ok := commentValueAt(s, i+len("COMMENT"))
+			if !ok {
+				continue
+			}
+			if depth == 0 {
+				stmt.Comment = &value
+			} else {
+				column := strings.Fields(s[segmentStart:i])
+				if len(column) > 0 {
+					stmt.ColumnComments = append(stmt.ColumnComments, columnComment{Column: column[0], Comment: value})
+				}
+			}
+			b.WriteString(strings.TrimRight(s[copied:i], " \t\r\n"))
+			copied = end
+			i = end - 1
+		}
+	}
+	b.WriteString(s[copied:])
+	stmt.SQL = b.String()
+
+	return stmt, true
+}
+
+// commentValueAt reads the "[=] '...'" that follows a COMMENT keyword ending at
+// s[start]. It returns the unescaped value and the index just past the literal.
+func commentValueAt(s string, start int) (string, int, bool) {
+	i := start
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	if i < len(s) && s[i] == '=' {
+		i++
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+	}
+	if i >= len(s) || s[i] != '\'' {
+		return "", 0, false
+	}
+	end := skipQuoted(s, i, '\'')
+	if end <= i {
+		// Unterminated literal
+		return "", 0, false
+	}
+	value := s[i+1 : end]
+	value = strings.ReplaceAll(value, "''", "'")
+	value = strings.ReplaceAll(value, `\'`, "'")
+	return value, end + 1, true
+}
+
+// quoteLiteral returns s as a single-quoted SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// executeCreate executes a CREATE statement and records its comments and owner.
+// Databases are created through the metadata store. Table and view comments are
+// stored in DuckDB with COMMENT ON, and schemas, tables, and views are also
+// registered in the metadata store under the session's current database. The
+// secondary indexes of a hybrid table are created once the table exists.
+func (e *Executor) executeCreate(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	switch stmt.Kind {
+	case "DATABASE":
+		return e.executeCreateDatabase(ctx, stmt)
+	case "PROCEDURE":
+		return e.executeCreateProcedure(ctx, stmt)
+	case "DATA METRIC FUNCTION":
+		return e.executeCreateDataMetricFunction(ctx, stmt)
+	case "ALERT":
+		return e.executeCreateAlert(ctx, stmt)
+	case "NOTIFICATION INTEGRATION":
+		return e.executeCreateNotificationIntegration(stmt)
+	case "SECRET":
+		return e.executeCreateSecret(ctx, stmt)
+	case "STAGE":
+		return e.executeCreateStage(ctx, stmt)
+	case "EXTERNAL ACCESS INTEGRATION":
+		return e.executeCreateExternalAccessIntegration(ctx, stmt)
+	case "NETWORK RULE":
+		// Network access is not restricted, so network rules are not recorded
+		return &ExecResult{}, nil
+	}
+
+	var indexes []hybridIndex
+	if stmt.Hybrid && stmt.Kind == "TABLE" {
+		sql, hybridIndexes, err := translateHybridTable(stmt)
+		if err != nil {
+			return nil, err
+		}
+		stmt.SQL, indexes = sql, hybridIndexes
+	}
+
+	result, err := e.execute(ctx, stmt.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch stmt.Kind {
+	case "TABLE", "VIEW":
+		// COMMENT ON goes to DuckDB as is, so database-qualified names are resolved first
+		name := e.resolveTableName(ctx, stmt.Name)
+		if stmt.Comment != nil {
+			commentSQL := fmt.Sprintf("COMMENT ON %s %s IS %s", stmt.Kind, name, quoteLiteral(*stmt.Comment))
+			if _, err := e.manager(ctx).Exec(ctx, commentSQL); err != nil {
+				return nil, fmt.Errorf("failed to set comment: %w", err)
+			}
+		}
+		for _, col := range stmt.ColumnComments {
+			commentSQL := fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", name, col.Column, quoteLiteral(col.Comment))
+			if _, err := e.manager(ctx).Exec(ctx, commentSQL); err != nil {
+				return nil, fmt.Errorf("failed to set comment on column %s: %w", col.Column, err)
+			}
+		}
+		if err := e.createHybridIndexes(ctx, name, indexes); err != nil {
+			return nil, err
+		}
+		register := e.registerView
+		if stmt.Kind == "TABLE" {
+			register = e.registerTable
+		}
+		if err := register(ctx, stmt); err != nil {
+			return nil, err
+		}
+	case "SCHEMA":
+		if err := e.registerSchema(ctx, stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// executeCreateDatabase handles CREATE DATABASE, which DuckDB does not support,
+// by creating the database in the metadata store.
+func (e *Executor) executeCreateDatabase(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	name := unquoteIdentifier(stmt.Name)
+	comment := ""
+	if stmt.Comment != nil {
+		comment = *stmt.Comment
+	}
+
+	if existing, err := e.repo.GetDatabaseByName(ctx, name); err == nil {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case stmt.OrReplace:
+			if err := e.repo.DropDatabase(ctx, existing.ID); err != nil {
+				return nil, fmt.Errorf("failed to replace database: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("database %s already exists", existing.Name)
+		}
+	}
+
+	if _, err := e.repo.CreateDatabase(ctx, name, comment); err != nil {
+		return nil, fmt.Errorf("create database execution error: %w", err)
+	}
+	return &ExecResult{}, nil
+}
+
+// registerSchema records a schema created with SQL in the metadata store, so
+// that its comment and owner are kept. Schemas are registered under the
+// database that qualifies their name, or else the session's current database,
+// if it is known and the schema is not registered yet.
+func (e *Executor) registerSchema(ctx context.Context, stmt *createStatement) error {
+	_, database, name := splitObjectName(ctx, stmt.Name)
+	if !strings.Contains(stmt.Name, ".") {
+		database = SessionInfoFromContext(ctx).Database
+	}
+	if database == "" {
+		return nil
+	}
+	db, err := e.repo.GetDatabaseByName(ctx, database)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := e.repo.GetSchemaByName(ctx, db.ID, name); err == nil {
+		return nil
+	}
+
+	comment := ""
+	if stmt.Comment != nil {
+		comment = *stmt.Comment
+	}
+	if _, err := e.repo.CreateSchema(ctx, db.ID, name, comment); err != nil {
+		return fmt.Errorf("failed to register schema: %w", err)
+	}
+	return nil
+}
+
+// unquoteIdentifier removes the double quotes around a quoted identifier.
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	return name
+}
@@ -0,0 +1,145 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// TestParseCreateStatement tests parsing CREATE headers and extracting COMMENT clauses.
+func TestParseCreateStatement(t *testing.T) {
+	comment := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected *createStatement
+	}{
+		{
+			name: "TableComment",
+			sql:  "CREATE TABLE users (id INT) COMMENT = 'User accounts'",
+			expected: &createStatement{
+				SQL: "CREATE TABLE users (id INT)", Kind: "TABLE", Name: "users", Comment: comment("User accounts"),
+			},
+		},
+		{
+			name: "ColumnComments",
+			sql:  "CREATE OR REPLACE TRANSIENT TABLE db.s.t (id INT COMMENT 'Key', name VARCHAR COMMENT 'It''s a name') COMMENT='t'",
+			expected: &createStatement{
+				SQL: "CREATE OR REPLACE TRANSIENT TABLE db.s.t (id INT, name VARCHAR)", Kind: "TABLE", Name: "db.s.t",
+				OrReplace: true, Comment: comment("t"),
+				ColumnComments: []columnComment{{Column: "id", Comment: "Key"}, {Column: "name", Comment: "It's a name"}},
+			},
+		},
+		{
+			name: "ViewBeforeAs",
+			sql:  "CREATE SECURE VIEW IF NOT EXISTS v COMMENT = 'v' AS SELECT 'COMMENT' AS comment FROM t",
+			expected: &createStatement{
+				SQL: "CREATE VIEW IF NOT EXISTS v AS SELECT 'COMMENT' AS comment FROM t", Kind: "VIEW", Name: "v",
+				IfNotExists: true, Secure: true, Comment: comment("v"), Query: "SELECT 'COMMENT' AS comment FROM t",
+			},
+		},
+		{
+			name: "MaterializedView",
+			sql:  "create or replace secure materialized view v as select 1",
+			expected: &createStatement{
+				SQL: "create or replace view v as select 1", Kind: "VIEW", Name: "v",
+				OrReplace: true, Secure: true, Materialized: true, Query: "select 1",
+			},
+		},
+		{
+			name: "QuotedName",
+			sql:  `CREATE SCHEMA "My Schema" COMMENT = 'x'`,
+			expected: &createStatement{
+				SQL: `CREATE SCHEMA "My Schema"`, Kind: "SCHEMA", Name: `"My Schema"`, Comment: comment("x"),
+			},
+		},
+		{
+			name: "ColumnNamedComment",
+			sql:  "CREATE TABLE notes (comment VARCHAR)",
+			expected: &createStatement{
+				SQL: "CREATE TABLE notes (comment VARCHAR)", Kind: "TABLE", Name: "notes",
+			},
+		},
+		{
+			name:     "NotCreate",
+			sql:      "INSERT INTO t VALUES ('COMMENT = ''x''')",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := parseCreateStatement(tt.sql)
+			if diff := cmp.Diff(tt.expected, got, cmp.AllowUnexported(createStatement{}, columnComment{})); diff != "" {
+				t.Errorf("parseCreateStatement() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_CreateComments tests that comments and owners of CREATE statements are recorded.
+func TestExecutor_CreateComments(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := metadata.ContextWithOwner(context.Background(), "ANALYST")
+
+	statements := []string{
+		"CREATE TABLE commented (id INT COMMENT 'Primary key') COMMENT = 'It''s commented'",
+		"CREATE VIEW commented_view COMMENT = 'A view' AS SELECT id FROM commented",
+		"CREATE DATABASE analytics COMMENT = 'Analytics data'",
+		"CREATE DATABASE IF NOT EXISTS analytics",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT comment FROM duckdb_tables() WHERE table_name = 'commented'")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"It's commented"}}, result.Rows); diff != "" {
+		t.Errorf("table comment mismatch (-want +got):\n%s", diff)
+	}
+	result, err = executor.Query(ctx, "SELECT comment FROM duckdb_columns() WHERE table_name = 'commented'")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"Primary key"}}, result.Rows); diff != "" {
+		t.Errorf("column comment mismatch (-want +got):\n%s", diff)
+	}
+	result, err = executor.Query(ctx, "SELECT comment FROM duckdb_views() WHERE view_name = 'commented_view'")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"A view"}}, result.Rows); diff != "" {
+		t.Errorf("view comment mismatch (-want +got):\n%s", diff)
+	}
+
+	db, err := repo.GetDatabaseByName(ctx, "ANALYTICS")
+	if err != nil {
+		t.Fatalf("GetDatabaseByName() error = %v", err)
+	}
+	if db.Comment != "Analytics data" || db.Owner != "ANALYST" {
+		t.Errorf("database comment, owner = %q, %q, want %q, %q", db.Comment, db.Owner, "Analytics data", "ANALYST")
+	}
+	if _, err := executor.Execute(ctx, "CREATE DATABASE analytics"); err == nil {
+		t.Error("Execute() duplicate CREATE DATABASE error = nil, want error")
+	}
+
+	// Schemas are registered under the session's current database
+	sessionCtx := ContextWithSessionInfo(ctx, SessionInfo{Database: "ANALYTICS"})
+	if _, err := executor.Execute(sessionCtx, "CREATE SCHEMA staging COMMENT = 'Staging area'"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	schema, err := repo.GetSchemaByName(ctx, db.ID, "STAGING")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	if schema.Comment != "Staging area" || schema.Owner != "ANALYST" {
+		t.Errorf("schema comment, owner = %q, %q, want %q, %q", schema.Comment, schema.Owner, "Staging area", "ANALYST")
+	}
+}
@@ -0,0 +1,507 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dataMetricResultsTable is the DuckDB table recording data metric function
+// measurements, which queries name SNOWFLAKE.LOCAL.DATA_QUALITY_MONITORING_RESULTS.
+const dataMetricResultsTable = "_metadata_dmf_results"
+
+// dataMetricResultsView is the Snowflake name of the measurements, split into
+// its database, schema, and view names.
+var dataMetricResultsView = []string{"SNOWFLAKE", "LOCAL", "DATA_QUALITY_MONITORING_RESULTS"}
+
+// dataMetricFunction is a data metric function (DMF) created with CREATE DATA
+// METRIC FUNCTION, or one of Snowflake's system DMFs.
+type dataMetricFunction struct {
+	// Name is the upper-case qualified name.
+	Name string
+	// TableArg is the name of the table argument, which Expression selects from.
+	TableArg string
+	// ColumnArgs are the names of the table argument's columns.
+	ColumnArgs []string
+	// Expression is the query returning the metric.
+	Expression string
+	CreatedOn  time.Time
+}
+
+// dataMetricAssociation is a DMF added to a table with ALTER TABLE ... ADD
+// DATA METRIC FUNCTION.
+type dataMetricAssociation struct {
+	Function string
+	Columns  []string
+}
+
+// dataMetricTable holds the DMFs added to a table and its schedule.
+type dataMetricTable struct {
+	// Ref is the table's name as DuckDB resolves it.
+	Ref                    string
+	Database, Schema, Name string
+	// Schedule is the DATA_METRIC_SCHEDULE. It is recorded but not run.
+	Schedule string
+	Metrics  []dataMetricAssociation
+}
+
+// dataMetricRegistry holds the DMFs and the tables they are added to, keyed by
+// their qualified names.
+type dataMetricRegistry struct {
+	mu        sync.Mutex
+	functions map[string]*dataMetricFunction
+	tables    map[string]*dataMetricTable
+}
+
+// systemDataMetricFunctions are Snowflake's system DMFs of the SNOWFLAKE.CORE schema.
+var systemDataMetricFunctions = newSystemDataMetricFunctions()
+
+// newSystemDataMetricFunctions builds the system DMFs, whose arguments are
+// named arg_t and arg_c1.
+func newSystemDataMetricFunctions() map[string]*dataMetricFunction {
+	functions := make(map[string]*dataMetricFunction)
+	register := func(name string, columns int, expression string) {
+		function := &dataMetricFunction{Name: "SNOWFLAKE.CORE." + name, TableArg: "arg_t", Expression: expression}
+		for i := 1; i <= columns; i++ {
+			function.ColumnArgs = append(function.ColumnArgs, fmt.Sprintf("arg_c%d", i))
+		}
+		functions[function.Name] = function
+	}
+
+	register("ROW_COUNT", 0, "SELECT COUNT(*) FROM arg_t")
+	register("NULL_COUNT", 1, "SELECT COUNT_IF(arg_c1 IS NULL) FROM arg_t")
+	register("NULL_PERCENT", 1, "SELECT COALESCE(100.0 * COUNT_IF(arg_c1 IS NULL) / NULLIF(COUNT(*), 0), 0) FROM arg_t")
+	register("BLANK_COUNT", 1, "SELECT COUNT_IF(TRIM(arg_c1) = '') FROM arg_t")
+	register("BLANK_PERCENT", 1, "SELECT COALESCE(100.0 * COUNT_IF(TRIM(arg_c1) = '') / NULLIF(COUNT(arg_c1), 0), 0) FROM arg_t")
+	register("DUPLICATE_COUNT", 1, "SELECT COUNT(arg_c1) - COUNT(DISTINCT arg_c1) FROM arg_t")
+	register("UNIQUE_COUNT", 1, "SELECT COUNT(DISTINCT arg_c1) FROM arg_t")
+	register("AVG", 1, "SELECT AVG(arg_c1) FROM arg_t")
+	register("MIN", 1, "SELECT MIN(arg_c1) FROM arg_t")
+	register("MAX", 1, "SELECT MAX(arg_c1) FROM arg_t")
+	register("STDDEV", 1, "SELECT STDDEV(arg_c1) FROM arg_t")
+	return functions
+}
+
+// DataMetricResult is a measurement of a data metric function on a table.
+type DataMetricResult struct {
+	MeasurementTime time.Time
+	TableDatabase   string
+	TableSchema     string
+	TableName       string
+	MetricDatabase  string
+	MetricSchema    string
+	MetricName      string
+	// ArgumentNames are the table's columns the metric was evaluated on.
+	ArgumentNames []string
+	// Value is the metric, or nil if it is NULL.
+	Value *float64
+}
+
+// configureDataMetrics creates the table recording DMF measurements.
+func (e *Executor) configureDataMetrics() {
+	createSQL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		MEASUREMENT_TIME TIMESTAMPTZ,
+		TABLE_DATABASE VARCHAR,
+		TABLE_SCHEMA VARCHAR,
+		TABLE_NAME VARCHAR,
+		METRIC_DATABASE VARCHAR,
+		METRIC_SCHEMA VARCHAR,
+		METRIC_NAME VARCHAR,
+		ARGUMENT_NAMES JSON,
+		VALUE DOUBLE
+	)`, dataMetricResultsTable)
+	if _, err := e.mgr.Exec(context.Background(), createSQL); err != nil {
+		log.Printf("Failed to create the data metric results table: %v", err)
+	}
+}
+
+// isDataMetricResultsView reports whether the parts of a name, as written,
+// name SNOWFLAKE.LOCAL.DATA_QUALITY_MONITORING_RESULTS.
+func isDataMetricResultsView(parts []string) bool {
+	if len(parts) != len(dataMetricResultsView) {
+		return false
+	}
+	for i, part := range parts {
+		if !strings.EqualFold(unquoteIdentifier(part), dataMetricResultsView[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDataMetricFunction parses a CREATE DATA METRIC FUNCTION statement, whose
+// single argument is a table with the columns the metric is evaluated on:
+//
+//	CREATE DATA METRIC FUNCTION name(arg_t TABLE(arg_c1 NUMBER, ...))
+//	RETURNS NUMBER AS 'SELECT ... FROM arg_t'
+func parseDataMetricFunction(ctx context.Context, stmt *createStatement) (*dataMetricFunction, error) {
+	function := &dataMetricFunction{Name: qualifiedName(ctx, stmt.Name)}
+
+	s := stmt.SQL
+	i := indexFold(s, "FUNCTION") + len("FUNCTION")
+	i += strings.Index(s[i:], stmt.Name) + len(stmt.Name)
+	rest := strings.TrimSpace(s[i:])
+	end := matchingParen(rest)
+	if !strings.HasPrefix(rest, "(") || end < 0 {
+		return nil, fmt.Errorf("data metric function %s has no argument list", stmt.Name)
+	}
+
+	args := splitFunctionArgs(strings.TrimSpace(rest[1:end]), 1)
+	if len(args) != 1 {
+		return nil, fmt.Errorf("data metric function %s must take a single TABLE argument", stmt.Name)
+	}
+	arg := strings.TrimSpace(args[0])
+	nameEnd := variableEnd(arg, 0)
+	columns := strings.TrimSpace(arg[nameEnd:])
+	if nameEnd == 0 || !keywordAt(columns, 0, "TABLE") {
+		return nil, fmt.Errorf("data metric function %s must take a single TABLE argument", stmt.Name)
+	}
+	function.TableArg = arg[:nameEnd]
+	columns = strings.TrimSpace(columns[len("TABLE"):])
+	if !strings.HasPrefix(columns, "(") || matchingParen(columns) != len(columns)-1 {
+		return nil, fmt.Errorf("invalid TABLE argument of data metric function %s", stmt.Name)
+	}
+	for _, column := range splitFunctionArgs(columns[1:len(columns)-1], 0) {
+		fields := strings.Fields(column)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid column %q of data metric function %s", strings.TrimSpace(column), stmt.Name)
+		}
+		function.ColumnArgs = append(function.ColumnArgs, fields[0])
+	}
+
+	_, body, ok := procedureBody(rest[end+1:])
+	if !ok {
+		return nil, fmt.Errorf("data metric function %s has no body", stmt.Name)
+	}
+	function.Expression = strings.TrimRight(strings.TrimSpace(body), ";")
+	return function, nil
+}
+
+// executeCreateDataMetricFunction handles CREATE DATA METRIC FUNCTION by
+// registering the function with the executor.
+func (e *Executor) executeCreateDataMetricFunction(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	function, err := parseDataMetricFunction(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	e.dataMetrics.mu.Lock()
+	defer e.dataMetrics.mu.Unlock()
+	if _, exists := e.dataMetrics.functions[function.Name]; exists {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case !stmt.OrReplace:
+			return nil, fmt.Errorf("data metric function %s already exists", function.Name)
+		}
+	}
+	if e.dataMetrics.functions == nil {
+		e.dataMetrics.functions = make(map[string]*dataMetricFunction)
+	}
+	function.CreatedOn = time.Now()
+	e.dataMetrics.functions[function.Name] = function
+	return &ExecResult{}, nil
+}
+
+// lookupDataMetricFunction returns the DMF a name refers to, completing it
+// with the session's current database and schema unless it is a system DMF.
+// The caller holds e.dataMetrics.mu.
+func (e *Executor) lookupDataMetricFunction(ctx context.Context, name string) (*dataMetricFunction, bool) {
+	if function, ok := systemDataMetricFunctions[strings.ToUpper(name)]; ok {
+		return function, true
+	}
+	function, ok := e.dataMetrics.functions[qualifiedName(ctx, name)]
+	return function, ok
+}
+
+// dataMetricAlter is an ALTER TABLE statement managing the table's DMFs.
+type dataMetricAlter struct {
+	Table string
+	// Action is ADD, DROP, SET, or UNSET.
+	Action       string
+	Associations []dataMetricAssociation
+	Schedule     string
+}
+
+// parseDataMetricAlter parses the ALTER TABLE statements that add DMFs to a
+// table, drop them, and set or unset its DATA_METRIC_SCHEDULE. It reports
+// false for other statements.
+func parseDataMetricAlter(sql string) (*dataMetricAlter, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	if !keywordsHavePrefix(statementKeywords(s), "ALTER", "TABLE") {
+		return nil, false, nil
+	}
+	rest := strings.TrimSpace(s[indexFold(s, "TABLE")+len("TABLE"):])
+	if keywordAt(rest, 0, "IF") {
+		rest = strings.TrimSpace(rest[len("IF"):])
+		if !keywordAt(rest, 0, "EXISTS") {
+			return nil, false, nil
+		}
+		rest = strings.TrimSpace(rest[len("EXISTS"):])
+	}
+	_, nameEnd := objectNameParts(rest, 0)
+	alter := &dataMetricAlter{Table: rest[:nameEnd]}
+	rest = strings.TrimSpace(rest[nameEnd:])
+	fields := strings.Fields(strings.ToUpper(rest))
+
+	switch {
+	case len(fields) >= 4 && (fields[0] == "ADD" || fields[0] == "DROP") &&
+		fields[1] == "DATA" && fields[2] == "METRIC" && fields[3] == "FUNCTION":
+		alter.Action = fields[0]
+		rest = strings.TrimSpace(rest[indexFold(rest, "FUNCTION")+len("FUNCTION"):])
+		for _, clause := range splitFunctionArgs(rest, 1) {
+			association, err := parseDataMetricAssociation(clause)
+			if err != nil {
+				return nil, true, err
+			}
+			alter.Associations = append(alter.Associations, association)
+		}
+	case len(fields) >= 2 && fields[0] == "SET" && strings.HasPrefix(fields[1], "DATA_METRIC_SCHEDULE"):
+		alter.Action = "SET"
+		value := strings.TrimSpace(rest[indexFold(rest, "DATA_METRIC_SCHEDULE")+len("DATA_METRIC_SCHEDULE"):])
+		value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+		end := skipQuoted(value, 0, '\'')
+		if !strings.HasPrefix(value, "'") || end != len(value)-1 {
+			return nil, true, fmt.Errorf("DATA_METRIC_SCHEDULE must be a string literal")
+		}
+		alter.Schedule = strings.ReplaceAll(value[1:end], "''", "'")
+	case len(fields) == 2 && fields[0] == "UNSET" && fields[1] == "DATA_METRIC_SCHEDULE":
+		alter.Action = "UNSET"
+	default:
+		return nil, false, nil
+	}
+	return alter, true, nil
+}
+
+// parseDataMetricAssociation parses a "function ON (column, ...)" clause.
+func parseDataMetricAssociation(clause string) (dataMetricAssociation, error) {
+	clause = strings.TrimSpace(clause)
+	on := topLevelKeyword(clause, "ON")
+	if on < 0 {
+		return dataMetricAssociation{}, fmt.Errorf("data metric function %q needs an ON (columns) clause", clause)
+	}
+	columns := strings.TrimSpace(clause[on+len("ON"):])
+	if !strings.HasPrefix(columns, "(") || matchingParen(columns) != len(columns)-1 {
+		return dataMetricAssociation{}, fmt.Errorf("data metric function %q needs an ON (columns) clause", clause)
+	}
+	association := dataMetricAssociation{Function: strings.TrimSpace(clause[:on])}
+	if list := strings.TrimSpace(columns[1 : len(columns)-1]); list != "" {
+		for _, column := range splitFunctionArgs(list, 0) {
+			association.Columns = append(association.Columns, strings.TrimSpace(column))
+		}
+	}
+	return association, nil
+}
+
+// executeDataMetricAlter adds DMFs to a table, drops them, or sets its
+// schedule. Added DMFs are checked against the table's columns, but schedules
+// are only recorded: measurements are taken with EvaluateDataMetrics.
+func (e *Executor) executeDataMetricAlter(ctx context.Context, alter *dataMetricAlter) (*ExecResult, error) {
+	key := qualifiedName(ctx, alter.Table)
+	database, schema, name := splitObjectName(ctx, alter.Table)
+	ref := e.resolveTableName(ctx, alter.Table)
+
+	e.dataMetrics.mu.Lock()
+	defer e.dataMetrics.mu.Unlock()
+	table := e.dataMetrics.tables[key]
+	if table == nil {
+		table = &dataMetricTable{
+			Ref:      ref,
+			Database: strings.ToUpper(database),
+			Schema:   strings.ToUpper(schema),
+			Name:     strings.ToUpper(name),
+		}
+	}
+
+	switch alter.Action {
+	case "ADD":
+		for _, association := range alter.Associations {
+			function, ok := e.lookupDataMetricFunction(ctx, association.Function)
+			if !ok {
+				return nil, fmt.Errorf("data metric function %s does not exist", association.Function)
+			}
+			if len(association.Columns) != len(function.ColumnArgs) {
+				return nil, fmt.Errorf("data metric function %s takes %d columns but %d were given",
+					function.Name, len(function.ColumnArgs), len(association.Columns))
+			}
+			columns := "*"
+			if len(association.Columns) > 0 {
+				columns = strings.Join(association.Columns, ", ")
+			}
+			if _, err := e.manager(ctx).Exec(ctx, fmt.Sprintf("SELECT %s FROM %s LIMIT 0", columns, ref)); err != nil {
+				return nil, fmt.Errorf("cannot add data metric function %s to table %s: %w", function.Name, alter.Table, err)
+			}
+			association.Function = function.Name
+			table.Metrics = append(table.Metrics, association)
+		}
+	case "DROP":
+		for _, association := range alter.Associations {
+			function, _ := e.lookupDataMetricFunction(ctx, association.Function)
+			index := -1
+			for i, metric := range table.Metrics {
+				if function != nil && metric.Function == function.Name && strings.EqualFold(
+					strings.Join(metric.Columns, ","), strings.Join(association.Columns, ",")) {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return nil, fmt.Errorf("data metric function %s is not added to table %s", association.Function, alter.Table)
+			}
+			table.Metrics = append(table.Metrics[:index], table.Metrics[index+1:]...)
+		}
+	case "SET":
+		table.Schedule = alter.Schedule
+	case "UNSET":
+		table.Schedule = ""
+	}
+
+	if e.dataMetrics.tables == nil {
+		e.dataMetrics.tables = make(map[string]*dataMetricTable)
+	}
+	e.dataMetrics.tables[key] = table
+	return &ExecResult{}, nil
+}
+
+// dataMetricQuery returns the query evaluating a DMF on columns of a table:
+// the table argument becomes a common table expression over the columns.
+func dataMetricQuery(function *dataMetricFunction, source string) string {
+	tableArg := function.TableArg
+	if len(function.ColumnArgs) > 0 {
+		tableArg += "(" + strings.Join(function.ColumnArgs, ", ") + ")"
+	}
+	return fmt.Sprintf("WITH %s AS (%s) %s", tableArg, source, function.Expression)
+}
+
+// rewriteDataMetricCall rewrites Snowflake's manual evaluation of a DMF,
+// SELECT dmf(SELECT columns FROM table), as the query evaluating it. It
+// reports false for other statements.
+func (e *Executor) rewriteDataMetricCall(ctx context.Context, sql string) (string, bool) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	if !keywordAt(s, 0, "SELECT") {
+		return "", false
+	}
+	s = strings.TrimSpace(s[len("SELECT"):])
+	paren := strings.IndexByte(s, '(')
+	if paren <= 0 || matchingParen(s[paren:]) != len(s)-paren-1 {
+		return "", false
+	}
+	source := strings.TrimSpace(s[paren+1 : len(s)-1])
+	if !keywordAt(source, 0, "SELECT") {
+		return "", false
+	}
+
+	e.dataMetrics.mu.Lock()
+	function, ok := e.lookupDataMetricFunction(ctx, strings.TrimSpace(s[:paren]))
+	e.dataMetrics.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	return dataMetricQuery(function, source), true
+}
+
+// EvaluateDataMetrics evaluates the data metric functions added to a table, or
+// to all tables when table is "", and records the measurements in
+// SNOWFLAKE.LOCAL.DATA_QUALITY_MONITORING_RESULTS. It stands in for the
+// DATA_METRIC_SCHEDULE, which the emulator does not run. Table names are
+// completed with the context's session database and schema.
+func (e *Executor) EvaluateDataMetrics(ctx context.Context, table string) ([]DataMetricResult, error) {
+	e.dataMetrics.mu.Lock()
+	var tables []*dataMetricTable
+	if table != "" {
+		found, ok := e.dataMetrics.tables[qualifiedName(ctx, table)]
+		if !ok {
+			e.dataMetrics.mu.Unlock()
+			return nil, fmt.Errorf("table %s has no data metric functions", table)
+		}
+		tables = append(tables, found)
+	} else {
+		for _, found := range e.dataMetrics.tables {
+			tables = append(tables, found)
+		}
+	}
+	type evaluation struct {
+		table       *dataMetricTable
+		association dataMetricAssociation
+		function    *dataMetricFunction
+	}
+	var evaluations []evaluation
+	for _, t := range tables {
+		for _, association := range t.Metrics {
+			function, ok := e.lookupDataMetricFunction(ctx, association.Function)
+			if !ok {
+				continue
+			}
+			evaluations = append(evaluations, evaluation{table: t, association: association, function: function})
+		}
+	}
+	e.dataMetrics.mu.Unlock()
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		return evaluations[i].table.Ref < evaluations[j].table.Ref
+	})
+
+	now := time.Now().UTC()
+	results := make([]DataMetricResult, 0, len(evaluations))
+	for _, ev := range evaluations {
+		columns := "*"
+		if len(ev.association.Columns) > 0 {
+			columns = strings.Join(ev.association.Columns, ", ")
+		}
+		source := fmt.Sprintf("SELECT %s FROM %s", columns, ev.table.Ref)
+		result, err := e.Query(ctx, dataMetricQuery(ev.function, source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate data metric function %s on table %s: %w", ev.function.Name, ev.table.Name, err)
+		}
+
+		metricDatabase, metricSchema, metricName := splitObjectName(context.Background(), ev.function.Name)
+		measurement := DataMetricResult{
+			MeasurementTime: now,
+			TableDatabase:   ev.table.Database,
+			TableSchema:     ev.table.Schema,
+			TableName:       ev.table.Name,
+			MetricDatabase:  metricDatabase,
+			MetricSchema:    metricSchema,
+			MetricName:      metricName,
+			ArgumentNames:   make([]string, len(ev.association.Columns)),
+		}
+		for i, column := range ev.association.Columns {
+			measurement.ArgumentNames[i] = strings.ToUpper(unquoteIdentifier(column))
+		}
+		if len(result.Rows) > 0 && result.Rows[0][0] != nil {
+			value, err := strconv.ParseFloat(fmt.Sprint(result.Rows[0][0]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("data metric function %s returned %v, which is not a number", ev.function.Name, result.Rows[0][0])
+			}
+			measurement.Value = &value
+		}
+		if err := e.recordDataMetricResult(ctx, measurement); err != nil {
+			return nil, err
+		}
+		results = append(results, measurement)
+	}
+	return results, nil
+}
+
+// recordDataMetricResult inserts a measurement into the results table.
+func (e *Executor) recordDataMetricResult(ctx context.Context, result DataMetricResult) error {
+	arguments, err := json.Marshal(result.ArgumentNames)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if result.Value != nil {
+		value = *result.Value
+	}
+	insertSQL := fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", dataMetricResultsTable)
+	if _, err := e.mgr.Exec(ctx, insertSQL, result.MeasurementTime, result.TableDatabase, result.TableSchema, result.TableName,
+		result.MetricDatabase, result.MetricSchema, result.MetricName, string(arguments), value); err != nil {
+		return fmt.Errorf("failed to record data metric result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDataMetricAlter(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		want   *dataMetricAlter
+		wantOK bool
+	}{
+		{
+			name: "Add",
+			sql:  "ALTER TABLE sales.raw.orders ADD DATA METRIC FUNCTION SNOWFLAKE.CORE.NULL_COUNT ON (email), dq.positive ON (a, b);",
+			want: &dataMetricAlter{Table: "sales.raw.orders", Action: "ADD", Associations: []dataMetricAssociation{
+				{Function: "SNOWFLAKE.CORE.NULL_COUNT", Columns: []string{"email"}},
+				{Function: "dq.positive", Columns: []string{"a", "b"}},
+			}},
+			wantOK: true,
+		},
+		{
+			name: "DropNoColumns",
+			sql:  "ALTER TABLE IF EXISTS orders DROP DATA METRIC FUNCTION SNOWFLAKE.CORE.ROW_COUNT ON ()",
+			want: &dataMetricAlter{Table: "orders", Action: "DROP", Associations: []dataMetricAssociation{
+				{Function: "SNOWFLAKE.CORE.ROW_COUNT"},
+			}},
+			wantOK: true,
+		},
+		{
+			name:   "Schedule",
+			sql:    "ALTER TABLE orders SET DATA_METRIC_SCHEDULE = 'USING CRON 0 8 * * * UTC'",
+			want:   &dataMetricAlter{Table: "orders", Action: "SET", Schedule: "USING CRON 0 8 * * * UTC"},
+			wantOK: true,
+		},
+		{
+			name:   "Unset",
+			sql:    "ALTER TABLE orders UNSET DATA_METRIC_SCHEDULE",
+			want:   &dataMetricAlter{Table: "orders", Action: "UNSET"},
+			wantOK: true,
+		},
+		{
+			name: "OtherAlter",
+			sql:  "ALTER TABLE orders ADD COLUMN note VARCHAR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseDataMetricAlter(tt.sql)
+			if err != nil {
+				t.Fatalf("parseDataMetricAlter() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("parseDataMetricAlter() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseDataMetricAlter() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_DataMetricFunctions tests creating a DMF, evaluating it
+// manually, and recording the measurements of the DMFs added to a table.
+func TestExecutor_DataMetricFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		"CREATE TABLE customers (id INTEGER, email VARCHAR, score INTEGER)",
+		"INSERT INTO customers VALUES (1, 'a@example.com', 5), (2, NULL, -1), (3, 'c@example.com', 7)",
+		`CREATE DATA METRIC FUNCTION positive_scores(arg_t TABLE(arg_score NUMBER))
+		RETURNS NUMBER
+		COMMENT = 'Rows with a positive score'
+		AS $$ SELECT COUNT_IF(arg_score > 0) FROM arg_t $$`,
+		"ALTER TABLE customers SET DATA_METRIC_SCHEDULE = '5 MINUTE'",
+		"ALTER TABLE customers ADD DATA METRIC FUNCTION SNOWFLAKE.CORE.NULL_COUNT ON (email), positive_scores ON (score)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	manual, err := executor.Query(ctx, "SELECT positive_scores(SELECT score FROM customers WHERE id < 3)")
+	if err != nil {
+		t.Fatalf("manual evaluation error = %v", err)
+	}
+	if len(manual.Rows) != 1 || fmt.Sprint(manual.Rows[0][0]) != "1" {
+		t.Errorf("manual evaluation = %v, want 1", manual.Rows)
+	}
+
+	results, err := executor.EvaluateDataMetrics(ctx, "customers")
+	if err != nil {
+		t.Fatalf("EvaluateDataMetrics() error = %v", err)
+	}
+	values := map[string]float64{}
+	for _, result := range results {
+		if result.Value == nil {
+			t.Fatalf("%s returned NULL", result.MetricName)
+		}
+		values[result.MetricName] = *result.Value
+	}
+	if diff := cmp.Diff(map[string]float64{"NULL_COUNT": 1, "POSITIVE_SCORES": 2}, values); diff != "" {
+		t.Errorf("measurements mismatch (-want +got):\n%s", diff)
+	}
+
+	recorded, err := executor.Query(ctx, "SELECT TABLE_NAME, METRIC_NAME, VALUE FROM SNOWFLAKE.LOCAL.DATA_QUALITY_MONITORING_RESULTS ORDER BY METRIC_NAME")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	expected := [][]interface{}{{"CUSTOMERS", "NULL_COUNT", 1.0}, {"CUSTOMERS", "POSITIVE_SCORES", 2.0}}
+	if diff := cmp.Diff(expected, recorded.Rows); diff != "" {
+		t.Errorf("recorded results mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, sql := range []string{
+		"ALTER TABLE customers ADD DATA METRIC FUNCTION missing_metric ON (email)",
+		"ALTER TABLE customers ADD DATA METRIC FUNCTION SNOWFLAKE.CORE.NULL_COUNT ON (email, score)",
+		"ALTER TABLE customers ADD DATA METRIC FUNCTION SNOWFLAKE.CORE.NULL_COUNT ON (no_such_column)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err == nil {
+			t.Errorf("Execute(%q) succeeded", sql)
+		}
+	}
+
+	if _, err := executor.Execute(ctx, "ALTER TABLE customers DROP DATA METRIC FUNCTION positive_scores ON (score)"); err != nil {
+		t.Fatalf("DROP DATA METRIC FUNCTION error = %v", err)
+	}
+	results, err = executor.EvaluateDataMetrics(ctx, "")
+	if err != nil {
+		t.Fatalf("EvaluateDataMetrics() error = %v", err)
+	}
+	if len(results) != 1 || results[0].MetricName != "NULL_COUNT" {
+		t.Errorf("EvaluateDataMetrics() after DROP = %+v, want NULL_COUNT only", results)
+	}
+}
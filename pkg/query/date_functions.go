@@ -0,0 +1,219 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// weekdayNumbers maps the two-letter prefixes of the day names Snowflake's
+// NEXT_DAY and PREVIOUS_DAY accept to their ISO day numbers.
+var weekdayNumbers = map[string]int{"MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6, "SU": 7}
+
+// registerDateFunctions registers translations for the date and time functions
+// DuckDB lacks or computes unlike Snowflake. They are resolved in
+// transformDateFunctions.
+func (t *Translator) registerDateFunctions() {
+	for _, name := range []string{
+		"LAST_DAY", "NEXT_DAY", "PREVIOUS_DAY", "DAYNAME", "MONTHNAME",
+		"TIME_SLICE", "ADD_MONTHS", "MONTHS_BETWEEN", "CONVERT_TIMEZONE",
+	} {
+		t.functionMap[name] = markFunction("__" + name + "__")
+	}
+}
+
+// truncatePart returns the DuckDB date_trunc() part for a Snowflake date or
+// time part, resolving its abbreviations and plurals. DuckDB timestamps hold
+// microseconds, so truncating to nanoseconds truncates to microseconds.
+func truncatePart(part string) string {
+	part = strings.ToUpper(strings.TrimSpace(part))
+	if alias, ok := datePartAliases[part]; ok {
+		part = alias
+	}
+	if part == "NANOSECOND" {
+		return "microsecond"
+	}
+	if unit, ok := intervalUnits[part]; ok {
+		return unit
+	}
+	return strings.ToLower(part)
+}
+
+// datePartArg returns the date or time part an argument names: the value of
+// a string literal or, as Snowflake also accepts, an unquoted word such as
+// MONTH.
+func datePartArg(arg string) (string, bool) {
+	if part, ok := stringLiteral(arg); ok {
+		return part, true
+	}
+	arg = strings.TrimSpace(arg)
+	if arg == "" || (arg[0] >= '0' && arg[0] <= '9') {
+		return "", false
+	}
+	for i := 0; i < len(arg); i++ {
+		if c := arg[i]; !isIdentChar(c) || c == '.' || c == '$' {
+			return "", false
+		}
+	}
+	return arg, true
+}
+
+// temporalArg returns a date or time argument, casting string literals, which
+// DuckDB does not convert implicitly, to a DATE or, if they hold a time, a
+// TIMESTAMP.
+func temporalArg(arg string) string {
+	arg = strings.TrimSpace(arg)
+	value, ok := stringLiteral(arg)
+	switch {
+	case !ok:
+		return arg
+	case strings.Contains(value, ":"):
+		return fmt.Sprintf("CAST(%s AS TIMESTAMP)", arg)
+	default:
+		return fmt.Sprintf("CAST(%s AS DATE)", arg)
+	}
+}
+
+// transformDateFunctions resolves the markers registered by registerDateFunctions.
+func (t *Translator) transformDateFunctions(sql string) string {
+	// LAST_DAY(d [, part]) → the last day of the month, or of the year, quarter,
+	// or week, of d. Weeks are left to transformWeekFunctions, which knows WEEK_START.
+	sql = t.transformMarkedFunction(sql, "__LAST_DAY__", func(args string) string {
+		parts := trimmedArgs(args, 2)
+		date := fmt.Sprintf("CAST(%s AS DATE)", temporalArg(parts[0]))
+		part := "month"
+		if len(parts) == 2 {
+			literal, ok := datePartArg(parts[1])
+			if !ok {
+				return "last_day(" + args + ")"
+			}
+			part = truncatePart(literal)
+		}
+		switch part {
+		case "month":
+			return fmt.Sprintf("last_day(%s)", date)
+		case "quarter":
+			return fmt.Sprintf("last_day(date_trunc('quarter', %s) + to_months(2))", date)
+		case "year":
+			return fmt.Sprintf("last_day(date_trunc('year', %s) + to_months(11))", date)
+		case "week":
+			return fmt.Sprintf("(__DATE_TRUNC__('week', %s) + 6)", date)
+		}
+		return "last_day(" + args + ")"
+	})
+
+	// NEXT_DAY(d, 'dow') and PREVIOUS_DAY(d, 'dow') → the first date after, or
+	// before, d falling on the day of the week named by the first two letters of dow
+	for _, marker := range []string{"__NEXT_DAY__", "__PREVIOUS_DAY__"} {
+		next := marker == "__NEXT_DAY__"
+		sql = t.transformMarkedFunction(sql, marker, func(args string) string {
+			parts := trimmedArgs(args, 2)
+			if len(parts) != 2 {
+				return strings.ToLower(strings.Trim(marker, "_")) + "(" + args + ")"
+			}
+			date := fmt.Sprintf("CAST(%s AS DATE)", temporalArg(parts[0]))
+			// 'MOTUWETHFRSASU' holds the prefixes in ISO order, two letters apart
+			weekday := fmt.Sprintf("((position(upper(left(trim(%s), 2)) IN 'MOTUWETHFRSASU') + 1) // 2)", parts[1])
+			if name, ok := stringLiteral(parts[1]); ok {
+				name = strings.ToUpper(strings.TrimSpace(name))
+				if len(name) >= 2 {
+					if n, ok := weekdayNumbers[name[:2]]; ok {
+						weekday = fmt.Sprint(n)
+					}
+				}
+			}
+			if next {
+				return fmt.Sprintf("(%s + CAST((%s - isodow(%s) + 6) %% 7 + 1 AS INTEGER))", date, weekday, date)
+			}
+			return fmt.Sprintf("(%s - CAST((isodow(%s) - %s + 6) %% 7 + 1 AS INTEGER))", date, date, weekday)
+		})
+	}
+
+	// DAYNAME(d) and MONTHNAME(d) → the three-letter English abbreviation, e.g. 'Fri' and 'May'
+	sql = t.transformMarkedFunction(sql, "__DAYNAME__", func(args string) string {
+		return fmt.Sprintf("strftime(CAST(%s AS DATE), '%%a')", temporalArg(args))
+	})
+	sql = t.transformMarkedFunction(sql, "__MONTHNAME__", func(args string) string {
+		return fmt.Sprintf("strftime(CAST(%s AS DATE), '%%b')", temporalArg(args))
+	})
+
+	sql = t.transformMarkedFunction(sql, "__TIME_SLICE__", timeSlice)
+
+	// ADD_MONTHS(d, n) → d + n months. As in Snowflake, the last day of a month
+	// maps to the last day of the resulting month, and other days are clamped to it.
+	sql = t.transformMarkedFunction(sql, "__ADD_MONTHS__", func(args string) string {
+		parts := trimmedArgs(args, 2)
+		if len(parts) != 2 {
+			return "add_months(" + args + ")"
+		}
+		date := temporalArg(parts[0])
+		added := fmt.Sprintf("%s + to_months(CAST(%s AS INTEGER))", date, parts[1])
+		return fmt.Sprintf("(%[2]s + to_days(CASE WHEN day(%[1]s) = day(last_day(%[1]s)) THEN day(last_day(%[2]s)) - day(%[2]s) ELSE 0 END))",
+			date, added)
+	})
+
+	// MONTHS_BETWEEN(a, b) → the months from b to a. As in Snowflake, the result
+	// is whole if both are the same day of the month or the last days of their
+	// months; otherwise the days add a fraction of a 31-day month. Times are ignored.
+	sql = t.transformMarkedFunction(sql, "__MONTHS_BETWEEN__", func(args string) string {
+		parts := trimmedArgs(args, 2)
+		if len(parts) != 2 {
+			return "months_between(" + args + ")"
+		}
+		a := fmt.Sprintf("CAST(%s AS DATE)", temporalArg(parts[0]))
+		b := fmt.Sprintf("CAST(%s AS DATE)", temporalArg(parts[1]))
+		months := fmt.Sprintf("((year(%[1]s) - year(%[2]s)) * 12 + month(%[1]s) - month(%[2]s))", a, b)
+		return fmt.Sprintf("CAST(CASE WHEN day(%[1]s) = day(%[2]s) OR (%[1]s = last_day(%[1]s) AND %[2]s = last_day(%[2]s)) THEN %[3]s "+
+			"ELSE %[3]s + (day(%[1]s) - day(%[2]s)) / 31 END AS DECIMAL(38, 6))", a, b, months)
+	})
+
+	// CONVERT_TIMEZONE(target, ts) → the local time in target of the instant ts;
+	// TIMESTAMPTZ keeps no offset and would show the instant in the session time zone.
+	// CONVERT_TIMEZONE(source, target, ts) → the local time in target of the local time ts in source
+	return t.transformMarkedFunction(sql, "__CONVERT_TIMEZONE__", func(args string) string {
+		parts := trimmedArgs(args, 3)
+		switch len(parts) {
+		case 2:
+			return fmt.Sprintf("timezone(%s, CAST(%s AS TIMESTAMPTZ))", parts[0], parts[1])
+		case 3:
+			return fmt.Sprintf("timezone(%s, timezone(%s, CAST(%s AS TIMESTAMP)))", parts[1], parts[0], parts[2])
+		}
+		return "convert_timezone(" + args + ")"
+	})
+}
+
+// timeSlice translates the arguments of TIME_SLICE(t, n, part [, 'START' | 'END'])
+// into the start, or end, of the slice of n parts holding t. As in Snowflake,
+// slices are aligned to 1970-01-01, and week slices to the Monday after it.
+func timeSlice(args string) string {
+	parts := trimmedArgs(args, 4)
+	if len(parts) != 3 && len(parts) != 4 {
+		return "time_slice(" + args + ")"
+	}
+	literal, ok := datePartArg(parts[2])
+	if !ok {
+		return "time_slice(" + args + ")"
+	}
+
+	n := fmt.Sprintf("CAST(%s AS INTEGER)", parts[1])
+	origin := "DATE '1970-01-01'"
+	var interval string
+	switch part := truncatePart(literal); part {
+	case "quarter":
+		interval = fmt.Sprintf("to_months(3 * %s)", n)
+	case "week":
+		interval = fmt.Sprintf("to_weeks(%s)", n)
+		origin = "DATE '1970-01-05'"
+	case "year", "month", "day", "hour", "minute", "second":
+		interval = fmt.Sprintf("to_%ss(%s)", part, n)
+	default:
+		return "time_slice(" + args + ")"
+	}
+
+	slice := fmt.Sprintf("time_bucket(%s, %s, %s)", interval, temporalArg(parts[0]), origin)
+	if len(parts) == 4 {
+		if position, ok := stringLiteral(parts[3]); ok && strings.EqualFold(strings.TrimSpace(position), "END") {
+			return fmt.Sprintf("(%s + %s)", slice, interval)
+		}
+	}
+	return slice
+}
@@ -0,0 +1,170 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestTranslator_DateFunctions tests LAST_DAY, NEXT_DAY, TIME_SLICE, ADD_MONTHS,
+// CONVERT_TIMEZONE, and the other date function translations.
+func TestTranslator_DateFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "DateTruncAlias",
+			input:    "SELECT DATE_TRUNC('mm', d), DATE_TRUNC('HH', ts) FROM t",
+			expected: "select date_trunc('month', d), date_trunc('hour', ts) from t",
+		},
+		{
+			name:     "UnquotedParts",
+			input:    "SELECT DATE_TRUNC(MONTH, d), DATE_TRUNC(hh, ts), LAST_DAY(d, YEAR), TIME_SLICE(ts, 15, MINUTE) FROM t",
+			expected: "select date_trunc('month', d), date_trunc('hour', ts), last_day(date_trunc('year', CAST(d AS DATE)) + to_months(11)), time_bucket(to_minutes(CAST(15 AS INTEGER)), ts, DATE '1970-01-01') from t",
+		},
+		{
+			name:     "LastDay",
+			input:    "SELECT LAST_DAY(d), LAST_DAY(d, 'YEAR') FROM t",
+			expected: "select last_day(CAST(d AS DATE)), last_day(date_trunc('year', CAST(d AS DATE)) + to_months(11)) from t",
+		},
+		{
+			name:     "NextDay",
+			input:    "SELECT NEXT_DAY(d, 'Friday') FROM t",
+			expected: "select (CAST(d AS DATE) + CAST((5 - isodow(CAST(d AS DATE)) + 6) % 7 + 1 AS INTEGER)) from t",
+		},
+		{
+			name:     "DayName",
+			input:    "SELECT DAYNAME(d), MONTHNAME(d) FROM t",
+			expected: "select strftime(CAST(d AS DATE), '%a'), strftime(CAST(d AS DATE), '%b') from t",
+		},
+		{
+			name:     "TimeSlice",
+			input:    "SELECT TIME_SLICE(ts, 15, 'MINUTE', 'END') FROM t",
+			expected: "select (time_bucket(to_minutes(CAST(15 AS INTEGER)), ts, DATE '1970-01-01') + to_minutes(CAST(15 AS INTEGER))) from t",
+		},
+		{
+			name:     "ConvertTimezone",
+			input:    "SELECT CONVERT_TIMEZONE('America/Los_Angeles', ts), CONVERT_TIMEZONE('UTC', 'Asia/Tokyo', ts) FROM t",
+			expected: "select timezone('America/Los_Angeles', CAST(ts AS TIMESTAMPTZ)), timezone('Asia/Tokyo', timezone('UTC', CAST(ts AS TIMESTAMP))) from t",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTranslator().Translate(tt.input)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_DateFunctions tests the date functions against DuckDB.
+func TestExecutor_DateFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		sql  string
+		// want holds the values as fmt.Sprint formats them, and times without their zone
+		want []string
+	}{
+		{
+			name: "DateTrunc",
+			sql:  "SELECT DATE_TRUNC('mm', CAST('2024-05-17' AS DATE)), DATE_TRUNC('hh', CAST('2024-05-17 10:11:12' AS TIMESTAMP)), TRUNC(CAST('2024-05-17' AS DATE), 'q')",
+			want: []string{"2024-05-01 00:00:00", "2024-05-17 10:00:00", "2024-04-01 00:00:00"},
+		},
+		{
+			name: "DateTruncUnquotedPart",
+			sql:  "SELECT DATE_TRUNC(MONTH, CAST('2024-05-17' AS DATE)), DATE_TRUNC(hh, CAST('2024-05-17 10:11:12' AS TIMESTAMP)), LAST_DAY('2024-02-10', YEAR)",
+			want: []string{"2024-05-01 00:00:00", "2024-05-17 10:00:00", "2024-12-31 00:00:00"},
+		},
+		{
+			name: "LastDay",
+			sql:  "SELECT LAST_DAY(CAST('2024-02-10' AS DATE)), LAST_DAY('2024-02-10', 'YEAR'), LAST_DAY('2024-02-10', 'quarter'), LAST_DAY(CAST('2024-05-17 10:00:00' AS TIMESTAMP), 'WEEK')",
+			want: []string{"2024-02-29 00:00:00", "2024-12-31 00:00:00", "2024-03-31 00:00:00", "2024-05-19 00:00:00"},
+		},
+		{
+			name: "NextAndPreviousDay",
+			sql:  "SELECT NEXT_DAY(CAST('2024-05-17' AS DATE), 'Friday'), NEXT_DAY('2024-05-17', 'sa'), PREVIOUS_DAY('2024-05-17', 'mo'), PREVIOUS_DAY('2024-05-17', 'Fr')",
+			want: []string{"2024-05-24 00:00:00", "2024-05-18 00:00:00", "2024-05-13 00:00:00", "2024-05-10 00:00:00"},
+		},
+		{
+			name: "Names",
+			sql:  "SELECT DAYNAME(CAST('2024-05-17' AS DATE)), MONTHNAME(CAST('2024-05-17 10:00:00' AS TIMESTAMP)), WEEKISO(CAST('2024-05-17' AS DATE))",
+			want: []string{"Fri", "May", "20"},
+		},
+		{
+			name: "TimeSlice",
+			sql: "SELECT TIME_SLICE(CAST('2024-05-17 10:11:12' AS TIMESTAMP), 15, 'MINUTE'), TIME_SLICE(CAST('2024-05-17 10:11:12' AS TIMESTAMP), 15, 'MINUTE', 'END'), " +
+				"TIME_SLICE(CAST('2024-05-17' AS DATE), 1, 'WEEK'), TIME_SLICE(CAST('2024-05-17' AS DATE), 1, 'QUARTER')",
+			want: []string{"2024-05-17 10:00:00", "2024-05-17 10:15:00", "2024-05-13 00:00:00", "2024-04-01 00:00:00"},
+		},
+		{
+			name: "AddMonths",
+			sql:  "SELECT ADD_MONTHS(CAST('2024-01-31' AS DATE), 1), ADD_MONTHS('2024-02-29', 1), ADD_MONTHS('2024-01-15 10:00:00', -2)",
+			want: []string{"2024-02-29 00:00:00", "2024-03-31 00:00:00", "2023-11-15 10:00:00"},
+		},
+		{
+			name: "MonthsBetween",
+			sql:  "SELECT MONTHS_BETWEEN('2024-03-31', '2024-02-29'), MONTHS_BETWEEN('2019-03-01 02:00:00', '2019-02-15 01:00:00'), MONTHS_BETWEEN('2019-01-01', '2019-02-01')",
+			want: []string{"1", "0.548387", "-1"},
+		},
+		{
+			name: "ConvertTimezone",
+			sql:  "SELECT CONVERT_TIMEZONE('America/Los_Angeles', 'Asia/Tokyo', '2024-05-17 10:00:00'), CONVERT_TIMEZONE(NULL, CAST('2024-05-17 10:00:00+00' AS TIMESTAMP_TZ))",
+			want: []string{"2024-05-18 02:00:00", "<nil>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			got := make([]string, len(result.Rows[0]))
+			for i, value := range result.Rows[0] {
+				if ts, ok := value.(time.Time); ok {
+					value = ts.Format(time.DateTime)
+				}
+				got[i] = fmt.Sprint(value)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_ConvertTimezoneTarget tests that the 2-argument CONVERT_TIMEZONE
+// returns the local time in the target time zone, and fails on unknown time zones.
+func TestExecutor_ConvertTimezoneTarget(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	result, err := executor.Query(ctx, "SELECT CONVERT_TIMEZONE('America/Los_Angeles', CAST('2024-05-17 10:00:00+00' AS TIMESTAMP_TZ)) AS converted")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if got := result.ColumnTypes[0].Type; got != "timestamp_ntz" {
+		t.Errorf("column type = %q, want timestamp_ntz", got)
+	}
+	want := "2024-05-17 03:00:00"
+	if got, ok := result.Rows[0][0].(time.Time); !ok || got.Format(time.DateTime) != want {
+		t.Errorf("CONVERT_TIMEZONE() = %v, want the local time %s", result.Rows[0][0], want)
+	}
+
+	if _, err := executor.Query(ctx, "SELECT CONVERT_TIMEZONE('Mars/Olympus_Mons', CAST('2024-05-17 10:00:00+00' AS TIMESTAMP_TZ))"); err == nil {
+		t.Error("CONVERT_TIMEZONE() to an unknown time zone succeeded, want error")
+	}
+}
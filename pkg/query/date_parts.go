@@ -0,0 +1,198 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// datePartAliases maps the abbreviations and plurals Snowflake accepts for the
+// date and time parts of EXTRACT and DATE_PART to the part they name.
+var datePartAliases = map[string]string{
+	"Y": "YEAR", "YY": "YEAR", "YYY": "YEAR", "YYYY": "YEAR", "YR": "YEAR", "YEARS": "YEAR", "YRS": "YEAR",
+	"MM": "MONTH", "MON": "MONTH", "MONS": "MONTH", "MONTHS": "MONTH",
+	"D": "DAY", "DD": "DAY", "DAYS": "DAY", "DAYOFMONTH": "DAY",
+	"WEEKDAY": "DAYOFWEEK", "DOW": "DAYOFWEEK", "DW": "DAYOFWEEK",
+	"WEEKDAY_ISO": "DAYOFWEEKISO", "DOW_ISO": "DAYOFWEEKISO", "DW_ISO": "DAYOFWEEKISO",
+	"YEARDAY": "DAYOFYEAR", "DOY": "DAYOFYEAR", "DY": "DAYOFYEAR",
+	"W": "WEEK", "WK": "WEEK", "WEEKOFYEAR": "WEEK", "WOY": "WEEK", "WY": "WEEK",
+	"WEEK_ISO": "WEEKISO", "WEEKOFYEARISO": "WEEKISO", "WEEKOFYEAR_ISO": "WEEKISO",
+	"Q": "QUARTER", "QTR": "QUARTER", "QTRS": "QUARTER", "QUARTERS": "QUARTER",
+	"H": "HOUR", "HH": "HOUR", "HR": "HOUR", "HOURS": "HOUR", "HRS": "HOUR",
+	"M": "MINUTE", "MI": "MINUTE", "MIN": "MINUTE", "MINUTES": "MINUTE", "MINS": "MINUTE",
+	"S": "SECOND", "SEC": "SECOND", "SECONDS": "SECOND", "SECS": "SECOND",
+	"NS": "NANOSECOND", "NSEC": "NANOSECOND", "NANOSEC": "NANOSECOND", "NSECOND": "NANOSECOND",
+	"NANOSECONDS": "NANOSECOND", "NANOSECS": "NANOSECOND", "NSECONDS": "NANOSECOND",
+	"EPOCH": "EPOCH_SECOND", "EPOCH_SECONDS": "EPOCH_SECOND",
+	"EPOCH_MILLISECONDS": "EPOCH_MILLISECOND", "EPOCH_MICROSECONDS": "EPOCH_MICROSECOND", "EPOCH_NANOSECONDS": "EPOCH_NANOSECOND",
+	"TZH": "TIMEZONE_HOUR", "TZM": "TIMEZONE_MINUTE",
+}
+
+// datePartExpressions maps the date and time parts that DuckDB's date_part()
+// does not compute like Snowflake to the expression computing them.
+// DAYOFWEEK and WEEK are left to transformWeekFunctions, as they depend on the
+// session's WEEK_START and WEEK_OF_YEAR_POLICY.
+var datePartExpressions = map[string]string{
+	"DAYOFWEEK":         "__DAYOFWEEK__(%s)",
+	"WEEK":              "__WEEK__(%s)",
+	"DAYOFWEEKISO":      "isodow(%s)",
+	"WEEKISO":           "week(%s)",
+	"YEAROFWEEK":        "isoyear(%s)",
+	"YEAROFWEEKISO":     "isoyear(%s)",
+	"NANOSECOND":        "(CAST(date_part('microsecond', %s) %% 1000000 AS BIGINT) * 1000)",
+	"EPOCH_SECOND":      "CAST(floor(epoch(%s)) AS BIGINT)",
+	"EPOCH_MILLISECOND": "epoch_ms(%s)",
+	"EPOCH_MICROSECOND": "epoch_us(%s)",
+	"EPOCH_NANOSECOND":  "epoch_ns(%s)",
+}
+
+// registerDatePartFunctions registers translations for the functions extracting
+// parts from dates and times, and for the *_FROM_PARTS functions building them.
+// They are resolved in transformDateParts.
+func (t *Translator) registerDatePartFunctions() {
+	t.functionMap["EXTRACT"] = markFunction("__DATE_PART__")
+	t.functionMap["DATE_PART"] = markFunction("__DATE_PART__")
+
+	for marker, names := range map[string][]string{
+		"__DATE_FROM_PARTS__":          {"DATE_FROM_PARTS", "DATEFROMPARTS"},
+		"__TIME_FROM_PARTS__":          {"TIME_FROM_PARTS", "TIMEFROMPARTS"},
+		"__TIMESTAMP_FROM_PARTS__":     {"TIMESTAMP_FROM_PARTS", "TIMESTAMPFROMPARTS", "TIMESTAMP_NTZ_FROM_PARTS", "TIMESTAMPNTZFROMPARTS"},
+		"__TIMESTAMP_LTZ_FROM_PARTS__": {"TIMESTAMP_LTZ_FROM_PARTS", "TIMESTAMPLTZFROMPARTS"},
+		"__TIMESTAMP_TZ_FROM_PARTS__":  {"TIMESTAMP_TZ_FROM_PARTS", "TIMESTAMPTZFROMPARTS"},
+	} {
+		for _, name := range names {
+			t.functionMap[name] = markFunction(marker)
+		}
+	}
+}
+
+// rewriteExtractFrom rewrites EXTRACT(part FROM expr) into EXTRACT('part', expr),
+// the form of DATE_PART, so that the statement can be parsed.
+func rewriteExtractFrom(sql string) string {
+	var b strings.Builder
+	last := 0
+	scanFunctionCalls(sql, func(name string, _, end int) bool {
+		if name != "EXTRACT" {
+			return true
+		}
+		open := strings.IndexByte(sql[end:], '(') + end
+		partStart := open + 1
+		for partStart < len(sql) && isSpace(sql[partStart]) {
+			partStart++
+		}
+		partEnd := partStart
+		for partEnd < len(sql) && isIdentChar(sql[partEnd]) {
+			partEnd++
+		}
+		from := partEnd
+		for from < len(sql) && isSpace(sql[from]) {
+			from++
+		}
+		if partEnd == partStart || !keywordAt(sql, from, "FROM") {
+			return true
+		}
+		b.WriteString(sql[last:partStart])
+		fmt.Fprintf(&b, "'%s',", sql[partStart:partEnd])
+		last = from + len("FROM")
+		return true
+	})
+	if last == 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
+
+// transformDateParts resolves the EXTRACT, DATE_PART, and *_FROM_PARTS markers.
+//
+// Snowflake's *_FROM_PARTS functions accept out-of-range parts and carry them
+// over, so that DATE_FROM_PARTS(2024, 2, 31) is 2024-03-02 and month 0 is the
+// December of the year before. The parts are therefore added to the start of
+// the year as intervals rather than passed to make_date().
+func (t *Translator) transformDateParts(sql string) string {
+	sql = t.transformMarkedFunction(sql, "__DATE_PART__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "date_part(" + args + ")"
+		}
+		part := strings.ToUpper(strings.Trim(strings.TrimSpace(parts[0]), "'`\""))
+		if alias, ok := datePartAliases[part]; ok {
+			part = alias
+		}
+		expr := strings.TrimSpace(parts[1])
+		if format, ok := datePartExpressions[part]; ok {
+			return fmt.Sprintf(format, expr)
+		}
+		return fmt.Sprintf("date_part('%s', %s)", strings.ToLower(part), expr)
+	})
+
+	sql = t.transformMarkedFunction(sql, "__DATE_FROM_PARTS__", func(args string) string {
+		parts := splitFunctionArgs(args, 3)
+		if len(parts) != 3 {
+			return "DATE_FROM_PARTS(" + args + ")"
+		}
+		return fmt.Sprintf("CAST(%s AS DATE)", dateFromParts(parts))
+	})
+
+	sql = t.transformMarkedFunction(sql, "__TIME_FROM_PARTS__", func(args string) string {
+		parts := splitFunctionArgs(args, 4)
+		if len(parts) != 3 && len(parts) != 4 {
+			return "TIME_FROM_PARTS(" + args + ")"
+		}
+		return fmt.Sprintf("(TIME '00:00:00' + %s)", timeFromParts(parts))
+	})
+
+	// timestamp returns the TIMESTAMP built by the (date, time) or (year, month,
+	// day, hour, minute, second [, nanosecond]) arguments of a *_FROM_PARTS call
+	timestamp := func(parts []string) (string, bool) {
+		switch len(parts) {
+		case 2:
+			return fmt.Sprintf("(CAST(%s AS DATE) + CAST(%s AS TIME))", strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])), true
+		case 6, 7:
+			return fmt.Sprintf("(%s + %s)", dateFromParts(parts[:3]), timeFromParts(parts[3:])), true
+		}
+		return "", false
+	}
+	sql = t.transformMarkedFunction(sql, "__TIMESTAMP_FROM_PARTS__", func(args string) string {
+		if ts, ok := timestamp(splitFunctionArgs(args, 7)); ok {
+			return ts
+		}
+		return "TIMESTAMP_FROM_PARTS(" + args + ")"
+	})
+	sql = t.transformMarkedFunction(sql, "__TIMESTAMP_LTZ_FROM_PARTS__", func(args string) string {
+		if ts, ok := timestamp(splitFunctionArgs(args, 7)); ok {
+			return fmt.Sprintf("CAST(%s AS TIMESTAMPTZ)", ts)
+		}
+		return "TIMESTAMP_LTZ_FROM_PARTS(" + args + ")"
+	})
+	// TIMESTAMP_TZ_FROM_PARTS takes the time zone of the parts as an optional last argument
+	return t.transformMarkedFunction(sql, "__TIMESTAMP_TZ_FROM_PARTS__", func(args string) string {
+		parts := splitFunctionArgs(args, 8)
+		if len(parts) == 8 {
+			if ts, ok := timestamp(parts[:7]); ok {
+				return fmt.Sprintf("timezone(%s, %s)", strings.TrimSpace(parts[7]), ts)
+			}
+		}
+		if ts, ok := timestamp(parts); ok {
+			return fmt.Sprintf("CAST(%s AS TIMESTAMPTZ)", ts)
+		}
+		return "TIMESTAMP_TZ_FROM_PARTS(" + args + ")"
+	})
+}
+
+// dateFromParts returns the timestamp at midnight of the year, month, and day
+// parts, carrying out-of-range months and days over.
+func dateFromParts(parts []string) string {
+	return fmt.Sprintf("(make_date(CAST(%s AS BIGINT), 1, 1) + to_months(CAST(%s AS INTEGER) - 1) + to_days(CAST(%s AS INTEGER) - 1))",
+		strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2]))
+}
+
+// timeFromParts returns the interval of the hour, minute, second, and optional
+// nanosecond parts, carrying out-of-range parts over.
+func timeFromParts(parts []string) string {
+	nanoseconds := "0"
+	if len(parts) > 3 {
+		nanoseconds = strings.TrimSpace(parts[3])
+	}
+	return fmt.Sprintf("to_microseconds(CAST(%s AS BIGINT) * 3600000000 + CAST(%s AS BIGINT) * 60000000 + CAST(%s AS BIGINT) * 1000000 + CAST(%s AS BIGINT) // 1000)",
+		strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2]), nanoseconds)
+}
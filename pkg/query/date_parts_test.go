@@ -0,0 +1,74 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_DateParts tests EXTRACT and DATE_PART with Snowflake's part
+// names, and that the *_FROM_PARTS functions carry out-of-range parts over.
+func TestExecutor_DateParts(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		sql  string
+		want []interface{}
+	}{
+		{
+			name: "DateFromParts",
+			sql:  "SELECT DATE_FROM_PARTS(2024, 2, 31), DATE_FROM_PARTS(2010, 1, 0), DATEFROMPARTS(2024, 0, 0)",
+			want: []interface{}{
+				time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+				time.Date(2009, 12, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "TimeFromParts",
+			sql:  "SELECT TIME_FROM_PARTS(12, 34, 56), TIME_FROM_PARTS(0, 0, -1), TIME_FROM_PARTS(1, 2, 3, 500000000)",
+			want: []interface{}{
+				time.Date(1, 1, 1, 12, 34, 56, 0, time.UTC),
+				time.Date(1, 1, 1, 23, 59, 59, 0, time.UTC),
+				time.Date(1, 1, 1, 1, 2, 3, 500000000, time.UTC),
+			},
+		},
+		{
+			name: "TimestampFromParts",
+			sql: `SELECT TIMESTAMP_FROM_PARTS(2024, 1, 1, 25, 0, 0),
+				TIMESTAMP_NTZ_FROM_PARTS(CAST('2024-03-01' AS DATE), CAST('10:00:00' AS TIME)),
+				TIMESTAMP_TZ_FROM_PARTS(2024, 1, 1, 0, 0, 0, 0, 'America/New_York')`,
+			want: []interface{}{
+				time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Extract",
+			sql: `SELECT EXTRACT(year FROM CAST('2024-05-06 07:08:09.123456' AS TIMESTAMP)),
+				EXTRACT(epoch_second FROM CAST('2024-05-06 07:08:09.5' AS TIMESTAMP)),
+				EXTRACT(nanosecond FROM CAST('2024-05-06 07:08:09.123456' AS TIMESTAMP)),
+				DATE_PART(dow, CAST('2024-05-05' AS DATE)),
+				DATE_PART('yyyy', CAST('2024-05-05' AS DATE)),
+				DATE_PART(epoch_millisecond, CAST('1970-01-02' AS DATE))`,
+			want: []interface{}{int64(2024), int64(1714979289), int64(123456000), int64(0), int64(2024), int64(86400000)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff([][]interface{}{tt.want}, result.Rows); diff != "" {
+				t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
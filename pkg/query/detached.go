@@ -0,0 +1,58 @@
+package query
+
+import (
+	"context"
+	"sync"
+)
+
+// runningQueries tracks the statements of each session that may be aborted when
+// the session ends, keyed by session ID and then query ID.
+type runningQueries struct {
+	mu        sync.Mutex
+	bySession map[string]map[string]context.CancelFunc
+}
+
+// trackQuery registers a statement of a session started with
+// ABORT_DETACHED_QUERY set, so that AbortSessionQueries can cancel it. The
+// returned function must be called when the statement finishes.
+func (e *Executor) trackQuery(ctx context.Context, sessionID, queryID string) (context.Context, func()) {
+	if !SessionParametersFromContext(ctx).AbortDetachedQuery {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	e.running.mu.Lock()
+	if e.running.bySession == nil {
+		e.running.bySession = make(map[string]map[string]context.CancelFunc)
+	}
+	if e.running.bySession[sessionID] == nil {
+		e.running.bySession[sessionID] = make(map[string]context.CancelFunc)
+	}
+	e.running.bySession[sessionID][queryID] = cancel
+	e.running.mu.Unlock()
+
+	return ctx, func() {
+		e.running.mu.Lock()
+		delete(e.running.bySession[sessionID], queryID)
+		if len(e.running.bySession[sessionID]) == 0 {
+			delete(e.running.bySession, sessionID)
+		}
+		e.running.mu.Unlock()
+		cancel()
+	}
+}
+
+// AbortSessionQueries cancels the running statements of a session that were
+// started with ABORT_DETACHED_QUERY set, and returns how many it cancelled.
+// Like Snowflake, other statements keep running after their session ends.
+func (e *Executor) AbortSessionQueries(sessionID string) int {
+	e.running.mu.Lock()
+	defer e.running.mu.Unlock()
+
+	queries := e.running.bySession[sessionID]
+	for _, cancel := range queries {
+		cancel()
+	}
+	delete(e.running.bySession, sessionID)
+	return len(queries)
+}
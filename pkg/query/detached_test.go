@@ -0,0 +1,56 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExecutor_AbortSessionQueries(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	slow := "SELECT COUNT(*) FROM range(100000000000) a"
+
+	running := func() int {
+		executor.running.mu.Lock()
+		defer executor.running.mu.Unlock()
+		return len(executor.running.bySession["1"])
+	}
+
+	// With ABORT_DETACHED_QUERY, ending the session cancels its running queries
+	abortCtx := ContextWithSessionParameters(ctx, SessionParameters{AbortDetachedQuery: true})
+	errs := make(chan error, 1)
+	go func() {
+		_, err := executor.QueryWithHistory(abortCtx, "1", "query-1", slow)
+		errs <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for running() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("query was not tracked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := executor.AbortSessionQueries("1"); got != 1 {
+		t.Errorf("AbortSessionQueries() = %d, want 1", got)
+	}
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("aborted query should fail")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("query was not aborted")
+	}
+	if got := running(); got != 0 {
+		t.Errorf("running queries after abort = %d, want 0", got)
+	}
+
+	// Without it, queries are not tracked and keep running
+	if _, err := executor.QueryWithHistory(ctx, "1", "query-2", "SELECT 1"); err != nil {
+		t.Fatalf("QueryWithHistory() error = %v", err)
+	}
+	if got := executor.AbortSessionQueries("1"); got != 0 {
+		t.Errorf("AbortSessionQueries() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,354 @@
+package query
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// DiffOptions configures how DiffResults matches the rows of two results.
+type DiffOptions struct {
+	// Key names the columns identifying a row. Rows with the same key are
+	// compared cell by cell and reported as changed when they differ.
+	Key []string
+	// Ordered compares rows by position, as for a query with ORDER BY. Rows
+	// are otherwise compared as multisets, ignoring their order.
+	Ordered bool
+}
+
+// ResultDiff is the difference between a left and a right result. Rows are
+// compared on the columns both results have, matched by name ignoring case.
+// Values are rendered as text, or nil for NULL.
+type ResultDiff struct {
+	LeftColumns  []string
+	RightColumns []string
+	// MissingColumns are the left columns the right result lacks, and
+	// ExtraColumns the right columns the left result lacks.
+	MissingColumns []string
+	ExtraColumns   []string
+	LeftRows       int
+	RightRows      int
+	// Removed are the rows only the left result has, and Added the rows only
+	// the right result has.
+	Removed []DiffRow
+	Added   []DiffRow
+	// Changed are the rows matched by key or position whose cells differ.
+	Changed []ChangedRow
+}
+
+// Equal reports whether the results have the same columns and rows.
+func (d *ResultDiff) Equal() bool {
+	return len(d.MissingColumns) == 0 && len(d.ExtraColumns) == 0 &&
+		len(d.Removed) == 0 && len(d.Added) == 0 && len(d.Changed) == 0
+}
+
+// DiffRow is a row of one of the compared results.
+type DiffRow struct {
+	// Index is the 0-based position of the row in its result.
+	Index  int
+	Values []interface{}
+}
+
+// ChangedRow is a pair of matched rows whose cells differ.
+type ChangedRow struct {
+	LeftIndex  int
+	RightIndex int
+	// Key holds the row's key values when rows are matched by key.
+	Key   []interface{}
+	Cells []CellDiff
+}
+
+// CellDiff is a cell that differs between matched rows.
+type CellDiff struct {
+	Column string
+	Left   interface{}
+	Right  interface{}
+}
+
+// diffColumn is a column both results have.
+type diffColumn struct {
+	name    string
+	left    int
+	right   int
+	numeric bool
+}
+
+// diffCell is a value rendered for comparison: its text, and the key values
+// compare by, which for numeric columns is the exact rational number.
+type diffCell struct {
+	text interface{}
+	key  string
+}
+
+// DiffResults compares a left and a right result. Columns that are numeric in
+// either result compare as numbers, so expected rows given inline without
+// column types match query results.
+func DiffResults(left, right *Result, opts DiffOptions) (*ResultDiff, error) {
+	diff := &ResultDiff{
+		LeftColumns:  left.Columns,
+		RightColumns: right.Columns,
+		LeftRows:     len(left.Rows),
+		RightRows:    len(right.Rows),
+	}
+
+	var columns []diffColumn
+	matched := make([]bool, len(right.Columns))
+	for i, name := range left.Columns {
+		j := indexColumn(right.Columns, name, matched)
+		if j < 0 {
+			diff.MissingColumns = append(diff.MissingColumns, name)
+			continue
+		}
+		matched[j] = true
+		numeric := isNumericRowType(columnRowType(left, i)) || isNumericRowType(columnRowType(right, j))
+		columns = append(columns, diffColumn{name: name, left: i, right: j, numeric: numeric})
+	}
+	for j, name := range right.Columns {
+		if !matched[j] {
+			diff.ExtraColumns = append(diff.ExtraColumns, name)
+		}
+	}
+
+	var key []int
+	for _, name := range opts.Key {
+		at := -1
+		for i, column := range columns {
+			if strings.EqualFold(column.name, name) {
+				at = i
+			}
+		}
+		if at < 0 {
+			return nil, fmt.Errorf("key column %s is not in both results", name)
+		}
+		key = append(key, at)
+	}
+
+	leftCells := diffCells(left, columns, true)
+	rightCells := diffCells(right, columns, false)
+
+	switch {
+	case len(key) > 0:
+		unmatched := map[string][]int{}
+		for j, row := range rightCells {
+			k := rowKey(row, key)
+			unmatched[k] = append(unmatched[k], j)
+		}
+		for i, row := range leftCells {
+			k := rowKey(row, key)
+			candidates := unmatched[k]
+			if len(candidates) == 0 {
+				diff.Removed = append(diff.Removed, DiffRow{Index: i, Values: left.diffValues(i)})
+				continue
+			}
+			j := candidates[0]
+			unmatched[k] = candidates[1:]
+			if cells := changedCells(columns, row, rightCells[j]); cells != nil {
+				keyValues := make([]interface{}, len(key))
+				for n, at := range key {
+					keyValues[n] = row[at].text
+				}
+				diff.Changed = append(diff.Changed, ChangedRow{LeftIndex: i, RightIndex: j, Key: keyValues, Cells: cells})
+			}
+		}
+		diff.Added = unmatchedRows(right, unmatched)
+	case opts.Ordered:
+		for i, row := range leftCells {
+			if i >= len(rightCells) {
+				diff.Removed = append(diff.Removed, DiffRow{Index: i, Values: left.diffValues(i)})
+				continue
+			}
+			if cells := changedCells(columns, row, rightCells[i]); cells != nil {
+				diff.Changed = append(diff.Changed, ChangedRow{LeftIndex: i, RightIndex: i, Cells: cells})
+			}
+		}
+		for j := len(leftCells); j < len(rightCells); j++ {
+			diff.Added = append(diff.Added, DiffRow{Index: j, Values: right.diffValues(j)})
+		}
+	default:
+		unmatched := map[string][]int{}
+		for j, row := range rightCells {
+			k := rowKey(row, nil)
+			unmatched[k] = append(unmatched[k], j)
+		}
+		for i, row := range leftCells {
+			k := rowKey(row, nil)
+			if candidates := unmatched[k]; len(candidates) > 0 {
+				unmatched[k] = candidates[1:]
+				continue
+			}
+			diff.Removed = append(diff.Removed, DiffRow{Index: i, Values: left.diffValues(i)})
+		}
+		diff.Added = unmatchedRows(right, unmatched)
+	}
+	return diff, nil
+}
+
+// indexColumn returns the index of the first column of columns not yet
+// matched that is named name, ignoring case, or -1.
+func indexColumn(columns []string, name string, matched []bool) int {
+	for j, column := range columns {
+		if !matched[j] && strings.EqualFold(column, name) {
+			return j
+		}
+	}
+	return -1
+}
+
+// columnRowType returns the Snowflake row type of a result's column, or "" for
+// a result without column types.
+func columnRowType(result *Result, i int) string {
+	if i < len(result.ColumnTypes) {
+		return result.ColumnTypes[i].Type
+	}
+	return ""
+}
+
+// isNumericRowType reports whether a Snowflake row type holds numbers.
+func isNumericRowType(rowType string) bool {
+	return rowType == "fixed" || rowType == "real"
+}
+
+// diffCells renders the common columns of a result's rows for comparison.
+func diffCells(result *Result, columns []diffColumn, left bool) [][]diffCell {
+	cells := make([][]diffCell, len(result.Rows))
+	for i, row := range result.Rows {
+		cells[i] = make([]diffCell, len(columns))
+		for n, column := range columns {
+			at := column.right
+			if left {
+				at = column.left
+			}
+			var val interface{}
+			if at < len(row) {
+				val = row[at]
+			}
+			text := diffText(val, columnRowType(result, at))
+			cells[i][n] = diffCell{text: text, key: diffKey(text, column.numeric)}
+		}
+	}
+	return cells
+}
+
+// diffValues renders all of a row's values as text.
+func (r *Result) diffValues(i int) []interface{} {
+	values := make([]interface{}, len(r.Rows[i]))
+	for j, val := range r.Rows[i] {
+		values[j] = diffText(val, columnRowType(r, j))
+	}
+	return values
+}
+
+// diffText renders a value as text for comparison: numbers as exact decimals,
+// temporal values in ISO 8601 layouts, binary values as hex, and
+// semi-structured values as JSON. NULL is nil.
+func diffText(val interface{}, rowType string) interface{} {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case *big.Int:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	case duckdb.Decimal:
+		if v.Value == nil {
+			return "0"
+		}
+		return new(big.Rat).SetFrac(v.Value, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(v.Scale)), nil)).FloatString(int(v.Scale))
+	case time.Time:
+		switch rowType {
+		case "date":
+			return v.Format("2006-01-02")
+		case "time":
+			return v.Format("15:04:05.999999999")
+		case "timestamp_tz", "timestamp_ltz":
+			return v.Format("2006-01-02 15:04:05.999999999 -07:00")
+		}
+		return v.Format("2006-01-02 15:04:05.999999999")
+	case []byte:
+		return strings.ToUpper(hex.EncodeToString(v))
+	case map[string]interface{}, []interface{}:
+		if encoded, err := json.Marshal(v); err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// diffKey returns the key a rendered value compares by. Values of numeric
+// columns compare as numbers, so 1.50 equals 1.5.
+func diffKey(text interface{}, numeric bool) string {
+	s, ok := text.(string)
+	if !ok {
+		return "null"
+	}
+	if numeric {
+		if r, ok := new(big.Rat).SetString(s); ok {
+			return "=" + r.RatString()
+		}
+	}
+	return "=" + s
+}
+
+// rowKey joins the keys of a row's cells at the given positions, or of all its
+// cells when positions is nil.
+func rowKey(row []diffCell, positions []int) string {
+	var b strings.Builder
+	if positions == nil {
+		for _, cell := range row {
+			b.WriteString(strconv.Quote(cell.key))
+		}
+		return b.String()
+	}
+	for _, at := range positions {
+		b.WriteString(strconv.Quote(row[at].key))
+	}
+	return b.String()
+}
+
+// changedCells returns the cells of the common columns that differ between two
+// rows, or nil when none do.
+func changedCells(columns []diffColumn, left, right []diffCell) []CellDiff {
+	var cells []CellDiff
+	for n, column := range columns {
+		if left[n].key != right[n].key {
+			cells = append(cells, CellDiff{Column: column.name, Left: left[n].text, Right: right[n].text})
+		}
+	}
+	return cells
+}
+
+// unmatchedRows returns the right rows left in unmatched, in result order.
+func unmatchedRows(right *Result, unmatched map[string][]int) []DiffRow {
+	remaining := map[int]bool{}
+	for _, indexes := range unmatched {
+		for _, j := range indexes {
+			remaining[j] = true
+		}
+	}
+	var rows []DiffRow
+	for j := range right.Rows {
+		if remaining[j] {
+			rows = append(rows, DiffRow{Index: j, Values: right.diffValues(j)})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,125 @@
+package query
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+func TestDiffResults(t *testing.T) {
+	orders := &Result{
+		Columns: []string{"ID", "AMOUNT", "DAY"},
+		ColumnTypes: []types.ColumnMetadata{
+			{Name: "ID", Type: "fixed"}, {Name: "AMOUNT", Type: "fixed", Scale: 2}, {Name: "DAY", Type: "date"},
+		},
+		Rows: [][]interface{}{
+			{int64(1), duckdb.Decimal{Scale: 2, Value: big.NewInt(150)}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			{int64(2), nil, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+			{int64(3), duckdb.Decimal{Scale: 2, Value: big.NewInt(-200)}, nil},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		right     *Result
+		opts      DiffOptions
+		want      *ResultDiff
+		wantEqual bool
+	}{
+		{
+			name: "EqualIgnoringOrderAndNumberFormat",
+			right: &Result{Columns: []string{"id", "amount", "day"}, Rows: [][]interface{}{
+				{json.Number("3"), json.Number("-2"), nil},
+				{json.Number("1"), "1.5", "2024-01-02"},
+				{json.Number("2"), nil, "2024-01-03"},
+			}},
+			want:      &ResultDiff{LeftRows: 3, RightRows: 3},
+			wantEqual: true,
+		},
+		{
+			name: "AddedAndRemovedRows",
+			right: &Result{Columns: []string{"ID", "AMOUNT", "DAY"}, Rows: [][]interface{}{
+				{json.Number("1"), json.Number("1.50"), "2024-01-02"},
+				{json.Number("2"), json.Number("0"), "2024-01-03"},
+				{json.Number("2"), nil, "2024-01-03"},
+			}},
+			want: &ResultDiff{
+				LeftRows: 3, RightRows: 3,
+				Removed: []DiffRow{{Index: 2, Values: []interface{}{"3", "-2.00", nil}}},
+				Added:   []DiffRow{{Index: 1, Values: []interface{}{"2", "0", "2024-01-03"}}},
+			},
+		},
+		{
+			name: "ChangedByKey",
+			right: &Result{Columns: []string{"ID", "AMOUNT", "DAY"}, Rows: [][]interface{}{
+				{json.Number("2"), json.Number("7"), "2024-01-04"},
+				{json.Number("1"), json.Number("1.5"), "2024-01-02"},
+				{json.Number("4"), nil, nil},
+			}},
+			opts: DiffOptions{Key: []string{"id"}},
+			want: &ResultDiff{
+				LeftRows: 3, RightRows: 3,
+				Removed: []DiffRow{{Index: 2, Values: []interface{}{"3", "-2.00", nil}}},
+				Added:   []DiffRow{{Index: 2, Values: []interface{}{"4", nil, nil}}},
+				Changed: []ChangedRow{{
+					LeftIndex: 1, RightIndex: 0, Key: []interface{}{"2"},
+					Cells: []CellDiff{{Column: "AMOUNT", Left: nil, Right: "7"}, {Column: "DAY", Left: "2024-01-03", Right: "2024-01-04"}},
+				}},
+			},
+		},
+		{
+			name: "Ordered",
+			right: &Result{Columns: []string{"ID", "AMOUNT", "DAY"}, Rows: [][]interface{}{
+				{json.Number("2"), nil, "2024-01-03"},
+				{json.Number("1"), json.Number("1.5"), "2024-01-02"},
+			}},
+			opts: DiffOptions{Ordered: true},
+			want: &ResultDiff{
+				LeftRows: 3, RightRows: 2,
+				Removed: []DiffRow{{Index: 2, Values: []interface{}{"3", "-2.00", nil}}},
+				Changed: []ChangedRow{
+					{LeftIndex: 0, RightIndex: 0, Cells: []CellDiff{
+						{Column: "ID", Left: "1", Right: "2"}, {Column: "AMOUNT", Left: "1.50", Right: nil}, {Column: "DAY", Left: "2024-01-02", Right: "2024-01-03"},
+					}},
+					{LeftIndex: 1, RightIndex: 1, Cells: []CellDiff{
+						{Column: "ID", Left: "2", Right: "1"}, {Column: "AMOUNT", Left: nil, Right: "1.5"}, {Column: "DAY", Left: "2024-01-03", Right: "2024-01-02"},
+					}},
+				},
+			},
+		},
+		{
+			name: "MissingAndExtraColumns",
+			right: &Result{Columns: []string{"ID", "NOTE"}, Rows: [][]interface{}{
+				{json.Number("1"), "a"}, {json.Number("2"), "b"}, {json.Number("3"), "c"},
+			}},
+			want: &ResultDiff{LeftRows: 3, RightRows: 3, MissingColumns: []string{"AMOUNT", "DAY"}, ExtraColumns: []string{"NOTE"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffResults(orders, tt.right, tt.opts)
+			if err != nil {
+				t.Fatalf("DiffResults() error = %v", err)
+			}
+			tt.want.LeftColumns = orders.Columns
+			tt.want.RightColumns = tt.right.Columns
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DiffResults() mismatch (-want +got):\n%s", diff)
+			}
+			if got.Equal() != tt.wantEqual {
+				t.Errorf("Equal() = %v, want %v", got.Equal(), tt.wantEqual)
+			}
+		})
+	}
+
+	if _, err := DiffResults(orders, &Result{Columns: []string{"ID"}}, DiffOptions{Key: []string{"DAY"}}); err == nil {
+		t.Error("DiffResults() with a key column missing from a result error = nil, want error")
+	}
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// sha2Digest returns the hex SHA-2 digest of data of a size SHA2 accepts.
+func sha2Digest(data []byte, bits int64) (string, error) {
+	switch bits {
+	case 224:
+		sum := sha256.Sum224(data)
+		return hex.EncodeToString(sum[:]), nil
+	case 256:
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	case 384:
+		sum := sha512.Sum384(data)
+		return hex.EncodeToString(sum[:]), nil
+	case 512:
+		sum := sha512.Sum512(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	return "", fmt.Errorf("SHA2 digest size %d is not supported: must be 224, 256, 384, or 512", bits)
+}
+
+// configureDigests registers snowflake_sha2(data, bits), the DuckDB function
+// behind SHA2 digests of other sizes than 256 bits, which DuckDB's sha256()
+// computes, for text and binary data.
+func (e *Executor) configureDigests() {
+	ctx := context.Background()
+	conn, err := e.mgr.Conn(ctx)
+	if err != nil {
+		log.Printf("Failed to register digest functions: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	bigint, _ := duckdb.NewTypeInfo(duckdb.TYPE_BIGINT)
+	varchar, _ := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	blob, _ := duckdb.NewTypeInfo(duckdb.TYPE_BLOB)
+	overloads := []duckdb.ScalarFunc{
+		&generatorFunction{inputs: []duckdb.TypeInfo{varchar, bigint}, result: varchar, run: func(_ context.Context, args []driver.Value) (any, error) {
+			return sha2Digest([]byte(args[0].(string)), args[1].(int64))
+		}},
+		&generatorFunction{inputs: []duckdb.TypeInfo{blob, bigint}, result: varchar, run: func(_ context.Context, args []driver.Value) (any, error) {
+			return sha2Digest(args[0].([]byte), args[1].(int64))
+		}},
+	}
+	if err := duckdb.RegisterScalarUDFSet(conn, "snowflake_sha2", overloads...); err != nil {
+		log.Printf("Failed to register digest function snowflake_sha2: %v", err)
+	}
+}
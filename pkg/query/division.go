@@ -0,0 +1,108 @@
+package query
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// errDivisionByZero is the error of a division by zero, which Snowflake fails
+// instead of returning infinity or NULL.
+var errDivisionByZero = errors.New("division by zero")
+
+// configureDivision registers snowflake_divide, the DuckDB function behind the
+// divisions the translator rewrites. snowflake_divide(a, b) divides DOUBLE
+// values, and snowflake_divide(a, b, scale) divides integer and decimal values
+// exactly, rounding the quotient half away from zero to scale digits, and
+// returns it as text, which casts to a DECIMAL exactly. Both fail dividing by
+// zero.
+func (e *Executor) configureDivision() {
+	ctx := context.Background()
+	conn, err := e.mgr.Conn(ctx)
+	if err != nil {
+		log.Printf("Failed to register division functions: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	anyType, _ := duckdb.NewTypeInfo(duckdb.TYPE_ANY)
+	bigint, _ := duckdb.NewTypeInfo(duckdb.TYPE_BIGINT)
+	double, _ := duckdb.NewTypeInfo(duckdb.TYPE_DOUBLE)
+	varchar, _ := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	overloads := []duckdb.ScalarFunc{
+		&generatorFunction{inputs: []duckdb.TypeInfo{double, double}, result: double, run: func(_ context.Context, args []driver.Value) (any, error) {
+			if args[1].(float64) == 0 {
+				return nil, errDivisionByZero
+			}
+			return args[0].(float64) / args[1].(float64), nil
+		}},
+		&generatorFunction{inputs: []duckdb.TypeInfo{anyType, anyType, bigint}, result: varchar, run: func(_ context.Context, args []driver.Value) (any, error) {
+			return exactQuotient(args[0], args[1], args[2].(int64))
+		}},
+	}
+	if err := duckdb.RegisterScalarUDFSet(conn, "snowflake_divide", overloads...); err != nil {
+		log.Printf("Failed to register division function snowflake_divide: %v", err)
+	}
+}
+
+// exactQuotient returns a / b rounded half away from zero to scale digits, as
+// text. The translator only uses the quotient of operands that are not
+// floating-point numbers, so that of floating-point operands is NULL.
+func exactQuotient(a, b driver.Value, scale int64) (any, error) {
+	dividend, ok, err := exactNumber(a)
+	if err != nil || !ok {
+		return nil, err
+	}
+	divisor, ok, err := exactNumber(b)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if divisor.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+	quotient := new(big.Rat).Quo(dividend, divisor)
+	return quotient.FloatString(int(scale)), nil
+}
+
+// exactNumber returns the exact value of an integer, decimal, or numeric text
+// value of a DuckDB function argument. It reports false for floating-point
+// values.
+func exactNumber(v driver.Value) (*big.Rat, bool, error) {
+	switch n := v.(type) {
+	case int8:
+		return new(big.Rat).SetInt64(int64(n)), true, nil
+	case int16:
+		return new(big.Rat).SetInt64(int64(n)), true, nil
+	case int32:
+		return new(big.Rat).SetInt64(int64(n)), true, nil
+	case int64:
+		return new(big.Rat).SetInt64(n), true, nil
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(n)), true, nil
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(n)), true, nil
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(n)), true, nil
+	case uint64:
+		return new(big.Rat).SetUint64(n), true, nil
+	case *big.Int:
+		return new(big.Rat).SetInt(n), true, nil
+	case duckdb.Decimal:
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n.Scale)), nil)
+		return new(big.Rat).SetFrac(n.Value, scale), true, nil
+	case string:
+		r, ok := new(big.Rat).SetString(n)
+		if !ok {
+			return nil, false, fmt.Errorf("numeric value '%s' is not recognized", n)
+		}
+		return r, true, nil
+	case float32, float64:
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("cannot divide a value of type %T", v)
+}
@@ -0,0 +1,213 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DropProtection controls how the Executor runs statements that destroy data:
+// DROP DATABASE, DROP SCHEMA, DROP TABLE, and TRUNCATE. Protection keeps
+// long-lived seed data shared by several test suites from being dropped by one
+// of them.
+type DropProtection int
+
+const (
+	// DropProtectionOff runs destructive statements.
+	DropProtectionOff DropProtection = iota
+	// DropProtectionReject fails destructive statements.
+	DropProtectionReject
+	// DropProtectionDryRun answers destructive statements with a warning
+	// describing what they would drop, without running them.
+	DropProtectionDryRun
+)
+
+// ErrDropProtected is returned in DropProtectionReject mode for a destructive
+// statement.
+var ErrDropProtected = errors.New("destructive statement rejected by drop protection")
+
+// ParseDropProtection parses a drop protection mode: "off", "reject", or
+// "dry_run". An empty string means off.
+func ParseDropProtection(s string) (DropProtection, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "off":
+		return DropProtectionOff, nil
+	case "reject":
+		return DropProtectionReject, nil
+	case "dry_run", "dry-run":
+		return DropProtectionDryRun, nil
+	default:
+		return DropProtectionOff, fmt.Errorf("invalid drop protection mode %q: must be off, reject, or dry_run", s)
+	}
+}
+
+// WithDropProtection rejects DROP DATABASE, DROP SCHEMA, DROP TABLE, and
+// TRUNCATE statements, or answers them with what they would drop.
+func WithDropProtection(mode DropProtection) ExecutorOption {
+	return func(e *Executor) {
+		e.dropProtection = mode
+	}
+}
+
+// destructiveStatement is a parsed DROP DATABASE, DROP SCHEMA, DROP TABLE, or
+// TRUNCATE statement.
+type destructiveStatement struct {
+	// Verb is DROP or TRUNCATE.
+	Verb string
+	// Kind is DATABASE, SCHEMA, or TABLE.
+	Kind string
+	// Name is the object's name as written.
+	Name string
+}
+
+// parseDestructiveStatement parses DROP {DATABASE | SCHEMA | TABLE} [IF
+// EXISTS] name and TRUNCATE [TABLE] [IF EXISTS] name. It reports false for
+// other statements.
+func parseDestructiveStatement(sql string) (destructiveStatement, bool) {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(stripLeadingComments(sql)), ";"))
+	if len(fields) < 2 {
+		return destructiveStatement{}, false
+	}
+
+	stmt := destructiveStatement{Verb: strings.ToUpper(fields[0]), Kind: "TABLE"}
+	switch stmt.Verb {
+	case "DROP":
+		stmt.Kind = strings.ToUpper(fields[1])
+		if stmt.Kind != "DATABASE" && stmt.Kind != "SCHEMA" && stmt.Kind != "TABLE" {
+			return destructiveStatement{}, false
+		}
+		fields = fields[2:]
+	case "TRUNCATE":
+		fields = fields[1:]
+		if len(fields) > 0 && strings.EqualFold(fields[0], "TABLE") {
+			fields = fields[1:]
+		}
+	default:
+		return destructiveStatement{}, false
+	}
+	if len(fields) >= 3 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") {
+		fields = fields[2:]
+	}
+	if len(fields) == 0 {
+		return destructiveStatement{}, false
+	}
+	stmt.Name = fields[0]
+	return stmt, true
+}
+
+// protectDestructive applies the Executor's drop protection to sql. It reports
+// false when sql is not destructive or protection is off, and sql should run.
+func (e *Executor) protectDestructive(ctx context.Context, sql string) (*ExecResult, bool, error) {
+	if e.dropProtection == DropProtectionOff {
+		return nil, false, nil
+	}
+	stmt, ok := parseDestructiveStatement(sql)
+	if !ok {
+		return nil, false, nil
+	}
+
+	warning, err := e.protect(ctx, stmt)
+	if err != nil {
+		return nil, true, err
+	}
+	return &ExecResult{Warnings: []string{warning}}, true, nil
+}
+
+// ProtectDrop applies the Executor's drop protection to dropping a database,
+// schema, or table without SQL, such as through the REST API. kind is
+// DATABASE, SCHEMA, or TABLE, and parts the object's name qualified by its
+// database and schema. It reports false when protection is off, and the
+// object should be dropped. Otherwise it returns ErrDropProtected in
+// DropProtectionReject mode, or the dry run's warning.
+func (e *Executor) ProtectDrop(ctx context.Context, kind string, parts ...string) (string, bool, error) {
+	if e.dropProtection == DropProtectionOff {
+		return "", false, nil
+	}
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		quoted[i] = quoteIdent(part)
+	}
+	warning, err := e.protect(ctx, destructiveStatement{Verb: "DROP", Kind: kind, Name: strings.Join(quoted, ".")})
+	return warning, true, err
+}
+
+// protect rejects a destructive statement, or returns the warning answering it
+// in DropProtectionDryRun mode.
+func (e *Executor) protect(ctx context.Context, stmt destructiveStatement) (string, error) {
+	if e.dropProtection == DropProtectionReject {
+		return "", fmt.Errorf("%w: %s %s %s", ErrDropProtected, stmt.Verb, stmt.Kind, stmt.Name)
+	}
+	return fmt.Sprintf("Dry run, nothing was changed: %s %s %s would %s", stmt.Verb, stmt.Kind, stmt.Name, e.describeDestructive(ctx, stmt)), nil
+}
+
+// describeDestructive describes what a destructive statement would remove: the
+// rows of a table, the tables of a schema, or the schemas of a database.
+// Objects that cannot be read are described by name only.
+func (e *Executor) describeDestructive(ctx context.Context, stmt destructiveStatement) string {
+	action := "drop"
+	if stmt.Verb == "TRUNCATE" {
+		action = "delete"
+	}
+
+	switch stmt.Kind {
+	case "DATABASE":
+		name := identifierName(stmt.Name)
+		db, err := e.repo.GetDatabaseByName(ctx, name)
+		if err != nil {
+			return fmt.Sprintf("drop database %s", name)
+		}
+		schemas, err := e.repo.ListSchemas(ctx, db.ID)
+		if err != nil {
+			return fmt.Sprintf("drop database %s", name)
+		}
+		names := make([]string, len(schemas))
+		for i, schema := range schemas {
+			names[i] = schema.Name
+		}
+		return fmt.Sprintf("drop database %s with %s", name, countedList(names, "schema"))
+	case "SCHEMA":
+		parts := strings.Split(stmt.Name, ".")
+		name := identifierName(parts[len(parts)-1])
+		result, err := e.queryRows(ctx, fmt.Sprintf(
+			"SELECT table_name FROM information_schema.tables WHERE upper(table_schema) = upper(%s) ORDER BY table_name",
+			quoteLiteral(name)))
+		if err != nil {
+			return fmt.Sprintf("drop schema %s", name)
+		}
+		names := make([]string, len(result.Rows))
+		for i, row := range result.Rows {
+			names[i] = fmt.Sprint(row[0])
+		}
+		return fmt.Sprintf("drop schema %s with %s", name, countedList(names, "table"))
+	default:
+		result, err := e.queryRows(ctx, "SELECT COUNT(*) FROM "+e.resolveTableName(ctx, stmt.Name))
+		if err != nil || len(result.Rows) != 1 {
+			return fmt.Sprintf("%s table %s", action, stmt.Name)
+		}
+		if stmt.Verb == "TRUNCATE" {
+			return fmt.Sprintf("delete %v rows of table %s", result.Rows[0][0], stmt.Name)
+		}
+		return fmt.Sprintf("drop table %s with %v rows", stmt.Name, result.Rows[0][0])
+	}
+}
+
+// identifierName returns the name an identifier refers to: quoted identifiers
+// as written, unquoted ones in upper case.
+func identifierName(identifier string) string {
+	if name := unquoteIdentifier(identifier); name != identifier {
+		return name
+	}
+	return strings.ToUpper(identifier)
+}
+
+// countedList renders names as e.g. "2 tables (A, B)".
+func countedList(names []string, noun string) string {
+	if len(names) != 1 {
+		noun += "s"
+	}
+	if len(names) == 0 {
+		return "0 " + noun
+	}
+	return fmt.Sprintf("%d %s (%s)", len(names), noun, strings.Join(names, ", "))
+}
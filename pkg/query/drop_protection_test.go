@@ -0,0 +1,172 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseDropProtection tests parsing of drop protection modes.
+func TestParseDropProtection(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected DropProtection
+		wantErr  bool
+	}{
+		{input: "", expected: DropProtectionOff},
+		{input: "off", expected: DropProtectionOff},
+		{input: "REJECT", expected: DropProtectionReject},
+		{input: "dry_run", expected: DropProtectionDryRun},
+		{input: "dry-run", expected: DropProtectionDryRun},
+		{input: "confirm", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDropProtection(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDropProtection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseDropProtection() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseDestructiveStatement tests detection of statements that destroy data.
+func TestParseDestructiveStatement(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		want   destructiveStatement
+		wantOK bool
+	}{
+		{name: "DropTable", sql: "DROP TABLE orders", want: destructiveStatement{Verb: "DROP", Kind: "TABLE", Name: "orders"}, wantOK: true},
+		{name: "DropTableIfExists", sql: "drop table if exists db.s.orders;", want: destructiveStatement{Verb: "DROP", Kind: "TABLE", Name: "db.s.orders"}, wantOK: true},
+		{name: "DropSchemaCascade", sql: "DROP SCHEMA staging CASCADE", want: destructiveStatement{Verb: "DROP", Kind: "SCHEMA", Name: "staging"}, wantOK: true},
+		{name: "DropDatabase", sql: "-- reset\nDROP DATABASE seed_db", want: destructiveStatement{Verb: "DROP", Kind: "DATABASE", Name: "seed_db"}, wantOK: true},
+		{name: "Truncate", sql: "TRUNCATE orders", want: destructiveStatement{Verb: "TRUNCATE", Kind: "TABLE", Name: "orders"}, wantOK: true},
+		{name: "TruncateTableIfExists", sql: "TRUNCATE TABLE IF EXISTS orders", want: destructiveStatement{Verb: "TRUNCATE", Kind: "TABLE", Name: "orders"}, wantOK: true},
+		{name: "DropView", sql: "DROP VIEW v", wantOK: false},
+		{name: "Delete", sql: "DELETE FROM orders", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDestructiveStatement(tt.sql)
+			if ok != tt.wantOK {
+				t.Fatalf("parseDestructiveStatement(%q) ok = %v, want %v", tt.sql, ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseDestructiveStatement() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_DropProtection tests that protected executors reject or
+// dry-run destructive statements and leave the data in place.
+func TestExecutor_DropProtection(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		mode         DropProtection
+		sql          string
+		wantErr      bool
+		wantWarnings []string
+		wantRows     int64
+	}{
+		{
+			name:     "Off",
+			mode:     DropProtectionOff,
+			sql:      "TRUNCATE TABLE seed_rows",
+			wantRows: 0,
+		},
+		{
+			name:     "RejectDrop",
+			mode:     DropProtectionReject,
+			sql:      "DROP TABLE seed_rows",
+			wantErr:  true,
+			wantRows: 3,
+		},
+		{
+			name:     "RejectTruncate",
+			mode:     DropProtectionReject,
+			sql:      "TRUNCATE seed_rows",
+			wantErr:  true,
+			wantRows: 3,
+		},
+		{
+			name:         "DryRunDropTable",
+			mode:         DropProtectionDryRun,
+			sql:          "DROP TABLE IF EXISTS seed_rows",
+			wantWarnings: []string{"Dry run, nothing was changed: DROP TABLE seed_rows would drop table seed_rows with 3 rows"},
+			wantRows:     3,
+		},
+		{
+			name:         "DryRunTruncate",
+			mode:         DropProtectionDryRun,
+			sql:          "TRUNCATE TABLE seed_rows",
+			wantWarnings: []string{"Dry run, nothing was changed: TRUNCATE TABLE seed_rows would delete 3 rows of table seed_rows"},
+			wantRows:     3,
+		},
+		{
+			name:         "DryRunDropSchema",
+			mode:         DropProtectionDryRun,
+			sql:          "DROP SCHEMA staging CASCADE",
+			wantWarnings: []string{"Dry run, nothing was changed: DROP SCHEMA staging would drop schema STAGING with 2 tables (events, users)"},
+			wantRows:     3,
+		},
+		{
+			name:         "DryRunDropDatabase",
+			mode:         DropProtectionDryRun,
+			sql:          "DROP DATABASE seed_db",
+			wantWarnings: []string{"Dry run, nothing was changed: DROP DATABASE seed_db would drop database SEED_DB with 0 schemas"},
+			wantRows:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, _ := setupTestExecutor(t, WithDropProtection(tt.mode))
+			for _, setup := range []string{
+				"CREATE DATABASE seed_db",
+				"CREATE SCHEMA staging",
+				"CREATE TABLE staging.users (id INTEGER)",
+				"CREATE TABLE staging.events (id INTEGER)",
+				"CREATE TABLE seed_rows (id INTEGER)",
+				"INSERT INTO seed_rows VALUES (1), (2), (3)",
+			} {
+				if _, err := executor.Execute(ctx, setup); err != nil {
+					t.Fatalf("Execute(%q) error = %v", setup, err)
+				}
+			}
+
+			result, err := executor.Execute(ctx, tt.sql)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDropProtected) {
+					t.Fatalf("Execute() error = %v, want ErrDropProtected", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Execute() error = %v", err)
+				}
+				if diff := cmp.Diff(tt.wantWarnings, result.Warnings); diff != "" {
+					t.Errorf("Execute() warnings mismatch (-want +got):\n%s", diff)
+				}
+			}
+
+			count, err := executor.Query(ctx, "SELECT COUNT(*) FROM seed_rows")
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff([][]interface{}{{tt.wantRows}}, count.Rows); diff != "" {
+				t.Errorf("row count mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// Parameters of the passphrase encryption of ENCRYPT. The key is derived from
+// the passphrase with PBKDF2-SHA256 and a random salt, which is stored before
+// the GCM nonce and the sealed value.
+const (
+	encryptionSaltSize   = 16
+	encryptionNonceSize  = 12
+	encryptionKeySize    = 32
+	encryptionIterations = 10000
+)
+
+// errDecryptionFailed is the error of a value that does not decrypt with the
+// passphrase and additional data it is given.
+var errDecryptionFailed = errors.New("decryption failed. Check encrypted data, key, AAD, or AEAD tag")
+
+// encryptValue encrypts value with AES-GCM under a key derived from
+// passphrase, authenticating aad, as ENCRYPT does. Each call uses a new salt
+// and nonce, so encrypting a value twice gives different results.
+func encryptValue(value, passphrase, aad []byte) ([]byte, error) {
+	salt := make([]byte, encryptionSaltSize, encryptionSaltSize+encryptionNonceSize+len(value)+16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, encryptionNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(append(salt, nonce...), nonce, value, aad), nil
+}
+
+// decryptValue decrypts a value encrypted by encryptValue.
+func decryptValue(encrypted, passphrase, aad []byte) ([]byte, error) {
+	if len(encrypted) < encryptionSaltSize+encryptionNonceSize {
+		return nil, errDecryptionFailed
+	}
+	salt, rest := encrypted[:encryptionSaltSize], encrypted[encryptionSaltSize:]
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	value, err := gcm.Open(nil, rest[:encryptionNonceSize], rest[encryptionNonceSize:], aad)
+	if err != nil {
+		return nil, errDecryptionFailed
+	}
+	return value, nil
+}
+
+// passphraseCipher returns the AES-GCM cipher of the key derived from
+// passphrase and salt.
+func passphraseCipher(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, encryptionIterations, encryptionKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, encryptionNonceSize)
+}
+
+// encryptionArgs returns the value, passphrase, and additional data of a call
+// of ENCRYPT or DECRYPT, checking its encryption method. Text arguments are
+// encrypted as their UTF-8 bytes.
+func encryptionArgs(args []driver.Value) (value, passphrase, aad []byte, err error) {
+	if len(args) == 4 {
+		if method := strings.ToUpper(fmt.Sprint(args[3])); method != "AES-GCM" {
+			return nil, nil, nil, fmt.Errorf("encryption method %s is not supported: must be AES-GCM", args[3])
+		}
+	}
+	if len(args) >= 3 {
+		if aad, err = encryptionBytes(args[2]); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if value, err = encryptionBytes(args[0]); err != nil {
+		return nil, nil, nil, err
+	}
+	return value, []byte(args[1].(string)), aad, nil
+}
+
+// encryptionBytes returns the bytes of a text or binary argument.
+func encryptionBytes(v driver.Value) ([]byte, error) {
+	switch b := v.(type) {
+	case string:
+		return []byte(b), nil
+	case []byte:
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot encrypt a value of type %T", v)
+}
+
+// configureEncryption registers snowflake_encrypt, snowflake_decrypt, and
+// snowflake_try_decrypt, the DuckDB functions behind ENCRYPT, DECRYPT, and
+// TRY_DECRYPT. They take the value, the passphrase, and optionally the
+// additional authenticated data and the encryption method, which must be
+// AES-GCM. TRY_DECRYPT returns NULL for values that do not decrypt.
+func (e *Executor) configureEncryption() {
+	ctx := context.Background()
+	conn, err := e.mgr.Conn(ctx)
+	if err != nil {
+		log.Printf("Failed to register encryption functions: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	anyType, _ := duckdb.NewTypeInfo(duckdb.TYPE_ANY)
+	varchar, _ := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	blob, _ := duckdb.NewTypeInfo(duckdb.TYPE_BLOB)
+	functions := map[string]func(args []driver.Value) (any, error){
+		"snowflake_encrypt": func(args []driver.Value) (any, error) {
+			value, passphrase, aad, err := encryptionArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return encryptValue(value, passphrase, aad)
+		},
+		"snowflake_decrypt": func(args []driver.Value) (any, error) {
+			value, passphrase, aad, err := encryptionArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return decryptValue(value, passphrase, aad)
+		},
+		"snowflake_try_decrypt": func(args []driver.Value) (any, error) {
+			value, passphrase, aad, err := encryptionArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			decrypted, err := decryptValue(value, passphrase, aad)
+			if errors.Is(err, errDecryptionFailed) {
+				return nil, nil
+			}
+			return decrypted, err
+		},
+	}
+	for name, fn := range functions {
+		run := func(_ context.Context, args []driver.Value) (any, error) { return fn(args) }
+		// Encrypting returns a new value on every call
+		volatile := name == "snowflake_encrypt"
+		overloads := []duckdb.ScalarFunc{
+			&generatorFunction{inputs: []duckdb.TypeInfo{anyType, varchar}, result: blob, volatile: volatile, run: run},
+			&generatorFunction{inputs: []duckdb.TypeInfo{anyType, varchar, anyType}, result: blob, volatile: volatile, run: run},
+			&generatorFunction{inputs: []duckdb.TypeInfo{anyType, varchar, anyType, varchar}, result: blob, volatile: volatile, run: run},
+		}
+		if err := duckdb.RegisterScalarUDFSet(conn, name, overloads...); err != nil {
+			log.Printf("Failed to register encryption function %s: %v", name, err)
+		}
+	}
+}
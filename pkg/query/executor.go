@@ -4,33 +4,64 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/cortex"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
-)
-
-// Binding validation regexes to prevent SQL injection
-var (
-	// Date format: YYYY-MM-DD
-	dateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
-	// Time format: HH:MM:SS or HH:MM:SS.fraction
-	timeRegex = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
-	// Timestamp format: YYYY-MM-DD HH:MM:SS or YYYY-MM-DDTHH:MM:SS with optional timezone
-	timestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?([+-]\d{2}:?\d{2}|Z)?$`)
+	"github.com/nnnkkk7/snowflake-emulator/pkg/notification"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
 )
 
 // Executor executes SQL queries against DuckDB with Snowflake SQL translation.
 type Executor struct {
 	mgr            *connection.Manager
-	repo           *metadata.Repository
+	repo           metadata.Store
 	translator     *Translator
 	copyProcessor  *CopyProcessor
 	mergeProcessor *MergeProcessor
+	orderingCheck  OrderingCheck
+	transactions   transactionRegistry
+	running        runningQueries
+	variables      variableRegistry
+	procedures     procedureRegistry
+	dataMetrics    dataMetricRegistry
+	alerts         alertRegistry
+	integrations   integrationRegistry
+	secrets        secretRegistry
+	cortex         cortex.Backend
+	// notificationSink receives the emails sent with SYSTEM$SEND_EMAIL.
+	notificationSink notification.Sink
+	// warehouses are the warehouses SHOW WAREHOUSES lists.
+	warehouses *warehouse.Manager
+	// warehouseRoutes are the connection managers of warehouses bound to
+	// their own DuckDB instances, by uppercase warehouse name.
+	warehouseRoutes map[string]*connection.Manager
+	// readOnly skips creating the executor's own tables, for a database
+	// opened read-only.
+	readOnly bool
+	// dropProtection rejects or dry-runs statements that destroy data.
+	dropProtection DropProtection
+	// stages are the stages CREATE STAGE, DROP STAGE, and LIST manage.
+	stages *stage.Manager
+	// sessions records the database, schema, warehouse, and role USE selects.
+	sessions SessionUpdater
+	// compat accumulates the statements the emulator did not handle
+	// faithfully, or is nil.
+	compat *CompatReport
+	// maxBindings is the maximum number of bindings of a statement, or 0 for
+	// no limit.
+	maxBindings int
+	// statementMemoryLimit is the memory in bytes a statement may use, or 0
+	// for no limit.
+	statementMemoryLimit int64
+	// memoryStatements counts the statements running under the statement
+	// memory limit.
+	memoryStatements runningStatements
 }
 
 // ExecutorOption configures an Executor.
@@ -50,37 +81,223 @@ func WithMergeProcessor(processor *MergeProcessor) ExecutorOption {
 	}
 }
 
+// WithTranslator sets the SQL translator used to convert Snowflake SQL to DuckDB SQL.
+// Use this to customize translation behavior, e.g. NewTranslator(WithHashFunction("my_hash")).
+func WithTranslator(translator *Translator) ExecutorOption {
+	return func(e *Executor) {
+		e.translator = translator
+	}
+}
+
+// WithReadOnly runs the executor over a database opened read-only, such as a
+// replica serving reads from a seeded file. The tables of data metric results
+// and sent notifications are not created, and writes fail with DuckDB's
+// read-only error.
+func WithReadOnly() ExecutorOption {
+	return func(e *Executor) {
+		e.readOnly = true
+	}
+}
+
+// WithMaxBindings sets the maximum number of bindings of a statement, 16,384
+// by default. Statements with more fail as Snowflake fails them. n <= 0
+// removes the limit.
+func WithMaxBindings(n int) ExecutorOption {
+	return func(e *Executor) {
+		if n < 0 {
+			n = 0
+		}
+		e.maxBindings = n
+	}
+}
+
 // NewExecutor creates a new query executor.
-func NewExecutor(mgr *connection.Manager, repo *metadata.Repository, opts ...ExecutorOption) *Executor {
+func NewExecutor(mgr *connection.Manager, repo metadata.Store, opts ...ExecutorOption) *Executor {
 	e := &Executor{
-		mgr:        mgr,
-		repo:       repo,
-		translator: NewTranslator(),
+		mgr:         mgr,
+		repo:        repo,
+		translator:  NewTranslator(),
+		maxBindings: DefaultMaxBindings,
 	}
 	for _, opt := range opts {
 		opt(e)
 	}
+	e.configureImplicitCasting()
+	e.configureCortex()
+	e.configureGenerators()
+	e.configureDigests()
+	e.configureEncryption()
+	e.configureDivision()
+	if !e.readOnly {
+		e.configureDataMetrics()
+		e.configureNotifications()
+	}
 	return e
 }
 
+// configureImplicitCasting enables DuckDB's legacy implicit VARCHAR casts when the
+// translator runs in implicit casting mode, so that comparisons such as
+// varchar_col = 5 and calls such as UPPER(int_col) behave like Snowflake.
+func (e *Executor) configureImplicitCasting() {
+	if !e.translator.ImplicitCasting() {
+		return
+	}
+	if _, err := e.mgr.Exec(context.Background(), "SET GLOBAL old_implicit_casting = true"); err != nil {
+		log.Printf("Failed to enable implicit casting: %v", err)
+	}
+}
+
 // Configure applies options to an existing Executor.
 // Use this to resolve circular dependencies when processors need the executor reference.
 func (e *Executor) Configure(opts ...ExecutorOption) {
 	for _, opt := range opts {
 		opt(e)
 	}
+	e.configureImplicitCasting()
+}
+
+// translate converts Snowflake SQL to DuckDB SQL using the session parameters carried by ctx.
+// Translation failures are reported as TranslationErrors at the end of sql, and
+// statements passed to DuckDB untranslated are recorded in the compatibility report.
+func (e *Executor) translate(ctx context.Context, sql string) (string, error) {
+	resolved, views := e.resolveObjectNames(ctx, sql)
+	translated, fallback, err := e.translator.translateReportingFallback(resolved, SessionParametersFromContext(ctx))
+	if err != nil {
+		return "", &TranslationError{SQL: sql, Construct: endOfInput, Offset: len(sql), Err: err}
+	}
+	if fallback != nil && e.compat != nil {
+		e.compat.record(CompatFallback, sql, "", fallback)
+	}
+	return expandVirtualViews(translated, views), nil
 }
 
 // Query executes a SELECT query and returns results.
-func (e *Executor) Query(ctx context.Context, sql string) (*Result, error) {
+func (e *Executor) Query(ctx context.Context, sql string) (result *Result, err error) {
+	defer func(statement string) { e.recordCompat(statement, err) }(sql)
+	sql = normalizeStatement(sql)
+
+	// A result spilled while it is read is only that of the statement itself
+	spill, ctx := takeResultSpill(ctx)
+
+	// $name references are replaced by the values of the session's SQL variables
+	sql, err = e.substituteVariables(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	// SYSTEM$GET_SECRET reads the secrets of the running procedure
+	sql, err = e.bindSecrets(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	// CURRENT_DATABASE() and the other context functions read the session
+	sql = bindContextFunctions(ctx, sql)
+
+	// Stored procedures and anonymous blocks are run by the emulator
+	if call, ok, err := parseCall(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryCall(ctx, call)
+	}
+	if stmt, ok, err := parseExecuteImmediate(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryExecuteImmediate(ctx, stmt)
+	}
+
+	// SELECT dmf(SELECT ... FROM t) evaluates a data metric function
+	if rewritten, ok := e.rewriteDataMetricCall(ctx, sql); ok {
+		sql = rewritten
+	}
+
+	// Open transactions are tracked by the emulator rather than DuckDB
+	if kind, ok := showTransactionsKind(sql); ok {
+		return e.queryShowTransactions(kind), nil
+	}
+	if isShowAlerts(sql) {
+		return e.queryShowAlerts(sql)
+	}
+	if category, ok := showIntegrationsCategory(sql); ok {
+		return e.queryShowIntegrations(sql, category)
+	}
+	if isShowSecrets(sql) {
+		return e.queryShowSecrets(sql)
+	}
+
+	// SQL variables and session parameters are held by the emulator
+	if stmt, ok, err := parseShowSessionStatement(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryShowSession(ctx, stmt), nil
+	}
+
+	// Staged files are kept by the stage manager
+	if stmt, ok, err := parseListStatement(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryList(ctx, stmt)
+	}
+
+	// Statements of a session with an open transaction run inside it
+	ctx = e.withTransaction(ctx)
+
+	// DuckDB has no SHOW COLUMNS, so columns are listed from result metadata
+	if stmt, ok, err := parseShowColumns(stripLeadingComments(sql)); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryShowColumns(ctx, stmt)
+	}
+
+	// Databases, schemas, and the objects of schemas are emulated, so they are
+	// listed and described from the metadata store
+	if stmt, ok, err := parseShowStatement(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryShow(ctx, stmt)
+	}
+	if stmt, ok, err := parseDescribeStatement(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.queryDescribe(ctx, stmt)
+	}
+
 	// Translate Snowflake SQL to DuckDB SQL
-	translatedSQL, err := e.translator.Translate(sql)
+	translatedSQL, err := e.translate(ctx, sql)
 	if err != nil {
 		return nil, fmt.Errorf("translation error: %w", err)
 	}
 
-	// Execute query
-	rows, err := e.mgr.Query(ctx, translatedSQL)
+	// Execute query, converting its result column-wise through DuckDB's Arrow
+	// interface when possible, and spilling it while it is read
+	limitedCtx, stop := e.limitStatementMemory(context.WithValue(ctx, resultSpillKey{}, spill))
+	result, ok, err := e.queryArrow(limitedCtx, translatedSQL)
+	if !ok {
+		spill.discard()
+		result, err = e.queryRows(limitedCtx, translatedSQL)
+	}
+	err = stop(err)
+	if err != nil {
+		spill.discard()
+		return nil, withUnsupportedFunction(sql, withSyntaxPosition(sql, translatedSQL, e.abortTransaction(ctx, err)))
+	}
+	if err := e.checkOrdering(sql, result); err != nil {
+		spill.discard()
+		return nil, err
+	}
+	return result, nil
+}
+
+// queryRows runs a translated query and converts its result row by row.
+func (e *Executor) queryRows(ctx context.Context, translatedSQL string) (*Result, error) {
+	rows, err := e.manager(ctx).Query(ctx, translatedSQL)
 	if err != nil {
 		return nil, fmt.Errorf("query execution error: %w", err)
 	}
@@ -95,8 +312,9 @@ func (e *Executor) Query(ctx context.Context, sql string) (*Result, error) {
 	// Capture column types before iterating (using TypeMapper)
 	columnTypes := InferColumnMetadata(columns, rows)
 
-	// Fetch all rows
-	var resultRows [][]interface{}
+	// Fetch all rows, spilling them while they are read if the query's result is spilled
+	result := &Result{Columns: columns, ColumnTypes: columnTypes}
+	spill := resultSpillFromContext(ctx)
 	for rows.Next() {
 		// Create a slice of interface{} to hold the values
 		values := make([]interface{}, len(columns))
@@ -112,21 +330,22 @@ func (e *Executor) Query(ctx context.Context, sql string) (*Result, error) {
 		// Convert values to appropriate types
 		row := make([]interface{}, len(columns))
 		for i, val := range values {
-			row[i] = convertValue(val)
+			row[i] = convertValue(val, columnTypes[i])
 		}
 
-		resultRows = append(resultRows, row)
+		result.Rows = append(result.Rows, row)
+		if err := spill.collect(result); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
-
-	return &Result{
-		Columns:     columns,
-		ColumnTypes: columnTypes,
-		Rows:        resultRows,
-	}, nil
+	if err := spill.finish(result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // QueryWithBindings executes a SELECT query with parameter bindings and returns results.
@@ -145,158 +364,149 @@ func (e *Executor) QueryWithBindings(ctx context.Context, sql string, bindings m
 	return e.Query(ctx, boundSQL)
 }
 
-// applyBindings replaces :N placeholders with actual values from bindings.
-// Snowflake uses :1, :2, etc. for positional parameters.
-func (e *Executor) applyBindings(sql string, bindings map[string]*QueryBindingValue) (string, error) {
-	// Get binding keys sorted in descending order to avoid :1 replacing :10, :11, etc.
-	keys := make([]int, 0, len(bindings))
-	for k := range bindings {
-		pos, err := strconv.Atoi(k)
-		if err != nil {
-			return "", fmt.Errorf("invalid binding key %q: must be a number", k)
-		}
-		keys = append(keys, pos)
+// BindParameters returns sql with its :N and ? placeholders replaced by the
+// bindings, for callers that record the bound statement in query history.
+func (e *Executor) BindParameters(sql string, bindings map[string]*BindingValue) (string, error) {
+	if len(bindings) == 0 {
+		return sql, nil
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(keys)))
-
-	result := sql
-	for _, pos := range keys {
-		key := strconv.Itoa(pos)
-		binding := bindings[key]
-		if binding == nil {
-			continue
-		}
-
-		placeholder := ":" + key
-		value, err := formatBindingValue(binding)
-		if err != nil {
-			return "", fmt.Errorf("error formatting binding %s: %w", key, err)
-		}
+	boundSQL, err := e.applyBindings(sql, bindings)
+	if err != nil {
+		return "", fmt.Errorf("binding error: %w", err)
+	}
+	return boundSQL, nil
+}
 
-		result = strings.ReplaceAll(result, placeholder, value)
+// ExecuteWithBindings executes a non-query SQL statement with parameter bindings.
+// Bindings are keyed by position (e.g., "1", "2", "3") and replace :1, :2, :3 placeholders.
+func (e *Executor) ExecuteWithBindings(ctx context.Context, sql string, bindings map[string]*QueryBindingValue) (*ExecResult, error) {
+	if len(bindings) == 0 {
+		return e.Execute(ctx, sql)
 	}
 
-	// Also handle ? placeholders (positional, 1-based)
-	result = e.replaceQuestionMarkPlaceholders(result, bindings)
+	// Replace binding placeholders with actual values
+	boundSQL, err := e.applyBindings(sql, bindings)
+	if err != nil {
+		return nil, fmt.Errorf("binding error: %w", err)
+	}
 
-	return result, nil
+	return e.Execute(ctx, boundSQL)
 }
 
-// replaceQuestionMarkPlaceholders replaces ? placeholders with binding values.
-func (e *Executor) replaceQuestionMarkPlaceholders(sql string, bindings map[string]*QueryBindingValue) string {
-	// Find all ? placeholders
-	re := regexp.MustCompile(`\?`)
-	matches := re.FindAllStringIndex(sql, -1)
-	if len(matches) == 0 {
-		return sql
-	}
-
-	// Replace from end to start to preserve indices
-	result := sql
-	for i := len(matches) - 1; i >= 0; i-- {
-		key := strconv.Itoa(i + 1) // 1-based
-		binding := bindings[key]
-		if binding == nil {
-			continue
-		}
+// Execute executes a non-query SQL statement (INSERT, UPDATE, DELETE, CREATE, DROP, etc.).
+func (e *Executor) Execute(ctx context.Context, sql string) (result *ExecResult, err error) {
+	defer func(statement string) { e.recordCompat(statement, err) }(sql)
+	sql = normalizeStatement(sql)
 
-		value, err := formatBindingValue(binding)
+	// SET and UNSET change the session's SQL variables
+	if stmt, ok, err := parseVariableStatement(sql); ok {
 		if err != nil {
-			continue // Skip on error
+			return nil, err
 		}
-
-		start := matches[i][0]
-		end := matches[i][1]
-		result = result[:start] + value + result[end:]
+		return e.executeVariableStatement(ctx, stmt)
 	}
 
-	return result
-}
-
-// formatBindingValue formats a binding value for SQL substitution.
-//
-//nolint:gocyclo // switch statement for type handling inherently has many branches
-func formatBindingValue(b *QueryBindingValue) (string, error) {
-	if b == nil {
-		return ValueNull, nil
+	// USE changes the session's current database, schema, warehouse, or role
+	if stmt, ok, err := parseUseStatement(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.executeUse(ctx, stmt)
 	}
 
-	switch strings.ToUpper(b.Type) {
-	case TypeText, "VARCHAR", "STRING":
-		// Escape single quotes and wrap in quotes
-		escaped := strings.ReplaceAll(b.Value, "'", "''")
-		return "'" + escaped + "'", nil
-
-	case "FIXED", "INTEGER", "BIGINT", "SMALLINT", "TINYINT":
-		// Validate it's a number
-		if _, err := strconv.ParseInt(b.Value, 10, 64); err != nil {
-			return "", fmt.Errorf("invalid integer value: %s", b.Value)
+	// ALTER SESSION sets or unsets the session's parameters
+	if stmt, ok, err := parseAlterSession(sql); ok {
+		if err != nil {
+			return nil, err
 		}
-		return b.Value, nil
+		return e.executeAlterSession(ctx, stmt)
+	}
 
-	case "REAL", "FLOAT", "DOUBLE", "NUMBER", "DECIMAL":
-		// Validate it's a number
-		if _, err := strconv.ParseFloat(b.Value, 64); err != nil {
-			return "", fmt.Errorf("invalid float value: %s", b.Value)
-		}
-		return b.Value, nil
+	// $name references are replaced by the values of the session's SQL variables
+	sql, err = e.substituteVariables(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
 
-	case "BOOLEAN":
-		lower := strings.ToLower(b.Value)
-		if lower == "true" || lower == "1" {
-			return "TRUE", nil
-		}
-		return "FALSE", nil
+	// SYSTEM$GET_SECRET reads the secrets of the running procedure
+	sql, err = e.bindSecrets(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
 
-	case "DATE":
-		// Validate date format to prevent SQL injection
-		if !dateRegex.MatchString(b.Value) {
-			return "", fmt.Errorf("invalid DATE format: %s (expected YYYY-MM-DD)", b.Value)
-		}
-		return "DATE '" + b.Value + "'", nil
+	// CURRENT_DATABASE() and the other context functions read the session
+	sql = bindContextFunctions(ctx, sql)
 
-	case "TIME":
-		// Validate time format to prevent SQL injection
-		if !timeRegex.MatchString(b.Value) {
-			return "", fmt.Errorf("invalid TIME format: %s (expected HH:MM:SS)", b.Value)
-		}
-		return "TIME '" + b.Value + "'", nil
+	// Drop protection keeps destructive statements from running
+	if result, ok, err := e.protectDestructive(ctx, sql); ok {
+		return result, err
+	}
 
-	case "TIMESTAMP", "TIMESTAMP_NTZ", "TIMESTAMP_LTZ", "TIMESTAMP_TZ":
-		// Validate timestamp format to prevent SQL injection
-		if !timestampRegex.MatchString(b.Value) {
-			return "", fmt.Errorf("invalid TIMESTAMP format: %s (expected YYYY-MM-DD HH:MM:SS)", b.Value)
-		}
-		return "TIMESTAMP '" + b.Value + "'", nil
+	// Bound how long writes wait for conflicting transactions by the session's LOCK_TIMEOUT
+	if timeout := SessionParametersFromContext(ctx).LockTimeout; timeout != nil {
+		ctx = connection.ContextWithLockTimeout(ctx, *timeout)
+	}
 
-	case ValueNull:
-		return ValueNull, nil
+	// Statements of a session with an open transaction run inside it. With
+	// AUTOCOMMIT off, DML opens one first.
+	ctx, err = e.withImplicitTransaction(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
 
-	default:
-		// Default to text treatment
-		escaped := strings.ReplaceAll(b.Value, "'", "''")
-		return "'" + escaped + "'", nil
+	limitedCtx, stop := e.limitStatementMemory(ctx)
+	result, err = e.executeStatement(limitedCtx, sql)
+	if err = stop(err); err != nil {
+		return nil, e.abortTransaction(ctx, err)
 	}
+	e.recordStatement(ctx, sql)
+	return result, nil
 }
 
-// ExecuteWithBindings executes a non-query SQL statement with parameter bindings.
-// Bindings are keyed by position (e.g., "1", "2", "3") and replace :1, :2, :3 placeholders.
-func (e *Executor) ExecuteWithBindings(ctx context.Context, sql string, bindings map[string]*QueryBindingValue) (*ExecResult, error) {
-	if len(bindings) == 0 {
-		return e.Execute(ctx, sql)
+// executeStatement executes a non-query statement in the context's transaction, if any.
+func (e *Executor) executeStatement(ctx context.Context, sql string) (*ExecResult, error) {
+	// CREATE statements may carry Snowflake COMMENT clauses that DuckDB rejects
+	if stmt, ok := parseCreateStatement(stripLeadingComments(sql)); ok {
+		return e.executeCreate(ctx, stmt)
 	}
-
-	// Replace binding placeholders with actual values
-	boundSQL, err := e.applyBindings(sql, bindings)
-	if err != nil {
-		return nil, fmt.Errorf("binding error: %w", err)
+	if name, ifExists, ok := dropProcedureName(sql); ok {
+		return e.executeDropProcedure(ctx, name, ifExists)
 	}
-
-	return e.Execute(ctx, boundSQL)
+	if name, ifExists, ok := dropIntegrationName(sql); ok {
+		return e.executeDropIntegration(name, ifExists)
+	}
+	if name, ifExists, ok := dropSecretName(sql); ok {
+		return e.executeDropSecret(ctx, name, ifExists)
+	}
+	if name, ifExists, ok := dropStageName(sql); ok {
+		return e.executeDropStage(ctx, name, ifExists)
+	}
+	if isNetworkRuleStatement(sql) {
+		return &ExecResult{}, nil
+	}
+	if stmt, ok, err := parseAlertStatement(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.executeAlertStatement(ctx, stmt)
+	}
+	if alter, ok, err := parseDataMetricAlter(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.executeDataMetricAlter(ctx, alter)
+	}
+	if alter, ok, err := parseAlterTable(sql); ok {
+		if err != nil {
+			return nil, err
+		}
+		return e.executeAlterTable(ctx, alter)
+	}
+	return e.execute(ctx, sql)
 }
 
-// Execute executes a non-query SQL statement (INSERT, UPDATE, DELETE, CREATE, DROP, etc.).
-func (e *Executor) Execute(ctx context.Context, sql string) (*ExecResult, error) {
+// execute dispatches a non-query statement to the handler for its kind.
+func (e *Executor) execute(ctx context.Context, sql string) (*ExecResult, error) {
 	// Use classifier to detect DDL statements that need metadata tracking
 	classifier := NewClassifier()
 
@@ -310,6 +520,11 @@ func (e *Executor) Execute(ctx context.Context, sql string) (*ExecResult, error)
 		return e.executeDropTable(ctx, sql)
 	}
 
+	// For DROP VIEW, registered views are removed from metadata
+	if name, ok := dropViewName(stripLeadingComments(sql)); ok {
+		return e.executeDropView(ctx, sql, name)
+	}
+
 	// Handle transaction control statements
 	if IsTransaction(sql) {
 		return e.executeTransaction(ctx, sql)
@@ -317,12 +532,13 @@ func (e *Executor) Execute(ctx context.Context, sql string) (*ExecResult, error)
 
 	// Handle COPY INTO statements
 	if IsCopy(sql) {
-		return e.executeCopy(ctx, sql)
+		return e.executeCopy(ctx, stripLeadingComments(sql))
 	}
 
-	// Handle MERGE INTO statements
-	if IsMerge(sql) {
-		return e.executeMerge(ctx, sql)
+	// Handle MERGE INTO statements. A MERGE with common table expressions is
+	// passed to DuckDB, since the processor does not carry the WITH clause.
+	if statement := stripLeadingComments(sql); IsMerge(statement) && !keywordAt(statement, 0, "WITH") {
+		return e.executeMerge(ctx, statement)
 	}
 
 	// Execute regular SQL statement
@@ -334,15 +550,15 @@ func (e *Executor) Execute(ctx context.Context, sql string) (*ExecResult, error)
 // This is a private method as it's only called from same-package processors.
 func (e *Executor) executeRaw(ctx context.Context, sql string) (*ExecResult, error) {
 	// Translate Snowflake SQL to DuckDB SQL
-	translatedSQL, err := e.translator.Translate(sql)
+	translatedSQL, err := e.translate(ctx, sql)
 	if err != nil {
 		return nil, fmt.Errorf("translation error: %w", err)
 	}
 
 	// Execute statement
-	result, err := e.mgr.Exec(ctx, translatedSQL)
+	result, err := e.manager(ctx).Exec(ctx, translatedSQL)
 	if err != nil {
-		return nil, fmt.Errorf("execution error: %w", err)
+		return nil, withUnsupportedFunction(sql, withSyntaxPosition(sql, translatedSQL, fmt.Errorf("execution error: %w", err)))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -358,19 +574,15 @@ func (e *Executor) executeRaw(ctx context.Context, sql string) (*ExecResult, err
 // executeCreateTable handles CREATE TABLE statements with metadata registration.
 func (e *Executor) executeCreateTable(ctx context.Context, sql string) (*ExecResult, error) {
 	// Execute the CREATE TABLE in DuckDB first
-	translatedSQL, err := e.translator.Translate(sql)
+	translatedSQL, err := e.translate(ctx, sql)
 	if err != nil {
 		return nil, fmt.Errorf("translation error: %w", err)
 	}
 
-	if _, err := e.mgr.Exec(ctx, translatedSQL); err != nil {
+	if _, err := e.manager(ctx).Exec(ctx, translatedSQL); err != nil {
 		return nil, fmt.Errorf("create table execution error: %w", err)
 	}
 
-	// Note: In a full implementation, we would parse the CREATE TABLE statement
-	// and register it in metadata. For now, we just execute it.
-	// This would require SQL parsing to extract table name, columns, etc.
-
 	return &ExecResult{
 		RowsAffected: 0,
 	}, nil
@@ -379,50 +591,42 @@ func (e *Executor) executeCreateTable(ctx context.Context, sql string) (*ExecRes
 // executeDropTable handles DROP TABLE statements with metadata cleanup.
 func (e *Executor) executeDropTable(ctx context.Context, sql string) (*ExecResult, error) {
 	// Execute the DROP TABLE in DuckDB first
-	translatedSQL, err := e.translator.Translate(sql)
+	translatedSQL, err := e.translate(ctx, sql)
 	if err != nil {
 		return nil, fmt.Errorf("translation error: %w", err)
 	}
 
-	if _, err := e.mgr.Exec(ctx, translatedSQL); err != nil {
+	if _, err := e.manager(ctx).Exec(ctx, translatedSQL); err != nil {
 		return nil, fmt.Errorf("drop table execution error: %w", err)
 	}
 
-	// Note: In a full implementation, we would remove the table from metadata.
-	// This would require SQL parsing to extract the table name.
+	if err := e.unregisterTable(ctx, sql); err != nil {
+		return nil, err
+	}
 
 	return &ExecResult{
 		RowsAffected: 0,
 	}, nil
 }
 
-// executeTransaction handles transaction control statements (BEGIN, COMMIT, ROLLBACK).
-// DuckDB supports transactions, so we pass them through directly.
+// executeTransaction handles transaction control statements (BEGIN, COMMIT,
+// ROLLBACK, and savepoints). A session's transaction runs on a dedicated DuckDB
+// connection, which the session's statements use until the transaction ends.
 func (e *Executor) executeTransaction(ctx context.Context, sql string) (*ExecResult, error) {
-	// DuckDB supports BEGIN, COMMIT, and ROLLBACK
-	// We execute them directly without translation
-	upperSQL := strings.ToUpper(strings.TrimSpace(sql))
+	if kind, name, ok := parseSavepointStatement(sql); ok {
+		return e.executeSavepoint(ctx, kind, name)
+	}
 
-	// Normalize transaction statements for DuckDB
-	var duckDBSQL string
 	switch {
-	case strings.HasPrefix(upperSQL, "BEGIN") || strings.HasPrefix(upperSQL, "START TRANSACTION"):
-		duckDBSQL = "BEGIN TRANSACTION"
-	case strings.HasPrefix(upperSQL, "COMMIT"):
-		duckDBSQL = "COMMIT"
-	case strings.HasPrefix(upperSQL, "ROLLBACK"):
-		duckDBSQL = "ROLLBACK"
+	case IsBegin(sql):
+		return e.beginTransaction(ctx)
+	case IsCommit(sql):
+		return e.endTransaction(ctx, "COMMIT")
+	case IsRollback(sql):
+		return e.endTransaction(ctx, "ROLLBACK")
 	default:
 		return nil, fmt.Errorf("unknown transaction statement: %s", sql)
 	}
-
-	if _, err := e.mgr.Exec(ctx, duckDBSQL); err != nil {
-		return nil, fmt.Errorf("transaction error: %w", err)
-	}
-
-	return &ExecResult{
-		RowsAffected: 0,
-	}, nil
 }
 
 // executeCopy handles COPY INTO statements.
@@ -461,6 +665,7 @@ func (e *Executor) executeCopy(ctx context.Context, sql string) (*ExecResult, er
 
 	return &ExecResult{
 		RowsAffected: result.RowsLoaded,
+		Warnings:     result.Errors,
 	}, nil
 }
 
@@ -488,14 +693,21 @@ func (e *Executor) executeMerge(ctx context.Context, sql string) (*ExecResult, e
 }
 
 // convertValue converts database values to appropriate Go types.
-func convertValue(val interface{}) interface{} {
+func convertValue(val interface{}, column types.ColumnMetadata) interface{} {
 	if val == nil {
 		return nil
 	}
 
 	switch v := val.(type) {
 	case []byte:
-		// Convert byte slices to strings
+		// A nil slice is a NULL, which must not become an empty string
+		if v == nil {
+			return nil
+		}
+		// BINARY values keep their bytes; other byte slices are converted to strings
+		if column.Type == "binary" {
+			return v
+		}
 		return string(v)
 	case int64:
 		// Keep as int64 for now, could convert to int if needed
@@ -512,6 +724,15 @@ func convertValue(val interface{}) interface{} {
 	}
 }
 
+// EndSession releases what the Executor holds for a session that logged out or
+// expired: it aborts the session's queries started with ABORT_DETACHED_QUERY,
+// drops its SQL variables, and rolls back its open transaction.
+func (e *Executor) EndSession(ctx context.Context, sessionID string) error {
+	e.AbortSessionQueries(sessionID)
+	e.clearVariables(sessionID)
+	return e.RollbackSession(ctx, sessionID)
+}
+
 // ExecuteWithHistory wraps Execute with query history tracking.
 func (e *Executor) ExecuteWithHistory(ctx context.Context, sessionID, queryID, sql string) (*ExecResult, error) {
 	startTime := time.Now()
@@ -522,13 +743,18 @@ func (e *Executor) ExecuteWithHistory(ctx context.Context, sessionID, queryID, s
 		log.Printf("Failed to record query start: %v", err)
 	}
 
+	e.recordQueryTag(ctx, entry)
+
 	// Execute the query
-	result, execErr := e.Execute(ctx, sql)
+	queryCtx, done := e.trackQuery(contextWithQueryID(ctx, queryID), sessionID, queryID)
+	result, execErr := e.Execute(queryCtx, sql)
+	done()
 
 	// Calculate execution time
 	executionTimeMs := time.Since(startTime).Milliseconds()
 
-	// Record result
+	// Record result, even for a canceled statement
+	ctx = context.WithoutCancel(ctx)
 	if entry != nil {
 		if execErr != nil {
 			_ = e.repo.RecordQueryFailure(ctx, entry.ID, execErr.Error(), executionTimeMs)
@@ -540,6 +766,21 @@ func (e *Executor) ExecuteWithHistory(ctx context.Context, sessionID, queryID, s
 	return result, execErr
 }
 
+// recordQueryTag records the QUERY_TAG of a statement's session on its query
+// history entry, which is nil when its start was not recorded.
+func (e *Executor) recordQueryTag(ctx context.Context, entry *metadata.QueryHistoryEntry) {
+	if entry == nil {
+		return
+	}
+	for name, tag := range SessionInfoFromContext(ctx).Parameters {
+		if strings.EqualFold(name, string(config.ParamQueryTag)) && tag != "" {
+			if err := e.repo.RecordQueryLabels(ctx, entry.ID, tag, nil); err != nil {
+				log.Printf("Failed to record query tag: %v", err)
+			}
+		}
+	}
+}
+
 // QueryWithHistory wraps Query with query history tracking.
 func (e *Executor) QueryWithHistory(ctx context.Context, sessionID, queryID, sql string) (*Result, error) {
 	startTime := time.Now()
@@ -550,13 +791,18 @@ func (e *Executor) QueryWithHistory(ctx context.Context, sessionID, queryID, sql
 		log.Printf("Failed to record query start: %v", err)
 	}
 
+	e.recordQueryTag(ctx, entry)
+
 	// Execute the query
-	result, execErr := e.Query(ctx, sql)
+	queryCtx, done := e.trackQuery(contextWithQueryID(ctx, queryID), sessionID, queryID)
+	result, execErr := e.Query(queryCtx, sql)
+	done()
 
 	// Calculate execution time
 	executionTimeMs := time.Since(startTime).Milliseconds()
 
-	// Record result
+	// Record result, even for a canceled statement
+	ctx = context.WithoutCancel(ctx)
 	if entry != nil {
 		if execErr != nil {
 			_ = e.repo.RecordQueryFailure(ctx, entry.ID, execErr.Error(), executionTimeMs)
@@ -571,3 +817,13 @@ func (e *Executor) QueryWithHistory(ctx context.Context, sessionID, queryID, sql
 
 	return result, execErr
 }
+
+// MetadataCacheStats returns the statistics of the metadata store's lookup
+// cache. It reports false if the store has no cache.
+func (e *Executor) MetadataCacheStats() (metadata.CacheStats, bool) {
+	cached, ok := e.repo.(interface{ CacheStats() metadata.CacheStats })
+	if !ok {
+		return metadata.CacheStats{}, false
+	}
+	return cached.CacheStats(), true
+}
@@ -1,9 +1,14 @@
 package query
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/google/go-cmp/cmp"
@@ -12,7 +17,7 @@ import (
 )
 
 // setupTestExecutor creates a test executor with in-memory DuckDB.
-func setupTestExecutor(t *testing.T) (*Executor, *metadata.Repository) {
+func setupTestExecutor(t *testing.T, opts ...ExecutorOption) (*Executor, *metadata.Repository) {
 	t.Helper()
 
 	db, err := sql.Open("duckdb", "")
@@ -32,7 +37,7 @@ func setupTestExecutor(t *testing.T) (*Executor, *metadata.Repository) {
 		t.Fatalf("failed to create repository: %v", err)
 	}
 
-	executor := NewExecutor(mgr, repo)
+	executor := NewExecutor(mgr, repo, opts...)
 	return executor, repo
 }
 
@@ -171,6 +176,20 @@ func TestExecutor_ExecuteWithTranslation(t *testing.T) { //nolint:gocyclo // Tes
 				}
 			},
 		},
+		{
+			name:         "MAX_BYTranslation",
+			sql:          "SELECT MAX_BY(NAME, AGE) AS oldest, MIN_BY(NAME, AGE) AS youngest FROM TEST_DB.PUBLIC_USERS",
+			expectedRows: 1,
+			expectedCols: 2,
+			checkFirstRow: func(t *testing.T, row []interface{}) {
+				if row[0] != "Alice" {
+					t.Errorf("Expected oldest 'Alice', got %v", row[0])
+				}
+				if row[1] != "Bob" {
+					t.Errorf("Expected youngest 'Bob', got %v", row[1])
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -283,6 +302,511 @@ func TestExecutor_ErrorHandling(t *testing.T) {
 	}
 }
 
+// TestExecutor_AggregateNulls tests the NULL semantics of MIN_BY, MAX_BY, and BOOLXOR_AGG.
+func TestExecutor_AggregateNulls(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	setup := []string{
+		"CREATE TABLE agg_nulls (g INTEGER, name VARCHAR, v INTEGER, flag INTEGER)",
+		"INSERT INTO agg_nulls VALUES (1, 'a', 1, 1), (1, NULL, 2, 0), (1, 'c', NULL, NULL), (2, 'd', 1, 1), (2, 'e', 2, 2), (3, 'f', 1, NULL)",
+	}
+	for _, sql := range setup {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT g, MAX_BY(name, v), MIN_BY(name, v), BOOLXOR_AGG(flag) FROM agg_nulls GROUP BY g ORDER BY g")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := [][]interface{}{
+		{int64(1), nil, "a", true},
+		{int64(2), "e", "d", false},
+		{int64(3), "f", "f", nil},
+	}
+	if diff := cmp.Diff(want, result.Rows); diff != "" {
+		t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_HashFunctions tests that HASH and HASH_AGG produce stable values.
+func TestExecutor_HashFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	_, err := executor.Execute(ctx, "CREATE TABLE hash_test (a INTEGER, b VARCHAR)")
+	if err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+	_, err = executor.Execute(ctx, "INSERT INTO hash_test VALUES (1, 'x'), (2, 'y')")
+	if err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+
+	// HASH(*) must equal HASH over the explicit column list
+	result, err := executor.Query(ctx, "SELECT HASH(*), HASH(a, b) FROM hash_test WHERE a = 1")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+	}
+	if _, ok := result.Rows[0][0].(int64); !ok {
+		t.Errorf("Expected HASH to return int64, got %T", result.Rows[0][0])
+	}
+	if result.Rows[0][0] != result.Rows[0][1] {
+		t.Errorf("HASH(*) = %v, HASH(a, b) = %v, want equal", result.Rows[0][0], result.Rows[0][1])
+	}
+
+	// HASH_AGG must not depend on row order
+	asc, err := executor.Query(ctx, "SELECT HASH_AGG(a, b) FROM hash_test")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	_, err = executor.Execute(ctx, "INSERT INTO hash_test VALUES (0, 'z')")
+	if err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+	filtered, err := executor.Query(ctx, "SELECT HASH_AGG(a, b) FROM hash_test WHERE a > 0")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff(asc.Rows, filtered.Rows); diff != "" {
+		t.Errorf("HASH_AGG mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_CryptoFunctions tests hashing and encoding functions against DuckDB.
+func TestExecutor_CryptoFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	result, err := executor.Query(ctx, "SELECT MD5('abc'), BASE64_ENCODE('abc'), BASE64_DECODE_STRING('YWJj'), HEX_ENCODE('abc'), HEX_DECODE_STRING('616263'), LENGTH(UUID_STRING())")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+	}
+
+	expected := []interface{}{"900150983cd24fb0d6963f7d28e17f72", "YWJj", "abc", "616263", "abc", int64(36)}
+	if diff := cmp.Diff(expected, result.Rows[0]); diff != "" {
+		t.Errorf("Crypto functions mismatch (-want +got):\n%s", diff)
+	}
+
+	// SHA2 digest sizes other than 256 bits are computed by a registered function
+	result, err = executor.Query(ctx, "SELECT SHA2('abc', 224), SHA2_HEX('abc', 384), SHA2('abc', 512), HEX_ENCODE(SHA2_BINARY('abc', 224), 0)")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	expected = []interface{}{
+		"23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+		"cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+		"ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		"23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+	}
+	if diff := cmp.Diff(expected, result.Rows[0]); diff != "" {
+		t.Errorf("SHA2 digest sizes mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := executor.Query(ctx, "SELECT SHA2('abc', 128)"); err == nil || !strings.Contains(err.Error(), "must be 224, 256, 384, or 512") {
+		t.Errorf("SHA2('abc', 128) error = %v, want an invalid digest size error", err)
+	}
+
+	// ENCRYPT/DECRYPT round-trip the value through AES-GCM, returned as BINARY like Snowflake
+	result, err = executor.Query(ctx, "SELECT ENCRYPT('secret', 'passphrase'), ENCRYPT('secret', 'passphrase'), "+
+		"DECRYPT(ENCRYPT('secret', 'passphrase'), 'passphrase'), "+
+		"DECRYPT(ENCRYPT(HEX_DECODE_BINARY('00ff'), 'passphrase', 'aad', 'AES-GCM'), 'passphrase', 'aad', 'aes-gcm'), "+
+		"TRY_DECRYPT(ENCRYPT('secret', 'passphrase'), 'wrong'), TRY_DECRYPT(ENCRYPT('secret', 'passphrase', 'aad', 'AES-GCM'), 'passphrase', 'other', 'AES-GCM'), "+
+		"DECRYPT(NULL, 'passphrase')")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	row := result.Rows[0]
+	first, _ := row[0].([]byte)
+	second, _ := row[1].([]byte)
+	if bytes.Contains(first, []byte("secret")) || bytes.Equal(first, second) {
+		t.Errorf("ENCRYPT() = %x, %x, want different values not containing the plaintext", first, second)
+	}
+	if diff := cmp.Diff([]interface{}{[]byte("secret"), []byte{0x00, 0xff}, nil, nil, nil}, row[2:]); diff != "" {
+		t.Errorf("DECRYPT(ENCRYPT()) mismatch (-want +got):\n%s", diff)
+	}
+	for _, sql := range []string{
+		"SELECT DECRYPT(ENCRYPT('secret', 'passphrase'), 'wrong')",
+		"SELECT DECRYPT(HEX_DECODE_BINARY('00ff'), 'passphrase')",
+	} {
+		if _, err := executor.Query(ctx, sql); err == nil || !strings.Contains(err.Error(), "decryption failed") {
+			t.Errorf("%s error = %v, want a decryption failure", sql, err)
+		}
+	}
+	if _, err := executor.Query(ctx, "SELECT ENCRYPT('secret', 'passphrase', 'aad', 'AES-CBC')"); err == nil || !strings.Contains(err.Error(), "must be AES-GCM") {
+		t.Errorf("ENCRYPT() with AES-CBC error = %v, want an unsupported method error", err)
+	}
+}
+
+// TestExecutor_StringMatchFunctions tests string matching and similarity functions against DuckDB.
+func TestExecutor_StringMatchFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	result, err := executor.Query(ctx, "SELECT CONTAINS('snowflake', 'flake'), STARTSWITH('snowflake', 'snow'), ENDSWITH('snowflake', 'snow'), EDITDISTANCE('kitten', 'sitting'), EDITDISTANCE('kitten', 'sitting', 2), JAROWINKLER_SIMILARITY('MARTHA', 'MARHTA')")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+	}
+
+	expected := []interface{}{true, true, false, int64(3), int64(2), int32(96)}
+	if diff := cmp.Diff(expected, result.Rows[0]); diff != "" {
+		t.Errorf("String match functions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_ConditionalFunctions tests DECODE, BOOL* and GREATEST/LEAST semantics against DuckDB.
+func TestExecutor_ConditionalFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	result, err := executor.Query(ctx, "SELECT DECODE(NULL, 1, 'one', NULL, 'null', 'other'), BOOLAND(1, 0), BOOLOR(0, 2), BOOLXOR(1, 1), GREATEST(1, NULL, 3), LEAST_IGNORE_NULLS(NULL, 2)")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+	}
+
+	expected := []interface{}{"null", false, true, false, nil, int32(2)}
+	if diff := cmp.Diff(expected, result.Rows[0]); diff != "" {
+		t.Errorf("Conditional functions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_VariantTypeFunctions tests TYPEOF and IS_<type> over VARIANT values against DuckDB.
+func TestExecutor_VariantTypeFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"CREATE TABLE variant_types (id INTEGER, v VARIANT)",
+		`INSERT INTO variant_types VALUES (1, PARSE_JSON('42')), (2, PARSE_JSON('2.50')), (3, PARSE_JSON('1e3')), ` +
+			`(4, PARSE_JSON('"text"')), (5, PARSE_JSON('true')), (6, PARSE_JSON('[1, 2]')), (7, PARSE_JSON('{"a": 1}')), ` +
+			`(8, PARSE_JSON('null')), (9, NULL)`,
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT TYPEOF(v), IS_INTEGER(v), IS_DECIMAL(v), IS_DOUBLE(v), IS_VARCHAR(v), "+
+		"IS_BOOLEAN(v), IS_ARRAY(v), IS_OBJECT(v), IS_NULL_VALUE(v) FROM variant_types ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	expected := [][]interface{}{
+		{"INTEGER", true, true, true, false, false, false, false, false},
+		{"DECIMAL", false, true, true, false, false, false, false, false},
+		{"DOUBLE", false, false, true, false, false, false, false, false},
+		{"VARCHAR", false, false, false, true, false, false, false, false},
+		{"BOOLEAN", false, false, false, false, true, false, false, false},
+		{"ARRAY", false, false, false, false, false, true, false, false},
+		{"OBJECT", false, false, false, false, false, false, true, false},
+		{"NULL_VALUE", false, false, false, false, false, false, false, true},
+		{nil, nil, nil, nil, nil, nil, nil, nil, nil},
+	}
+	if diff := cmp.Diff(expected, result.Rows); diff != "" {
+		t.Errorf("Variant type functions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_TryParseJSON tests that TRY_PARSE_JSON and CHECK_JSON return
+// NULL and an error message for malformed JSON instead of failing.
+func TestExecutor_TryParseJSON(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"CREATE TABLE raw_events (id INTEGER, raw VARCHAR)",
+		`INSERT INTO raw_events VALUES (1, '{"a": 1}'), (2, '{"a": '), (3, NULL), (4, '[1, 2]')`,
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT TRY_PARSE_JSON(raw), CHECK_JSON(raw) FROM raw_events ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	expected := [][]interface{}{
+		{map[string]interface{}{"a": float64(1)}, nil},
+		{nil, "invalid JSON"},
+		{nil, nil},
+		{[]interface{}{float64(1), float64(2)}, nil},
+	}
+	if diff := cmp.Diff(expected, result.Rows); diff != "" {
+		t.Errorf("TRY_PARSE_JSON and CHECK_JSON mismatch (-want +got):\n%s", diff)
+	}
+
+	// PARSE_JSON still fails on malformed JSON
+	if _, err := executor.Query(ctx, "SELECT PARSE_JSON(raw) FROM raw_events"); err == nil {
+		t.Error("Query() of PARSE_JSON over malformed JSON error = nil, want error")
+	}
+}
+
+// TestExecutor_ImplicitCasting tests Snowflake-style implicit conversions when enabled.
+func TestExecutor_ImplicitCasting(t *testing.T) {
+	executor, _ := setupTestExecutor(t, WithTranslator(NewTranslator(WithImplicitCasting())))
+	ctx := context.Background()
+
+	_, err := executor.Execute(ctx, "CREATE TABLE cast_test (v VARCHAR, n INTEGER)")
+	if err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+	_, err = executor.Execute(ctx, "INSERT INTO cast_test VALUES ('5', 3)")
+	if err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []interface{}
+	}{
+		{
+			name:     "StringLiteralArithmetic",
+			sql:      "SELECT '5' + 1, 10 - '4'",
+			expected: []interface{}{int32(6), int32(6)},
+		},
+		{
+			name:     "VarcharComparedToNumber",
+			sql:      "SELECT n FROM cast_test WHERE v = 5",
+			expected: []interface{}{int64(3)},
+		},
+		{
+			name:     "NumberPassedToStringFunction",
+			sql:      "SELECT UPPER(n) FROM cast_test",
+			expected: []interface{}{"3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(result.Rows) != 1 {
+				t.Fatalf("Expected 1 row, got %d", len(result.Rows))
+			}
+			if diff := cmp.Diff(tt.expected, result.Rows[0]); diff != "" {
+				t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_NullOrdering tests that NULLs sort like Snowflake: last for ASC, first for DESC.
+func TestExecutor_NullOrdering(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	_, err := executor.Execute(ctx, "CREATE TABLE null_order (id INTEGER, v INTEGER)")
+	if err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+	_, err = executor.Execute(ctx, "INSERT INTO null_order VALUES (1, 1), (2, NULL), (3, 2)")
+	if err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected [][]interface{}
+	}{
+		{
+			name:     "Asc",
+			sql:      "SELECT v FROM null_order ORDER BY v",
+			expected: [][]interface{}{{int64(1)}, {int64(2)}, {nil}},
+		},
+		{
+			name:     "Desc",
+			sql:      "SELECT v FROM null_order ORDER BY v DESC",
+			expected: [][]interface{}{{nil}, {int64(2)}, {int64(1)}},
+		},
+		{
+			name:     "WindowDesc",
+			sql:      "SELECT id, ROW_NUMBER() OVER (ORDER BY v DESC) AS rn FROM null_order ORDER BY id",
+			expected: [][]interface{}{{int64(1), int64(3)}, {int64(2), int64(1)}, {int64(3), int64(2)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result.Rows); diff != "" {
+				t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_DivisionAndRounding tests Snowflake division scale and rounding semantics.
+func TestExecutor_DivisionAndRounding(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{name: "IntegerDivision", sql: "SELECT 1 / 3", expected: "0.333333"},
+		{name: "RoundsLastDigit", sql: "SELECT 2 / 3", expected: "0.666667"},
+		{name: "DecimalDividend", sql: "SELECT 1.00 / 3", expected: "0.33333333"},
+		{name: "FloatDivision", sql: "SELECT 1e0 / 4", expected: "0.25"},
+		{name: "RoundHalfAwayFromZero", sql: "SELECT ROUND(-2.5)", expected: "-3"},
+		{name: "RoundHalfToEven", sql: "SELECT ROUND(2.5, 0, 'HALF_TO_EVEN')", expected: "2"},
+		{name: "RoundHalfToEvenKeepsNumber", sql: "SELECT ROUND(123456789012345678.125, 2, 'HALF_TO_EVEN')", expected: "123456789012345678.12"},
+		{name: "RoundHalfToEvenNegative", sql: "SELECT ROUND(-3.5, 0, 'HALF_TO_EVEN')", expected: "-4"},
+		{name: "ExactBigintDivision", sql: "SELECT b / 1 FROM division_operands", expected: "123456789012345678"},
+		{name: "DoubleColumnDivision", sql: "SELECT d / 3 FROM division_operands", expected: "0.03333333333333333"},
+		{name: "LargeDoubleDivision", sql: "SELECT big / 2 FROM division_operands", expected: "5e+299"},
+		{name: "NullDivision", sql: "SELECT n / 2 FROM division_operands", expected: "<nil>"},
+		{name: "Div0", sql: "SELECT DIV0(b, z) FROM division_operands", expected: "0"},
+		{name: "NullLiteralDivision", sql: "SELECT 1 / NULL", expected: "<nil>"},
+		{name: "TextDividend", sql: "SELECT s / 4 FROM division_operands", expected: "2.5"},
+	}
+
+	_, err := executor.Execute(ctx, "CREATE TABLE division_operands AS SELECT CAST(123456789012345678 AS BIGINT) AS b, CAST(0.1 AS DOUBLE) AS d, "+
+		"CAST(1e300 AS DOUBLE) AS big, CAST(NULL AS INTEGER) AS n, 0 AS z, '10' AS s")
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if got := fmt.Sprint(result.Rows[0][0]); got != tt.expected {
+				t.Errorf("Query() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	for _, sql := range []string{"SELECT b / z FROM division_operands", "SELECT d / z FROM division_operands"} {
+		if _, err := executor.Query(ctx, sql); err == nil || !strings.Contains(err.Error(), "division by zero") {
+			t.Errorf("Query(%q) error = %v, want division by zero", sql, err)
+		}
+	}
+}
+
+// TestExecutor_WeekParameters tests week functions against known Snowflake results.
+func TestExecutor_WeekParameters(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+
+	tests := []struct {
+		name     string
+		params   SessionParameters
+		sql      string
+		expected []interface{}
+	}{
+		{
+			// 2017-01-01 is a Sunday in ISO week 52 of 2016
+			name:     "Defaults",
+			params:   SessionParameters{},
+			sql:      "SELECT DAYOFWEEK('2017-01-01'), WEEK('2017-01-01')",
+			expected: []interface{}{int64(0), int64(52)},
+		},
+		{
+			name:     "MondayStartJanuaryFirstPolicy",
+			params:   SessionParameters{WeekStart: 1, WeekOfYearPolicy: 1},
+			sql:      "SELECT DAYOFWEEK('2017-01-01'), WEEK('2017-01-01'), WEEK('2017-01-02')",
+			expected: []interface{}{int32(7), int64(1), int64(2)},
+		},
+		{
+			name:     "SundayStart",
+			params:   SessionParameters{WeekStart: 7},
+			sql:      "SELECT DAYOFWEEK('2017-01-01'), WEEK('2017-01-01'), DATE_TRUNC('week', '2017-01-04')",
+			expected: []interface{}{int32(1), int64(1), time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			// DATE_TRUNC keeps the type of its argument under any WEEK_START
+			name:     "DateTruncTimestamp",
+			params:   SessionParameters{},
+			sql:      "SELECT DATE_TRUNC('WEEK', '2024-01-10 13:45:00'::TIMESTAMP)::STRING, DATE_TRUNC('WEEK', '2024-01-10'::DATE)::STRING",
+			expected: []interface{}{"2024-01-08 00:00:00", "2024-01-08"},
+		},
+		{
+			name:     "DateTruncTimestampWednesdayStart",
+			params:   SessionParameters{WeekStart: 3},
+			sql:      "SELECT DATE_TRUNC('WEEK', '2024-01-10 13:45:00'::TIMESTAMP)::STRING, DATE_TRUNC('WEEK', '2024-01-09'::DATE)::STRING",
+			expected: []interface{}{"2024-01-10 00:00:00", "2024-01-03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithSessionParameters(context.Background(), tt.params)
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result.Rows[0]); diff != "" {
+				t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_LockTimeout tests that LOCK_TIMEOUT bounds how long an UPDATE waits
+// for a conflicting transaction.
+func TestExecutor_LockTimeout(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	if _, err := executor.Execute(ctx, "CREATE TABLE lock_test (id INTEGER, v INTEGER)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, err := executor.Execute(ctx, "INSERT INTO lock_test VALUES (1, 1)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// Hold an uncommitted update on a separate connection
+	conn, err := executor.mgr.DB().Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if _, err := conn.ExecContext(ctx, "BEGIN TRANSACTION"); err != nil {
+		t.Fatalf("BEGIN error = %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "UPDATE lock_test SET v = 2 WHERE id = 1"); err != nil {
+		t.Fatalf("UPDATE error = %v", err)
+	}
+
+	params := ParseSessionParameters(map[string]string{"LOCK_TIMEOUT": "0"})
+	_, err = executor.Execute(ContextWithSessionParameters(ctx, params), "UPDATE lock_test SET v = 3 WHERE id = 1")
+
+	var lockErr *connection.LockTimeoutError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("Execute() error = %v, want LockTimeoutError", err)
+	}
+	if lockErr.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", lockErr.Attempts)
+	}
+}
+
 // TestExecutor_GetColumnInfo tests column metadata retrieval.
 func TestExecutor_GetColumnInfo(t *testing.T) {
 	executor, repo := setupTestExecutor(t)
@@ -469,6 +993,11 @@ func TestFormatBindingValue(t *testing.T) {
 			binding:  &QueryBindingValue{Type: "TEXT", Value: "it's"},
 			expected: "'it''s'",
 		},
+		{
+			name:     "TextWithBackslash",
+			binding:  &QueryBindingValue{Type: "TEXT", Value: `C:\dir`},
+			expected: `'C:\\dir'`,
+		},
 		{
 			name:     "IntegerValue",
 			binding:  &QueryBindingValue{Type: "FIXED", Value: "123"},
@@ -514,6 +1043,51 @@ func TestFormatBindingValue(t *testing.T) {
 			binding: &QueryBindingValue{Type: "REAL", Value: "not a float"},
 			wantErr: true,
 		},
+		{
+			name:     "FixedDecimal",
+			binding:  &QueryBindingValue{Type: "FIXED", Value: "12.50"},
+			expected: "12.50",
+		},
+		{
+			name:     "RealInfinity",
+			binding:  &QueryBindingValue{Type: "REAL", Value: "inf"},
+			expected: "CAST('inf' AS DOUBLE)",
+		},
+		{
+			name:     "Binary",
+			binding:  &QueryBindingValue{Type: "BINARY", Value: "48656C6C6F"},
+			expected: "from_hex('48656C6C6F')",
+		},
+		{
+			name:    "InvalidBinary",
+			binding: &QueryBindingValue{Type: "BINARY", Value: "zz'"},
+			wantErr: true,
+		},
+		{
+			name:     "Variant",
+			binding:  &QueryBindingValue{Type: "VARIANT", Value: `{"a": "it's"}`},
+			expected: `CAST('{"a": "it''s"}' AS JSON)`,
+		},
+		{
+			name:     "EpochDate",
+			binding:  &QueryBindingValue{Type: "DATE", Value: "1705276800000"},
+			expected: "DATE '2024-01-15'",
+		},
+		{
+			name:     "EpochTime",
+			binding:  &QueryBindingValue{Type: "TIME", Value: "37800000000000"},
+			expected: "TIME '10:30:00.000000000'",
+		},
+		{
+			name:     "EpochTimestampNTZ",
+			binding:  &QueryBindingValue{Type: "TIMESTAMP_NTZ", Value: "1705314600000000000"},
+			expected: "TIMESTAMP '2024-01-15 10:30:00.000000000'",
+		},
+		{
+			name:     "EpochTimestampTZ",
+			binding:  &QueryBindingValue{Type: "TIMESTAMP_TZ", Value: "1705314600000000000 1980"},
+			expected: "TIMESTAMPTZ '2024-01-15 19:30:00.000000000+09:00'",
+		},
 	}
 
 	for _, tt := range tests {
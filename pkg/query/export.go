@@ -0,0 +1,443 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
+)
+
+// ExportFormat is the file format of the table data written by ExportDatabase.
+type ExportFormat string
+
+// Export formats.
+const (
+	ExportFormatCSV     ExportFormat = "CSV"
+	ExportFormatParquet ExportFormat = "PARQUET"
+)
+
+// Files written by ExportDatabase, relative to the export directory.
+const (
+	exportDDLFile  = "ddl.sql"
+	exportLoadFile = "load.sql"
+	exportDataDir  = "data"
+	// exportStage is the temporary stage load.sql uploads the data files to.
+	exportStage = "EMULATOR_EXPORT"
+)
+
+// publicSchema is the schema unqualified tables are created in.
+const publicSchema = "PUBLIC"
+
+// plainIdentifier matches identifiers that Snowflake resolves without quotes.
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// simpleDefault matches column defaults that mean the same in Snowflake:
+// numbers, string literals, booleans, NULL, and the current date and time.
+var simpleDefault = regexp.MustCompile(`(?i)^(-?[0-9]+(\.[0-9]+)?|'([^']|'')*'|TRUE|FALSE|NULL|CURRENT_(TIMESTAMP|DATE|TIME)(\(\))?|now\(\))$`)
+
+// DatabaseExport describes the directory written by ExportDatabase.
+type DatabaseExport struct {
+	Database  string
+	Directory string
+	Format    ExportFormat
+	Tables    []ExportedTable
+	// Skipped lists the objects or parts of objects that were not exported,
+	// with the reason.
+	Skipped []string
+}
+
+// ExportedTable is a table whose DDL and data were exported.
+type ExportedTable struct {
+	Schema string
+	Name   string
+	// File is the table's data file, relative to the export directory.
+	File string
+	Rows int64
+}
+
+// exportColumn is a column of an exported table.
+type exportColumn struct {
+	Name     string
+	Type     string
+	Nullable bool
+	Default  string
+	// Transform anonymizes the column's exported values, if set.
+	Transform ColumnTransform
+}
+
+// exportTable is a table to export and where its rows are stored in DuckDB.
+type exportTable struct {
+	Schema     string
+	Name       string
+	Physical   string
+	Comment    string
+	Columns    []exportColumn
+	PrimaryKey []string
+}
+
+// ParseExportFormat parses an export format name, defaulting to CSV.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch ExportFormat(strings.ToUpper(strings.TrimSpace(name))) {
+	case "", ExportFormatCSV:
+		return ExportFormatCSV, nil
+	case ExportFormatParquet:
+		return ExportFormatParquet, nil
+	}
+	return "", fmt.Errorf("unknown export format %q: must be CSV or PARQUET", name)
+}
+
+// ResolveExportDir resolves dir, relative to root, to the directory an export
+// is written to. Directories that are absolute or leave root are rejected.
+func ResolveExportDir(root, dir string) (string, error) {
+	if !filepath.IsLocal(dir) {
+		return "", fmt.Errorf("export directory %q must be a relative path inside the export root", dir)
+	}
+	return filepath.Join(root, dir), nil
+}
+
+// ExportDatabase writes a database to dir for loading into a Snowflake
+// account: ddl.sql holds GET_DDL-style statements creating the database, its
+// schemas, and its tables; data/SCHEMA/TABLE.csv.gz or .parquet holds each
+// table's rows; and load.sql uploads and copies the data files into the tables
+// when run with SnowSQL from dir. Tables created without a schema belong to
+// the database's PUBLIC schema. Views are skipped, since DuckDB keeps their
+// translated definitions rather than their Snowflake SQL. Columns can be
+// anonymized with WithColumnTransforms, hashing with WithHashSalt's salt.
+func (e *Executor) ExportDatabase(ctx context.Context, database, dir string, format ExportFormat, opts ...ExportOption) (*DatabaseExport, error) {
+	var options exportOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	db, err := e.repo.GetDatabaseByName(ctx, strings.ToUpper(database))
+	if err != nil {
+		return nil, fmt.Errorf("database %s does not exist", strings.ToUpper(database))
+	}
+	schemas, err := e.repo.ListSchemas(ctx, db.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &DatabaseExport{Database: db.Name, Directory: dir, Format: format}
+	schemaComments := map[string]string{publicSchema: ""}
+	schemaNames := []string{publicSchema}
+	for _, schema := range schemas {
+		if _, ok := schemaComments[schema.Name]; !ok {
+			schemaNames = append(schemaNames, schema.Name)
+		}
+		schemaComments[schema.Name] = schema.Comment
+	}
+
+	var tables []*exportTable
+	for _, schema := range schemaNames {
+		found, skipped, err := e.exportTables(ctx, db, schema)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, found...)
+		export.Skipped = append(export.Skipped, skipped...)
+	}
+	// Schema and table names become paths of data files, which must stay in dir
+	for _, table := range tables {
+		if !exportFileName(table.Schema) || !exportFileName(table.Name) {
+			return nil, fmt.Errorf("cannot export table %s.%s: its name is not a valid file name", table.Schema, table.Name)
+		}
+	}
+	if err := applyColumnTransforms(tables, options.transforms); err != nil {
+		return nil, err
+	}
+	if options.hashSalt == "" {
+		if options.hashSalt, err = randomHashSalt(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	// Data files first, so that the DDL is only written for complete exports
+	for _, table := range tables {
+		exported, err := e.exportTableData(ctx, table, dir, format, options.hashSalt)
+		if err != nil {
+			return nil, err
+		}
+		export.Tables = append(export.Tables, *exported)
+	}
+
+	var ddl strings.Builder
+	fmt.Fprintf(&ddl, "create or replace database %s%s;\n", snowflakeIdentifier(db.Name), commentClause(db.Comment))
+	for _, schema := range schemaNames {
+		if schema == publicSchema && !hasSchema(tables, schema) && schemaComments[schema] == "" {
+			continue
+		}
+		fmt.Fprintf(&ddl, "\ncreate or replace schema %s.%s%s;\n", snowflakeIdentifier(db.Name), snowflakeIdentifier(schema), commentClause(schemaComments[schema]))
+	}
+	for _, table := range tables {
+		ddl.WriteString("\n" + tableDDL(db.Name, table, &export.Skipped))
+	}
+	if err := os.WriteFile(filepath.Join(dir, exportDDLFile), []byte(ddl.String()), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", exportDDLFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, exportLoadFile), []byte(loadScript(db.Name, export)), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", exportLoadFile, err)
+	}
+	return export, nil
+}
+
+// exportTables lists the tables of a schema of db with their columns, as
+// schemaTables finds them. It also returns the views it skips.
+func (e *Executor) exportTables(ctx context.Context, db *metadata.Database, schema string) ([]*exportTable, []string, error) {
+	found, err := e.schemaTables(ctx, db, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tables []*exportTable
+	for _, table := range found {
+		exported := &exportTable{
+			Schema:   schema,
+			Name:     table.Name,
+			Physical: table.Physical,
+			Comment:  table.Comment,
+		}
+		if table.Registered == nil {
+			if err := e.physicalColumns(ctx, table.PhysicalSchema, exported); err != nil {
+				return nil, nil, err
+			}
+			tables = append(tables, exported)
+			continue
+		}
+
+		// Tables created through the REST API keep their Snowflake column types
+		for _, col := range metadata.ParseColumnDefs(table.Registered.ColumnDefinitions) {
+			column := exportColumn{Name: col.Name, Type: col.Type, Nullable: col.Nullable}
+			if col.Default != nil {
+				column.Default = *col.Default
+			}
+			exported.Columns = append(exported.Columns, column)
+			if col.PrimaryKey {
+				exported.PrimaryKey = append(exported.PrimaryKey, col.Name)
+			}
+		}
+		tables = append(tables, exported)
+	}
+
+	var skipped []string
+	viewRows, err := e.manager(ctx).Query(ctx, `SELECT view_name FROM duckdb_views()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND NOT internal ORDER BY view_name`, physicalSchemaName(schema))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list views of schema %s: %w", schema, err)
+	}
+	for viewRows.Next() {
+		var name string
+		if err := viewRows.Scan(&name); err != nil {
+			_ = viewRows.Close()
+			return nil, nil, err
+		}
+		skipped = append(skipped, fmt.Sprintf("view %s.%s: its Snowflake definition is not kept", schema, name))
+	}
+	_ = viewRows.Close()
+	return tables, skipped, nil
+}
+
+// tableExists reports whether DuckDB has a table in the given schema.
+func (e *Executor) tableExists(ctx context.Context, schema, name string) bool {
+	var count int
+	err := e.manager(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM duckdb_tables()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND lower(table_name) = lower(?)`, schema, name).Scan(&count)
+	return err == nil && count > 0
+}
+
+// physicalColumns reads the columns and primary key of a table created with
+// SQL, mapping their DuckDB types to Snowflake types.
+func (e *Executor) physicalColumns(ctx context.Context, schema string, table *exportTable) error {
+	rows, err := e.manager(ctx).Query(ctx, `SELECT column_name, data_type, is_nullable, column_default FROM duckdb_columns()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND table_name = ?
+		ORDER BY column_index`, schema, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table.Name, err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var column exportColumn
+		var duckType string
+		var columnDefault sql.NullString
+		if err := rows.Scan(&column.Name, &duckType, &column.Nullable, &columnDefault); err != nil {
+			return err
+		}
+		column.Type = snowflakeColumnType(duckType)
+		column.Default = columnDefault.String
+		table.Columns = append(table.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var primaryKey []interface{}
+	err = e.manager(ctx).QueryRow(ctx, `SELECT constraint_column_names FROM duckdb_constraints()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND table_name = ? AND constraint_type = 'PRIMARY KEY'`,
+		schema, table.Name).Scan(&primaryKey)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read primary key of %s: %w", table.Name, err)
+	}
+	for _, name := range primaryKey {
+		table.PrimaryKey = append(table.PrimaryKey, fmt.Sprint(name))
+	}
+	return nil
+}
+
+// exportTableData writes a table's rows to its data file with DuckDB's COPY,
+// hashing values with salt.
+func (e *Executor) exportTableData(ctx context.Context, table *exportTable, dir string, format ExportFormat, salt string) (*ExportedTable, error) {
+	file := filepath.Join(exportDataDir, table.Schema, table.Name+".csv.gz")
+	options := "FORMAT CSV, HEADER, COMPRESSION GZIP"
+	if format == ExportFormatParquet {
+		file = filepath.Join(exportDataDir, table.Schema, table.Name+".parquet")
+		options = "FORMAT PARQUET"
+	}
+	path := filepath.Join(dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	result, err := e.manager(ctx).Exec(ctx, fmt.Sprintf("COPY (SELECT %s FROM %s) TO %s (%s)", exportSelectList(table, salt), table.Physical, quoteLiteral(path), options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to export table %s.%s: %w", table.Schema, table.Name, err)
+	}
+	rows, _ := result.RowsAffected()
+	return &ExportedTable{Schema: table.Schema, Name: table.Name, File: filepath.ToSlash(file), Rows: rows}, nil
+}
+
+// tableDDL renders a CREATE TABLE statement like GET_DDL's. Column defaults
+// that may not mean the same in Snowflake are left out and noted in skipped.
+func tableDDL(database string, table *exportTable, skipped *[]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "create or replace TABLE %s.%s.%s (\n", snowflakeIdentifier(database), snowflakeIdentifier(table.Schema), snowflakeIdentifier(table.Name))
+	for i, col := range table.Columns {
+		fmt.Fprintf(&b, "\t%s %s", snowflakeIdentifier(col.Name), col.Type)
+		if !col.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		switch {
+		case col.Default == "":
+		case simpleDefault.MatchString(col.Default):
+			fmt.Fprintf(&b, " DEFAULT %s", snowflakeDefault(col.Default))
+		default:
+			*skipped = append(*skipped, fmt.Sprintf("default of column %s.%s.%s: %s", table.Schema, table.Name, col.Name, col.Default))
+		}
+		if i < len(table.Columns)-1 || len(table.PrimaryKey) > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	if len(table.PrimaryKey) > 0 {
+		keys := make([]string, len(table.PrimaryKey))
+		for i, key := range table.PrimaryKey {
+			keys[i] = snowflakeIdentifier(key)
+		}
+		fmt.Fprintf(&b, "\tprimary key (%s)\n", strings.Join(keys, ", "))
+	}
+	fmt.Fprintf(&b, ")%s;\n", commentClause(table.Comment))
+	return b.String()
+}
+
+// loadScript renders the SnowSQL script that uploads the data files to a
+// temporary stage and copies them into the tables created by ddl.sql.
+func loadScript(database string, export *DatabaseExport) string {
+	stage := snowflakeIdentifier(database) + "." + publicSchema + "." + exportStage
+	fileFormat := "type = csv skip_header = 1 field_optionally_enclosed_by = '\"' empty_field_as_null = true"
+	copyOptions := ""
+	if export.Format == ExportFormatParquet {
+		fileFormat = "type = parquet"
+		copyOptions = " match_by_column_name = case_insensitive"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Loads the data files of this export into the tables created by %s.\n", exportDDLFile)
+	fmt.Fprintf(&b, "-- Run with SnowSQL from the export directory after %s.\n", exportDDLFile)
+	fmt.Fprintf(&b, "create or replace temporary stage %s;\n", stage)
+	for _, table := range export.Tables {
+		dir := filepath.ToSlash(filepath.Dir(table.File))
+		fmt.Fprintf(&b, "\nput file://%s @%s/%s auto_compress = false overwrite = true;\n", table.File, stage, dir)
+		fmt.Fprintf(&b, "copy into %s.%s.%s from @%s/%s file_format = (%s)%s;\n",
+			snowflakeIdentifier(database), snowflakeIdentifier(table.Schema), snowflakeIdentifier(table.Name),
+			stage, table.File, fileFormat, copyOptions)
+	}
+	return b.String()
+}
+
+// snowflakeColumnType converts a DuckDB column type to the Snowflake type
+// declaration that holds its values, keeping the precision of decimals.
+func snowflakeColumnType(duckType string) string {
+	mapping := sftypes.FromDuckDBType(duckType)
+	switch mapping.Type {
+	case sftypes.TypeNumber:
+		var precision, scale int
+		if _, err := fmt.Sscanf(strings.ToUpper(duckType), "DECIMAL(%d,%d)", &precision, &scale); err == nil {
+			return fmt.Sprintf("NUMBER(%d,%d)", precision, scale)
+		}
+		return "NUMBER(38,0)"
+	}
+	return string(mapping.Type)
+}
+
+// snowflakeDefault renders a simple DuckDB column default in Snowflake SQL.
+func snowflakeDefault(value string) string {
+	upper := strings.ToUpper(value)
+	switch {
+	case upper == "NOW()" || strings.HasPrefix(upper, "CURRENT_TIMESTAMP"):
+		return "CURRENT_TIMESTAMP()"
+	case strings.HasPrefix(upper, "CURRENT_DATE"):
+		return "CURRENT_DATE()"
+	case strings.HasPrefix(upper, "CURRENT_TIME"):
+		return "CURRENT_TIME()"
+	}
+	return value
+}
+
+// snowflakeIdentifier renders a name so that Snowflake resolves it to the same
+// object: plain identifiers unquoted, so that they resolve case-insensitively
+// like the emulator's, and other names quoted.
+func snowflakeIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return strings.ToUpper(name)
+	}
+	return quoteIdent(name)
+}
+
+// quoteIdent quotes a name as an identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// commentClause renders a COMMENT clause, or "" for an empty comment.
+func commentClause(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return " comment=" + quoteLiteral(comment)
+}
+
+// exportFileName reports whether a schema or table name can name a file of an
+// export, staying in the directory it is joined to.
+func exportFileName(name string) bool {
+	return filepath.IsLocal(name) && !strings.ContainsAny(name, `/\`)
+}
+
+// hasSchema reports whether any table belongs to schema.
+func hasSchema(tables []*exportTable, schema string) bool {
+	for _, table := range tables {
+		if table.Schema == schema {
+			return true
+		}
+	}
+	return false
+}
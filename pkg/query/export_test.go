@@ -0,0 +1,400 @@
+package query
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// TestExecutor_ExportDatabase tests exporting a database as Snowflake DDL,
+// gzipped CSV data files, and a load script.
+func TestExecutor_ExportDatabase(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	db, err := repo.CreateDatabase(ctx, "SALES", "Sales data")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "SALES", Schema: "PUBLIC"})
+	statements := []string{
+		"CREATE SCHEMA SALES.RAW COMMENT = 'Raw data'",
+		"CREATE TABLE SALES.RAW.ORDERS (id INTEGER PRIMARY KEY, amount NUMBER(10,2) NOT NULL, " +
+			"note VARCHAR DEFAULT 'none', ordered_at TIMESTAMP_NTZ DEFAULT CURRENT_TIMESTAMP(), attrs VARIANT)",
+		`INSERT INTO SALES.RAW.ORDERS VALUES (1, 9.5, '', '2024-01-02 03:04:05', PARSE_JSON('{"rush":true}')), ` +
+			"(2, 20, NULL, '2024-01-03 00:00:00', NULL)",
+		`CREATE TABLE events (id INT, "Event Name" VARCHAR)`,
+		"CREATE VIEW SALES.RAW.LARGE_ORDERS AS SELECT * FROM SALES.RAW.ORDERS WHERE amount > 10",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	// Tables created through the REST API are exported with their Snowflake types
+	raw, err := repo.GetSchemaByName(ctx, db.ID, "RAW")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	columns := []metadata.ColumnDef{{Name: "CODE", Type: "VARCHAR", PrimaryKey: true}, {Name: "RATE", Type: "FLOAT", Nullable: true}}
+	if _, err := repo.CreateTable(ctx, raw.ID, "CURRENCIES", columns, "Exchange rates"); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "export")
+	export, err := executor.ExportDatabase(ctx, "sales", dir, ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("ExportDatabase() error = %v", err)
+	}
+
+	expectedTables := []ExportedTable{
+		{Schema: "PUBLIC", Name: "events", File: "data/PUBLIC/events.csv.gz", Rows: 0},
+		{Schema: "RAW", Name: "ORDERS", File: "data/RAW/ORDERS.csv.gz", Rows: 2},
+		{Schema: "RAW", Name: "CURRENCIES", File: "data/RAW/CURRENCIES.csv.gz", Rows: 0},
+	}
+	if diff := cmp.Diff(expectedTables, export.Tables); diff != "" {
+		t.Errorf("exported tables mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"view RAW.LARGE_ORDERS: its Snowflake definition is not kept"}, export.Skipped); diff != "" {
+		t.Errorf("skipped objects mismatch (-want +got):\n%s", diff)
+	}
+
+	expectedDDL := `create or replace database SALES comment='Sales data';
+
+create or replace schema SALES.PUBLIC;
+
+create or replace schema SALES.RAW comment='Raw data';
+
+create or replace TABLE SALES.PUBLIC.EVENTS (
+	ID NUMBER(38,0),
+	"Event Name" VARCHAR
+);
+
+create or replace TABLE SALES.RAW.ORDERS (
+	ID NUMBER(38,0) NOT NULL,
+	AMOUNT NUMBER(10,2) NOT NULL,
+	NOTE VARCHAR DEFAULT 'none',
+	ORDERED_AT TIMESTAMP_NTZ DEFAULT CURRENT_TIMESTAMP(),
+	ATTRS VARIANT,
+	primary key (ID)
+);
+
+create or replace TABLE SALES.RAW.CURRENCIES (
+	CODE VARCHAR NOT NULL,
+	RATE FLOAT,
+	primary key (CODE)
+) comment='Exchange rates';
+`
+	if diff := cmp.Diff(expectedDDL, readExportFile(t, dir, "ddl.sql")); diff != "" {
+		t.Errorf("ddl.sql mismatch (-want +got):\n%s", diff)
+	}
+
+	load := readExportFile(t, dir, "load.sql")
+	for _, want := range []string{
+		"create or replace temporary stage SALES.PUBLIC.EMULATOR_EXPORT;",
+		"put file://data/RAW/ORDERS.csv.gz @SALES.PUBLIC.EMULATOR_EXPORT/data/RAW auto_compress = false overwrite = true;",
+		"copy into SALES.RAW.ORDERS from @SALES.PUBLIC.EMULATOR_EXPORT/data/RAW/ORDERS.csv.gz " +
+			`file_format = (type = csv skip_header = 1 field_optionally_enclosed_by = '"' empty_field_as_null = true);`,
+	} {
+		if !strings.Contains(load, want) {
+			t.Errorf("load.sql does not contain %q:\n%s", want, load)
+		}
+	}
+
+	// Empty strings are quoted so that they load as '' rather than NULL
+	file, err := os.Open(filepath.Join(dir, "data", "RAW", "ORDERS.csv.gz"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	expectedCSV := "id,amount,note,ordered_at,attrs\n" +
+		`1,9.50,"",2024-01-02 03:04:05,"{""rush"":true}"` + "\n" +
+		"2,20.00,,2024-01-03 00:00:00,\n"
+	if diff := cmp.Diff(expectedCSV, string(data)); diff != "" {
+		t.Errorf("ORDERS data mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_ExportDatabaseParquet tests exporting table data as Parquet.
+func TestExecutor_ExportDatabaseParquet(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+	if _, err := repo.CreateDatabase(ctx, "SALES", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for _, sql := range []string{"CREATE TABLE orders (id INTEGER)", "INSERT INTO orders VALUES (1), (2), (3)"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	dir := t.TempDir()
+	export, err := executor.ExportDatabase(ctx, "SALES", dir, ExportFormatParquet)
+	if err != nil {
+		t.Fatalf("ExportDatabase() error = %v", err)
+	}
+	if diff := cmp.Diff([]ExportedTable{{Schema: "PUBLIC", Name: "orders", File: "data/PUBLIC/orders.parquet", Rows: 3}}, export.Tables); diff != "" {
+		t.Errorf("exported tables mismatch (-want +got):\n%s", diff)
+	}
+
+	result, err := executor.Query(ctx, "SELECT COUNT(*) FROM read_parquet("+quoteLiteral(filepath.Join(dir, "data", "PUBLIC", "orders.parquet"))+")")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(3)}}, result.Rows); diff != "" {
+		t.Errorf("Parquet rows mismatch (-want +got):\n%s", diff)
+	}
+	if load := readExportFile(t, dir, "load.sql"); !strings.Contains(load, "file_format = (type = parquet) match_by_column_name = case_insensitive;") {
+		t.Errorf("load.sql does not copy Parquet files:\n%s", load)
+	}
+
+	if _, err := executor.ExportDatabase(ctx, "MISSING", dir, ExportFormatCSV); err == nil {
+		t.Error("ExportDatabase() of a missing database succeeded")
+	}
+}
+
+// TestExecutor_ExportDatabaseUnsafeNames tests that tables whose schema or
+// table names would place their data files outside the export directory are
+// rejected before anything is written.
+func TestExecutor_ExportDatabaseUnsafeNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		table  string
+	}{
+		{name: "ParentTable", schema: "PUBLIC", table: `"../../x"`},
+		{name: "SeparatorTable", schema: "PUBLIC", table: `"a/b"`},
+		{name: "ParentSchema", schema: "..", table: "t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, repo := setupTestExecutor(t)
+			ctx := context.Background()
+			db, err := repo.CreateDatabase(ctx, "SALES", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			if tt.schema != "PUBLIC" {
+				if _, err := repo.CreateSchema(ctx, db.ID, tt.schema, ""); err != nil {
+					t.Fatalf("CreateSchema() error = %v", err)
+				}
+			}
+			ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "SALES", Schema: "PUBLIC"})
+			statements := []string{"CREATE TABLE SALES." + quoteIdent(tt.schema) + "." + tt.table + " (id INTEGER)"}
+			if tt.schema != "PUBLIC" {
+				statements = append([]string{"CREATE SCHEMA IF NOT EXISTS " + quoteIdent(tt.schema)}, statements...)
+			}
+			for _, sql := range statements {
+				if _, err := executor.Execute(ctx, sql); err != nil {
+					t.Fatalf("Execute(%q) error = %v", sql, err)
+				}
+			}
+
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "export", "sales")
+			if _, err := executor.ExportDatabase(ctx, "SALES", dir, ExportFormatCSV); err == nil || !strings.Contains(err.Error(), "not a valid file name") {
+				t.Fatalf("ExportDatabase() error = %v, want an invalid file name error", err)
+			}
+			entries, err := os.ReadDir(parent)
+			if err != nil {
+				t.Fatalf("ReadDir() error = %v", err)
+			}
+			if len(entries) > 0 {
+				t.Errorf("ExportDatabase() wrote %d entries, want none", len(entries))
+			}
+		})
+	}
+}
+
+// TestResolveExportDir tests resolving export directories under the export root.
+func TestResolveExportDir(t *testing.T) {
+	root := filepath.Join("srv", "exports")
+	tests := []struct {
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{dir: "sales", want: filepath.Join(root, "sales")},
+		{dir: "nightly/sales", want: filepath.Join(root, "nightly", "sales")},
+		{dir: "", wantErr: true},
+		{dir: "..", wantErr: true},
+		{dir: "../sales", wantErr: true},
+		{dir: "nightly/../../sales", wantErr: true},
+		{dir: "/tmp/sales", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got, err := ResolveExportDir(root, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveExportDir(%q) error = %v, wantErr %v", tt.dir, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveExportDir(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExecutor_ExportDatabaseTransforms tests anonymizing columns of the
+// exported data with hash, mask, and null-out transforms.
+func TestExecutor_ExportDatabaseTransforms(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+	if _, err := repo.CreateDatabase(ctx, "SALES", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for _, sql := range []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, email VARCHAR NOT NULL, age INTEGER NOT NULL, note VARCHAR)",
+		"INSERT INTO customers VALUES (1, 'alice@example.com', 34, 'VIP-2024'), (2, 'bob@example.org', 41, NULL)",
+		"CREATE TABLE orders (customer_id INTEGER, amount INTEGER)",
+		"INSERT INTO orders VALUES (1, 10), (2, 20)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	dir := t.TempDir()
+	transforms := map[string]map[string]ColumnTransform{
+		"CUSTOMERS":     {"id": ColumnTransformHash, "EMAIL": ColumnTransformMask, "age": ColumnTransformNull, "note": ColumnTransformMask},
+		"public.orders": {"customer_id": ColumnTransformHash},
+	}
+	if _, err := executor.ExportDatabase(ctx, "SALES", dir, ExportFormatParquet, WithColumnTransforms(transforms)); err != nil {
+		t.Fatalf("ExportDatabase() error = %v", err)
+	}
+
+	file := func(table string) string {
+		return quoteLiteral(filepath.Join(dir, "data", "PUBLIC", table+".parquet"))
+	}
+	result, err := executor.Query(ctx, "SELECT c.email, c.age, c.note, o.amount, length(c.id) FROM read_parquet("+file("customers")+") c "+
+		"JOIN read_parquet("+file("orders")+") o ON o.customer_id = c.id ORDER BY o.amount")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := [][]interface{}{
+		{"*****@*******.***", nil, "***-****", int64(10), int64(64)},
+		{"***@*******.***", nil, nil, int64(20), int64(64)},
+	}
+	if diff := cmp.Diff(want, result.Rows); diff != "" {
+		t.Errorf("transformed rows mismatch (-want +got):\n%s", diff)
+	}
+
+	// Hashes are salted, with a random salt per export unless one is given
+	hashes := func(dir string) []interface{} {
+		t.Helper()
+		result, err := executor.Query(ctx, "SELECT id, id = sha256('1') FROM read_parquet("+quoteLiteral(filepath.Join(dir, "data", "PUBLIC", "customers.parquet"))+") ORDER BY email")
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if result.Rows[0][1] != false {
+			t.Errorf("hash of 1 is the unsalted sha256('1')")
+		}
+		return result.Rows[0]
+	}
+	otherDir := t.TempDir()
+	if _, err := executor.ExportDatabase(ctx, "SALES", otherDir, ExportFormatParquet, WithColumnTransforms(transforms)); err != nil {
+		t.Fatalf("ExportDatabase() error = %v", err)
+	}
+	if first, second := hashes(dir), hashes(otherDir); first[0] == second[0] {
+		t.Errorf("exports with random salts hashed 1 alike: %v", first[0])
+	}
+	saltedDir := t.TempDir()
+	if _, err := executor.ExportDatabase(ctx, "SALES", saltedDir, ExportFormatParquet, WithColumnTransforms(transforms), WithHashSalt("pepper")); err != nil {
+		t.Fatalf("ExportDatabase() error = %v", err)
+	}
+	result, err = executor.Query(ctx, "SELECT sha256('pepper1')")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if got := hashes(saltedDir)[0]; got != result.Rows[0][0] {
+		t.Errorf("hash of 1 with salt pepper = %v, want %v", got, result.Rows[0][0])
+	}
+
+	// Hashed columns become VARCHAR and nulled columns nullable; masked text keeps its type
+	ddl := readExportFile(t, dir, "ddl.sql")
+	for _, want := range []string{"\tID VARCHAR NOT NULL,", "\tEMAIL VARCHAR NOT NULL,", "\tAGE NUMBER(38,0),", "\tNOTE VARCHAR,", "\tCUSTOMER_ID VARCHAR,"} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("ddl.sql does not contain %q:\n%s", want, ddl)
+		}
+	}
+
+	for _, tt := range []struct {
+		name       string
+		transforms map[string]map[string]ColumnTransform
+	}{
+		{name: "MissingTable", transforms: map[string]map[string]ColumnTransform{"RAW.CUSTOMERS": {"EMAIL": ColumnTransformMask}}},
+		{name: "MissingColumn", transforms: map[string]map[string]ColumnTransform{"CUSTOMERS": {"PHONE": ColumnTransformMask}}},
+		{name: "NullPrimaryKey", transforms: map[string]map[string]ColumnTransform{"CUSTOMERS": {"ID": ColumnTransformNull}}},
+		{name: "MaskPrimaryKey", transforms: map[string]map[string]ColumnTransform{"CUSTOMERS": {"ID": ColumnTransformMask}}},
+	} {
+		if _, err := executor.ExportDatabase(ctx, "SALES", t.TempDir(), ExportFormatCSV, WithColumnTransforms(tt.transforms)); err == nil {
+			t.Errorf("%s: ExportDatabase() succeeded", tt.name)
+		}
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ExportFormat
+		wantErr bool
+	}{
+		{name: "", want: ExportFormatCSV},
+		{name: "csv", want: ExportFormatCSV},
+		{name: "Parquet", want: ExportFormatParquet},
+		{name: "json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseExportFormat(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseExportFormat(%q) = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// readExportFile reads a file of an export directory.
+func readExportFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("ReadFile(%s) error = %v", name, err)
+	}
+	return string(data)
+}
+
+func TestParseColumnTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ColumnTransform
+		wantErr bool
+	}{
+		{name: "hash", want: ColumnTransformHash},
+		{name: "Mask", want: ColumnTransformMask},
+		{name: "NULL", want: ColumnTransformNull},
+		{name: "redact", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseColumnTransform(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseColumnTransform(%q) = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
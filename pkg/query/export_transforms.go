@@ -0,0 +1,195 @@
+package query
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ColumnTransform anonymizes the values of a column in the data files written
+// by ExportDatabase, so that seeded data can be shared as fixtures.
+type ColumnTransform string
+
+// Column transforms.
+const (
+	// ColumnTransformHash replaces values with the hex SHA-256 digest of their
+	// text prefixed with the export's salt, so that equal values, such as the
+	// keys joining tables, stay equal, while values cannot be recovered by
+	// hashing guesses without the salt.
+	ColumnTransformHash ColumnTransform = "HASH"
+	// ColumnTransformMask replaces the letters and digits of values' text with
+	// *, keeping their length and punctuation. Distinct values may mask alike,
+	// so primary key columns cannot be masked.
+	ColumnTransformMask ColumnTransform = "MASK"
+	// ColumnTransformNull replaces values with NULL.
+	ColumnTransformNull ColumnTransform = "NULL"
+)
+
+// ParseColumnTransform parses a column transform name.
+func ParseColumnTransform(name string) (ColumnTransform, error) {
+	switch transform := ColumnTransform(strings.ToUpper(strings.TrimSpace(name))); transform {
+	case ColumnTransformHash, ColumnTransformMask, ColumnTransformNull:
+		return transform, nil
+	}
+	return "", fmt.Errorf("unknown column transform %q: must be HASH, MASK, or NULL", name)
+}
+
+// ExportOption configures ExportDatabase.
+type ExportOption func(*exportOptions)
+
+// exportOptions are the options of an ExportDatabase call.
+type exportOptions struct {
+	transforms map[string]map[string]ColumnTransform
+	hashSalt   string
+}
+
+// WithColumnTransforms anonymizes columns of the exported data. transforms
+// maps tables, named TABLE in the PUBLIC schema or SCHEMA.TABLE, to the
+// transforms of their columns; names match case-insensitively. Hashed columns
+// are exported as VARCHAR, as are masked columns that are not text, and nulled
+// columns as nullable, so that load.sql loads the transformed files.
+func WithColumnTransforms(transforms map[string]map[string]ColumnTransform) ExportOption {
+	return func(o *exportOptions) {
+		o.transforms = transforms
+	}
+}
+
+// WithHashSalt sets the salt of the values hashed by ColumnTransformHash. By
+// default each export hashes with a random salt, which is not written to the
+// export, so that its hashes cannot be matched with those of other exports. A
+// fixed salt, kept secret, hashes values alike in every export, e.g. to join
+// the fixtures of several exports.
+func WithHashSalt(salt string) ExportOption {
+	return func(o *exportOptions) {
+		o.hashSalt = salt
+	}
+}
+
+// randomHashSalt returns a random salt for hashing the values of an export.
+func randomHashSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate hash salt: %w", err)
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+// applyColumnTransforms sets the transforms of the exported tables' columns
+// and adjusts their types to the transformed values. Transforms of tables or
+// columns that are not exported are errors, so that misspelled names do not
+// leak the data they were meant to hide.
+func applyColumnTransforms(tables []*exportTable, transforms map[string]map[string]ColumnTransform) error {
+	// Sorted, so that the first error is the same on every run
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema, tableName, ok := strings.Cut(name, ".")
+		if !ok {
+			schema, tableName = publicSchema, name
+		}
+		var table *exportTable
+		for _, t := range tables {
+			if strings.EqualFold(t.Schema, schema) && strings.EqualFold(t.Name, tableName) {
+				table = t
+				break
+			}
+		}
+		if table == nil {
+			return fmt.Errorf("cannot transform columns of %s: no such table is exported", name)
+		}
+
+		for columnName, transform := range transforms[name] {
+			column := table.column(columnName)
+			if column == nil {
+				return fmt.Errorf("cannot transform column %s of %s: no such column", columnName, name)
+			}
+			switch transform {
+			case ColumnTransformHash:
+				column.Type = "VARCHAR"
+			case ColumnTransformMask:
+				if table.isPrimaryKey(column.Name) {
+					return fmt.Errorf("cannot mask column %s of %s: it is part of the primary key", column.Name, name)
+				}
+				if !isTextType(column.Type) {
+					column.Type = "VARCHAR"
+				}
+			case ColumnTransformNull:
+				if table.isPrimaryKey(column.Name) {
+					return fmt.Errorf("cannot null out column %s of %s: it is part of the primary key", column.Name, name)
+				}
+				column.Nullable = true
+			default:
+				return fmt.Errorf("unknown column transform %q", transform)
+			}
+			column.Transform = transform
+		}
+	}
+	return nil
+}
+
+// column returns the column of a table with a name, matched case-insensitively.
+func (t *exportTable) column(name string) *exportColumn {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// isPrimaryKey reports whether a column is part of a table's primary key.
+func (t *exportTable) isPrimaryKey(name string) bool {
+	for _, key := range t.PrimaryKey {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTextType reports whether a Snowflake column type holds text.
+func isTextType(typ string) bool {
+	typ = strings.ToUpper(typ)
+	for _, prefix := range []string{"VARCHAR", "STRING", "TEXT", "CHAR", "NVARCHAR", "NCHAR"} {
+		if strings.HasPrefix(typ, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// exportSelectList returns the select list reading a table's rows for export,
+// with its columns' transforms applied, or * if it has none. Hashed values are
+// prefixed with salt.
+func exportSelectList(table *exportTable, salt string) string {
+	transformed := false
+	for _, column := range table.Columns {
+		transformed = transformed || column.Transform != ""
+	}
+	if !transformed {
+		return "*"
+	}
+
+	items := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		name := quoteIdent(column.Name)
+		switch column.Transform {
+		case ColumnTransformHash:
+			items[i] = fmt.Sprintf("sha256(%s || CAST(%s AS VARCHAR)) AS %s", quoteLiteral(salt), name, name)
+		case ColumnTransformMask:
+			items[i] = fmt.Sprintf("regexp_replace(CAST(%s AS VARCHAR), '[\\p{L}\\p{N}]', '*', 'g') AS %s", name, name)
+		case ColumnTransformNull:
+			// CASE keeps the column's type, which Parquet files record
+			items[i] = fmt.Sprintf("CASE WHEN false THEN %s END AS %s", name, name)
+		default:
+			items[i] = name
+		}
+	}
+	return strings.Join(items, ", ")
+}
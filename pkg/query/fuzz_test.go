@@ -0,0 +1,106 @@
+package query
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// maxParseDuration bounds how long translating or parsing one fuzzed statement
+// may take. Inputs are short, so anything close to it means runaway work.
+const maxParseDuration = time.Second
+
+// addTestStatementSeeds adds the SQL string literals of this package's tests
+// whose first keyword is one of keywords to the seed corpus of f, so that the
+// corpus grows with the tests.
+func addTestStatementSeeds(f *testing.F, keywords ...string) {
+	f.Helper()
+
+	files, err := filepath.Glob("*_test.go")
+	if err != nil {
+		f.Fatalf("failed to list test files: %v", err)
+	}
+	want := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		want[keyword] = true
+	}
+
+	fset := token.NewFileSet()
+	seeds := 0
+	for _, file := range files {
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			f.Fatalf("failed to parse %s: %v", file, err)
+		}
+		ast.Inspect(parsed, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			sql, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+			if keywords := statementKeywords(sql); len(keywords) > 0 && want[keywords[0]] {
+				f.Add(sql)
+				seeds++
+			}
+			return true
+		})
+	}
+	if seeds == 0 {
+		f.Fatalf("no seed statements found for %v", keywords)
+	}
+}
+
+// checkDuration fails t if the work started at start took too long.
+func checkDuration(t *testing.T, start time.Time, sql string) {
+	t.Helper()
+	if elapsed := time.Since(start); elapsed > maxParseDuration {
+		t.Errorf("took %v for %q", elapsed, sql)
+	}
+}
+
+func FuzzTranslate(f *testing.F) {
+	addTestStatementSeeds(f, "SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "ALTER", "DROP", "SHOW")
+	f.Add("SELECT IFF(")
+	f.Add("SELECT CAST(x AS")
+	f.Add("SELECT 'unterminated")
+
+	translator := NewTranslator()
+	f.Fuzz(func(t *testing.T, sql string) {
+		start := time.Now()
+		_, _ = translator.Translate(sql)
+		checkDuration(t, start, sql)
+	})
+}
+
+func FuzzParseMergeStatement(f *testing.F) {
+	addTestStatementSeeds(f, "MERGE")
+	f.Add("MERGE INTO t USING s ON (")
+	f.Add("MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND THEN")
+
+	processor := NewMergeProcessor(nil)
+	f.Fuzz(func(t *testing.T, sql string) {
+		start := time.Now()
+		_, _ = processor.ParseMergeStatement(sql)
+		checkDuration(t, start, sql)
+	})
+}
+
+func FuzzParseCopyStatement(f *testing.F) {
+	addTestStatementSeeds(f, "COPY")
+	f.Add("COPY INTO t FROM @s FILE_FORMAT = (")
+	f.Add("COPY INTO t FROM @s PATTERN = '")
+
+	processor := NewCopyProcessor(nil, nil, nil)
+	f.Fuzz(func(t *testing.T, sql string) {
+		start := time.Now()
+		_, _ = processor.ParseCopyStatement(sql)
+		checkDuration(t, start, sql)
+	})
+}
@@ -0,0 +1,221 @@
+package query
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"sync"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+	"github.com/google/uuid"
+)
+
+// generatorFunctions maps the Snowflake data generation functions the emulator
+// supports to the DuckDB functions registered for them.
+var generatorFunctions = map[string]string{
+	"RANDOM":  "snowflake_random",
+	"UNIFORM": "snowflake_uniform",
+	"NORMAL":  "snowflake_normal",
+	"ZIPF":    "snowflake_zipf",
+}
+
+// Streams of the generators, so that NORMAL, UNIFORM, and ZIPF draw different
+// values from the same generator value, and from a RANDOM seed.
+const (
+	randomStream uint64 = iota + 1
+	uniformStream
+	normalStream
+	zipfStream
+)
+
+// configureGenerators registers the DuckDB functions behind the data generation
+// functions RANDOM, UNIFORM, NORMAL, and ZIPF, and the two-argument UUID_STRING.
+//
+// As in Snowflake, RANDOM() returns a random 64-bit integer per row, and
+// RANDOM(seed) returns the same sequence of integers in every statement using
+// the same seed. UNIFORM, NORMAL, and ZIPF are functions of their generator
+// argument: a constant generator gives the same value for every row, and
+// RANDOM() a different one. The values differ from the ones Snowflake returns
+// for the same seed, but are the same on every run.
+func (e *Executor) configureGenerators() {
+	ctx := context.Background()
+	conn, err := e.mgr.Conn(ctx)
+	if err != nil {
+		log.Printf("Failed to register generator functions: %v", err)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	bigint, _ := duckdb.NewTypeInfo(duckdb.TYPE_BIGINT)
+	double, _ := duckdb.NewTypeInfo(duckdb.TYPE_DOUBLE)
+	varchar, _ := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+
+	functions := map[string][]duckdb.ScalarFunc{
+		"snowflake_random": {
+			&generatorFunction{result: bigint, volatile: true, run: func(_ context.Context, _ []driver.Value) (any, error) {
+				return int64(rand.Uint64()), nil
+			}},
+			&generatorFunction{inputs: []duckdb.TypeInfo{bigint}, result: bigint, volatile: true, bind: bindRandomSeed, run: seededRandom},
+		},
+		"snowflake_uniform": {
+			&generatorFunction{inputs: []duckdb.TypeInfo{bigint, bigint, bigint}, result: bigint, run: func(_ context.Context, args []driver.Value) (any, error) {
+				low, high := args[0].(int64), args[1].(int64)
+				if low > high {
+					return nil, fmt.Errorf("UNIFORM minimum %d is greater than maximum %d", low, high)
+				}
+				return low + generator(args[2].(int64), uniformStream).Int64N(high-low+1), nil
+			}},
+			&generatorFunction{inputs: []duckdb.TypeInfo{double, double, bigint}, result: double, run: func(_ context.Context, args []driver.Value) (any, error) {
+				low, high := args[0].(float64), args[1].(float64)
+				if low > high {
+					return nil, fmt.Errorf("UNIFORM minimum %g is greater than maximum %g", low, high)
+				}
+				return low + generator(args[2].(int64), uniformStream).Float64()*(high-low), nil
+			}},
+		},
+		"snowflake_normal": {
+			&generatorFunction{inputs: []duckdb.TypeInfo{double, double, bigint}, result: double, run: func(_ context.Context, args []driver.Value) (any, error) {
+				mean, stddev := args[0].(float64), args[1].(float64)
+				return mean + stddev*generator(args[2].(int64), normalStream).NormFloat64(), nil
+			}},
+		},
+		"snowflake_zipf": {
+			&generatorFunction{inputs: []duckdb.TypeInfo{double, bigint, bigint}, result: bigint, run: func(_ context.Context, args []driver.Value) (any, error) {
+				return zipf(args[0].(float64), args[1].(int64), args[2].(int64))
+			}},
+		},
+		"snowflake_uuid_string": {
+			&generatorFunction{inputs: []duckdb.TypeInfo{varchar, varchar}, result: varchar, run: func(_ context.Context, args []driver.Value) (any, error) {
+				namespace, err := uuid.Parse(args[0].(string))
+				if err != nil {
+					return nil, fmt.Errorf("UUID_STRING namespace %q is not a UUID", args[0])
+				}
+				return uuid.NewSHA1(namespace, []byte(args[1].(string))).String(), nil
+			}},
+		},
+	}
+	for name, overloads := range functions {
+		if err := duckdb.RegisterScalarUDFSet(conn, name, overloads...); err != nil {
+			log.Printf("Failed to register generator function %s: %v", name, err)
+		}
+	}
+}
+
+// generatorFunction is a DuckDB scalar function computing a generated value for
+// each row.
+type generatorFunction struct {
+	inputs   []duckdb.TypeInfo
+	result   duckdb.TypeInfo
+	volatile bool
+	bind     duckdb.ScalarBinderFn
+	run      func(ctx context.Context, args []driver.Value) (any, error)
+}
+
+// Config implements duckdb.ScalarFunc. Functions returning a new value on every
+// call are volatile, so that DuckDB does not fold them into a constant.
+func (f *generatorFunction) Config() duckdb.ScalarFuncConfig {
+	return duckdb.ScalarFuncConfig{InputTypeInfos: f.inputs, ResultTypeInfo: f.result, Volatile: f.volatile}
+}
+
+// Executor implements duckdb.ScalarFunc.
+func (f *generatorFunction) Executor() duckdb.ScalarFuncExecutor {
+	return duckdb.ScalarFuncExecutor{RowContextExecutor: f.run, ScalarBinder: f.bind}
+}
+
+// generator returns the random number generator seeded with a generator value.
+func generator(seed int64, stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), stream))
+}
+
+// randomSequencesKey is the context key of a statement's RANDOM(seed) sequences.
+type randomSequencesKey struct{}
+
+// randomSequences holds the generators of the RANDOM(seed) calls of a
+// statement, by seed. Rows may be computed by several threads at once.
+type randomSequences struct {
+	mu         sync.Mutex
+	generators map[int64]*rand.Rand
+}
+
+// bindRandomSeed starts the sequence of a RANDOM(seed) call when its statement
+// is bound. DuckDB binds every statement anew, so the sequence starts over in
+// each statement.
+func bindRandomSeed(ctx context.Context, args []duckdb.ScalarUDFArg) (context.Context, error) {
+	if !args[0].Foldable || args[0].Value == nil {
+		return nil, fmt.Errorf("the seed of RANDOM must be a constant")
+	}
+	// The seed is folded before it is cast to BIGINT, so it has the type of its literal
+	var seed int64
+	switch value := args[0].Value.(type) {
+	case int8:
+		seed = int64(value)
+	case int16:
+		seed = int64(value)
+	case int32:
+		seed = int64(value)
+	case int64:
+		seed = value
+	case uint8:
+		seed = int64(value)
+	case uint16:
+		seed = int64(value)
+	case uint32:
+		seed = int64(value)
+	case uint64:
+		seed = int64(value)
+	default:
+		return nil, fmt.Errorf("the seed of RANDOM must be an integer")
+	}
+	sequences, ok := ctx.Value(randomSequencesKey{}).(*randomSequences)
+	if !ok {
+		sequences = &randomSequences{generators: make(map[int64]*rand.Rand)}
+		ctx = context.WithValue(ctx, randomSequencesKey{}, sequences)
+	}
+	sequences.mu.Lock()
+	defer sequences.mu.Unlock()
+	if _, ok := sequences.generators[seed]; !ok {
+		sequences.generators[seed] = generator(seed, randomStream)
+	}
+	return ctx, nil
+}
+
+// seededRandom returns the next integer of the sequence of a RANDOM(seed) call.
+func seededRandom(ctx context.Context, args []driver.Value) (any, error) {
+	sequences, ok := ctx.Value(randomSequencesKey{}).(*randomSequences)
+	if !ok {
+		return nil, fmt.Errorf("RANDOM(seed) is not available in this statement")
+	}
+	seed := args[0].(int64)
+	sequences.mu.Lock()
+	defer sequences.mu.Unlock()
+	g, ok := sequences.generators[seed]
+	if !ok {
+		g = generator(seed, randomStream)
+		sequences.generators[seed] = g
+	}
+	return int64(g.Uint64()), nil
+}
+
+// zipf returns the integer from 1 to n that the generator value gen picks from
+// a Zipf distribution with exponent s, in which k has a probability
+// proportional to 1/k^s.
+func zipf(s float64, n, gen int64) (any, error) {
+	if s <= 0 || n < 1 {
+		return nil, fmt.Errorf("ZIPF needs a positive exponent and element count, got %g and %d", s, n)
+	}
+	var total float64
+	for k := int64(1); k <= n; k++ {
+		total += math.Pow(float64(k), -s)
+	}
+	target := generator(gen, zipfStream).Float64() * total
+	for k := int64(1); k < n; k++ {
+		target -= math.Pow(float64(k), -s)
+		if target < 0 {
+			return k, nil
+		}
+	}
+	return n, nil
+}
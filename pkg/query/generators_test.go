@@ -0,0 +1,58 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_GeneratorFunctions tests that the data generation functions are
+// reproducible for a seed and keep their values within range.
+func TestExecutor_GeneratorFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	if _, err := executor.Execute(ctx, "CREATE TABLE nums AS SELECT range AS n FROM range(1000)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	query := func(sql string) [][]interface{} {
+		t.Helper()
+		result, err := executor.Query(ctx, sql)
+		if err != nil {
+			t.Fatalf("Query(%q) error = %v", sql, err)
+		}
+		return result.Rows
+	}
+
+	// A seed gives the same sequence in every statement, and different seeds different ones
+	seeded := "SELECT RANDOM(42), RANDOM(7) FROM nums ORDER BY n LIMIT 3"
+	first := query(seeded)
+	if diff := cmp.Diff(first, query(seeded)); diff != "" {
+		t.Errorf("RANDOM(seed) differs between statements (-first +second):\n%s", diff)
+	}
+	if first[0][0] == first[1][0] || first[0][0] == first[0][1] {
+		t.Errorf("RANDOM(seed) rows = %v, want distinct values", first)
+	}
+	if _, err := executor.Query(ctx, "SELECT RANDOM(n) FROM nums"); err == nil {
+		t.Error("Query() of RANDOM with a column seed error = nil, want error")
+	}
+
+	bounds := query(`SELECT MIN(u), MAX(u), MIN(f) >= 0.5 AND MAX(f) < 1.5, MIN(z), MAX(z), COUNT(DISTINCT r)
+		FROM (SELECT UNIFORM(1, 6, RANDOM()) AS u, UNIFORM(0.5, 1.5, RANDOM()) AS f, ZIPF(1, 10, RANDOM()) AS z, RANDOM() AS r FROM nums) AS g`)
+	if diff := cmp.Diff([][]interface{}{{int64(1), int64(6), true, int64(1), int64(10), int64(1000)}}, bounds); diff != "" {
+		t.Errorf("generated values mismatch (-want +got):\n%s", diff)
+	}
+
+	// A constant generator gives the same value for every row
+	constant := query("SELECT COUNT(DISTINCT NORMAL(0, 1, 5)), COUNT(DISTINCT UNIFORM(1, 1000, 5)) FROM nums")
+	if diff := cmp.Diff([][]interface{}{{int64(1), int64(1)}}, constant); diff != "" {
+		t.Errorf("constant generator mismatch (-want +got):\n%s", diff)
+	}
+
+	uuids := query("SELECT UUID_STRING('fe971b24-9572-4005-b22f-351e9c09274d', 'foo'), LENGTH(UUID_STRING())")
+	if diff := cmp.Diff([][]interface{}{{"dc0b6f65-fca6-5b4b-9d37-ccc3fde1f3e2", int64(36)}}, uuids); diff != "" {
+		t.Errorf("UUID_STRING mismatch (-want +got):\n%s", diff)
+	}
+}
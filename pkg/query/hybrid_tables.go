@@ -0,0 +1,118 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// hybridIndex is a secondary index declared in a CREATE HYBRID TABLE column list.
+type hybridIndex struct {
+	Name    string
+	Columns string // Column list as written, without parentheses
+}
+
+// translateHybridTable rewrites a CREATE HYBRID TABLE as the CREATE TABLE that
+// DuckDB runs: the HYBRID keyword is dropped, as are the INDEX clauses of the
+// column list, which are returned to be created with CREATE INDEX. DuckDB
+// enforces PRIMARY KEY, UNIQUE, and FOREIGN KEY constraints, as Snowflake does
+// for hybrid tables, but a hybrid table must have a primary key.
+func translateHybridTable(stmt *createStatement) (string, []hybridIndex, error) {
+	sql := stmt.SQL
+	hybrid := -1
+	open := -1
+	for i := 0; i < len(sql) && open < 0; i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case hybrid < 0 && keywordAt(sql, i, "HYBRID"):
+			hybrid = i
+		case c == '(':
+			open = i
+		case keywordAt(sql, i, "AS"):
+			i = len(sql)
+		}
+	}
+	if open < 0 {
+		return "", nil, fmt.Errorf("hybrid table %s must define its columns and a primary key", stmt.Name)
+	}
+	closing := matchingParen(sql[open:])
+	if closing < 0 {
+		return "", nil, fmt.Errorf("unterminated column list in CREATE HYBRID TABLE %s", stmt.Name)
+	}
+	closing += open
+
+	var kept []string
+	var indexes []hybridIndex
+	primaryKey := false
+	for _, segment := range splitFunctionArgs(sql[open+1:closing], 0) {
+		if index, ok := parseHybridIndex(segment); ok {
+			indexes = append(indexes, index)
+			continue
+		}
+		primaryKey = primaryKey || hasPrimaryKey(segment)
+		kept = append(kept, segment)
+	}
+	if !primaryKey {
+		return "", nil, fmt.Errorf("hybrid table %s must have a primary key", stmt.Name)
+	}
+
+	translated := sql[:hybrid] + strings.TrimLeft(sql[hybrid+len("HYBRID"):open+1], " \t\r\n") +
+		strings.Join(kept, ",") + sql[closing:]
+	return translated, indexes, nil
+}
+
+// parseHybridIndex parses an "INDEX name (columns) [INCLUDE (columns)]" clause
+// of a hybrid table's column list. Included columns only speed up Snowflake's
+// lookups, so they are dropped.
+func parseHybridIndex(segment string) (hybridIndex, bool) {
+	s := strings.TrimSpace(segment)
+	if !keywordAt(s, 0, "INDEX") {
+		return hybridIndex{}, false
+	}
+	s = strings.TrimSpace(s[len("INDEX"):])
+	open := strings.IndexByte(s, '(')
+	if open <= 0 {
+		return hybridIndex{}, false
+	}
+	closing := matchingParen(s[open:])
+	if closing < 0 {
+		return hybridIndex{}, false
+	}
+	return hybridIndex{
+		Name:    strings.TrimSpace(s[:open]),
+		Columns: strings.TrimSpace(s[open+1 : open+closing]),
+	}, true
+}
+
+// hasPrimaryKey reports whether a column definition or table constraint
+// declares a primary key.
+func hasPrimaryKey(segment string) bool {
+	for i := 0; i < len(segment); i++ {
+		switch c := segment[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(segment, i, c)
+		case keywordAt(segment, i, "PRIMARY"):
+			rest := strings.TrimLeft(segment[i+len("PRIMARY"):], " \t\r\n")
+			if keywordAt(rest, 0, "KEY") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// createHybridIndexes creates the secondary indexes of a hybrid table. Snowflake
+// scopes index names to their table while DuckDB scopes them to the schema, so
+// the DuckDB index is named after both.
+func (e *Executor) createHybridIndexes(ctx context.Context, table string, indexes []hybridIndex) error {
+	_, _, tableName := splitObjectName(ctx, table)
+	for _, index := range indexes {
+		indexName := quoteIdent(tableName + "_" + unquoteIdentifier(index.Name))
+		indexSQL := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", indexName, table, index.Columns)
+		if _, err := e.manager(ctx).Exec(ctx, indexSQL); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", index.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTranslateHybridTable(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		want        string
+		wantIndexes []hybridIndex
+		wantErr     bool
+	}{
+		{
+			name: "ColumnPrimaryKey",
+			sql:  "CREATE HYBRID TABLE orders (id INT PRIMARY KEY, status VARCHAR)",
+			want: "CREATE TABLE orders (id INT PRIMARY KEY, status VARCHAR)",
+		},
+		{
+			name: "ConstraintAndIndexes",
+			sql: "CREATE OR REPLACE HYBRID TABLE app.orders (id INT, customer_id INT, status VARCHAR, " +
+				"CONSTRAINT pk_orders PRIMARY KEY (id), INDEX idx_customer (customer_id) INCLUDE (status), INDEX idx_status(status))",
+			want: "CREATE OR REPLACE TABLE app.orders (id INT, customer_id INT, status VARCHAR, CONSTRAINT pk_orders PRIMARY KEY (id))",
+			wantIndexes: []hybridIndex{
+				{Name: "idx_customer", Columns: "customer_id"},
+				{Name: "idx_status", Columns: "status"},
+			},
+		},
+		{
+			name:    "MissingPrimaryKey",
+			sql:     "CREATE HYBRID TABLE orders (id INT, note VARCHAR DEFAULT 'PRIMARY KEY')",
+			wantErr: true,
+		},
+		{
+			name:    "NoColumnList",
+			sql:     "CREATE HYBRID TABLE orders AS SELECT 1 AS id",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, ok := parseCreateStatement(tt.sql)
+			if !ok || !stmt.Hybrid || stmt.Kind != "TABLE" {
+				t.Fatalf("parseCreateStatement() = %+v, %v, want a hybrid table", stmt, ok)
+			}
+			got, indexes, err := translateHybridTable(stmt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("translateHybridTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("translateHybridTable() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantIndexes, indexes); diff != "" {
+				t.Errorf("indexes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_HybridTable tests that hybrid tables enforce their primary key
+// and accept the MERGE upserts of unistore applications.
+func TestExecutor_HybridTable(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	executor.Configure(WithMergeProcessor(NewMergeProcessor(executor)))
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		"CREATE HYBRID TABLE sessions (id INT PRIMARY KEY, user_id INT NOT NULL, state VARCHAR, INDEX idx_user (user_id)) COMMENT = 'Session state'",
+		"INSERT INTO sessions VALUES (1, 10, 'new')",
+		"MERGE INTO sessions USING (SELECT 1 AS id, 10 AS user_id, 'active' AS state) s ON sessions.id = s.id " +
+			"WHEN MATCHED THEN UPDATE SET state = s.state WHEN NOT MATCHED THEN INSERT (id, user_id, state) VALUES (s.id, s.user_id, s.state)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	if _, err := executor.Execute(ctx, "INSERT INTO sessions VALUES (1, 11, 'duplicate')"); err == nil || !strings.Contains(err.Error(), "Duplicate key") {
+		t.Errorf("duplicate primary key error = %v, want a constraint violation", err)
+	}
+	if _, err := executor.Execute(ctx, "CREATE HYBRID TABLE no_key (id INT)"); err == nil {
+		t.Error("CREATE HYBRID TABLE without a primary key succeeded")
+	}
+
+	result, err := executor.Query(ctx, "SELECT id, state FROM sessions WHERE user_id = 10")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(1), "active"}}, result.Rows); diff != "" {
+		t.Errorf("sessions mismatch (-want +got):\n%s", diff)
+	}
+
+	indexes, err := executor.Query(ctx, "SELECT index_name FROM duckdb_indexes() WHERE table_name = 'sessions'")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"sessions_idx_user"}}, indexes.Rows); diff != "" {
+		t.Errorf("indexes mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// resolveIdentifier resolves IDENTIFIER(...) starting at sql[i], whose argument
+// is a string literal or a $name session variable holding an object name. It
+// returns the object name, the index after the closing parenthesis, and false
+// if sql[i:] is not such a call.
+func (e *Executor) resolveIdentifier(ctx context.Context, sql string, i int) (identifier string, end int, ok bool, err error) {
+	i += len("IDENTIFIER")
+	skipSpace := func() {
+		for i < len(sql) && isSpace(sql[i]) {
+			i++
+		}
+	}
+	skipSpace()
+	if i >= len(sql) || sql[i] != '(' {
+		return "", 0, false, nil
+	}
+	i++
+	skipSpace()
+
+	switch {
+	case i < len(sql) && sql[i] == '\'':
+		closing := skipQuoted(sql, i, '\'')
+		if sql[closing] != '\'' || closing == i {
+			return "", 0, false, nil
+		}
+		identifier = strings.ReplaceAll(sql[i+1:closing], "''", "'")
+		i = closing + 1
+	case i+1 < len(sql) && sql[i] == '$' && isVariableStart(sql[i+1]):
+		nameEnd := variableEnd(sql, i+1)
+		name := strings.ToUpper(sql[i+1 : nameEnd])
+		variable, err := e.lookupVariable(ctx, name)
+		if err != nil {
+			return "", 0, false, err
+		}
+		value, isString := variable.Value.(string)
+		if !isString {
+			return "", 0, false, fmt.Errorf("session variable '$%s' does not hold an identifier", name)
+		}
+		identifier = value
+		i = nameEnd
+	default:
+		return "", 0, false, nil
+	}
+
+	skipSpace()
+	if i >= len(sql) || sql[i] != ')' {
+		return "", 0, false, nil
+	}
+	identifier = strings.TrimSpace(identifier)
+	if !isObjectName(identifier) {
+		return "", 0, false, fmt.Errorf("invalid identifier '%s'", identifier)
+	}
+	return identifier, i + 1, true, nil
+}
+
+// isObjectName reports whether s is an object name of up to three parts, each
+// an unquoted identifier or a double-quoted one, separated by dots.
+func isObjectName(s string) bool {
+	parts := 0
+	for i := 0; ; {
+		if i < len(s) && s[i] == '"' {
+			closing := skipQuoted(s, i, '"')
+			// Doubled quotes inside a quoted identifier are escapes
+			for closing+1 < len(s) && s[closing+1] == '"' {
+				closing = skipQuoted(s, closing+1, '"')
+			}
+			if s[closing] != '"' || closing == i {
+				return false
+			}
+			i = closing + 1
+		} else {
+			start := i
+			for i < len(s) && isIdentChar(s[i]) && s[i] != '.' {
+				i++
+			}
+			if i == start || !isVariableStart(s[start]) {
+				return false
+			}
+		}
+		parts++
+		if i == len(s) {
+			return parts <= 3
+		}
+		if s[i] != '.' {
+			return false
+		}
+		i++
+	}
+}
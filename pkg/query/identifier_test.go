@@ -0,0 +1,73 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIsObjectName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "orders", want: true},
+		{name: "db.schema.orders", want: true},
+		{name: `"My DB"."My Schema"."Order ""Items"""`, want: true},
+		{name: "db.schema.table.extra", want: false},
+		{name: "orders; DROP TABLE x", want: false},
+		{name: "db..orders", want: false},
+		{name: "1orders", want: false},
+		{name: `"unterminated`, want: false},
+		{name: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isObjectName(tt.name); got != tt.want {
+				t.Errorf("isObjectName(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutor_Identifier(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1"})
+
+	statements := []string{
+		"CREATE TABLE IDENTIFIER('main.ident_orders') (id INTEGER)",
+		"INSERT INTO IDENTIFIER( 'ident_orders' ) VALUES (1), (2)",
+		"SET target = 'main.ident_orders'",
+		"DELETE FROM identifier($target) WHERE id = 1",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, `SELECT id FROM IDENTIFIER('"main"."ident_orders"')`)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(2)}}, result.Rows); diff != "" {
+		t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Arguments that are not object names are rejected rather than spliced in
+	for _, sql := range []string{
+		"SELECT * FROM IDENTIFIER('ident_orders; DROP TABLE ident_orders')",
+		"SELECT * FROM IDENTIFIER('ident_orders WHERE 1=1')",
+	} {
+		if _, err := executor.Query(ctx, sql); err == nil {
+			t.Errorf("Query(%q) should fail", sql)
+		}
+	}
+
+	// A column named identifier is not a call
+	if _, err := executor.Query(ctx, "SELECT 1 AS identifier"); err != nil {
+		t.Errorf("Query() with an identifier column error = %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SnowflakeSource reads the structure and data of databases in a Snowflake
+// account, for ImportDatabase.
+type SnowflakeSource interface {
+	// DatabaseDDL returns the statements recreating a database, its schemas,
+	// and their objects, as GET_DDL returns them.
+	DatabaseDDL(ctx context.Context, database string) (string, error)
+	// Tables lists the tables of a database.
+	Tables(ctx context.Context, database string) ([]SourceTable, error)
+	// SampleRows returns up to limit rows of a table, in column order.
+	SampleRows(ctx context.Context, database string, table SourceTable, limit int) ([][]interface{}, error)
+}
+
+// SourceTable is a table of a Snowflake database.
+type SourceTable struct {
+	Schema string
+	Name   string
+}
+
+// DatabaseImport describes what ImportDatabase copied into the emulator.
+type DatabaseImport struct {
+	Database string
+	// Statements is the number of DDL statements that were applied.
+	Statements int
+	Tables     []ImportedTable
+	// Skipped lists the statements and tables that could not be imported,
+	// with the reason.
+	Skipped []string
+}
+
+// ImportedTable is a table whose sampled rows were imported.
+type ImportedTable struct {
+	Schema string
+	Name   string
+	Rows   int64
+}
+
+// snowflakeSource is a SnowflakeSource reading a Snowflake account through a
+// database/sql connection, such as one opened with the gosnowflake driver.
+type snowflakeSource struct {
+	db *sql.DB
+}
+
+// NewSnowflakeSource returns a SnowflakeSource that reads a Snowflake account
+// through db with GET_DDL, SHOW TABLES, and SELECT ... SAMPLE.
+func NewSnowflakeSource(db *sql.DB) SnowflakeSource {
+	return &snowflakeSource{db: db}
+}
+
+// DatabaseDDL implements SnowflakeSource. Names are fully qualified, so the
+// statements do not depend on the session's current database.
+func (s *snowflakeSource) DatabaseDDL(ctx context.Context, database string) (string, error) {
+	var ddl string
+	query := fmt.Sprintf("SELECT GET_DDL('DATABASE', %s, TRUE)", quoteLiteral(snowflakeIdentifier(database)))
+	if err := s.db.QueryRowContext(ctx, query).Scan(&ddl); err != nil {
+		return "", fmt.Errorf("failed to get DDL of database %s: %w", database, err)
+	}
+	return ddl, nil
+}
+
+// Tables implements SnowflakeSource.
+func (s *snowflakeSource) Tables(ctx context.Context, database string) ([]SourceTable, error) {
+	rows, err := s.db.QueryContext(ctx, "SHOW TABLES IN DATABASE "+snowflakeIdentifier(database))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables of database %s: %w", database, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	// SHOW output has many columns, so they are found by name
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	schemaIndex, nameIndex := -1, -1
+	for i, column := range columns {
+		switch strings.ToLower(column) {
+		case "schema_name":
+			schemaIndex = i
+		case "name":
+			nameIndex = i
+		}
+	}
+	if schemaIndex < 0 || nameIndex < 0 {
+		return nil, fmt.Errorf("SHOW TABLES returned no schema_name or name column")
+	}
+
+	var tables []SourceTable
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		tables = append(tables, SourceTable{Schema: values[schemaIndex].String, Name: values[nameIndex].String})
+	}
+	return tables, rows.Err()
+}
+
+// SampleRows implements SnowflakeSource.
+func (s *snowflakeSource) SampleRows(ctx context.Context, database string, table SourceTable, limit int) ([][]interface{}, error) {
+	query := fmt.Sprintf("SELECT * FROM %s.%s.%s SAMPLE (%d ROWS)",
+		snowflakeIdentifier(database), snowflakeIdentifier(table.Schema), snowflakeIdentifier(table.Name), limit)
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample table %s.%s: %w", table.Schema, table.Name, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// ImportDatabase replicates a database of a Snowflake account into the
+// emulator. The statements of the database's DDL are run in order; those the
+// emulator does not support, such as file formats or tasks, are skipped and
+// listed in the result rather than failing the import. With sampleRows above
+// zero, up to that many rows of each created table are copied as well.
+func (e *Executor) ImportDatabase(ctx context.Context, source SnowflakeSource, database string, sampleRows int) (*DatabaseImport, error) {
+	ddl, err := source.DatabaseDDL(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unquoted names resolve to upper case, as in Snowflake
+	name := database
+	if plainIdentifier.MatchString(name) {
+		name = strings.ToUpper(name)
+	}
+	result := &DatabaseImport{Database: name}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: name, Schema: publicSchema})
+	for _, statement := range SplitStatements(ddl) {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		if _, err := e.Execute(ctx, statement); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", statementSummary(statement), err))
+			continue
+		}
+		result.Statements++
+	}
+	if _, err := e.repo.GetDatabaseByName(ctx, name); err != nil {
+		return nil, fmt.Errorf("database %s was not created: %w", name, err)
+	}
+
+	if sampleRows <= 0 {
+		return result, nil
+	}
+	tables, err := source.Tables(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if !e.tableExists(ctx, table.Schema, table.Name) {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("data of table %s.%s: the table was not created", table.Schema, table.Name))
+			continue
+		}
+		rows, err := source.SampleRows(ctx, database, table, sampleRows)
+		if err != nil {
+			return nil, err
+		}
+		imported, err := e.importRows(ctx, table, rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Tables = append(result.Tables, *imported)
+	}
+	return result, nil
+}
+
+// importRows inserts rows into a table created from the source's DDL. The
+// values are bound as parameters, so DuckDB converts the driver's values, such
+// as the strings gosnowflake returns for NUMBER and VARIANT columns, to the
+// column types.
+func (e *Executor) importRows(ctx context.Context, table SourceTable, rows [][]interface{}) (*ImportedTable, error) {
+	imported := &ImportedTable{Schema: table.Schema, Name: table.Name}
+	for _, row := range rows {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(row)), ", ")
+		insertSQL := fmt.Sprintf("INSERT INTO %s.%s VALUES (%s)", quoteIdent(table.Schema), quoteIdent(table.Name), placeholders)
+		if _, err := e.manager(ctx).Exec(ctx, insertSQL, row...); err != nil {
+			return nil, fmt.Errorf("failed to import rows of table %s.%s: %w", table.Schema, table.Name, err)
+		}
+		imported.Rows++
+	}
+	return imported, nil
+}
+
+// statementSummary returns the first line of a statement, for messages.
+func statementSummary(statement string) string {
+	if line, _, found := strings.Cut(statement, "\n"); found {
+		return strings.TrimSpace(line) + " ..."
+	}
+	return statement
+}
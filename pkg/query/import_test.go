@@ -0,0 +1,133 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeSnowflakeSource is a SnowflakeSource serving fixed DDL and rows.
+type fakeSnowflakeSource struct {
+	ddl    string
+	tables []SourceTable
+	rows   map[string][][]interface{}
+	limits []int
+}
+
+func (s *fakeSnowflakeSource) DatabaseDDL(_ context.Context, database string) (string, error) {
+	if s.ddl == "" {
+		return "", errors.New("Database '" + database + "' does not exist or not authorized.")
+	}
+	return s.ddl, nil
+}
+
+func (s *fakeSnowflakeSource) Tables(_ context.Context, _ string) ([]SourceTable, error) {
+	return s.tables, nil
+}
+
+func (s *fakeSnowflakeSource) SampleRows(_ context.Context, _ string, table SourceTable, limit int) ([][]interface{}, error) {
+	s.limits = append(s.limits, limit)
+	return s.rows[table.Schema+"."+table.Name], nil
+}
+
+// TestExecutor_ImportDatabase tests replicating a database's GET_DDL output
+// and sampled rows into the emulator.
+func TestExecutor_ImportDatabase(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	source := &fakeSnowflakeSource{
+		ddl: `create or replace database SALES comment='Sales data';
+
+create or replace schema SALES.PUBLIC;
+
+create or replace schema SALES.RAW comment='Raw data';
+
+create or replace TABLE SALES.RAW.ORDERS (
+	ID NUMBER(38,0) NOT NULL,
+	AMOUNT NUMBER(10,2),
+	ATTRS VARIANT,
+	ORDERED_AT TIMESTAMP_NTZ(9),
+	primary key (ID)
+) comment='Orders; one row per order';
+
+create or replace view SALES.RAW.LARGE_ORDERS(
+	ID
+) as select id from SALES.RAW.ORDERS where amount > 10;
+
+create or replace file format SALES.RAW.CSV_FORMAT
+	type = csv
+	skip_header = 1;
+
+create or replace TABLE SALES.RAW.EXTERNAL_ONLY (
+	ID NUMBER(38,0)
+) with tag (SALES.RAW.PII = 'yes');
+`,
+		tables: []SourceTable{{Schema: "RAW", Name: "ORDERS"}, {Schema: "RAW", Name: "EXTERNAL_ONLY"}},
+		rows: map[string][][]interface{}{
+			// gosnowflake returns NUMBER and VARIANT values as strings
+			"RAW.ORDERS": {
+				{"1", "9.50", `{"rush":true}`, "2024-01-02 03:04:05"},
+				{"2", "20", nil, nil},
+			},
+		},
+	}
+
+	result, err := executor.ImportDatabase(ctx, source, "sales", 100)
+	if err != nil {
+		t.Fatalf("ImportDatabase() error = %v", err)
+	}
+	if result.Database != "SALES" || result.Statements != 5 {
+		t.Errorf("ImportDatabase() database, statements = %q, %d, want SALES, 5", result.Database, result.Statements)
+	}
+	if diff := cmp.Diff([]ImportedTable{{Schema: "RAW", Name: "ORDERS", Rows: 2}}, result.Tables); diff != "" {
+		t.Errorf("imported tables mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.Skipped) != 3 {
+		t.Errorf("ImportDatabase() skipped %d objects, want the file format, the tagged table, and its data: %q", len(result.Skipped), result.Skipped)
+	}
+	if diff := cmp.Diff([]int{100}, source.limits); diff != "" {
+		t.Errorf("sample limits mismatch (-want +got):\n%s", diff)
+	}
+
+	db, err := repo.GetDatabaseByName(ctx, "SALES")
+	if err != nil {
+		t.Fatalf("GetDatabaseByName() error = %v", err)
+	}
+	if db.Comment != "Sales data" {
+		t.Errorf("database comment = %q, want %q", db.Comment, "Sales data")
+	}
+
+	queryResult, err := executor.Query(ctx, "SELECT ID, AMOUNT, ATTRS, ORDERED_AT IS NULL FROM SALES.RAW.ORDERS ORDER BY ID")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(queryResult.Rows) != 2 {
+		t.Fatalf("ORDERS has %d rows, want 2", len(queryResult.Rows))
+	}
+	if diff := cmp.Diff(map[string]interface{}{"rush": true}, queryResult.Rows[0][2]); diff != "" {
+		t.Errorf("ATTRS mismatch (-want +got):\n%s", diff)
+	}
+	if got := queryResult.Rows[1][3]; got != true {
+		t.Errorf("ORDERED_AT IS NULL = %v, want true", got)
+	}
+
+	viewResult, err := executor.Query(ctx, "SELECT ID FROM SALES.RAW.LARGE_ORDERS")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(viewResult.Rows) != 1 {
+		t.Errorf("LARGE_ORDERS has %d rows, want 1", len(viewResult.Rows))
+	}
+}
+
+// TestExecutor_ImportDatabaseMissing tests that a database the source cannot
+// read fails the import.
+func TestExecutor_ImportDatabaseMissing(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	if _, err := executor.ImportDatabase(context.Background(), &fakeSnowflakeSource{}, "MISSING", 0); err == nil {
+		t.Error("ImportDatabase() of a missing database succeeded")
+	}
+}
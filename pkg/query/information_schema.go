@@ -0,0 +1,325 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
+)
+
+// informationSchemaColumn is a column of an emulated INFORMATION_SCHEMA view
+// and the DuckDB type of its values.
+type informationSchemaColumn struct {
+	Name string
+	Type string
+}
+
+// informationSchemaViews are the INFORMATION_SCHEMA views the emulator serves
+// from the metadata store, with Snowflake's columns. Other views, such as
+// INFORMATION_SCHEMA.FUNCTIONS, are left to DuckDB's catalog.
+var informationSchemaViews = map[string][]informationSchemaColumn{
+	"SCHEMATA": {
+		{"CATALOG_NAME", "VARCHAR"}, {"SCHEMA_NAME", "VARCHAR"}, {"SCHEMA_OWNER", "VARCHAR"},
+		{"IS_TRANSIENT", "VARCHAR"}, {"IS_MANAGED_ACCESS", "VARCHAR"}, {"RETENTION_TIME", "BIGINT"},
+		{"DEFAULT_CHARACTER_SET_CATALOG", "VARCHAR"}, {"DEFAULT_CHARACTER_SET_SCHEMA", "VARCHAR"},
+		{"DEFAULT_CHARACTER_SET_NAME", "VARCHAR"}, {"SQL_PATH", "VARCHAR"},
+		{"CREATED", "TIMESTAMP"}, {"LAST_ALTERED", "TIMESTAMP"}, {"COMMENT", "VARCHAR"},
+	},
+	"TABLES": {
+		{"TABLE_CATALOG", "VARCHAR"}, {"TABLE_SCHEMA", "VARCHAR"}, {"TABLE_NAME", "VARCHAR"},
+		{"TABLE_OWNER", "VARCHAR"}, {"TABLE_TYPE", "VARCHAR"}, {"IS_TRANSIENT", "VARCHAR"},
+		{"CLUSTERING_KEY", "VARCHAR"}, {"ROW_COUNT", "BIGINT"}, {"BYTES", "BIGINT"},
+		{"RETENTION_TIME", "BIGINT"}, {"IS_INSERTABLE_INTO", "VARCHAR"}, {"IS_TYPED", "VARCHAR"},
+		{"CREATED", "TIMESTAMP"}, {"LAST_ALTERED", "TIMESTAMP"}, {"LAST_DDL", "TIMESTAMP"},
+		{"AUTO_CLUSTERING_ON", "VARCHAR"}, {"COMMENT", "VARCHAR"}, {"IS_TEMPORARY", "VARCHAR"},
+		{"IS_ICEBERG", "VARCHAR"}, {"IS_DYNAMIC", "VARCHAR"}, {"IS_HYBRID", "VARCHAR"},
+	},
+	"COLUMNS": {
+		{"TABLE_CATALOG", "VARCHAR"}, {"TABLE_SCHEMA", "VARCHAR"}, {"TABLE_NAME", "VARCHAR"},
+		{"COLUMN_NAME", "VARCHAR"}, {"ORDINAL_POSITION", "BIGINT"}, {"COLUMN_DEFAULT", "VARCHAR"},
+		{"IS_NULLABLE", "VARCHAR"}, {"DATA_TYPE", "VARCHAR"}, {"CHARACTER_MAXIMUM_LENGTH", "BIGINT"},
+		{"CHARACTER_OCTET_LENGTH", "BIGINT"}, {"NUMERIC_PRECISION", "BIGINT"}, {"NUMERIC_PRECISION_RADIX", "BIGINT"},
+		{"NUMERIC_SCALE", "BIGINT"}, {"DATETIME_PRECISION", "BIGINT"}, {"IS_IDENTITY", "VARCHAR"},
+		{"COMMENT", "VARCHAR"},
+	},
+	"VIEWS": {
+		{"TABLE_CATALOG", "VARCHAR"}, {"TABLE_SCHEMA", "VARCHAR"}, {"TABLE_NAME", "VARCHAR"},
+		{"TABLE_OWNER", "VARCHAR"}, {"VIEW_DEFINITION", "VARCHAR"}, {"CHECK_OPTION", "VARCHAR"},
+		{"IS_UPDATABLE", "VARCHAR"}, {"INSERTABLE_INTO", "VARCHAR"}, {"IS_SECURE", "VARCHAR"},
+		{"CREATED", "TIMESTAMP"}, {"LAST_ALTERED", "TIMESTAMP"}, {"LAST_DDL", "TIMESTAMP"},
+		{"COMMENT", "VARCHAR"},
+	},
+}
+
+// maxVarcharLength is the length Snowflake reports for VARCHAR columns declared
+// without one, in characters and in bytes.
+const maxVarcharLength = 16777216
+
+// isInformationSchemaView reports whether the name parts schema.view, as
+// written, name an emulated INFORMATION_SCHEMA view.
+func isInformationSchemaView(schema, view string) bool {
+	_, ok := informationSchemaViews[strings.ToUpper(unquoteIdentifier(view))]
+	return ok && strings.EqualFold(unquoteIdentifier(schema), informationSchema)
+}
+
+// informationSchemaView renders an INFORMATION_SCHEMA view of db as a subquery
+// listing its rows, so that it can stand in for the view's name.
+func (e *Executor) informationSchemaView(ctx context.Context, db *metadata.Database, view string) (string, error) {
+	var rows [][]interface{}
+	var err error
+	switch view {
+	case "SCHEMATA":
+		rows, err = e.informationSchemaSchemata(ctx, db)
+	case "TABLES":
+		rows, err = e.informationSchemaTables(ctx, db)
+	case "COLUMNS":
+		rows, err = e.informationSchemaColumns(ctx, db)
+	case "VIEWS":
+		rows, err = e.informationSchemaViewRows(ctx, db)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return virtualViewSubquery(informationSchemaViews[view], rows), nil
+}
+
+// virtualViewSubquery renders the rows of an emulated view with the columns
+// as a subquery. The subquery is DuckDB SQL, which is not translated.
+func virtualViewSubquery(columns []informationSchemaColumn, rows [][]interface{}) string {
+	selects := make([]string, 0, max(len(rows), 1))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = fmt.Sprintf("CAST(%s AS %s) AS %s", sqlValue(row[i]), col.Type, col.Name)
+		}
+		selects = append(selects, "SELECT "+strings.Join(values, ", "))
+	}
+	subquery := strings.Join(selects, " UNION ALL ")
+	if len(rows) == 0 {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = fmt.Sprintf("CAST(NULL AS %s) AS %s", col.Type, col.Name)
+		}
+		subquery = "SELECT " + strings.Join(values, ", ") + " LIMIT 0"
+	}
+	return "(" + subquery + ")"
+}
+
+// sqlValue renders a value of an emulated view's row as a SQL literal.
+func sqlValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteLiteral(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return quoteLiteral(v.UTC().Format("2006-01-02 15:04:05.999999"))
+	}
+	return quoteLiteral(fmt.Sprint(value))
+}
+
+// informationSchemaFlag renders an INFORMATION_SCHEMA flag.
+func informationSchemaFlag(flag bool) string {
+	if flag {
+		return "YES"
+	}
+	return "NO"
+}
+
+// nullIfEmpty returns nil for an empty string, which INFORMATION_SCHEMA shows
+// as NULL, and the string otherwise.
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullIfZero returns nil for the zero time, and the time otherwise.
+func nullIfZero(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// informationSchemaSchemata lists the rows of INFORMATION_SCHEMA.SCHEMATA.
+func (e *Executor) informationSchemaSchemata(ctx context.Context, db *metadata.Database) ([][]interface{}, error) {
+	schemas, err := e.databaseSchemas(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, 0, len(schemas))
+	for _, schema := range schemas {
+		created := nullIfZero(schema.CreatedAt)
+		rows = append(rows, []interface{}{
+			db.Name, schema.Name, nullIfEmpty(schema.Owner), "NO", "NO", int64(1),
+			nil, nil, nil, nil, created, created, nullIfEmpty(schema.Comment),
+		})
+	}
+	return rows, nil
+}
+
+// informationSchemaTables lists the rows of INFORMATION_SCHEMA.TABLES: the
+// tables and views of every schema of db.
+func (e *Executor) informationSchemaTables(ctx context.Context, db *metadata.Database) ([][]interface{}, error) {
+	var rows [][]interface{}
+	err := e.eachInformationSchemaObject(ctx, db, func(schema string, table *schemaTable, view *schemaView) error {
+		rows = append(rows, informationSchemaTableRow(db, schema, table, view))
+		return nil
+	})
+	return rows, err
+}
+
+// informationSchemaTableRow returns the row of INFORMATION_SCHEMA.TABLES of a
+// table or view of db. Tables created with SQL and through the REST API have
+// the owner and creation time of their registration. DuckDB does not record
+// the size of a table, so BYTES is NULL.
+func informationSchemaTableRow(db *metadata.Database, schema string, table *schemaTable, view *schemaView) []interface{} {
+	if view != nil {
+		tableType, owner, created := "VIEW", "", interface{}(nil)
+		if view.Registered != nil {
+			if view.Registered.Materialized {
+				tableType = "MATERIALIZED VIEW"
+			}
+			owner, created = view.Registered.Owner, nullIfZero(view.Registered.CreatedAt)
+		}
+		return []interface{}{
+			db.Name, schema, view.Name, nullIfEmpty(owner), tableType, "NO", nil, nil, nil,
+			nil, "NO", "YES", created, created, created, "NO", nullIfEmpty(view.Comment), "NO", "NO", "NO", "NO",
+		}
+	}
+	owner, clusteringKey, created := "", "", interface{}(nil)
+	if table.Registered != nil {
+		owner, clusteringKey = table.Registered.Owner, table.Registered.ClusteringKey
+		created = nullIfZero(table.Registered.CreatedAt)
+	}
+	return []interface{}{
+		db.Name, schema, table.Name, nullIfEmpty(owner), "BASE TABLE", "NO", nullIfEmpty(clusteringKey), table.Rows, nil,
+		int64(1), "YES", "YES", created, created, created, "NO", nullIfEmpty(table.Comment), "NO", "NO", "NO", "NO",
+	}
+}
+
+// informationSchemaColumns lists the rows of INFORMATION_SCHEMA.COLUMNS: the
+// columns of the tables and views of every schema of db, with the Snowflake
+// types of their DuckDB types.
+func (e *Executor) informationSchemaColumns(ctx context.Context, db *metadata.Database) ([][]interface{}, error) {
+	var rows [][]interface{}
+	err := e.eachInformationSchemaObject(ctx, db, func(schema string, table *schemaTable, view *schemaView) error {
+		name, physicalSchema, physicalName := "", "", ""
+		if view != nil {
+			name, physicalSchema, physicalName = view.Name, physicalSchemaName(schema), view.Name
+		} else {
+			name, physicalSchema, physicalName = table.Name, table.PhysicalSchema, table.PhysicalName
+		}
+		columns, err := e.manager(ctx).Query(ctx, `SELECT column_name, column_index, column_default, is_nullable, data_type, comment
+			FROM duckdb_columns() WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND table_name = ?
+			ORDER BY column_index`, physicalSchema, physicalName)
+		if err != nil {
+			return fmt.Errorf("failed to read columns of %s: %w", name, err)
+		}
+		defer func() { _ = columns.Close() }()
+		for columns.Next() {
+			var column, duckType string
+			var position int64
+			var columnDefault, comment sql.NullString
+			var nullable bool
+			if err := columns.Scan(&column, &position, &columnDefault, &nullable, &duckType, &comment); err != nil {
+				return err
+			}
+			var columnDefaultValue interface{}
+			if columnDefault.Valid {
+				columnDefaultValue = snowflakeDefault(columnDefault.String)
+			}
+			row := append([]interface{}{db.Name, schema, name, column, position, columnDefaultValue, informationSchemaFlag(nullable)}, columnDataType(duckType)...)
+			rows = append(rows, append(row, "NO", nullIfEmpty(comment.String)))
+		}
+		return columns.Err()
+	})
+	return rows, err
+}
+
+// columnDataType returns the DATA_TYPE, CHARACTER_MAXIMUM_LENGTH,
+// CHARACTER_OCTET_LENGTH, NUMERIC_PRECISION, NUMERIC_PRECISION_RADIX,
+// NUMERIC_SCALE, and DATETIME_PRECISION of a column of a DuckDB type.
+func columnDataType(duckType string) []interface{} {
+	switch declaration := snowflakeColumnType(duckType); sftypes.FromDuckDBType(duckType).Type {
+	case sftypes.TypeNumber, sftypes.TypeInteger:
+		var precision, scale int64
+		if _, err := fmt.Sscanf(declaration, "NUMBER(%d,%d)", &precision, &scale); err != nil {
+			precision, scale = 38, 0
+		}
+		return []interface{}{"NUMBER", nil, nil, precision, int64(10), scale, nil}
+	case sftypes.TypeFloat:
+		return []interface{}{"FLOAT", nil, nil, nil, nil, nil, nil}
+	case sftypes.TypeVarchar:
+		return []interface{}{"TEXT", int64(maxVarcharLength), int64(maxVarcharLength), nil, nil, nil, nil}
+	case sftypes.TypeTimestamp:
+		return []interface{}{"TIMESTAMP_NTZ", nil, nil, nil, nil, nil, int64(9)}
+	case sftypes.TypeTime, sftypes.TypeTimestampLTZ, sftypes.TypeTimestampTZ:
+		return []interface{}{declaration, nil, nil, nil, nil, nil, int64(9)}
+	default:
+		return []interface{}{declaration, nil, nil, nil, nil, nil, nil}
+	}
+}
+
+// informationSchemaViewRows lists the rows of INFORMATION_SCHEMA.VIEWS.
+// VIEW_DEFINITION is the translated query DuckDB runs.
+func (e *Executor) informationSchemaViewRows(ctx context.Context, db *metadata.Database) ([][]interface{}, error) {
+	var rows [][]interface{}
+	err := e.eachInformationSchemaObject(ctx, db, func(schema string, _ *schemaTable, view *schemaView) error {
+		if view == nil {
+			return nil
+		}
+		secure, owner, created := false, "", interface{}(nil)
+		if view.Registered != nil {
+			secure, owner, created = view.Registered.Secure, view.Registered.Owner, nullIfZero(view.Registered.CreatedAt)
+		}
+		rows = append(rows, []interface{}{
+			db.Name, schema, view.Name, nullIfEmpty(owner), view.Text, "NONE", "NO", "NO", informationSchemaFlag(secure),
+			created, created, created, nullIfEmpty(view.Comment),
+		})
+		return nil
+	})
+	return rows, err
+}
+
+// eachInformationSchemaObject calls visit with each table, and then each view,
+// of every schema of db but INFORMATION_SCHEMA, with their registrations.
+func (e *Executor) eachInformationSchemaObject(ctx context.Context, db *metadata.Database, visit func(schema string, table *schemaTable, view *schemaView) error) error {
+	schemas, err := e.scopeSchemas(ctx, &showStatement{Object: "TABLES"}, db)
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		tables, err := e.registeredSchemaTables(ctx, db, schema)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			if err := visit(schema, table, nil); err != nil {
+				return err
+			}
+		}
+		views, err := e.schemaViews(ctx, db, schema)
+		if err != nil {
+			return err
+		}
+		for _, view := range views {
+			if err := visit(schema, nil, view); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_InformationSchema tests that the INFORMATION_SCHEMA views list
+// the objects of a database with Snowflake's columns and schema names.
+func TestExecutor_InformationSchema(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	if _, err := repo.CreateDatabase(ctx, "ANALYTICS", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "ANALYTICS", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE SCHEMA STAGING COMMENT = 'Raw data'",
+		"CREATE TABLE ORDERS (ID NUMBER(10,0) NOT NULL, NOTE VARCHAR DEFAULT 'none', PLACED_AT TIMESTAMP_NTZ) COMMENT = 'All orders'",
+		"INSERT INTO ORDERS (ID) VALUES (1), (2)",
+		"CREATE TABLE STAGING.EVENTS (PAYLOAD VARIANT)",
+		"CREATE VIEW ORDER_IDS AS SELECT ID FROM ORDERS",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sql  string
+		want [][]interface{}
+	}{
+		{
+			name: "Schemata",
+			sql:  "SELECT SCHEMA_NAME, COMMENT FROM ANALYTICS.INFORMATION_SCHEMA.SCHEMATA ORDER BY SCHEMA_NAME",
+			want: [][]interface{}{{"INFORMATION_SCHEMA", "Views describing the contents of schemas in this database"}, {"PUBLIC", nil}, {"STAGING", "Raw data"}},
+		},
+		{
+			name: "Tables",
+			sql: `SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE, ROW_COUNT, COMMENT, IFF(CREATED IS NULL, 'no', 'yes')
+				FROM INFORMATION_SCHEMA.TABLES ORDER BY TABLE_SCHEMA, TABLE_NAME`,
+			want: [][]interface{}{
+				{"ANALYTICS", "PUBLIC", "ORDERS", "BASE TABLE", int64(2), "All orders", "yes"},
+				{"ANALYTICS", "PUBLIC", "ORDER_IDS", "VIEW", nil, nil, "yes"},
+				{"ANALYTICS", "STAGING", "EVENTS", "BASE TABLE", int64(0), nil, "yes"},
+			},
+		},
+		{
+			name: "Columns",
+			sql: `SELECT c.TABLE_NAME, c.COLUMN_NAME, c.ORDINAL_POSITION, c.IS_NULLABLE, c.DATA_TYPE, c.NUMERIC_PRECISION, c.COLUMN_DEFAULT
+				FROM information_schema.columns c WHERE c.TABLE_SCHEMA = 'PUBLIC' ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`,
+			want: [][]interface{}{
+				{"ORDERS", "ID", int64(1), "NO", "NUMBER", int64(10), nil},
+				{"ORDERS", "NOTE", int64(2), "YES", "TEXT", nil, "'none'"},
+				{"ORDERS", "PLACED_AT", int64(3), "YES", "TIMESTAMP_NTZ", nil, nil},
+				{"ORDER_IDS", "ID", int64(1), "YES", "NUMBER", int64(10), nil},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, result.Rows); diff != "" {
+				t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
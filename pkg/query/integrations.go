@@ -0,0 +1,292 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Integration categories, as SHOW INTEGRATIONS reports them.
+const (
+	integrationNotification = "NOTIFICATION"
+	integrationSecurity     = "SECURITY"
+)
+
+// integration is an integration created with CREATE NOTIFICATION INTEGRATION
+// or CREATE EXTERNAL ACCESS INTEGRATION.
+type integration struct {
+	Name     string
+	Type     string // EMAIL, WEBHOOK, QUEUE, or EXTERNAL_ACCESS
+	Category string
+	Enabled  bool
+	// AllowedRecipients are the addresses an email integration may send to,
+	// or nil to allow any address.
+	AllowedRecipients []string
+	// AllowedNetworkRules are the network rules of an external access
+	// integration. The emulator does not restrict network access.
+	AllowedNetworkRules []string
+	// AllowedSecrets are the qualified names of the secrets an external access
+	// integration lets procedures use, unless AllSecrets is set.
+	AllowedSecrets []string
+	AllSecrets     bool
+	Comment        string
+	CreatedOn      time.Time
+}
+
+// integrationRegistry holds the integrations, keyed by their upper-case names.
+// Integrations are account objects, so names are not qualified.
+type integrationRegistry struct {
+	mu     sync.Mutex
+	byName map[string]*integration
+}
+
+// showIntegrationsNames are the columns of a SHOW INTEGRATIONS result.
+var showIntegrationsNames = []string{"name", "type", "category", "enabled", "comment", "created_on"}
+
+// integrationKinds are the words between DROP or SHOW and INTEGRATION[S] that
+// select a category of integrations.
+var integrationKinds = map[string]string{
+	"NOTIFICATION":    integrationNotification,
+	"EXTERNAL ACCESS": integrationSecurity,
+}
+
+// integrationName returns the account-level name of an integration, which is
+// upper-cased unless quoted.
+func integrationName(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		return unquoteIdentifier(name)
+	}
+	return strings.ToUpper(name)
+}
+
+// objectProperties parses the "NAME = value" properties that follow the name
+// of an object in a CREATE statement. String literals are unquoted, and lists
+// are returned with their parentheses, to be split with propertyList.
+func objectProperties(s string) (map[string]string, error) {
+	properties := map[string]string{}
+	i := 0
+	for {
+		for i < len(s) && (isSpace(s[i]) || s[i] == ';') {
+			i++
+		}
+		if i >= len(s) {
+			return properties, nil
+		}
+		start := i
+		for i < len(s) && isIdentChar(s[i]) {
+			i++
+		}
+		name := strings.ToUpper(s[start:i])
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if name == "" || i >= len(s) || s[i] != '=' {
+			return nil, fmt.Errorf("expected NAME = value at %q", strings.TrimSpace(s[start:]))
+		}
+		i++
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+
+		switch {
+		case i < len(s) && s[i] == '\'':
+			value, end, ok := commentValueAt(s, i)
+			if !ok || s[end-1] != '\'' {
+				return nil, fmt.Errorf("unterminated value of %s", name)
+			}
+			properties[name] = value
+			i = end
+		case i < len(s) && s[i] == '(':
+			end := matchingParen(s[i:])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated list of %s", name)
+			}
+			properties[name] = s[i : i+end+1]
+			i += end + 1
+		default:
+			start := i
+			for i < len(s) && !isSpace(s[i]) && s[i] != ';' {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("%s has no value", name)
+			}
+			properties[name] = s[start:i]
+		}
+	}
+}
+
+// propertyList splits a parenthesized property list, unquoting its string
+// literals. It reports false if value is not a list.
+func propertyList(value string) ([]string, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "(") || matchingParen(value) != len(value)-1 {
+		return nil, false
+	}
+	items := []string{}
+	if inner := strings.TrimSpace(value[1 : len(value)-1]); inner != "" {
+		for _, item := range splitFunctionArgs(inner, 0) {
+			item = strings.TrimSpace(item)
+			if unquoted, end, ok := commentValueAt(item, 0); ok && end == len(item) {
+				item = unquoted
+			}
+			items = append(items, item)
+		}
+	}
+	return items, true
+}
+
+// createProperties returns the properties of a CREATE statement, which follow
+// the object's name.
+func createProperties(stmt *createStatement) (map[string]string, error) {
+	s := strings.TrimRight(stmt.SQL, "; \t\r\n")
+	kind := strings.Fields(stmt.Kind)
+	i := indexFold(s, kind[len(kind)-1]) + len(kind[len(kind)-1])
+	i += strings.Index(s[i:], stmt.Name) + len(stmt.Name)
+	properties, err := objectProperties(s[i:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid properties of %s %s: %w", strings.ToLower(stmt.Kind), stmt.Name, err)
+	}
+	return properties, nil
+}
+
+// registerIntegration registers an integration created by stmt with the
+// executor.
+func (e *Executor) registerIntegration(stmt *createStatement, created *integration) (*ExecResult, error) {
+	created.CreatedOn = time.Now()
+
+	e.integrations.mu.Lock()
+	defer e.integrations.mu.Unlock()
+	if _, exists := e.integrations.byName[created.Name]; exists {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case !stmt.OrReplace:
+			return nil, fmt.Errorf("integration %s already exists", created.Name)
+		}
+	}
+	if e.integrations.byName == nil {
+		e.integrations.byName = make(map[string]*integration)
+	}
+	e.integrations.byName[created.Name] = created
+	return &ExecResult{}, nil
+}
+
+// lookupIntegration returns a copy of an integration, or false if it does not
+// exist.
+func (e *Executor) lookupIntegration(name string) (integration, bool) {
+	e.integrations.mu.Lock()
+	defer e.integrations.mu.Unlock()
+	found, ok := e.integrations.byName[name]
+	if !ok {
+		return integration{}, false
+	}
+	return *found, true
+}
+
+// integrationStatement returns the category selected by the words between the
+// verb and INTEGRATION[S] of a DROP or SHOW statement, "" for all integrations,
+// and the words after INTEGRATION[S]. It reports false for other statements.
+func integrationStatement(sql, verb, noun string) (category string, rest []string, ok bool) {
+	fields := strings.Fields(strings.TrimRight(stripLeadingComments(sql), "; \t\r\n"))
+	if len(fields) < 2 || !strings.EqualFold(fields[0], verb) {
+		return "", nil, false
+	}
+	for i := 1; i < len(fields) && i <= 3; i++ {
+		if !strings.EqualFold(fields[i], noun) {
+			continue
+		}
+		kind := strings.ToUpper(strings.Join(fields[1:i], " "))
+		if kind == "" {
+			return "", fields[i+1:], true
+		}
+		category, ok := integrationKinds[kind]
+		return category, fields[i+1:], ok
+	}
+	return "", nil, false
+}
+
+// dropIntegrationName returns the name of the integration dropped by a DROP
+// [NOTIFICATION | EXTERNAL ACCESS] INTEGRATION [IF EXISTS] name statement.
+func dropIntegrationName(sql string) (name string, ifExists, ok bool) {
+	_, fields, ok := integrationStatement(sql, "DROP", "INTEGRATION")
+	if !ok || len(fields) == 0 {
+		return "", false, false
+	}
+	if len(fields) >= 3 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") {
+		ifExists = true
+		fields = fields[2:]
+	}
+	return integrationName(fields[0]), ifExists, true
+}
+
+// executeDropIntegration drops an integration.
+func (e *Executor) executeDropIntegration(name string, ifExists bool) (*ExecResult, error) {
+	e.integrations.mu.Lock()
+	defer e.integrations.mu.Unlock()
+	if _, ok := e.integrations.byName[name]; !ok {
+		if ifExists {
+			return &ExecResult{}, nil
+		}
+		return nil, fmt.Errorf("integration %s does not exist", name)
+	}
+	delete(e.integrations.byName, name)
+	return &ExecResult{}, nil
+}
+
+// showIntegrationsCategory reports whether sql is SHOW [NOTIFICATION |
+// EXTERNAL ACCESS] INTEGRATIONS, and returns the category it lists, or "" for
+// all integrations.
+func showIntegrationsCategory(sql string) (string, bool) {
+	category, _, ok := integrationStatement(sql, "SHOW", "INTEGRATIONS")
+	return category, ok
+}
+
+// queryShowIntegrations lists the integrations of a category, or of all
+// categories when category is "", filtered by an optional LIKE pattern.
+func (e *Executor) queryShowIntegrations(sql, category string) (*Result, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	rest := strings.TrimSpace(s[indexFold(s, "INTEGRATIONS")+len("INTEGRATIONS"):])
+	like := ""
+	if keywordAt(rest, 0, "LIKE") {
+		value, _, ok := commentValueAt(rest, len("LIKE"))
+		if !ok {
+			return nil, fmt.Errorf("SHOW INTEGRATIONS: invalid LIKE pattern")
+		}
+		like = value
+	}
+
+	result := &Result{Columns: showIntegrationsNames}
+	for _, name := range showIntegrationsNames {
+		col := types.ColumnMetadata{Type: "text", Nullable: true}
+		switch name {
+		case "enabled":
+			col.Type = "boolean"
+		case "created_on":
+			col.Type = "timestamp_ltz"
+		}
+		col.Name = name
+		result.ColumnTypes = append(result.ColumnTypes, col)
+	}
+
+	e.integrations.mu.Lock()
+	defer e.integrations.mu.Unlock()
+	names := make([]string, 0, len(e.integrations.byName))
+	for name, listed := range e.integrations.byName {
+		if (category == "" || listed.Category == category) && (like == "" || likePattern(like).MatchString(name)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		listed := e.integrations.byName[name]
+		result.Rows = append(result.Rows, []interface{}{
+			listed.Name, listed.Type, listed.Category, listed.Enabled, listed.Comment, listed.CreatedOn,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// intervalTerm matches one "<n> <part>" term of an interval literal, with its
+// separating comma. The part is optional and defaults to seconds.
+var intervalTerm = regexp.MustCompile(`^\s*([+-]?(?:\d+\.?\d*|\.\d+))\s*([A-Za-z_]*)\s*,?`)
+
+// intervalUnits maps the date and time parts of Snowflake interval literals to
+// the units of DuckDB interval strings. Parts are resolved through
+// datePartAliases first.
+var intervalUnits = map[string]string{
+	"YEAR":         "year",
+	"QUARTER":      "quarter",
+	"MONTH":        "month",
+	"WEEK":         "week",
+	"DAY":          "day",
+	"HOUR":         "hour",
+	"MINUTE":       "minute",
+	"SECOND":       "second",
+	"MS":           "millisecond",
+	"MSEC":         "millisecond",
+	"MILLISECOND":  "millisecond",
+	"MILLISECONDS": "millisecond",
+	"US":           "microsecond",
+	"USEC":         "microsecond",
+	"MICROSECOND":  "microsecond",
+	"MICROSECONDS": "microsecond",
+}
+
+// translateIntervalLiterals rewrites the strings of INTERVAL literals from
+// Snowflake's comma-separated form into DuckDB's, e.g. INTERVAL '1 day, 3 hours'
+// becomes INTERVAL '1 day 3 hour'. Literals that are not valid Snowflake
+// intervals are left unchanged.
+func translateIntervalLiterals(sql string) string {
+	return replaceIntervalLiterals(sql, func(spec string) string {
+		if duckSpec, ok := intervalSpec(spec); ok {
+			spec = duckSpec
+		}
+		return fmt.Sprintf("INTERVAL '%s'", spec)
+	})
+}
+
+// markIntervalLiterals rewrites INTERVAL '<spec>' literals into __INTERVAL__
+// calls, which the parser accepts. transformIntervals restores them.
+func markIntervalLiterals(sql string) string {
+	return replaceIntervalLiterals(sql, func(spec string) string {
+		return fmt.Sprintf("__INTERVAL__('%s')", spec)
+	})
+}
+
+// transformIntervals restores the INTERVAL literals marked by markIntervalLiterals.
+func (t *Translator) transformIntervals(sql string) string {
+	return t.transformMarkedFunction(sql, "__INTERVAL__", func(args string) string {
+		return "INTERVAL " + strings.TrimSpace(args)
+	})
+}
+
+// replaceIntervalLiterals replaces each INTERVAL '<spec>' literal in sql with
+// the result of replace. Literals inside strings, quoted identifiers, and
+// comments are left alone.
+func replaceIntervalLiterals(sql string, replace func(spec string) string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case keywordAt(sql, i, "INTERVAL"):
+			open := i + len("INTERVAL")
+			for open < len(sql) && isSpace(sql[open]) {
+				open++
+			}
+			if open == len(sql) || sql[open] != '\'' {
+				continue
+			}
+			closing := skipQuoted(sql, open, '\'')
+			if closing <= open || sql[closing] != '\'' {
+				return sql
+			}
+			b.WriteString(sql[last:i])
+			b.WriteString(replace(sql[open+1 : closing]))
+			last = closing + 1
+			i = closing
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	if last == 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
+
+// intervalSpec converts the string of a Snowflake interval literal, a
+// comma-separated list of "<n> <part>" terms, into a DuckDB interval string.
+// Terms may be negative and mix months with days and times, as DuckDB keeps
+// them apart like Snowflake. Nanoseconds are rounded to microseconds.
+func intervalSpec(spec string) (string, bool) {
+	var terms []string
+	for rest := spec; strings.TrimSpace(rest) != ""; {
+		match := intervalTerm.FindStringSubmatch(rest)
+		if match == nil {
+			return "", false
+		}
+		rest = rest[len(match[0]):]
+
+		number, part := match[1], strings.ToUpper(match[2])
+		if part == "" {
+			part = "SECOND"
+		}
+		if alias, ok := datePartAliases[part]; ok {
+			part = alias
+		}
+		if part == "NANOSECOND" {
+			nanoseconds, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return "", false
+			}
+			number, part = strconv.FormatFloat(nanoseconds/1000, 'f', -1, 64), "MICROSECOND"
+		}
+		unit, ok := intervalUnits[part]
+		if !ok {
+			return "", false
+		}
+		terms = append(terms, number+" "+unit)
+	}
+	if len(terms) == 0 {
+		return "", false
+	}
+	return strings.Join(terms, " "), true
+}
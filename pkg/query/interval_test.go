@@ -0,0 +1,57 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIntervalSpec(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		want   string
+		wantOK bool
+	}{
+		{name: "CommaSeparated", spec: "1 day, 3 hours", want: "1 day 3 hour", wantOK: true},
+		{name: "Abbreviations", spec: "2 y,3mm, 4 d", want: "2 year 3 month 4 day", wantOK: true},
+		{name: "Negative", spec: "-1 month, 2 days", want: "-1 month 2 day", wantOK: true},
+		{name: "DefaultSeconds", spec: "10", want: "10 second", wantOK: true},
+		{name: "SubSecond", spec: "5 ms, 7 us, 2000 ns", want: "5 millisecond 7 microsecond 2 microsecond", wantOK: true},
+		{name: "UnknownPart", spec: "1 decade", wantOK: false},
+		{name: "TimeString", spec: "01:02:03", wantOK: false},
+		{name: "Empty", spec: " ", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := intervalSpec(tt.spec)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("intervalSpec(%q) = %q, %v, want %q, %v", tt.spec, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestExecutor_IntervalLiterals tests arithmetic with Snowflake interval literals.
+func TestExecutor_IntervalLiterals(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+
+	result, err := executor.Query(context.Background(), `SELECT
+		CAST('2024-01-31' AS TIMESTAMP) + INTERVAL '1 month, 3 hours',
+		CAST('2024-01-01' AS TIMESTAMP) - INTERVAL '2 months, -3 d',
+		CAST('2024-01-01' AS TIMESTAMP) - INTERVAL '90'`)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := [][]interface{}{{
+		time.Date(2024, 2, 29, 3, 0, 0, 0, time.UTC),
+		time.Date(2023, 11, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 31, 23, 58, 30, 0, time.UTC),
+	}}
+	if diff := cmp.Diff(want, result.Rows); diff != "" {
+		t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,265 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
+)
+
+// lambdaMarker marks a lambda expression, params -> body, which the parser
+// rejects, as __LAMBDA__('params', body). Its body is translated like any other
+// expression.
+const lambdaMarker = "__LAMBDA__"
+
+// lambda is a lambda expression of FILTER, TRANSFORM, or REDUCE.
+type lambda struct {
+	// names are the names of the parameters, and types their DuckDB types, or
+	// "" for parameters declared without a type.
+	names []string
+	types []string
+	body  string
+}
+
+// String returns the lambda in DuckDB's syntax. The lambda keyword form is
+// used rather than an arrow, which DuckDB also reads as a JSON operator.
+func (l lambda) String() string {
+	return fmt.Sprintf("lambda %s: %s", strings.Join(l.names, ", "), l.body)
+}
+
+// markLambdas rewrites the lambda expressions of sql, such as x -> x > 1,
+// x INT -> x * 2, and (acc, val) -> acc + val, into __LAMBDA__ calls, which
+// the parser accepts. Arrows inside strings, quoted identifiers, and comments
+// are left alone.
+func markLambdas(sql string) string {
+	for {
+		arrow := lambdaArrow(sql)
+		if arrow < 0 {
+			return sql
+		}
+		start, end := lambdaStart(sql, arrow), lambdaEnd(sql, arrow+2)
+		if start < 0 || end < 0 {
+			return sql
+		}
+		params := strings.TrimSpace(sql[start:arrow])
+		body := strings.TrimSpace(sql[arrow+2 : end])
+		sql = sql[:start] + fmt.Sprintf("%s(%s, %s)", lambdaMarker, quoteLiteral(params), body) + sql[end:]
+	}
+}
+
+// markLambdaFunctions rewrites the calls of FILTER, TRANSFORM, and REDUCE
+// that take a lambda marked by markLambdas into their markers, as the parser
+// would, and reports whether it found any. It serves statements the parser
+// rejects, such as those with array literals like [1, 2, 3]. FILTER clauses of
+// aggregates are left alone, since they take no lambda.
+func markLambdaFunctions(sql string) (string, bool) {
+	var b strings.Builder
+	copied := 0
+	scanFunctionCalls(sql, func(name string, start, end int) bool {
+		if name != "FILTER" && name != "TRANSFORM" && name != "REDUCE" {
+			return true
+		}
+		open := end + strings.IndexByte(sql[end:], '(')
+		closing := matchingParen(sql[open:])
+		if closing < 0 || !strings.Contains(sql[open:open+closing], lambdaMarker+"(") {
+			return true
+		}
+		b.WriteString(sql[copied:start])
+		b.WriteString(semiStructuredMarkers[name])
+		copied = open
+		return true
+	})
+	if copied == 0 {
+		return sql, false
+	}
+	b.WriteString(sql[copied:])
+	return b.String(), true
+}
+
+// transformFallbackLambdas translates the calls of FILTER, TRANSFORM, and
+// REDUCE with lambdas in a statement the parser rejected. Their lambdas are
+// otherwise left in Snowflake's syntax, which DuckDB reads differently.
+func (t *Translator) transformFallbackLambdas(sql string) string {
+	marked, ok := markLambdaFunctions(markLambdas(sql))
+	if !ok {
+		return sql
+	}
+	return t.transformLambdaFunctions(marked)
+}
+
+// lambdaArrow returns the index of the first -> of sql, or -1 if there is none.
+func lambdaArrow(sql string) int {
+	for i := 0; i < len(sql)-1; i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '-' && sql[i+1] == '>' && (i+2 == len(sql) || sql[i+2] != '>'):
+			return i
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	return -1
+}
+
+// lambdaStart returns the index where the parameters of the lambda whose arrow
+// is at sql[arrow] start, after the comma or parenthesis before them.
+func lambdaStart(sql string, arrow int) int {
+	depth := 0
+	for i := arrow - 1; i >= 0; i-- {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			return -1
+		case c == ')':
+			depth++
+		case c == '(' && depth > 0:
+			depth--
+		case depth == 0 && (c == '(' || c == ','):
+			i++
+			for i < arrow && isSpace(sql[i]) {
+				i++
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// lambdaEnd returns the index of the comma or parenthesis that ends the body
+// of a lambda starting at sql[start].
+func lambdaEnd(sql string, start int) int {
+	depth := 0
+	for i := start; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case (c == ')' || c == ']' || c == '}') && depth > 0:
+			depth--
+		case depth == 0 && (c == ')' || c == ','):
+			return i
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	return -1
+}
+
+// parseLambda reads a lambda marked by markLambdas. Parameter types are mapped
+// to the DuckDB types that store them.
+func parseLambda(arg string) (lambda, bool) {
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, lambdaMarker+"(") || !strings.HasSuffix(arg, ")") {
+		return lambda{}, false
+	}
+	parts := splitFunctionArgs(arg[len(lambdaMarker)+1:len(arg)-1], 2)
+	if len(parts) != 2 {
+		return lambda{}, false
+	}
+	literal := strings.TrimSpace(parts[0])
+	if len(literal) < 2 || literal[0] != '\'' || literal[len(literal)-1] != '\'' {
+		return lambda{}, false
+	}
+	params := strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	if strings.HasPrefix(params, "(") && strings.HasSuffix(params, ")") {
+		params = params[1 : len(params)-1]
+	}
+
+	l := lambda{body: strings.TrimSpace(parts[1])}
+	for _, param := range splitFunctionArgs(params, 2) {
+		fields := strings.Fields(param)
+		if len(fields) == 0 {
+			return lambda{}, false
+		}
+		duckType := strings.Join(fields[1:], " ")
+		if mapped, ok := sftypes.DuckDBTypeFor(duckType); ok {
+			duckType = mapped
+		}
+		l.names = append(l.names, fields[0])
+		l.types = append(l.types, duckType)
+	}
+	return l, true
+}
+
+// inferTypes types the parameters declared without a type that the body uses
+// as operands of arithmetic as DOUBLE, since Snowflake computes with VARIANT
+// values but DuckDB cannot compute with JSON ones. Other untyped parameters
+// stay JSON, so that they may hold arrays and objects.
+func (l *lambda) inferTypes() {
+	for i, name := range l.names {
+		if l.types[i] == "" && arithmeticOperand(l.body, name) {
+			l.types[i] = "DOUBLE"
+		}
+	}
+}
+
+// arithmeticOperand reports whether the identifier name is an operand of +,
+// -, *, /, or % in expr. Names inside strings and quoted identifiers, and
+// those following a . or :, which name fields rather than the parameter, are
+// not counted.
+func arithmeticOperand(expr, name string) bool {
+	isOperator := func(c byte) bool { return strings.IndexByte("+-*/%", c) >= 0 }
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(expr, i, c)
+		case isIdentChar(c):
+			end := i
+			for end < len(expr) && isIdentChar(expr[end]) {
+				end++
+			}
+			if !strings.EqualFold(expr[i:end], name) || (i > 0 && (expr[i-1] == '.' || expr[i-1] == ':')) {
+				i = end - 1
+				continue
+			}
+			before := i - 1
+			for before >= 0 && isSpace(expr[before]) {
+				before--
+			}
+			after := end
+			for after < len(expr) && isSpace(expr[after]) {
+				after++
+			}
+			if (before >= 0 && isOperator(expr[before])) || (after < len(expr) && isOperator(expr[after]) &&
+				(after+1 == len(expr) || expr[after+1] != '>')) {
+				return true
+			}
+			i = end - 1
+		}
+	}
+	return false
+}
+
+// transformLambdas restores the lambdas left in sql, those passed to functions
+// other than FILTER, TRANSFORM, and REDUCE, in DuckDB's syntax.
+func (t *Translator) transformLambdas(sql string) string {
+	return t.transformMarkedFunction(sql, lambdaMarker, func(args string) string {
+		l, ok := parseLambda(lambdaMarker + "(" + args + ")")
+		if !ok {
+			return args
+		}
+		return l.String()
+	})
+}
+
+// lambdaElements returns the expression converting the list of JSON values
+// list to the type of a lambda parameter. Strings are unquoted for text types,
+// and parameters without a type take the JSON values as they are.
+func lambdaElements(list, duckType string) string {
+	switch {
+	case duckType == "":
+		return list
+	case duckType == "VARCHAR" || strings.HasPrefix(duckType, "VARCHAR("):
+		return fmt.Sprintf("list_transform(%s, lambda __e: json_extract_string(__e, '$'))", list)
+	default:
+		return fmt.Sprintf("list_transform(%s, lambda __e: CAST(__e AS %s))", list, duckType)
+	}
+}
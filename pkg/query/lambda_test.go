@@ -0,0 +1,75 @@
+package query
+
+import "testing"
+
+func TestMarkLambdas(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "OneParameter", sql: "SELECT FILTER(arr, x -> x > 1)", want: "SELECT FILTER(arr, __LAMBDA__('x', x > 1))"},
+		{name: "TypedParameter", sql: "SELECT TRANSFORM(arr, x NUMBER(10, 2) -> x * 2) FROM t", want: "SELECT TRANSFORM(arr, __LAMBDA__('x NUMBER(10, 2)', x * 2)) FROM t"},
+		{name: "TwoParameters", sql: "SELECT REDUCE(arr, 0, (acc, val) -> acc + f(val, 1))", want: "SELECT REDUCE(arr, 0, __LAMBDA__('(acc, val)', acc + f(val, 1)))"},
+		{name: "Nested", sql: "SELECT TRANSFORM(a, x -> FILTER(x, y -> y > 1))", want: "SELECT TRANSFORM(a, __LAMBDA__('x', FILTER(x, __LAMBDA__('y', y > 1))))"},
+		{name: "ArrowInString", sql: "SELECT 'x -> y'", want: "SELECT 'x -> y'"},
+		{name: "ArrowInComment", sql: "SELECT 1 -- x -> y", want: "SELECT 1 -- x -> y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markLambdas(tt.sql); got != tt.want {
+				t.Errorf("markLambdas(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkLambdaFunctions(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		want   string
+		wantOK bool
+	}{
+		{name: "Transform", sql: "SELECT TRANSFORM ([1], __LAMBDA__('x', x))", want: "SELECT __TRANSFORM__([1], __LAMBDA__('x', x))", wantOK: true},
+		{name: "Nested", sql: "SELECT filter([[1]], __LAMBDA__('a', REDUCE(a, 0, __LAMBDA__('(s, x)', s + x)) > 0))", want: "SELECT __FILTER__([[1]], __LAMBDA__('a', __REDUCE__(a, 0, __LAMBDA__('(s, x)', s + x)) > 0))", wantOK: true},
+		{name: "AggregateFilter", sql: "SELECT COUNT(*) FILTER (WHERE x > 1) FROM [1]", want: "SELECT COUNT(*) FILTER (WHERE x > 1) FROM [1]"},
+		{name: "NoLambda", sql: "SELECT TRANSFORM(a, b)", want: "SELECT TRANSFORM(a, b)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := markLambdaFunctions(tt.sql)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("markLambdaFunctions(%q) = %q, %v, want %q, %v", tt.sql, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestArithmeticOperand(t *testing.T) {
+	tests := []struct {
+		expr string
+		name string
+		want bool
+	}{
+		{expr: "x * 2", name: "x", want: true},
+		{expr: "1 + X", name: "x", want: true},
+		{expr: "f(x) % 2 = 0", name: "x", want: false},
+		{expr: "x % 2 = 0", name: "x", want: true},
+		{expr: "-x", name: "x", want: true},
+		{expr: "x > 1", name: "x", want: false},
+		{expr: "xs * 2", name: "x", want: false},
+		{expr: "v:x * 2", name: "x", want: false},
+		{expr: "t.x * 2", name: "x", want: false},
+		{expr: "'x' || x", name: "x", want: false},
+		{expr: "'x * 2' = x", name: "x", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := arithmeticOperand(tt.expr, tt.name); got != tt.want {
+			t.Errorf("arithmeticOperand(%q, %q) = %v, want %v", tt.expr, tt.name, got, tt.want)
+		}
+	}
+}
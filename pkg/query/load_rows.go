@@ -0,0 +1,322 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// Formats of the rows LoadRows reads.
+const (
+	LoadFormatCSV    = "csv"
+	LoadFormatTSV    = "tsv"
+	LoadFormatNDJSON = "ndjson"
+)
+
+// ErrTableNotFound is returned by LoadRows and PreviewTable for a table that
+// does not exist.
+var ErrTableNotFound = errors.New("table does not exist or not authorized")
+
+// loadTable is the name of the temporary table holding the rows appended by
+// LoadRows, as its INSERT reads them.
+const loadTable = "loaded_rows"
+
+// LoadRows loads rows in the given format into a table with DuckDB's
+// appender, without staging them, and returns the number of rows loaded. The
+// table may have been created with SQL or through the REST API.
+//
+// CSV and TSV start with a header naming table columns, case-insensitively;
+// the columns it leaves out get their defaults, and empty fields are NULL.
+// NDJSON has an object per line keyed by column name; columns an object leaves
+// out are NULL. Values are converted to their column's type, so a value that
+// does not convert, or a name that is not a column, fails the load, and no
+// rows are loaded.
+func (e *Executor) LoadRows(ctx context.Context, database, schema, table, format string, r io.Reader) (int64, error) {
+	tableName, err := e.physicalTableName(ctx, database, schema, table)
+	if err != nil {
+		return 0, err
+	}
+	described, err := e.queryRows(ctx, "DESCRIBE SELECT * FROM "+tableName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe table %s: %w", table, err)
+	}
+	columns := make([]copyColumn, len(described.Rows))
+	for i, row := range described.Rows {
+		columns[i] = copyColumn{name: fmt.Sprint(row[0]), dataType: fmt.Sprint(row[1])}
+	}
+
+	var source rowSource
+	switch format {
+	case LoadFormatCSV, LoadFormatTSV:
+		source, err = newDelimitedRowSource(r, format == LoadFormatTSV, columns, table)
+	case LoadFormatNDJSON:
+		source = newNDJSONRowSource(r, columns, table)
+	default:
+		return 0, fmt.Errorf("unsupported load format: %s", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var loaded int64
+	err = e.manager(ctx).Raw(ctx, func(driverConn any) error {
+		conn, ok := driverConn.(driver.Conn)
+		if !ok {
+			return errors.New("loading rows requires a DuckDB connection")
+		}
+		loaded, err = appendRows(ctx, conn, tableName, source)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return loaded, nil
+}
+
+// physicalTableName returns the qualified DuckDB name of a table of the REST
+// API's resource paths, created with SQL or through the REST API.
+func (e *Executor) physicalTableName(ctx context.Context, database, schema, table string) (string, error) {
+	notFound := fmt.Errorf("%w: %s.%s.%s", ErrTableNotFound, database, schema, table)
+	db, err := e.repo.GetDatabaseByName(ctx, database)
+	if err != nil {
+		return "", notFound
+	}
+	schemas, err := e.databaseSchemas(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	for _, sch := range schemas {
+		if !strings.EqualFold(sch.Name, schema) {
+			continue
+		}
+		tables, err := e.schemaTables(ctx, db, sch.Name)
+		if err != nil {
+			return "", err
+		}
+		for _, t := range tables {
+			if strings.EqualFold(t.Name, table) {
+				return t.Physical, nil
+			}
+		}
+	}
+	return "", notFound
+}
+
+// appendRows appends the rows of source to a table in a transaction, through
+// a query appender that converts the batched text values to the column types.
+func appendRows(ctx context.Context, conn driver.Conn, tableName string, source rowSource) (int64, error) {
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return 0, errors.New("loading rows requires a DuckDB connection")
+	}
+	columns := source.columns()
+	varchar, err := duckdb.NewTypeInfo(duckdb.TYPE_VARCHAR)
+	if err != nil {
+		return 0, err
+	}
+	names := make([]string, len(columns))
+	casts := make([]string, len(columns))
+	colTypes := make([]duckdb.TypeInfo, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdent(col.name)
+		casts[i] = fmt.Sprintf("CAST(%s AS %s)", quoteIdent(col.name), col.dataType)
+		colTypes[i] = varchar
+	}
+	insertSQL := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+		tableName, strings.Join(names, ", "), strings.Join(casts, ", "), loadTable)
+
+	if _, err := execer.ExecContext(ctx, "BEGIN TRANSACTION", nil); err != nil {
+		return 0, err
+	}
+	rollback := func(err error) (int64, error) {
+		_, _ = execer.ExecContext(context.WithoutCancel(ctx), "ROLLBACK", nil)
+		return 0, err
+	}
+
+	appender, err := duckdb.NewQueryAppender(conn, insertSQL, loadTable, colTypes, namesOf(columns))
+	if err != nil {
+		return rollback(fmt.Errorf("failed to load rows: %w", err))
+	}
+	var loaded int64
+	for {
+		// The appender does not see the context, so a canceled load stops here
+		if err := ctx.Err(); err != nil {
+			_ = appender.Close()
+			return rollback(err)
+		}
+		row, err := source.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			err = appender.AppendRow(row...)
+		}
+		if err != nil {
+			_ = appender.Close()
+			return rollback(fmt.Errorf("failed to load row %d: %w", loaded+1, err))
+		}
+		loaded++
+	}
+	if err := appender.Close(); err != nil {
+		return rollback(fmt.Errorf("failed to load rows: %w", err))
+	}
+	if _, err := execer.ExecContext(ctx, "COMMIT", nil); err != nil {
+		return rollback(err)
+	}
+	return loaded, nil
+}
+
+// namesOf returns the names of columns.
+func namesOf(columns []copyColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	return names
+}
+
+// rowSource reads the rows LoadRows loads, as text values of its columns or
+// nil for NULL. next returns io.EOF after the last row.
+type rowSource interface {
+	columns() []copyColumn
+	next() ([]driver.Value, error)
+}
+
+// loadColumn returns the column of a table a loaded field names: the column
+// of exactly that name, or else the only one matching it case-insensitively.
+func loadColumn(columns []copyColumn, name, table string) (int, error) {
+	match := -1
+	for i, col := range columns {
+		if col.name == name {
+			return i, nil
+		}
+		if strings.EqualFold(col.name, name) {
+			if match >= 0 {
+				return 0, fmt.Errorf("column %q is ambiguous in table %s", name, table)
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return 0, fmt.Errorf("column %q does not exist in table %s", name, table)
+	}
+	return match, nil
+}
+
+// delimitedRowSource reads CSV or TSV rows with a header.
+type delimitedRowSource struct {
+	reader *csv.Reader
+	cols   []copyColumn
+}
+
+// newDelimitedRowSource reads the header of CSV or TSV rows and matches it to
+// the table's columns.
+func newDelimitedRowSource(r io.Reader, tabs bool, columns []copyColumn, table string) (*delimitedRowSource, error) {
+	reader := csv.NewReader(r)
+	if tabs {
+		reader.Comma = '\t'
+	}
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+	source := &delimitedRowSource{reader: reader}
+	seen := make(map[int]bool)
+	for _, name := range header {
+		i, err := loadColumn(columns, strings.TrimSpace(name), table)
+		if err != nil {
+			return nil, err
+		}
+		if seen[i] {
+			return nil, fmt.Errorf("column %q appears more than once in the header", columns[i].name)
+		}
+		seen[i] = true
+		source.cols = append(source.cols, columns[i])
+	}
+	return source, nil
+}
+
+func (s *delimitedRowSource) columns() []copyColumn { return s.cols }
+
+func (s *delimitedRowSource) next() ([]driver.Value, error) {
+	record, err := s.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]driver.Value, len(record))
+	for i, field := range record {
+		if field != "" {
+			row[i] = field
+		}
+	}
+	return row, nil
+}
+
+// ndjsonRowSource reads a JSON object per line.
+type ndjsonRowSource struct {
+	scanner *bufio.Scanner
+	cols    []copyColumn
+	table   string
+	line    int
+}
+
+// newNDJSONRowSource reads NDJSON rows of a table's columns.
+func newNDJSONRowSource(r io.Reader, columns []copyColumn, table string) *ndjsonRowSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	return &ndjsonRowSource{scanner: scanner, cols: columns, table: table}
+}
+
+func (s *ndjsonRowSource) columns() []copyColumn { return s.cols }
+
+// next decodes the next non-empty line. Strings load as their text, except
+// into JSON columns, which load every value as JSON.
+func (s *ndjsonRowSource) next() ([]driver.Value, error) {
+	var line []byte
+	for len(line) == 0 {
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		s.line++
+		line = bytes.TrimSpace(s.scanner.Bytes())
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(line, &object); err != nil {
+		return nil, fmt.Errorf("line %d is not a JSON object: %w", s.line, err)
+	}
+	row := make([]driver.Value, len(s.cols))
+	for name, raw := range object {
+		i, err := loadColumn(s.cols, name, s.table)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", s.line, err)
+		}
+		switch {
+		case string(raw) == "null":
+		case s.cols[i].dataType == "JSON" || raw[0] != '"':
+			row[i] = string(raw)
+		default:
+			var text string
+			if err := json.Unmarshal(raw, &text); err != nil {
+				return nil, fmt.Errorf("line %d: %w", s.line, err)
+			}
+			row[i] = text
+		}
+	}
+	return row, nil
+}
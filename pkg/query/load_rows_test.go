@@ -0,0 +1,94 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_LoadRows tests loading CSV, TSV, and NDJSON rows into a table.
+func TestExecutor_LoadRows(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1", Database: "LOAD_DB", Schema: "PUBLIC"})
+
+	if _, err := repo.CreateDatabase(ctx, "LOAD_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	if _, err := executor.Execute(ctx, "CREATE TABLE items (id INTEGER, name VARCHAR DEFAULT 'none', price NUMBER(10, 2), attrs VARIANT)"); err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		data     string
+		want     int64
+		wantErr  string
+		wantRows [][]interface{}
+	}{
+		{
+			name:   "CSV",
+			format: LoadFormatCSV,
+			data:   "ID,Price,attrs\n1,9.99,\"{\"\"color\"\": \"\"red\"\"}\"\n2,,\n",
+			want:   2,
+			wantRows: [][]interface{}{
+				{"1", "none", "9.99", `{"color": "red"}`},
+				{"2", "none", nil, nil},
+			},
+		},
+		{
+			name:     "TSV",
+			format:   LoadFormatTSV,
+			data:     "id\tname\n3\tlamp\n",
+			want:     1,
+			wantRows: [][]interface{}{{"3", "lamp", nil, nil}},
+		},
+		{
+			name:   "NDJSON",
+			format: LoadFormatNDJSON,
+			data:   "{\"id\": 4, \"name\": \"desk\", \"attrs\": {\"legs\": 4}}\n\n{\"ID\": \"5\", \"price\": 12.5, \"attrs\": \"oak\"}\n",
+			want:   2,
+			wantRows: [][]interface{}{
+				{"4", "desk", nil, `{"legs": 4}`},
+				{"5", nil, "12.50", `"oak"`},
+			},
+		},
+		{name: "UnknownColumn", format: LoadFormatCSV, data: "id,color\n6,red\n", wantErr: `column "color" does not exist`},
+		{name: "DuplicateColumn", format: LoadFormatCSV, data: "id,ID\n6,6\n", wantErr: "more than once"},
+		{name: "MissingHeader", format: LoadFormatCSV, data: "", wantErr: "missing header row"},
+		{name: "Conversion", format: LoadFormatCSV, data: "id\n6\nseven\n", wantErr: "seven"},
+		{name: "NotAnObject", format: LoadFormatNDJSON, data: "{\"id\": 6}\n[7]\n", wantErr: "line 2 is not a JSON object"},
+		{name: "Format", format: "xml", data: "<id>6</id>", wantErr: "unsupported load format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := executor.Execute(ctx, "DELETE FROM items"); err != nil {
+				t.Fatalf("DELETE error = %v", err)
+			}
+			got, err := executor.LoadRows(ctx, "load_db", "public", "items", tt.format, strings.NewReader(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadRows() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("LoadRows() = %d, %v, want %d", got, err, tt.want)
+			}
+
+			// Failed loads load no rows
+			result, err := executor.Query(ctx, "SELECT id::VARCHAR, name, price::VARCHAR, attrs::VARCHAR FROM items ORDER BY id")
+			if err != nil {
+				t.Fatalf("SELECT error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantRows, result.Rows); diff != "" {
+				t.Errorf("rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := executor.LoadRows(ctx, "LOAD_DB", "PUBLIC", "missing", LoadFormatCSV, strings.NewReader("id\n1\n")); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("LoadRows() into a missing table error = %v, want ErrTableNotFound", err)
+	}
+}
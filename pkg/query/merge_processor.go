@@ -145,12 +145,12 @@ func (h *MergeProcessor) ParseMergeStatement(sql string) (*MergeStatement, error
 	}
 	onCondition := onMatch[1]
 	// Truncate at WHEN keyword (case-insensitive)
-	whenIdx := strings.Index(strings.ToUpper(onCondition), " WHEN")
+	whenIdx := indexFold(onCondition, " WHEN")
 	if whenIdx == -1 {
-		whenIdx = strings.Index(strings.ToUpper(onCondition), "\nWHEN")
+		whenIdx = indexFold(onCondition, "\nWHEN")
 	}
 	if whenIdx == -1 {
-		whenIdx = strings.Index(strings.ToUpper(onCondition), "\tWHEN")
+		whenIdx = indexFold(onCondition, "\tWHEN")
 	}
 	if whenIdx != -1 {
 		onCondition = onCondition[:whenIdx]
@@ -241,7 +241,7 @@ func (h *MergeProcessor) parseWhenClause(section, upperSection string) (WhenClau
 		if len(updateMatch) > 1 {
 			setStr := updateMatch[1]
 			// Truncate at WHEN keyword if present (for multi-clause MERGE)
-			whenIdx := strings.Index(strings.ToUpper(setStr), " WHEN")
+			whenIdx := indexFold(setStr, " WHEN")
 			if whenIdx != -1 {
 				setStr = setStr[:whenIdx]
 			}
@@ -452,6 +452,9 @@ func (h *MergeProcessor) executeDecomposedMerge(ctx context.Context, stmt *Merge
 		if !when.IsMatched {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
 
 		switch when.Action {
 		case MergeActionUpdate:
@@ -476,6 +479,9 @@ func (h *MergeProcessor) executeDecomposedMerge(ctx context.Context, stmt *Merge
 		if when.IsMatched {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
 
 		if when.Action == MergeActionInsert {
 			rows, err := h.executeNotMatchedInsert(ctx, stmt, when)
@@ -3,6 +3,7 @@ package query
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 
 	_ "github.com/duckdb/duckdb-go/v2"
@@ -313,6 +314,26 @@ func TestMergeProcessor_ExecuteMerge_Integration(t *testing.T) {
 			t.Fatalf("Expected 1 result row for COUNT(*)")
 		}
 	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		stmt := &MergeStatement{
+			TargetTable: "target",
+			TargetAlias: "t",
+			SourceTable: "source",
+			SourceAlias: "s",
+			OnCondition: "t.id = s.id",
+			WhenClauses: []WhenClause{
+				{IsMatched: true, Action: MergeActionDelete},
+				{IsMatched: false, Action: MergeActionInsert, InsertVals: []string{"s.id", "s.value", "s.name"}},
+			},
+		}
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := handler.ExecuteMerge(canceled, stmt); !errors.Is(err, context.Canceled) {
+			t.Errorf("ExecuteMerge() error = %v, want context.Canceled", err)
+		}
+	})
 }
 
 func TestIsMerge(t *testing.T) {
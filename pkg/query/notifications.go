@@ -0,0 +1,196 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/notification"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// sentEmailsTable is the DuckDB table capturing the emails sent with
+// SYSTEM$SEND_EMAIL, for tests to inspect.
+const sentEmailsTable = "_metadata_sent_emails"
+
+// sendEmailProcedure is the system procedure sending an email.
+const sendEmailProcedure = "SYSTEM$SEND_EMAIL"
+
+// WithNotificationSink sets the sink that emails sent with SYSTEM$SEND_EMAIL
+// are delivered to, besides being captured by the emulator.
+func WithNotificationSink(sink notification.Sink) ExecutorOption {
+	return func(e *Executor) {
+		e.notificationSink = sink
+	}
+}
+
+// configureNotifications creates the table capturing sent emails.
+func (e *Executor) configureNotifications() {
+	createSQL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		SENT_AT TIMESTAMPTZ,
+		INTEGRATION VARCHAR,
+		RECIPIENTS VARCHAR,
+		SUBJECT VARCHAR,
+		BODY VARCHAR,
+		CONTENT_TYPE VARCHAR
+	)`, sentEmailsTable)
+	if _, err := e.mgr.Exec(context.Background(), createSQL); err != nil {
+		log.Printf("Failed to create the sent emails table: %v", err)
+	}
+}
+
+// parseNotificationIntegration parses the properties of a CREATE NOTIFICATION
+// INTEGRATION statement. Properties of other integration types, such as
+// WEBHOOK_URL, are accepted and ignored.
+func parseNotificationIntegration(stmt *createStatement) (*integration, error) {
+	properties, err := createProperties(stmt)
+	if err != nil {
+		return nil, err
+	}
+	created := &integration{
+		Name:     integrationName(stmt.Name),
+		Type:     strings.ToUpper(properties["TYPE"]),
+		Category: integrationNotification,
+		Enabled:  !strings.EqualFold(properties["ENABLED"], "FALSE"),
+	}
+	if created.Type == "" {
+		return nil, fmt.Errorf("notification integration %s has no TYPE", stmt.Name)
+	}
+	if stmt.Comment != nil {
+		created.Comment = *stmt.Comment
+	}
+	if value, ok := properties["ALLOWED_RECIPIENTS"]; ok {
+		recipients, ok := propertyList(value)
+		if !ok {
+			return nil, fmt.Errorf("ALLOWED_RECIPIENTS of notification integration %s must be a list of addresses", stmt.Name)
+		}
+		for _, recipient := range recipients {
+			created.AllowedRecipients = append(created.AllowedRecipients, strings.ToLower(recipient))
+		}
+	}
+	return created, nil
+}
+
+// executeCreateNotificationIntegration handles CREATE NOTIFICATION INTEGRATION
+// by registering the integration with the executor.
+func (e *Executor) executeCreateNotificationIntegration(stmt *createStatement) (*ExecResult, error) {
+	created, err := parseNotificationIntegration(stmt)
+	if err != nil {
+		return nil, err
+	}
+	return e.registerIntegration(stmt, created)
+}
+
+// callSendEmail runs CALL SYSTEM$SEND_EMAIL(integration, recipients, subject,
+// body [, content_type]). The email is checked against the integration as
+// Snowflake does, captured in the sent emails table, and delivered to the
+// notification sink, if any.
+func (e *Executor) callSendEmail(ctx context.Context, call *procedureCall) (*Result, error) {
+	if len(call.Args) < 4 || len(call.Args) > 5 || len(call.NamedArgs) > 0 {
+		return nil, fmt.Errorf("%s takes an integration, recipients, a subject, a body, and an optional content type", sendEmailProcedure)
+	}
+	values, err := e.evaluate(ctx, nil, call.Args...)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(values))
+	for i, value := range values {
+		if value.Value == nil {
+			return nil, fmt.Errorf("argument %d of %s is NULL", i+1, sendEmailProcedure)
+		}
+		args[i] = fmt.Sprint(value.Value)
+	}
+
+	email := notification.Email{
+		Integration: strings.ToUpper(args[0]),
+		Subject:     args[2],
+		Body:        args[3],
+		ContentType: "text/plain",
+		SentAt:      time.Now().UTC(),
+	}
+	for _, recipient := range strings.Split(args[1], ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			email.Recipients = append(email.Recipients, recipient)
+		}
+	}
+	if len(args) == 5 {
+		email.ContentType = strings.ToLower(args[4])
+	}
+	if err := e.checkEmail(email); err != nil {
+		return nil, err
+	}
+
+	insertSQL := fmt.Sprintf("INSERT INTO %s VALUES (?, ?, ?, ?, ?, ?)", sentEmailsTable)
+	if _, err := e.mgr.Exec(ctx, insertSQL, email.SentAt, email.Integration, strings.Join(email.Recipients, ","),
+		email.Subject, email.Body, email.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to capture email: %w", err)
+	}
+	if e.notificationSink != nil {
+		if err := e.notificationSink.Send(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+	return scalarResult(sendEmailProcedure, sessionVariable{
+		Value: true,
+		Type:  types.ColumnMetadata{Type: "boolean", Nullable: true},
+	}), nil
+}
+
+// checkEmail checks that an email may be sent through its integration.
+func (e *Executor) checkEmail(email notification.Email) error {
+	switch email.ContentType {
+	case "text/plain", "text/html":
+	default:
+		return fmt.Errorf("invalid content type %q: must be text/plain or text/html", email.ContentType)
+	}
+	if len(email.Recipients) == 0 {
+		return fmt.Errorf("%s needs at least one recipient", sendEmailProcedure)
+	}
+
+	integration, ok := e.lookupIntegration(email.Integration)
+	switch {
+	case !ok:
+		return fmt.Errorf("integration %s does not exist", email.Integration)
+	case integration.Type != "EMAIL":
+		return fmt.Errorf("integration %s is not an email integration", email.Integration)
+	case !integration.Enabled:
+		return fmt.Errorf("integration %s is disabled", email.Integration)
+	}
+	if integration.AllowedRecipients == nil {
+		return nil
+	}
+	for _, recipient := range email.Recipients {
+		allowed := false
+		for _, address := range integration.AllowedRecipients {
+			allowed = allowed || strings.EqualFold(recipient, address)
+		}
+		if !allowed {
+			return fmt.Errorf("email recipient %s is not allowed by integration %s", recipient, email.Integration)
+		}
+	}
+	return nil
+}
+
+// SentEmails returns the emails sent with SYSTEM$SEND_EMAIL, oldest first.
+func (e *Executor) SentEmails(ctx context.Context) ([]notification.Email, error) {
+	rows, err := e.mgr.Query(ctx, fmt.Sprintf(
+		"SELECT SENT_AT, INTEGRATION, RECIPIENTS, SUBJECT, BODY, CONTENT_TYPE FROM %s ORDER BY SENT_AT", sentEmailsTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sent emails: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var emails []notification.Email
+	for rows.Next() {
+		var email notification.Email
+		var recipients string
+		if err := rows.Scan(&email.SentAt, &email.Integration, &recipients, &email.Subject, &email.Body, &email.ContentType); err != nil {
+			return nil, err
+		}
+		email.Recipients = strings.Split(recipients, ",")
+		emails = append(emails, email)
+	}
+	return emails, rows.Err()
+}
@@ -0,0 +1,146 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/notification"
+)
+
+// fakeNotificationSink records the emails it receives.
+type fakeNotificationSink struct {
+	emails []notification.Email
+}
+
+func (s *fakeNotificationSink) Send(_ context.Context, email notification.Email) error {
+	s.emails = append(s.emails, email)
+	return nil
+}
+
+func TestParseNotificationIntegration(t *testing.T) {
+	sql := `CREATE NOTIFICATION INTEGRATION ops_email
+		TYPE = EMAIL
+		ENABLED = FALSE
+		ALLOWED_RECIPIENTS = ('Ops@example.com', 'oncall@example.com')
+		COMMENT = 'Alerts for the ops team'`
+	stmt, ok := parseCreateStatement(sql)
+	if !ok || stmt.Kind != "NOTIFICATION INTEGRATION" {
+		t.Fatalf("parseCreateStatement() = %+v, %v, want a notification integration", stmt, ok)
+	}
+	got, err := parseNotificationIntegration(stmt)
+	if err != nil {
+		t.Fatalf("parseNotificationIntegration() error = %v", err)
+	}
+	want := &integration{
+		Name:              "OPS_EMAIL",
+		Type:              "EMAIL",
+		Category:          integrationNotification,
+		AllowedRecipients: []string{"ops@example.com", "oncall@example.com"},
+		Comment:           "Alerts for the ops team",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseNotificationIntegration() mismatch (-want +got):\n%s", diff)
+	}
+
+	stmt, _ = parseCreateStatement("CREATE NOTIFICATION INTEGRATION ops_email ENABLED = TRUE")
+	if _, err := parseNotificationIntegration(stmt); err == nil {
+		t.Error("parseNotificationIntegration() without TYPE succeeded")
+	}
+}
+
+// TestExecutor_SendEmail tests capturing the emails sent by an alert with
+// SYSTEM$SEND_EMAIL and delivering them to the notification sink.
+func TestExecutor_SendEmail(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	sink := &fakeNotificationSink{}
+	executor.Configure(WithNotificationSink(sink))
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		`CREATE NOTIFICATION INTEGRATION ops_email TYPE = EMAIL ENABLED = TRUE
+		ALLOWED_RECIPIENTS = ('ops@example.com', 'oncall@example.com')`,
+		"CREATE NOTIFICATION INTEGRATION disabled_email TYPE = EMAIL ENABLED = FALSE",
+		"CREATE TABLE readings (sensor VARCHAR, value INTEGER)",
+		"INSERT INTO readings VALUES ('a', 120)",
+		`CREATE ALERT high_reading WAREHOUSE = compute_wh SCHEDULE = '1 MINUTE'
+		IF (EXISTS (SELECT * FROM readings WHERE value > 100))
+		THEN CALL SYSTEM$SEND_EMAIL('ops_email', 'ops@example.com, oncall@example.com', 'High reading', CONCAT('Sensor a read ', 120))`,
+		"EXECUTE ALERT high_reading",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "CALL SYSTEM$SEND_EMAIL('OPS_EMAIL', 'ops@example.com', 'Report', '<b>done</b>', 'text/html')")
+	if err != nil {
+		t.Fatalf("CALL SYSTEM$SEND_EMAIL error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{true}}, result.Rows); diff != "" {
+		t.Errorf("CALL SYSTEM$SEND_EMAIL mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []notification.Email{
+		{
+			Integration: "OPS_EMAIL",
+			Recipients:  []string{"ops@example.com", "oncall@example.com"},
+			Subject:     "High reading",
+			Body:        "Sensor a read 120",
+			ContentType: "text/plain",
+		},
+		{
+			Integration: "OPS_EMAIL",
+			Recipients:  []string{"ops@example.com"},
+			Subject:     "Report",
+			Body:        "<b>done</b>",
+			ContentType: "text/html",
+		},
+	}
+	ignoreSentAt := cmpopts.IgnoreFields(notification.Email{}, "SentAt")
+	sent, err := executor.SentEmails(ctx)
+	if err != nil {
+		t.Fatalf("SentEmails() error = %v", err)
+	}
+	if diff := cmp.Diff(want, sent, ignoreSentAt); diff != "" {
+		t.Errorf("SentEmails() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, sink.emails, ignoreSentAt); diff != "" {
+		t.Errorf("sink emails mismatch (-want +got):\n%s", diff)
+	}
+
+	for sql, wantErr := range map[string]string{
+		"CALL SYSTEM$SEND_EMAIL('ops_email', 'someone@example.com', 's', 'b')":    "not allowed",
+		"CALL SYSTEM$SEND_EMAIL('disabled_email', 'ops@example.com', 's', 'b')":   "disabled",
+		"CALL SYSTEM$SEND_EMAIL('missing', 'ops@example.com', 's', 'b')":          "does not exist",
+		"CALL SYSTEM$SEND_EMAIL('ops_email', 'ops@example.com', 's', 'b', 'x/y')": "content type",
+		"CALL SYSTEM$SEND_EMAIL('ops_email', 'ops@example.com', 's')":             "takes",
+	} {
+		if _, err := executor.Query(ctx, sql); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Query(%q) error = %v, want one containing %q", sql, err, wantErr)
+		}
+	}
+
+	show, err := executor.Query(ctx, "SHOW INTEGRATIONS LIKE 'ops%'")
+	if err != nil {
+		t.Fatalf("SHOW INTEGRATIONS error = %v", err)
+	}
+	if len(show.Rows) != 1 || show.Rows[0][0] != "OPS_EMAIL" || show.Rows[0][3] != true {
+		t.Errorf("SHOW INTEGRATIONS rows = %v, want the enabled OPS_EMAIL", show.Rows)
+	}
+
+	for _, sql := range []string{
+		"DROP INTEGRATION ops_email",
+		"DROP NOTIFICATION INTEGRATION IF EXISTS ops_email",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Query(ctx, "CALL SYSTEM$SEND_EMAIL('ops_email', 'ops@example.com', 's', 'b')"); err == nil {
+		t.Error("SYSTEM$SEND_EMAIL through a dropped integration succeeded")
+	}
+}
@@ -0,0 +1,123 @@
+package query
+
+import "strings"
+
+// orderByTerminators are keywords that end an ORDER BY list at the same nesting level.
+var orderByTerminators = []string{
+	"LIMIT", "OFFSET", "FETCH", "ROWS", "RANGE", "GROUPS",
+	"UNION", "INTERSECT", "EXCEPT", "MINUS", "FOR",
+}
+
+// alignNullOrdering appends explicit NULLS FIRST/LAST to every ORDER BY item that
+// does not specify one, so that NULL placement matches Snowflake's defaults:
+// NULLs sort last for ASC and first for DESC (DuckDB sorts them last for both).
+//
+// The rewrite works on SQL text rather than the AST so it also covers window
+// functions and WITHIN GROUP clauses, which vitess-sqlparser cannot parse.
+func alignNullOrdering(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i : end+1])
+			i = end + 1
+		case keywordAt(sql, i, "ORDER"):
+			j := i + len("ORDER")
+			for j < len(sql) && isSpace(sql[j]) {
+				j++
+			}
+			if !keywordAt(sql, j, "BY") {
+				b.WriteString(sql[i:j])
+				i = j
+				continue
+			}
+			b.WriteString(sql[i : j+len("BY")])
+			i = rewriteOrderByItems(sql, j+len("BY"), &b)
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// rewriteOrderByItems writes the ORDER BY list starting at sql[start] with null
+// ordering added to each item, and returns the index where the list ends.
+func rewriteOrderByItems(sql string, start int, b *strings.Builder) int {
+	depth := 0
+	itemStart := start
+	i := start
+
+	flush := func() {
+		b.WriteString(annotateOrderByItem(sql[itemStart:i]))
+	}
+
+	for i < len(sql) {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c) + 1
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				flush()
+				return i
+			}
+			depth--
+		case c == ',' && depth == 0:
+			flush()
+			b.WriteByte(',')
+			i++
+			itemStart = i
+			continue
+		case c == ';' && depth == 0:
+			flush()
+			return i
+		case depth == 0 && isTerminatorAt(sql, i):
+			flush()
+			return i
+		}
+		i++
+	}
+
+	flush()
+	return i
+}
+
+// annotateOrderByItem appends NULLS FIRST/LAST to a single ORDER BY item,
+// preserving its surrounding whitespace. Items with explicit null ordering are kept.
+func annotateOrderByItem(item string) string {
+	trimmed := strings.TrimRight(item, " \t\r\n")
+	if strings.TrimSpace(trimmed) == "" {
+		return item
+	}
+	trailing := item[len(trimmed):]
+
+	// Rewrite ORDER BY clauses nested inside the item, e.g. in scalar subqueries
+	trimmed = alignNullOrdering(trimmed)
+
+	words := strings.Fields(strings.ToUpper(trimmed))
+	if len(words) >= 2 && words[len(words)-2] == "NULLS" {
+		return trimmed + trailing
+	}
+	if words[len(words)-1] == "DESC" {
+		return trimmed + " NULLS FIRST" + trailing
+	}
+	return trimmed + " NULLS LAST" + trailing
+}
+
+// isTerminatorAt reports whether an ORDER BY terminating keyword starts at sql[i].
+func isTerminatorAt(sql string, i int) bool {
+	for _, kw := range orderByTerminators {
+		if keywordAt(sql, i, kw) {
+			return true
+		}
+	}
+	return false
+}
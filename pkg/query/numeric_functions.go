@@ -0,0 +1,121 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// transformNumericFunctions resolves the markers of the numeric functions
+// registered by registerNumericFunctions, except ROUND.
+func (t *Translator) transformNumericFunctions(sql string) string {
+	// TO_NUMBER(expr [, format] [, precision [, scale]]) → CAST(expr AS DECIMAL(precision, scale))
+	for _, marker := range []string{"__TO_NUMBER__", "__TRY_TO_NUMBER__"} {
+		cast := "CAST"
+		if marker == "__TRY_TO_NUMBER__" {
+			cast = "TRY_CAST"
+		}
+		sql = t.transformMarkedFunction(sql, marker, func(args string) string {
+			return toNumber(cast, args)
+		})
+	}
+
+	// ZEROIFNULL(x) → COALESCE(x, 0)
+	sql = t.transformMarkedFunction(sql, "__ZEROIFNULL__", func(args string) string {
+		return fmt.Sprintf("COALESCE(%s, 0)", strings.TrimSpace(args))
+	})
+
+	// DIV0(a, b, a / b) is 0 when b is 0, and DIV0NULL(a, b, a / b) also when b is NULL
+	for _, marker := range []string{"__DIV0__", "__DIV0NULL__"} {
+		orNull := marker == "__DIV0NULL__"
+		sql = t.transformMarkedFunction(sql, marker, func(args string) string {
+			parts := trimmedArgs(args, 3)
+			if len(parts) != 3 {
+				return "div0(" + args + ")"
+			}
+			divisor, quotient := parts[1], parts[2]
+			condition := fmt.Sprintf("(%s) = 0", divisor)
+			if orNull {
+				condition += fmt.Sprintf(" OR (%s) IS NULL", divisor)
+			}
+			return fmt.Sprintf("CASE WHEN %s THEN 0 ELSE %s END", condition, quotient)
+		})
+	}
+
+	// SQUARE(x) → power(x, 2)
+	sql = t.transformMarkedFunction(sql, "__SQUARE__", func(args string) string {
+		return fmt.Sprintf("power(%s, 2)", strings.TrimSpace(args))
+	})
+
+	// TRUNCATE(x [, scale]) → trunc(x [, scale]); with a date part, such as
+	// TRUNC(d, 'MONTH'), → date_trunc('month', d)
+	return t.transformMarkedFunction(sql, "__TRUNCATE__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) == 2 {
+			if part, ok := stringLiteral(parts[1]); ok {
+				return fmt.Sprintf("date_trunc(%s, %s)", quoteLiteral(truncatePart(part)), strings.TrimSpace(parts[0]))
+			}
+		}
+		return "trunc(" + args + ")"
+	})
+}
+
+// toNumber translates the arguments of TO_NUMBER, TO_DECIMAL, or TO_NUMERIC
+// into a cast to DECIMAL. As in Snowflake, the precision defaults to 38 and the
+// scale to 0, so that fractions are rounded away unless a scale is given. A
+// format literal, such as '$9,999.99' or 'XXXX', says which characters of a
+// string to drop, or that it is hexadecimal. Other formats are left to fail in
+// DuckDB.
+func toNumber(cast, args string) string {
+	parts := splitFunctionArgs(args, 4)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) == 0 {
+		return "to_number(" + args + ")"
+	}
+	value, rest := parts[0], parts[1:]
+	if len(rest) > 0 {
+		if format, ok := stringLiteral(rest[0]); ok {
+			formatted, ok := numberFromFormat(cast, value, format)
+			if !ok {
+				return "to_number(" + args + ")"
+			}
+			value, rest = formatted, rest[1:]
+		}
+	}
+	precision, scale := "38", "0"
+	if len(rest) > 0 {
+		precision = rest[0]
+	}
+	if len(rest) > 1 {
+		scale = rest[1]
+	}
+	return fmt.Sprintf("%s(%s AS DECIMAL(%s, %s))", cast, value, precision, scale)
+}
+
+// numberFromFormat returns an expression converting value, a string in the
+// numeric format model format, into one DuckDB can cast to a number.
+func numberFromFormat(cast, value, format string) (string, bool) {
+	text := fmt.Sprintf("trim(CAST(%s AS VARCHAR))", value)
+	if strings.HasPrefix(strings.ToUpper(format), "TM") {
+		return text, true
+	}
+	if hexFormatModel.MatchString(format) {
+		return fmt.Sprintf("%s(('0x' || %s) AS BIGINT)", cast, text), true
+	}
+	m := numberFormatModel.FindStringSubmatch(format)
+	if m == nil {
+		return "", false
+	}
+	if strings.Contains(m[4], ",") {
+		text = fmt.Sprintf("replace(%s, ',', '')", text)
+	}
+	if m[3] != "" {
+		text = fmt.Sprintf("replace(%s, '$', '')", text)
+	}
+	if m[6] != "" {
+		// A trailing sign moves to the front
+		text = fmt.Sprintf("CASE WHEN ends_with(%s, '-') THEN '-' || rtrim(%s, '-') ELSE rtrim(%s, '+') END", text, text, text)
+	}
+	return text, true
+}
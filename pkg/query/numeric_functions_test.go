@@ -0,0 +1,141 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestTranslator_NumericFunctions tests TO_NUMBER, DIV0, TRUNCATE, and the other numeric function translations.
+func TestTranslator_NumericFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "ToNumber",
+			input:    "SELECT TO_NUMBER(s) FROM t",
+			expected: "select CAST(s AS DECIMAL(38, 0)) from t",
+		},
+		{
+			name:     "ToDecimalPrecisionScale",
+			input:    "SELECT TO_DECIMAL(s, 10, 2) FROM t",
+			expected: "select CAST(s AS DECIMAL(10, 2)) from t",
+		},
+		{
+			name:     "ToNumberFormat",
+			input:    "SELECT TO_NUMBER(s, '$9,999.99', 10, 2) FROM t",
+			expected: "select CAST(replace(replace(trim(CAST(s AS VARCHAR)), ',', ''), '$', '') AS DECIMAL(10, 2)) from t",
+		},
+		{
+			name:     "TryToNumberHex",
+			input:    "SELECT TRY_TO_NUMERIC(s, 'XXX') FROM t",
+			expected: "select TRY_CAST(TRY_CAST(('0x' || trim(CAST(s AS VARCHAR))) AS BIGINT) AS DECIMAL(38, 0)) from t",
+		},
+		{
+			name:     "ZeroIfNull",
+			input:    "SELECT ZEROIFNULL(x) FROM t",
+			expected: "select COALESCE(x, 0) from t",
+		},
+		{
+			name:     "Div0",
+			input:    "SELECT DIV0(a, b) FROM t",
+			expected: "select CASE WHEN (b) = 0 THEN 0 ELSE " + exactDivisionSQL("a", "b", 6) + " END from t",
+		},
+		{
+			name:     "Div0Null",
+			input:    "SELECT DIV0NULL(a, b) FROM t",
+			expected: "select CASE WHEN (b) = 0 OR (b) IS NULL THEN 0 ELSE " + exactDivisionSQL("a", "b", 6) + " END from t",
+		},
+		{
+			name:     "Square",
+			input:    "SELECT SQUARE(x) FROM t",
+			expected: "select power(x, 2) from t",
+		},
+		{
+			name:     "TruncateScale",
+			input:    "SELECT TRUNCATE(x, 1), TRUNC(x) FROM t",
+			expected: "select trunc(x, 1), trunc(x) from t",
+		},
+		{
+			name:     "TruncDatePart",
+			input:    "SELECT TRUNC(d, 'MONTH') FROM t",
+			expected: "select date_trunc('month', d) from t",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTranslator().Translate(tt.input)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_NumericFunctions tests the numeric functions against DuckDB.
+func TestExecutor_NumericFunctions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		sql  string
+		// want holds the values as fmt.Sprint formats them
+		want []string
+	}{
+		{
+			name: "ToNumberRounds",
+			sql:  "SELECT TO_NUMBER('12.5'), TO_NUMBER('12.3456', 10, 2), TO_NUMERIC(12.345, 10, 1)",
+			want: []string{"13", "12.35", "12.3"},
+		},
+		{
+			name: "ToNumberFormats",
+			sql:  "SELECT TO_DECIMAL('1,234.56', '9,999.99', 10, 2), TO_NUMBER('$1,234', '$9,999'), TO_NUMBER('12-', '99MI'), TO_NUMBER('FF', 'XX')",
+			want: []string{"1234.56", "1234", "-12", "255"},
+		},
+		{
+			name: "TryToNumber",
+			sql:  "SELECT TRY_TO_NUMBER('abc'), TRY_TO_DECIMAL('1.5', 5, 1)",
+			want: []string{"<nil>", "1.5"},
+		},
+		{
+			name: "ZeroIfNull",
+			sql:  "SELECT ZEROIFNULL(NULL), ZEROIFNULL(5)",
+			want: []string{"0", "5"},
+		},
+		{
+			name: "Div0",
+			sql:  "SELECT DIV0(1, 0), DIV0(1, 3), DIV0(1, NULL), DIV0NULL(1, NULL), DIV0NULL(1, 0)",
+			want: []string{"0", "0.333333", "<nil>", "0", "0"},
+		},
+		{
+			name: "SquareAndTruncate",
+			sql:  "SELECT SQUARE(3), TRUNCATE(3.789, 1), TRUNC(-3.789), TRUNC(1234.5, -2)",
+			want: []string{"9", "3.7", "-3", "1200"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			got := make([]string, len(result.Rows[0]))
+			for i, value := range result.Rows[0] {
+				got[i] = fmt.Sprint(value)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// resolveDatabaseNames rewrites database-qualified object names for DuckDB.
+// Databases are emulated in the metadata store while their schemas are DuckDB
+// schemas, so the database part of db.schema.object, and of db.schema in
+// CREATE, ALTER, and DROP SCHEMA, is dropped when db names a database.
+// db.INFORMATION_SCHEMA.TABLES, COLUMNS, SCHEMATA, and VIEWS, and the same
+// views of the session's current database without a database part, become
+// subqueries listing the database's objects with Snowflake's columns, and
+// SNOWFLAKE.LOCAL.DATA_QUALITY_MONITORING_RESULTS the table of data metric
+// function measurements. SNOWFLAKE.ACCOUNT_USAGE views become subqueries
+// listing the objects of every database and the query history. Tables of
+// the REST API, stored as DATABASE.SCHEMA_TABLE, are found by their
+// db.schema.table and schema.table names, and objects of the PUBLIC schema
+// in main. Unqualified table and view names resolve against the session's
+// current database and schema. Names inside literals, comments, and $$ bodies are left alone, as
+// are JSON paths such as v:a.b.c.
+func (e *Executor) resolveDatabaseNames(ctx context.Context, sql string) string {
+	resolved, views := e.resolveObjectNames(ctx, sql)
+	return expandVirtualViews(resolved, views)
+}
+
+// resolveTableName resolves the name of a table or view, as written, like
+// resolveDatabaseNames does in a FROM clause.
+func (e *Executor) resolveTableName(ctx context.Context, name string) string {
+	const from = "SELECT * FROM "
+	return strings.TrimPrefix(e.resolveDatabaseNames(ctx, from+name), from)
+}
+
+// resolveObjectNames rewrites object names like resolveDatabaseNames, but
+// leaves a placeholder table name for each emulated view, to be
+// replaced by the returned subqueries with expandVirtualViews. The
+// subqueries are DuckDB SQL, so statements are translated with placeholders.
+func (e *Executor) resolveObjectNames(ctx context.Context, sql string) (string, []string) {
+	schemaStatement := isSchemaStatement(sql)
+	session := e.newSessionNames(ctx, sql)
+	if session == nil && !strings.Contains(sql, ".") {
+		return sql, nil
+	}
+	// The object a CREATE, ALTER, or DROP statement names is not looked up
+	// among the tables of the REST API, which SQL does not create or drop
+	keywords := statementKeywords(sql)
+	findTarget := len(keywords) > 0 && (keywords[0] == "CREATE" || keywords[0] == "ALTER" || keywords[0] == "DROP")
+
+	var views []string
+	virtualView := func(name, view, subquery string, ok, aliased bool) string {
+		if !ok {
+			return name
+		}
+		// The view's name is aliased after translation, as TABLES is a keyword of the parser
+		if !aliased {
+			subquery += " AS " + strings.ToUpper(unquoteIdentifier(view))
+		}
+		views = append(views, subquery)
+		return virtualViewPlaceholder(len(views) - 1)
+	}
+
+	var b strings.Builder
+	// prev is the uppercase word before the current name, if only spaces follow it
+	prev := ""
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i : end+1])
+			i = end
+		case c == '$' && i+1 < len(sql) && sql[i+1] == '$':
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String(), views
+			}
+			b.WriteString(sql[i : i+2+end+2])
+			i += 2 + end + 1
+		case (c == '"' || isVariableStart(c)) && (i == 0 || (!isIdentChar(sql[i-1]) && sql[i-1] != ':' && sql[i-1] != '"')):
+			parts, end := objectNameParts(sql, i)
+			target := findTarget && (prev == "TABLE" || prev == "VIEW" || prev == "EXISTS") && !strings.EqualFold(parts[0], "IF")
+			if target {
+				findTarget = false
+			}
+			switch {
+			case isDataMetricResultsView(parts):
+				b.WriteString(dataMetricResultsTable)
+			case isAccountUsageView(parts):
+				subquery, ok := e.accountUsageObject(ctx, parts[2])
+				b.WriteString(virtualView(sql[i:end], parts[2], subquery, ok, followedByAlias(sql, end)))
+			case len(parts) == 3 && isInformationSchemaView(parts[1], parts[2]) && e.isDatabase(ctx, parts[0]):
+				subquery, ok := e.informationSchemaObject(ctx, parts[0], parts[2])
+				b.WriteString(virtualView(sql[i:end], parts[2], subquery, ok, followedByAlias(sql, end)))
+			case len(parts) == 2 && isInformationSchemaView(parts[0], parts[1]) && SessionInfoFromContext(ctx).Database != "":
+				subquery, ok := e.informationSchemaObject(ctx, SessionInfoFromContext(ctx).Database, parts[1])
+				b.WriteString(virtualView(sql[i:end], parts[1], subquery, ok, followedByAlias(sql, end)))
+			case len(parts) >= 3 && e.isDatabase(ctx, parts[0]):
+				b.WriteString(e.qualifiedTableName(ctx, objectName(parts[0]), parts[1], parts[2], !target))
+				for _, part := range parts[3:] {
+					b.WriteString("." + part)
+				}
+			case len(parts) == 2 && schemaStatement && e.isDatabase(ctx, parts[0]):
+				// The schema's name is the statement's only qualified name
+				b.WriteString(parts[1])
+			case len(parts) == 2 && tableKeywords[prev]:
+				b.WriteString(e.qualifiedTableName(ctx, SessionInfoFromContext(ctx).Database, parts[0], parts[1], !target))
+			case len(parts) == 1:
+				b.WriteString(session.resolve(sql, parts[0], end, prev, target))
+			default:
+				b.WriteString(sql[i:end])
+			}
+			prev = ""
+			if len(parts) == 1 {
+				prev = strings.ToUpper(parts[0])
+			}
+			i = end - 1
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				b.WriteString(sql[i:end])
+				i = end - 1
+				continue
+			}
+			if !isSpace(c) {
+				prev = ""
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), views
+}
+
+// isSchemaStatement reports whether sql creates, alters, or drops a schema.
+func isSchemaStatement(sql string) bool {
+	keywords := statementKeywords(sql)
+	if len(keywords) == 0 || (keywords[0] != "CREATE" && keywords[0] != "ALTER" && keywords[0] != "DROP") {
+		return false
+	}
+	for _, keyword := range keywords[1:] {
+		if keyword != "OR" && keyword != "REPLACE" && !createModifiers[keyword] {
+			return keyword == "SCHEMA"
+		}
+	}
+	return false
+}
+
+// objectNameParts returns the dot-separated parts of the name starting at
+// sql[i], each as written, and the index after the name.
+func objectNameParts(sql string, i int) (parts []string, end int) {
+	for {
+		start := i
+		if i < len(sql) && sql[i] == '"' {
+			i = skipQuoted(sql, i, '"') + 1
+			for i < len(sql) && sql[i] == '"' {
+				i = skipQuoted(sql, i, '"') + 1
+			}
+		} else {
+			i = variableEnd(sql, i)
+		}
+		if i == start {
+			return parts, start
+		}
+		parts = append(parts, sql[start:i])
+		if i+1 >= len(sql) || sql[i] != '.' || (sql[i+1] != '"' && !isVariableStart(sql[i+1])) {
+			return parts, i
+		}
+		i++
+	}
+}
+
+// isDatabase reports whether a name part, as written, names a database of the
+// metadata store.
+func (e *Executor) isDatabase(ctx context.Context, part string) bool {
+	name := unquoteIdentifier(part)
+	if name == part {
+		name = strings.ToUpper(name)
+	}
+	db, err := e.repo.GetDatabaseByName(ctx, name)
+	return err == nil && db.Name == name
+}
+
+// informationSchemaObject renders an INFORMATION_SCHEMA view of the database
+// written as database as a subquery. It reports false if the database does not
+// exist or its objects cannot be listed, in which case the name is kept.
+func (e *Executor) informationSchemaObject(ctx context.Context, database, view string) (string, bool) {
+	name := unquoteIdentifier(database)
+	if name == database {
+		name = strings.ToUpper(name)
+	}
+	db, err := e.repo.GetDatabaseByName(ctx, name)
+	if err != nil {
+		return "", false
+	}
+	subquery, err := e.informationSchemaView(ctx, db, strings.ToUpper(unquoteIdentifier(view)))
+	if err != nil {
+		log.Printf("Failed to list INFORMATION_SCHEMA.%s of %s: %v", view, db.Name, err)
+		return "", false
+	}
+	return subquery, true
+}
+
+// virtualViewPlaceholder is the table name standing in for the i-th emulated
+// view of a statement until it is translated.
+func virtualViewPlaceholder(i int) string {
+	return fmt.Sprintf("__information_schema_view_%d__", i)
+}
+
+// expandVirtualViews replaces the placeholders left by
+// resolveObjectNames with their views' subqueries.
+func expandVirtualViews(sql string, views []string) string {
+	for i, view := range views {
+		sql = strings.ReplaceAll(sql, virtualViewPlaceholder(i), view)
+	}
+	return sql
+}
+
+// aliasEndKeywords are the keywords that may follow a table in a FROM clause
+// without being its alias.
+var aliasEndKeywords = map[string]bool{
+	"WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "OFFSET": true, "FETCH": true,
+	"QUALIFY": true, "WINDOW": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true,
+	"CROSS": true, "NATURAL": true, "ASOF": true, "POSITIONAL": true, "ON": true, "USING": true, "UNION": true, "EXCEPT": true,
+	"INTERSECT": true, "MINUS": true, "SAMPLE": true, "TABLESAMPLE": true,
+}
+
+// followedByAlias reports whether the table name ending at sql[end] is
+// followed by an alias.
+func followedByAlias(sql string, end int) bool {
+	for end < len(sql) && isSpace(sql[end]) {
+		end++
+	}
+	if end == len(sql) {
+		return false
+	}
+	if sql[end] == '"' {
+		return true
+	}
+	if !isVariableStart(sql[end]) {
+		return false
+	}
+	word := sql[end:variableEnd(sql, end)]
+	return !aliasEndKeywords[strings.ToUpper(word)]
+}
@@ -0,0 +1,176 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+func TestExecutor_ResolveDatabaseNames(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+	db, err := repo.CreateDatabase(ctx, "TEST_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	public, err := repo.CreateSchema(ctx, db.ID, "PUBLIC", "")
+	if err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+	if _, err := repo.CreateTable(ctx, public.ID, "USERS", []metadata.ColumnDef{{Name: "ID", Type: "INTEGER"}}, ""); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+	view := func(name string) string {
+		t.Helper()
+		subquery, err := executor.informationSchemaView(ctx, db, name)
+		if err != nil {
+			t.Fatalf("informationSchemaView() error = %v", err)
+		}
+		return subquery
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "ThreePartName",
+			input:    "SELECT * FROM TEST_DB.s.t",
+			expected: "SELECT * FROM s.t",
+		},
+		{
+			name:     "QuotedParts",
+			input:    `INSERT INTO "TEST_DB"."FLYWAY"."history" VALUES (1)`,
+			expected: `INSERT INTO "FLYWAY"."history" VALUES (1)`,
+		},
+		{
+			name:     "LowerCaseDatabase",
+			input:    "SELECT test_db.s.t.id FROM test_db.s.t",
+			expected: "SELECT s.t.id FROM s.t",
+		},
+		{
+			name:     "QuotedLowerCaseIsNotDatabase",
+			input:    `SELECT * FROM "test_db".s.t`,
+			expected: `SELECT * FROM "test_db".s.t`,
+		},
+		{
+			name:     "UnknownDatabase",
+			input:    "SELECT * FROM OTHER.s.t",
+			expected: "SELECT * FROM OTHER.s.t",
+		},
+		{
+			name:     "TwoPartNameUnchanged",
+			input:    "SELECT * FROM TEST_DB.t JOIN s.u ON t.id = s.u.id",
+			expected: "SELECT * FROM TEST_DB.t JOIN s.u ON t.id = s.u.id",
+		},
+		{
+			name:     "PublicSchema",
+			input:    "SELECT * FROM TEST_DB.PUBLIC.t JOIN public.u ON t.id = u.id",
+			expected: "SELECT * FROM main.t JOIN main.u ON t.id = u.id",
+		},
+		{
+			name:     "RESTTable",
+			input:    `SELECT u.ID FROM TEST_DB.PUBLIC.USERS u JOIN "TEST_DB"."PUBLIC"."USERS" v ON u.ID = v.ID`,
+			expected: `SELECT u.ID FROM "TEST_DB"."PUBLIC_USERS" u JOIN "TEST_DB"."PUBLIC_USERS" v ON u.ID = v.ID`,
+		},
+		{
+			name:     "RESTTableIsNotDropped",
+			input:    "DROP TABLE IF EXISTS TEST_DB.PUBLIC.USERS",
+			expected: "DROP TABLE IF EXISTS main.USERS",
+		},
+		{
+			name:     "QualifiedColumnUnchanged",
+			input:    "SELECT public.x FROM t",
+			expected: "SELECT public.x FROM t",
+		},
+		{
+			name:     "CreateSchema",
+			input:    "CREATE SCHEMA IF NOT EXISTS TEST_DB.SCHEMACHANGE",
+			expected: "CREATE SCHEMA IF NOT EXISTS SCHEMACHANGE",
+		},
+		{
+			name:     "LiteralsAndJSONPaths",
+			input:    "SELECT 'TEST_DB.s.t', v:TEST_DB.s.t FROM t -- TEST_DB.s.t",
+			expected: "SELECT 'TEST_DB.s.t', v:TEST_DB.s.t FROM t -- TEST_DB.s.t",
+		},
+		{
+			name:     "InformationSchemaTables",
+			input:    "SELECT CREATED FROM TEST_DB.INFORMATION_SCHEMA.TABLES WHERE TABLE_NAME = 'T'",
+			expected: "SELECT CREATED FROM " + view("TABLES") + " AS TABLES WHERE TABLE_NAME = 'T'",
+		},
+		{
+			name:     "InformationSchemaAlias",
+			input:    "SELECT c.COLUMN_NAME FROM test_db.information_schema.columns c",
+			expected: "SELECT c.COLUMN_NAME FROM " + view("COLUMNS") + " c",
+		},
+		{
+			name:     "InformationSchemaWithoutCurrentDatabase",
+			input:    "SELECT * FROM INFORMATION_SCHEMA.TABLES",
+			expected: "SELECT * FROM INFORMATION_SCHEMA.TABLES",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, executor.resolveDatabaseNames(ctx, tt.input)); diff != "" {
+				t.Errorf("resolveDatabaseNames() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_DatabaseQualifiedNames tests statements that name schemas and
+// tables with their database, as migration tools do.
+func TestExecutor_DatabaseQualifiedNames(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+	if _, err := repo.CreateDatabase(ctx, "METADATA", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "METADATA", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE SCHEMA IF NOT EXISTS METADATA.SCHEMACHANGE",
+		"CREATE TABLE IF NOT EXISTS METADATA.SCHEMACHANGE.CHANGE_HISTORY (VERSION VARCHAR, INSTALLED_ON TIMESTAMP_LTZ DEFAULT CURRENT_TIMESTAMP())",
+		"INSERT INTO METADATA.SCHEMACHANGE.CHANGE_HISTORY (VERSION) VALUES ('1.1')",
+		"CREATE TABLE METADATA.PUBLIC.NOTES (ID INTEGER)",
+		"INSERT INTO METADATA.PUBLIC.NOTES VALUES (1), (2)",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, CREATED IS NOT NULL FROM METADATA.INFORMATION_SCHEMA.TABLES "+
+		"WHERE TABLE_SCHEMA = 'SCHEMACHANGE'")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"METADATA", "SCHEMACHANGE", "CHANGE_HISTORY", true}}, result.Rows); diff != "" {
+		t.Errorf("INFORMATION_SCHEMA.TABLES rows mismatch (-want +got):\n%s", diff)
+	}
+
+	result, err = executor.Query(ctx, "SELECT VERSION FROM METADATA.SCHEMACHANGE.CHANGE_HISTORY WHERE INSTALLED_ON IS NOT NULL")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"1.1"}}, result.Rows); diff != "" {
+		t.Errorf("change history rows mismatch (-want +got):\n%s", diff)
+	}
+
+	// Tables of the PUBLIC schema are the same table with and without qualification
+	result, err = executor.Query(ctx, "SELECT COUNT(*) FROM NOTES JOIN PUBLIC.NOTES n USING (ID)")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(2)}}, result.Rows); diff != "" {
+		t.Errorf("PUBLIC table rows mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := executor.Execute(ctx, "DROP TABLE METADATA.PUBLIC.NOTES"); err != nil {
+		t.Fatalf("Execute() of DROP TABLE error = %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// OrderingCheck controls how the Executor reports SELECT statements that return
+// several rows without an ORDER BY. DuckDB and Snowflake return such rows in
+// different orders, so tests that assert on them are flaky.
+type OrderingCheck int
+
+const (
+	// OrderingCheckOff disables the check.
+	OrderingCheckOff OrderingCheck = iota
+	// OrderingCheckWarn attaches a warning to the result and logs it.
+	OrderingCheckWarn
+	// OrderingCheckError fails the query.
+	OrderingCheckError
+)
+
+// ErrUnorderedResult is returned in OrderingCheckError mode when a SELECT
+// without ORDER BY returns more than one row.
+var ErrUnorderedResult = errors.New("query returned multiple rows without ORDER BY")
+
+// ParseOrderingCheck parses an ordering check mode: "off", "warn", or "error".
+// An empty string means off.
+func ParseOrderingCheck(s string) (OrderingCheck, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "off":
+		return OrderingCheckOff, nil
+	case "warn":
+		return OrderingCheckWarn, nil
+	case "error":
+		return OrderingCheckError, nil
+	default:
+		return OrderingCheckOff, fmt.Errorf("invalid ordering check mode %q: must be off, warn, or error", s)
+	}
+}
+
+// WithOrderingCheck flags SELECT statements that return more than one row
+// without an ORDER BY, either as a result warning or as an error.
+func WithOrderingCheck(mode OrderingCheck) ExecutorOption {
+	return func(e *Executor) {
+		e.orderingCheck = mode
+	}
+}
+
+// checkOrdering applies the Executor's ordering check to a query result.
+func (e *Executor) checkOrdering(sql string, result *Result) error {
+	if e.orderingCheck == OrderingCheckOff || result.RowCount() <= 1 || !isUnorderedSelect(sql) {
+		return nil
+	}
+
+	slog.Warn("query returned multiple rows without ORDER BY",
+		slog.String("sql", sql),
+		slog.Int("rows", result.RowCount()),
+	)
+
+	if e.orderingCheck == OrderingCheckError {
+		return fmt.Errorf("%w: add an ORDER BY clause to make the row order deterministic", ErrUnorderedResult)
+	}
+	result.Warnings = append(result.Warnings, fmt.Sprintf(
+		"Query returned %d rows without ORDER BY; row order may differ from Snowflake", result.RowCount()))
+	return nil
+}
+
+// isUnorderedSelect reports whether sql is a SELECT (or WITH ... SELECT) whose
+// outermost query has no ORDER BY. ORDER BY clauses nested in parentheses,
+// such as in subqueries or window functions, do not order the result.
+func isUnorderedSelect(sql string) bool {
+	trimmed := strings.TrimLeft(sql, " \t\r\n(")
+	if !keywordAt(trimmed, 0, "SELECT") && !keywordAt(trimmed, 0, "WITH") {
+		return false
+	}
+
+	// A query wrapped in parentheses is ordered by an ORDER BY inside them
+	outerDepth := strings.Count(sql[:len(sql)-len(trimmed)], "(")
+
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth <= outerDepth && keywordAt(sql, i, "ORDER"):
+			j := i + len("ORDER")
+			for j < len(sql) && isSpace(sql[j]) {
+				j++
+			}
+			if keywordAt(sql, j, "BY") {
+				return false
+			}
+		}
+	}
+	return true
+}
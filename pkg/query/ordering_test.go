@@ -0,0 +1,125 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestIsUnorderedSelect tests detection of SELECTs without a top-level ORDER BY.
+func TestIsUnorderedSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected bool
+	}{
+		{name: "NoOrderBy", sql: "SELECT id FROM t", expected: true},
+		{name: "OrderBy", sql: "SELECT id FROM t ORDER BY id", expected: false},
+		{name: "LowercaseOrderBy", sql: "select id from t order  by id", expected: false},
+		{name: "CTE", sql: "WITH c AS (SELECT id FROM t ORDER BY id) SELECT id FROM c", expected: true},
+		{name: "WindowFunction", sql: "SELECT ROW_NUMBER() OVER (ORDER BY id) FROM t", expected: true},
+		{name: "ParenthesizedQuery", sql: "(SELECT id FROM t ORDER BY id)", expected: false},
+		{name: "OrderByInLiteral", sql: "SELECT 'ORDER BY' FROM t", expected: true},
+		{name: "ColumnNamedOrderBy", sql: "SELECT order_by FROM t", expected: true},
+		{name: "Show", sql: "SHOW TABLES", expected: false},
+		{name: "Insert", sql: "INSERT INTO t SELECT id FROM s", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnorderedSelect(tt.sql); got != tt.expected {
+				t.Errorf("isUnorderedSelect(%q) = %v, want %v", tt.sql, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseOrderingCheck tests parsing of ordering check modes.
+func TestParseOrderingCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected OrderingCheck
+		wantErr  bool
+	}{
+		{input: "", expected: OrderingCheckOff},
+		{input: "off", expected: OrderingCheckOff},
+		{input: "WARN", expected: OrderingCheckWarn},
+		{input: "error", expected: OrderingCheckError},
+		{input: "strict", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOrderingCheck(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOrderingCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseOrderingCheck() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestExecutor_OrderingCheck tests that unordered multi-row results are flagged.
+func TestExecutor_OrderingCheck(t *testing.T) {
+	const unordered = "SELECT * FROM (VALUES (1), (2)) AS v(id)"
+
+	tests := []struct {
+		name         string
+		mode         OrderingCheck
+		sql          string
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "Off",
+			mode: OrderingCheckOff,
+			sql:  unordered,
+		},
+		{
+			name:         "Warn",
+			mode:         OrderingCheckWarn,
+			sql:          unordered,
+			wantWarnings: []string{"Query returned 2 rows without ORDER BY; row order may differ from Snowflake"},
+		},
+		{
+			name: "WarnOrdered",
+			mode: OrderingCheckWarn,
+			sql:  unordered + " ORDER BY id",
+		},
+		{
+			name: "WarnSingleRow",
+			mode: OrderingCheckWarn,
+			sql:  "SELECT 1",
+		},
+		{
+			name:    "Error",
+			mode:    OrderingCheckError,
+			sql:     unordered,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, _ := setupTestExecutor(t, WithOrderingCheck(tt.mode))
+
+			result, err := executor.Query(context.Background(), tt.sql)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnorderedResult) {
+					t.Errorf("Query() error = %v, want ErrUnorderedResult", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantWarnings, result.Warnings); diff != "" {
+				t.Errorf("Warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+)
+
+// outputFormatElements are the Snowflake date and time format elements, longest
+// first so that e.g. MMMM is matched before MM and HH24 before HH.
+var outputFormatElements = []string{
+	"TZH:TZM", "TZHTZM", "YYYY", "MMMM", "HH24", "HH12",
+	"TZH", "TZM", "MON", "YY", "MM", "DD", "DY", "HH", "MI", "SS", "FF", "AM", "PM",
+}
+
+// OutputFormat returns the Snowflake format used to render values of a temporal
+// row type: DATE_OUTPUT_FORMAT for date, TIME_OUTPUT_FORMAT for time, and
+// TIMESTAMP_NTZ_OUTPUT_FORMAT for timestamp_ntz. Timestamps without a type
+// specific format use TIMESTAMP_OUTPUT_FORMAT. Unset and AUTO formats use the
+// defaults. It returns "" for other row types.
+func (p SessionParameters) OutputFormat(rowType string) string {
+	var format, fallback string
+	switch rowType {
+	case "date":
+		format, fallback = p.DateOutputFormat, config.DefaultDateOutputFormat
+	case "time":
+		format, fallback = p.TimeOutputFormat, config.DefaultTimeOutputFormat
+	case "timestamp_ntz":
+		format, fallback = p.TimestampNTZOutputFormat, p.timestampOutputFormat()
+	case "timestamp_ltz", "timestamp_tz":
+		format, fallback = "", p.timestampOutputFormat()
+	default:
+		return ""
+	}
+	if format == "" || strings.EqualFold(format, "AUTO") {
+		return fallback
+	}
+	return format
+}
+
+// timestampOutputFormat returns TIMESTAMP_OUTPUT_FORMAT, or its default.
+func (p SessionParameters) timestampOutputFormat() string {
+	if p.TimestampOutputFormat == "" || strings.EqualFold(p.TimestampOutputFormat, "AUTO") {
+		return config.DefaultTimestampOutputFormat
+	}
+	return p.TimestampOutputFormat
+}
+
+// FormatOutputValue renders a temporal value of the given row type with the
+// output format the session parameters select for it. Other values are
+// returned unchanged.
+func (p SessionParameters) FormatOutputValue(val interface{}, rowType string) interface{} {
+	t, ok := val.(time.Time)
+	if !ok {
+		return val
+	}
+	format := p.OutputFormat(rowType)
+	if format == "" {
+		return val
+	}
+	return FormatDateTime(t, format)
+}
+
+// FormatDateTime renders t with a Snowflake date and time format such as
+// YYYY-MM-DD HH24:MI:SS.FF3. Elements are matched case-insensitively, text in
+// double quotes is copied literally, and other characters are copied as-is.
+func FormatDateTime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '"' {
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				b.WriteString(format[i+1:])
+				break
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		element := ""
+		for _, e := range outputFormatElements {
+			if len(format)-i >= len(e) && strings.EqualFold(format[i:i+len(e)], e) {
+				element = e
+				break
+			}
+		}
+		if element == "" {
+			b.WriteByte(format[i])
+			i++
+			continue
+		}
+		i += len(element)
+
+		if element == "FF" {
+			// FF takes an optional precision digit and defaults to nanoseconds
+			digits := 9
+			if i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				digits = int(format[i] - '0')
+				i++
+			}
+			b.WriteString(fmt.Sprintf("%09d", t.Nanosecond())[:digits])
+			continue
+		}
+		b.WriteString(formatElement(t, element))
+	}
+	return b.String()
+}
+
+// formatElement renders a single format element other than FF.
+func formatElement(t time.Time, element string) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	switch element {
+	case "YYYY":
+		return fmt.Sprintf("%04d", t.Year())
+	case "YY":
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case "MMMM":
+		return t.Month().String()
+	case "MON":
+		return t.Format("Jan")
+	case "MM":
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case "DD":
+		return fmt.Sprintf("%02d", t.Day())
+	case "DY":
+		return t.Format("Mon")
+	case "HH24", "HH":
+		return fmt.Sprintf("%02d", t.Hour())
+	case "HH12":
+		return t.Format("03")
+	case "AM", "PM":
+		return t.Format("PM")
+	case "MI":
+		return fmt.Sprintf("%02d", t.Minute())
+	case "SS":
+		return fmt.Sprintf("%02d", t.Second())
+	case "TZH:TZM":
+		return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+	case "TZHTZM":
+		return fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset%3600/60)
+	case "TZH":
+		return fmt.Sprintf("%c%02d", sign, offset/3600)
+	case "TZM":
+		return fmt.Sprintf("%02d", offset%3600/60)
+	}
+	return element
+}
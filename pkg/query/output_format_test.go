@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFormatDateTime tests rendering times with Snowflake format elements.
+func TestFormatDateTime(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 13, 4, 5, 123456789, time.FixedZone("", -(7*3600+30*60)))
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "YYYY-MM-DD", expected: "2024-03-05"},
+		{format: "yyyy-mm-dd hh24:mi:ss", expected: "2024-03-05 13:04:05"},
+		{format: "DD/MM/YY", expected: "05/03/24"},
+		{format: "DY, DD MON YYYY", expected: "Tue, 05 Mar 2024"},
+		{format: "MMMM DD", expected: "March 05"},
+		{format: "HH12:MI AM", expected: "01:04 PM"},
+		{format: "HH24:MI:SS.FF", expected: "13:04:05.123456789"},
+		{format: "HH24:MI:SS.FF3", expected: "13:04:05.123"},
+		{format: "HH24:MI:SS.FF0", expected: "13:04:05."},
+		{format: "YYYY-MM-DD HH24:MI:SS TZHTZM", expected: "2024-03-05 13:04:05 -0730"},
+		{format: "TZH:TZM", expected: "-07:30"},
+		{format: `YYYY-MM-DD"T"HH24:MI:SS`, expected: "2024-03-05T13:04:05"},
+		{format: `"Day" DD`, expected: "Day 05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := FormatDateTime(ts, tt.format); got != tt.expected {
+				t.Errorf("FormatDateTime(%q) = %q, want %q", tt.format, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSessionParameters_OutputFormat tests choosing the output format for a row type.
+func TestSessionParameters_OutputFormat(t *testing.T) {
+	params := SessionParameters{
+		TimeOutputFormat:      "AUTO",
+		TimestampOutputFormat: "YYYY-MM-DD HH24:MI:SS.FF3",
+	}
+
+	tests := []struct {
+		rowType  string
+		expected string
+	}{
+		{rowType: "date", expected: "YYYY-MM-DD"},
+		{rowType: "time", expected: "HH24:MI:SS"},
+		{rowType: "timestamp_ntz", expected: "YYYY-MM-DD HH24:MI:SS.FF3"},
+		{rowType: "timestamp_ltz", expected: "YYYY-MM-DD HH24:MI:SS.FF3"},
+		{rowType: "text", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rowType, func(t *testing.T) {
+			if got := params.OutputFormat(tt.rowType); got != tt.expected {
+				t.Errorf("OutputFormat(%q) = %q, want %q", tt.rowType, got, tt.expected)
+			}
+		})
+	}
+}
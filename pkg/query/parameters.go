@@ -0,0 +1,129 @@
+package query
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+)
+
+// SessionParameters holds the Snowflake session parameters that change how SQL is translated
+// and how results are rendered. The zero value matches Snowflake's defaults.
+type SessionParameters struct {
+	// WeekStart is WEEK_START: 0 for legacy Monday-based semantics, or 1 (Monday) through 7 (Sunday).
+	WeekStart int
+	// WeekOfYearPolicy is WEEK_OF_YEAR_POLICY: 0 for ISO-like weeks where week 1 has at
+	// least 4 days of the year, or 1 for weeks where week 1 contains January 1.
+	WeekOfYearPolicy int
+	// LockTimeout is LOCK_TIMEOUT: how long a write waits for a conflicting transaction
+	// before failing with a lock timeout. Nil uses the connection manager's retry policy.
+	LockTimeout *time.Duration
+	// NoAutocommit is set when AUTOCOMMIT is FALSE: DML statements outside a
+	// transaction implicitly open one, which stays open until COMMIT or ROLLBACK.
+	NoAutocommit bool
+	// AbortDetachedQuery is ABORT_DETACHED_QUERY: when set, a statement is
+	// cancelled if its client disconnects or its session ends. Otherwise it runs
+	// to completion, as in Snowflake.
+	AbortDetachedQuery bool
+	// DateOutputFormat, TimeOutputFormat, TimestampOutputFormat, and
+	// TimestampNTZOutputFormat are the DATE_OUTPUT_FORMAT, TIME_OUTPUT_FORMAT,
+	// TIMESTAMP_OUTPUT_FORMAT, and TIMESTAMP_NTZ_OUTPUT_FORMAT formats used to render
+	// temporal values as text. Empty values use the defaults.
+	DateOutputFormat         string
+	TimeOutputFormat         string
+	TimestampOutputFormat    string
+	TimestampNTZOutputFormat string
+}
+
+// ParseSessionParameters extracts translation and output parameters from session parameter values.
+// Parameter names are case-insensitive. Missing or out-of-range values keep their defaults.
+func ParseSessionParameters(params map[string]string) SessionParameters {
+	var p SessionParameters
+	for name, value := range params {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		isInt := err == nil
+		switch config.SessionParameter(strings.ToUpper(name)) {
+		case config.ParamWeekStart:
+			if isInt && n >= 0 && n <= 7 {
+				p.WeekStart = n
+			}
+		case config.ParamWeekOfYearPolicy:
+			if isInt && (n == 0 || n == 1) {
+				p.WeekOfYearPolicy = n
+			}
+		case config.ParamLockTimeout:
+			if isInt && n >= 0 {
+				timeout := time.Duration(n) * time.Second
+				p.LockTimeout = &timeout
+			}
+		case config.ParamAutocommit:
+			if autocommit, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+				p.NoAutocommit = !autocommit
+			}
+		case config.ParamAbortDetachedQuery:
+			if abort, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+				p.AbortDetachedQuery = abort
+			}
+		case config.ParamDateOutputFormat:
+			p.DateOutputFormat = value
+		case config.ParamTimeOutputFormat:
+			p.TimeOutputFormat = value
+		case config.ParamTimestampOutputFormat:
+			p.TimestampOutputFormat = value
+		case config.ParamTimestampNTZOutputFormat:
+			p.TimestampNTZOutputFormat = value
+		}
+	}
+	return p
+}
+
+// sessionParametersKey is the context key for SessionParameters.
+type sessionParametersKey struct{}
+
+// ContextWithSessionParameters returns a copy of ctx carrying session parameters
+// that the Executor applies when translating statements.
+func ContextWithSessionParameters(ctx context.Context, params SessionParameters) context.Context {
+	return context.WithValue(ctx, sessionParametersKey{}, params)
+}
+
+// SessionParametersFromContext returns the session parameters carried by ctx, or the defaults.
+func SessionParametersFromContext(ctx context.Context) SessionParameters {
+	if params, ok := ctx.Value(sessionParametersKey{}).(SessionParameters); ok {
+		return params
+	}
+	return SessionParameters{}
+}
+
+// SessionInfo identifies the session a statement runs in.
+type SessionInfo struct {
+	// ID identifies the session. Statements of the same session share its
+	// open transaction.
+	ID       string
+	User     string
+	Role     string
+	Database string
+	Schema   string
+	// Warehouse is the session's current warehouse, which selects the DuckDB
+	// instance its statements run on.
+	Warehouse string
+	// Parameters are the parameter values the session set, by
+	// case-insensitive name, as SHOW PARAMETERS lists them.
+	Parameters map[string]string
+}
+
+// sessionInfoKey is the context key for SessionInfo.
+type sessionInfoKey struct{}
+
+// ContextWithSessionInfo returns a copy of ctx carrying the session's user, role,
+// and current database and schema.
+func ContextWithSessionInfo(ctx context.Context, info SessionInfo) context.Context {
+	return context.WithValue(ctx, sessionInfoKey{}, info)
+}
+
+// SessionInfoFromContext returns the session info carried by ctx, or the zero value.
+func SessionInfoFromContext(ctx context.Context) SessionInfo {
+	info, _ := ctx.Value(sessionInfoKey{}).(SessionInfo)
+	return info
+}
@@ -0,0 +1,90 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseSessionParameters tests extraction of translation parameters.
+func TestParseSessionParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   map[string]string
+		expected SessionParameters
+	}{
+		{
+			name:     "Defaults",
+			params:   nil,
+			expected: SessionParameters{},
+		},
+		{
+			name:     "WeekParameters",
+			params:   map[string]string{"WEEK_START": "7", "week_of_year_policy": "1"},
+			expected: SessionParameters{WeekStart: 7, WeekOfYearPolicy: 1},
+		},
+		{
+			name:     "LockTimeout",
+			params:   map[string]string{"lock_timeout": "30"},
+			expected: SessionParameters{LockTimeout: durationPtr(30 * time.Second)},
+		},
+		{
+			name:     "Autocommit",
+			params:   map[string]string{"autocommit": "FALSE"},
+			expected: SessionParameters{NoAutocommit: true},
+		},
+		{
+			name:     "AbortDetachedQuery",
+			params:   map[string]string{"ABORT_DETACHED_QUERY": "true"},
+			expected: SessionParameters{AbortDetachedQuery: true},
+		},
+		{
+			name: "OutputFormats",
+			params: map[string]string{
+				"date_output_format":          "DD/MM/YYYY",
+				"TIME_OUTPUT_FORMAT":          "HH24:MI",
+				"TIMESTAMP_OUTPUT_FORMAT":     "YYYY-MM-DD HH24:MI:SS.FF3",
+				"TIMESTAMP_NTZ_OUTPUT_FORMAT": "AUTO",
+			},
+			expected: SessionParameters{
+				DateOutputFormat:         "DD/MM/YYYY",
+				TimeOutputFormat:         "HH24:MI",
+				TimestampOutputFormat:    "YYYY-MM-DD HH24:MI:SS.FF3",
+				TimestampNTZOutputFormat: "AUTO",
+			},
+		},
+		{
+			name:     "InvalidValuesIgnored",
+			params:   map[string]string{"WEEK_START": "8", "WEEK_OF_YEAR_POLICY": "yes", "LOCK_TIMEOUT": "-1", "AUTOCOMMIT": "off"},
+			expected: SessionParameters{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseSessionParameters(tt.params)
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("ParseSessionParameters() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestSessionParametersFromContext tests carrying parameters in a context.
+func TestSessionParametersFromContext(t *testing.T) {
+	if diff := cmp.Diff(SessionParameters{}, SessionParametersFromContext(context.Background())); diff != "" {
+		t.Errorf("SessionParametersFromContext() mismatch (-want +got):\n%s", diff)
+	}
+
+	params := SessionParameters{WeekStart: 3}
+	ctx := ContextWithSessionParameters(context.Background(), params)
+	if diff := cmp.Diff(params, SessionParametersFromContext(ctx)); diff != "" {
+		t.Errorf("SessionParametersFromContext() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,29 @@
+package query
+
+import (
+	"context"
+	"fmt"
+)
+
+// PreviewTable returns the first limit rows of a table, created with SQL or
+// through the REST API, with its column metadata. The rows are in the table's
+// storage order, as Snowflake's previews are.
+func (e *Executor) PreviewTable(ctx context.Context, database, schema, table string, limit int) (*Result, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("preview limit must be positive, got %d", limit)
+	}
+	tableName, err := e.physicalTableName(ctx, database, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	previewSQL := fmt.Sprintf("SELECT * FROM %s LIMIT %d", tableName, limit)
+	result, ok, err := e.queryArrow(ctx, previewSQL)
+	if !ok {
+		result, err = e.queryRows(ctx, previewSQL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview table %s: %w", table, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_PreviewTable tests previewing the first rows of a table.
+func TestExecutor_PreviewTable(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1", Database: "PREVIEW_DB", Schema: "PUBLIC"})
+
+	if _, err := repo.CreateDatabase(ctx, "PREVIEW_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for _, sql := range []string{
+		"CREATE TABLE items (id INTEGER, name VARCHAR)",
+		"INSERT INTO items VALUES (1, 'lamp'), (2, 'desk'), (3, 'chair')",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.PreviewTable(ctx, "preview_db", "public", "ITEMS", 2)
+	if err != nil {
+		t.Fatalf("PreviewTable() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(1), "lamp"}, {int64(2), "desk"}}, result.Rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.ColumnTypes) != 2 || result.ColumnTypes[1].Name != "name" || result.ColumnTypes[1].Type != "text" {
+		t.Errorf("ColumnTypes = %+v, want id and a text name", result.ColumnTypes)
+	}
+
+	if _, err := executor.PreviewTable(ctx, "PREVIEW_DB", "PUBLIC", "missing", 2); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("PreviewTable() of a missing table error = %v, want ErrTableNotFound", err)
+	}
+	if _, err := executor.PreviewTable(ctx, "PREVIEW_DB", "PUBLIC", "items", 0); err == nil {
+		t.Error("PreviewTable() with limit 0 error = nil, want error")
+	}
+}
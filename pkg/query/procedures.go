@@ -0,0 +1,348 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// languageSQL is the language of Snowflake Scripting procedures.
+const languageSQL = "SQL"
+
+// procedure is a stored procedure created with CREATE PROCEDURE.
+type procedure struct {
+	// Name is the unqualified name, which CALL returns as its column name.
+	Name       string
+	Parameters []procedureParameter
+	Returns    string
+	Language   string
+	// Body is the procedure's block for SQL procedures, or nil.
+	Body *scriptBlock
+	// Secrets maps the aliases of the SECRETS clause to the qualified names of
+	// the secrets, which the procedure reads with SYSTEM$GET_SECRET.
+	Secrets map[string]string
+	// ExternalAccessIntegrations are the integrations allowing the secrets.
+	ExternalAccessIntegrations []string
+	CreatedOn                  time.Time
+}
+
+// procedureParameter is a parameter of a stored procedure.
+type procedureParameter struct {
+	Name string
+	Type string
+	// Default is the expression of the parameter's DEFAULT clause, or "" if the
+	// argument is required.
+	Default string
+}
+
+// procedureRegistry holds the stored procedures, keyed by their qualified name.
+type procedureRegistry struct {
+	mu     sync.Mutex
+	byName map[string]*procedure
+}
+
+// procedureCall is a parsed CALL statement.
+type procedureCall struct {
+	Name string
+	// Args are the positional argument expressions, in order.
+	Args []string
+	// NamedArgs are the name => expr arguments, keyed by parameter name.
+	NamedArgs map[string]string
+}
+
+// qualifiedName returns the upper-case database.schema.object name of an
+// object, completing it with the session's current database and schema.
+func qualifiedName(ctx context.Context, name string) string {
+	database, schema, object := splitObjectName(ctx, name)
+	var parts []string
+	for _, part := range []string{database, schema, object} {
+		if part != "" {
+			parts = append(parts, strings.ToUpper(part))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseProcedure parses the signature and body of a CREATE PROCEDURE statement.
+func parseProcedure(stmt *createStatement) (*procedure, error) {
+	_, _, name := splitObjectName(context.Background(), stmt.Name)
+	proc := &procedure{Name: strings.ToUpper(name), Language: languageSQL}
+
+	s := stmt.SQL
+	i := indexFold(s, "PROCEDURE") + len("PROCEDURE")
+	i += strings.Index(s[i:], stmt.Name) + len(stmt.Name)
+	rest := strings.TrimSpace(s[i:])
+	end := matchingParen(rest)
+	if !strings.HasPrefix(rest, "(") || end < 0 {
+		return nil, fmt.Errorf("procedure %s has no parameter list", stmt.Name)
+	}
+	if params := strings.TrimSpace(rest[1:end]); params != "" {
+		for _, param := range splitFunctionArgs(params, 0) {
+			fields := strings.Fields(param)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid parameter %q of procedure %s", strings.TrimSpace(param), stmt.Name)
+			}
+			parameter := procedureParameter{Name: variableName(fields[0]), Type: fields[1]}
+			if defaultAt := topLevelKeyword(param, "DEFAULT"); defaultAt >= 0 {
+				parameter.Default = strings.TrimSpace(param[defaultAt+len("DEFAULT"):])
+			}
+			proc.Parameters = append(proc.Parameters, parameter)
+		}
+	}
+	rest = rest[end+1:]
+
+	// The body is the $$ text or string literal after the AS that is not part of
+	// EXECUTE AS CALLER or OWNER
+	header, body, ok := procedureBody(rest)
+	if !ok {
+		return nil, fmt.Errorf("procedure %s has no body", stmt.Name)
+	}
+	if at := topLevelKeyword(header, "RETURNS"); at >= 0 {
+		returns := strings.TrimSpace(header[at+len("RETURNS"):])
+		typeEnd := 0
+		for typeEnd < len(returns) && isIdentChar(returns[typeEnd]) {
+			typeEnd++
+		}
+		if strings.HasPrefix(returns[typeEnd:], "(") {
+			typeEnd += matchingParen(returns[typeEnd:]) + 1
+		}
+		proc.Returns = strings.ToUpper(returns[:typeEnd])
+	}
+	if at := topLevelKeyword(header, "LANGUAGE"); at >= 0 {
+		if fields := strings.Fields(header[at+len("LANGUAGE"):]); len(fields) > 0 {
+			proc.Language = strings.ToUpper(fields[0])
+		}
+	}
+	if proc.Returns == "" {
+		return nil, fmt.Errorf("procedure %s has no RETURNS clause", stmt.Name)
+	}
+	secrets, integrations, err := parseProcedureSecrets(header)
+	if err != nil {
+		return nil, fmt.Errorf("procedure %s: %w", stmt.Name, err)
+	}
+	proc.Secrets, proc.ExternalAccessIntegrations = secrets, integrations
+
+	if proc.Language == languageSQL {
+		block, err := parseScriptBlock(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body of procedure %s: %w", stmt.Name, err)
+		}
+		proc.Body = block
+	}
+	return proc, nil
+}
+
+// procedureBody splits the clauses after a procedure's parameter list into the
+// header before its body and the body, given as AS $$...$$ or AS '...'.
+func procedureBody(s string) (header, body string, ok bool) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(s, i, c)
+		case keywordAt(s, i, "AS"):
+			j := i + len("AS")
+			for j < len(s) && isSpace(s[j]) {
+				j++
+			}
+			switch {
+			case strings.HasPrefix(s[j:], "$$"):
+				end := strings.Index(s[j+2:], "$$")
+				if end < 0 {
+					return "", "", false
+				}
+				return s[:i], s[j+2 : j+2+end], true
+			case j < len(s) && s[j] == '\'':
+				end := skipQuoted(s, j, '\'')
+				if end <= j {
+					return "", "", false
+				}
+				text := strings.ReplaceAll(s[j+1:end], "''", "'")
+				return s[:i], strings.ReplaceAll(text, `\'`, "'"), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// executeCreateProcedure handles CREATE PROCEDURE, which DuckDB does not
+// support, by registering the procedure with the executor. Procedures in other
+// languages than SQL are registered so that deployments succeed, but cannot be
+// called.
+func (e *Executor) executeCreateProcedure(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	proc, err := parseProcedure(stmt)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.resolveProcedureSecrets(ctx, proc); err != nil {
+		return nil, err
+	}
+	name := qualifiedName(ctx, stmt.Name)
+
+	e.procedures.mu.Lock()
+	defer e.procedures.mu.Unlock()
+	if _, exists := e.procedures.byName[name]; exists {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case !stmt.OrReplace:
+			return nil, fmt.Errorf("procedure %s already exists", name)
+		}
+	}
+	if e.procedures.byName == nil {
+		e.procedures.byName = make(map[string]*procedure)
+	}
+	proc.CreatedOn = time.Now()
+	e.procedures.byName[name] = proc
+	return &ExecResult{}, nil
+}
+
+// dropProcedureName returns the name of the procedure dropped by a DROP
+// PROCEDURE [IF EXISTS] name(types) statement.
+func dropProcedureName(sql string) (name string, ifExists, ok bool) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	keywords := statementKeywords(s)
+	if !keywordsHavePrefix(keywords, "DROP", "PROCEDURE") {
+		return "", false, false
+	}
+	s = strings.TrimSpace(s[indexFold(s, "PROCEDURE")+len("PROCEDURE"):])
+	if keywordAt(s, 0, "IF") {
+		ifExists = true
+		s = strings.TrimSpace(s[len("IF"):])
+		if !keywordAt(s, 0, "EXISTS") {
+			return "", false, false
+		}
+		s = strings.TrimSpace(s[len("EXISTS"):])
+	}
+	if paren := strings.IndexByte(s, '('); paren >= 0 {
+		s = s[:paren]
+	}
+	return strings.TrimSpace(s), ifExists, true
+}
+
+// executeDropProcedure drops a procedure registered with CREATE PROCEDURE.
+func (e *Executor) executeDropProcedure(ctx context.Context, name string, ifExists bool) (*ExecResult, error) {
+	qualified := qualifiedName(ctx, name)
+
+	e.procedures.mu.Lock()
+	defer e.procedures.mu.Unlock()
+	if _, ok := e.procedures.byName[qualified]; !ok {
+		if ifExists {
+			return &ExecResult{}, nil
+		}
+		return nil, fmt.Errorf("procedure %s does not exist", qualified)
+	}
+	delete(e.procedures.byName, qualified)
+	return &ExecResult{}, nil
+}
+
+// parseCall parses CALL name(arg, ...) and CALL name(param => arg, ...). It
+// reports false for other statements.
+func parseCall(sql string) (*procedureCall, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	if !keywordAt(s, 0, "CALL") {
+		return nil, false, nil
+	}
+	s = strings.TrimSpace(s[len("CALL"):])
+	paren := strings.IndexByte(s, '(')
+	if paren < 0 || matchingParen(s[paren:]) != len(s)-paren-1 {
+		return nil, true, fmt.Errorf("CALL expects name(arguments)")
+	}
+
+	call := &procedureCall{Name: strings.TrimSpace(s[:paren]), NamedArgs: map[string]string{}}
+	args := strings.TrimSpace(s[paren+1 : len(s)-1])
+	if args == "" {
+		return call, true, nil
+	}
+	for _, arg := range splitFunctionArgs(args, 0) {
+		arg = strings.TrimSpace(arg)
+		if name, expr, named := strings.Cut(arg, "=>"); named && isVariableName(strings.TrimSpace(name)) {
+			call.NamedArgs[variableName(strings.TrimSpace(name))] = strings.TrimSpace(expr)
+			continue
+		}
+		if len(call.NamedArgs) > 0 {
+			return nil, true, fmt.Errorf("positional arguments cannot follow named arguments")
+		}
+		call.Args = append(call.Args, arg)
+	}
+	return call, true, nil
+}
+
+// queryCall runs a stored procedure. The result is a single row holding the
+// procedure's return value in a column named after the procedure.
+func (e *Executor) queryCall(ctx context.Context, call *procedureCall) (*Result, error) {
+	if strings.EqualFold(call.Name, sendEmailProcedure) {
+		return e.callSendEmail(ctx, call)
+	}
+	qualified := qualifiedName(ctx, call.Name)
+	e.procedures.mu.Lock()
+	proc, ok := e.procedures.byName[qualified]
+	e.procedures.mu.Unlock()
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("procedure %s does not exist", qualified)
+	case proc.Language != languageSQL:
+		return nil, fmt.Errorf("procedures in LANGUAGE %s cannot be called", proc.Language)
+	case strings.HasPrefix(proc.Returns, "TABLE"):
+		return nil, fmt.Errorf("procedures returning TABLE are not supported")
+	case len(call.Args) > len(proc.Parameters):
+		return nil, fmt.Errorf("procedure %s takes %d arguments but %d were given", proc.Name, len(proc.Parameters), len(call.Args))
+	}
+
+	// Arguments are cast to their parameter types and evaluated in one query
+	exprs := make([]string, len(proc.Parameters))
+	for i, param := range proc.Parameters {
+		expr, named := call.NamedArgs[param.Name]
+		switch {
+		case i < len(call.Args):
+			expr = call.Args[i]
+		case named:
+		case param.Default != "":
+			expr = param.Default
+		default:
+			return nil, fmt.Errorf("missing argument %s of procedure %s", param.Name, proc.Name)
+		}
+		delete(call.NamedArgs, param.Name)
+		exprs[i] = fmt.Sprintf("CAST(%s AS %s)", expr, param.Type)
+	}
+	for name := range call.NamedArgs {
+		return nil, fmt.Errorf("procedure %s has no parameter %s", proc.Name, name)
+	}
+
+	vars := map[string]sessionVariable{}
+	if len(exprs) > 0 {
+		values, err := e.evaluate(ctx, nil, exprs...)
+		if err != nil {
+			return nil, err
+		}
+		for i, param := range proc.Parameters {
+			vars[param.Name] = values[i]
+		}
+	}
+
+	value, err := e.runBlock(withProcedureSecrets(ctx, proc.Secrets), proc.Body, vars)
+	if err != nil {
+		return nil, fmt.Errorf("procedure %s failed: %w", proc.Name, err)
+	}
+	if value.Value != nil && isScalarType(proc.Returns) {
+		cast := fmt.Sprintf("CAST(%s AS %s)", variableLiteral(value), proc.Returns)
+		values, err := e.evaluate(ctx, nil, cast)
+		if err != nil {
+			return nil, fmt.Errorf("procedure %s returned a value that is not %s: %w", proc.Name, proc.Returns, err)
+		}
+		value = values[0]
+	}
+	return scalarResult(proc.Name, value), nil
+}
+
+// isScalarType reports whether a RETURNS type is a scalar type that return
+// values can be cast to.
+func isScalarType(typeName string) bool {
+	base, _, _ := strings.Cut(typeName, "(")
+	switch base {
+	case "VARIANT", "OBJECT", "ARRAY", "TABLE", "GEOGRAPHY", "GEOMETRY":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *procedureCall
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "no arguments", sql: "CALL p()", want: &procedureCall{Name: "p", NamedArgs: map[string]string{}}, wantOK: true},
+		{name: "positional", sql: "call db.s.p(1, 'a,b');", want: &procedureCall{Name: "db.s.p", Args: []string{"1", "'a,b'"}, NamedArgs: map[string]string{}}, wantOK: true},
+		{name: "named", sql: "CALL p(1, b => 'x')", want: &procedureCall{Name: "p", Args: []string{"1"}, NamedArgs: map[string]string{"B": "'x'"}}, wantOK: true},
+		{name: "positional after named", sql: "CALL p(a => 1, 2)", wantOK: true, wantErr: true},
+		{name: "no parentheses", sql: "CALL p", wantOK: true, wantErr: true},
+		{name: "select", sql: "SELECT 1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseCall(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseCall(%q) = (%v, %v, %v), want ok %v, error %v", tt.sql, got, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseCall(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+func TestExecutor_Procedures(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"CREATE TABLE audit (event VARCHAR)",
+		`CREATE OR REPLACE PROCEDURE log_event(event VARCHAR, times INTEGER DEFAULT 1)
+RETURNS VARCHAR
+LANGUAGE SQL
+EXECUTE AS CALLER
+AS
+$$
+BEGIN
+  INSERT INTO audit VALUES (:event);
+  RETURN CONCAT('logged ', event, ' x', times);
+END;
+$$`,
+		"CREATE PROCEDURE add_one(n NUMBER) RETURNS NUMBER LANGUAGE SQL AS 'BEGIN RETURN n + 1; END'",
+		"CREATE PROCEDURE js() RETURNS VARCHAR LANGUAGE JAVASCRIPT AS $$ return 'x'; $$",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		sql        string
+		wantColumn string
+		want       interface{}
+	}{
+		{name: "positional with default", sql: "CALL log_event('deploy')", wantColumn: "LOG_EVENT", want: "logged deploy x1"},
+		{name: "named", sql: "CALL log_event(times => 2, event => 'seed')", wantColumn: "LOG_EVENT", want: "logged seed x2"},
+		{name: "cast to return type", sql: "CALL add_one(41)", wantColumn: "ADD_ONE", want: "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if diff := cmp.Diff([]string{tt.wantColumn}, result.Columns); diff != "" {
+				t.Errorf("Query(%q) columns mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+			if len(result.Rows) != 1 || fmt.Sprint(result.Rows[0][0]) != fmt.Sprint(tt.want) {
+				t.Errorf("Query(%q) rows = %v, want [[%v]]", tt.sql, result.Rows, tt.want)
+			}
+		})
+	}
+
+	audit, err := executor.Query(ctx, "SELECT event FROM audit ORDER BY event")
+	if err != nil {
+		t.Fatalf("SELECT error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"deploy"}, {"seed"}}, audit.Rows); diff != "" {
+		t.Errorf("audit rows mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, sql := range []string{
+		"CALL js()",
+		"CALL log_event()",
+		"CALL missing()",
+	} {
+		if _, err := executor.Query(ctx, sql); err == nil {
+			t.Errorf("Query(%q) should fail", sql)
+		}
+	}
+	if _, err := executor.Execute(ctx, "CREATE PROCEDURE add_one(n NUMBER) RETURNS NUMBER LANGUAGE SQL AS 'BEGIN RETURN n; END'"); err == nil {
+		t.Error("CREATE PROCEDURE of an existing procedure should fail")
+	}
+
+	if _, err := executor.Execute(ctx, "DROP PROCEDURE add_one(NUMBER)"); err != nil {
+		t.Fatalf("DROP PROCEDURE error = %v", err)
+	}
+	if _, err := executor.Query(ctx, "CALL add_one(1)"); err == nil {
+		t.Error("dropped procedure should not be callable")
+	}
+	if _, err := executor.Execute(ctx, "DROP PROCEDURE IF EXISTS add_one(NUMBER)"); err != nil {
+		t.Errorf("DROP PROCEDURE IF EXISTS error = %v", err)
+	}
+}
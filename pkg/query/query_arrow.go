@@ -0,0 +1,245 @@
+//go:build duckdb_arrow
+
+package query
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// errArrowUnsupported reports that a query's result cannot be converted
+// through Arrow, so it is converted row by row instead.
+var errArrowUnsupported = errors.New("result not convertible through Arrow")
+
+// arrowTypeNames maps the DuckDB types, as DESCRIBE reports them, whose
+// columns are converted through Arrow to their names as the driver reports
+// them. Decimals are converted as well. Other types, such as JSON, UUID, or
+// LIST, are converted row by row, since their Arrow types do not tell them
+// apart or need nested conversion.
+var arrowTypeNames = map[string]string{
+	"BOOLEAN":                  "BOOLEAN",
+	"TINYINT":                  "TINYINT",
+	"SMALLINT":                 "SMALLINT",
+	"INTEGER":                  "INTEGER",
+	"BIGINT":                   "BIGINT",
+	"UTINYINT":                 "UTINYINT",
+	"USMALLINT":                "USMALLINT",
+	"UINTEGER":                 "UINTEGER",
+	"UBIGINT":                  "UBIGINT",
+	"FLOAT":                    "FLOAT",
+	"DOUBLE":                   "DOUBLE",
+	"VARCHAR":                  "VARCHAR",
+	"BLOB":                     "BLOB",
+	"DATE":                     "DATE",
+	"TIMESTAMP":                "TIMESTAMP",
+	"TIMESTAMP_S":              "TIMESTAMP_S",
+	"TIMESTAMP_MS":             "TIMESTAMP_MS",
+	"TIMESTAMP_NS":             "TIMESTAMP_NS",
+	"TIMESTAMP WITH TIME ZONE": "TIMESTAMPTZ",
+}
+
+// queryArrow runs a translated SELECT through DuckDB's Arrow interface and
+// converts its result a column at a time, producing the same values as
+// queryRows. It reports false, without running the query, for other
+// statements and for results with columns of other types.
+func (e *Executor) queryArrow(ctx context.Context, translatedSQL string) (*Result, bool, error) {
+	keywords := statementKeywords(translatedSQL)
+	if len(keywords) == 0 || (keywords[0] != "SELECT" && keywords[0] != "WITH" && keywords[0] != "FROM") {
+		return nil, false, nil
+	}
+
+	var result *Result
+	err := e.manager(ctx).Raw(ctx, func(driverConn any) error {
+		conn, ok := driverConn.(driver.Conn)
+		if !ok {
+			return errArrowUnsupported
+		}
+		a, err := duckdb.NewArrowFromConn(conn)
+		if err != nil {
+			return errArrowUnsupported
+		}
+		result, err = queryArrowResult(ctx, a, translatedSQL)
+		return err
+	})
+	if errors.Is(err, errArrowUnsupported) {
+		return nil, false, nil
+	}
+	return result, true, err
+}
+
+// queryArrowResult describes a query to find its column types, and runs it if
+// they can all be converted through Arrow.
+func queryArrowResult(ctx context.Context, a *duckdb.Arrow, query string) (*Result, error) {
+	result, err := describeArrowResult(ctx, a, query)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := a.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Release()
+
+	fields := reader.Schema().Fields()
+	if len(fields) != len(result.Columns) {
+		return nil, errArrowUnsupported
+	}
+	converters := make([]arrowConverter, len(fields))
+	for i, field := range fields {
+		converter, ok := newArrowConverter(field.Type, result.ColumnTypes[i].Type == "binary")
+		if !ok {
+			return nil, errArrowUnsupported
+		}
+		converters[i] = converter
+	}
+
+	spill := resultSpillFromContext(ctx)
+	for reader.Next() {
+		record := reader.RecordBatch()
+		rows := make([][]interface{}, record.NumRows())
+		values := make([]interface{}, len(rows)*len(converters))
+		for i := range rows {
+			rows[i] = values[i*len(converters) : (i+1)*len(converters) : (i+1)*len(converters)]
+		}
+		for i, convert := range converters {
+			convert(record.Column(i), rows, i)
+		}
+		result.Rows = append(result.Rows, rows...)
+		if err := spill.collect(result); err != nil {
+			return nil, err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if err := spill.finish(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// describeArrowResult returns an empty result with the columns of a query,
+// found with DESCRIBE without running it. It returns errArrowUnsupported if
+// the query cannot be described or has a column of a type not in
+// arrowTypeNames.
+func describeArrowResult(ctx context.Context, a *duckdb.Arrow, query string) (*Result, error) {
+	reader, err := a.QueryContext(ctx, "DESCRIBE "+query)
+	if err != nil {
+		return nil, errArrowUnsupported
+	}
+	defer reader.Release()
+
+	result := &Result{}
+	for reader.Next() {
+		record := reader.RecordBatch()
+		names, ok := record.Column(0).(*array.String)
+		if !ok {
+			return nil, errArrowUnsupported
+		}
+		duckTypes, ok := record.Column(1).(*array.String)
+		if !ok {
+			return nil, errArrowUnsupported
+		}
+		for i := 0; i < names.Len(); i++ {
+			duckType, ok := arrowTypeNames[duckTypes.Value(i)]
+			if !ok && strings.HasPrefix(duckTypes.Value(i), "DECIMAL(") {
+				duckType, ok = duckTypes.Value(i), true
+			}
+			if !ok {
+				return nil, errArrowUnsupported
+			}
+			name := strings.Clone(names.Value(i))
+			result.Columns = append(result.Columns, name)
+			result.ColumnTypes = append(result.ColumnTypes, defaultTypeMapper.ColumnMetadata(name, duckType))
+		}
+	}
+	if err := reader.Err(); err != nil || len(result.Columns) == 0 {
+		return nil, errArrowUnsupported
+	}
+	return result, nil
+}
+
+// arrowConverter sets column col of rows to the values of an Arrow column.
+type arrowConverter func(column arrow.Array, rows [][]interface{}, col int)
+
+// newArrowConverter returns the converter of an Arrow type, producing the
+// values the DuckDB driver scans for the column. Strings and bytes are copied,
+// since Arrow's buffers are released with their record. Bytes are converted to
+// strings unless binary is set, as convertValue does.
+func newArrowConverter(dataType arrow.DataType, binary bool) (arrowConverter, bool) {
+	switch dataType := dataType.(type) {
+	case *arrow.BooleanType:
+		return convertArrowValues[*array.Boolean](func(v bool) interface{} { return v }), true
+	case *arrow.Int8Type:
+		return convertArrowValues[*array.Int8](func(v int8) interface{} { return v }), true
+	case *arrow.Int16Type:
+		return convertArrowValues[*array.Int16](func(v int16) interface{} { return v }), true
+	case *arrow.Int32Type:
+		return convertArrowValues[*array.Int32](func(v int32) interface{} { return v }), true
+	case *arrow.Int64Type:
+		return convertArrowValues[*array.Int64](func(v int64) interface{} { return v }), true
+	case *arrow.Uint8Type:
+		return convertArrowValues[*array.Uint8](func(v uint8) interface{} { return v }), true
+	case *arrow.Uint16Type:
+		return convertArrowValues[*array.Uint16](func(v uint16) interface{} { return v }), true
+	case *arrow.Uint32Type:
+		return convertArrowValues[*array.Uint32](func(v uint32) interface{} { return v }), true
+	case *arrow.Uint64Type:
+		return convertArrowValues[*array.Uint64](func(v uint64) interface{} { return v }), true
+	case *arrow.Float32Type:
+		return convertArrowValues[*array.Float32](func(v float32) interface{} { return v }), true
+	case *arrow.Float64Type:
+		return convertArrowValues[*array.Float64](func(v float64) interface{} { return v }), true
+	case *arrow.StringType:
+		return convertArrowValues[*array.String](func(v string) interface{} { return strings.Clone(v) }), true
+	case *arrow.BinaryType:
+		if binary {
+			return convertArrowValues[*array.Binary](func(v []byte) interface{} { return append([]byte{}, v...) }), true
+		}
+		return convertArrowValues[*array.Binary](func(v []byte) interface{} { return string(v) }), true
+	case *arrow.Date32Type:
+		return convertArrowValues[*array.Date32](func(v arrow.Date32) interface{} {
+			return time.Unix(int64(v)*24*60*60, 0).UTC()
+		}), true
+	case *arrow.TimestampType:
+		unit := dataType.Unit
+		return convertArrowValues[*array.Timestamp](func(v arrow.Timestamp) interface{} {
+			return v.ToTime(unit).UTC()
+		}), true
+	case *arrow.Decimal128Type:
+		width, scale := uint8(dataType.Precision), uint8(dataType.Scale)
+		return convertArrowValues[*array.Decimal128](func(v decimal128.Num) interface{} {
+			return duckdb.Decimal{Width: width, Scale: scale, Value: v.BigInt()}
+		}), true
+	}
+	return nil, false
+}
+
+// arrowValues is an Arrow array of values of type T.
+type arrowValues[T any] interface {
+	arrow.Array
+	Value(i int) T
+}
+
+// convertArrowValues returns a converter for arrays of type A, converting
+// their non-NULL values with convert.
+func convertArrowValues[A arrowValues[T], T any](convert func(T) interface{}) arrowConverter {
+	return func(column arrow.Array, rows [][]interface{}, col int) {
+		values := column.(A)
+		for i := range rows {
+			if !values.IsNull(i) {
+				rows[i][col] = convert(values.Value(i))
+			}
+		}
+	}
+}
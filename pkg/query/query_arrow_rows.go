@@ -0,0 +1,11 @@
+//go:build !duckdb_arrow
+
+package query
+
+import "context"
+
+// queryArrow reports false in builds without the duckdb_arrow tag, which
+// DuckDB's Arrow interface needs, so results are converted row by row.
+func (e *Executor) queryArrow(context.Context, string) (*Result, bool, error) {
+	return nil, false, nil
+}
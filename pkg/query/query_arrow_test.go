@@ -0,0 +1,73 @@
+//go:build duckdb_arrow
+
+package query
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExecutor_QueryArrow(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	if _, err := executor.mgr.Exec(ctx, `CREATE TABLE arrow_values AS
+		SELECT range::INTEGER AS id, 'name ' || range AS name, range * 1.5 AS score,
+			(range / 4)::DECIMAL(10,2) AS price, range::BIGINT * 1000000000 AS big,
+			range % 2 = 0 AS even, DATE '2024-01-01' + range::INTEGER AS day,
+			TIMESTAMP '2024-01-01 00:00:00' + INTERVAL (range) SECOND AS at,
+			TIMESTAMPTZ '2024-01-01 00:00:00+00' + INTERVAL (range) MINUTE AS at_tz,
+			CASE WHEN range % 3 = 0 THEN NULL ELSE (range % 200)::UTINYINT END AS maybe,
+			('blob' || range)::BLOB AS data
+		FROM range(5000)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		sql    string
+		wantOK bool
+	}{
+		{name: "ScalarTypes", sql: "SELECT * FROM arrow_values ORDER BY id", wantOK: true},
+		{name: "CommonTableExpression", sql: "WITH v AS (SELECT id, price FROM arrow_values) SELECT * FROM v WHERE id < 10 ORDER BY id", wantOK: true},
+		{name: "EmptyResult", sql: "SELECT id, name FROM arrow_values WHERE id < 0", wantOK: true},
+		{name: "JSONFallsBack", sql: `SELECT id, '{"a": 1}'::JSON AS doc FROM arrow_values WHERE id < 3 ORDER BY id`},
+		{name: "ListFallsBack", sql: "SELECT id, [id, id] AS pair FROM arrow_values WHERE id < 3 ORDER BY id"},
+		{name: "HugeintFallsBack", sql: "SELECT id::HUGEINT AS id FROM arrow_values WHERE id < 3 ORDER BY id"},
+		{name: "ShowFallsBack", sql: "SHOW TABLES"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok, err := executor.queryArrow(ctx, tc.sql)
+			if err != nil {
+				t.Fatalf("queryArrow() error = %v", err)
+			}
+			if ok != tc.wantOK {
+				t.Fatalf("queryArrow() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			want, err := executor.queryRows(ctx, tc.sql)
+			if err != nil {
+				t.Fatalf("queryRows() error = %v", err)
+			}
+			if diff := cmp.Diff(want, got, cmp.Comparer(func(x, y *big.Int) bool { return x.Cmp(y) == 0 }), cmpopts.IgnoreUnexported(Result{})); diff != "" {
+				t.Errorf("queryArrow() mismatch with queryRows() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExecutor_QueryArrowError(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+
+	_, ok, err := executor.queryArrow(context.Background(), "SELECT 1 / 0::INTEGER AS x, error('boom')")
+	if !ok || err == nil {
+		t.Errorf("queryArrow() = ok %v, error %v, want the query's error", ok, err)
+	}
+}
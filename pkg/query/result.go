@@ -10,6 +10,9 @@ import (
 type BindingValue struct {
 	Type  string // FIXED, TEXT, REAL, BOOLEAN, DATE, TIME, TIMESTAMP, etc.
 	Value string // String representation of the value
+	// Values holds the elements of an array binding, which binds a bulk
+	// INSERT once per element. A nil element is NULL.
+	Values []*string
 }
 
 // QueryBindingValue is an alias for BindingValue for backward compatibility.
@@ -24,11 +27,24 @@ type Result struct {
 	Columns     []string
 	ColumnTypes []types.ColumnMetadata
 	Rows        [][]interface{}
+	Warnings    []string
+
+	// partitions hold the rows of a result spilled while it was read, in
+	// place of Rows, and spilledRows counts them.
+	partitions  []resultPartition
+	spilledRows int
+}
+
+// RowCount returns the number of rows of the result, including those spilled
+// to disk while it was read.
+func (r *Result) RowCount() int {
+	return len(r.Rows) + r.spilledRows
 }
 
 // ExecResult represents the result of a non-query execution (INSERT, UPDATE, DELETE, etc.).
 type ExecResult struct {
 	RowsAffected int64
+	Warnings     []string
 }
 
 // CopyResult contains the result of a COPY INTO operation.
@@ -37,6 +53,23 @@ type CopyResult struct {
 	RowsInserted int64
 	FilesLoaded  int
 	Errors       []string
+	// Files reports the outcome of each file matched by the statement.
+	Files []CopyFileResult
+}
+
+// Load statuses of a file, as COPY INTO reports them.
+const (
+	CopyFileLoaded          = "LOADED"
+	CopyFilePartiallyLoaded = "PARTIALLY_LOADED"
+	CopyFileLoadFailed      = "LOAD_FAILED"
+)
+
+// CopyFileResult contains the outcome of loading one file with COPY INTO.
+type CopyFileResult struct {
+	File       string
+	Status     string
+	RowsLoaded int64
+	Error      string
 }
 
 // MergeResult contains the result of a MERGE operation.
@@ -0,0 +1,143 @@
+package query
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Default limits of a result chunk.
+const (
+	DefaultChunkRows  = 10000
+	DefaultChunkBytes = 8 << 20
+)
+
+// ChunkStore splits large query results into chunks, as Snowflake does for
+// drivers: the first chunk is returned with the query response and the others
+// are kept for the driver to download. Chunks are dropped after a TTL.
+type ChunkStore struct {
+	mu       sync.Mutex
+	results  map[string]*chunkedResult
+	ttl      time.Duration
+	maxRows  int
+	maxBytes int64
+}
+
+// chunkedResult is the chunks of a result after the first.
+type chunkedResult struct {
+	columnTypes []types.ColumnMetadata
+	chunks      [][][]interface{}
+	expires     time.Time
+}
+
+// ChunkInfo describes a chunk kept for download.
+type ChunkInfo struct {
+	RowCount int
+	// Size is the estimated size of the chunk's rows as JSON, in bytes.
+	Size int64
+}
+
+// ChunkStoreOption configures a ChunkStore.
+type ChunkStoreOption func(*ChunkStore)
+
+// WithChunkLimits sets the most rows and estimated bytes of a chunk. A result
+// within both limits is returned whole. A limit of 0 or less is not applied.
+func WithChunkLimits(rows int, bytes int64) ChunkStoreOption {
+	return func(s *ChunkStore) {
+		s.maxRows = rows
+		s.maxBytes = bytes
+	}
+}
+
+// NewChunkStore creates a chunk store keeping chunks for ttl, with chunks of
+// at most DefaultChunkRows rows and DefaultChunkBytes bytes.
+func NewChunkStore(ttl time.Duration, opts ...ChunkStoreOption) *ChunkStore {
+	s := &ChunkStore{
+		results:  make(map[string]*chunkedResult),
+		ttl:      ttl,
+		maxRows:  DefaultChunkRows,
+		maxBytes: DefaultChunkBytes,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Split splits a result into chunks within the store's limits. It returns the
+// rows of the first chunk and describes the others, which are kept under
+// queryID until they expire. A result within the limits is returned whole,
+// with no chunks kept.
+func (s *ChunkStore) Split(queryID string, result *Result) ([][]interface{}, []ChunkInfo) {
+	var chunks [][][]interface{}
+	var infos []ChunkInfo
+	start := 0
+	var size int64
+	for i, row := range result.Rows {
+		rowSize := estimateRowSize(row)
+		full := (s.maxRows > 0 && i-start >= s.maxRows) || (s.maxBytes > 0 && i > start && size+rowSize > s.maxBytes)
+		if full {
+			chunks = append(chunks, result.Rows[start:i])
+			infos = append(infos, ChunkInfo{RowCount: i - start, Size: size})
+			start, size = i, 0
+		}
+		size += rowSize
+	}
+	if len(chunks) == 0 {
+		return result.Rows, nil
+	}
+	chunks = append(chunks, result.Rows[start:])
+	infos = append(infos, ChunkInfo{RowCount: len(result.Rows) - start, Size: size})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeExpired()
+	s.results[queryID] = &chunkedResult{
+		columnTypes: result.ColumnTypes,
+		chunks:      chunks[1:],
+		expires:     time.Now().Add(s.ttl),
+	}
+	return chunks[0], infos[1:]
+}
+
+// Chunk returns the rows of a kept chunk, numbered from 0 for the chunk after
+// the first, and the column types of its result.
+func (s *ChunkStore) Chunk(queryID string, index int) ([][]interface{}, []types.ColumnMetadata, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[queryID]
+	if !ok || time.Now().After(result.expires) || index < 0 || index >= len(result.chunks) {
+		return nil, nil, false
+	}
+	return result.chunks[index], result.columnTypes, true
+}
+
+// removeExpired drops the chunks of expired results. The caller holds s.mu.
+func (s *ChunkStore) removeExpired() {
+	now := time.Now()
+	for queryID, result := range s.results {
+		if now.After(result.expires) {
+			delete(s.results, queryID)
+		}
+	}
+}
+
+// estimateRowSize estimates the size of a row rendered as a JSON array of
+// strings: the length of text and binary values, and a fixed size for others.
+func estimateRowSize(row []interface{}) int64 {
+	size := int64(2)
+	for _, val := range row {
+		switch v := val.(type) {
+		case nil:
+			size += 5
+		case string:
+			size += int64(len(v)) + 3
+		case []byte:
+			size += int64(2*len(v)) + 3
+		default:
+			size += 24
+		}
+	}
+	return size
+}
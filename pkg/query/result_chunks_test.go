@@ -0,0 +1,84 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChunkStore_Split(t *testing.T) {
+	rows := func(values ...interface{}) [][]interface{} {
+		result := make([][]interface{}, len(values))
+		for i, v := range values {
+			result[i] = []interface{}{v}
+		}
+		return result
+	}
+	long := strings.Repeat("x", 100)
+
+	tests := []struct {
+		name       string
+		opts       []ChunkStoreOption
+		rows       [][]interface{}
+		wantFirst  [][]interface{}
+		wantCounts []int
+	}{
+		{name: "WithinLimits", rows: rows("a", "b"), wantFirst: rows("a", "b")},
+		{
+			name:       "ByRows",
+			opts:       []ChunkStoreOption{WithChunkLimits(2, 0)},
+			rows:       rows("a", "b", "c", "d", "e"),
+			wantFirst:  rows("a", "b"),
+			wantCounts: []int{2, 1},
+		},
+		{
+			name:       "ByBytes",
+			opts:       []ChunkStoreOption{WithChunkLimits(0, 250)},
+			rows:       rows(long, long, long, "a", long),
+			wantFirst:  rows(long, long),
+			wantCounts: []int{3},
+		},
+		{
+			name:       "RowLargerThanLimit",
+			opts:       []ChunkStoreOption{WithChunkLimits(0, 10)},
+			rows:       rows(long, long),
+			wantFirst:  rows(long),
+			wantCounts: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewChunkStore(time.Hour, tt.opts...)
+			first, infos := store.Split("query-1", &Result{Columns: []string{"V"}, Rows: tt.rows})
+			if diff := cmp.Diff(tt.wantFirst, first); diff != "" {
+				t.Errorf("Split() first chunk mismatch (-want +got):\n%s", diff)
+			}
+			var counts []int
+			total := len(first)
+			for i, info := range infos {
+				counts = append(counts, info.RowCount)
+				chunk, _, ok := store.Chunk("query-1", i)
+				if !ok || len(chunk) != info.RowCount {
+					t.Errorf("Chunk(%d) = %d rows, %v, want %d rows", i, len(chunk), ok, info.RowCount)
+				}
+				total += len(chunk)
+			}
+			if diff := cmp.Diff(tt.wantCounts, counts); diff != "" {
+				t.Errorf("Split() chunk row counts mismatch (-want +got):\n%s", diff)
+			}
+			if total != len(tt.rows) {
+				t.Errorf("chunks hold %d rows, want %d", total, len(tt.rows))
+			}
+		})
+	}
+
+	// Chunks are dropped once they expire
+	store := NewChunkStore(-time.Second, WithChunkLimits(1, 0))
+	store.Split("query-2", &Result{Rows: rows("a", "b")})
+	if _, _, ok := store.Chunk("query-2", 0); ok {
+		t.Error("Chunk() of an expired result ok = true, want false")
+	}
+}
@@ -0,0 +1,256 @@
+package query
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+func init() {
+	// Result values are interface values, whose concrete types gob must know
+	for _, value := range []interface{}{
+		time.Time{}, &big.Int{}, duckdb.Decimal{}, duckdb.Interval{}, duckdb.UUID{}, duckdb.Map{},
+		map[string]interface{}{}, []interface{}{},
+	} {
+		gob.Register(value)
+	}
+}
+
+// StatementManagerOption configures a StatementManager.
+type StatementManagerOption func(*StatementManager)
+
+// WithResultSpill spills the results of more than thresholdRows rows to files
+// under dir, in partitions of partitionRows rows that are read back when they
+// are fetched. Spilled files are removed with their statement.
+func WithResultSpill(dir string, thresholdRows, partitionRows int) StatementManagerOption {
+	return func(sm *StatementManager) {
+		sm.spillDir = dir
+		sm.spillThreshold = thresholdRows
+		sm.partitionRows = partitionRows
+	}
+}
+
+// resultPartition is a partition of a spilled result.
+type resultPartition struct {
+	RowCount int
+	Size     int64 // Size of the partition's file in bytes
+	Path     string
+}
+
+// PartitionInfo describes a partition of a statement's result.
+type PartitionInfo struct {
+	RowCount int
+	// Size is the size of a spilled partition in bytes, or 0 for a result
+	// held in memory.
+	Size int64
+}
+
+// Partitions returns the partitions of a successful statement's result. A
+// result held in memory is a single partition.
+func (s *Statement) Partitions() []PartitionInfo {
+	if s.partitions == nil {
+		if s.Result == nil {
+			return nil
+		}
+		return []PartitionInfo{{RowCount: len(s.Result.Rows)}}
+	}
+	infos := make([]PartitionInfo, len(s.partitions))
+	for i, partition := range s.partitions {
+		infos[i] = PartitionInfo{RowCount: partition.RowCount, Size: partition.Size}
+	}
+	return infos
+}
+
+// PartitionRows returns the rows of a partition of a successful statement's
+// result, reading spilled partitions back from disk.
+func (s *Statement) PartitionRows(partition int) ([][]interface{}, error) {
+	if s.partitions == nil {
+		if s.Result == nil || partition != 0 {
+			return nil, fmt.Errorf("statement %s has no result partition %d", s.Handle, partition)
+		}
+		return s.Result.Rows, nil
+	}
+	if partition < 0 || partition >= len(s.partitions) {
+		return nil, fmt.Errorf("statement %s has no result partition %d", s.Handle, partition)
+	}
+
+	file, err := os.Open(s.partitions[partition].Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result partition %d: %w", partition, err)
+	}
+	defer func() { _ = file.Close() }()
+	var rows [][]interface{}
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to decode result partition %d: %w", partition, err)
+	}
+	return rows, nil
+}
+
+// spillResult writes the rows of a result to partition files under the
+// statement's spill directory. The returned partitions replace the rows.
+func (sm *StatementManager) spillResult(handle string, result *Result) ([]resultPartition, error) {
+	dir := filepath.Join(sm.spillDir, handle)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	var partitions []resultPartition
+	for start := 0; start < len(result.Rows); start += sm.partitionRows {
+		end := min(start+sm.partitionRows, len(result.Rows))
+		path := filepath.Join(dir, fmt.Sprintf("partition-%d.gob", len(partitions)))
+		size, err := writePartition(path, result.Rows[start:end])
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, err
+		}
+		partitions = append(partitions, resultPartition{RowCount: end - start, Size: size, Path: path})
+	}
+	return partitions, nil
+}
+
+// resultSpillKey is the context key of a statement's resultSpill.
+type resultSpillKey struct{}
+
+// resultSpill writes the rows of a query's result to partition files as they
+// are read from DuckDB, once they outnumber the spill threshold, so that at
+// most a partition of them and the rows read with the last one are held in
+// memory.
+type resultSpill struct {
+	dir           string
+	threshold     int
+	partitionRows int
+	partitions    []resultPartition
+	rows          int // Rows written to partitions
+}
+
+// ContextWithResultSpill returns a copy of ctx under which Executor.Query
+// spills the result of the statement with the given handle while reading it,
+// rather than after the whole result is read. SetResult takes the spilled
+// partitions. Without a spill directory, ctx is returned unchanged.
+func (sm *StatementManager) ContextWithResultSpill(ctx context.Context, handle string) context.Context {
+	if sm.spillDir == "" || sm.partitionRows <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, resultSpillKey{}, &resultSpill{
+		dir:           filepath.Join(sm.spillDir, handle),
+		threshold:     sm.spillThreshold,
+		partitionRows: sm.partitionRows,
+	})
+}
+
+// takeResultSpill returns the resultSpill carried by ctx, or nil, and a copy
+// of ctx without it, so that only the statement's own result is spilled, not
+// those of the statements it runs.
+func takeResultSpill(ctx context.Context) (*resultSpill, context.Context) {
+	spill, _ := ctx.Value(resultSpillKey{}).(*resultSpill)
+	if spill == nil {
+		return nil, ctx
+	}
+	return spill, context.WithValue(ctx, resultSpillKey{}, (*resultSpill)(nil))
+}
+
+// resultSpillFromContext returns the resultSpill carried by ctx, or nil.
+func resultSpillFromContext(ctx context.Context) *resultSpill {
+	spill, _ := ctx.Value(resultSpillKey{}).(*resultSpill)
+	return spill
+}
+
+// collect writes the full partitions of the rows read into result so far
+// once they outnumber the spill threshold, and removes them from it.
+func (s *resultSpill) collect(result *Result) error {
+	if s == nil || (s.partitions == nil && len(result.Rows) <= s.threshold) {
+		return nil
+	}
+	written := 0
+	for len(result.Rows)-written >= s.partitionRows {
+		if err := s.write(result.Rows[written : written+s.partitionRows]); err != nil {
+			return err
+		}
+		written += s.partitionRows
+	}
+	if written > 0 {
+		// The rest is copied, so that the written rows can be freed
+		result.Rows = append([][]interface{}(nil), result.Rows[written:]...)
+	}
+	return nil
+}
+
+// finish writes the rows left in result as its last partition, if any were
+// written, and moves the partitions to the result in place of its rows.
+func (s *resultSpill) finish(result *Result) error {
+	if s == nil || s.partitions == nil {
+		return nil
+	}
+	if len(result.Rows) > 0 {
+		if err := s.write(result.Rows); err != nil {
+			return err
+		}
+	}
+	result.Rows = nil
+	result.partitions, result.spilledRows = s.partitions, s.rows
+	return nil
+}
+
+// write writes rows as the next partition.
+func (s *resultSpill) write(rows [][]interface{}) error {
+	if s.partitions == nil {
+		if err := os.MkdirAll(s.dir, 0o700); err != nil {
+			return fmt.Errorf("failed to spill result: %w", err)
+		}
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("partition-%d.gob", len(s.partitions)))
+	size, err := writePartition(path, rows)
+	if err != nil {
+		return fmt.Errorf("failed to spill result: %w", err)
+	}
+	s.partitions = append(s.partitions, resultPartition{RowCount: len(rows), Size: size, Path: path})
+	s.rows += len(rows)
+	return nil
+}
+
+// discard removes the partitions written for a result that was not returned.
+func (s *resultSpill) discard() {
+	if s == nil || s.partitions == nil {
+		return
+	}
+	_ = os.RemoveAll(s.dir)
+	s.partitions, s.rows = nil, 0
+}
+
+// writePartition writes rows to a partition file and returns its size.
+func writePartition(path string, rows [][]interface{}) (int64, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(file)
+	if err := gob.NewEncoder(w).Encode(rows); err != nil {
+		_ = file.Close()
+		return 0, fmt.Errorf("failed to encode result partition: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+	return info.Size(), file.Close()
+}
+
+// removeSpill removes the spilled partitions of a statement.
+func (sm *StatementManager) removeSpill(stmt *Statement) {
+	if stmt.partitions == nil {
+		return
+	}
+	_ = os.RemoveAll(filepath.Join(sm.spillDir, stmt.Handle))
+}
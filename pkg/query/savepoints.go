@@ -0,0 +1,93 @@
+package query
+
+import (
+	"context"
+	"fmt"
+)
+
+// Savepoint statement kinds.
+const (
+	savepointCreate   = "SAVEPOINT"
+	savepointRelease  = "RELEASE"
+	savepointRollback = "ROLLBACK TO"
+)
+
+// parseSavepointStatement recognizes SAVEPOINT name, RELEASE [SAVEPOINT] name,
+// and ROLLBACK [WORK | TRANSACTION] TO [SAVEPOINT] name, returning the kind of
+// statement and the savepoint name.
+func parseSavepointStatement(sql string) (kind, name string, ok bool) {
+	keywords := statementKeywords(sql)
+	if len(keywords) == 0 {
+		return "", "", false
+	}
+
+	rest := keywords[1:]
+	switch keywords[0] {
+	case "SAVEPOINT":
+		kind = savepointCreate
+	case "RELEASE":
+		kind = savepointRelease
+		if len(rest) > 0 && rest[0] == "SAVEPOINT" {
+			rest = rest[1:]
+		}
+	case "ROLLBACK":
+		kind = savepointRollback
+		if len(rest) > 0 && (rest[0] == "WORK" || rest[0] == "TRANSACTION") {
+			rest = rest[1:]
+		}
+		if len(rest) == 0 || rest[0] != "TO" {
+			return "", "", false
+		}
+		rest = rest[1:]
+		if len(rest) > 0 && rest[0] == "SAVEPOINT" {
+			rest = rest[1:]
+		}
+	default:
+		return "", "", false
+	}
+
+	if len(rest) != 1 {
+		return "", "", false
+	}
+	return kind, rest[0], true
+}
+
+// executeSavepoint sets, releases, or rolls back to a savepoint of the
+// session's open transaction.
+//
+// DuckDB has no savepoints, so the emulator tracks their names itself, which
+// lets ORMs set and release them around nested transactions. Rolling back to a
+// savepoint would have to undo part of the DuckDB transaction, so it fails
+// instead, leaving the transaction open; rolling back the whole transaction
+// still works.
+func (e *Executor) executeSavepoint(ctx context.Context, kind, name string) (*ExecResult, error) {
+	e.transactions.mu.Lock()
+	defer e.transactions.mu.Unlock()
+	tx, ok := e.transactions.open[SessionInfoFromContext(ctx).ID]
+	if !ok {
+		return nil, fmt.Errorf("transaction error: %s can only be used in a transaction", kind)
+	}
+
+	if kind == savepointCreate {
+		tx.savepoints = append(tx.savepoints, name)
+		return &ExecResult{RowsAffected: 0}, nil
+	}
+
+	// The most recent savepoint of a name is the one released or rolled back to
+	index := -1
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i] == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("transaction error: savepoint %s does not exist", name)
+	}
+
+	if kind == savepointRollback {
+		return nil, fmt.Errorf("transaction error: ROLLBACK TO SAVEPOINT %s is not supported, since DuckDB cannot roll back part of a transaction; use ROLLBACK to roll back the whole transaction", name)
+	}
+	tx.savepoints = tx.savepoints[:index]
+	return &ExecResult{RowsAffected: 0}, nil
+}
@@ -0,0 +1,98 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSavepointStatement(t *testing.T) {
+	tests := []struct {
+		sql      string
+		wantKind string
+		wantName string
+		wantOK   bool
+	}{
+		{sql: "SAVEPOINT sp1", wantKind: savepointCreate, wantName: "SP1", wantOK: true},
+		{sql: "RELEASE SAVEPOINT sp1", wantKind: savepointRelease, wantName: "SP1", wantOK: true},
+		{sql: "RELEASE sp1", wantKind: savepointRelease, wantName: "SP1", wantOK: true},
+		{sql: "ROLLBACK TO SAVEPOINT sp1", wantKind: savepointRollback, wantName: "SP1", wantOK: true},
+		{sql: "ROLLBACK TRANSACTION TO sp1", wantKind: savepointRollback, wantName: "SP1", wantOK: true},
+		{sql: "ROLLBACK", wantOK: false},
+		{sql: "ROLLBACK WORK", wantOK: false},
+		{sql: "SAVEPOINT", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			kind, name, ok := parseSavepointStatement(tt.sql)
+			if kind != tt.wantKind || name != tt.wantName || ok != tt.wantOK {
+				t.Errorf("parseSavepointStatement(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.sql, kind, name, ok, tt.wantKind, tt.wantName, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExecutor_Savepoints(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1", User: "TESTER"})
+
+	ids := func() []interface{} {
+		t.Helper()
+		result, err := executor.Query(ctx, "SELECT id FROM items ORDER BY id")
+		if err != nil {
+			t.Fatalf("SELECT error = %v", err)
+		}
+		var got []interface{}
+		for _, row := range result.Rows {
+			got = append(got, row[0])
+		}
+		return got
+	}
+
+	// Nested transactions as ORMs issue them
+	statements := []string{
+		"CREATE TABLE items (id INTEGER)",
+		"BEGIN",
+		"INSERT INTO items VALUES (1)",
+		"SAVEPOINT outer_sp",
+		"INSERT INTO items VALUES (2)",
+		"SAVEPOINT inner_sp",
+		"INSERT INTO items VALUES (3)",
+		"RELEASE SAVEPOINT inner_sp",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Execute(ctx, "RELEASE SAVEPOINT inner_sp"); err == nil {
+		t.Error("RELEASE of a released savepoint should fail")
+	}
+
+	// Rolling back to a savepoint fails without touching the transaction
+	_, err := executor.Execute(ctx, "ROLLBACK TO SAVEPOINT outer_sp")
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("ROLLBACK TO SAVEPOINT error = %v, want not supported", err)
+	}
+	if diff := cmp.Diff([]interface{}{int64(1), int64(2), int64(3)}, ids()); diff != "" {
+		t.Errorf("after ROLLBACK TO outer_sp mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := executor.Execute(ctx, "RELEASE SAVEPOINT outer_sp"); err != nil {
+		t.Fatalf("RELEASE SAVEPOINT error = %v", err)
+	}
+	if _, err := executor.Execute(ctx, "COMMIT"); err != nil {
+		t.Fatalf("COMMIT error = %v", err)
+	}
+	if diff := cmp.Diff([]interface{}{int64(1), int64(2), int64(3)}, ids()); diff != "" {
+		t.Errorf("after COMMIT mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := executor.Execute(ctx, "SAVEPOINT sp"); err == nil {
+		t.Error("SAVEPOINT outside a transaction should fail")
+	}
+}
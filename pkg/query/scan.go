@@ -0,0 +1,51 @@
+package query
+
+import "strings"
+
+// The helpers below scan SQL text for the rewrites that work on the text
+// rather than the parsed statement.
+
+// keywordAt reports whether keyword (case-insensitive) starts at sql[i] as a whole word.
+func keywordAt(sql string, i int, keyword string) bool {
+	if i+len(keyword) > len(sql) || !strings.EqualFold(sql[i:i+len(keyword)], keyword) {
+		return false
+	}
+	if i > 0 && isIdentChar(sql[i-1]) {
+		return false
+	}
+	end := i + len(keyword)
+	return end == len(sql) || !isIdentChar(sql[end])
+}
+
+// skipQuoted returns the index of the quote closing the literal or quoted
+// identifier that starts at s[start]. Doubled quotes inside either, and
+// backslash-escaped ones inside string literals, are skipped. If the literal
+// or identifier is unterminated, the index of the last byte is returned.
+func skipQuoted(s string, start int, quote byte) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '\'' {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(s) - 1
+}
+
+// isIdentChar reports whether c may be part of a possibly qualified identifier.
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isSpace reports whether c is SQL whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
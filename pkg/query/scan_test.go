@@ -0,0 +1,72 @@
+package query
+
+import "testing"
+
+func TestKeywordAt(t *testing.T) {
+	tests := []struct {
+		sql     string
+		i       int
+		keyword string
+		want    bool
+	}{
+		{sql: "ORDER BY x", i: 0, keyword: "order", want: true},
+		{sql: "x order by", i: 2, keyword: "ORDER", want: true},
+		{sql: "reorder", i: 2, keyword: "ORDER", want: false},
+		{sql: "ORDERS", i: 0, keyword: "ORDER", want: false},
+		{sql: "t.order", i: 2, keyword: "ORDER", want: false},
+		{sql: "ORD", i: 0, keyword: "ORDER", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := keywordAt(tt.sql, tt.i, tt.keyword); got != tt.want {
+			t.Errorf("keywordAt(%q, %d, %q) = %v, want %v", tt.sql, tt.i, tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestSkipQuoted(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		start int
+		want  int
+	}{
+		{name: "String", s: "'abc' x", want: 4},
+		{name: "DoubledQuote", s: "'it''s' x", want: 6},
+		{name: "BackslashEscape", s: `'a\'b' x`, want: 5},
+		{name: "Unterminated", s: "'abc", want: 3},
+		{name: "Identifier", s: `"a b".c`, want: 4},
+		{name: "IdentifierDoubledQuote", s: `"a""b" x`, want: 5},
+		{name: "IdentifierBackslash", s: `"a\" x`, want: 3},
+		{name: "Offset", s: `x = 'y'`, start: 4, want: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skipQuoted(tt.s, tt.start, tt.s[tt.start]); got != tt.want {
+				t.Errorf("skipQuoted(%q, %d) = %d, want %d", tt.s, tt.start, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsIdentCharAndIsSpace(t *testing.T) {
+	for _, c := range []byte("aZ09_$.") {
+		if !isIdentChar(c) {
+			t.Errorf("isIdentChar(%q) = false, want true", c)
+		}
+	}
+	for _, c := range []byte(" \t\n\r(),'\"-:") {
+		if isIdentChar(c) {
+			t.Errorf("isIdentChar(%q) = true, want false", c)
+		}
+	}
+	for _, c := range []byte(" \t\n\r") {
+		if !isSpace(c) {
+			t.Errorf("isSpace(%q) = false, want true", c)
+		}
+	}
+	if isSpace('x') {
+		t.Error("isSpace('x') = true, want false")
+	}
+}
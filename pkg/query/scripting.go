@@ -0,0 +1,481 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// anonymousBlockColumn is the result column of EXECUTE IMMEDIATE with a block.
+const anonymousBlockColumn = "anonymous block"
+
+// unsupportedScriptKeywords start Snowflake Scripting statements that blocks
+// cannot run yet.
+var unsupportedScriptKeywords = map[string]bool{
+	"IF":        true,
+	"CASE":      true,
+	"FOR":       true,
+	"WHILE":     true,
+	"REPEAT":    true,
+	"LOOP":      true,
+	"BREAK":     true,
+	"CONTINUE":  true,
+	"BEGIN":     true,
+	"DECLARE":   true,
+	"EXCEPTION": true,
+	"RAISE":     true,
+	"OPEN":      true,
+	"FETCH":     true,
+	"CLOSE":     true,
+}
+
+// scriptBlock is a Snowflake Scripting block: [DECLARE ...] BEGIN ... END.
+type scriptBlock struct {
+	Declarations []scriptAssignment
+	Statements   []string
+}
+
+// scriptAssignment assigns an expression to a block variable, as in a DECLARE
+// section, a LET statement, or name := expr.
+type scriptAssignment struct {
+	Name string
+	Expr string
+}
+
+// executeImmediate is a parsed EXECUTE IMMEDIATE statement.
+type executeImmediate struct {
+	// Text is the statement or block to run.
+	Text string
+	// Using are the expressions bound to the ? and :1, :2, ... placeholders of Text.
+	Using []string
+}
+
+// parseExecuteImmediate parses EXECUTE IMMEDIATE '<text>' | $$<text>$$
+// [USING (expr, ...)]. It reports false for other statements.
+func parseExecuteImmediate(sql string) (*executeImmediate, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	keywords := statementKeywords(s)
+	if !keywordsHavePrefix(keywords, "EXECUTE", "IMMEDIATE") {
+		return nil, false, nil
+	}
+	i := indexFold(s, "IMMEDIATE") + len("IMMEDIATE")
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+
+	stmt := &executeImmediate{}
+	switch {
+	case strings.HasPrefix(s[i:], "$$"):
+		end := strings.Index(s[i+2:], "$$")
+		if end < 0 {
+			return nil, true, fmt.Errorf("unterminated $$ in EXECUTE IMMEDIATE")
+		}
+		stmt.Text = s[i+2 : i+2+end]
+		i += 2 + end + 2
+	case i < len(s) && s[i] == '\'':
+		end := skipQuoted(s, i, '\'')
+		if end <= i || s[end] != '\'' {
+			return nil, true, fmt.Errorf("unterminated string in EXECUTE IMMEDIATE")
+		}
+		text := strings.ReplaceAll(s[i+1:end], "''", "'")
+		stmt.Text = strings.ReplaceAll(text, `\'`, "'")
+		i = end + 1
+	default:
+		return nil, true, fmt.Errorf("EXECUTE IMMEDIATE expects a string literal, $$ text, or variable")
+	}
+
+	rest := strings.TrimSpace(s[i:])
+	if rest == "" {
+		return stmt, true, nil
+	}
+	if !keywordAt(rest, 0, "USING") {
+		return nil, true, fmt.Errorf("unexpected %q after EXECUTE IMMEDIATE text", rest)
+	}
+	rest = strings.TrimSpace(rest[len("USING"):])
+	end := matchingParen(rest)
+	if !strings.HasPrefix(rest, "(") || end != len(rest)-1 {
+		return nil, true, fmt.Errorf("USING expects a parenthesized list of bind variables")
+	}
+	for _, expr := range splitFunctionArgs(rest[1:end], 0) {
+		stmt.Using = append(stmt.Using, strings.TrimSpace(expr))
+	}
+	return stmt, true, nil
+}
+
+// isScriptBlock reports whether text is a Snowflake Scripting block rather than
+// a single statement.
+func isScriptBlock(text string) bool {
+	s := strings.TrimSpace(stripLeadingComments(text))
+	if keywordAt(s, 0, "DECLARE") {
+		return true
+	}
+	// BEGIN alone, or BEGIN TRANSACTION, starts a transaction instead
+	s = strings.TrimRight(s, "; \t\r\n")
+	return keywordAt(s, 0, "BEGIN") && len(s) >= len("BEGIN END") && keywordAt(s, len(s)-len("END"), "END")
+}
+
+// parseScriptBlock parses [DECLARE declarations] BEGIN statements END.
+func parseScriptBlock(text string) (*scriptBlock, error) {
+	s := strings.TrimRight(strings.TrimSpace(stripLeadingComments(text)), "; \t\r\n")
+	block := &scriptBlock{}
+
+	if keywordAt(s, 0, "DECLARE") {
+		begin := topLevelKeyword(s, "BEGIN")
+		if begin < 0 {
+			return nil, fmt.Errorf("DECLARE section without BEGIN")
+		}
+		for _, declaration := range SplitStatements(s[len("DECLARE"):begin]) {
+			assignment, err := parseDeclaration(declaration)
+			if err != nil {
+				return nil, err
+			}
+			block.Declarations = append(block.Declarations, assignment)
+		}
+		s = s[begin:]
+	}
+
+	if !keywordAt(s, 0, "BEGIN") || len(s) < len("BEGIN END") || !keywordAt(s, len(s)-len("END"), "END") {
+		return nil, fmt.Errorf("block must be BEGIN ... END")
+	}
+	block.Statements = SplitStatements(s[len("BEGIN") : len(s)-len("END")])
+	return block, nil
+}
+
+// parseDeclaration parses a DECLARE entry: name [type] [DEFAULT | := expr].
+func parseDeclaration(declaration string) (scriptAssignment, error) {
+	fields := strings.Fields(declaration)
+	if len(fields) == 0 || !isVariableName(fields[0]) {
+		return scriptAssignment{}, fmt.Errorf("invalid declaration %q", declaration)
+	}
+	for _, kind := range []string{"CURSOR", "RESULTSET", "EXCEPTION"} {
+		if len(fields) > 1 && strings.EqualFold(fields[1], kind) {
+			return scriptAssignment{}, fmt.Errorf("%s declarations are not supported", kind)
+		}
+	}
+	assignment := scriptAssignment{Name: variableName(fields[0]), Expr: "NULL"}
+	if expr, ok := assignedExpr(declaration); ok {
+		assignment.Expr = expr
+	}
+	return assignment, nil
+}
+
+// assignedExpr returns the expression after the first top-level := or DEFAULT
+// in s.
+func assignedExpr(s string) (string, bool) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(s, i, c)
+		case strings.HasPrefix(s[i:], ":="):
+			return strings.TrimSpace(s[i+2:]), true
+		case keywordAt(s, i, "DEFAULT"):
+			return strings.TrimSpace(s[i+len("DEFAULT"):]), true
+		}
+	}
+	return "", false
+}
+
+// topLevelKeyword returns the index of the first occurrence of keyword in s
+// outside literals, quoted identifiers, comments, and parentheses, or -1.
+func topLevelKeyword(s, keyword string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(s, i, c)
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && keywordAt(s, i, keyword):
+			return i
+		default:
+			if end, ok := commentEnd(s, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	return -1
+}
+
+// SplitStatements splits a script at semicolons outside literals, quoted
+// identifiers, comments, and $$ bodies, dropping statements with no tokens,
+// such as a comment after the last semicolon.
+func SplitStatements(sql string) []string {
+	var statements []string
+	start := 0
+	add := func(end int) {
+		if statement := strings.TrimSpace(sql[start:end]); stripLeadingComments(statement) != "" {
+			statements = append(statements, statement)
+		}
+	}
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '$' && i+1 < len(sql) && sql[i+1] == '$':
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				i = len(sql)
+				break
+			}
+			i += 2 + end + 1
+		case c == ';':
+			add(i)
+			start = i + 1
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+	add(len(sql))
+	return statements
+}
+
+// queryExecuteImmediate runs EXECUTE IMMEDIATE. A block returns a single row
+// holding its RETURN value; a single statement returns its own result.
+func (e *Executor) queryExecuteImmediate(ctx context.Context, stmt *executeImmediate) (*Result, error) {
+	text := stmt.Text
+	if len(stmt.Using) > 0 {
+		values, err := e.evaluate(ctx, nil, stmt.Using...)
+		if err != nil {
+			return nil, err
+		}
+		text = bindPlaceholders(text, values)
+	}
+
+	if !isScriptBlock(text) {
+		return e.runStatement(ctx, text)
+	}
+	block, err := parseScriptBlock(text)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.runBlock(ctx, block, map[string]sessionVariable{})
+	if err != nil {
+		return nil, err
+	}
+	return scalarResult(anonymousBlockColumn, value), nil
+}
+
+// runStatement runs one statement as a query or, for other statements, as a
+// command whose result reports the number of rows affected.
+func (e *Executor) runStatement(ctx context.Context, sql string) (*Result, error) {
+	if ClassifySQL(sql).IsQuery {
+		return e.Query(ctx, sql)
+	}
+	result, err := e.Execute(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return scalarResult("number of rows affected", sessionVariable{
+		Value: result.RowsAffected,
+		Type:  types.ColumnMetadata{Type: "fixed", Precision: 19, Nullable: true},
+	}), nil
+}
+
+// runBlock runs the statements of a block with vars as its variables, and
+// returns the value of its RETURN statement, or NULL if it has none.
+func (e *Executor) runBlock(ctx context.Context, block *scriptBlock, vars map[string]sessionVariable) (sessionVariable, error) {
+	null := sessionVariable{Type: types.ColumnMetadata{Type: "text", Nullable: true}}
+
+	assign := func(assignment scriptAssignment) error {
+		values, err := e.evaluate(ctx, vars, assignment.Expr)
+		if err != nil {
+			return fmt.Errorf("failed to assign %s: %w", assignment.Name, err)
+		}
+		vars[assignment.Name] = values[0]
+		return nil
+	}
+
+	for _, declaration := range block.Declarations {
+		if err := assign(declaration); err != nil {
+			return null, err
+		}
+	}
+
+	for _, statement := range block.Statements {
+		keywords := statementKeywords(statement)
+		if len(keywords) == 0 {
+			continue
+		}
+		switch {
+		case keywords[0] == "RETURN":
+			expr := strings.TrimSpace(stripLeadingComments(statement)[len("RETURN"):])
+			if expr == "" {
+				return null, nil
+			}
+			values, err := e.evaluate(ctx, vars, expr)
+			if err != nil {
+				return null, err
+			}
+			return values[0], nil
+		case keywords[0] == "LET":
+			rest := strings.TrimSpace(stripLeadingComments(statement)[len("LET"):])
+			assignment, err := parseDeclaration(rest)
+			if err != nil {
+				return null, err
+			}
+			if err := assign(assignment); err != nil {
+				return null, err
+			}
+		case keywords[0] == "NULL" && len(keywords) == 1:
+		case unsupportedScriptKeywords[keywords[0]] &&
+			!keywordsHavePrefix(keywords, "BEGIN", "TRANSACTION") && !keywordsHavePrefix(keywords, "BEGIN", "WORK"):
+			// In blocks, BEGIN starts a nested block unless followed by TRANSACTION
+			return null, fmt.Errorf("%s statements in Snowflake Scripting blocks are not supported", keywords[0])
+		default:
+			if name, expr, ok := parseAssignment(statement); ok {
+				if _, declared := vars[name]; !declared {
+					return null, fmt.Errorf("variable %s is not declared", name)
+				}
+				if err := assign(scriptAssignment{Name: name, Expr: expr}); err != nil {
+					return null, err
+				}
+				continue
+			}
+			if _, err := e.runStatement(ctx, bindScriptVariables(statement, vars, false)); err != nil {
+				return null, err
+			}
+		}
+	}
+	return null, nil
+}
+
+// parseAssignment parses name := expr.
+func parseAssignment(statement string) (name, expr string, ok bool) {
+	s := strings.TrimSpace(stripLeadingComments(statement))
+	end := variableEnd(s, 0)
+	if end == 0 || !isVariableStart(s[0]) {
+		return "", "", false
+	}
+	rest := strings.TrimSpace(s[end:])
+	if !strings.HasPrefix(rest, ":=") {
+		return "", "", false
+	}
+	return strings.ToUpper(s[:end]), strings.TrimSpace(rest[2:]), true
+}
+
+// evaluate evaluates expressions in a single query, with block variables
+// replaced by their values, and returns their values.
+func (e *Executor) evaluate(ctx context.Context, vars map[string]sessionVariable, exprs ...string) ([]sessionVariable, error) {
+	bound := make([]string, len(exprs))
+	for i, expr := range exprs {
+		bound[i] = bindScriptVariables(expr, vars, true)
+	}
+	result, err := e.Query(ctx, "SELECT "+strings.Join(bound, ", "))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rows) > 1 {
+		return nil, fmt.Errorf("expression returned %d rows, expected at most one", len(result.Rows))
+	}
+	values := make([]sessionVariable, len(exprs))
+	for i := range values {
+		values[i].Type = result.ColumnTypes[i]
+		if len(result.Rows) == 1 {
+			values[i].Value = result.Rows[0][i]
+		}
+	}
+	return values, nil
+}
+
+// bindScriptVariables replaces :name references to block variables with their
+// values as literals. In expressions, variables may also be referenced by bare
+// name, as in LET and RETURN. Literals, quoted identifiers, comments, ::
+// casts, and qualified names are left alone.
+func bindScriptVariables(sql string, vars map[string]sessionVariable, bareNames bool) string {
+	if len(vars) == 0 {
+		return sql
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i : end+1])
+			i = end
+		case c == ':' && i+1 < len(sql) && isVariableStart(sql[i+1]) && (i == 0 || (sql[i-1] != ':' && !isIdentChar(sql[i-1]))):
+			end := variableEnd(sql, i+1)
+			if variable, ok := vars[strings.ToUpper(sql[i+1:end])]; ok {
+				b.WriteString(variableLiteral(variable))
+				i = end - 1
+				continue
+			}
+			b.WriteByte(c)
+		case bareNames && isVariableStart(c) && (i == 0 || (!isIdentChar(sql[i-1]) && sql[i-1] != ':')):
+			end := variableEnd(sql, i)
+			qualified := end < len(sql) && (sql[end] == '.' || sql[end] == '(')
+			if variable, ok := vars[strings.ToUpper(sql[i:end])]; ok && !qualified {
+				b.WriteString(variableLiteral(variable))
+			} else {
+				b.WriteString(sql[i:end])
+			}
+			i = end - 1
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				b.WriteString(sql[i:end])
+				i = end - 1
+				continue
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// bindPlaceholders replaces the ? and :1, :2, ... placeholders of sql with
+// values as literals. The nth ? is bound to the nth value.
+func bindPlaceholders(sql string, values []sessionVariable) string {
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i : end+1])
+			i = end
+		case c == '?' && next < len(values):
+			b.WriteString(variableLiteral(values[next]))
+			next++
+		case c == ':' && i+1 < len(sql) && sql[i+1] >= '1' && sql[i+1] <= '9' && (i == 0 || (sql[i-1] != ':' && !isIdentChar(sql[i-1]))):
+			end := i + 1
+			n := 0
+			for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+				n = n*10 + int(sql[end]-'0')
+				end++
+			}
+			if n > len(values) {
+				b.WriteString(sql[i:end])
+			} else {
+				b.WriteString(variableLiteral(values[n-1]))
+			}
+			i = end - 1
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				b.WriteString(sql[i:end])
+				i = end - 1
+				continue
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// scalarResult returns a result of one row and one column holding value.
+func scalarResult(column string, value sessionVariable) *Result {
+	columnType := value.Type
+	columnType.Name = column
+	return &Result{
+		Columns:     []string{column},
+		ColumnTypes: []types.ColumnMetadata{columnType},
+		Rows:        [][]interface{}{{value.Value}},
+	}
+}
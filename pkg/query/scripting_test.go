@@ -0,0 +1,142 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{name: "statements", sql: "SELECT 1; SELECT 2;", want: []string{"SELECT 1", "SELECT 2"}},
+		{name: "literal", sql: "INSERT INTO t VALUES ('a;b'); SELECT 1", want: []string{"INSERT INTO t VALUES ('a;b')", "SELECT 1"}},
+		{name: "comment", sql: "SELECT 1 -- one; two\n; SELECT 2", want: []string{"SELECT 1 -- one; two", "SELECT 2"}},
+		{name: "dollar body", sql: "CREATE PROCEDURE p() RETURNS INT AS $$ BEGIN RETURN 1; END $$; CALL p()", want: []string{"CREATE PROCEDURE p() RETURNS INT AS $$ BEGIN RETURN 1; END $$", "CALL p()"}},
+		{name: "empty", sql: " ; ", want: nil},
+		{name: "trailing comment", sql: "SELECT 1; -- done\n", want: []string{"SELECT 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, SplitStatements(tt.sql)); diff != "" {
+				t.Errorf("SplitStatements(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+func TestParseExecuteImmediate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *executeImmediate
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "literal", sql: "EXECUTE IMMEDIATE 'SELECT ''a'''", want: &executeImmediate{Text: "SELECT 'a'"}, wantOK: true},
+		{name: "dollar", sql: "execute immediate $$BEGIN RETURN 1; END$$;", want: &executeImmediate{Text: "BEGIN RETURN 1; END"}, wantOK: true},
+		{name: "using", sql: "EXECUTE IMMEDIATE 'SELECT ?, ?' USING (1, CONCAT('a', 'b'))", want: &executeImmediate{Text: "SELECT ?, ?", Using: []string{"1", "CONCAT('a', 'b')"}}, wantOK: true},
+		{name: "not quoted", sql: "EXECUTE IMMEDIATE SELECT 1", wantOK: true, wantErr: true},
+		{name: "trailing text", sql: "EXECUTE IMMEDIATE 'SELECT 1' now", wantOK: true, wantErr: true},
+		{name: "execute task", sql: "EXECUTE TASK t", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseExecuteImmediate(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseExecuteImmediate(%q) = (%v, %v, %v), want ok %v, error %v", tt.sql, got, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseExecuteImmediate(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+func TestExecutor_ExecuteImmediate(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1"})
+
+	for _, sql := range []string{
+		"CREATE TABLE users (id INTEGER, name VARCHAR)",
+		"SET stmt = 'INSERT INTO users VALUES (1, ''alice'')'",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		sql         string
+		wantColumns []string
+		want        [][]interface{}
+	}{
+		{
+			name:        "statement from variable",
+			sql:         "EXECUTE IMMEDIATE $stmt",
+			wantColumns: []string{"number of rows affected"},
+			want:        [][]interface{}{{int64(1)}},
+		},
+		{
+			name:        "query with bind variables",
+			sql:         "EXECUTE IMMEDIATE 'SELECT name FROM users WHERE id = ? AND name = :2' USING (1, 'alice')",
+			wantColumns: []string{"name"},
+			want:        [][]interface{}{{"alice"}},
+		},
+		{
+			name: "anonymous block",
+			sql: `EXECUTE IMMEDIATE $$
+DECLARE
+  next_id INTEGER DEFAULT 2;
+  label VARCHAR;
+BEGIN
+  label := 'bob';
+  INSERT INTO users VALUES (:next_id, :label);
+  LET total INTEGER := (SELECT COUNT(*) FROM users);
+  RETURN CONCAT(label, ':', total);
+END;
+$$`,
+			wantColumns: []string{anonymousBlockColumn},
+			want:        [][]interface{}{{"bob:2"}},
+		},
+		{
+			name:        "block without RETURN",
+			sql:         "EXECUTE IMMEDIATE 'BEGIN DELETE FROM users WHERE id = 2; END'",
+			wantColumns: []string{anonymousBlockColumn},
+			want:        [][]interface{}{{nil}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !IsQuery(tt.sql) {
+				t.Errorf("IsQuery(%q) = false, want true", tt.sql)
+			}
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if diff := cmp.Diff(tt.wantColumns, result.Columns); diff != "" {
+				t.Errorf("Query(%q) columns mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+			if diff := cmp.Diff(tt.want, result.Rows); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+
+	for _, sql := range []string{
+		"EXECUTE IMMEDIATE 'BEGIN IF (1 = 1) THEN RETURN 1; END IF; END'",
+		"EXECUTE IMMEDIATE 'BEGIN undeclared := 1; END'",
+	} {
+		if _, err := executor.Query(ctx, sql); err == nil {
+			t.Errorf("Query(%q) should fail", sql)
+		}
+	}
+}
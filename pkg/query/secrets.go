@@ -0,0 +1,428 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// getSecretFunction is the emulator's function reading a secret of the running
+// procedure, standing in for the _snowflake module of Python handlers.
+const getSecretFunction = "SYSTEM$GET_SECRET"
+
+// secretValueFields are the properties returned by SYSTEM$GET_SECRET when no
+// field is given, keyed by secret type. The emulator has no OAuth server, so
+// the refresh token of an OAUTH2 secret stands in for its access token.
+var secretValueFields = map[string]string{
+	"GENERIC_STRING": "SECRET_STRING",
+	"PASSWORD":       "PASSWORD",
+	"OAUTH2":         "OAUTH_REFRESH_TOKEN",
+}
+
+// secretTypes are the accepted secret types.
+var secretTypes = map[string]bool{
+	"GENERIC_STRING":       true,
+	"PASSWORD":             true,
+	"OAUTH2":               true,
+	"CLOUD_PROVIDER_TOKEN": true,
+	"SYMMETRIC_KEY":        true,
+}
+
+// secret is a secret created with CREATE SECRET.
+type secret struct {
+	Database, Schema, Name string
+	Owner                  string
+	Type                   string
+	// Properties are the secret's properties, such as SECRET_STRING or
+	// USERNAME, keyed by their upper-case names. They hold the fake values
+	// that procedures read with SYSTEM$GET_SECRET.
+	Properties map[string]string
+	Comment    string
+	CreatedOn  time.Time
+}
+
+// secretRegistry holds the secrets, keyed by their qualified name.
+type secretRegistry struct {
+	mu     sync.Mutex
+	byName map[string]*secret
+}
+
+// showSecretsNames are the columns of a SHOW SECRETS result. Secret values are
+// never listed.
+var showSecretsNames = []string{
+	"created_on", "name", "database_name", "schema_name", "owner", "comment", "secret_type", "oauth_scopes",
+}
+
+// procedureSecretsKey is the context key of the secrets of the running procedure.
+type procedureSecretsKey struct{}
+
+// withProcedureSecrets returns a context running a procedure whose SECRETS
+// clause maps the aliases of secrets to their qualified names.
+func withProcedureSecrets(ctx context.Context, secrets map[string]string) context.Context {
+	return context.WithValue(ctx, procedureSecretsKey{}, secrets)
+}
+
+// parseSecret parses the properties of a CREATE SECRET statement.
+func parseSecret(ctx context.Context, stmt *createStatement) (*secret, error) {
+	properties, err := createProperties(stmt)
+	if err != nil {
+		return nil, err
+	}
+	database, schema, name := splitObjectName(ctx, stmt.Name)
+	s := &secret{
+		Database:   strings.ToUpper(database),
+		Schema:     strings.ToUpper(schema),
+		Name:       strings.ToUpper(name),
+		Owner:      SessionInfoFromContext(ctx).Role,
+		Type:       strings.ToUpper(properties["TYPE"]),
+		Properties: properties,
+	}
+	if !secretTypes[s.Type] {
+		return nil, fmt.Errorf("secret %s has an invalid TYPE %q", stmt.Name, properties["TYPE"])
+	}
+	delete(properties, "TYPE")
+	if stmt.Comment != nil {
+		s.Comment = *stmt.Comment
+	}
+	return s, nil
+}
+
+// executeCreateSecret handles CREATE SECRET by registering the secret with the
+// executor.
+func (e *Executor) executeCreateSecret(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	s, err := parseSecret(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	s.CreatedOn = time.Now()
+	name := qualifiedName(ctx, stmt.Name)
+
+	e.secrets.mu.Lock()
+	defer e.secrets.mu.Unlock()
+	if _, exists := e.secrets.byName[name]; exists {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case !stmt.OrReplace:
+			return nil, fmt.Errorf("secret %s already exists", name)
+		}
+	}
+	if e.secrets.byName == nil {
+		e.secrets.byName = make(map[string]*secret)
+	}
+	e.secrets.byName[name] = s
+	return &ExecResult{}, nil
+}
+
+// lookupSecret returns a copy of the secret with a qualified name, or false if
+// it does not exist.
+func (e *Executor) lookupSecret(name string) (secret, bool) {
+	e.secrets.mu.Lock()
+	defer e.secrets.mu.Unlock()
+	found, ok := e.secrets.byName[name]
+	if !ok {
+		return secret{}, false
+	}
+	return *found, true
+}
+
+// dropSecretName returns the name of the secret dropped by a DROP SECRET [IF
+// EXISTS] name statement.
+func dropSecretName(sql string) (name string, ifExists, ok bool) {
+	fields := strings.Fields(strings.TrimRight(stripLeadingComments(sql), "; \t\r\n"))
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "DROP") || !strings.EqualFold(fields[1], "SECRET") {
+		return "", false, false
+	}
+	fields = fields[2:]
+	if len(fields) >= 3 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") {
+		ifExists = true
+		fields = fields[2:]
+	}
+	return fields[0], ifExists, true
+}
+
+// executeDropSecret drops a secret.
+func (e *Executor) executeDropSecret(ctx context.Context, name string, ifExists bool) (*ExecResult, error) {
+	qualified := qualifiedName(ctx, name)
+
+	e.secrets.mu.Lock()
+	defer e.secrets.mu.Unlock()
+	if _, ok := e.secrets.byName[qualified]; !ok {
+		if ifExists {
+			return &ExecResult{}, nil
+		}
+		return nil, fmt.Errorf("secret %s does not exist", qualified)
+	}
+	delete(e.secrets.byName, qualified)
+	return &ExecResult{}, nil
+}
+
+// isShowSecrets reports whether sql is a SHOW SECRETS statement.
+func isShowSecrets(sql string) bool {
+	return keywordsHavePrefix(statementKeywords(sql), "SHOW", "SECRETS")
+}
+
+// queryShowSecrets lists the secrets, filtered by an optional LIKE pattern. The
+// IN clause is ignored, so secrets of all schemas are listed.
+func (e *Executor) queryShowSecrets(sql string) (*Result, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	rest := strings.TrimSpace(s[indexFold(s, "SECRETS")+len("SECRETS"):])
+	like := ""
+	if keywordAt(rest, 0, "LIKE") {
+		value, _, ok := commentValueAt(rest, len("LIKE"))
+		if !ok {
+			return nil, fmt.Errorf("SHOW SECRETS: invalid LIKE pattern")
+		}
+		like = value
+	}
+
+	result := &Result{Columns: showSecretsNames}
+	for _, name := range showSecretsNames {
+		col := types.ColumnMetadata{Type: "text", Nullable: true}
+		if name == "created_on" {
+			col.Type = "timestamp_ltz"
+		}
+		col.Name = name
+		result.ColumnTypes = append(result.ColumnTypes, col)
+	}
+
+	e.secrets.mu.Lock()
+	defer e.secrets.mu.Unlock()
+	names := make([]string, 0, len(e.secrets.byName))
+	for name, listed := range e.secrets.byName {
+		if like == "" || likePattern(like).MatchString(listed.Name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		listed := e.secrets.byName[name]
+		var scopes interface{}
+		if value, ok := listed.Properties["OAUTH_SCOPES"]; ok {
+			scopes = value
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			listed.CreatedOn, listed.Name, listed.Database, listed.Schema, listed.Owner, listed.Comment, listed.Type, scopes,
+		})
+	}
+	return result, nil
+}
+
+// executeCreateExternalAccessIntegration handles CREATE EXTERNAL ACCESS
+// INTEGRATION by registering the integration with the executor. The emulator
+// does not restrict network access, so its network rules are only recorded,
+// but the secrets it allows must exist.
+func (e *Executor) executeCreateExternalAccessIntegration(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	properties, err := createProperties(stmt)
+	if err != nil {
+		return nil, err
+	}
+	created := &integration{
+		Name:     integrationName(stmt.Name),
+		Type:     "EXTERNAL_ACCESS",
+		Category: integrationSecurity,
+		Enabled:  strings.EqualFold(properties["ENABLED"], "TRUE"),
+	}
+	if stmt.Comment != nil {
+		created.Comment = *stmt.Comment
+	}
+	if value, ok := properties["ALLOWED_NETWORK_RULES"]; ok {
+		rules, ok := propertyList(value)
+		if !ok {
+			return nil, fmt.Errorf("ALLOWED_NETWORK_RULES of integration %s must be a list of network rules", stmt.Name)
+		}
+		created.AllowedNetworkRules = rules
+	}
+	switch value := properties["ALLOWED_AUTHENTICATION_SECRETS"]; {
+	case strings.EqualFold(value, "ALL"):
+		created.AllSecrets = true
+	case value == "", strings.EqualFold(value, "NONE"):
+	default:
+		secrets, ok := propertyList(value)
+		if !ok {
+			return nil, fmt.Errorf("ALLOWED_AUTHENTICATION_SECRETS of integration %s must be a list of secrets, ALL, or NONE", stmt.Name)
+		}
+		for _, name := range secrets {
+			qualified := qualifiedName(ctx, name)
+			if _, ok := e.lookupSecret(qualified); !ok {
+				return nil, fmt.Errorf("secret %s does not exist", qualified)
+			}
+			created.AllowedSecrets = append(created.AllowedSecrets, qualified)
+		}
+	}
+	return e.registerIntegration(stmt, created)
+}
+
+// isNetworkRuleStatement reports whether sql alters or drops a network rule.
+// The emulator does not restrict network access, so network rules are accepted
+// and ignored.
+func isNetworkRuleStatement(sql string) bool {
+	keywords := statementKeywords(sql)
+	return keywordsHavePrefix(keywords, "ALTER", "NETWORK", "RULE") ||
+		keywordsHavePrefix(keywords, "DROP", "NETWORK", "RULE")
+}
+
+// parseProcedureSecrets parses the SECRETS = ('alias' = secret, ...) and
+// EXTERNAL_ACCESS_INTEGRATIONS = (integration, ...) clauses of a procedure's
+// header.
+func parseProcedureSecrets(header string) (secrets map[string]string, integrations []string, err error) {
+	if value, ok := objectProperty(header, "EXTERNAL_ACCESS_INTEGRATIONS"); ok {
+		names, ok := propertyList(value)
+		if !ok {
+			return nil, nil, fmt.Errorf("EXTERNAL_ACCESS_INTEGRATIONS must be a list of integrations")
+		}
+		for _, name := range names {
+			integrations = append(integrations, integrationName(name))
+		}
+	}
+	value, ok := objectProperty(header, "SECRETS")
+	if !ok {
+		return nil, integrations, nil
+	}
+	entries, ok := propertyList(value)
+	if !ok {
+		return nil, nil, fmt.Errorf("SECRETS must be a list of 'alias' = secret entries")
+	}
+	secrets = map[string]string{}
+	for _, entry := range entries {
+		alias, name, found := strings.Cut(entry, "=")
+		unquoted, end, isLiteral := commentValueAt(strings.TrimSpace(alias), 0)
+		if !found || !isLiteral || end != len(strings.TrimSpace(alias)) || strings.TrimSpace(name) == "" {
+			return nil, nil, fmt.Errorf("invalid SECRETS entry %q: expected 'alias' = secret", entry)
+		}
+		secrets[unquoted] = strings.TrimSpace(name)
+	}
+	return secrets, integrations, nil
+}
+
+// resolveProcedureSecrets qualifies the secrets of a procedure's SECRETS clause
+// and checks, as Snowflake does, that each one exists and is allowed by one of
+// the procedure's external access integrations.
+func (e *Executor) resolveProcedureSecrets(ctx context.Context, proc *procedure) error {
+	var allowed []integration
+	for _, name := range proc.ExternalAccessIntegrations {
+		found, ok := e.lookupIntegration(name)
+		if !ok || found.Type != "EXTERNAL_ACCESS" {
+			return fmt.Errorf("external access integration %s does not exist", name)
+		}
+		allowed = append(allowed, found)
+	}
+
+	for alias, name := range proc.Secrets {
+		qualified := qualifiedName(ctx, name)
+		if _, ok := e.lookupSecret(qualified); !ok {
+			return fmt.Errorf("secret %s does not exist", qualified)
+		}
+		permitted := false
+		for _, found := range allowed {
+			permitted = permitted || found.AllSecrets
+			for _, secretName := range found.AllowedSecrets {
+				permitted = permitted || secretName == qualified
+			}
+		}
+		if !permitted {
+			return fmt.Errorf("secret %s is not allowed by the external access integrations of procedure %s", qualified, proc.Name)
+		}
+		proc.Secrets[alias] = qualified
+	}
+	return nil
+}
+
+// bindSecrets replaces SYSTEM$GET_SECRET('alias' [, 'field']) calls with the
+// value of a secret of the running procedure, given by its alias in the
+// procedure's SECRETS clause. Without a field, the value is the secret string,
+// password, or OAuth token of the secret. Procedure bodies are left alone, so
+// that they are bound when the procedure runs.
+func (e *Executor) bindSecrets(ctx context.Context, sql string) (string, error) {
+	if indexFold(sql, getSecretFunction) < 0 {
+		return sql, nil
+	}
+
+	var b strings.Builder
+	copied := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '$' && strings.HasPrefix(sql[i:], "$$"):
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				i = len(sql)
+				continue
+			}
+			i += 2 + end + 1
+		case keywordAt(sql, i, getSecretFunction):
+			open := i + len(getSecretFunction)
+			for open < len(sql) && isSpace(sql[open]) {
+				open++
+			}
+			if open >= len(sql) || sql[open] != '(' {
+				continue
+			}
+			closing := matchingParen(sql[open:])
+			if closing < 0 {
+				return "", fmt.Errorf("unterminated %s call", getSecretFunction)
+			}
+			closing += open
+			value, err := e.secretValue(ctx, sql[open+1:closing])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(sql[copied:i])
+			if value == nil {
+				b.WriteString("NULL")
+			} else {
+				b.WriteString(quoteLiteral(*value))
+			}
+			copied = closing + 1
+			i = closing
+		}
+	}
+	b.WriteString(sql[copied:])
+	return b.String(), nil
+}
+
+// secretValue returns the value that SYSTEM$GET_SECRET(args) reads, or nil if
+// the secret has no value for the field.
+func (e *Executor) secretValue(ctx context.Context, args string) (*string, error) {
+	var values []string
+	for _, arg := range splitFunctionArgs(args, 2) {
+		arg = strings.TrimSpace(arg)
+		value, end, ok := commentValueAt(arg, 0)
+		if !ok || end != len(arg) {
+			return nil, fmt.Errorf("%s takes string literals, not %s", getSecretFunction, arg)
+		}
+		values = append(values, value)
+	}
+	if len(values) < 1 || len(values) > 2 {
+		return nil, fmt.Errorf("%s takes a secret alias and an optional field", getSecretFunction)
+	}
+
+	secrets, _ := ctx.Value(procedureSecretsKey{}).(map[string]string)
+	name, ok := secrets[values[0]]
+	if !ok {
+		return nil, fmt.Errorf("%s: %q is not in the SECRETS of the running procedure", getSecretFunction, values[0])
+	}
+	found, ok := e.lookupSecret(name)
+	if !ok {
+		return nil, fmt.Errorf("secret %s does not exist", name)
+	}
+
+	field := secretValueFields[found.Type]
+	if len(values) == 2 {
+		field = strings.ToUpper(values[1])
+	}
+	if field == "" {
+		return nil, fmt.Errorf("%s needs a field for secret %s of type %s", getSecretFunction, name, found.Type)
+	}
+	value, ok := found.Properties[field]
+	if !ok {
+		return nil, nil
+	}
+	return &value, nil
+}
@@ -0,0 +1,155 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestObjectProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "Password",
+			input: " TYPE = PASSWORD USERNAME = 'svc' PASSWORD = 'it''s secret'",
+			want:  map[string]string{"TYPE": "PASSWORD", "USERNAME": "svc", "PASSWORD": "it's secret"},
+		},
+		{
+			name:  "List",
+			input: "ALLOWED_NETWORK_RULES = (api_rule, other_rule) ENABLED=TRUE;",
+			want:  map[string]string{"ALLOWED_NETWORK_RULES": "(api_rule, other_rule)", "ENABLED": "TRUE"},
+		},
+		{name: "MissingEquals", input: "TYPE PASSWORD", wantErr: true},
+		{name: "MissingValue", input: "TYPE =", wantErr: true},
+		{name: "UnterminatedString", input: "SECRET_STRING = 'abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := objectProperties(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("objectProperties() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); !tt.wantErr && diff != "" {
+				t.Errorf("objectProperties() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseProcedureSecrets(t *testing.T) {
+	header := `RETURNS VARCHAR LANGUAGE PYTHON RUNTIME_VERSION = '3.11' HANDLER = 'run'
+		EXTERNAL_ACCESS_INTEGRATIONS = (api_access, "Other")
+		SECRETS = ('cred' = api_key, 'login' = db.ops.login)`
+	secrets, integrations, err := parseProcedureSecrets(header)
+	if err != nil {
+		t.Fatalf("parseProcedureSecrets() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"cred": "api_key", "login": "db.ops.login"}, secrets); diff != "" {
+		t.Errorf("secrets mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"API_ACCESS", "Other"}, integrations); diff != "" {
+		t.Errorf("integrations mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, _, err := parseProcedureSecrets("RETURNS VARCHAR SECRETS = (cred = api_key)"); err == nil {
+		t.Error("parseProcedureSecrets() with an unquoted alias succeeded")
+	}
+}
+
+// TestExecutor_Secrets tests reading secrets from a procedure allowed to use
+// them by an external access integration.
+func TestExecutor_Secrets(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	for _, sql := range []string{
+		"CREATE SECRET api_key TYPE = GENERIC_STRING SECRET_STRING = 'fake-api-key' COMMENT = 'Weather API key'",
+		"CREATE SECRET login TYPE = PASSWORD USERNAME = 'svc' PASSWORD = 'fake-password'",
+		"CREATE SECRET unused TYPE = GENERIC_STRING SECRET_STRING = 'x'",
+		"CREATE NETWORK RULE api_rule MODE = EGRESS TYPE = HOST_PORT VALUE_LIST = ('api.example.com')",
+		`CREATE EXTERNAL ACCESS INTEGRATION api_access
+		ALLOWED_NETWORK_RULES = (api_rule)
+		ALLOWED_AUTHENTICATION_SECRETS = (api_key, login)
+		ENABLED = TRUE`,
+		`CREATE PROCEDURE api_token() RETURNS VARCHAR LANGUAGE SQL
+		EXTERNAL_ACCESS_INTEGRATIONS = (api_access)
+		SECRETS = ('cred' = api_key, 'login' = login)
+		AS $$
+		BEGIN
+			LET user VARCHAR := SYSTEM$GET_SECRET('login', 'username');
+			RETURN CONCAT(user, ':', SYSTEM$GET_SECRET('login'), ':', SYSTEM$GET_SECRET('cred'));
+		END;
+		$$`,
+		`CREATE PROCEDURE fetch_weather() RETURNS VARCHAR LANGUAGE PYTHON RUNTIME_VERSION = '3.11'
+		PACKAGES = ('requests') HANDLER = 'run'
+		EXTERNAL_ACCESS_INTEGRATIONS = (api_access) SECRETS = ('cred' = api_key)
+		AS $$
+def run(session):
+    return _snowflake.get_generic_secret_string('cred')
+$$`,
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "CALL api_token()")
+	if err != nil {
+		t.Fatalf("CALL api_token() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"svc:fake-password:fake-api-key"}}, result.Rows); diff != "" {
+		t.Errorf("CALL api_token() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := executor.Query(ctx, "SELECT SYSTEM$GET_SECRET('cred')"); err == nil || !strings.Contains(err.Error(), "SECRETS of the running procedure") {
+		t.Errorf("SYSTEM$GET_SECRET outside a procedure error = %v", err)
+	}
+
+	for sql, wantErr := range map[string]string{
+		"CREATE PROCEDURE p() RETURNS VARCHAR EXTERNAL_ACCESS_INTEGRATIONS = (api_access) SECRETS = ('c' = unused) AS 'BEGIN RETURN 1; END'":  "not allowed",
+		"CREATE PROCEDURE p() RETURNS VARCHAR EXTERNAL_ACCESS_INTEGRATIONS = (api_access) SECRETS = ('c' = missing) AS 'BEGIN RETURN 1; END'": "does not exist",
+		"CREATE PROCEDURE p() RETURNS VARCHAR EXTERNAL_ACCESS_INTEGRATIONS = (missing) AS 'BEGIN RETURN 1; END'":                              "does not exist",
+		"CREATE EXTERNAL ACCESS INTEGRATION other ALLOWED_AUTHENTICATION_SECRETS = (missing) ENABLED = TRUE":                                  "does not exist",
+		"CREATE SECRET bad TYPE = PLAINTEXT": "invalid TYPE",
+	} {
+		if _, err := executor.Execute(ctx, sql); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("Execute(%q) error = %v, want one containing %q", sql, err, wantErr)
+		}
+	}
+
+	show, err := executor.Query(ctx, "SHOW SECRETS LIKE 'API%'")
+	if err != nil {
+		t.Fatalf("SHOW SECRETS error = %v", err)
+	}
+	if len(show.Rows) != 1 || show.Rows[0][1] != "API_KEY" || show.Rows[0][5] != "Weather API key" || show.Rows[0][6] != "GENERIC_STRING" {
+		t.Errorf("SHOW SECRETS rows = %v, want API_KEY", show.Rows)
+	}
+	show, err = executor.Query(ctx, "SHOW EXTERNAL ACCESS INTEGRATIONS")
+	if err != nil {
+		t.Fatalf("SHOW EXTERNAL ACCESS INTEGRATIONS error = %v", err)
+	}
+	if len(show.Rows) != 1 || show.Rows[0][0] != "API_ACCESS" || show.Rows[0][1] != "EXTERNAL_ACCESS" {
+		t.Errorf("SHOW EXTERNAL ACCESS INTEGRATIONS rows = %v, want API_ACCESS", show.Rows)
+	}
+
+	for _, sql := range []string{
+		"DROP SECRET api_key",
+		"DROP SECRET IF EXISTS api_key",
+		"DROP EXTERNAL ACCESS INTEGRATION api_access",
+		"DROP NETWORK RULE api_rule",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Query(ctx, "CALL api_token()"); err == nil {
+		t.Error("CALL api_token() with a dropped secret succeeded")
+	}
+}
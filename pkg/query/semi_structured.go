@@ -0,0 +1,381 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semiStructuredMarkers maps the Snowflake array and object functions to the
+// markers they are translated through.
+var semiStructuredMarkers = map[string]string{
+	"ARRAY_AGG":               "__ARRAY_AGG__",
+	"ARRAYAGG":                "__ARRAY_AGG__",
+	"ARRAY_APPEND":            "__ARRAY_APPEND__",
+	"ARRAY_CAT":               "__ARRAY_CAT__",
+	"ARRAY_COMPACT":           "__ARRAY_COMPACT__",
+	"ARRAY_CONSTRUCT_COMPACT": "__ARRAY_CONSTRUCT_COMPACT__",
+	"ARRAY_CONTAINS":          "__ARRAY_CONTAINS__",
+	"ARRAY_DISTINCT":          "__ARRAY_DISTINCT__",
+	"ARRAY_SIZE":              "__ARRAY_SIZE__",
+	"ARRAY_SLICE":             "__ARRAY_SLICE__",
+	"ARRAY_TO_STRING":         "__ARRAY_TO_STRING__",
+	"ARRAYS_OVERLAP":          "__ARRAYS_OVERLAP__",
+	"OBJECT_INSERT":           "__OBJECT_INSERT__",
+	"OBJECT_DELETE":           "__OBJECT_DELETE__",
+	"OBJECT_KEYS":             "__OBJECT_KEYS__",
+	"OBJECT_PICK":             "__OBJECT_PICK__",
+	"GET":                     "__GET__",
+	"GET_PATH":                "__GET_PATH__",
+	"FILTER":                  "__FILTER__",
+	"TRANSFORM":               "__TRANSFORM__",
+	"REDUCE":                  "__REDUCE__",
+}
+
+// registerSemiStructuredFunctions registers translations for the functions
+// building arrays and objects from others. They are resolved in
+// transformSemiStructured.
+func (t *Translator) registerSemiStructuredFunctions() {
+	for name, marker := range semiStructuredMarkers {
+		t.functionMap[name] = markFunction(marker)
+	}
+	// NULL arguments are JSON nulls of the array
+	t.functionMap["ARRAY_CONSTRUCT"] = FunctionTranslator{Name: "json_array"}
+}
+
+// transformSemiStructured resolves the array and object function markers.
+// ARRAY and OBJECT values are JSON, so arrays are cast to lists of JSON values
+// for DuckDB's list functions and the results back to JSON. Elements appended
+// to arrays are converted with to_json(), so strings stay strings.
+func (t *Translator) transformSemiStructured(sql string) string {
+	// NULLs are left out, and no values give an empty array rather than NULL
+	sql = t.transformMarkedFunction(sql, "__ARRAY_AGG__", func(args string) string {
+		distinct, value, order := splitAggregateArgs(args)
+		return fmt.Sprintf("COALESCE(to_json(list(%s%s%s) FILTER (WHERE %s IS NOT NULL)), CAST('[]' AS JSON))", distinct, value, order, value)
+	})
+
+	// A NULL array gives NULL, where DuckDB's list functions would take it for
+	// an empty list
+	sql = t.transformMarkedFunction(sql, "__ARRAY_APPEND__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "array_append(" + args + ")"
+		}
+		array := strings.TrimSpace(parts[0])
+		return fmt.Sprintf("CASE WHEN (%s) IS NOT NULL THEN to_json(list_append(%s, to_json(%s))) END", array, jsonList(array), strings.TrimSpace(parts[1]))
+	})
+
+	sql = t.transformMarkedFunction(sql, "__ARRAY_CAT__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "array_cat(" + args + ")"
+		}
+		first, second := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		return fmt.Sprintf("CASE WHEN (%s) IS NOT NULL AND (%s) IS NOT NULL THEN to_json(list_concat(%s, %s)) END", first, second, jsonList(first), jsonList(second))
+	})
+
+	// JSON nulls are NULL in a list of JSON values, so both are removed
+	sql = t.transformMarkedFunction(sql, "__ARRAY_COMPACT__", func(args string) string {
+		return fmt.Sprintf("to_json(list_filter(%s, __e -> __e IS NOT NULL))", jsonList(args))
+	})
+
+	sql = t.transformMarkedFunction(sql, "__ARRAY_CONSTRUCT_COMPACT__", func(args string) string {
+		if strings.TrimSpace(args) == "" {
+			return "json_array()"
+		}
+		return fmt.Sprintf("to_json(list_filter(%s, lambda __e: __e IS NOT NULL))", jsonList("json_array("+args+")"))
+	})
+
+	// The value is compared with the elements as JSON, so 1 matches 1 but not '1'
+	sql = t.transformMarkedFunction(sql, "__ARRAY_CONTAINS__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "array_contains(" + args + ")"
+		}
+		return fmt.Sprintf("list_contains(%s, to_json(%s))", jsonList(parts[1]), strings.TrimSpace(parts[0]))
+	})
+
+	// Each element is kept at its first position, which keeps their order and one NULL
+	sql = t.transformMarkedFunction(sql, "__ARRAY_DISTINCT__", func(args string) string {
+		list := jsonList(args)
+		return fmt.Sprintf("to_json(list_filter(%s, (__e, __i) -> list_position(%s, __e) = __i))", list, list)
+	})
+
+	sql = t.transformMarkedFunction(sql, "__ARRAY_SLICE__", func(args string) string {
+		parts := splitFunctionArgs(args, 3)
+		if len(parts) != 3 {
+			return "array_slice(" + args + ")"
+		}
+		return fmt.Sprintf("to_json(list_slice(%s, %s, %s))", jsonList(parts[0]), sliceBound(parts[1], false), sliceBound(parts[2], true))
+	})
+
+	// Values that are not arrays have no size
+	sql = t.transformMarkedFunction(sql, "__ARRAY_SIZE__", func(args string) string {
+		array := jsonObject(args)
+		return fmt.Sprintf("CASE WHEN json_type(%s) = 'ARRAY' THEN CAST(json_array_length(%s) AS BIGINT) END", array, array)
+	})
+
+	// Strings are joined without their quotes, and NULL elements as empty strings
+	sql = t.transformMarkedFunction(sql, "__ARRAY_TO_STRING__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "array_to_string(" + args + ")"
+		}
+		return fmt.Sprintf("array_to_string(list_transform(%s, lambda __e: COALESCE(json_extract_string(__e, '$'), '')), %s)",
+			jsonList(parts[0]), strings.TrimSpace(parts[1]))
+	})
+
+	sql = t.transformMarkedFunction(sql, "__ARRAYS_OVERLAP__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "arrays_overlap(" + args + ")"
+		}
+		return fmt.Sprintf("list_has_any(%s, %s)", jsonList(parts[0]), jsonList(parts[1]))
+	})
+
+	sql = t.transformMarkedFunction(sql, "__OBJECT_INSERT__", func(args string) string {
+		parts := splitFunctionArgs(args, 4)
+		if len(parts) < 3 || len(parts) > 4 {
+			return "object_insert(" + args + ")"
+		}
+		object, key, value := jsonObject(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+		insert := fmt.Sprintf("CASE WHEN list_contains(json_keys(%s), %s) THEN error('Duplicate field key ' || %s) ELSE json_merge_patch(%s, json_object(%s, %s)) END",
+			object, key, key, object, key, value)
+		// Updated values replace the old ones rather than being merged into them
+		update := fmt.Sprintf("json_merge_patch(json_merge_patch(%s, json_object(%s, NULL)), json_object(%s, %s))", object, key, key, value)
+		if len(parts) == 3 {
+			return insert
+		}
+		switch flag := strings.ToUpper(strings.TrimSpace(parts[3])); flag {
+		case "TRUE":
+			return update
+		case "FALSE":
+			return insert
+		default:
+			return fmt.Sprintf("CASE WHEN %s THEN %s ELSE %s END", flag, update, insert)
+		}
+	})
+
+	sql = t.transformMarkedFunction(sql, "__OBJECT_DELETE__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) < 2 {
+			return "object_delete(" + args + ")"
+		}
+		deleted := make([]string, 0, 2*(len(parts)-1))
+		for _, key := range parts[1:] {
+			deleted = append(deleted, strings.TrimSpace(key), "NULL")
+		}
+		return fmt.Sprintf("json_merge_patch(%s, json_object(%s))", jsonObject(parts[0]), strings.Join(deleted, ", "))
+	})
+
+	// The keys the object has are picked, in the order they are given
+	sql = t.transformMarkedFunction(sql, "__OBJECT_PICK__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) < 2 {
+			return "object_pick(" + args + ")"
+		}
+		object := jsonObject(parts[0])
+		keys := make([]string, len(parts)-1)
+		for i, key := range parts[1:] {
+			keys[i] = strings.TrimSpace(key)
+		}
+		return fmt.Sprintf("to_json(map_from_entries(list_transform(list_filter([%s], __k -> list_contains(json_keys(%s), __k)), __k -> {'key': __k, 'value': %s->__k})))",
+			strings.Join(keys, ", "), object, object)
+	})
+
+	// Keys are listed in the order the object holds them; values that are not
+	// objects give NULL
+	sql = t.transformMarkedFunction(sql, "__OBJECT_KEYS__", func(args string) string {
+		object := jsonObject(args)
+		return fmt.Sprintf("CASE WHEN json_type(%s) = 'OBJECT' THEN to_json(json_keys(%s)) END", object, object)
+	})
+
+	// A literal key or index reads a path; others are resolved by the type of
+	// the value, as keys of objects and indexes of arrays
+	sql = t.transformMarkedFunction(sql, "__GET__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "get(" + args + ")"
+		}
+		value, field := jsonObject(parts[0]), strings.TrimSpace(parts[1])
+		if key, ok := stringLiteralValue(field); ok {
+			return fmt.Sprintf("json_extract(%s, '%s')", value, jsonPathLiteral([]variantPathElement{{key: key}}))
+		}
+		if n, err := strconv.Atoi(field); err == nil && n >= 0 {
+			return fmt.Sprintf("json_extract(%s, '%s')", value, jsonPathLiteral([]variantPathElement{{index: n, isIndex: true}}))
+		}
+		return fmt.Sprintf(`CASE json_type(%s) WHEN 'ARRAY' THEN json_extract(%s, '$[' || TRY_CAST(%s AS BIGINT) || ']') `+
+			`WHEN 'OBJECT' THEN json_extract(%s, '$."' || replace(CAST(%s AS VARCHAR), '"', '\"') || '"') END`,
+			value, value, field, value, field)
+	})
+
+	// Literal paths use the syntax of v:a.b[0]; others are read as JSON paths
+	// after a $.
+	sql = t.transformMarkedFunction(sql, "__GET_PATH__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "get_path(" + args + ")"
+		}
+		value, path := jsonObject(parts[0]), strings.TrimSpace(parts[1])
+		if text, ok := stringLiteralValue(path); ok {
+			if elements, ok := parsePathLiteral(text); ok {
+				return fmt.Sprintf("json_extract(%s, '%s')", value, jsonPathLiteral(elements))
+			}
+		}
+		return fmt.Sprintf("json_extract(%s, '$.' || %s)", value, path)
+	})
+
+	return t.transformLambdaFunctions(sql)
+}
+
+// transformLambdaFunctions resolves the markers of FILTER, TRANSFORM, and
+// REDUCE, and the lambdas left in sql.
+func (t *Translator) transformLambdaFunctions(sql string) string {
+	// The lambda parameter of FILTER and TRANSFORM takes the elements, cast to
+	// its type if it has one
+	sql = t.transformMarkedFunction(sql, "__FILTER__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		l, ok := lambda{}, len(parts) == 2
+		if ok {
+			l, ok = parseLambda(parts[1])
+		}
+		if !ok || len(l.names) != 1 {
+			return "filter(" + args + ")"
+		}
+		l.inferTypes()
+		return fmt.Sprintf("to_json(list_filter(%s, %s))", lambdaElements(jsonList(parts[0]), l.types[0]), l)
+	})
+
+	sql = t.transformMarkedFunction(sql, "__TRANSFORM__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		l, ok := lambda{}, len(parts) == 2
+		if ok {
+			l, ok = parseLambda(parts[1])
+		}
+		if !ok || len(l.names) != 1 {
+			return "transform(" + args + ")"
+		}
+		l.inferTypes()
+		return fmt.Sprintf("to_json(list_transform(%s, %s))", lambdaElements(jsonList(parts[0]), l.types[0]), l)
+	})
+
+	// The accumulator starts as the initial value, cast to the type of the
+	// accumulator parameter if it has one, and an empty array gives it back.
+	// JSON values cannot be added, so untyped parameters folding a number, like
+	// those the lambda computes with, are DOUBLE
+	sql = t.transformMarkedFunction(sql, "__REDUCE__", func(args string) string {
+		parts := splitFunctionArgs(args, 3)
+		l, ok := lambda{}, len(parts) == 3
+		if ok {
+			l, ok = parseLambda(parts[2])
+		}
+		if !ok || len(l.names) != 2 {
+			return "reduce(" + args + ")"
+		}
+		initial := strings.TrimSpace(parts[1])
+		if _, err := strconv.ParseFloat(initial, 64); err == nil && l.types[0] == "" && l.types[1] == "" {
+			l.types = []string{"DOUBLE", "DOUBLE"}
+		}
+		l.inferTypes()
+		if l.types[0] != "" {
+			initial = fmt.Sprintf("CAST(%s AS %s)", initial, l.types[0])
+		}
+		return fmt.Sprintf("list_reduce(%s, %s, %s)", lambdaElements(jsonList(parts[0]), l.types[1]), l, initial)
+	})
+
+	return t.transformLambdas(sql)
+}
+
+// jsonList returns the expression converting an ARRAY value, a JSON array or
+// a DuckDB list, to a list of JSON values.
+func jsonList(arg string) string {
+	return fmt.Sprintf("CAST(CAST(%s AS JSON) AS JSON[])", strings.TrimSpace(arg))
+}
+
+// splitAggregateArgs splits the argument of an aggregate into its DISTINCT
+// keyword, with the space after it, its value, and its ORDER BY clause, with
+// the space before it.
+func splitAggregateArgs(args string) (distinct, value, order string) {
+	value = strings.TrimSpace(args)
+	if keywordAt(value, 0, "DISTINCT") {
+		distinct = value[:len("DISTINCT")] + " "
+		value = strings.TrimSpace(value[len("DISTINCT"):])
+	}
+	depth := 0
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(value, i, c)
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && keywordAt(value, i, "ORDER"):
+			return distinct, strings.TrimSpace(value[:i]), " " + value[i:]
+		}
+	}
+	return distinct, value, ""
+}
+
+// jsonObject returns the expression converting an OBJECT value to JSON.
+func jsonObject(arg string) string {
+	return fmt.Sprintf("CAST(%s AS JSON)", strings.TrimSpace(arg))
+}
+
+// sliceBound converts a bound of ARRAY_SLICE, a 0-based index that counts
+// from the end when negative and that excludes its element when it ends the
+// slice, into a bound of DuckDB's list_slice(), which is 1-based, counts from
+// the end with -1 for the last element, and includes both ends.
+func sliceBound(arg string, end bool) string {
+	arg = strings.TrimSpace(arg)
+	if n, err := strconv.Atoi(arg); err == nil {
+		switch {
+		case n < 0 && end:
+			return strconv.Itoa(n - 1)
+		case n >= 0 && !end:
+			return strconv.Itoa(n + 1)
+		}
+		return strconv.Itoa(n)
+	}
+	if end {
+		return fmt.Sprintf("CASE WHEN %s < 0 THEN %s - 1 ELSE %s END", arg, arg, arg)
+	}
+	return fmt.Sprintf("CASE WHEN %s < 0 THEN %s ELSE %s + 1 END", arg, arg, arg)
+}
+
+// stringLiteralValue returns the value of a single-quoted string literal.
+func stringLiteralValue(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", false
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), true
+}
+
+// parsePathLiteral parses the path of GET_PATH, such as a.b[0] or [0]."Key",
+// which may start with an index.
+func parsePathLiteral(path string) ([]variantPathElement, bool) {
+	var elements []variantPathElement
+	i := 0
+	for i < len(path) && path[i] == '[' {
+		element, end, ok := variantPathBracket(path, i)
+		if !ok {
+			return nil, false
+		}
+		elements = append(elements, element)
+		i = end
+	}
+	if i == len(path) {
+		return elements, len(elements) > 0
+	}
+	if i > 0 {
+		if path[i] != '.' {
+			return nil, false
+		}
+		i++
+	}
+	rest, end := parseVariantPath(path, i)
+	if len(rest) == 0 || end != len(path) {
+		return nil, false
+	}
+	return append(elements, rest...), true
+}
@@ -0,0 +1,152 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_SemiStructured tests the array and object functions against
+// DuckDB.
+func TestExecutor_SemiStructured(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"CREATE TABLE docs (id INTEGER, arr VARIANT, obj VARIANT)",
+		`INSERT INTO docs VALUES (1, PARSE_JSON('[1, 2, null, 2, "a", 1]'), PARSE_JSON('{"a": 1, "b": {"c": 2}}'))`,
+		"CREATE TABLE items (id INTEGER, name VARCHAR)",
+		"INSERT INTO items VALUES (1, 'x'), (2, NULL), (3, 'z'), (4, 'x')",
+		"CREATE TABLE posts (id INTEGER, tags ARRAY, scores VARIANT, author VARCHAR)",
+		"INSERT INTO posts SELECT 1, ARRAY_CONSTRUCT('a', 'a', 'b'), PARSE_JSON('[1, 1, 2]'), 'x'",
+		"INSERT INTO posts SELECT 2, ARRAY_CONSTRUCT('a', 'c'), PARSE_JSON('[1, 2, 3]'), 'y'",
+		"INSERT INTO posts SELECT 3, NULL, NULL, NULL",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		sql     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "ArrayAppend", sql: "SELECT ARRAY_APPEND(arr, 'b') FROM docs", want: []interface{}{float64(1), float64(2), nil, float64(2), "a", float64(1), "b"}},
+		{name: "ArrayCat", sql: "SELECT ARRAY_CAT(PARSE_JSON('[1]'), PARSE_JSON('[\"x\", [2]]')) FROM docs", want: []interface{}{float64(1), "x", []interface{}{float64(2)}}},
+		{name: "ArrayAppendToNull", sql: "SELECT ARRAY_APPEND(NULL, 1) FROM docs", want: nil},
+		{name: "ArrayAppendToNullColumn", sql: "SELECT ARRAY_APPEND(tags, 'x') FROM posts WHERE id = 3", want: nil},
+		{name: "ArrayCatNull", sql: "SELECT ARRAY_CAT(NULL, arr) FROM docs", want: nil},
+		{name: "ArrayCatToNull", sql: "SELECT ARRAY_CAT(arr, NULL) FROM docs", want: nil},
+		{name: "ArrayCatNulls", sql: "SELECT ARRAY_CAT(NULL, NULL) FROM docs", want: nil},
+		{name: "ArrayCompact", sql: "SELECT ARRAY_COMPACT(arr) FROM docs", want: []interface{}{float64(1), float64(2), float64(2), "a", float64(1)}},
+		{name: "ArrayDistinct", sql: "SELECT ARRAY_DISTINCT(arr) FROM docs", want: []interface{}{float64(1), float64(2), nil, "a"}},
+		{name: "ArraySlice", sql: "SELECT ARRAY_SLICE(arr, 1, 3) FROM docs", want: []interface{}{float64(2), nil}},
+		{name: "ArraySliceFromEnd", sql: "SELECT ARRAY_SLICE(arr, -2, -1) FROM docs", want: []interface{}{"a"}},
+		{name: "ArraySliceColumnBounds", sql: "SELECT ARRAY_SLICE(arr, id + 3, id + 5) FROM docs", want: []interface{}{"a", float64(1)}},
+		{name: "ArraysOverlap", sql: "SELECT ARRAYS_OVERLAP(arr, PARSE_JSON('[\"a\", 7]')) FROM docs", want: true},
+		{name: "ArraysDoNotOverlap", sql: "SELECT ARRAYS_OVERLAP(arr, PARSE_JSON('[7]')) FROM docs", want: false},
+		{name: "ObjectInsert", sql: "SELECT OBJECT_INSERT(obj, 'd', 'x') FROM docs", want: map[string]interface{}{"a": float64(1), "b": map[string]interface{}{"c": float64(2)}, "d": "x"}},
+		{name: "ObjectInsertExistingKey", sql: "SELECT OBJECT_INSERT(obj, 'a', 2) FROM docs", wantErr: true},
+		{name: "ObjectInsertUpdate", sql: "SELECT OBJECT_INSERT(obj, 'b', PARSE_JSON('{\"e\": 3}'), TRUE) FROM docs", want: map[string]interface{}{"a": float64(1), "b": map[string]interface{}{"e": float64(3)}}},
+		{name: "ObjectDelete", sql: "SELECT OBJECT_DELETE(obj, 'a', 'missing') FROM docs", want: map[string]interface{}{"b": map[string]interface{}{"c": float64(2)}}},
+		{name: "Filter", sql: "SELECT FILTER(PARSE_JSON('[1, 3, null, 2]'), x -> x > 1) FROM docs", want: []interface{}{float64(3), float64(2)}},
+		{name: "FilterTypedParameter", sql: "SELECT FILTER(PARSE_JSON('[\"a\", \"b\"]'), s VARCHAR -> s = 'b') FROM docs", want: []interface{}{"b"}},
+		{name: "Transform", sql: "SELECT TRANSFORM(PARSE_JSON('[1, 2]'), x INT -> IFF(x > id, x * 10, x)) FROM docs", want: []interface{}{float64(1), float64(20)}},
+		{name: "TransformUntypedArithmetic", sql: "SELECT TRANSFORM(PARSE_JSON('[1, 2.5, null]'), x -> x * 2) FROM docs", want: []interface{}{float64(2), float64(5), nil}},
+		{name: "TransformUntypedArithmeticOnColumn", sql: "SELECT TRANSFORM(scores, s -> s + id) FROM posts WHERE id = 2", want: []interface{}{float64(3), float64(4), float64(5)}},
+		{name: "FilterUntypedArithmetic", sql: "SELECT FILTER(PARSE_JSON('[1, 2, 3, 4]'), x -> x % 2 = 0) FROM docs", want: []interface{}{float64(2), float64(4)}},
+		{name: "ReduceUntypedArithmetic", sql: "SELECT REDUCE(scores, id, (acc, val) -> acc * 10 + val) FROM posts WHERE id = 2", want: float64(2123)},
+		{name: "TransformNested", sql: "SELECT TRANSFORM(PARSE_JSON('[[1, 2], [3]]'), a -> FILTER(a, x -> x > 1)) FROM docs", want: []interface{}{[]interface{}{float64(2)}, []interface{}{float64(3)}}},
+		{name: "Reduce", sql: "SELECT REDUCE(PARSE_JSON('[1, 2, 3]'), 10, (acc, val) -> acc + val) FROM docs", want: float64(16)},
+		{name: "ReduceTyped", sql: "SELECT REDUCE(PARSE_JSON('[\"a\", \"b\"]'), 'x', (acc VARCHAR, val VARCHAR) -> CONCAT(acc, val)) FROM docs", want: "xab"},
+		{name: "ReduceEmpty", sql: "SELECT REDUCE(PARSE_JSON('[]'), 0, (acc, val) -> acc + val) FROM docs", want: float64(0)},
+		{name: "TransformArrayLiteral", sql: "SELECT TRANSFORM([1, 2, 3], x -> x * 2)", want: []interface{}{float64(2), float64(4), float64(6)}},
+		{name: "TransformArrayLiteralTyped", sql: "SELECT TRANSFORM([1, 2, 3], x INT -> x * 2)", want: []interface{}{float64(2), float64(4), float64(6)}},
+		{name: "FilterArrayLiteral", sql: "SELECT FILTER([1, 3, 2], x -> x > 1)", want: []interface{}{float64(3), float64(2)}},
+		{name: "ReduceArrayLiteral", sql: "SELECT REDUCE([1, 2, 3], 0, (acc, x) -> acc + x)", want: float64(6)},
+		{name: "ReduceArrayLiteralWithAggregateFilter", sql: "SELECT REDUCE([1, 2], COUNT(*) FILTER (WHERE id > 0), (acc, x) -> acc + x) FROM docs", want: float64(4)},
+		{name: "ArrayAgg", sql: "SELECT ARRAY_AGG(name) WITHIN GROUP (ORDER BY id DESC) FROM items", want: []interface{}{"x", "z", "x"}},
+		{name: "ArrayAggDistinct", sql: "SELECT ARRAY_SIZE(ARRAY_AGG(DISTINCT name)) FROM items", want: int64(2)},
+		{name: "ArrayAggEmpty", sql: "SELECT ARRAY_AGG(name) FROM items WHERE id > 10", want: []interface{}{}},
+		{name: "ArrayUnionAgg", sql: "SELECT ARRAY_UNION_AGG(tags) FROM posts", want: []interface{}{"a", "a", "b", "c"}},
+		{name: "ArrayUnionAggVariant", sql: "SELECT ARRAY_UNION_AGG(scores) FROM posts", want: []interface{}{float64(1), float64(1), float64(2), float64(3)}},
+		{name: "ArrayUnionAggConstruct", sql: "SELECT ARRAY_UNION_AGG(ARRAY_CONSTRUCT(id, 1)) FROM posts", want: []interface{}{float64(1), float64(1), float64(2), float64(3)}},
+		{name: "ArrayUnionAggEmpty", sql: "SELECT ARRAY_UNION_AGG(tags) FROM posts WHERE id > 10", want: []interface{}{}},
+		{name: "ArrayUniqueAgg", sql: "SELECT ARRAY_SIZE(ARRAY_UNIQUE_AGG(name)) FROM items", want: int64(2)},
+		{name: "ArrayUniqueAggVariant", sql: "SELECT ARRAY_UNIQUE_AGG(scores) FROM posts WHERE id = 1", want: []interface{}{[]interface{}{float64(1), float64(1), float64(2)}}},
+		{name: "ObjectAgg", sql: "SELECT OBJECT_AGG(author, scores) FROM posts", want: map[string]interface{}{"x": []interface{}{float64(1), float64(1), float64(2)}, "y": []interface{}{float64(1), float64(2), float64(3)}}},
+		{name: "ObjectAggSkipsNullValues", sql: "SELECT OBJECT_AGG(author, NULLIF(id, 2)) FROM posts", want: map[string]interface{}{"x": float64(1)}},
+		{name: "ObjectAggDuplicateKey", sql: "SELECT OBJECT_AGG(name, id) FROM items", wantErr: true},
+		{name: "ListAggWithinGroup", sql: "SELECT LISTAGG(name, ',') WITHIN GROUP (ORDER BY name DESC, id) FROM items", want: "z,x,x"},
+		{name: "ArrayConstruct", sql: "SELECT ARRAY_CONSTRUCT(1, 'a', NULL, ARRAY_CONSTRUCT(), obj) FROM docs", want: []interface{}{float64(1), "a", nil, []interface{}{}, map[string]interface{}{"a": float64(1), "b": map[string]interface{}{"c": float64(2)}}}},
+		{name: "ArrayConstructCompact", sql: "SELECT ARRAY_CONSTRUCT_COMPACT(NULL, 'a', id) FROM docs", want: []interface{}{"a", float64(1)}},
+		{name: "ArraySize", sql: "SELECT ARRAY_SIZE(arr) FROM docs", want: int64(6)},
+		{name: "ArraySizeOfObject", sql: "SELECT ARRAY_SIZE(obj) FROM docs", want: nil},
+		{name: "ArrayContains", sql: "SELECT ARRAY_CONTAINS('a', arr) AND NOT ARRAY_CONTAINS(7, arr) FROM docs", want: true},
+		{name: "ArrayContainsVariantCast", sql: "SELECT ARRAY_CONTAINS('a'::VARIANT, arr) AND NOT ARRAY_CONTAINS('2'::VARIANT, arr) FROM docs", want: true},
+		{name: "ArrayContainsObject", sql: "SELECT ARRAY_CONTAINS(obj, ARRAY_CONSTRUCT(1, obj)) FROM docs", want: true},
+		{name: "ArrayToString", sql: "SELECT ARRAY_TO_STRING(arr, '-') FROM docs", want: "1-2--2-a-1"},
+		{name: "NestedInOtherFunctions", sql: "SELECT IFF(ARRAY_SIZE(ARRAY_CAT(ARRAY_AGG(name), ARRAY_CONSTRUCT('q'))) > 3, UPPER(ARRAY_TO_STRING(ARRAY_SLICE(ARRAY_AGG(name) WITHIN GROUP (ORDER BY name), 0, 2), '|')), 'few') FROM items", want: "X|X"},
+		{name: "ObjectKeys", sql: "SELECT OBJECT_KEYS(obj) FROM docs", want: []interface{}{"a", "b"}},
+		{name: "ObjectKeysOfPath", sql: "SELECT OBJECT_KEYS(obj:b) FROM docs", want: []interface{}{"c"}},
+		{name: "ObjectKeysOfArray", sql: "SELECT OBJECT_KEYS(arr) FROM docs", want: nil},
+		{name: "GetKey", sql: "SELECT GET(obj, 'b') FROM docs", want: map[string]interface{}{"c": float64(2)}},
+		{name: "GetIndex", sql: "SELECT GET(arr, 4) FROM docs", want: "a"},
+		{name: "GetColumnIndex", sql: "SELECT GET(arr, id + 3) FROM docs", want: "a"},
+		{name: "GetColumnKeyOfPath", sql: "SELECT GET(obj:b, LOWER('C')) FROM docs", want: float64(2)},
+		{name: "GetMissing", sql: "SELECT GET(obj, 'z') IS NULL AND GET(arr, 'a') IS NULL FROM docs", want: true},
+		{name: "GetPath", sql: "SELECT GET_PATH(obj, 'b.c') FROM docs", want: float64(2)},
+		{name: "GetPathIndexFirst", sql: "SELECT GET_PATH(PARSE_JSON('[{\"K y\": [5, 6]}]'), '[0].\"K y\"[1]') FROM docs", want: float64(6)},
+		{name: "GetPathOfGet", sql: "SELECT GET_PATH(GET(obj, 'b'), 'c') FROM docs", want: float64(2)},
+		{name: "GetPathCastToString", sql: "SELECT GET_PATH(PARSE_JSON('{\"a\": {\"b\": \"x\"}}'), 'a.b')::string FROM docs", want: "x"},
+		{name: "GetPathCastToNumber", sql: "SELECT GET_PATH(obj, 'b.c')::NUMBER + 1 = 3 FROM docs", want: true},
+		{name: "GetCastToString", sql: "SELECT CONCAT(GET(arr, 4)::STRING, GET(obj, 'a')::VARCHAR) FROM docs", want: "a1"},
+		{name: "GetCastToInt", sql: "SELECT GET(arr, 1)::INT * 10 FROM docs", want: int64(20)},
+		{name: "TryParseJSONIsObject", sql: "SELECT IS_OBJECT(TRY_PARSE_JSON('{\"a\": [1]}'):a) OR IS_ARRAY(GET(TRY_PARSE_JSON('{\"a\": [1]}'), 'a')) FROM docs", want: true},
+		{name: "TryParseJSONInvalid", sql: "SELECT OBJECT_KEYS(TRY_PARSE_JSON('{')) FROM docs", want: nil},
+		{name: "ObjectPick", sql: "SELECT OBJECT_PICK(obj, 'b', 'missing') FROM docs", want: map[string]interface{}{"b": map[string]interface{}{"c": float64(2)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query(%q) error = %v, wantErr %v", tt.sql, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff([][]interface{}{{tt.want}}, result.Rows); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+func TestMarkWithinGroup(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "ArrayAgg", sql: "SELECT ARRAY_AGG(x) WITHIN GROUP (ORDER BY y DESC) FROM t", want: "SELECT __WITHIN_GROUP__(ARRAY_AGG(x), 'ORDER BY y DESC') FROM t"},
+		{name: "ListAgg", sql: "SELECT listagg(x, ', ') within group (order by y) FROM t", want: "SELECT __WITHIN_GROUP__(listagg(x, ', '), 'order by y') FROM t"},
+		{name: "QuoteInOrder", sql: "SELECT ARRAY_AGG(x) WITHIN GROUP (ORDER BY y = 'a') FROM t", want: "SELECT __WITHIN_GROUP__(ARRAY_AGG(x), 'ORDER BY y = ''a''') FROM t"},
+		{name: "Nested", sql: "SELECT ARRAY_SIZE(ARRAY_AGG(x) WITHIN GROUP (ORDER BY y)), ARRAYAGG(z) WITHIN GROUP (ORDER BY z) FROM t", want: "SELECT ARRAY_SIZE(__WITHIN_GROUP__(ARRAY_AGG(x), 'ORDER BY y')), __WITHIN_GROUP__(ARRAYAGG(z), 'ORDER BY z') FROM t"},
+		{name: "WithoutWithinGroup", sql: "SELECT ARRAY_AGG(x) FROM t", want: "SELECT ARRAY_AGG(x) FROM t"},
+		{name: "OrderedSetAggregate", sql: "SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY x) FROM t", want: "SELECT PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY x) FROM t"},
+		{name: "Literal", sql: "SELECT 'ARRAY_AGG(x) WITHIN GROUP (ORDER BY y)'", want: "SELECT 'ARRAY_AGG(x) WITHIN GROUP (ORDER BY y)'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, markWithinGroup(tt.sql)); diff != "" {
+				t.Errorf("markWithinGroup(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
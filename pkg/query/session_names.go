@@ -0,0 +1,141 @@
+package query
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// tableKeywords are the keywords after which a name is a table or view, as in
+// FROM t, JOIN t, INSERT INTO t, UPDATE t, DROP TABLE IF EXISTS t.
+var tableKeywords = map[string]bool{
+	"FROM": true, "JOIN": true, "INTO": true, "UPDATE": true, "TABLE": true, "VIEW": true,
+	"EXISTS": true, "USING": true, "TRUNCATE": true, "CLONE": true,
+}
+
+// commonTableExpression matches the name of a common table expression, name AS (.
+var commonTableExpression = regexp.MustCompile(`(?i)([A-Za-z_][A-Za-z0-9_$]*)\s+AS\s*\(`)
+
+// sessionNames resolves the unqualified table and view names of a statement
+// against the session's current database and schema. Tables and views created
+// with SQL live in the DuckDB schema of their schema, or in main for PUBLIC,
+// and tables created through the REST API in DATABASE.SCHEMA_TABLE.
+type sessionNames struct {
+	e        *Executor
+	ctx      context.Context
+	database string
+	schema   string
+	// keyword is the statement's first keyword, e.g. SELECT or CREATE.
+	keyword string
+	// temporary is set for statements creating temporary objects, which
+	// cannot be created in a schema.
+	temporary bool
+	ctes      map[string]bool
+	resolved  map[string]string
+}
+
+// newSessionNames returns the resolver of the unqualified names of sql, or nil
+// if the session has no current database.
+func (e *Executor) newSessionNames(ctx context.Context, sql string) *sessionNames {
+	info := SessionInfoFromContext(ctx)
+	if info.Database == "" || info.Schema == "" {
+		return nil
+	}
+	n := &sessionNames{
+		e: e, ctx: ctx, database: info.Database, schema: strings.ToUpper(info.Schema),
+		ctes: map[string]bool{}, resolved: map[string]string{},
+	}
+	keywords := statementKeywords(sql)
+	if len(keywords) > 0 {
+		n.keyword = keywords[0]
+	}
+	for _, keyword := range keywords {
+		if keyword == "TEMPORARY" || keyword == "TEMP" || keyword == "VOLATILE" {
+			n.temporary = true
+		}
+	}
+	for _, match := range commonTableExpression.FindAllStringSubmatch(sql, -1) {
+		n.ctes[strings.ToUpper(match[1])] = true
+	}
+	return n
+}
+
+// resolve returns the name to write for the one-part name ending at
+// sql[end], which follows the keyword prev. target is set for the object a
+// CREATE, ALTER, or DROP statement names. Names not in table position, of
+// common table expressions, or of no table of the session's schema are kept.
+func (n *sessionNames) resolve(sql, name string, end int, prev string, target bool) string {
+	if n == nil || !tableKeywords[prev] || strings.EqualFold(name, "IF") || n.ctes[strings.ToUpper(name)] {
+		return name
+	}
+	if target && n.keyword == "CREATE" {
+		if n.schema == publicSchema || n.temporary {
+			return name
+		}
+		if schema, ok := n.e.duckDBSchema(n.ctx, n.schema); ok {
+			return quoteIdent(schema) + "." + name
+		}
+		return name
+	}
+	// FROM f(...) calls a table function
+	if prev == "FROM" || prev == "JOIN" {
+		rest := strings.TrimLeft(sql[end:], " \t\r\n")
+		if strings.HasPrefix(rest, "(") {
+			return name
+		}
+	}
+
+	if resolved, ok := n.resolved[name]; ok {
+		return resolved
+	}
+	resolved := name
+	object := objectName(name)
+	physicalSchema := physicalSchemaName(n.schema)
+	switch {
+	case n.e.objectExists(n.ctx, physicalSchema, object):
+		if physicalSchema != "main" {
+			resolved = quoteIdent(physicalSchema) + "." + name
+		}
+	case !target && n.e.tableExists(n.ctx, n.database, n.schema+"_"+object):
+		resolved = quoteIdent(n.database) + "." + quoteIdent(n.schema+"_"+object)
+	}
+	if !target {
+		n.resolved[name] = resolved
+	}
+	return resolved
+}
+
+// qualifiedTableName returns the DuckDB name of the table or view schema.name
+// of database, with schema and name as written. Tables of the REST API are
+// stored as DATABASE.SCHEMA_TABLE, and are used unless rest is unset. Other
+// objects are in the DuckDB schema of the same name, or in main for PUBLIC.
+func (e *Executor) qualifiedTableName(ctx context.Context, database, schema, name string, rest bool) string {
+	if physical := objectName(schema) + "_" + objectName(name); rest && database != "" && e.tableExists(ctx, database, physical) {
+		return quoteIdent(database) + "." + quoteIdent(physical)
+	}
+	if objectName(schema) == publicSchema {
+		return "main." + name
+	}
+	return schema + "." + name
+}
+
+// objectExists reports whether the DuckDB schema has a table or view named
+// name, ignoring case.
+func (e *Executor) objectExists(ctx context.Context, schema, name string) bool {
+	var count int
+	err := e.manager(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM (
+		SELECT schema_name, table_name AS name FROM duckdb_tables() WHERE database_name = current_database()
+		UNION ALL
+		SELECT schema_name, view_name FROM duckdb_views() WHERE database_name = current_database() AND NOT internal
+	) WHERE lower(schema_name) = lower(?) AND lower(name) = lower(?)`, schema, name).Scan(&count)
+	return err == nil && count > 0
+}
+
+// duckDBSchema returns the name of the DuckDB schema named schema, ignoring
+// case.
+func (e *Executor) duckDBSchema(ctx context.Context, schema string) (string, bool) {
+	var name string
+	err := e.manager(ctx).QueryRow(ctx, `SELECT schema_name FROM duckdb_schemas()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) LIMIT 1`, schema).Scan(&name)
+	return name, err == nil
+}
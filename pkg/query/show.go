@@ -0,0 +1,659 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// informationSchema is the schema Snowflake lists in every database.
+const informationSchema = "INFORMATION_SCHEMA"
+
+// Scopes of a SHOW statement's IN clause.
+const (
+	showInAccount  = "ACCOUNT"
+	showInDatabase = "DATABASE"
+	showInSchema   = "SCHEMA"
+)
+
+// Columns of the SHOW results, as Snowflake returns them. TERSE results have
+// the showTerseNames columns.
+var (
+	showDatabasesNames = []string{
+		"created_on", "name", "is_default", "is_current", "origin", "owner", "comment", "options", "retention_time", "kind",
+	}
+	showSchemasNames = []string{
+		"created_on", "name", "is_default", "is_current", "database_name", "owner", "comment", "options", "retention_time",
+	}
+	showTablesNames = []string{
+		"created_on", "name", "database_name", "schema_name", "kind", "comment", "cluster_by", "rows", "bytes",
+		"owner", "retention_time", "automatic_clustering", "change_tracking", "is_external",
+	}
+	showWarehousesNames = []string{
+		"name", "state", "type", "size", "running", "queued", "is_default", "is_current",
+		"auto_suspend", "auto_resume", "created_on", "owner", "comment",
+	}
+	showViewsNames = []string{
+		"created_on", "name", "reserved", "database_name", "schema_name", "owner", "comment", "text",
+		"is_secure", "is_materialized", "owner_role_type", "change_tracking",
+	}
+	showTerseNames = []string{"created_on", "name", "kind", "database_name", "schema_name"}
+)
+
+// WithWarehouseManager sets the warehouse manager SHOW WAREHOUSES lists. The
+// REST API's warehouse endpoints should share it.
+func WithWarehouseManager(mgr *warehouse.Manager) ExecutorOption {
+	return func(e *Executor) {
+		e.warehouses = mgr
+	}
+}
+
+// showStatement is a parsed SHOW [TERSE] <objects> [LIKE '...'] [IN scope
+// [name]] [STARTS WITH '...'] [LIMIT n], listing one of the showObjectTypes.
+type showStatement struct {
+	// Object is the plural name of the object type, e.g. TABLES or FILE FORMATS.
+	Object     string
+	Terse      bool
+	Like       string
+	StartsWith string
+	// Limit is the maximum number of rows, or 0 for all rows.
+	Limit int
+	// Scope is ACCOUNT, DATABASE, or SCHEMA, or "" for the session's current
+	// database, or the whole account without one.
+	Scope string
+	// Name is the database or schema of the scope, as written, or "" for the
+	// session's current one.
+	Name string
+}
+
+// showObjectType is an object type SHOW lists and DESCRIBE describes.
+type showObjectType struct {
+	// Names are the columns of the SHOW result.
+	Names []string
+	// Container is the scope the objects are in: ACCOUNT for databases and
+	// warehouses, DATABASE for schemas, and SCHEMA for the objects of schemas.
+	Container string
+	// Singular is the name DESCRIBE takes, as in DESCRIBE FILE FORMAT f, or ""
+	// for tables and views, whose columns DuckDB describes.
+	Singular string
+	// NoTerse is set for object types SHOW TERSE does not list.
+	NoTerse bool
+	// list returns the rows of the objects in the statement's scope.
+	list func(e *Executor, ctx context.Context, stmt *showStatement) ([]showRow, error)
+}
+
+// showObjectTypes are the object types SHOW lists, keyed by their plural name.
+// Every type is listed with the same LIKE, IN, STARTS WITH, LIMIT, and TERSE
+// handling, and described by DESCRIBE from its SHOW row.
+var showObjectTypes = map[string]*showObjectType{
+	"DATABASES":      {Names: showDatabasesNames, Container: showInAccount, Singular: "DATABASE", list: (*Executor).showDatabases},
+	"SCHEMAS":        {Names: showSchemasNames, Container: showInDatabase, Singular: "SCHEMA", list: (*Executor).showSchemas},
+	"TABLES":         {Names: showTablesNames, Container: showInSchema, list: (*Executor).showTables},
+	"VIEWS":          {Names: showViewsNames, Container: showInSchema, list: (*Executor).showViews},
+	"WAREHOUSES":     {Names: showWarehousesNames, Container: showInAccount, Singular: "WAREHOUSE", NoTerse: true, list: (*Executor).showWarehouses},
+	"STAGES":         {Names: showStagesNames, Container: showInSchema, Singular: "STAGE", list: (*Executor).showStages},
+	"FILE FORMATS":   {Names: showFileFormatsNames, Container: showInSchema, Singular: "FILE FORMAT", list: (*Executor).showFileFormats},
+	"PROCEDURES":     {Names: showProceduresNames, Container: showInSchema, Singular: "PROCEDURE", list: (*Executor).showProcedures},
+	"FUNCTIONS":      {Names: showFunctionsNames, Container: showInSchema, Singular: "FUNCTION", list: (*Executor).showFunctions},
+	"USER FUNCTIONS": {Names: showFunctionsNames, Container: showInSchema, list: (*Executor).showFunctions},
+	"PIPES":          {Names: showPipesNames, Container: showInSchema, Singular: "PIPE", list: (*Executor).showUnsupportedObjects},
+	"STREAMS":        {Names: showStreamsNames, Container: showInSchema, Singular: "STREAM", list: (*Executor).showUnsupportedObjects},
+	"TASKS":          {Names: showTasksNames, Container: showInSchema, Singular: "TASK", list: (*Executor).showUnsupportedObjects},
+}
+
+// objectTypeWords returns the name of the object type of showObjectTypes at
+// the start of fields, of one or two words, and the number of words it
+// takes. singular matches the names DESCRIBE takes instead.
+func objectTypeWords(fields []string, singular bool) (string, int) {
+	for n := 2; n >= 1; n-- {
+		if len(fields) < n {
+			continue
+		}
+		name := strings.ToUpper(strings.Join(fields[:n], " "))
+		for plural, objectType := range showObjectTypes {
+			if (!singular && name == plural) || (singular && name == objectType.Singular) {
+				return plural, n
+			}
+		}
+	}
+	return "", 0
+}
+
+// afterFields returns the rest of s after its first n whitespace-separated fields.
+func afterFields(s string, n int) string {
+	for ; n > 0; n-- {
+		s = strings.TrimSpace(s)
+		end := strings.IndexAny(s, " \t\r\n")
+		if end < 0 {
+			return ""
+		}
+		s = s[end:]
+	}
+	return strings.TrimSpace(s)
+}
+
+// parseShowStatement parses a SHOW statement listing one of the
+// showObjectTypes. It reports false for other statements.
+func parseShowStatement(sql string) (*showStatement, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	fields := strings.Fields(s)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "SHOW") {
+		return nil, false, nil
+	}
+	stmt := &showStatement{}
+	i := 1
+	if strings.EqualFold(fields[1], "TERSE") {
+		stmt.Terse = true
+		i++
+	}
+	object, n := objectTypeWords(fields[i:], false)
+	if object == "" {
+		return nil, false, nil
+	}
+	stmt.Object = object
+	if stmt.Terse && showObjectTypes[object].NoTerse {
+		return nil, true, fmt.Errorf("SHOW TERSE %s is not supported", stmt.Object)
+	}
+
+	rest := afterFields(s, i+n)
+	if err := parseShowClauses(stmt, rest); err != nil {
+		return nil, true, fmt.Errorf("SHOW %s: %w", stmt.Object, err)
+	}
+	return stmt, true, nil
+}
+
+// parseShowClauses parses the LIKE, IN, STARTS WITH, and LIMIT clauses of a
+// SHOW statement.
+func parseShowClauses(stmt *showStatement, rest string) error {
+	for rest != "" {
+		switch {
+		case keywordAt(rest, 0, "LIKE"):
+			value, end, ok := commentValueAt(rest, len("LIKE"))
+			if !ok {
+				return fmt.Errorf("invalid LIKE pattern")
+			}
+			stmt.Like, rest = value, rest[end:]
+		case keywordAt(rest, 0, "STARTS"):
+			after := strings.TrimSpace(rest[len("STARTS"):])
+			if !keywordAt(after, 0, "WITH") {
+				return fmt.Errorf("expected STARTS WITH '...'")
+			}
+			value, end, ok := commentValueAt(after, len("WITH"))
+			if !ok {
+				return fmt.Errorf("invalid STARTS WITH prefix")
+			}
+			stmt.StartsWith, rest = value, after[end:]
+		case keywordAt(rest, 0, "LIMIT"):
+			var word string
+			word, rest = nextWord(rest[len("LIMIT"):])
+			limit, err := strconv.Atoi(word)
+			if err != nil || limit < 1 {
+				return fmt.Errorf("invalid LIMIT %q", word)
+			}
+			stmt.Limit = limit
+			if keywordAt(rest, 0, "FROM") {
+				return fmt.Errorf("LIMIT ... FROM is not supported")
+			}
+		case keywordAt(rest, 0, "IN"):
+			var err error
+			if rest, err = parseShowScope(stmt, rest[len("IN"):]); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected %q", rest)
+		}
+		rest = strings.TrimSpace(rest)
+	}
+	return nil
+}
+
+// parseShowScope parses the scope of an IN clause and returns the rest of the
+// statement. A bare name is a database for SHOW SCHEMAS and a schema for the
+// objects of schemas, such as SHOW TABLES.
+func parseShowScope(stmt *showStatement, rest string) (string, error) {
+	word, rest := nextWord(rest)
+	scope := strings.ToUpper(word)
+	switch scope {
+	case showInAccount:
+	case showInDatabase, showInSchema:
+		if name, after := nextWord(rest); name != "" && !showClauseKeyword(name) {
+			stmt.Name, rest = name, after
+		}
+	case "":
+		return "", fmt.Errorf("IN needs a scope")
+	default:
+		scope = showInSchema
+		if showObjectTypes[stmt.Object].Container == showInDatabase {
+			scope = showInDatabase
+		}
+		stmt.Name = word
+	}
+	stmt.Scope = scope
+
+	switch container := showObjectTypes[stmt.Object].Container; {
+	case container == showInAccount && scope != showInAccount,
+		container == showInDatabase && scope == showInSchema:
+		return "", fmt.Errorf("IN %s is not supported", scope)
+	}
+	return rest, nil
+}
+
+// showClauseKeyword reports whether a word starts a clause of a SHOW statement.
+func showClauseKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "LIKE", "IN", "STARTS", "LIMIT":
+		return true
+	}
+	return false
+}
+
+// nextWord returns the keyword, number, or possibly qualified name at the
+// start of s, and the rest of s.
+func nextWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	end := 0
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+	} else {
+		_, end = objectNameParts(s, 0)
+	}
+	return s[:end], strings.TrimSpace(s[end:])
+}
+
+// showRow is a row of a SHOW result, with the fields it is filtered and sorted by.
+type showRow struct {
+	Database, Schema, Name string
+	Kind                   string
+	CreatedOn              interface{}
+	Values                 []interface{}
+}
+
+// queryShow lists the objects of one of the showObjectTypes. Rows are
+// filtered by the LIKE pattern and STARTS WITH prefix of their names, and
+// ordered by database, schema, and name.
+func (e *Executor) queryShow(ctx context.Context, stmt *showStatement) (*Result, error) {
+	objectType := showObjectTypes[stmt.Object]
+	names := objectType.Names
+	rows, err := objectType.list(e, ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Database != b.Database {
+			return a.Database < b.Database
+		}
+		if a.Schema != b.Schema {
+			return a.Schema < b.Schema
+		}
+		return a.Name < b.Name
+	})
+	if stmt.Terse {
+		names = showTerseNames
+	}
+	result := &Result{Columns: names, ColumnTypes: showColumnTypes(names)}
+	for _, row := range rows {
+		if stmt.Like != "" && !likePattern(stmt.Like).MatchString(row.Name) {
+			continue
+		}
+		if !strings.HasPrefix(row.Name, stmt.StartsWith) {
+			continue
+		}
+		if stmt.Limit > 0 && len(result.Rows) == stmt.Limit {
+			break
+		}
+		values := row.Values
+		if stmt.Terse {
+			values = []interface{}{row.CreatedOn, row.Name, row.Kind, row.Database, row.Schema}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, nil
+}
+
+// showColumnTypes returns the column types of a SHOW result.
+func showColumnTypes(names []string) []types.ColumnMetadata {
+	columnTypes := make([]types.ColumnMetadata, len(names))
+	for i, name := range names {
+		col := types.ColumnMetadata{Name: name, Type: "text", Nullable: true}
+		switch name {
+		case "created_on":
+			col.Type = "timestamp_ltz"
+		case "rows", "bytes", "running", "queued", "auto_suspend", "min_num_arguments", "max_num_arguments":
+			col.Type, col.Precision = "fixed", 38
+		}
+		columnTypes[i] = col
+	}
+	return columnTypes
+}
+
+// yesNo renders a SHOW flag.
+func yesNo(flag bool) string {
+	if flag {
+		return "Y"
+	}
+	return "N"
+}
+
+// showDatabases lists the databases of the metadata store.
+func (e *Executor) showDatabases(ctx context.Context, _ *showStatement) ([]showRow, error) {
+	databases, err := e.repo.ListDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current := SessionInfoFromContext(ctx).Database
+	rows := make([]showRow, 0, len(databases))
+	for _, db := range databases {
+		rows = append(rows, showRow{
+			Name:      db.Name,
+			Kind:      "STANDARD",
+			CreatedOn: db.CreatedAt,
+			Values: []interface{}{
+				db.CreatedAt, db.Name, "N", yesNo(strings.EqualFold(db.Name, current)), "", db.Owner, db.Comment, "", "1", "STANDARD",
+			},
+		})
+	}
+	return rows, nil
+}
+
+// scopeDatabases returns the databases of a SHOW statement's scope: the named
+// or current database, or all databases for the account or a session without
+// a current database.
+func (e *Executor) scopeDatabases(ctx context.Context, stmt *showStatement) ([]*metadata.Database, error) {
+	name := SessionInfoFromContext(ctx).Database
+	switch {
+	case stmt.Scope == showInAccount, stmt.Scope == "" && name == "":
+		return e.repo.ListDatabases(ctx)
+	case stmt.Scope == showInDatabase && stmt.Name != "":
+		name = stmt.Name
+	case stmt.Scope == showInSchema && stmt.Name != "":
+		if parts, _ := objectNameParts(stmt.Name, 0); len(parts) == 2 {
+			name = parts[0]
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("cannot perform SHOW %s: this session does not have a current database", stmt.Object)
+	}
+	db, err := e.repo.GetDatabaseByName(ctx, objectName(name))
+	if err != nil {
+		return nil, fmt.Errorf("database '%s' does not exist or not authorized", objectName(name))
+	}
+	return []*metadata.Database{db}, nil
+}
+
+// objectName returns the stored name of an identifier, which is upper-cased
+// unless quoted.
+func objectName(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		return unquoteIdentifier(name)
+	}
+	return strings.ToUpper(name)
+}
+
+// databaseSchemas returns the schemas of a database: INFORMATION_SCHEMA and
+// PUBLIC, which every database has, and the schemas registered in the
+// metadata store.
+func (e *Executor) databaseSchemas(ctx context.Context, db *metadata.Database) ([]*metadata.Schema, error) {
+	registered, err := e.repo.ListSchemas(ctx, db.ID)
+	if err != nil {
+		return nil, err
+	}
+	schemas := []*metadata.Schema{
+		{DatabaseID: db.ID, Name: informationSchema, Comment: "Views describing the contents of schemas in this database", CreatedAt: db.CreatedAt},
+	}
+	hasPublic := false
+	for _, schema := range registered {
+		hasPublic = hasPublic || schema.Name == publicSchema
+	}
+	if !hasPublic {
+		schemas = append(schemas, &metadata.Schema{DatabaseID: db.ID, Name: publicSchema, CreatedAt: db.CreatedAt, Owner: db.Owner})
+	}
+	return append(schemas, registered...), nil
+}
+
+// showSchemas lists the schemas of the databases of a SHOW SCHEMAS statement.
+func (e *Executor) showSchemas(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	databases, err := e.scopeDatabases(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	info := SessionInfoFromContext(ctx)
+	var rows []showRow
+	for _, db := range databases {
+		schemas, err := e.databaseSchemas(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, schema := range schemas {
+			current := strings.EqualFold(db.Name, info.Database) && strings.EqualFold(schema.Name, info.Schema)
+			rows = append(rows, showRow{
+				Database:  db.Name,
+				Name:      schema.Name,
+				Kind:      "STANDARD",
+				CreatedOn: schema.CreatedAt,
+				Values: []interface{}{
+					schema.CreatedAt, schema.Name, "N", yesNo(current), db.Name, schema.Owner, schema.Comment, "", "1",
+				},
+			})
+		}
+	}
+	return rows, nil
+}
+
+// showTables lists the tables of the schemas of a SHOW TABLES statement.
+func (e *Executor) showTables(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	databases, err := e.scopeDatabases(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	var rows []showRow
+	for _, db := range databases {
+		schemas, err := e.scopeSchemas(ctx, stmt, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, schema := range schemas {
+			tables, err := e.registeredSchemaTables(ctx, db, schema)
+			if err != nil {
+				return nil, err
+			}
+			for _, table := range tables {
+				var createdOn interface{}
+				owner, clusterBy := "", ""
+				if table.Registered != nil {
+					createdOn = nullIfZero(table.Registered.CreatedAt)
+					owner, clusterBy = table.Registered.Owner, table.Registered.ClusteringKey
+				}
+				rows = append(rows, showRow{
+					Database:  db.Name,
+					Schema:    schema,
+					Name:      table.Name,
+					Kind:      "TABLE",
+					CreatedOn: createdOn,
+					Values: []interface{}{
+						createdOn, table.Name, db.Name, schema, "TABLE", table.Comment, clusterBy, table.Rows, nil,
+						owner, "1", "OFF", "OFF", "N",
+					},
+				})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// scopeSchemas returns the names of the schemas of db in a SHOW statement's
+// scope: the named or current schema, or all schemas but INFORMATION_SCHEMA.
+func (e *Executor) scopeSchemas(ctx context.Context, stmt *showStatement, db *metadata.Database) ([]string, error) {
+	schemas, err := e.databaseSchemas(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Scope != showInSchema {
+		names := make([]string, 0, len(schemas))
+		for _, schema := range schemas {
+			if schema.Name != informationSchema {
+				names = append(names, schema.Name)
+			}
+		}
+		return names, nil
+	}
+
+	name := SessionInfoFromContext(ctx).Schema
+	if stmt.Name != "" {
+		parts, _ := objectNameParts(stmt.Name, 0)
+		name = objectName(parts[len(parts)-1])
+	}
+	if name == "" {
+		return nil, fmt.Errorf("cannot perform SHOW %s: this session does not have a current schema", stmt.Object)
+	}
+	for _, schema := range schemas {
+		if strings.EqualFold(schema.Name, name) {
+			return []string{schema.Name}, nil
+		}
+	}
+	return nil, fmt.Errorf("schema '%s.%s' does not exist or not authorized", db.Name, name)
+}
+
+// schemaTable is a table of a schema of a database.
+type schemaTable struct {
+	Name string
+	// PhysicalSchema, PhysicalName, and Physical are the DuckDB schema, name,
+	// and qualified name the table is stored under.
+	PhysicalSchema string
+	PhysicalName   string
+	Physical       string
+	Comment        string
+	// Rows is DuckDB's estimate of the table's row count.
+	Rows int64
+	// Registered is the table's metadata, for tables created through the REST API.
+	Registered *metadata.Table
+}
+
+// physicalSchemaName returns the DuckDB schema the tables created with SQL in
+// a schema are stored in: the schema of the same name, or main for PUBLIC.
+func physicalSchemaName(schema string) string {
+	if schema == publicSchema {
+		return "main"
+	}
+	return schema
+}
+
+// schemaTables lists the tables of a schema of db: those created with SQL in
+// the DuckDB schema of the same name, or in main for PUBLIC, and those created
+// through the REST API, which are stored as DATABASE.SCHEMA_TABLE.
+func (e *Executor) schemaTables(ctx context.Context, db *metadata.Database, schema string) ([]*schemaTable, error) {
+	physicalSchema := physicalSchemaName(schema)
+	rows, err := e.manager(ctx).Query(ctx, `SELECT table_name, comment, estimated_size FROM duckdb_tables()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND NOT internal AND NOT temporary
+		AND table_name NOT LIKE '\_metadata\_%' ESCAPE '\'
+		ORDER BY table_name`, physicalSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables of schema %s: %w", schema, err)
+	}
+	var tables []*schemaTable
+	for rows.Next() {
+		var name string
+		var comment sql.NullString
+		var size sql.NullInt64
+		if err := rows.Scan(&name, &comment, &size); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		tables = append(tables, &schemaTable{
+			Name:           name,
+			PhysicalSchema: physicalSchema,
+			PhysicalName:   name,
+			Physical:       quoteIdent(physicalSchema) + "." + quoteIdent(name),
+			Comment:        comment.String,
+			Rows:           size.Int64,
+		})
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	registered, err := e.repo.GetSchemaByName(ctx, db.ID, schema)
+	if err != nil {
+		return tables, nil
+	}
+	restTables, err := e.repo.ListTables(ctx, registered.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range restTables {
+		if table.TableType != "BASE TABLE" || !e.tableExists(ctx, db.Name, schema+"_"+table.Name) {
+			continue
+		}
+		tables = append(tables, &schemaTable{
+			Name:           table.Name,
+			PhysicalSchema: db.Name,
+			PhysicalName:   schema + "_" + table.Name,
+			Physical:       quoteIdent(db.Name) + "." + quoteIdent(schema+"_"+table.Name),
+			Comment:        table.Comment,
+			Registered:     table,
+		})
+	}
+	return tables, nil
+}
+
+// registeredSchemaTables lists the tables of a schema of db like schemaTables,
+// but with the registrations in the metadata store of those created with SQL
+// too, which hold their owner and creation time.
+func (e *Executor) registeredSchemaTables(ctx context.Context, db *metadata.Database, schema string) ([]*schemaTable, error) {
+	tables, err := e.schemaTables(ctx, db, schema)
+	if err != nil {
+		return nil, err
+	}
+	registered, err := e.repo.GetSchemaByName(ctx, db.ID, schema)
+	if err != nil {
+		return tables, nil
+	}
+	restTables, err := e.repo.ListTables(ctx, registered.ID)
+	if err != nil {
+		return nil, err
+	}
+	registrations := make(map[string]*metadata.Table, len(restTables))
+	for _, table := range restTables {
+		registrations[strings.ToUpper(table.Name)] = table
+	}
+	for _, table := range tables {
+		if table.Registered == nil {
+			table.Registered = registrations[strings.ToUpper(table.Name)]
+		}
+	}
+	return tables, nil
+}
+
+// showWarehouses lists the warehouses of the warehouse manager.
+func (e *Executor) showWarehouses(ctx context.Context, _ *showStatement) ([]showRow, error) {
+	if e.warehouses == nil {
+		return nil, nil
+	}
+	warehouses, err := e.warehouses.ListWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]showRow, 0, len(warehouses))
+	for _, wh := range warehouses {
+		rows = append(rows, showRow{
+			Name:      wh.Name,
+			CreatedOn: wh.CreatedAt,
+			Values: []interface{}{
+				wh.Name, string(wh.State), "STANDARD", wh.Size, int64(0), int64(0), "N", "N",
+				int64(wh.AutoSuspend), strconv.FormatBool(wh.AutoResume), wh.CreatedAt, wh.Owner, wh.Comment,
+			},
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,190 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// showColumnsNames are the columns of a SHOW COLUMNS result, as Snowflake returns them.
+var showColumnsNames = []string{
+	"table_name", "schema_name", "column_name", "data_type", "null?", "default",
+	"kind", "expression", "comment", "database_name", "autoincrement",
+}
+
+// showColumnsStatement is a parsed SHOW COLUMNS [LIKE '...'] [IN [TABLE | VIEW]
+// name | IN SCHEMA [name] | IN DATABASE [name] | IN ACCOUNT].
+type showColumnsStatement struct {
+	// Like is the LIKE pattern, or "" to show all columns.
+	Like string
+	// Object is the table or view name as written, or "" to show the columns
+	// of the tables of Scope.
+	Object string
+	// Scope lists the tables whose columns are shown when Object is "".
+	Scope showStatement
+}
+
+// parseShowColumns parses a SHOW COLUMNS statement. It reports false if sql is
+// not a SHOW COLUMNS statement.
+func parseShowColumns(sql string) (*showColumnsStatement, bool, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(sql), ";")
+	fields := strings.Fields(s)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "SHOW") || !strings.EqualFold(fields[1], "COLUMNS") {
+		return nil, false, nil
+	}
+
+	stmt := &showColumnsStatement{Scope: showStatement{Object: "COLUMNS"}}
+	rest := strings.TrimSpace(s[indexFold(s, "COLUMNS")+len("COLUMNS"):])
+	if keywordAt(rest, 0, "LIKE") {
+		value, end, ok := commentValueAt(rest, len("LIKE"))
+		if !ok {
+			return nil, true, fmt.Errorf("SHOW COLUMNS: invalid LIKE pattern")
+		}
+		stmt.Like = value
+		rest = strings.TrimSpace(rest[end:])
+	}
+	if rest == "" {
+		return stmt, true, nil
+	}
+
+	fields = strings.Fields(rest)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "IN") {
+		return nil, true, fmt.Errorf("SHOW COLUMNS: unexpected %q", rest)
+	}
+	fields = fields[1:]
+	switch strings.ToUpper(fields[0]) {
+	case "TABLE", "VIEW":
+		fields = fields[1:]
+	case showInAccount, showInDatabase, showInSchema:
+		stmt.Scope.Scope = strings.ToUpper(fields[0])
+		switch {
+		case len(fields) == 2 && stmt.Scope.Scope != showInAccount:
+			stmt.Scope.Name = fields[1]
+		case len(fields) > 1:
+			return nil, true, fmt.Errorf("SHOW COLUMNS: unexpected %q", strings.Join(fields[1:], " "))
+		}
+		return stmt, true, nil
+	}
+	if len(fields) != 1 {
+		return nil, true, fmt.Errorf("SHOW COLUMNS: unexpected %q", rest)
+	}
+	stmt.Object = fields[0]
+	return stmt, true, nil
+}
+
+// showColumnsDataType is the data_type JSON of a SHOW COLUMNS row.
+type showColumnsDataType struct {
+	Type      string `json:"type"`
+	Precision *int64 `json:"precision,omitempty"`
+	Scale     *int64 `json:"scale,omitempty"`
+	Nullable  bool   `json:"nullable"`
+}
+
+// queryShowColumns lists the columns of a table or view, or of the tables of a
+// schema, database, or the account. Column types are inferred from the
+// object's definition, so views over translated SQL report the Snowflake
+// types of their query.
+func (e *Executor) queryShowColumns(ctx context.Context, stmt *showColumnsStatement) (*Result, error) {
+	var like *regexp.Regexp
+	if stmt.Like != "" {
+		like = likePattern(stmt.Like)
+	}
+	result := &Result{Columns: showColumnsNames}
+	for _, name := range showColumnsNames {
+		result.ColumnTypes = append(result.ColumnTypes, types.ColumnMetadata{Name: name, Type: "text", Nullable: true})
+	}
+
+	if stmt.Object != "" {
+		database, schema, object := splitObjectName(ctx, stmt.Object)
+		if err := e.appendShowColumns(ctx, result, e.resolveTableName(ctx, stmt.Object), database, schema, object, like); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	databases, err := e.scopeDatabases(ctx, &stmt.Scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, db := range databases {
+		schemas, err := e.scopeSchemas(ctx, &stmt.Scope, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, schema := range schemas {
+			tables, err := e.schemaTables(ctx, db, schema)
+			if err != nil {
+				return nil, err
+			}
+			for _, table := range tables {
+				if err := e.appendShowColumns(ctx, result, table.Physical, db.Name, schema, table.Name, like); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// appendShowColumns appends the SHOW COLUMNS rows of the columns of a table
+// or view, stored under physical, that match like.
+func (e *Executor) appendShowColumns(ctx context.Context, result *Result, physical, database, schema, object string, like *regexp.Regexp) error {
+	rows, err := e.manager(ctx).Query(ctx, "SELECT * FROM "+physical+" LIMIT 0")
+	if err != nil {
+		return fmt.Errorf("query execution error: %w", err)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		_ = rows.Close()
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+	columnTypes := InferColumnMetadata(columns, rows)
+	_ = rows.Close()
+
+	for _, col := range columnTypes {
+		if like != nil && !like.MatchString(col.Name) {
+			continue
+		}
+		dataType := showColumnsDataType{Type: strings.ToUpper(col.Type), Nullable: col.Nullable}
+		if col.Type == "fixed" {
+			precision, scale := col.Precision, col.Scale
+			dataType.Precision, dataType.Scale = &precision, &scale
+		}
+		encoded, err := json.Marshal(dataType)
+		if err != nil {
+			return fmt.Errorf("failed to encode data type: %w", err)
+		}
+		nullable := "false"
+		if col.Nullable {
+			nullable = "true"
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			strings.ToUpper(object), strings.ToUpper(schema), col.Name, string(encoded), nullable, "",
+			"COLUMN", "", "", strings.ToUpper(database), "",
+		})
+	}
+	return nil
+}
+
+// likePattern compiles a case-insensitive SQL LIKE pattern, where % matches any
+// run of characters and _ matches a single character.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,153 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseShowColumns tests parsing SHOW COLUMNS statements.
+func TestParseShowColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected *showColumnsStatement
+		wantOK   bool
+		wantErr  bool
+	}{
+		{
+			name:     "InView",
+			sql:      "SHOW COLUMNS IN VIEW v",
+			expected: &showColumnsStatement{Object: "v", Scope: showStatement{Object: "COLUMNS"}},
+			wantOK:   true,
+		},
+		{
+			name:     "LikeInTable",
+			sql:      "show columns like 'AM%' in table db.s.t;",
+			expected: &showColumnsStatement{Like: "AM%", Object: "db.s.t", Scope: showStatement{Object: "COLUMNS"}},
+			wantOK:   true,
+		},
+		{
+			name:     "InName",
+			sql:      "SHOW COLUMNS IN t",
+			expected: &showColumnsStatement{Object: "t", Scope: showStatement{Object: "COLUMNS"}},
+			wantOK:   true,
+		},
+		{
+			name:     "InSchema",
+			sql:      "SHOW COLUMNS IN SCHEMA public",
+			expected: &showColumnsStatement{Scope: showStatement{Object: "COLUMNS", Scope: "SCHEMA", Name: "public"}},
+			wantOK:   true,
+		},
+		{
+			name:     "NoScope",
+			sql:      "SHOW COLUMNS",
+			expected: &showColumnsStatement{Scope: showStatement{Object: "COLUMNS"}},
+			wantOK:   true,
+		},
+		{name: "InAccountWithName", sql: "SHOW COLUMNS IN ACCOUNT x", wantOK: true, wantErr: true},
+		{name: "NoObject", sql: "SHOW COLUMNS IN TABLE", wantOK: true, wantErr: true},
+		{name: "OtherShow", sql: "SHOW TABLES", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseShowColumns(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseShowColumns() ok, err = %v, %v, want %v, error %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("parseShowColumns() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_ShowColumns tests listing the columns of a view over translated SQL.
+func TestExecutor_ShowColumns(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{Database: "TEST_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE TABLE orders (id INTEGER NOT NULL, amount NUMBER(10,2))",
+		"CREATE VIEW order_sizes AS SELECT id, amount, IFF(amount > 50, 'large', 'small') AS size FROM orders",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SHOW COLUMNS IN VIEW order_sizes")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff(showColumnsNames, result.Columns); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+	expected := [][]interface{}{
+		{"ORDER_SIZES", "PUBLIC", "id", `{"type":"FIXED","precision":38,"scale":0,"nullable":true}`, "true", "", "COLUMN", "", "", "TEST_DB", ""},
+		{"ORDER_SIZES", "PUBLIC", "amount", `{"type":"FIXED","precision":10,"scale":2,"nullable":true}`, "true", "", "COLUMN", "", "", "TEST_DB", ""},
+		{"ORDER_SIZES", "PUBLIC", "size", `{"type":"TEXT","nullable":true}`, "true", "", "COLUMN", "", "", "TEST_DB", ""},
+	}
+	if diff := cmp.Diff(expected, result.Rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+
+	result, err = executor.Query(ctx, "SHOW COLUMNS LIKE 'AM%' IN TABLE orders")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][2] != "amount" {
+		t.Errorf("SHOW COLUMNS LIKE rows = %v, want only amount", result.Rows)
+	}
+
+	if _, err := executor.Query(ctx, "SHOW COLUMNS IN VIEW missing"); err == nil {
+		t.Error("Query() for a missing view error = nil, want error")
+	}
+}
+
+// TestExecutor_ShowColumnsResolvesNames tests that SHOW COLUMNS finds tables
+// by database-qualified names and by the session's current database and schema,
+// as FROM does.
+func TestExecutor_ShowColumnsResolvesNames(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	for _, sql := range []string{
+		"CREATE DATABASE D2",
+		"CREATE SCHEMA D2.S2",
+		"CREATE TABLE D2.S2.T1 (id INTEGER, name VARCHAR)",
+	} {
+		if _, err := executor.Execute(context.Background(), sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		session SessionInfo
+		sql     string
+	}{
+		{name: "ThreePartName", sql: "SHOW COLUMNS IN TABLE D2.S2.T1"},
+		{name: "ThreePartNameInOtherDatabase", session: SessionInfo{Database: "TEST_DB", Schema: "PUBLIC"}, sql: "SHOW COLUMNS IN TABLE D2.S2.T1"},
+		{name: "CurrentSchema", session: SessionInfo{Database: "D2", Schema: "S2"}, sql: "SHOW COLUMNS IN TABLE T1"},
+		{name: "CurrentDatabase", session: SessionInfo{Database: "D2", Schema: "PUBLIC"}, sql: "SHOW COLUMNS IN TABLE S2.T1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ContextWithSessionInfo(context.Background(), tt.session), tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var got [][]interface{}
+			for _, row := range result.Rows {
+				got = append(got, []interface{}{row[0], row[1], row[2], row[9]})
+			}
+			want := [][]interface{}{{"T1", "S2", "id", "D2"}, {"T1", "S2", "name", "D2"}}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
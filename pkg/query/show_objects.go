@@ -0,0 +1,260 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// Columns of the SHOW results of the objects of schemas other than tables,
+// views, and stages, as Snowflake returns them.
+var (
+	showFileFormatsNames = []string{
+		"created_on", "name", "database_name", "schema_name", "type", "owner", "comment", "format_options", "owner_role_type",
+	}
+	showProceduresNames = []string{
+		"created_on", "name", "schema_name", "is_builtin", "is_aggregate", "is_ansi", "min_num_arguments",
+		"max_num_arguments", "arguments", "description", "catalog_name", "is_table_function", "valid_for_clustering",
+		"is_secure", "secrets", "external_access_integrations",
+	}
+	showFunctionsNames = append(append([]string{}, showProceduresNames...),
+		"is_external_function", "language", "is_memoizable", "is_data_metric")
+	showPipesNames = []string{
+		"created_on", "name", "database_name", "schema_name", "definition", "owner", "notification_channel", "comment",
+		"integration", "pattern", "error_integration", "owner_role_type", "invalid", "invalid_reason", "kind",
+	}
+	showStreamsNames = []string{
+		"created_on", "name", "database_name", "schema_name", "owner", "comment", "table_name", "source_type",
+		"base_tables", "type", "stale", "mode", "stale_after", "invalid_reason", "owner_role_type",
+	}
+	showTasksNames = []string{
+		"created_on", "name", "id", "database_name", "schema_name", "owner", "comment", "warehouse", "schedule",
+		"predecessors", "state", "definition", "condition", "allow_overlapping_execution", "error_integration",
+		"last_committed_on", "last_suspended_on", "owner_role_type", "config", "budget",
+	}
+	describeNames = []string{"property", "value"}
+)
+
+// forEachScopeSchema calls fn with each schema of the databases of a SHOW
+// statement's scope.
+func (e *Executor) forEachScopeSchema(ctx context.Context, stmt *showStatement, fn func(db *metadata.Database, schema string) error) error {
+	databases, err := e.scopeDatabases(ctx, stmt)
+	if err != nil {
+		return err
+	}
+	for _, db := range databases {
+		schemas, err := e.scopeSchemas(ctx, stmt, db)
+		if err != nil {
+			return err
+		}
+		for _, schema := range schemas {
+			if err := fn(db, schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// showFileFormats lists the file formats of the schemas of a SHOW FILE
+// FORMATS statement.
+func (e *Executor) showFileFormats(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	var rows []showRow
+	err := e.forEachScopeSchema(ctx, stmt, func(db *metadata.Database, schemaName string) error {
+		schema, err := e.repo.GetSchemaByName(ctx, db.ID, schemaName)
+		if err != nil {
+			return nil
+		}
+		formats, err := e.repo.ListFileFormats(ctx, schema.ID)
+		if err != nil {
+			return err
+		}
+		for _, f := range formats {
+			rows = append(rows, showRow{
+				Database:  db.Name,
+				Schema:    schema.Name,
+				Name:      f.Name,
+				Kind:      "FILE_FORMAT",
+				CreatedOn: f.CreatedAt,
+				Values: []interface{}{
+					f.CreatedAt, f.Name, db.Name, schema.Name, strings.ToUpper(f.FormatType), f.Owner, f.Comment, f.Options, "ROLE",
+				},
+			})
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// schemaObjectNames returns the qualified names of names, the keys of a
+// registry of objects such as procedures, that are in db.schema, sorted.
+func schemaObjectNames[T any](names map[string]T, db *metadata.Database, schema string) []string {
+	prefix := strings.ToUpper(db.Name+"."+schema) + "."
+	var matched []string
+	for name := range names {
+		if strings.HasPrefix(name, prefix) && !strings.Contains(name[len(prefix):], ".") {
+			matched = append(matched, name)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// showProcedures lists the procedures created with CREATE PROCEDURE in the
+// schemas of a SHOW PROCEDURES statement.
+func (e *Executor) showProcedures(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	e.procedures.mu.Lock()
+	defer e.procedures.mu.Unlock()
+	var rows []showRow
+	err := e.forEachScopeSchema(ctx, stmt, func(db *metadata.Database, schema string) error {
+		for _, name := range schemaObjectNames(e.procedures.byName, db, schema) {
+			proc := e.procedures.byName[name]
+			required, argTypes := 0, make([]string, len(proc.Parameters))
+			for i, param := range proc.Parameters {
+				argTypes[i] = strings.ToUpper(param.Type)
+				if param.Default == "" {
+					required++
+				}
+			}
+			var secrets, integrations interface{}
+			if len(proc.Secrets) > 0 {
+				encoded, _ := json.Marshal(proc.Secrets)
+				secrets = string(encoded)
+			}
+			if len(proc.ExternalAccessIntegrations) > 0 {
+				integrations = "[" + strings.Join(proc.ExternalAccessIntegrations, ", ") + "]"
+			}
+			arguments := fmt.Sprintf("%s(%s) RETURN %s", proc.Name, strings.Join(argTypes, ", "), strings.ToUpper(proc.Returns))
+			rows = append(rows, showRow{
+				Database:  db.Name,
+				Schema:    schema,
+				Name:      proc.Name,
+				Kind:      "PROCEDURE",
+				CreatedOn: proc.CreatedOn,
+				Values: []interface{}{
+					proc.CreatedOn, proc.Name, schema, "N", "N", "N", int64(required), int64(len(proc.Parameters)),
+					arguments, "user-defined procedure", db.Name, "N", "N", "N", secrets, integrations,
+				},
+			})
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// showFunctions lists the data metric functions created with CREATE DATA
+// METRIC FUNCTION in the schemas of a SHOW [USER] FUNCTIONS statement.
+// Built-in functions are not listed.
+func (e *Executor) showFunctions(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	e.dataMetrics.mu.Lock()
+	defer e.dataMetrics.mu.Unlock()
+	var rows []showRow
+	err := e.forEachScopeSchema(ctx, stmt, func(db *metadata.Database, schema string) error {
+		for _, qualified := range schemaObjectNames(e.dataMetrics.functions, db, schema) {
+			function := e.dataMetrics.functions[qualified]
+			name := qualified[strings.LastIndex(qualified, ".")+1:]
+			rows = append(rows, showRow{
+				Database:  db.Name,
+				Schema:    schema,
+				Name:      name,
+				Kind:      "FUNCTION",
+				CreatedOn: function.CreatedOn,
+				Values: []interface{}{
+					function.CreatedOn, name, schema, "N", "N", "N", int64(1), int64(1),
+					name + "(TABLE) RETURN NUMBER", "user-defined function", db.Name, "N", "N", "N", nil, nil,
+					"N", languageSQL, "N", "Y",
+				},
+			})
+		}
+		return nil
+	})
+	return rows, err
+}
+
+// showUnsupportedObjects lists the pipes, streams, or tasks of a SHOW
+// statement's scope. The emulator cannot create them, so once the scope is
+// found there are none.
+func (e *Executor) showUnsupportedObjects(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	err := e.forEachScopeSchema(ctx, stmt, func(*metadata.Database, string) error { return nil })
+	return nil, err
+}
+
+// describeStatement is a parsed DESC[RIBE] <type> name of one of the
+// showObjectTypes that has a Singular name.
+type describeStatement struct {
+	// Object is the plural name of the object type, e.g. FILE FORMATS.
+	Object string
+	// Name is the object's possibly qualified name, as written. The argument
+	// types of procedures and functions are dropped.
+	Name string
+}
+
+// parseDescribeStatement parses a DESCRIBE statement of an object of the
+// showObjectTypes. It reports false for other statements, such as DESCRIBE
+// TABLE.
+func parseDescribeStatement(sql string) (*describeStatement, bool, error) {
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	fields := strings.Fields(s)
+	if len(fields) < 2 || (!strings.EqualFold(fields[0], "DESC") && !strings.EqualFold(fields[0], "DESCRIBE")) {
+		return nil, false, nil
+	}
+	object, n := objectTypeWords(fields[1:], true)
+	if object == "" {
+		return nil, false, nil
+	}
+	name := afterFields(s, 1+n)
+	if paren := strings.IndexByte(name, '('); paren >= 0 {
+		name = strings.TrimSpace(name[:paren])
+	}
+	if word, rest := nextWord(name); word == "" || rest != "" {
+		return nil, true, fmt.Errorf("DESCRIBE %s: invalid name %q", showObjectTypes[object].Singular, name)
+	}
+	return &describeStatement{Object: object, Name: name}, true, nil
+}
+
+// queryDescribe describes an object by listing it like SHOW does in the scope
+// its name gives, and returning the columns of its row as property and value
+// rows.
+func (e *Executor) queryDescribe(ctx context.Context, stmt *describeStatement) (*Result, error) {
+	objectType := showObjectTypes[stmt.Object]
+	parts, _ := objectNameParts(stmt.Name, 0)
+	show := &showStatement{Object: stmt.Object, Scope: objectType.Container}
+	if len(parts) > 1 {
+		show.Name = strings.Join(parts[:len(parts)-1], ".")
+	}
+	rows, err := objectType.list(e, ctx, show)
+	if err != nil {
+		return nil, err
+	}
+
+	name := objectName(parts[len(parts)-1])
+	for _, row := range rows {
+		if !strings.EqualFold(row.Name, name) {
+			continue
+		}
+		result := &Result{Columns: describeNames, ColumnTypes: showColumnTypes(describeNames)}
+		for i, property := range objectType.Names {
+			result.Rows = append(result.Rows, []interface{}{property, describeValue(row.Values[i])})
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("%s '%s' does not exist or not authorized", strings.ToLower(objectType.Singular), name)
+}
+
+// describeValue renders a value of a SHOW row as the text of a DESCRIBE
+// property.
+func describeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
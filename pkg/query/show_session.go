@@ -0,0 +1,197 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Columns of the SHOW VARIABLES and SHOW PARAMETERS results, as Snowflake
+// returns them.
+var (
+	showVariablesNames  = []string{"session_id", "created_on", "updated_on", "name", "value", "type", "comment"}
+	showParametersNames = []string{"key", "value", "default", "level", "description", "type"}
+)
+
+// showSessionStatement is a parsed SHOW VARIABLES [LIKE '...'] or SHOW
+// PARAMETERS [LIKE '...'] [IN SESSION | IN ACCOUNT].
+type showSessionStatement struct {
+	// Object is VARIABLES or PARAMETERS.
+	Object string
+	Like   string
+	// Scope is SESSION or ACCOUNT.
+	Scope string
+}
+
+// parseShowSessionStatement parses a SHOW VARIABLES or SHOW PARAMETERS
+// statement. It reports false for other statements.
+func parseShowSessionStatement(sql string) (*showSessionStatement, bool, error) {
+	keywords := statementKeywords(sql)
+	if len(keywords) < 2 || keywords[0] != "SHOW" || (keywords[1] != "VARIABLES" && keywords[1] != "PARAMETERS") {
+		return nil, false, nil
+	}
+	stmt := &showSessionStatement{Object: keywords[1], Scope: "SESSION"}
+
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	rest := strings.TrimSpace(s[indexFold(s, stmt.Object)+len(stmt.Object):])
+	if keywordAt(rest, 0, "LIKE") {
+		value, end, ok := commentValueAt(rest, len("LIKE"))
+		if !ok {
+			return nil, true, fmt.Errorf("SHOW %s: invalid LIKE pattern", stmt.Object)
+		}
+		stmt.Like = value
+		rest = strings.TrimSpace(rest[end:])
+	}
+	if stmt.Object == "PARAMETERS" && keywordAt(rest, 0, "IN") {
+		scope, remainder := nextWord(strings.TrimSpace(rest[len("IN"):]))
+		stmt.Scope = strings.ToUpper(scope)
+		if stmt.Scope != "SESSION" && stmt.Scope != "ACCOUNT" {
+			return nil, true, fmt.Errorf("SHOW PARAMETERS IN %s is not supported", scope)
+		}
+		rest = strings.TrimSpace(remainder)
+	}
+	if rest != "" {
+		return nil, true, fmt.Errorf("SHOW %s: unexpected '%s'", stmt.Object, rest)
+	}
+	return stmt, true, nil
+}
+
+// queryShowSession lists the SQL variables or the parameters of the session.
+func (e *Executor) queryShowSession(ctx context.Context, stmt *showSessionStatement) *Result {
+	if stmt.Object == "VARIABLES" {
+		return e.showVariables(ctx, stmt.Like)
+	}
+	return showParameters(ctx, stmt)
+}
+
+// showVariables lists the SQL variables of the session, ordered by name.
+func (e *Executor) showVariables(ctx context.Context, like string) *Result {
+	sessionID := SessionInfoFromContext(ctx).ID
+	result := &Result{Columns: showVariablesNames, ColumnTypes: showSessionColumnTypes(showVariablesNames)}
+
+	e.variables.mu.Lock()
+	defer e.variables.mu.Unlock()
+	variables := e.variables.bySession[sessionID]
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		if like == "" || likePattern(like).MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		variable := variables[name]
+		result.Rows = append(result.Rows, []interface{}{
+			sessionID, variable.CreatedOn, variable.UpdatedOn, name, variableText(variable), strings.ToUpper(variable.Type.Type), "",
+		})
+	}
+	return result
+}
+
+// SessionVariables returns the values of the SQL variables a session set, by
+// name.
+func (e *Executor) SessionVariables(sessionID string) map[string]interface{} {
+	e.variables.mu.Lock()
+	defer e.variables.mu.Unlock()
+	values := make(map[string]interface{}, len(e.variables.bySession[sessionID]))
+	for name, variable := range e.variables.bySession[sessionID] {
+		values[name] = variable.Value
+	}
+	return values
+}
+
+// variableText renders a variable's value as SHOW VARIABLES lists it.
+func variableText(variable sessionVariable) interface{} {
+	switch v := variable.Value.(type) {
+	case nil:
+		return nil
+	case string:
+		return v
+	case time.Time:
+		switch variable.Type.Type {
+		case "date":
+			return v.Format("2006-01-02")
+		case "time":
+			return v.Format("15:04:05.999999999")
+		case "timestamp_ltz", "timestamp_tz":
+			return v.Format("2006-01-02 15:04:05.999999999 -07:00")
+		default:
+			return v.Format("2006-01-02 15:04:05.999999999")
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// showParameters lists the session parameters the emulator knows, with their
+// defaults, and those the session set, ordered by name. IN ACCOUNT lists the
+// defaults.
+func showParameters(ctx context.Context, stmt *showSessionStatement) *Result {
+	defaults := config.DefaultSessionParameters()
+	values := make(map[string]string, len(defaults))
+	if stmt.Scope == "SESSION" {
+		for name, value := range SessionInfoFromContext(ctx).Parameters {
+			values[strings.ToUpper(name)] = value
+		}
+	}
+	keys := make([]string, 0, len(defaults)+len(values))
+	for name := range defaults {
+		keys = append(keys, string(name))
+	}
+	for name := range values {
+		if _, ok := defaults[config.SessionParameter(name)]; !ok {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+
+	result := &Result{Columns: showParametersNames, ColumnTypes: showSessionColumnTypes(showParametersNames)}
+	for _, key := range keys {
+		if stmt.Like != "" && !likePattern(stmt.Like).MatchString(key) {
+			continue
+		}
+		def, known := defaults[config.SessionParameter(key)]
+		value, level := def, ""
+		if set, ok := values[key]; ok {
+			value, level = set, stmt.Scope
+		}
+		typeSample := def
+		if !known {
+			typeSample = value
+		}
+		result.Rows = append(result.Rows, []interface{}{key, value, def, level, "", parameterType(typeSample)})
+	}
+	return result
+}
+
+// parameterType returns the type SHOW PARAMETERS reports for a parameter
+// with the given value.
+func parameterType(value string) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return "NUMBER"
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return "BOOLEAN"
+	}
+	return "STRING"
+}
+
+// showSessionColumnTypes returns the column types of a SHOW VARIABLES or SHOW
+// PARAMETERS result.
+func showSessionColumnTypes(names []string) []types.ColumnMetadata {
+	columnTypes := make([]types.ColumnMetadata, len(names))
+	for i, name := range names {
+		col := types.ColumnMetadata{Name: name, Type: "text", Nullable: true}
+		if name == "created_on" || name == "updated_on" {
+			col.Type = "timestamp_ltz"
+		}
+		columnTypes[i] = col
+	}
+	return columnTypes
+}
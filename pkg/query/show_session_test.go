@@ -0,0 +1,131 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseShowSessionStatement tests parsing of SHOW VARIABLES and SHOW PARAMETERS.
+func TestParseShowSessionStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *showSessionStatement
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "Variables", sql: "SHOW VARIABLES", want: &showSessionStatement{Object: "VARIABLES", Scope: "SESSION"}, wantOK: true},
+		{name: "VariablesLike", sql: "show variables like 'min%';", want: &showSessionStatement{Object: "VARIABLES", Like: "min%", Scope: "SESSION"}, wantOK: true},
+		{name: "ParametersInSession", sql: "SHOW PARAMETERS LIKE '%FORMAT%' IN SESSION", want: &showSessionStatement{Object: "PARAMETERS", Like: "%FORMAT%", Scope: "SESSION"}, wantOK: true},
+		{name: "ParametersInAccount", sql: "SHOW PARAMETERS IN ACCOUNT", want: &showSessionStatement{Object: "PARAMETERS", Scope: "ACCOUNT"}, wantOK: true},
+		{name: "ParametersInTable", sql: "SHOW PARAMETERS IN TABLE t", wantOK: true, wantErr: true},
+		{name: "Tables", sql: "SHOW TABLES", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseShowSessionStatement(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseShowSessionStatement(%q) ok = %v, err = %v, want ok = %v, wantErr %v", tt.sql, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseShowSessionStatement() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_ShowVariablesAndParameters tests listing the SQL variables and
+// parameters of a session.
+func TestExecutor_ShowVariablesAndParameters(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{
+		ID:         "1",
+		Parameters: map[string]string{"week_start": "1", "QUERY_TAG": "nightly"},
+	})
+	other := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "2"})
+
+	for _, sql := range []string{"SET min_id = 2", "SET (label, enabled) = ('x', TRUE)"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		sql     string
+		columns []int
+		want    [][]interface{}
+	}{
+		{
+			name:    "Variables",
+			ctx:     ctx,
+			sql:     "SHOW VARIABLES",
+			columns: []int{0, 3, 4, 5},
+			want: [][]interface{}{
+				{"1", "ENABLED", "true", "BOOLEAN"},
+				{"1", "LABEL", "x", "TEXT"},
+				{"1", "MIN_ID", "2", "FIXED"},
+			},
+		},
+		{
+			name:    "VariablesLike",
+			ctx:     ctx,
+			sql:     "SHOW VARIABLES LIKE 'min%'",
+			columns: []int{3, 4},
+			want:    [][]interface{}{{"MIN_ID", "2"}},
+		},
+		{
+			name: "VariablesOfAnotherSession",
+			ctx:  other,
+			sql:  "SHOW VARIABLES",
+		},
+		{
+			name:    "ParametersInSession",
+			ctx:     ctx,
+			sql:     "SHOW PARAMETERS LIKE 'week%' IN SESSION",
+			columns: []int{0, 1, 2, 3, 5},
+			want: [][]interface{}{
+				{"WEEK_OF_YEAR_POLICY", "0", "0", "", "NUMBER"},
+				{"WEEK_START", "1", "0", "SESSION", "NUMBER"},
+			},
+		},
+		{
+			name:    "ParametersSetBySession",
+			ctx:     ctx,
+			sql:     "SHOW PARAMETERS LIKE 'QUERY_TAG'",
+			columns: []int{0, 1, 2, 3, 5},
+			want:    [][]interface{}{{"QUERY_TAG", "nightly", "", "SESSION", "STRING"}},
+		},
+		{
+			name:    "ParametersInAccount",
+			ctx:     ctx,
+			sql:     "SHOW PARAMETERS LIKE 'autocommit' IN ACCOUNT",
+			columns: []int{0, 1, 3, 5},
+			want:    [][]interface{}{{"AUTOCOMMIT", "true", "", "BOOLEAN"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(tt.ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			var got [][]interface{}
+			for _, row := range result.Rows {
+				values := make([]interface{}, len(tt.columns))
+				for i, column := range tt.columns {
+					values[i] = row[column]
+				}
+				got = append(got, values)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
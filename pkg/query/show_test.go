@@ -0,0 +1,371 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
+)
+
+// TestParseShowStatement tests parsing SHOW statements listing databases,
+// schemas, tables, and warehouses.
+func TestParseShowStatement(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected *showStatement
+		wantOK   bool
+		wantErr  bool
+	}{
+		{
+			name:     "Databases",
+			sql:      "SHOW DATABASES",
+			expected: &showStatement{Object: "DATABASES"},
+			wantOK:   true,
+		},
+		{
+			name:     "TerseSchemasInDatabase",
+			sql:      "show terse schemas in database analytics;",
+			expected: &showStatement{Object: "SCHEMAS", Terse: true, Scope: "DATABASE", Name: "analytics"},
+			wantOK:   true,
+		},
+		{
+			name:     "SchemasInName",
+			sql:      `SHOW SCHEMAS IN "Analytics"`,
+			expected: &showStatement{Object: "SCHEMAS", Scope: "DATABASE", Name: `"Analytics"`},
+			wantOK:   true,
+		},
+		{
+			name: "TablesAllClauses",
+			sql:  "SHOW TABLES LIKE '%ORDER%' IN SCHEMA analytics.sales STARTS WITH 'O' LIMIT 10",
+			expected: &showStatement{
+				Object: "TABLES", Like: "%ORDER%", Scope: "SCHEMA", Name: "analytics.sales", StartsWith: "O", Limit: 10,
+			},
+			wantOK: true,
+		},
+		{
+			name:     "TablesInCurrentSchema",
+			sql:      "SHOW TABLES IN SCHEMA LIMIT 5",
+			expected: &showStatement{Object: "TABLES", Scope: "SCHEMA", Limit: 5},
+			wantOK:   true,
+		},
+		{
+			name:     "ViewsInName",
+			sql:      "SHOW VIEWS IN analytics.sales",
+			expected: &showStatement{Object: "VIEWS", Scope: "SCHEMA", Name: "analytics.sales"},
+			wantOK:   true,
+		},
+		{
+			name:     "TablesInAccount",
+			sql:      "SHOW TABLES IN ACCOUNT",
+			expected: &showStatement{Object: "TABLES", Scope: "ACCOUNT"},
+			wantOK:   true,
+		},
+		{
+			name:     "Warehouses",
+			sql:      "SHOW WAREHOUSES LIKE 'COMPUTE%'",
+			expected: &showStatement{Object: "WAREHOUSES", Like: "COMPUTE%"},
+			wantOK:   true,
+		},
+		{
+			name:     "FileFormatsInSchema",
+			sql:      "SHOW FILE  FORMATS LIKE 'CSV%' IN SCHEMA s",
+			expected: &showStatement{Object: "FILE FORMATS", Like: "CSV%", Scope: "SCHEMA", Name: "s"},
+			wantOK:   true,
+		},
+		{
+			name:     "UserFunctions",
+			sql:      "SHOW USER FUNCTIONS IN analytics.sales",
+			expected: &showStatement{Object: "USER FUNCTIONS", Scope: "SCHEMA", Name: "analytics.sales"},
+			wantOK:   true,
+		},
+		{name: "DatabasesInSchema", sql: "SHOW DATABASES IN SCHEMA s", wantOK: true, wantErr: true},
+		{name: "InvalidLimit", sql: "SHOW TABLES LIMIT x", wantOK: true, wantErr: true},
+		{name: "TrailingWords", sql: "SHOW TABLES HISTORY", wantOK: true, wantErr: true},
+		{name: "TerseWarehouses", sql: "SHOW TERSE WAREHOUSES", wantOK: true, wantErr: true},
+		{name: "Columns", sql: "SHOW COLUMNS IN TABLE t", wantOK: false},
+		{name: "Alerts", sql: "SHOW ALERTS", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseShowStatement(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseShowStatement() ok, err = %v, %v, want %v, error %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.expected, got); diff != "" {
+				t.Errorf("parseShowStatement() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_Show tests listing databases, schemas, tables, columns, and
+// warehouses from the metadata store.
+func TestExecutor_Show(t *testing.T) {
+	warehouses := warehouse.NewManager()
+	executor, _ := setupTestExecutor(t, WithWarehouseManager(warehouses))
+	// Objects are owned by the session's role, as the handlers record it
+	ctx := ContextWithSessionInfo(metadata.ContextWithOwner(context.Background(), "SYSADMIN"), SessionInfo{Database: "SALES_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE DATABASE sales_db",
+		"CREATE DATABASE other_db COMMENT = 'other'",
+		"CREATE SCHEMA sales_db.staging",
+		"CREATE TABLE customers (id INTEGER, name VARCHAR)",
+		"CREATE TABLE orders (id INTEGER, amount NUMBER(10,2))",
+		"CREATE TABLE staging.raw_orders (payload VARCHAR)",
+		"INSERT INTO orders VALUES (1, 10.5), (2, 20)",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := warehouses.CreateWarehouse(ctx, "compute_wh", "X-SMALL", "default warehouse"); err != nil {
+		t.Fatalf("CreateWarehouse() error = %v", err)
+	}
+
+	// column projects a column of a SHOW result by name
+	column := func(result *Result, name string) []interface{} {
+		t.Helper()
+		index := -1
+		for i, col := range result.Columns {
+			if col == name {
+				index = i
+			}
+		}
+		if index < 0 {
+			t.Fatalf("result has no column %s: %v", name, result.Columns)
+		}
+		values := []interface{}{}
+		for _, row := range result.Rows {
+			values = append(values, row[index])
+		}
+		return values
+	}
+
+	tests := []struct {
+		name   string
+		sql    string
+		column string
+		want   []interface{}
+	}{
+		{name: "Databases", sql: "SHOW DATABASES", column: "name", want: []interface{}{"OTHER_DB", "SALES_DB"}},
+		{name: "CurrentDatabase", sql: "SHOW DATABASES", column: "is_current", want: []interface{}{"N", "Y"}},
+		{name: "DatabasesLike", sql: "SHOW DATABASES LIKE 'sales%'", column: "name", want: []interface{}{"SALES_DB"}},
+		{
+			name: "Schemas", sql: "SHOW SCHEMAS", column: "name",
+			want: []interface{}{"INFORMATION_SCHEMA", "PUBLIC", "STAGING"},
+		},
+		{name: "SchemasInDatabase", sql: "SHOW TERSE SCHEMAS IN DATABASE other_db", column: "name", want: []interface{}{"INFORMATION_SCHEMA", "PUBLIC"}},
+		{
+			name: "Tables", sql: "SHOW TABLES", column: "name",
+			want: []interface{}{"customers", "orders", "raw_orders"},
+		},
+		{name: "TablesSchemaName", sql: "SHOW TABLES", column: "schema_name", want: []interface{}{"PUBLIC", "PUBLIC", "STAGING"}},
+		{name: "TablesInSchema", sql: "SHOW TABLES IN SCHEMA staging", column: "name", want: []interface{}{"raw_orders"}},
+		{name: "TablesInQualifiedSchema", sql: "SHOW TERSE TABLES IN sales_db.public LIMIT 1", column: "name", want: []interface{}{"customers"}},
+		{name: "TablesStartsWith", sql: "SHOW TABLES STARTS WITH 'o'", column: "name", want: []interface{}{"orders"}},
+		{name: "TableRows", sql: "SHOW TABLES LIKE 'orders'", column: "rows", want: []interface{}{int64(2)}},
+		{name: "TablesOwner", sql: "SHOW TABLES", column: "owner", want: []interface{}{"SYSADMIN", "SYSADMIN", "SYSADMIN"}},
+		{
+			name: "ColumnsInSchema", sql: "SHOW COLUMNS IN SCHEMA public", column: "column_name",
+			want: []interface{}{"id", "name", "id", "amount"},
+		},
+		{name: "ColumnsInDatabase", sql: "SHOW COLUMNS LIKE 'payload' IN DATABASE", column: "table_name", want: []interface{}{"RAW_ORDERS"}},
+		{name: "Warehouses", sql: "SHOW WAREHOUSES", column: "name", want: []interface{}{"COMPUTE_WH"}},
+		{name: "WarehouseState", sql: "SHOW WAREHOUSES", column: "state", want: []interface{}{"SUSPENDED"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if len(result.Columns) != len(result.ColumnTypes) {
+				t.Errorf("Query(%q) has %d columns and %d column types", tt.sql, len(result.Columns), len(result.ColumnTypes))
+			}
+			if diff := cmp.Diff(tt.want, column(result, tt.column)); diff != "" {
+				t.Errorf("Query(%q) %s mismatch (-want +got):\n%s", tt.sql, tt.column, diff)
+			}
+		})
+	}
+
+	// Tables created with SQL have the creation time INFORMATION_SCHEMA.TABLES reports
+	for _, sql := range []string{"SHOW TABLES LIKE 'orders'", "SHOW TERSE TABLES LIKE 'orders'"} {
+		shown, err := executor.Query(ctx, sql)
+		if err != nil {
+			t.Fatalf("Query(%q) error = %v", sql, err)
+		}
+		created, err := executor.Query(ctx, "SELECT CREATED FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_NAME = 'orders'")
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		createdOn, ok := column(shown, "created_on")[0].(time.Time)
+		if want, _ := created.Rows[0][0].(time.Time); !ok || createdOn.IsZero() || !createdOn.Equal(want) {
+			t.Errorf("Query(%q) created_on = %v, want %v", sql, column(shown, "created_on")[0], created.Rows[0][0])
+		}
+	}
+
+	errorTests := []string{
+		"SHOW TABLES IN SCHEMA missing",
+		"SHOW SCHEMAS IN DATABASE missing",
+	}
+	for _, sql := range errorTests {
+		if _, err := executor.Query(ctx, sql); err == nil {
+			t.Errorf("Query(%q) error = nil, want error", sql)
+		}
+	}
+}
+
+// TestExecutor_ShowObjects tests listing and describing the objects of schemas
+// other than tables and views.
+func TestExecutor_ShowObjects(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	executor.Configure(WithStageManager(stage.NewManager(repo, t.TempDir())))
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{Database: "SALES_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE DATABASE sales_db",
+		"CREATE SCHEMA sales_db.staging",
+		"CREATE PROCEDURE add_one(n NUMBER, step NUMBER DEFAULT 1) RETURNS NUMBER LANGUAGE SQL AS 'BEGIN RETURN n + step; END'",
+		"CREATE PROCEDURE staging.load() RETURNS VARCHAR LANGUAGE SQL AS 'BEGIN RETURN 1; END'",
+		`CREATE DATA METRIC FUNCTION positive_scores(arg_t TABLE(arg_score NUMBER))
+		RETURNS NUMBER AS $$ SELECT COUNT_IF(arg_score > 0) FROM arg_t $$`,
+		"CREATE STAGE raw COMMENT = 'landing files'",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	db, err := repo.GetDatabaseByName(ctx, "SALES_DB")
+	if err != nil {
+		t.Fatalf("GetDatabaseByName() error = %v", err)
+	}
+	public, err := repo.GetSchemaByName(ctx, db.ID, "PUBLIC")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	if _, err := repo.CreateFileFormat(ctx, public.ID, "CSV_FORMAT", "csv", `{"delimiter":","}`, ""); err != nil {
+		t.Fatalf("CreateFileFormat() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sql     string
+		columns []string
+		want    [][]interface{}
+	}{
+		{
+			name: "Procedures", sql: "SHOW PROCEDURES", columns: []string{"name", "schema_name", "min_num_arguments", "arguments"},
+			want: [][]interface{}{
+				{"ADD_ONE", "PUBLIC", int64(1), "ADD_ONE(NUMBER, NUMBER) RETURN NUMBER"},
+				{"LOAD", "STAGING", int64(0), "LOAD() RETURN VARCHAR"},
+			},
+		},
+		{
+			name: "ProceduresInSchema", sql: "SHOW TERSE PROCEDURES IN SCHEMA staging", columns: []string{"name", "kind", "schema_name"},
+			want: [][]interface{}{{"LOAD", "PROCEDURE", "STAGING"}},
+		},
+		{
+			name: "UserFunctions", sql: "SHOW USER FUNCTIONS LIKE 'positive%'", columns: []string{"name", "is_data_metric"},
+			want: [][]interface{}{{"POSITIVE_SCORES", "Y"}},
+		},
+		{
+			name: "FileFormats", sql: "SHOW FILE FORMATS IN SCHEMA sales_db.public", columns: []string{"name", "type", "format_options"},
+			want: [][]interface{}{{"CSV_FORMAT", "CSV", `{"delimiter":","}`}},
+		},
+		{name: "Tasks", sql: "SHOW TASKS", columns: []string{"name", "schedule"}},
+		{name: "Pipes", sql: "SHOW PIPES IN DATABASE sales_db", columns: []string{"name", "definition"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if len(result.Columns) != len(result.ColumnTypes) {
+				t.Errorf("Query(%q) has %d columns and %d column types", tt.sql, len(result.Columns), len(result.ColumnTypes))
+			}
+			var got [][]interface{}
+			for _, row := range result.Rows {
+				projected := make([]interface{}, len(tt.columns))
+				for i, name := range tt.columns {
+					index := -1
+					for j, col := range result.Columns {
+						if col == name {
+							index = j
+						}
+					}
+					if index < 0 {
+						t.Fatalf("Query(%q) has no column %s: %v", tt.sql, name, result.Columns)
+					}
+					projected[i] = row[index]
+				}
+				got = append(got, projected)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+
+	describeTests := []struct {
+		name string
+		sql  string
+		// want are some of the described properties and their values.
+		want map[string]interface{}
+	}{
+		{
+			name: "Procedure", sql: "DESCRIBE PROCEDURE add_one(NUMBER, NUMBER)",
+			want: map[string]interface{}{"name": "ADD_ONE", "max_num_arguments": "2", "catalog_name": "SALES_DB", "secrets": nil},
+		},
+		{
+			name: "Stage", sql: "DESC STAGE sales_db.public.raw",
+			want: map[string]interface{}{"name": "RAW", "comment": "landing files", "type": "INTERNAL"},
+		},
+		{name: "Schema", sql: "DESC SCHEMA staging", want: map[string]interface{}{"name": "STAGING", "database_name": "SALES_DB"}},
+		{name: "FileFormat", sql: "DESC FILE FORMAT csv_format", want: map[string]interface{}{"type": "CSV"}},
+	}
+	for _, tt := range describeTests {
+		t.Run("Describe"+tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if diff := cmp.Diff([]string{"property", "value"}, result.Columns); diff != "" {
+				t.Errorf("Query(%q) columns mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+			got := map[string]interface{}{}
+			for _, row := range result.Rows {
+				if _, ok := tt.want[row[0].(string)]; ok {
+					got[row[0].(string)] = row[1]
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+
+	errorTests := []string{
+		"SHOW PROCEDURES IN SCHEMA missing",
+		"SHOW TASKS IN DATABASE missing",
+		"DESCRIBE STAGE missing",
+		"DESC FILE FORMAT",
+	}
+	for _, sql := range errorTests {
+		if _, err := executor.Query(ctx, sql); err == nil {
+			t.Errorf("Query(%q) error = nil, want error", sql)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrStageNotFound is returned by the stage file methods for a stage that does
+// not exist.
+var ErrStageNotFound = errors.New("stage does not exist or not authorized")
+
+// StageFile is a file of an internal stage, as LIST reports it.
+type StageFile struct {
+	// Name is the stage's name in lower case followed by the file's path.
+	Name string
+	Size int64
+	// MD5 is the hex MD5 digest of the file's contents.
+	MD5          string
+	LastModified time.Time
+}
+
+// ListStageFiles lists the files of an internal stage like LIST does. A
+// non-empty pattern is a regular expression the listed names must match in full.
+func (e *Executor) ListStageFiles(ctx context.Context, database, schema, stageName, pattern string) ([]StageFile, error) {
+	schemaID, name, err := e.resourceStage(ctx, database, schema, stageName)
+	if err != nil {
+		return nil, err
+	}
+	var re *regexp.Regexp
+	if pattern != "" {
+		if re, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return e.stageFiles(ctx, schemaID, name, "", re)
+}
+
+// PutStageFile writes data to a file of an internal stage at path, replacing
+// the file if it exists, and returns the file as LIST reports it.
+func (e *Executor) PutStageFile(ctx context.Context, database, schema, stageName, path string, data io.Reader) (*StageFile, error) {
+	schemaID, name, err := e.resourceStage(ctx, database, schema, stageName)
+	if err != nil {
+		return nil, err
+	}
+	path = strings.TrimPrefix(path, "/")
+	if err := e.stages.PutFile(ctx, schemaID, name, path, data); err != nil {
+		return nil, err
+	}
+
+	files, err := e.stageFiles(ctx, schemaID, name, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	listedName := strings.ToLower(name) + "/" + path
+	for i := range files {
+		if files[i].Name == listedName {
+			return &files[i], nil
+		}
+	}
+	return nil, fmt.Errorf("file %s was not written to stage %s", path, name)
+}
+
+// GetStageFile opens the file at path of an internal stage for reading.
+func (e *Executor) GetStageFile(ctx context.Context, database, schema, stageName, path string) (io.ReadCloser, error) {
+	schemaID, name, err := e.resourceStage(ctx, database, schema, stageName)
+	if err != nil {
+		return nil, err
+	}
+	return e.stages.GetFile(ctx, schemaID, name, strings.TrimPrefix(path, "/"))
+}
+
+// RemoveStageFile removes the file at path from an internal stage.
+func (e *Executor) RemoveStageFile(ctx context.Context, database, schema, stageName, path string) error {
+	schemaID, name, err := e.resourceStage(ctx, database, schema, stageName)
+	if err != nil {
+		return err
+	}
+	return e.stages.RemoveFile(ctx, schemaID, name, strings.TrimPrefix(path, "/"))
+}
+
+// resourceStage returns the schema ID and registered name of a stage of the
+// REST API's resource paths.
+func (e *Executor) resourceStage(ctx context.Context, database, schema, stageName string) (string, string, error) {
+	if e.stages == nil {
+		return "", "", fmt.Errorf("stage manager not configured")
+	}
+	notFound := fmt.Errorf("%w: %s.%s.%s", ErrStageNotFound, database, schema, stageName)
+	db, err := e.repo.GetDatabaseByName(ctx, database)
+	if err != nil {
+		return "", "", notFound
+	}
+	sch, err := e.repo.GetSchemaByName(ctx, db.ID, schema)
+	if err != nil {
+		return "", "", notFound
+	}
+	stageObj, err := e.stages.GetStage(ctx, sch.ID, stageName)
+	if err != nil {
+		return "", "", notFound
+	}
+	return sch.ID, stageObj.Name, nil
+}
@@ -0,0 +1,341 @@
+package query
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // LIST reports MD5 digests, as Snowflake does
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Columns of the SHOW STAGES and LIST results, as Snowflake returns them.
+var (
+	showStagesNames = []string{
+		"created_on", "name", "database_name", "schema_name", "url", "has_credentials", "has_encryption_key",
+		"owner", "comment", "region", "type", "cloud", "notification_channel", "storage_integration",
+		"endpoint", "owner_role_type", "directory_enabled",
+	}
+	listStageNames = []string{"name", "size", "md5", "last_modified"}
+)
+
+// WithStageManager sets the stage manager of CREATE STAGE, DROP STAGE, and
+// LIST. The COPY processor should share it.
+func WithStageManager(mgr *stage.Manager) ExecutorOption {
+	return func(e *Executor) {
+		e.stages = mgr
+	}
+}
+
+// stageType returns the type of a stage created with the given properties:
+// EXTERNAL with a URL, INTERNAL otherwise.
+func stageType(properties map[string]string) string {
+	if properties["URL"] != "" {
+		return "EXTERNAL"
+	}
+	return "INTERNAL"
+}
+
+// objectSchema resolves a possibly qualified object name, such as a stage's,
+// against the session's current database and schema. It returns the object's
+// registered schema and its unqualified name. A database's PUBLIC schema is
+// registered on first use when register is set, since every database has one.
+func (e *Executor) objectSchema(ctx context.Context, name string, register bool) (*metadata.Schema, string, error) {
+	database, schemaName, objectName := splitObjectName(ctx, name)
+	if database == "" || schemaName == "" {
+		return nil, "", fmt.Errorf("cannot resolve %s: this session does not have a current database and schema", name)
+	}
+	db, err := e.repo.GetDatabaseByName(ctx, database)
+	if err != nil {
+		return nil, "", fmt.Errorf("database '%s' does not exist or not authorized", strings.ToUpper(database))
+	}
+	schema, err := e.repo.GetSchemaByName(ctx, db.ID, schemaName)
+	if err != nil && register && strings.EqualFold(schemaName, publicSchema) {
+		schema, err = e.repo.CreateSchema(ctx, db.ID, publicSchema, "")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("schema '%s.%s' does not exist or not authorized", db.Name, strings.ToUpper(schemaName))
+	}
+	return schema, objectName, nil
+}
+
+// executeCreateStage creates a stage with the URL and comment of a CREATE
+// STAGE statement. Stages without a URL are internal, and keep their files in
+// the stage manager's directory. Other properties, such as FILE_FORMAT, are
+// accepted and ignored.
+func (e *Executor) executeCreateStage(ctx context.Context, stmt *createStatement) (*ExecResult, error) {
+	if e.stages == nil {
+		return nil, fmt.Errorf("stage manager not configured")
+	}
+	properties, err := createProperties(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("CREATE STAGE %s: %w", stmt.Name, err)
+	}
+	schema, name, err := e.objectSchema(ctx, stmt.Name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := e.stages.GetStage(ctx, schema.ID, name); err == nil {
+		switch {
+		case stmt.IfNotExists:
+			return &ExecResult{}, nil
+		case !stmt.OrReplace:
+			return nil, fmt.Errorf("stage %s already exists", existing.Name)
+		}
+		if err := e.stages.DropStage(ctx, schema.ID, existing.Name); err != nil {
+			return nil, fmt.Errorf("failed to replace stage %s: %w", existing.Name, err)
+		}
+	}
+
+	comment := ""
+	if stmt.Comment != nil {
+		comment = *stmt.Comment
+	}
+	if _, err := e.stages.CreateStage(ctx, schema.ID, name, stageType(properties), properties["URL"], comment); err != nil {
+		return nil, fmt.Errorf("create stage execution error: %w", err)
+	}
+	return &ExecResult{}, nil
+}
+
+// dropStageName returns the name of the stage a DROP STAGE statement drops,
+// and whether it has IF EXISTS.
+func dropStageName(sql string) (name string, ifExists, ok bool) {
+	fields := strings.Fields(strings.TrimRight(stripLeadingComments(sql), "; \t\r\n"))
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "DROP") || !strings.EqualFold(fields[1], "STAGE") {
+		return "", false, false
+	}
+	fields = fields[2:]
+	if len(fields) >= 3 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") {
+		ifExists = true
+		fields = fields[2:]
+	}
+	return fields[0], ifExists, true
+}
+
+// executeDropStage drops a stage and the files of an internal stage.
+func (e *Executor) executeDropStage(ctx context.Context, name string, ifExists bool) (*ExecResult, error) {
+	if e.stages == nil {
+		return nil, fmt.Errorf("stage manager not configured")
+	}
+	schema, stageName, err := e.objectSchema(ctx, name, false)
+	if err == nil {
+		_, err = e.stages.GetStage(ctx, schema.ID, stageName)
+	}
+	if err != nil {
+		if ifExists {
+			return &ExecResult{}, nil
+		}
+		return nil, fmt.Errorf("stage '%s' does not exist or not authorized", strings.ToUpper(name))
+	}
+	if err := e.stages.DropStage(ctx, schema.ID, stageName); err != nil {
+		return nil, fmt.Errorf("drop stage execution error: %w", err)
+	}
+	return &ExecResult{}, nil
+}
+
+// showStages lists the stages of the schemas of a SHOW STAGES statement.
+func (e *Executor) showStages(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	databases, err := e.scopeDatabases(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	var rows []showRow
+	for _, db := range databases {
+		schemaNames, err := e.scopeSchemas(ctx, stmt, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, schemaName := range schemaNames {
+			schema, err := e.repo.GetSchemaByName(ctx, db.ID, schemaName)
+			if err != nil {
+				continue
+			}
+			stages, err := e.repo.ListStages(ctx, schema.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range stages {
+				kind := strings.ToUpper(s.StageType)
+				cloud := interface{}(nil)
+				if kind == "EXTERNAL" {
+					cloud = stageCloud(s.URL)
+				}
+				rows = append(rows, showRow{
+					Database:  db.Name,
+					Schema:    schema.Name,
+					Name:      s.Name,
+					Kind:      kind,
+					CreatedOn: s.CreatedAt,
+					Values: []interface{}{
+						s.CreatedAt, s.Name, db.Name, schema.Name, s.URL, "N", "N", s.Owner, s.Comment, nil, kind, cloud,
+						nil, nil, nil, "ROLE", "N",
+					},
+				})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// stageCloud returns the cloud SHOW STAGES reports for an external stage URL.
+func stageCloud(url string) interface{} {
+	switch {
+	case strings.HasPrefix(strings.ToLower(url), "s3://"):
+		return "AWS"
+	case strings.HasPrefix(strings.ToLower(url), "gcs://"):
+		return "GCP"
+	case strings.HasPrefix(strings.ToLower(url), "azure://"):
+		return "AZURE"
+	}
+	return nil
+}
+
+// listStatement is a parsed LIST @stage[/path] [PATTERN = '...'].
+type listStatement struct {
+	// Stage is the stage name as written, without the @.
+	Stage string
+	// Path is the prefix of the listed files' paths, or "" for all files.
+	Path    string
+	Pattern string
+}
+
+// parseListStatement parses a LIST or LS statement. It reports false for
+// other statements.
+func parseListStatement(sql string) (*listStatement, bool, error) {
+	keywords := statementKeywords(sql)
+	if len(keywords) == 0 || (keywords[0] != "LIST" && keywords[0] != "LS") {
+		return nil, false, nil
+	}
+	s := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+	rest := strings.TrimSpace(s[len(keywords[0]):])
+	if !strings.HasPrefix(rest, "@") {
+		return nil, true, fmt.Errorf("%s: expected @stage", keywords[0])
+	}
+	if strings.HasPrefix(rest, "@~") || strings.HasPrefix(rest, "@%") {
+		return nil, true, fmt.Errorf("%s: user and table stages are not supported", keywords[0])
+	}
+
+	parts, end := objectNameParts(rest, 1)
+	if len(parts) == 0 {
+		return nil, true, fmt.Errorf("%s: expected @stage", keywords[0])
+	}
+	stmt := &listStatement{Stage: rest[1:end]}
+	rest = rest[end:]
+	if strings.HasPrefix(rest, "/") {
+		pathEnd := strings.IndexAny(rest, " \t\r\n")
+		if pathEnd < 0 {
+			pathEnd = len(rest)
+		}
+		stmt.Path, rest = strings.TrimPrefix(rest[:pathEnd], "/"), rest[pathEnd:]
+	}
+
+	properties, err := objectProperties(rest)
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", keywords[0], err)
+	}
+	for name, value := range properties {
+		if name != "PATTERN" {
+			return nil, true, fmt.Errorf("%s: unexpected %s", keywords[0], name)
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return nil, true, fmt.Errorf("%s: invalid PATTERN: %w", keywords[0], err)
+		}
+		stmt.Pattern = value
+	}
+	return stmt, true, nil
+}
+
+// queryList lists the files of an internal stage, with their sizes, MD5
+// digests, and modification times. Names are the stage's name in lower case
+// followed by the file's path, which the PATTERN regular expression must match
+// in full.
+func (e *Executor) queryList(ctx context.Context, stmt *listStatement) (*Result, error) {
+	if e.stages == nil {
+		return nil, fmt.Errorf("stage manager not configured")
+	}
+	schema, name, err := e.objectSchema(ctx, stmt.Stage, false)
+	if err != nil {
+		return nil, err
+	}
+	stageObj, err := e.stages.GetStage(ctx, schema.ID, name)
+	if err != nil {
+		return nil, fmt.Errorf("stage '%s' does not exist or not authorized", strings.ToUpper(stmt.Stage))
+	}
+	var pattern *regexp.Regexp
+	if stmt.Pattern != "" {
+		pattern = regexp.MustCompile("^(?:" + stmt.Pattern + ")$")
+	}
+	files, err := e.stageFiles(ctx, schema.ID, stageObj.Name, stmt.Path, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Columns: listStageNames, ColumnTypes: listStageColumnTypes()}
+	for _, file := range files {
+		result.Rows = append(result.Rows, []interface{}{
+			file.Name, file.Size, file.MD5, file.LastModified.UTC().Format(http.TimeFormat),
+		})
+	}
+	return result, nil
+}
+
+// stageFiles returns the files of an internal stage whose paths start with
+// path and, named as LIST names them, match pattern if it is set.
+func (e *Executor) stageFiles(ctx context.Context, schemaID, stageName, path string, pattern *regexp.Regexp) ([]StageFile, error) {
+	files, err := e.stages.ListFiles(ctx, schemaID, stageName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var listed []StageFile
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name, path) {
+			continue
+		}
+		fileName := strings.ToLower(stageName) + "/" + file.Name
+		if pattern != nil && !pattern.MatchString(fileName) {
+			continue
+		}
+		digest, err := e.stageFileMD5(ctx, schemaID, stageName, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, StageFile{Name: fileName, Size: file.Size, MD5: digest, LastModified: file.ModifiedTime})
+	}
+	return listed, nil
+}
+
+// stageFileMD5 returns the hex MD5 digest of a staged file.
+func (e *Executor) stageFileMD5(ctx context.Context, schemaID, stageName, fileName string) (string, error) {
+	reader, err := e.stages.GetFile(ctx, schemaID, stageName, fileName)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = reader.Close() }()
+
+	hash := md5.New() //nolint:gosec // LIST reports MD5 digests, as Snowflake does
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// listStageColumnTypes returns the column types of a LIST result.
+func listStageColumnTypes() []types.ColumnMetadata {
+	columnTypes := make([]types.ColumnMetadata, len(listStageNames))
+	for i, name := range listStageNames {
+		col := types.ColumnMetadata{Name: name, Type: "text", Nullable: true}
+		if name == "size" {
+			col.Type, col.Precision = "fixed", 38
+		}
+		columnTypes[i] = col
+	}
+	return columnTypes
+}
@@ -0,0 +1,157 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+)
+
+// TestParseListStatement tests parsing of LIST and LS statements.
+func TestParseListStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *listStatement
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "Stage", sql: "LIST @my_stage", want: &listStatement{Stage: "my_stage"}, wantOK: true},
+		{name: "QualifiedWithPath", sql: "ls @db.public.my_stage/2024/01;", want: &listStatement{Stage: "db.public.my_stage", Path: "2024/01"}, wantOK: true},
+		{name: "Pattern", sql: "LIST @s PATTERN = '.*[.]csv'", want: &listStatement{Stage: "s", Pattern: ".*[.]csv"}, wantOK: true},
+		{name: "UserStage", sql: "LIST @~", wantOK: true, wantErr: true},
+		{name: "NoStage", sql: "LIST my_stage", wantOK: true, wantErr: true},
+		{name: "InvalidPattern", sql: "LIST @s PATTERN = '('", wantOK: true, wantErr: true},
+		{name: "Select", sql: "SELECT 1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseListStatement(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseListStatement(%q) ok = %v, err = %v, want ok = %v, wantErr %v", tt.sql, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseListStatement() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_Stages tests creating, listing, and dropping stages with SQL.
+func TestExecutor_Stages(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	stageMgr := stage.NewManager(repo, t.TempDir())
+	executor.Configure(WithStageManager(stageMgr))
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1", Database: "STAGE_DB", Schema: "PUBLIC"})
+
+	db, err := repo.CreateDatabase(ctx, "STAGE_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for _, sql := range []string{
+		"CREATE STAGE raw FILE_FORMAT = (TYPE = CSV) COMMENT = 'landing files'",
+		"CREATE STAGE IF NOT EXISTS raw",
+		"CREATE OR REPLACE STAGE stage_db.public.ext URL = 's3://bucket/path/'",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Execute(ctx, "CREATE STAGE raw"); err == nil {
+		t.Error("Expected an error creating an existing stage")
+	}
+
+	schema, err := repo.GetSchemaByName(ctx, db.ID, "PUBLIC")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	for name, data := range map[string]string{"2024/a.csv": "id\n1\n", "2024/b.json": "{}", "c.csv": "x"} {
+		if err := stageMgr.PutFile(ctx, schema.ID, "RAW", name, strings.NewReader(data)); err != nil {
+			t.Fatalf("PutFile(%s) error = %v", name, err)
+		}
+	}
+
+	show, err := executor.Query(ctx, "SHOW STAGES")
+	if err != nil {
+		t.Fatalf("SHOW STAGES error = %v", err)
+	}
+	var stages [][]interface{}
+	for _, row := range show.Rows {
+		stages = append(stages, []interface{}{row[1], row[4], row[8], row[10], row[11]})
+	}
+	wantStages := [][]interface{}{
+		{"EXT", "s3://bucket/path/", "", "EXTERNAL", "AWS"},
+		{"RAW", "", "landing files", "INTERNAL", nil},
+	}
+	if diff := cmp.Diff(wantStages, stages); diff != "" {
+		t.Errorf("SHOW STAGES mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		name string
+		sql  string
+		want [][]interface{}
+	}{
+		{
+			name: "All",
+			sql:  "LIST @raw",
+			want: [][]interface{}{
+				{"raw/2024/a.csv", int64(5), "bc9280dfc1d4e67233f138f5bbbf0951"},
+				{"raw/2024/b.json", int64(2), "99914b932bd37a50b983c5e7c90ae93b"},
+				{"raw/c.csv", int64(1), "9dd4e461268c8034f5c8564e155c67a6"},
+			},
+		},
+		{
+			name: "Path",
+			sql:  "LS @stage_db.public.raw/2024",
+			want: [][]interface{}{
+				{"raw/2024/a.csv", int64(5), "bc9280dfc1d4e67233f138f5bbbf0951"},
+				{"raw/2024/b.json", int64(2), "99914b932bd37a50b983c5e7c90ae93b"},
+			},
+		},
+		{
+			name: "Pattern",
+			sql:  "LIST @raw PATTERN = '.*[.]csv'",
+			want: [][]interface{}{
+				{"raw/2024/a.csv", int64(5), "bc9280dfc1d4e67233f138f5bbbf0951"},
+				{"raw/c.csv", int64(1), "9dd4e461268c8034f5c8564e155c67a6"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if diff := cmp.Diff(listStageNames, result.Columns); diff != "" {
+				t.Errorf("columns mismatch (-want +got):\n%s", diff)
+			}
+			var got [][]interface{}
+			for _, row := range result.Rows {
+				if row[3] == "" {
+					t.Errorf("%s has no last_modified", row[0])
+				}
+				got = append(got, row[:3])
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, sql := range []string{"DROP STAGE raw", "DROP STAGE IF EXISTS raw"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if _, err := executor.Execute(ctx, "DROP STAGE raw"); err == nil {
+		t.Error("Expected an error dropping a missing stage")
+	}
+	if _, err := executor.Query(ctx, "LIST @raw"); err == nil {
+		t.Error("Expected an error listing a dropped stage")
+	}
+}
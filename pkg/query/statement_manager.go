@@ -3,6 +3,7 @@ package query
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -33,22 +34,54 @@ type Statement struct {
 	CompletedOn *time.Time
 	Result      *Result
 	Error       *apierror.SnowflakeError
-	cancelFunc  context.CancelFunc
+	// Parameters are the statement's session parameters, which select how its
+	// result is rendered whenever it is fetched.
+	Parameters SessionParameters
+	// QueryTag and Labels are the QUERY_TAG and labels the statement was
+	// submitted with, which are echoed whenever its status is reported.
+	QueryTag   string
+	Labels     map[string]string
+	cancelFunc context.CancelFunc
+	// idempotencyKey is the key the statement was submitted with, if any.
+	idempotencyKey *idempotencyKey
+	// partitions are the partitions of a spilled result, whose Rows are nil,
+	// or nil for a result held in memory.
+	partitions []resultPartition
 }
 
 // StatementManager manages active statements with thread safety.
 type StatementManager struct {
 	mu         sync.RWMutex
 	statements map[string]*Statement
-	ttl        time.Duration
+	// idempotencyKeys maps the idempotency keys of statements to their
+	// handles, for as long as the statements are kept.
+	idempotencyKeys map[idempotencyKey]string
+	ttl             time.Duration
+	// spillDir, spillThreshold, and partitionRows configure result spilling;
+	// results are held in memory when spillDir is "".
+	spillDir       string
+	spillThreshold int
+	partitionRows  int
+	// workers and queueSize size the worker pool running statements
+	// submitted with Submit.
+	workers   int
+	queueSize int
+	queue     chan statementTask
 }
 
 // NewStatementManager creates a new statement manager.
-func NewStatementManager(ttl time.Duration) *StatementManager {
+func NewStatementManager(ttl time.Duration, opts ...StatementManagerOption) *StatementManager {
 	sm := &StatementManager{
-		statements: make(map[string]*Statement),
-		ttl:        ttl,
+		statements:      make(map[string]*Statement),
+		idempotencyKeys: make(map[idempotencyKey]string),
+		ttl:             ttl,
+		workers:         DefaultStatementWorkers,
+		queueSize:       DefaultStatementQueueSize,
 	}
+	for _, opt := range opts {
+		opt(sm)
+	}
+	sm.startWorkers()
 	go sm.cleanupLoop()
 	return sm
 }
@@ -58,6 +91,40 @@ func (sm *StatementManager) CreateStatement(sqlText, database, schema, warehouse
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	return sm.createStatement(sqlText, database, schema, warehouse)
+}
+
+// idempotencyKey identifies the submissions of a statement by a client.
+type idempotencyKey struct {
+	scope string
+	key   string
+}
+
+// CreateIdempotentStatement creates a new statement submitted with an
+// idempotency key, unless a statement kept by the manager was submitted with
+// the same key in the same scope: then it returns a copy of that statement and
+// true, so that the statement runs at most once however often its submission
+// is retried. The scope identifies the client, such as by its session, so that
+// clients choosing the same key do not see each other's statements.
+func (sm *StatementManager) CreateIdempotentStatement(scope, key, sqlText, database, schema, warehouse string) (*Statement, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	id := idempotencyKey{scope: scope, key: key}
+	if handle, ok := sm.idempotencyKeys[id]; ok {
+		if existing, ok := sm.statements[handle]; ok {
+			snapshot := *existing
+			return &snapshot, true
+		}
+	}
+	stmt := sm.createStatement(sqlText, database, schema, warehouse)
+	stmt.idempotencyKey = &id
+	sm.idempotencyKeys[id] = stmt.Handle
+	return stmt, false
+}
+
+// createStatement creates a new statement. The caller holds sm.mu.
+func (sm *StatementManager) createStatement(sqlText, database, schema, warehouse string) *Statement {
 	handle := generateStatementHandle()
 	stmt := &Statement{
 		Handle:    handle,
@@ -72,12 +139,17 @@ func (sm *StatementManager) CreateStatement(sqlText, database, schema, warehouse
 	return stmt
 }
 
-// GetStatement retrieves a statement by handle.
+// GetStatement retrieves a statement by handle. It returns a copy of the
+// statement, which stays consistent while a worker runs the statement.
 func (sm *StatementManager) GetStatement(handle string) (*Statement, bool) {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 	stmt, ok := sm.statements[handle]
-	return stmt, ok
+	if !ok {
+		return nil, false
+	}
+	snapshot := *stmt
+	return &snapshot, true
 }
 
 // UpdateStatus updates the status of a statement.
@@ -98,30 +170,51 @@ func (sm *StatementManager) UpdateStatus(handle string, status StatementStatus)
 	return true
 }
 
-// SetResult sets the result of a successful statement.
+// SetResult sets the result of a successful statement. Results larger than the
+// spill threshold are written to disk, unless they were spilled while they
+// were read under ContextWithResultSpill, and their rows are read back by
+// Statement.PartitionRows. The result of a canceled statement is discarded.
 func (sm *StatementManager) SetResult(handle string, result *Result) bool {
+	partitions := result.partitions
+	if partitions != nil {
+		result = &Result{Columns: result.Columns, ColumnTypes: result.ColumnTypes, Warnings: result.Warnings}
+	} else if sm.spillDir != "" && sm.partitionRows > 0 && len(result.Rows) > sm.spillThreshold {
+		spilled, err := sm.spillResult(handle, result)
+		if err != nil {
+			log.Printf("Keeping the result of statement %s in memory: %v", handle, err)
+		} else {
+			partitions = spilled
+			result = &Result{Columns: result.Columns, ColumnTypes: result.ColumnTypes, Warnings: result.Warnings}
+		}
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	stmt, ok := sm.statements[handle]
-	if !ok {
+	if !ok || stmt.Status == StatementStatusCanceled {
+		if partitions != nil {
+			sm.removeSpill(&Statement{Handle: handle, partitions: partitions})
+		}
 		return false
 	}
 
 	stmt.Result = result
+	stmt.partitions = partitions
 	stmt.Status = StatementStatusSuccess
 	now := time.Now()
 	stmt.CompletedOn = &now
 	return true
 }
 
-// SetError sets the error of a failed statement.
+// SetError sets the error of a failed statement. The error of a canceled
+// statement, such as that of its canceled context, is discarded.
 func (sm *StatementManager) SetError(handle string, err *apierror.SnowflakeError) bool {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	stmt, ok := sm.statements[handle]
-	if !ok {
+	if !ok || stmt.Status == StatementStatusCanceled {
 		return false
 	}
 
@@ -132,6 +225,35 @@ func (sm *StatementManager) SetError(handle string, err *apierror.SnowflakeError
 	return true
 }
 
+// SetParameters sets the session parameters a statement runs with.
+func (sm *StatementManager) SetParameters(handle string, params SessionParameters) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	stmt, ok := sm.statements[handle]
+	if !ok {
+		return false
+	}
+
+	stmt.Parameters = params
+	return true
+}
+
+// SetLabels sets the QUERY_TAG and labels a statement was submitted with.
+func (sm *StatementManager) SetLabels(handle, queryTag string, labels map[string]string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	stmt, ok := sm.statements[handle]
+	if !ok {
+		return false
+	}
+
+	stmt.QueryTag = queryTag
+	stmt.Labels = labels
+	return true
+}
+
 // SetCancelFunc sets the cancel function for a running statement.
 func (sm *StatementManager) SetCancelFunc(handle string, cancelFunc context.CancelFunc) bool {
 	sm.mu.Lock()
@@ -174,7 +296,9 @@ func (sm *StatementManager) CancelStatement(handle string) error {
 func (sm *StatementManager) DeleteStatement(handle string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	delete(sm.statements, handle)
+	if stmt, ok := sm.statements[handle]; ok {
+		sm.remove(stmt)
+	}
 }
 
 // cleanupLoop periodically removes expired statements.
@@ -187,19 +311,30 @@ func (sm *StatementManager) cleanupLoop() {
 	}
 }
 
-// cleanup removes statements that have been completed for longer than TTL.
+// cleanup removes statements that have been completed for longer than TTL,
+// along with their spilled results and idempotency keys.
 func (sm *StatementManager) cleanup() {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
 	now := time.Now()
-	for handle, stmt := range sm.statements {
+	for _, stmt := range sm.statements {
 		if stmt.CompletedOn != nil && now.Sub(*stmt.CompletedOn) > sm.ttl {
-			delete(sm.statements, handle)
+			sm.remove(stmt)
 		}
 	}
 }
 
+// remove removes a statement with its spilled result and idempotency key.
+// The caller holds sm.mu.
+func (sm *StatementManager) remove(stmt *Statement) {
+	sm.removeSpill(stmt)
+	if stmt.idempotencyKey != nil {
+		delete(sm.idempotencyKeys, *stmt.idempotencyKey)
+	}
+	delete(sm.statements, stmt.Handle)
+}
+
 // generateStatementHandle generates a unique statement handle in Snowflake format.
 func generateStatementHandle() string {
 	id := uuid.New()
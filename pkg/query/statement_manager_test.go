@@ -1,9 +1,16 @@
 package query
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	duckdb "github.com/duckdb/duckdb-go/v2"
 	"github.com/google/go-cmp/cmp"
 	"github.com/nnnkkk7/snowflake-emulator/server/apierror"
 )
@@ -275,3 +282,239 @@ func TestGenerateStatementHandle(t *testing.T) {
 		t.Errorf("Expected handle to start with '01', got %s", handle1)
 	}
 }
+
+func TestStatementManager_SpillResult(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewStatementManager(1*time.Hour, WithResultSpill(dir, 10, 10))
+
+	rows := make([][]interface{}, 25)
+	for i := range rows {
+		rows[i] = []interface{}{
+			int64(i), fmt.Sprint("row", i), nil,
+			time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			duckdb.Decimal{Width: 10, Scale: 2, Value: big.NewInt(int64(i) * 100)},
+			[]interface{}{"a", int32(1)},
+			map[string]interface{}{"k": true},
+		}
+	}
+	stmt := sm.CreateStatement("SELECT ...", "", "", "")
+	sm.SetResult(stmt.Handle, &Result{Columns: []string{"A"}, Rows: rows})
+
+	if stmt.Result.Rows != nil {
+		t.Errorf("spilled result keeps %d rows in memory", len(stmt.Result.Rows))
+	}
+	partitions := stmt.Partitions()
+	var counts []int
+	for _, partition := range partitions {
+		counts = append(counts, partition.RowCount)
+		if partition.Size == 0 {
+			t.Errorf("partition %+v has no size", partition)
+		}
+	}
+	if diff := cmp.Diff([]int{10, 10, 5}, counts); diff != "" {
+		t.Fatalf("partition row counts mismatch (-want +got):\n%s", diff)
+	}
+	compareBigInts := cmp.Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+	for i := range partitions {
+		got, err := stmt.PartitionRows(i)
+		if err != nil {
+			t.Fatalf("PartitionRows(%d) error = %v", i, err)
+		}
+		if diff := cmp.Diff(rows[i*10:min(i*10+10, len(rows))], got, compareBigInts); diff != "" {
+			t.Errorf("PartitionRows(%d) mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+	if _, err := stmt.PartitionRows(3); err == nil {
+		t.Error("PartitionRows(3) succeeded")
+	}
+
+	small := sm.CreateStatement("SELECT 1", "", "", "")
+	sm.SetResult(small.Handle, &Result{Columns: []string{"A"}, Rows: rows[:10]})
+	if len(small.Result.Rows) != 10 || len(small.Partitions()) != 1 {
+		t.Errorf("result under the threshold was spilled: %d rows, %d partitions", len(small.Result.Rows), len(small.Partitions()))
+	}
+
+	sm.DeleteStatement(stmt.Handle)
+	if _, err := os.Stat(filepath.Join(dir, stmt.Handle)); !os.IsNotExist(err) {
+		t.Errorf("spilled partitions remain after DeleteStatement: %v", err)
+	}
+}
+
+// TestStatementManager_SpillWhileReading tests that a query's result is
+// spilled while it is read, holding at most a partition of it in memory.
+func TestStatementManager_SpillWhileReading(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewStatementManager(1*time.Hour, WithResultSpill(dir, 10, 4))
+	stmt := sm.CreateStatement("SELECT ...", "", "", "")
+	spill := resultSpillFromContext(sm.ContextWithResultSpill(context.Background(), stmt.Handle))
+
+	result := &Result{Columns: []string{"N"}}
+	for i := range 25 {
+		result.Rows = append(result.Rows, []interface{}{int64(i)})
+		if err := spill.collect(result); err != nil {
+			t.Fatalf("collect() error = %v", err)
+		}
+		if i >= 10 && len(result.Rows) >= 4 {
+			t.Fatalf("after %d rows, %d rows are held in memory, want less than a partition", i+1, len(result.Rows))
+		}
+	}
+	if err := spill.finish(result); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+	if result.Rows != nil || result.RowCount() != 25 {
+		t.Fatalf("finished result has %d rows in memory and %d in all, want 0 and 25", len(result.Rows), result.RowCount())
+	}
+
+	sm.SetResult(stmt.Handle, result)
+	var counts []int
+	for _, partition := range stmt.Partitions() {
+		counts = append(counts, partition.RowCount)
+	}
+	if diff := cmp.Diff([]int{4, 4, 4, 4, 4, 4, 1}, counts); diff != "" {
+		t.Fatalf("partition row counts mismatch (-want +got):\n%s", diff)
+	}
+	got, err := stmt.PartitionRows(6)
+	if err != nil {
+		t.Fatalf("PartitionRows(6) error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(24)}}, got); diff != "" {
+		t.Errorf("PartitionRows(6) mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutor_QuerySpillsWhileReading tests that Executor.Query spills the
+// result of a query under ContextWithResultSpill, and only that result.
+func TestExecutor_QuerySpillsWhileReading(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	dir := t.TempDir()
+	sm := NewStatementManager(1*time.Hour, WithResultSpill(dir, 10, 10))
+
+	stmt := sm.CreateStatement("SELECT ...", "", "", "")
+	ctx := sm.ContextWithResultSpill(context.Background(), stmt.Handle)
+	result, err := executor.Query(ctx, "SELECT range AS n FROM range(25) ORDER BY n")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if result.Rows != nil || result.RowCount() != 25 {
+		t.Fatalf("Query() result has %d rows in memory and %d in all, want 0 and 25", len(result.Rows), result.RowCount())
+	}
+	// The partitions are written before the result is set
+	if _, err := os.Stat(filepath.Join(dir, stmt.Handle, "partition-2.gob")); err != nil {
+		t.Fatalf("partition 2 was not written while the result was read: %v", err)
+	}
+	sm.SetResult(stmt.Handle, result)
+	got, err := stmt.PartitionRows(2)
+	if err != nil {
+		t.Fatalf("PartitionRows(2) error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(20)}, {int64(21)}, {int64(22)}, {int64(23)}, {int64(24)}}, got); diff != "" {
+		t.Errorf("PartitionRows(2) mismatch (-want +got):\n%s", diff)
+	}
+
+	// Small results stay in memory
+	small := sm.CreateStatement("SELECT ...", "", "", "")
+	result, err = executor.Query(sm.ContextWithResultSpill(context.Background(), small.Handle), "SELECT range AS n FROM range(5) ORDER BY n")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(result.Rows) != 5 {
+		t.Errorf("Query() result under the threshold has %d rows in memory, want 5", len(result.Rows))
+	}
+	if _, err := os.Stat(filepath.Join(dir, small.Handle)); !os.IsNotExist(err) {
+		t.Errorf("result under the threshold was spilled: %v", err)
+	}
+}
+
+func TestStatementManager_Submit(t *testing.T) {
+	sm := NewStatementManager(1*time.Hour, WithStatementWorkers(1, 2))
+
+	// A submitted statement runs once a worker picks it up
+	stmt := sm.CreateStatement("SELECT 1", "TEST_DB", "PUBLIC", "")
+	done := make(chan struct{})
+	if err := sm.Submit(context.Background(), stmt.Handle, func(ctx context.Context) {
+		defer close(done)
+		sm.SetResult(stmt.Handle, &Result{Columns: []string{"A"}, Rows: [][]interface{}{{1}}})
+	}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-done
+	if got, _ := sm.GetStatement(stmt.Handle); got.Status != StatementStatusSuccess {
+		t.Errorf("Expected status %s, got %s", StatementStatusSuccess, got.Status)
+	}
+
+	// Canceling a running statement cancels its context and discards its error
+	blocked := sm.CreateStatement("SELECT 2", "TEST_DB", "PUBLIC", "")
+	started, finished := make(chan struct{}), make(chan struct{})
+	if err := sm.Submit(context.Background(), blocked.Handle, func(ctx context.Context) {
+		defer close(finished)
+		close(started)
+		<-ctx.Done()
+		sm.SetError(blocked.Handle, apierror.NewSnowflakeError(apierror.CodeSQLExecutionError, ctx.Err().Error()))
+	}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-started
+
+	// While the only worker is busy, two statements are queued and the next rejected
+	queued := sm.CreateStatement("SELECT 3", "TEST_DB", "PUBLIC", "")
+	ran := false
+	if err := sm.Submit(context.Background(), queued.Handle, func(context.Context) { ran = true }); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	last := sm.CreateStatement("SELECT 4", "TEST_DB", "PUBLIC", "")
+	lastDone := make(chan struct{})
+	if err := sm.Submit(context.Background(), last.Handle, func(context.Context) { close(lastDone) }); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	rejected := sm.CreateStatement("SELECT 5", "TEST_DB", "PUBLIC", "")
+	if err := sm.Submit(context.Background(), rejected.Handle, func(context.Context) {}); !errors.Is(err, ErrStatementQueueFull) {
+		t.Errorf("Submit() to a full queue error = %v, want %v", err, ErrStatementQueueFull)
+	}
+	if err := sm.CancelStatement(queued.Handle); err != nil {
+		t.Fatalf("CancelStatement() error = %v", err)
+	}
+
+	if err := sm.CancelStatement(blocked.Handle); err != nil {
+		t.Fatalf("CancelStatement() error = %v", err)
+	}
+	<-finished
+	if got, _ := sm.GetStatement(blocked.Handle); got.Status != StatementStatusCanceled || got.Error != nil {
+		t.Errorf("Expected status %s without error, got %s, %v", StatementStatusCanceled, got.Status, got.Error)
+	}
+
+	// The statement canceled while queued is skipped
+	<-lastDone
+	if ran {
+		t.Error("Expected the statement canceled while queued not to run")
+	}
+}
+
+func TestStatementManager_CreateIdempotentStatement(t *testing.T) {
+	sm := NewStatementManager(1 * time.Hour)
+
+	first, existing := sm.CreateIdempotentStatement("session-1", "key-1", "SELECT 1", "TEST_DB", "PUBLIC", "")
+	if existing {
+		t.Fatal("Expected the first statement of a key to be new")
+	}
+	again, existing := sm.CreateIdempotentStatement("session-1", "key-1", "SELECT 1", "TEST_DB", "PUBLIC", "")
+	if !existing || again.Handle != first.Handle {
+		t.Errorf("CreateIdempotentStatement() = %s, %v, want %s, true", again.Handle, existing, first.Handle)
+	}
+	other, existing := sm.CreateIdempotentStatement("session-1", "key-2", "SELECT 1", "TEST_DB", "PUBLIC", "")
+	if existing || other.Handle == first.Handle {
+		t.Errorf("CreateIdempotentStatement() of another key = %s, %v, want a new statement", other.Handle, existing)
+	}
+
+	// Keys are scoped, so another client's statement is new
+	elsewhere, existing := sm.CreateIdempotentStatement("session-2", "key-1", "SELECT 1", "TEST_DB", "PUBLIC", "")
+	if existing || elsewhere.Handle == first.Handle {
+		t.Errorf("CreateIdempotentStatement() in another scope = %s, %v, want a new statement", elsewhere.Handle, existing)
+	}
+
+	// A key is released with its statement
+	sm.DeleteStatement(first.Handle)
+	renewed, existing := sm.CreateIdempotentStatement("session-1", "key-1", "SELECT 1", "TEST_DB", "PUBLIC", "")
+	if existing || renewed.Handle == first.Handle {
+		t.Errorf("CreateIdempotentStatement() after delete = %s, %v, want a new statement", renewed.Handle, existing)
+	}
+}
@@ -0,0 +1,151 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+)
+
+// statementMemoryPollInterval is how often the memory use of a statement is
+// checked against the statement memory limit.
+const statementMemoryPollInterval = 20 * time.Millisecond
+
+// byteUnits are the size units ParseStatementMemoryLimit accepts, as DuckDB's
+// memory_limit does: decimal KB, MB, GB, and TB, and binary KiB, MiB, GiB, and TiB.
+var byteUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// ParseStatementMemoryLimit parses a memory size such as "512MB" or "1GiB"
+// into bytes. An empty string means no limit and parses as 0.
+func ParseStatementMemoryLimit(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	number := strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	unit, ok := byteUnits[strings.ToUpper(strings.TrimSpace(s[len(number):]))]
+	size, err := strconv.ParseInt(number, 10, 64)
+	if !ok || err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q: must be a positive number of B, KB, MB, GB, TB, KiB, MiB, GiB, or TiB", s)
+	}
+	return size * unit, nil
+}
+
+// WithStatementMemoryLimit stops a statement, failing it with an out-of-memory
+// error, once DuckDB's memory use has grown by more than limit bytes while it
+// ran alone. DuckDB does not report the memory of single statements, so growth
+// while other statements run is charged to none of them; DuckDB's memory_limit,
+// set with connection.WithDatabaseMemoryLimit, is the hard cap for those. A
+// limit of 0 disables the check.
+func WithStatementMemoryLimit(limit int64) ExecutorOption {
+	return func(e *Executor) {
+		e.statementMemoryLimit = limit
+	}
+}
+
+// runningStatements counts the statements running under the statement memory
+// limit. Its epoch changes whenever one starts or ends, so that a statement
+// can tell whether it ran alone between two checks of its memory.
+type runningStatements struct {
+	mu    sync.Mutex
+	count int
+	epoch uint64
+}
+
+// add changes the number of running statements by delta and returns the new
+// epoch and count.
+func (r *runningStatements) add(delta int) (uint64, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.count += delta
+	r.epoch++
+	return r.epoch, r.count
+}
+
+// state returns the current epoch and number of running statements.
+func (r *runningStatements) state() (uint64, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.epoch, r.count
+}
+
+// limitStatementMemory returns a copy of ctx for running a statement under the
+// executor's statement memory limit, canceled once the statement exceeds it.
+// Memory growth counts toward the statement only between checks during which
+// no other statement started, ran, or ended. The returned stop function ends
+// the check and returns the statement's error, replaced by an out-of-memory
+// error if the limit stopped the statement.
+func (e *Executor) limitStatementMemory(ctx context.Context) (context.Context, func(error) error) {
+	if e.statementMemoryLimit <= 0 {
+		return ctx, func(err error) error { return err }
+	}
+	db := e.manager(ctx).DB()
+	used := func() (int64, error) {
+		var bytes int64
+		err := db.QueryRowContext(context.Background(), "SELECT CAST(COALESCE(SUM(memory_usage_bytes), 0) AS BIGINT) FROM duckdb_memory()").Scan(&bytes)
+		return bytes, err
+	}
+	epoch, _ := e.memoryStatements.add(1)
+	last, err := used()
+	if err != nil {
+		e.memoryStatements.add(-1)
+		return ctx, func(err error) error { return err }
+	}
+
+	limited, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(statementMemoryPollInterval)
+		defer ticker.Stop()
+		var grown int64
+		for {
+			select {
+			case <-done:
+				return
+			case <-limited.Done():
+				return
+			case <-ticker.C:
+			}
+			bytes, err := used()
+			if err != nil {
+				continue
+			}
+			now, count := e.memoryStatements.state()
+			if now == epoch && count == 1 {
+				grown = max(grown+bytes-last, 0)
+			}
+			epoch, last = now, bytes
+			if grown > e.statementMemoryLimit {
+				cancel(fmt.Errorf("statement used more than %d bytes of memory: %w", e.statementMemoryLimit, connection.ErrStatementMemoryLimit))
+				return
+			}
+		}
+	}()
+
+	return limited, func(err error) error {
+		close(done)
+		e.memoryStatements.add(-1)
+		cause := context.Cause(limited)
+		cancel(nil)
+		if err != nil && errors.Is(cause, connection.ErrStatementMemoryLimit) {
+			return cause
+		}
+		return err
+	}
+}
@@ -0,0 +1,88 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+)
+
+func TestParseStatementMemoryLimit(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "1024", want: 1024},
+		{input: "512MB", want: 512_000_000},
+		{input: " 2 gib ", want: 2 << 30},
+		{input: "10KiB", want: 10 << 10},
+		{input: "0MB", wantErr: true},
+		{input: "MB", wantErr: true},
+		{input: "5 parsecs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseStatementMemoryLimit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStatementMemoryLimit(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStatementMemoryLimit(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExecutor_StatementMemoryLimit tests that a statement using more memory
+// than the statement memory limit is stopped with an out-of-memory error,
+// while other statements run.
+func TestExecutor_StatementMemoryLimit(t *testing.T) {
+	executor, _ := setupTestExecutor(t, WithStatementMemoryLimit(32<<20))
+	ctx := context.Background()
+	if _, err := executor.Execute(ctx, "CREATE TABLE numbers AS SELECT range AS n FROM range(1000000)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	_, err := executor.Query(ctx, "SELECT n % 100000 AS g, LENGTH(LISTAGG(REPEAT('x', 100), ',')) AS l FROM numbers GROUP BY 1 ORDER BY 2")
+	if !errors.Is(err, connection.ErrStatementMemoryLimit) || !connection.IsOutOfMemoryError(err) || errors.Is(err, context.Canceled) {
+		t.Errorf("Query() error = %v, want the statement memory limit error", err)
+	}
+	_, err = executor.Execute(ctx, "CREATE TABLE groups AS SELECT n % 100000 AS g, LENGTH(LISTAGG(REPEAT('x', 100), ',')) AS l FROM numbers GROUP BY 1")
+	if !errors.Is(err, connection.ErrStatementMemoryLimit) {
+		t.Errorf("Execute() error = %v, want the statement memory limit error", err)
+	}
+
+	result, err := executor.Query(ctx, "SELECT COUNT(*) FROM numbers")
+	if err != nil || result.Rows[0][0] != int64(1000000) {
+		t.Errorf("Query() after the limit = %v, %v, want 1000000", result, err)
+	}
+}
+
+// TestExecutor_StatementMemoryLimitConcurrent tests that a statement is not
+// stopped for memory taken by another statement running at the same time.
+func TestExecutor_StatementMemoryLimitConcurrent(t *testing.T) {
+	executor, _ := setupTestExecutor(t, WithStatementMemoryLimit(32<<20))
+	ctx := context.Background()
+	if _, err := executor.Execute(ctx, "CREATE TABLE numbers AS SELECT range AS n FROM range(1000000)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// The small statement scans without holding memory, long enough to run
+	// while the large one grows past the limit
+	small := make(chan error, 1)
+	go func() {
+		_, err := executor.Query(ctx, "SELECT COUNT(*) FROM range(100000000) WHERE range % 7 = 0")
+		small <- err
+	}()
+	_, largeErr := executor.Query(ctx, "SELECT n % 100000 AS g, LENGTH(LISTAGG(REPEAT('x', 100), ',')) AS l FROM numbers GROUP BY 1 ORDER BY 2")
+	if err := <-small; err != nil {
+		t.Errorf("Query() of the small statement error = %v, want nil", err)
+	}
+	if largeErr != nil && !errors.Is(largeErr, connection.ErrStatementMemoryLimit) {
+		t.Errorf("Query() of the large statement error = %v, want nil or the statement memory limit error", largeErr)
+	}
+}
@@ -0,0 +1,94 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Default size of the worker pool running asynchronous statements.
+const (
+	DefaultStatementWorkers   = 4
+	DefaultStatementQueueSize = 1000
+)
+
+// ErrStatementQueueFull is returned by Submit when as many statements as the
+// queue holds are waiting for a worker.
+var ErrStatementQueueFull = errors.New("statement queue is full")
+
+// WithStatementWorkers sets the number of workers running asynchronous
+// statements and the number of statements that may wait for one.
+func WithStatementWorkers(workers, queueSize int) StatementManagerOption {
+	return func(sm *StatementManager) {
+		sm.workers = workers
+		sm.queueSize = queueSize
+	}
+}
+
+// statementTask is a submitted statement waiting for a worker.
+type statementTask struct {
+	handle string
+	ctx    context.Context
+	cancel context.CancelFunc
+	run    func(ctx context.Context)
+}
+
+// Submit queues run to execute a pending statement in the worker pool. The
+// statement is marked running when a worker picks it up, and run receives a
+// context derived from ctx that is canceled when the statement is canceled.
+// run records the statement's result or error with SetResult or SetError.
+func (sm *StatementManager) Submit(ctx context.Context, handle string, run func(ctx context.Context)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	if !sm.SetCancelFunc(handle, cancel) {
+		cancel()
+		return fmt.Errorf("statement not found: %s", handle)
+	}
+	select {
+	case sm.queue <- statementTask{handle: handle, ctx: ctx, cancel: cancel, run: run}:
+		return nil
+	default:
+		cancel()
+		return ErrStatementQueueFull
+	}
+}
+
+// startWorkers starts the worker pool running submitted statements.
+func (sm *StatementManager) startWorkers() {
+	workers := sm.workers
+	if workers <= 0 {
+		workers = 1
+	}
+	queueSize := sm.queueSize
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	sm.queue = make(chan statementTask, queueSize)
+	for range workers {
+		go sm.worker()
+	}
+}
+
+// worker runs submitted statements until the queue is closed. Statements
+// canceled while queued are skipped.
+func (sm *StatementManager) worker() {
+	for task := range sm.queue {
+		if sm.start(task.handle) {
+			task.run(task.ctx)
+		}
+		task.cancel()
+	}
+}
+
+// start marks a pending statement running. It reports false for a statement
+// that was canceled or removed while queued.
+func (sm *StatementManager) start(handle string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	stmt, ok := sm.statements[handle]
+	if !ok || stmt.Status != StatementStatusPending {
+		return false
+	}
+	stmt.Status = StatementStatusRunning
+	return true
+}
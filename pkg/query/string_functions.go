@@ -0,0 +1,356 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// registerStringFunctions registers translations for Snowflake string functions
+// whose arguments or edge cases differ from DuckDB's. They are resolved in
+// transformStringFunctions.
+func (t *Translator) registerStringFunctions() {
+	for _, name := range []string{
+		"SPLIT", "SPLIT_PART", "STRTOK", "CHARINDEX", "INSERT", "LPAD", "RPAD",
+		"REGEXP_SUBSTR", "REGEXP_REPLACE", "REGEXP_COUNT",
+	} {
+		t.functionMap[name] = markFunction("__" + name + "__")
+	}
+}
+
+// transformStringFunctions resolves the markers of registerStringFunctions.
+func (t *Translator) transformStringFunctions(sql string) string {
+	// SPLIT(s, sep) → a JSON array, which holds s itself when s or sep is empty
+	sql = t.transformMarkedFunction(sql, "__SPLIT__", func(args string) string {
+		parts := trimmedArgs(args, 2)
+		if len(parts) != 2 {
+			return "string_split(" + args + ")"
+		}
+		s, sep := parts[0], parts[1]
+		return fmt.Sprintf("to_json(CASE WHEN (%s) = '' OR (%s) = '' THEN [%s] ELSE string_split(%s, %s) END)", s, sep, s, s, sep)
+	})
+
+	// SPLIT_PART(s, sep, n) → split_part(s, sep, n), where part 0 is the first,
+	// and an empty separator leaves s whole
+	sql = t.transformMarkedFunction(sql, "__SPLIT_PART__", func(args string) string {
+		parts := trimmedArgs(args, 3)
+		if len(parts) != 3 {
+			return "split_part(" + args + ")"
+		}
+		s, sep, n := parts[0], parts[1], parts[2]
+		if n == "0" {
+			n = "1"
+		} else if _, ok := integerLiteral(n); !ok {
+			n = fmt.Sprintf("CASE WHEN (%s) = 0 THEN 1 ELSE %s END", n, n)
+		}
+		if literal, ok := stringLiteral(sep); ok && literal != "" {
+			return fmt.Sprintf("split_part(%s, %s, %s)", s, sep, n)
+		}
+		return fmt.Sprintf("CASE WHEN (%s) = '' THEN CASE WHEN (%s) IN (1, -1) THEN %s ELSE '' END ELSE split_part(%s, %s, %s) END", sep, n, s, s, sep, n)
+	})
+
+	// STRTOK(s [, delimiters [, n]]) → the nth non-empty token of s, split at
+	// any of the delimiter characters
+	sql = t.transformMarkedFunction(sql, "__STRTOK__", func(args string) string {
+		parts := trimmedArgs(args, 3)
+		s, delimiters, n := parts[0], "' '", "1"
+		if len(parts) > 1 {
+			delimiters = parts[1]
+		}
+		if len(parts) > 2 {
+			n = parts[2]
+		}
+		tokens := func(split string) string {
+			return fmt.Sprintf("list_filter(%s, lambda __e: __e <> '')[%s]", split, n)
+		}
+		if literal, ok := stringLiteral(delimiters); ok {
+			if literal == "" {
+				return tokens(fmt.Sprintf("[%s]", s))
+			}
+			first, _ := utf8.DecodeRuneInString(literal)
+			separator := quoteLiteral(string(first))
+			if utf8.RuneCountInString(literal) == 1 {
+				return tokens(fmt.Sprintf("string_split(%s, %s)", s, separator))
+			}
+			to := quoteLiteral(strings.Repeat(string(first), utf8.RuneCountInString(literal)))
+			return tokens(fmt.Sprintf("string_split(translate(%s, %s, %s), %s)", s, delimiters, to, separator))
+		}
+		separator := fmt.Sprintf("left(%s, 1)", delimiters)
+		return tokens(fmt.Sprintf("CASE WHEN (%s) = '' THEN [%s] ELSE string_split(translate(%s, %s, repeat(%s, length(%s))), %s) END",
+			delimiters, s, s, delimiters, separator, delimiters, separator))
+	})
+
+	// CHARINDEX(sub, s [, start]) → the position of sub in s from start, or 0
+	sql = t.transformMarkedFunction(sql, "__CHARINDEX__", func(args string) string {
+		parts := trimmedArgs(args, 3)
+		switch len(parts) {
+		case 2:
+			return fmt.Sprintf("instr(%s, %s)", parts[1], parts[0])
+		case 3:
+			sub, s, start := parts[0], parts[1], parts[2]
+			if n, ok := integerLiteral(start); !ok {
+				start = fmt.Sprintf("greatest(%s, 1)", start)
+			} else if n < 1 {
+				return fmt.Sprintf("instr(%s, %s)", s, sub)
+			}
+			found := fmt.Sprintf("instr(substr(%s, %s), %s)", s, start, sub)
+			return fmt.Sprintf("CASE WHEN %s = 0 THEN 0 ELSE %s + %s - 1 END", found, found, start)
+		}
+		return "charindex(" + args + ")"
+	})
+
+	// INSERT(base, pos, len, s) → base with the len characters from pos replaced by s
+	sql = t.transformMarkedFunction(sql, "__INSERT__", func(args string) string {
+		parts := trimmedArgs(args, 4)
+		if len(parts) != 4 {
+			return "insert(" + args + ")"
+		}
+		base, pos, length, s := parts[0], parts[1], parts[2], parts[3]
+		return fmt.Sprintf("(substr(%s, 1, (%s) - 1) || %s || substr(%s, (%s) + (%s)))", base, pos, s, base, pos, length)
+	})
+
+	// LPAD and RPAD pad with a space by default, and an empty pad only truncates
+	for _, fn := range []string{"lpad", "rpad"} {
+		sql = t.transformMarkedFunction(sql, "__"+strings.ToUpper(fn)+"__", func(args string) string {
+			parts := trimmedArgs(args, 3)
+			if len(parts) < 2 {
+				return fn + "(" + args + ")"
+			}
+			base, length, pad := parts[0], parts[1], "' '"
+			if len(parts) > 2 {
+				pad = parts[2]
+			}
+			truncated := fmt.Sprintf("left(%s, greatest(%s, 0))", base, length)
+			if literal, ok := stringLiteral(pad); ok {
+				if literal == "" {
+					return truncated
+				}
+				return fmt.Sprintf("%s(%s, %s, %s)", fn, base, length, pad)
+			}
+			return fmt.Sprintf("CASE WHEN (%s) = '' THEN %s ELSE %s(%s, %s, %s) END", pad, truncated, fn, base, length, pad)
+		})
+	}
+
+	// REGEXP_SUBSTR(s, pattern [, position [, occurrence [, parameters [, group]]]])
+	// → the occurrence-th match of regexp_extract_all(), or NULL
+	sql = t.transformMarkedFunction(sql, "__REGEXP_SUBSTR__", func(args string) string {
+		parts := trimmedArgs(args, 6)
+		if len(parts) < 2 {
+			return "regexp_substr(" + args + ")"
+		}
+		subject := regexpSubject(parts[0], optionalArg(parts, 2, "1"))
+		occurrence := optionalArg(parts, 3, "1")
+		pattern, extract := regexpPattern(parts[1], optionalArg(parts, 4, "''"))
+		group := "0"
+		if extract {
+			group = "1"
+		}
+		group = optionalArg(parts, 5, group)
+		return fmt.Sprintf("regexp_extract_all(%s, %s, %s)[%s]", subject, pattern, group, occurrence)
+	})
+
+	// REGEXP_REPLACE(s, pattern [, replacement [, position [, occurrence [, parameters]]]])
+	// → regexp_replace(), replacing every match unless the occurrence is 1
+	sql = t.transformMarkedFunction(sql, "__REGEXP_REPLACE__", func(args string) string {
+		parts := trimmedArgs(args, 6)
+		if len(parts) < 2 {
+			return "regexp_replace(" + args + ")"
+		}
+		s, position := parts[0], optionalArg(parts, 3, "1")
+		pattern, _ := regexpPattern(parts[1], optionalArg(parts, 5, "''"))
+		options := "'g'"
+		switch occurrence := optionalArg(parts, 4, "0"); occurrence {
+		case "0":
+		case "1":
+			options = "''"
+		default:
+			return "error('REGEXP_REPLACE replaces all matches or the first one only; occurrence " + strings.ReplaceAll(occurrence, "'", "''") + " is not supported')"
+		}
+		replaced := fmt.Sprintf("regexp_replace(%s, %s, %s, %s)", regexpSubject(s, position), pattern, optionalArg(parts, 2, "''"), options)
+		if position == "1" {
+			return replaced
+		}
+		return fmt.Sprintf("(substr(%s, 1, (%s) - 1) || %s)", s, position, replaced)
+	})
+
+	// REGEXP_COUNT(s, pattern [, position [, parameters]]) → the number of matches
+	return t.transformMarkedFunction(sql, "__REGEXP_COUNT__", func(args string) string {
+		parts := trimmedArgs(args, 4)
+		if len(parts) < 2 {
+			return "regexp_count(" + args + ")"
+		}
+		pattern, _ := regexpPattern(parts[1], optionalArg(parts, 3, "''"))
+		return fmt.Sprintf("len(regexp_extract_all(%s, %s))", regexpSubject(parts[0], optionalArg(parts, 2, "1")), pattern)
+	})
+}
+
+// trimmedArgs splits the arguments of a marked function and trims them.
+func trimmedArgs(args string, expectedCount int) []string {
+	parts := splitFunctionArgs(args, expectedCount)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// optionalArg returns parts[i], or def if the argument was omitted.
+func optionalArg(parts []string, i int, def string) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return def
+}
+
+// integerLiteral returns the value of an integer literal such as 3 or -1.
+func integerLiteral(arg string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	return n, err == nil
+}
+
+// regexpSubject returns the part of s a regular expression function searches,
+// from the 1-based character position.
+func regexpSubject(s, position string) string {
+	if position == "1" {
+		return s
+	}
+	return fmt.Sprintf("substr(%s, %s)", s, position)
+}
+
+// regexpPattern prefixes pattern with the RE2 flags of a literal Snowflake
+// regular expression parameters string: c for case-sensitive, i for
+// case-insensitive, m for multi-line, and s for a dot matching newlines. It
+// also reports whether the parameters contain e, extracting a group.
+// Parameters that are not literals are ignored.
+func regexpPattern(pattern, parameters string) (string, bool) {
+	literal, _ := stringLiteral(parameters)
+	var insensitive, multiline, dotAll, extract bool
+	for _, c := range literal {
+		switch c {
+		case 'c':
+			insensitive = false
+		case 'i':
+			insensitive = true
+		case 'm':
+			multiline = true
+		case 's':
+			dotAll = true
+		case 'e':
+			extract = true
+		}
+	}
+	var flags string
+	if insensitive {
+		flags += "i"
+	}
+	if multiline {
+		flags += "m"
+	}
+	if dotAll {
+		flags += "s"
+	}
+	if flags == "" {
+		return pattern, extract
+	}
+	return fmt.Sprintf("('(?%s)' || %s)", flags, pattern), extract
+}
+
+// markStringCalls rewrites the string function calls the parser rejects into
+// ones it accepts: POSITION(sub, s [, start]) and POSITION(sub IN s) become
+// CHARINDEX, which takes the same arguments, and INSERT(...) is marked as
+// __INSERT__(...).
+func markStringCalls(sql string) string {
+	var b strings.Builder
+	last := -1
+	scanFunctionCalls(sql, func(name string, start, end int) bool {
+		if name != "POSITION" && name != "INSERT" {
+			return true
+		}
+		open := end + strings.IndexByte(sql[end:], '(')
+		args, ok := callArguments(sql, open)
+		if !ok {
+			return true
+		}
+		b.WriteString(sql[max(last, 0):start])
+		if name == "INSERT" {
+			b.WriteString("__INSERT__")
+			last = end
+			return true
+		}
+		if len(splitFunctionArgs(args, 3)) > 1 {
+			b.WriteString("CHARINDEX")
+			last = end
+			return true
+		}
+		in := topLevelKeyword(args, "IN")
+		if in < 0 {
+			b.WriteString(sql[start:end])
+			last = end
+			return true
+		}
+		b.WriteString("CHARINDEX(" + args[:in] + "," + args[in+len("IN"):] + ")")
+		last = open + len(args) + 2
+		return true
+	})
+	if last < 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
+
+// callArguments returns the text between the parenthesis at sql[open] and the
+// one closing it.
+func callArguments(sql string, open int) (string, bool) {
+	depth := 0
+	for i := open; i < len(sql); i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return sql[open+1 : i], true
+			}
+		case '\'':
+			i = skipQuoted(sql, i, '\'')
+		}
+	}
+	return "", false
+}
+
+// markConcatOperators rewrites the || operators, which the parser reads as OR,
+// to the bitwise | operator, which Snowflake lacks and the parser reads with
+// the same precedence as || in Snowflake: below + and -, above comparisons.
+// The walk over the statement turns them back into ||.
+func markConcatOperators(sql string) string {
+	if !strings.Contains(sql, "||") {
+		return sql
+	}
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case strings.HasPrefix(sql[i:], "$$"):
+			if end := strings.Index(sql[i+2:], "$$"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+		case strings.HasPrefix(sql[i:], "||"):
+			b.WriteString(sql[last:i] + "|")
+			last = i + 2
+			i++
+		}
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
@@ -0,0 +1,204 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestTranslator_StringFunctionPack tests SPLIT, STRTOK, CHARINDEX, the REGEXP
+// functions, and the other string function translations.
+func TestTranslator_StringFunctionPack(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Split",
+			input:    "SELECT SPLIT(s, ',') FROM t",
+			expected: "select to_json(CASE WHEN (s) = '' OR (',') = '' THEN [s] ELSE string_split(s, ',') END) from t",
+		},
+		{
+			name:     "SplitPartZero",
+			input:    "SELECT SPLIT_PART(s, ',', 0) FROM t",
+			expected: "select split_part(s, ',', 1) from t",
+		},
+		{
+			name:     "SplitPartColumns",
+			input:    "SELECT SPLIT_PART(s, sep, n) FROM t",
+			expected: "select CASE WHEN (sep) = '' THEN CASE WHEN (CASE WHEN (n) = 0 THEN 1 ELSE n END) IN (1, -1) THEN s ELSE '' END ELSE split_part(s, sep, CASE WHEN (n) = 0 THEN 1 ELSE n END) END from t",
+		},
+		{
+			name:     "StrtokDelimiters",
+			input:    "SELECT STRTOK(s, ' .', 2) FROM t",
+			expected: "select list_filter(string_split(translate(s, ' .', '  '), ' '), lambda __e: __e <> '')[2] from t",
+		},
+		{
+			name:     "StrtokDefaults",
+			input:    "SELECT STRTOK(s) FROM t",
+			expected: "select list_filter(string_split(s, ' '), lambda __e: __e <> '')[1] from t",
+		},
+		{
+			name:     "Charindex",
+			input:    "SELECT CHARINDEX('b', s), CHARINDEX('b', s, 3) FROM t",
+			expected: "select instr(s, 'b'), CASE WHEN instr(substr(s, 3), 'b') = 0 THEN 0 ELSE instr(substr(s, 3), 'b') + 3 - 1 END from t",
+		},
+		{
+			name:     "PositionForms",
+			input:    "SELECT POSITION('b', s), POSITION('b' IN s) FROM t",
+			expected: "select instr(s, 'b'), instr(s, 'b') from t",
+		},
+		{
+			name:     "Insert",
+			input:    "SELECT INSERT(s, 3, 2, 'XYZ') FROM t",
+			expected: "select (substr(s, 1, (3) - 1) || 'XYZ' || substr(s, (3) + (2))) from t",
+		},
+		{
+			name:     "Concat",
+			input:    "SELECT UPPER(a) || '||' || b::STRING, a || 'x' = 'ax' FROM t",
+			expected: "select UPPER(a) || '||' || CAST(b AS VARCHAR), a || 'x' = 'ax' from t",
+		},
+		{
+			name:     "PadDefaultsAndEmpty",
+			input:    "SELECT LPAD(s, 5), RPAD(s, 5, '') FROM t",
+			expected: "select lpad(s, 5, ' '), left(s, greatest(5, 0)) from t",
+		},
+		{
+			name:     "RegexpSubstr",
+			input:    "SELECT REGEXP_SUBSTR(s, '([a-z])-', 2, 1, 'ie') FROM t",
+			expected: "select regexp_extract_all(substr(s, 2), ('(?i)' || '([a-z])-'), 1)[1] from t",
+		},
+		{
+			name:     "RegexpReplaceAll",
+			input:    "SELECT REGEXP_REPLACE(s, '[0-9]') FROM t",
+			expected: "select regexp_replace(s, '[0-9]', '', 'g') from t",
+		},
+		{
+			name:     "RegexpReplaceFirstFromPosition",
+			input:    "SELECT REGEXP_REPLACE(s, '[0-9]', 'X', 3, 1) FROM t",
+			expected: "select (substr(s, 1, (3) - 1) || regexp_replace(substr(s, 3), '[0-9]', 'X', '')) from t",
+		},
+		{
+			name:     "RegexpCount",
+			input:    "SELECT REGEXP_COUNT(s, 'a', 1, 'i') FROM t",
+			expected: "select len(regexp_extract_all(s, ('(?i)' || 'a'))) from t",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTranslator().Translate(tt.input)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_StringFunctionPack tests the string functions against DuckDB.
+func TestExecutor_StringFunctionPack(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	for _, sql := range []string{
+		"CREATE TABLE parts (s VARCHAR, sep VARCHAR, n INTEGER)",
+		"INSERT INTO parts VALUES ('a,b,,c', ',', 0)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sql  string
+		// want holds the values as fmt.Sprint formats them
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Split",
+			sql:  "SELECT ARRAY_TO_STRING(SPLIT('a,b,c', ','), '|'), ARRAY_SIZE(SPLIT('', ',')), ARRAY_TO_STRING(SPLIT('abc', ''), '|'), TYPEOF(SPLIT('a', ','))",
+			want: []string{"a|b|c", "1", "abc", "ARRAY"},
+		},
+		{
+			name: "SplitPart",
+			sql:  "SELECT SPLIT_PART('a,b,c', ',', 0), SPLIT_PART('a,b,c', ',', -1), SPLIT_PART('a,b,c', ',', 5), SPLIT_PART('abc', '', 1)",
+			want: []string{"a", "c", "", "abc"},
+		},
+		{
+			name: "SplitPartColumns",
+			sql:  "SELECT SPLIT_PART(s, sep, n), STRTOK(s, sep, 3) FROM parts",
+			want: []string{"a", "c"},
+		},
+		{
+			name: "Strtok",
+			sql:  "SELECT STRTOK('a.b c', ' .', 2), STRTOK('hello world'), STRTOK('a,,b', ',', 2), STRTOK('a,,b', ',', 3), STRTOK('', ',')",
+			want: []string{"b", "hello", "b", "<nil>", "<nil>"},
+		},
+		{
+			name: "CharindexAndPosition",
+			sql:  "SELECT CHARINDEX('b', 'abcb'), CHARINDEX('b', 'abcb', 3), CHARINDEX('z', 'abc', 2), POSITION('b', 'abcb', 3), POSITION('c' IN 'abcb')",
+			want: []string{"2", "4", "0", "4", "3"},
+		},
+		{
+			name: "Insert",
+			sql:  "SELECT INSERT('abcdef', 3, 2, 'XYZ'), INSERT('abc', 4, 0, 'd')",
+			want: []string{"abXYZef", "abcd"},
+		},
+		{
+			name: "Concat",
+			sql:  "SELECT 'a' || 1 + 1 || 'b', UPPER('x') || 5::STRING, 'a' || 'b' = 'ab'",
+			want: []string{"a2b", "X5", "true"},
+		},
+		{
+			name: "Pad",
+			sql:  "SELECT LPAD('abc', 5, ''), LPAD('abc', 5), RPAD('abc', 6, 'xy'), LPAD('abc', -1, 'x'), RPAD('abc', 2, 'x')",
+			want: []string{"abc", "  abc", "abcxyx", "", "ab"},
+		},
+		{
+			name: "RegexpSubstr",
+			sql:  "SELECT REGEXP_SUBSTR('a1b22c333', '[0-9]+', 1, 2), REGEXP_SUBSTR('a1b22c333', '[0-9]+', 4), REGEXP_SUBSTR('abc', 'z'), REGEXP_SUBSTR('Ab-cd', '([a-z])(-)', 1, 1, 'ie'), REGEXP_SUBSTR('Ab-cd', '([a-z])(-)', 1, 1, 'e', 2)",
+			want: []string{"22", "22", "<nil>", "b", "-"},
+		},
+		{
+			name: "RegexpReplace",
+			sql:  "SELECT REGEXP_REPLACE('a1b2', '[0-9]', 'X'), REGEXP_REPLACE('a1b2', '[0-9]'), REGEXP_REPLACE('a1b2c3', '[0-9]', 'X', 3), REGEXP_REPLACE('a1b2', '[0-9]', 'X', 1, 1), REGEXP_REPLACE('AbA', 'a', '-', 1, 0, 'i')",
+			want: []string{"aXbX", "ab", "a1bXcX", "aXb2", "-b-"},
+		},
+		{
+			name:    "RegexpReplaceOccurrence",
+			sql:     "SELECT REGEXP_REPLACE('a1b2', '[0-9]', 'X', 1, 2)",
+			wantErr: true,
+		},
+		{
+			name: "RegexpCount",
+			sql:  "SELECT REGEXP_COUNT('a1b2', '[0-9]'), REGEXP_COUNT('a1b2c3', '[0-9]', 3), REGEXP_COUNT('AaA', 'a', 1, 'i'), REGEXP_COUNT('x12y', '\\\\d')",
+			want: []string{"2", "2", "3", "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]string, len(result.Rows[0]))
+			for i, value := range result.Rows[0] {
+				got[i] = fmt.Sprint(value)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// columnOptionKeywords end the type of a column definition, and the
+// expression of its DEFAULT.
+var columnOptionKeywords = map[string]bool{
+	"NOT":           true,
+	"NULL":          true,
+	"DEFAULT":       true,
+	"PRIMARY":       true,
+	"UNIQUE":        true,
+	"REFERENCES":    true,
+	"FOREIGN":       true,
+	"CONSTRAINT":    true,
+	"CHECK":         true,
+	"COLLATE":       true,
+	"AUTOINCREMENT": true,
+	"IDENTITY":      true,
+	"WITH":          true,
+	"MASKING":       true,
+	"TAG":           true,
+	"AS":            true,
+	"COMMENT":       true,
+}
+
+// registerTable records a table created with SQL in the metadata store, so
+// that catalog listings and the REST API show it. Its columns are parsed from
+// the column list, or inferred from the created table for CREATE TABLE ... AS
+// SELECT, LIKE, and CLONE. Tables are registered under the database and schema
+// they are created in, which must be registered, except for the PUBLIC schema,
+// which is registered with its first table. Temporary tables are not
+// registered, as they only exist in their session.
+func (e *Executor) registerTable(ctx context.Context, stmt *createStatement) error {
+	if stmt.Temporary {
+		return nil
+	}
+	database, _, _ := splitObjectName(ctx, stmt.Name)
+	schema, name, err := e.objectSchema(ctx, stmt.Name, true)
+	if err != nil {
+		return nil
+	}
+	existing, err := e.repo.GetTableByName(ctx, schema.ID, name)
+	if err == nil {
+		if stmt.IfNotExists || e.tableExists(ctx, database, schema.Name+"_"+existing.Name) {
+			// The statement did not create a table, or created one over a table of the REST API
+			return nil
+		}
+	}
+
+	columns, ok := parseColumnDefinitions(stmt)
+	if !ok {
+		columns, err = e.viewColumns(ctx, stmt.Name)
+		if err != nil {
+			return err
+		}
+	}
+	comment := ""
+	if stmt.Comment != nil {
+		comment = *stmt.Comment
+	}
+	if _, err := e.repo.RegisterTable(ctx, schema.ID, name, columns, comment); err != nil {
+		return fmt.Errorf("failed to register table: %w", err)
+	}
+	return nil
+}
+
+// unregisterTable removes a table dropped with SQL from the metadata store.
+// Tables of the REST API stay registered, as DROP TABLE does not drop them.
+func (e *Executor) unregisterTable(ctx context.Context, sql string) error {
+	tableName, ok := dropTableName(sql)
+	if !ok {
+		return nil
+	}
+	database, _, _ := splitObjectName(ctx, tableName)
+	schema, name, ok := e.lookupObjectSchema(ctx, tableName)
+	if !ok {
+		return nil
+	}
+	table, err := e.repo.GetTableByName(ctx, schema.ID, name)
+	if err != nil || table.TableType != "BASE TABLE" || e.tableExists(ctx, database, schema.Name+"_"+table.Name) {
+		return nil
+	}
+	if err := e.repo.DropTable(ctx, table.ID); err != nil {
+		return fmt.Errorf("failed to unregister table: %w", err)
+	}
+	return nil
+}
+
+// dropTableName returns the name of the table dropped by a DROP TABLE statement.
+func dropTableName(sql string) (string, bool) {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(stripLeadingComments(sql)), ";"))
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "DROP") || !strings.EqualFold(fields[1], "TABLE") {
+		return "", false
+	}
+	fields = fields[2:]
+	if len(fields) >= 3 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") {
+		fields = fields[2:]
+	}
+	return fields[0], true
+}
+
+// parseColumnDefinitions parses the column list of a CREATE TABLE statement
+// into the names, types, NOT NULL constraints, DEFAULT expressions, and
+// primary keys of its columns. Unquoted names are upper-cased, as Snowflake
+// stores them, and types are kept as written. A PRIMARY KEY table constraint
+// marks its columns; other table constraints are skipped. It reports false if
+// the statement has no column list with types, such as a CREATE TABLE ... AS
+// SELECT.
+func parseColumnDefinitions(stmt *createStatement) ([]metadata.ColumnDef, bool) {
+	if stmt.Query != "" {
+		return nil, false
+	}
+	sql := stmt.SQL
+	open := -1
+	for i := 0; i < len(sql) && open < 0; i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '(':
+			open = i
+		case keywordAt(sql, i, "LIKE") || keywordAt(sql, i, "CLONE"):
+			return nil, false
+		}
+	}
+	if open < 0 {
+		return nil, false
+	}
+	closing := matchingParen(sql[open:])
+	if closing < 0 {
+		return nil, false
+	}
+
+	var columns []metadata.ColumnDef
+	var primaryKey []string
+	for _, segment := range splitFunctionArgs(sql[open+1:open+closing], 0) {
+		tokens := definitionTokens(segment)
+		if len(tokens) == 0 {
+			continue
+		}
+		if columnConstraintKeywords[strings.ToUpper(tokens[0])] {
+			primaryKey = append(primaryKey, constraintPrimaryKey(tokens)...)
+			continue
+		}
+		column, ok := parseColumnDefinition(tokens)
+		if !ok {
+			return nil, false
+		}
+		columns = append(columns, column)
+	}
+	for _, name := range primaryKey {
+		for i := range columns {
+			if columns[i].Name == name {
+				columns[i].PrimaryKey = true
+				columns[i].Nullable = false
+			}
+		}
+	}
+	return columns, len(columns) > 0
+}
+
+// parseColumnDefinition parses the tokens of a "name type [options]" column
+// definition.
+func parseColumnDefinition(tokens []string) (metadata.ColumnDef, bool) {
+	column := metadata.ColumnDef{Name: columnName(tokens[0]), Nullable: true}
+	i := 1
+	for i < len(tokens) && !columnOptionKeywords[strings.ToUpper(tokens[i])] {
+		i++
+	}
+	column.Type = columnType(tokens[1:i])
+	if column.Type == "" {
+		return metadata.ColumnDef{}, false
+	}
+
+	for i < len(tokens) {
+		switch keyword := strings.ToUpper(tokens[i]); keyword {
+		case "NOT":
+			column.Nullable = false
+			i += 2
+		case "NULL":
+			column.Nullable = true
+			i++
+		case "PRIMARY":
+			column.PrimaryKey = true
+			column.Nullable = false
+			i += 2
+		case "DEFAULT":
+			// The default is at least one token, so that DEFAULT NULL keeps its NULL
+			end := i + 2
+			for end < len(tokens) && !columnOptionKeywords[strings.ToUpper(tokens[end])] {
+				end++
+			}
+			if i+1 < len(tokens) {
+				expr := strings.Join(tokens[i+1:min(end, len(tokens))], " ")
+				column.Default = &expr
+			}
+			i = end
+		case "CONSTRAINT":
+			// The constraint's name
+			i += 2
+		default:
+			i++
+		}
+	}
+	return column, true
+}
+
+// columnType returns the type declared by tokens, upper-cased, with its
+// parameters written without spaces, such as NUMBER(10,2).
+func columnType(tokens []string) string {
+	var dataType strings.Builder
+	for _, token := range tokens {
+		if dataType.Len() > 0 && !strings.HasPrefix(token, "(") {
+			dataType.WriteByte(' ')
+		}
+		dataType.WriteString(strings.ToUpper(strings.Join(strings.Fields(token), "")))
+	}
+	return dataType.String()
+}
+
+// constraintPrimaryKey returns the columns of a "[CONSTRAINT name] PRIMARY KEY
+// (columns)" table constraint, or nil for other table constraints.
+func constraintPrimaryKey(tokens []string) []string {
+	if strings.EqualFold(tokens[0], "CONSTRAINT") && len(tokens) > 2 {
+		tokens = tokens[2:]
+	}
+	if len(tokens) < 2 || !strings.EqualFold(tokens[0], "PRIMARY") {
+		return nil
+	}
+	list := strings.TrimSpace(strings.Join(tokens[1:], " "))
+	if len(list) < 3 || !strings.EqualFold(list[:3], "KEY") {
+		return nil
+	}
+	list = strings.TrimSpace(list[3:])
+	if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+		return nil
+	}
+	var names []string
+	for _, name := range strings.Split(list[1:len(list)-1], ",") {
+		names = append(names, columnName(strings.TrimSpace(name)))
+	}
+	return names
+}
+
+// columnName returns the name Snowflake stores for a column name as written:
+// quoted names as they are, and unquoted names upper-cased.
+func columnName(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		return unquoteIdentifier(name)
+	}
+	return strings.ToUpper(name)
+}
+
+// definitionTokens splits a column definition into its words. Quoted strings
+// and identifiers and parenthesized groups are kept whole, and a group that
+// directly follows a word, such as the precision of NUMBER(10, 2), is part of
+// that word.
+func definitionTokens(s string) []string {
+	var tokens []string
+	start := -1
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isSpace(c) && depth == 0 {
+			if start >= 0 {
+				tokens = append(tokens, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		switch c {
+		case '\'', '"':
+			i = skipQuoted(s, i, c)
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,144 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// TestParseColumnDefinitions tests parsing the column list of CREATE TABLE statements.
+func TestParseColumnDefinitions(t *testing.T) {
+	text := func(s string) *string { return &s }
+	tests := []struct {
+		name   string
+		sql    string
+		want   []metadata.ColumnDef
+		wantOK bool
+	}{
+		{
+			name: "TypesAndOptions",
+			sql: "CREATE TABLE t (id INTEGER NOT NULL, amount NUMBER (10, 2) DEFAULT 0.0, note varchar(100) NULL COMMENT 'free text', " +
+				`"Mixed Case" DOUBLE PRECISION, created TIMESTAMP_NTZ DEFAULT CURRENT_TIMESTAMP() NOT NULL, tag VARCHAR DEFAULT NULL)`,
+			want: []metadata.ColumnDef{
+				{Name: "ID", Type: "INTEGER"},
+				{Name: "AMOUNT", Type: "NUMBER(10,2)", Nullable: true, Default: text("0.0")},
+				{Name: "NOTE", Type: "VARCHAR(100)", Nullable: true},
+				{Name: "Mixed Case", Type: "DOUBLE PRECISION", Nullable: true},
+				{Name: "CREATED", Type: "TIMESTAMP_NTZ", Default: text("CURRENT_TIMESTAMP()")},
+				{Name: "TAG", Type: "VARCHAR", Nullable: true, Default: text("NULL")},
+			},
+			wantOK: true,
+		},
+		{
+			name: "InlinePrimaryKey",
+			sql:  "CREATE OR REPLACE TABLE t (id NUMBER CONSTRAINT pk PRIMARY KEY, name VARCHAR DEFAULT 'a, b' UNIQUE)",
+			want: []metadata.ColumnDef{
+				{Name: "ID", Type: "NUMBER", PrimaryKey: true},
+				{Name: "NAME", Type: "VARCHAR", Nullable: true, Default: text("'a, b'")},
+			},
+			wantOK: true,
+		},
+		{
+			name: "TablePrimaryKey",
+			sql:  "CREATE TABLE t (a INT, b INT, c INT, CONSTRAINT pk PRIMARY KEY (a, b), UNIQUE (c), FOREIGN KEY (c) REFERENCES u (id))",
+			want: []metadata.ColumnDef{
+				{Name: "A", Type: "INT", PrimaryKey: true},
+				{Name: "B", Type: "INT", PrimaryKey: true},
+				{Name: "C", Type: "INT", Nullable: true},
+			},
+			wantOK: true,
+		},
+		{name: "AsSelect", sql: "CREATE TABLE t AS SELECT 1 AS id"},
+		{name: "AsSelectWithColumnNames", sql: "CREATE TABLE t (id) AS SELECT 1"},
+		{name: "Like", sql: "CREATE TABLE t LIKE u"},
+		{name: "Clone", sql: "CREATE TABLE t CLONE u"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, ok := parseCreateStatement(tt.sql)
+			if !ok {
+				t.Fatalf("parseCreateStatement(%q) failed", tt.sql)
+			}
+			got, ok := parseColumnDefinitions(stmt)
+			if ok != tt.wantOK {
+				t.Fatalf("parseColumnDefinitions() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseColumnDefinitions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_RegisterTable tests that tables created with SQL are registered
+// in the metadata store with their columns, and unregistered when dropped.
+func TestExecutor_RegisterTable(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	db, err := repo.CreateDatabase(ctx, "TEST_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "TEST_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, amount NUMBER(10,2) NOT NULL DEFAULT 0, note VARCHAR) COMMENT = 'All orders'",
+		"CREATE TABLE IF NOT EXISTS orders (other VARCHAR)",
+		"CREATE TABLE order_copy AS SELECT id, note FROM orders",
+		"CREATE TEMPORARY TABLE scratch (id INTEGER)",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	// The PUBLIC schema is registered with the first table created in it
+	schema, err := repo.GetSchemaByName(ctx, db.ID, "PUBLIC")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	tables, err := repo.ListTables(ctx, schema.ID)
+	if err != nil {
+		t.Fatalf("ListTables() error = %v", err)
+	}
+	got := make(map[string][]metadata.ColumnDef)
+	for _, table := range tables {
+		if table.TableType != "BASE TABLE" {
+			t.Errorf("table %s type = %q, want BASE TABLE", table.Name, table.TableType)
+		}
+		got[table.Name] = metadata.ParseColumnDefs(table.ColumnDefinitions)
+	}
+	zero := "0"
+	want := map[string][]metadata.ColumnDef{
+		"ORDERS": {
+			{Name: "ID", Type: "INTEGER", PrimaryKey: true},
+			{Name: "AMOUNT", Type: "NUMBER(10,2)", Default: &zero},
+			{Name: "NOTE", Type: "VARCHAR", Nullable: true},
+		},
+		"ORDER_COPY": {
+			{Name: "id", Type: "NUMBER(38,0)", Nullable: true},
+			{Name: "note", Type: "VARCHAR", Nullable: true},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("registered tables mismatch (-want +got):\n%s", diff)
+	}
+	orders, err := repo.GetTableByName(ctx, schema.ID, "ORDERS")
+	if err != nil || orders.Comment != "All orders" {
+		t.Errorf("GetTableByName() = %+v, %v, want comment %q", orders, err, "All orders")
+	}
+
+	if _, err := executor.Execute(ctx, "DROP TABLE IF EXISTS orders"); err != nil {
+		t.Fatalf("Execute() drop error = %v", err)
+	}
+	if _, err := executor.Query(ctx, "SELECT * FROM orders"); err == nil {
+		t.Error("Query() of a dropped table error = nil, want error")
+	}
+	if _, err := repo.GetTableByName(ctx, schema.ID, "ORDERS"); err == nil {
+		t.Error("GetTableByName() after DROP TABLE error = nil, want error")
+	}
+}
@@ -0,0 +1,204 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// numberFormatModel matches a Snowflake numeric format model: an optional FM
+// modifier, a leading sign, a dollar sign, the digits of the integer part with
+// group separators, the digits of the fraction, and a trailing sign.
+var numberFormatModel = regexp.MustCompile(`(?i)^(FM)?(S)?(\$)?([09,]*)(?:\.([09]*))?(MI|S)?$`)
+
+// hexFormatModel matches a Snowflake hexadecimal format model, such as 0XXX.
+var hexFormatModel = regexp.MustCompile(`(?i)^(FM)?(0*)(X+)$`)
+
+// strftimeElements maps the Snowflake date and time format elements to
+// DuckDB's strftime specifiers. DuckDB keeps microseconds, so FF0 to FF9 are
+// rounded up to milliseconds, microseconds, or nanoseconds with zeros.
+var strftimeElements = map[string]string{
+	"YYYY": "%Y", "YY": "%y", "MMMM": "%B", "MON": "%b", "MM": "%m", "DD": "%d", "DY": "%a",
+	"HH24": "%H", "HH": "%H", "HH12": "%I", "AM": "%p", "PM": "%p", "MI": "%M", "SS": "%S",
+}
+
+// registerToCharFunctions registers translations for TO_CHAR and TO_VARCHAR.
+// They are resolved in transformToChar.
+func (t *Translator) registerToCharFunctions() {
+	t.functionMap["TO_CHAR"] = markFunction("__TO_CHAR__")
+	t.functionMap["TO_VARCHAR"] = markFunction("__TO_CHAR__")
+}
+
+// transformToChar resolves the TO_CHAR markers. Without a format the value is
+// cast to VARCHAR. A format literal is translated into a strftime() call for
+// date and time formats, such as YYYY-MM-DD HH24:MI:SS, or into a format()
+// expression for numeric formats, such as 999,999.00 or FM$9,990.00. Formats
+// that are not literals are left to fail in DuckDB.
+func (t *Translator) transformToChar(sql string) string {
+	return t.transformMarkedFunction(sql, "__TO_CHAR__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		switch len(parts) {
+		case 1:
+			return fmt.Sprintf("CAST(%s AS VARCHAR)", strings.TrimSpace(parts[0]))
+		case 2:
+			value := strings.TrimSpace(parts[0])
+			if format, ok := stringLiteral(parts[1]); ok {
+				if expr, ok := numberFormat(value, format); ok {
+					return expr
+				}
+				return fmt.Sprintf("strftime(%s, %s)", value, quoteLiteral(strftimeFormat(format)))
+			}
+		}
+		return "to_char(" + args + ")"
+	})
+}
+
+// stringLiteral returns the value of a single-quoted SQL string literal.
+func stringLiteral(arg string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < 2 || arg[0] != '\'' || skipQuoted(arg, 0, '\'') != len(arg)-1 {
+		return "", false
+	}
+	return strings.ReplaceAll(arg[1:len(arg)-1], "''", "'"), true
+}
+
+// strftimeFormat converts a Snowflake date and time format into a strftime
+// format. Elements are matched case-insensitively, text in double quotes is
+// copied literally, and other characters are copied as-is, like FormatDateTime.
+func strftimeFormat(format string) string {
+	var b strings.Builder
+	literal := func(s string) { b.WriteString(strings.ReplaceAll(s, "%", "%%")) }
+	for i := 0; i < len(format); {
+		if format[i] == '"' {
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				literal(format[i+1:])
+				break
+			}
+			literal(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		element := ""
+		for _, e := range outputFormatElements {
+			if len(format)-i >= len(e) && strings.EqualFold(format[i:i+len(e)], e) {
+				element = e
+				break
+			}
+		}
+		specifier, ok := strftimeElements[element]
+		switch {
+		case element == "FF":
+			i += len(element)
+			digits := 9
+			if i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				digits = int(format[i] - '0')
+				i++
+			}
+			switch {
+			case digits == 0:
+			case digits <= 3:
+				b.WriteString("%g")
+			case digits <= 6:
+				b.WriteString("%f")
+			default:
+				b.WriteString("%f000")
+			}
+		case ok:
+			i += len(element)
+			b.WriteString(specifier)
+		default:
+			literal(format[i : i+1])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// numberFormat returns the expression rendering value with a Snowflake
+// numeric format model, and false if format is not one. Like Snowflake, the
+// result is right-aligned to the width of the format, with a position for the
+// sign unless the format has one, and is all # if the integer part does not
+// fit. The FM modifier drops the padding and the sign's position.
+func numberFormat(value, format string) (string, bool) {
+	if match := hexFormatModel.FindStringSubmatch(format); match != nil {
+		verb := "X"
+		if match[3][0] == 'x' {
+			verb = "x"
+		}
+		digits := len(match[2]) + len(match[3])
+		spec := verb
+		if len(match[2]) > 0 {
+			spec = fmt.Sprintf("0%d%s", digits, verb)
+		}
+		expr := fmt.Sprintf("format('{:%s}', CAST(%s AS BIGINT))", spec, value)
+		overflow := fmt.Sprintf("CAST(%s AS BIGINT) >= %d", value, uint64(1)<<(4*min(digits, 15)))
+		if match[1] == "" {
+			return fmt.Sprintf("CASE WHEN %s THEN repeat('#', %d) ELSE lpad(%s, %d, ' ') END", overflow, digits+1, expr, digits+1), true
+		}
+		return fmt.Sprintf("CASE WHEN %s THEN repeat('#', %d) ELSE %s END", overflow, digits, expr), true
+	}
+
+	match := numberFormatModel.FindStringSubmatch(format)
+	if match == nil {
+		return "", false
+	}
+	fill, leadingSign, dollar, integer, fraction, trailingSign := match[1] == "", match[2] != "", match[3] != "", match[4], match[5], strings.ToUpper(match[6])
+	integerDigits := strings.Count(integer, "9") + strings.Count(integer, "0")
+	if integerDigits+len(fraction) == 0 {
+		return "", false
+	}
+
+	rounded := fmt.Sprintf("round(CAST(%s AS DOUBLE), %d)", value, len(fraction))
+	spec := ""
+	if strings.Contains(integer, ",") {
+		spec = ","
+	}
+	// Leading zeros are kept from the first 0 of the integer part on. DuckDB's
+	// format() cannot pad grouped digits with zeros, so those are not padded.
+	if zeros := strings.IndexByte(integer, '0'); zeros >= 0 && spec == "" {
+		width := len(integer) - zeros
+		if len(fraction) > 0 {
+			width += 1 + len(fraction)
+		}
+		spec = fmt.Sprintf("0%d%s", width, spec)
+	}
+	digits := fmt.Sprintf("format('{:%s.%df}', abs(%s))", spec, len(fraction), rounded)
+	if dollar {
+		digits = "'$' || " + digits
+	}
+
+	width := len(format) - len(match[1])
+	var expr string
+	switch {
+	case leadingSign || trailingSign == "S":
+		sign := fmt.Sprintf("CASE WHEN %s < 0 THEN '-' ELSE '+' END", rounded)
+		if leadingSign {
+			expr = sign + " || " + digits
+		} else {
+			expr = digits + " || " + sign
+		}
+	case trailingSign == "MI":
+		expr = fmt.Sprintf("%s || CASE WHEN %s < 0 THEN '-' ELSE %s END", digits, rounded, fillText(fill, " "))
+	default:
+		expr = fmt.Sprintf("CASE WHEN %s < 0 THEN '-' ELSE %s END || %s", rounded, fillText(fill, " "), digits)
+		if fill {
+			width++
+		}
+	}
+	overflow := fmt.Sprintf("abs(%s) >= 1e%d", rounded, integerDigits)
+	if fill {
+		expr = fmt.Sprintf("lpad(%s, %d, ' ')", expr, width)
+	}
+	return fmt.Sprintf("CASE WHEN %s THEN repeat('#', %d) ELSE %s END", overflow, width, expr), true
+}
+
+// fillText returns text as a SQL literal when fill mode pads the result, and
+// the empty string literal when the FM modifier drops padding.
+func fillText(fill bool, text string) string {
+	if fill {
+		return quoteLiteral(text)
+	}
+	return "''"
+}
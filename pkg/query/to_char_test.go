@@ -0,0 +1,46 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_ToChar tests that TO_CHAR and TO_VARCHAR render dates, times,
+// and numbers with Snowflake format models.
+func TestExecutor_ToChar(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"DateTime", "SELECT TO_CHAR(CAST('2024-03-05 14:07:09.123456' AS TIMESTAMP), 'YYYY-MM-DD HH24:MI:SS.FF3')", "2024-03-05 14:07:09.123"},
+		{"QuotedText", `SELECT TO_VARCHAR(CAST('2024-03-05' AS DATE), 'DY, DD MON YYYY "at" 100%')`, "Tue, 05 Mar 2024 at 100%"},
+		{"TwelveHourClock", "SELECT TO_CHAR(CAST('2024-03-05 14:07:09' AS TIMESTAMP), 'hh12:mi am')", "02:07 PM"},
+		{"NoFormat", "SELECT TO_VARCHAR(42)", "42"},
+		{"Grouped", "SELECT TO_CHAR(1234.5, '999,999.00')", "   1,234.50"},
+		{"FillMode", "SELECT TO_CHAR(-1234.567, 'FM999,999.00')", "-1,234.57"},
+		{"Dollar", "SELECT TO_CHAR(12.5, 'FM$9,990.00')", "$12.50"},
+		{"LeadingZeros", "SELECT TO_CHAR(5, '000')", " 005"},
+		{"LeadingSign", "SELECT TO_CHAR(3, 'S99')", " +3"},
+		{"TrailingMinus", "SELECT TO_CHAR(-3, '99MI')", "  3-"},
+		{"Overflow", "SELECT TO_CHAR(1234567, '999,999')", "########"},
+		{"Hexadecimal", "SELECT TO_CHAR(255, 'FM0XXX')", "00FF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff([][]interface{}{{tt.want}}, result.Rows); diff != "" {
+				t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Transaction is an open transaction of a session.
+type Transaction struct {
+	ID        int64
+	SessionID string
+	User      string
+	StartedAt time.Time
+	// Locks are the tables the transaction has written, in the order it first
+	// wrote them.
+	Locks []Lock
+}
+
+// Lock is a table held by an open transaction because the transaction wrote it.
+type Lock struct {
+	// Resource is the fully qualified table name.
+	Resource   string
+	AcquiredAt time.Time
+	// QueryID is the ID of the statement that first wrote the table, if known.
+	QueryID string
+}
+
+// sessionTransaction is an open transaction and the connection it runs on.
+// Statements of the session run on conn until the transaction ends.
+type sessionTransaction struct {
+	Transaction
+	conn *sql.Conn
+	// savepoints are the names of the transaction's savepoints, oldest first.
+	savepoints []string
+}
+
+// transactionRegistry tracks the open transaction of each session.
+type transactionRegistry struct {
+	mu     sync.Mutex
+	open   map[string]*sessionTransaction
+	lastID int64
+}
+
+// showTransactionsNames are the columns of a SHOW TRANSACTIONS result.
+var showTransactionsNames = []string{"id", "user", "session", "name", "started_on", "state", "scope"}
+
+// showLocksNames are the columns of a SHOW LOCKS result.
+var showLocksNames = []string{"resource", "type", "transaction", "transaction_started_on", "status", "acquired_on", "query_id"}
+
+// queryIDKey is the context key for the ID of the statement being executed.
+type queryIDKey struct{}
+
+// contextWithQueryID returns a copy of ctx carrying the ID of the statement being executed.
+func contextWithQueryID(ctx context.Context, queryID string) context.Context {
+	return context.WithValue(ctx, queryIDKey{}, queryID)
+}
+
+// queryIDFromContext returns the ID of the statement being executed, or "".
+func queryIDFromContext(ctx context.Context) string {
+	queryID, _ := ctx.Value(queryIDKey{}).(string)
+	return queryID
+}
+
+// Transactions returns the open transactions of all sessions, oldest first.
+func (e *Executor) Transactions() []Transaction {
+	e.transactions.mu.Lock()
+	defer e.transactions.mu.Unlock()
+
+	transactions := make([]Transaction, 0, len(e.transactions.open))
+	for _, tx := range e.transactions.open {
+		t := tx.Transaction
+		t.Locks = append([]Lock(nil), tx.Locks...)
+		transactions = append(transactions, t)
+	}
+	sort.Slice(transactions, func(i, j int) bool { return transactions[i].ID < transactions[j].ID })
+	return transactions
+}
+
+// withTransaction returns ctx pinned to the connection of the session's open
+// transaction, or ctx unchanged if the session has none.
+func (e *Executor) withTransaction(ctx context.Context) context.Context {
+	e.transactions.mu.Lock()
+	defer e.transactions.mu.Unlock()
+
+	if tx, ok := e.transactions.open[SessionInfoFromContext(ctx).ID]; ok {
+		return connection.ContextWithConn(ctx, tx.conn)
+	}
+	return ctx
+}
+
+// withImplicitTransaction returns ctx pinned to the session's open transaction
+// like withTransaction. When the session's AUTOCOMMIT is off and sql is DML, a
+// transaction is opened first if the session has none, as Snowflake does.
+func (e *Executor) withImplicitTransaction(ctx context.Context, sql string) (context.Context, error) {
+	if !SessionParametersFromContext(ctx).NoAutocommit || !startsImplicitTransaction(sql) {
+		return e.withTransaction(ctx), nil
+	}
+
+	e.transactions.mu.Lock()
+	_, open := e.transactions.open[SessionInfoFromContext(ctx).ID]
+	e.transactions.mu.Unlock()
+	if !open {
+		if _, err := e.beginTransaction(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return e.withTransaction(ctx), nil
+}
+
+// startsImplicitTransaction reports whether sql is DML, which opens a
+// transaction when AUTOCOMMIT is off.
+func startsImplicitTransaction(sql string) bool {
+	_, ok := dmlTarget(sql)
+	return ok || IsCopy(sql)
+}
+
+// RollbackSession rolls back the open transaction of a session, if any. Like
+// Snowflake, a transaction still open when its session ends is rolled back.
+func (e *Executor) RollbackSession(ctx context.Context, sessionID string) error {
+	_, err := e.endTransaction(ContextWithSessionInfo(ctx, SessionInfo{ID: sessionID}), "ROLLBACK")
+	return err
+}
+
+// beginTransaction opens a transaction for the session on a dedicated
+// connection. Like Snowflake, BEGIN inside an open transaction is ignored; the
+// result carries a warning instead.
+func (e *Executor) beginTransaction(ctx context.Context) (*ExecResult, error) {
+	info := SessionInfoFromContext(ctx)
+
+	e.transactions.mu.Lock()
+	_, open := e.transactions.open[info.ID]
+	e.transactions.mu.Unlock()
+	if open {
+		slog.Warn("BEGIN ignored inside an open transaction", slog.String("session", info.ID))
+		return &ExecResult{RowsAffected: 0, Warnings: []string{
+			"BEGIN ignored: a transaction is already open in this session",
+		}}, nil
+	}
+
+	conn, err := e.manager(ctx).Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("transaction error: %w", err)
+	}
+	if _, err := e.manager(ctx).Exec(connection.ContextWithConn(ctx, conn), "BEGIN TRANSACTION"); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("transaction error: %w", err)
+	}
+
+	e.transactions.mu.Lock()
+	defer e.transactions.mu.Unlock()
+	if e.transactions.open == nil {
+		e.transactions.open = make(map[string]*sessionTransaction)
+	}
+	e.transactions.lastID++
+	e.transactions.open[info.ID] = &sessionTransaction{
+		Transaction: Transaction{
+			ID:        e.transactions.lastID,
+			SessionID: info.ID,
+			User:      info.User,
+			StartedAt: time.Now(),
+		},
+		conn: conn,
+	}
+	return &ExecResult{RowsAffected: 0}, nil
+}
+
+// endTransaction commits or rolls back the session's open transaction and
+// releases its connection. Like Snowflake, it does nothing if the session has
+// no open transaction.
+func (e *Executor) endTransaction(ctx context.Context, statement string) (*ExecResult, error) {
+	e.transactions.mu.Lock()
+	tx, ok := e.transactions.open[SessionInfoFromContext(ctx).ID]
+	if ok {
+		delete(e.transactions.open, tx.SessionID)
+	}
+	e.transactions.mu.Unlock()
+	if !ok {
+		return &ExecResult{RowsAffected: 0}, nil
+	}
+
+	// DuckDB ends the transaction even if COMMIT fails, so the connection is released either way
+	_, err := e.manager(ctx).Exec(connection.ContextWithConn(ctx, tx.conn), statement)
+	_ = tx.conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("transaction error: %w", err)
+	}
+	return &ExecResult{RowsAffected: 0}, nil
+}
+
+// recordStatement records the table written by a statement executed in the
+// session's open transaction as locked by the transaction.
+func (e *Executor) recordStatement(ctx context.Context, sql string) {
+	if IsTransaction(sql) {
+		return
+	}
+
+	e.transactions.mu.Lock()
+	defer e.transactions.mu.Unlock()
+	tx, ok := e.transactions.open[SessionInfoFromContext(ctx).ID]
+	if !ok {
+		return
+	}
+	target, ok := dmlTarget(sql)
+	if !ok {
+		return
+	}
+	resource := qualifiedName(ctx, target)
+	for _, lock := range tx.Locks {
+		if lock.Resource == resource {
+			return
+		}
+	}
+	tx.Locks = append(tx.Locks, Lock{Resource: resource, AcquiredAt: time.Now(), QueryID: queryIDFromContext(ctx)})
+}
+
+// abortTransaction rolls back the session's open transaction after a statement
+// in it ran out of memory or disk space, and returns the statement's error
+// noting the rollback. DuckDB aborts its transaction on such a failure, and its
+// earlier statements are not replayed, since statements such as ones calling
+// RANDOM or CURRENT_TIMESTAMP would write different data the second time.
+// Other errors are returned unchanged.
+func (e *Executor) abortTransaction(ctx context.Context, err error) error {
+	if !connection.IsResourceError(err) {
+		return err
+	}
+
+	e.transactions.mu.Lock()
+	tx, ok := e.transactions.open[SessionInfoFromContext(ctx).ID]
+	e.transactions.mu.Unlock()
+	if !ok {
+		return err
+	}
+
+	slog.Warn("Transaction rolled back after running out of resources",
+		slog.String("session", tx.SessionID), slog.String("error", err.Error()))
+	_, _ = e.endTransaction(context.WithoutCancel(ctx), "ROLLBACK")
+	return fmt.Errorf("%w; transaction %d was rolled back", err, tx.ID)
+}
+
+// dmlTarget returns the table written by an INSERT, UPDATE, DELETE, MERGE, or
+// TRUNCATE statement.
+func dmlTarget(sql string) (string, bool) {
+	keywords := statementKeywords(sql)
+	if len(keywords) == 0 {
+		return "", false
+	}
+
+	var skip []string
+	switch keywords[0] {
+	case "INSERT":
+		skip = []string{"OVERWRITE", "INTO"}
+	case "UPDATE":
+	case "DELETE":
+		skip = []string{"FROM"}
+	case "MERGE":
+		skip = []string{"INTO"}
+	case "TRUNCATE":
+		skip = []string{"TABLE", "IF", "EXISTS"}
+	default:
+		return "", false
+	}
+
+	rest := keywords[1:]
+	for _, keyword := range skip {
+		if len(rest) > 0 && rest[0] == keyword {
+			rest = rest[1:]
+		}
+	}
+	if len(rest) == 0 {
+		return "", false
+	}
+	return rest[0], true
+}
+
+// showTransactionsKind reports whether sql is SHOW TRANSACTIONS or SHOW LOCKS,
+// optionally IN ACCOUNT, and returns which.
+func showTransactionsKind(sql string) (string, bool) {
+	keywords := statementKeywords(sql)
+	if len(keywords) < 2 || keywords[0] != "SHOW" || (keywords[1] != "TRANSACTIONS" && keywords[1] != "LOCKS") {
+		return "", false
+	}
+	return keywords[1], true
+}
+
+// queryShowTransactions lists the open transactions, or the locks they hold, of
+// all sessions.
+func (e *Executor) queryShowTransactions(kind string) *Result {
+	timestamp := types.ColumnMetadata{Type: "timestamp_ltz", Nullable: true}
+	text := types.ColumnMetadata{Type: "text", Nullable: true}
+	id := types.ColumnMetadata{Type: "fixed", Precision: 19, Scale: 0, Nullable: true}
+
+	result := &Result{}
+	if kind == "LOCKS" {
+		result.Columns = showLocksNames
+		for _, name := range showLocksNames {
+			col := text
+			switch name {
+			case "transaction":
+				col = id
+			case "transaction_started_on", "acquired_on":
+				col = timestamp
+			}
+			col.Name = name
+			result.ColumnTypes = append(result.ColumnTypes, col)
+		}
+		for _, tx := range e.Transactions() {
+			for _, lock := range tx.Locks {
+				result.Rows = append(result.Rows, []interface{}{
+					lock.Resource, "PARTITIONS", tx.ID, tx.StartedAt, "HOLDING", lock.AcquiredAt, lock.QueryID,
+				})
+			}
+		}
+		return result
+	}
+
+	result.Columns = showTransactionsNames
+	for _, name := range showTransactionsNames {
+		col := text
+		switch name {
+		case "id":
+			col = id
+		case "started_on":
+			col = timestamp
+		}
+		col.Name = name
+		result.ColumnTypes = append(result.ColumnTypes, col)
+	}
+	for _, tx := range e.Transactions() {
+		result.Rows = append(result.Rows, []interface{}{
+			tx.ID, tx.User, tx.SessionID, strconv.FormatInt(tx.ID, 10), tx.StartedAt, "running", "session",
+		})
+	}
+	return result
+}
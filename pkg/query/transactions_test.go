@@ -0,0 +1,236 @@
+package query
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+func TestExecutor_SessionTransactions(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	alice := ContextWithSessionInfo(ctx, SessionInfo{ID: "1", User: "ALICE"})
+	bob := ContextWithSessionInfo(ctx, SessionInfo{ID: "2", User: "BOB"})
+
+	if _, err := executor.Execute(ctx, "CREATE TABLE accounts (id INTEGER)"); err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+
+	if _, err := executor.Execute(alice, "BEGIN"); err != nil {
+		t.Fatalf("BEGIN error = %v", err)
+	}
+	nested, err := executor.Execute(alice, "BEGIN TRANSACTION")
+	if err != nil {
+		t.Fatalf("nested BEGIN error = %v", err)
+	}
+	if len(nested.Warnings) != 1 {
+		t.Errorf("nested BEGIN warnings = %v, want one warning", nested.Warnings)
+	}
+	if _, err := executor.ExecuteWithHistory(alice, "1", "query-1", "INSERT INTO accounts VALUES (1)"); err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+
+	// The other session does not see the uncommitted row
+	count := func(ctx context.Context) int64 {
+		t.Helper()
+		result, err := executor.Query(ctx, "SELECT COUNT(*) FROM accounts")
+		if err != nil {
+			t.Fatalf("SELECT error = %v", err)
+		}
+		return result.Rows[0][0].(int64)
+	}
+	if got := count(bob); got != 0 {
+		t.Errorf("other session count = %d, want 0", got)
+	}
+	if got := count(alice); got != 1 {
+		t.Errorf("own session count = %d, want 1", got)
+	}
+
+	transactions, err := executor.Query(bob, "SHOW TRANSACTIONS")
+	if err != nil {
+		t.Fatalf("SHOW TRANSACTIONS error = %v", err)
+	}
+	if diff := cmp.Diff(showTransactionsNames, transactions.Columns); diff != "" {
+		t.Errorf("SHOW TRANSACTIONS columns mismatch (-want +got):\n%s", diff)
+	}
+	if len(transactions.Rows) != 1 || transactions.Rows[0][1] != "ALICE" || transactions.Rows[0][2] != "1" {
+		t.Errorf("SHOW TRANSACTIONS rows = %v, want one transaction of ALICE in session 1", transactions.Rows)
+	}
+
+	locks, err := executor.Query(bob, "SHOW LOCKS IN ACCOUNT")
+	if err != nil {
+		t.Fatalf("SHOW LOCKS error = %v", err)
+	}
+	if len(locks.Rows) != 1 || locks.Rows[0][0] != "ACCOUNTS" || locks.Rows[0][6] != "query-1" {
+		t.Errorf("SHOW LOCKS rows = %v, want ACCOUNTS held by query-1", locks.Rows)
+	}
+
+	// COMMIT outside a transaction does nothing
+	if _, err := executor.Execute(bob, "COMMIT"); err != nil {
+		t.Errorf("COMMIT without a transaction error = %v", err)
+	}
+	if _, err := executor.Execute(alice, "COMMIT"); err != nil {
+		t.Fatalf("COMMIT error = %v", err)
+	}
+	if got := count(bob); got != 1 {
+		t.Errorf("count after COMMIT = %d, want 1", got)
+	}
+	if got := executor.Transactions(); len(got) != 0 {
+		t.Errorf("Transactions() after COMMIT = %v, want none", got)
+	}
+
+	// ROLLBACK discards the session's writes
+	if _, err := executor.Execute(alice, "BEGIN"); err != nil {
+		t.Fatalf("BEGIN error = %v", err)
+	}
+	if _, err := executor.Execute(alice, "DELETE FROM accounts"); err != nil {
+		t.Fatalf("DELETE error = %v", err)
+	}
+	if _, err := executor.Execute(alice, "ROLLBACK"); err != nil {
+		t.Fatalf("ROLLBACK error = %v", err)
+	}
+	if got := count(alice); got != 1 {
+		t.Errorf("count after ROLLBACK = %d, want 1", got)
+	}
+}
+
+func TestExecutor_AutocommitOff(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	manual := ContextWithSessionParameters(
+		ContextWithSessionInfo(ctx, SessionInfo{ID: "1"}), SessionParameters{NoAutocommit: true})
+	other := ContextWithSessionInfo(ctx, SessionInfo{ID: "2"})
+
+	count := func() int64 {
+		t.Helper()
+		result, err := executor.Query(other, "SELECT COUNT(*) FROM events")
+		if err != nil {
+			t.Fatalf("SELECT error = %v", err)
+		}
+		return result.Rows[0][0].(int64)
+	}
+
+	// DDL does not open a transaction
+	if _, err := executor.Execute(manual, "CREATE TABLE events (id INTEGER)"); err != nil {
+		t.Fatalf("CREATE TABLE error = %v", err)
+	}
+	if got := executor.Transactions(); len(got) != 0 {
+		t.Fatalf("Transactions() after DDL = %v, want none", got)
+	}
+
+	// DML opens one that stays open across statements until COMMIT
+	for _, sql := range []string{"INSERT INTO events VALUES (1)", "INSERT INTO events VALUES (2)"} {
+		if _, err := executor.Execute(manual, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	if got := executor.Transactions(); len(got) != 1 {
+		t.Fatalf("Transactions() after DML = %v, want one", got)
+	}
+	if got := count(); got != 0 {
+		t.Errorf("count before COMMIT = %d, want 0", got)
+	}
+	if _, err := executor.Execute(manual, "COMMIT"); err != nil {
+		t.Fatalf("COMMIT error = %v", err)
+	}
+	if got := count(); got != 2 {
+		t.Errorf("count after COMMIT = %d, want 2", got)
+	}
+
+	// Ending the session rolls back what it left open
+	if _, err := executor.Execute(manual, "DELETE FROM events"); err != nil {
+		t.Fatalf("DELETE error = %v", err)
+	}
+	if err := executor.RollbackSession(ctx, "1"); err != nil {
+		t.Fatalf("RollbackSession() error = %v", err)
+	}
+	if got := count(); got != 2 {
+		t.Errorf("count after RollbackSession = %d, want 2", got)
+	}
+	if got := executor.Transactions(); len(got) != 0 {
+		t.Errorf("Transactions() after RollbackSession = %v, want none", got)
+	}
+}
+
+// TestExecutor_TransactionOutOfMemory tests that a statement running out of
+// memory rolls back the session's open transaction instead of replaying it.
+func TestExecutor_TransactionOutOfMemory(t *testing.T) {
+	db, err := connection.Open(":memory:", connection.WithDatabaseMemoryLimit("64MB"), connection.WithTempDirectory(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	executor := NewExecutor(mgr, repo)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1", User: "ALICE"})
+
+	for _, sql := range []string{
+		"CREATE TABLE events (id INTEGER)",
+		"CREATE TABLE numbers AS SELECT range AS n FROM range(1000000)",
+		"BEGIN",
+		"INSERT INTO events VALUES (1)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	_, err = executor.Query(ctx, "SELECT LENGTH(LISTAGG(REPEAT('x', 1000), ',')) FROM numbers")
+	if !connection.IsOutOfMemoryError(err) || !strings.Contains(err.Error(), "was rolled back") {
+		t.Fatalf("Query() error = %v, want an out-of-memory error rolling back the transaction", err)
+	}
+	if got := executor.Transactions(); len(got) != 0 {
+		t.Errorf("Transactions() after running out of memory = %v, want none", got)
+	}
+
+	// Later statements run outside the rolled back transaction
+	for _, sql := range []string{"INSERT INTO events VALUES (2)", "COMMIT"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) after running out of memory error = %v", sql, err)
+		}
+	}
+	_, err = executor.Execute(ctx, "INSERT INTO events SELECT LENGTH(LISTAGG(REPEAT('x', 1000), ',')) FROM numbers")
+	if !connection.IsOutOfMemoryError(err) {
+		t.Fatalf("Execute() error = %v, want an out-of-memory error", err)
+	}
+	result, err := executor.Query(context.Background(), "SELECT id FROM events ORDER BY id")
+	if err != nil {
+		t.Fatalf("SELECT error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(2)}}, result.Rows); diff != "" {
+		t.Errorf("committed rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDMLTarget(t *testing.T) {
+	tests := []struct {
+		sql    string
+		want   string
+		wantOK bool
+	}{
+		{sql: "INSERT INTO db.s.t VALUES (1)", want: "DB.S.T", wantOK: true},
+		{sql: "INSERT OVERWRITE INTO t SELECT 1", want: "T", wantOK: true},
+		{sql: "UPDATE t SET a = 1", want: "T", wantOK: true},
+		{sql: "/* job */ DELETE FROM t WHERE a = 1", want: "T", wantOK: true},
+		{sql: "MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE", want: "T", wantOK: true},
+		{sql: "TRUNCATE TABLE IF EXISTS t", want: "T", wantOK: true},
+		{sql: "SELECT * FROM t", wantOK: false},
+		{sql: "CREATE TABLE t (a INT)", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			got, ok := dmlTarget(tt.sql)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("dmlTarget(%q) = (%q, %v), want (%q, %v)", tt.sql, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	duckdb "github.com/duckdb/duckdb-go/v2"
+)
+
+// endOfInput is the construct of a syntax error at the end of a statement.
+const endOfInput = "<EOF>"
+
+// TranslationError reports a statement that could not be translated or parsed,
+// locating the failing construct in the statement the client sent.
+type TranslationError struct {
+	// SQL is the statement, after session variables were substituted.
+	SQL string
+	// Construct is the token the failure is reported at, or <EOF> for a
+	// statement that ended early.
+	Construct string
+	// Offset is the byte offset of Construct in SQL.
+	Offset int
+	// Err is the error of the translator or DuckDB's parser.
+	Err error
+}
+
+// Line returns the 1-based line of the failing construct.
+func (e *TranslationError) Line() int {
+	return strings.Count(e.SQL[:e.offset()], "\n") + 1
+}
+
+// Position returns the 0-based position of the failing construct in its line,
+// as Snowflake reports it.
+func (e *TranslationError) Position() int {
+	offset := e.offset()
+	return offset - (strings.LastIndexByte(e.SQL[:offset], '\n') + 1)
+}
+
+// offset returns Offset clamped to the statement.
+func (e *TranslationError) offset() int {
+	return min(max(e.Offset, 0), len(e.SQL))
+}
+
+// Error implements the error interface, in the form of Snowflake's syntax errors.
+func (e *TranslationError) Error() string {
+	return fmt.Sprintf("syntax error line %d at position %d unexpected '%s'", e.Line(), e.Position(), e.Construct)
+}
+
+// Unwrap returns the error of the translator or DuckDB's parser.
+func (e *TranslationError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// parserNearPattern matches the token of a DuckDB parser error.
+	parserNearPattern = regexp.MustCompile(`at or near "((?:[^"]|"")*)"`)
+	// parserLinePattern matches the context and caret DuckDB appends to a parser
+	// error: the line number, the (possibly elided) line, and the caret's indent.
+	parserLinePattern = regexp.MustCompile(`\nLINE (\d+): (.*)\n( *)\^`)
+)
+
+// withSyntaxPosition returns a TranslationError wrapping err when DuckDB failed
+// to parse translatedSQL, locating the failing token in sql, the statement
+// before translation. Otherwise it returns err.
+func withSyntaxPosition(sql, translatedSQL string, err error) error {
+	var duckErr *duckdb.Error
+	if !errors.As(err, &duckErr) || duckErr.Type != duckdb.ErrorTypeParser {
+		return err
+	}
+	construct, translatedOffset := parserErrorLocation(translatedSQL, duckErr.Msg)
+	return &TranslationError{
+		SQL:       sql,
+		Construct: construct,
+		Offset:    originalOffset(sql, translatedSQL, construct, translatedOffset),
+		Err:       err,
+	}
+}
+
+// parserErrorLocation returns the token a DuckDB parser error is reported at
+// and its offset in the parsed SQL, or -1 if the error has no position.
+func parserErrorLocation(sql, message string) (string, int) {
+	if strings.Contains(message, "at end of input") {
+		return endOfInput, len(sql)
+	}
+	construct := ""
+	if m := parserNearPattern.FindStringSubmatch(message); m != nil {
+		construct = strings.ReplaceAll(m[1], `""`, `"`)
+	}
+
+	m := parserLinePattern.FindStringSubmatch(message)
+	if m == nil {
+		return construct, -1
+	}
+	lineNumber, _ := strconv.Atoi(m[1])
+	context := m[2]
+	column := len(m[3]) - len("LINE "+m[1]+": ")
+
+	// Long lines are elided around the error with "..."
+	if trimmed, ok := strings.CutPrefix(context, "..."); ok {
+		context = trimmed
+		column -= len("...")
+	}
+	context = strings.TrimSuffix(context, "...")
+
+	lines := strings.SplitAfter(sql, "\n")
+	if lineNumber < 1 || lineNumber > len(lines) || column < 0 {
+		return construct, -1
+	}
+	lineStart := 0
+	for _, line := range lines[:lineNumber-1] {
+		lineStart += len(line)
+	}
+	at := strings.Index(lines[lineNumber-1], context)
+	if at < 0 {
+		return construct, -1
+	}
+	return construct, lineStart + at + column
+}
+
+// originalOffset maps the offset of a construct in translatedSQL to its offset
+// in sql. Translation rewrites parts of a statement, so the construct is
+// located by its occurrence count when the statements differ before it.
+func originalOffset(sql, translatedSQL, construct string, translatedOffset int) int {
+	if construct == endOfInput {
+		return len(strings.TrimRight(sql, "; \t\r\n"))
+	}
+	if translatedOffset >= 0 && translatedOffset <= len(sql) && sql[:translatedOffset] == translatedSQL[:translatedOffset] {
+		return translatedOffset
+	}
+	if construct == "" {
+		return 0
+	}
+
+	occurrence := 0
+	if translatedOffset >= 0 {
+		occurrence = countFold(translatedSQL[:translatedOffset], construct)
+	}
+	offset := -1
+	for i := 0; i <= occurrence; i++ {
+		next := indexFold(sql[offset+1:], construct)
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+	return max(offset, 0)
+}
+
+// countFold counts the non-overlapping occurrences of substr in s, ignoring case.
+func countFold(s, substr string) int {
+	count := 0
+	for {
+		at := indexFold(s, substr)
+		if at < 0 {
+			return count
+		}
+		count++
+		s = s[at+len(substr):]
+	}
+}
@@ -0,0 +1,71 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExecutor_SyntaxErrorPosition tests that parse failures are reported at
+// the line and position of the failing token in the statement as sent.
+func TestExecutor_SyntaxErrorPosition(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	if _, err := executor.Execute(ctx, "CREATE TABLE t (a INTEGER, b INTEGER, x INTEGER, y BOOLEAN)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	type position struct {
+		Line, Position int
+		Construct      string
+	}
+	tests := []struct {
+		name    string
+		sql     string
+		execute bool
+		want    position
+	}{
+		{name: "FirstToken", sql: "SELEC 1", want: position{1, 0, "SELEC"}},
+		{name: "SecondLine", sql: "SELECT a,\n  b FROM t WHERE x = 1 AND AND y", want: position{2, 27, "AND"}},
+		{name: "ThirdLine", sql: "SELECT a\nFROM t\nWHERE x = 1 OR OR y", want: position{3, 15, "OR"}},
+		{name: "EndOfInput", sql: "SELECT a FROM t WHERE", want: position{1, 21, "<EOF>"}},
+		{name: "Translated", sql: "SELECT IFF(y, a, b)\nFROM t\nWHERE WHERE x = 1", want: position{3, 6, "WHERE"}},
+		{name: "Execute", sql: "INSERT INTO t\nVALUES (1, 2, 3, TRUE) (4)", execute: true, want: position{2, 23, "("}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.execute {
+				_, err = executor.Execute(ctx, tt.sql)
+			} else {
+				_, err = executor.Query(ctx, tt.sql)
+			}
+			var syntax *TranslationError
+			if !errors.As(err, &syntax) {
+				t.Fatalf("error = %v, want a TranslationError", err)
+			}
+			got := position{syntax.Line(), syntax.Position(), syntax.Construct}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("position mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Failures other than syntax errors keep DuckDB's error
+	_, err := executor.Query(ctx, "SELECT missing FROM t")
+	var syntax *TranslationError
+	if err == nil || errors.As(err, &syntax) {
+		t.Errorf("Query() error = %v, want a binder error", err)
+	}
+}
+
+func TestTranslationError_Error(t *testing.T) {
+	err := &TranslationError{SQL: "SELECT 1\nFROM\nWHERE", Construct: "WHERE", Offset: 14}
+	want := "syntax error line 3 at position 0 unexpected 'WHERE'"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
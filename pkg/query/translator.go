@@ -2,14 +2,56 @@ package query
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/blastrain/vitess-sqlparser/sqlparser"
 )
 
+// DefaultHashFunction is the DuckDB function used to implement HASH and HASH_AGG.
+const DefaultHashFunction = "hash"
+
+// numericLiteralRegex matches string literals that Snowflake implicitly converts to numbers.
+var numericLiteralRegex = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
 // Translator converts Snowflake SQL to DuckDB-compatible SQL using AST manipulation.
 type Translator struct {
-	functionMap map[string]FunctionTranslator
+	functionMap     map[string]FunctionTranslator
+	hashFunction    string
+	implicitCasting bool
+}
+
+// TranslatorOption configures a Translator.
+type TranslatorOption func(*Translator)
+
+// WithHashFunction sets the DuckDB function used to compute HASH and HASH_AGG values.
+// The function must accept any number of arguments and return an unsigned 64-bit integer,
+// like DuckDB's built-in hash(). Use this to plug in a custom UDF when hash values
+// need to be cross-checked against another system.
+func WithHashFunction(name string) TranslatorOption {
+	return func(t *Translator) {
+		if name != "" {
+			t.hashFunction = name
+		}
+	}
+}
+
+// WithImplicitCasting enables Snowflake's implicit string-to-number conversion for
+// string literals used in arithmetic, e.g. '5' + 1. DuckDB rejects these expressions.
+// Executors using such a translator also enable DuckDB's old_implicit_casting setting,
+// which lets VARCHAR values be compared against and passed to non-string types.
+func WithImplicitCasting() TranslatorOption {
+	return func(t *Translator) {
+		t.implicitCasting = true
+	}
+}
+
+// ImplicitCasting reports whether implicit casting compatibility is enabled.
+func (t *Translator) ImplicitCasting() bool {
+	return t.implicitCasting
 }
 
 // FunctionTranslator defines how to translate a specific function.
@@ -19,9 +61,13 @@ type FunctionTranslator struct {
 }
 
 // NewTranslator creates a new SQL translator with registered function mappings.
-func NewTranslator() *Translator {
+func NewTranslator(opts ...TranslatorOption) *Translator {
 	t := &Translator{
-		functionMap: make(map[string]FunctionTranslator),
+		functionMap:  make(map[string]FunctionTranslator),
+		hashFunction: DefaultHashFunction,
+	}
+	for _, opt := range opts {
+		opt(t)
 	}
 	t.registerFunctions()
 	return t
@@ -74,6 +120,10 @@ func (t *Translator) registerFunctions() {
 		},
 	}
 
+	// TRY_PARSE_JSON and CHECK_JSON: Mark for post-processing
+	t.functionMap["TRY_PARSE_JSON"] = markFunction("__TRY_PARSE_JSON__")
+	t.functionMap["CHECK_JSON"] = markFunction("__CHECK_JSON__")
+
 	// DATEADD: Marks for post-processing
 	// DATEADD(part, n, date) → (date + INTERVAL n part)
 	t.functionMap["DATEADD"] = FunctionTranslator{
@@ -91,44 +141,356 @@ func (t *Translator) registerFunctions() {
 			return fn
 		},
 	}
+
+	// HASH: Marks for post-processing
+	// HASH(args) → hash(args) shifted into the signed 64-bit range
+	t.functionMap["HASH"] = FunctionTranslator{
+		Handler: func(fn *sqlparser.FuncExpr) sqlparser.Expr {
+			fn.Name = sqlparser.NewColIdent("__HASH__")
+			return fn
+		},
+	}
+
+	t.registerAggregateFunctions()
+	t.registerCryptoFunctions()
+	t.registerStringMatchFunctions()
+	t.registerStringFunctions()
+	t.registerConditionalFunctions()
+	t.registerNumericFunctions()
+	t.registerWeekFunctions()
+	t.registerVariantTypeFunctions()
+	t.registerGeneratorFunctions()
+	t.registerDatePartFunctions()
+	t.registerDateFunctions()
+	t.registerToCharFunctions()
+	t.registerSemiStructuredFunctions()
+}
+
+// registerAggregateFunctions registers translations for Snowflake aggregate functions.
+func (t *Translator) registerAggregateFunctions() {
+	// MIN_BY/MAX_BY(value, key) → ARG_MIN_NULL/ARG_MAX_NULL(value, key), which
+	// return a NULL value of the row with the min/max key as Snowflake does
+	t.functionMap["MIN_BY"] = FunctionTranslator{Name: "arg_min_null"}
+	t.functionMap["MAX_BY"] = FunctionTranslator{Name: "arg_max_null"}
+
+	// OBJECT_AGG: Marks for post-processing
+	// OBJECT_AGG(key, value) → the JSON object of the non-NULL pairs
+	t.functionMap["OBJECT_AGG"] = markFunction("__OBJECT_AGG__")
+
+	// Bitwise and boolean aggregates
+	t.functionMap["BITAND_AGG"] = FunctionTranslator{Name: "bit_and"}
+	t.functionMap["BITOR_AGG"] = FunctionTranslator{Name: "bit_or"}
+	t.functionMap["BITXOR_AGG"] = FunctionTranslator{Name: "bit_xor"}
+	t.functionMap["BOOLAND_AGG"] = FunctionTranslator{Name: "bool_and"}
+	t.functionMap["BOOLOR_AGG"] = FunctionTranslator{Name: "bool_or"}
+
+	// BOOLXOR_AGG: Marks for post-processing
+	// BOOLXOR_AGG(x) → whether exactly one non-NULL x is TRUE
+	t.functionMap["BOOLXOR_AGG"] = markFunction("__BOOLXOR_AGG__")
+
+	// ARRAY_UNIQUE_AGG: Marks for post-processing
+	// ARRAY_UNIQUE_AGG(x) → the JSON array of the distinct non-NULL values of x
+	t.functionMap["ARRAY_UNIQUE_AGG"] = markFunction("__ARRAY_UNIQUE_AGG__")
+
+	// HASH_AGG: Marks for post-processing
+	// HASH_AGG(args) → order-independent sum of HASH(args) wrapped to a signed 64-bit value
+	t.functionMap["HASH_AGG"] = markFunction("__HASH_AGG__")
+
+	// ARRAY_UNION_AGG: Marks for post-processing
+	// ARRAY_UNION_AGG(arr) → the multiset union of the arrays arr
+	t.functionMap["ARRAY_UNION_AGG"] = markFunction("__ARRAY_UNION_AGG__")
+}
+
+// registerCryptoFunctions registers translations for Snowflake hashing and encoding functions.
+func (t *Translator) registerCryptoFunctions() {
+	// Hex digests map directly onto DuckDB's hash functions
+	t.functionMap["MD5"] = FunctionTranslator{Name: "md5"}
+	t.functionMap["MD5_HEX"] = FunctionTranslator{Name: "md5"}
+	t.functionMap["SHA1"] = FunctionTranslator{Name: "sha1"}
+	t.functionMap["SHA1_HEX"] = FunctionTranslator{Name: "sha1"}
+
+	// Binary digests, SHA2 digest sizes, BASE64/HEX codecs and UUID_STRING
+	// need their arguments rewritten: mark for post-processing
+	for _, name := range []string{
+		"MD5_BINARY", "SHA1_BINARY", "SHA2", "SHA2_HEX", "SHA2_BINARY",
+		"BASE64_ENCODE", "BASE64_DECODE_STRING", "BASE64_DECODE_BINARY",
+		"TRY_BASE64_DECODE_STRING", "TRY_BASE64_DECODE_BINARY",
+		"HEX_ENCODE", "HEX_DECODE_STRING", "HEX_DECODE_BINARY",
+		"TRY_HEX_DECODE_STRING", "TRY_HEX_DECODE_BINARY",
+	} {
+		t.functionMap[name] = markFunction("__" + name + "__")
+	}
+
+	// ENCRYPT/DECRYPT/TRY_DECRYPT → registered AES-GCM functions keyed by the passphrase
+	t.functionMap["ENCRYPT"] = FunctionTranslator{Name: "snowflake_encrypt"}
+	t.functionMap["DECRYPT"] = FunctionTranslator{Name: "snowflake_decrypt"}
+	t.functionMap["TRY_DECRYPT"] = FunctionTranslator{Name: "snowflake_try_decrypt"}
+
+	// UUID_STRING() → CAST(uuid() AS VARCHAR)
+	// UUID_STRING(namespace, name) → snowflake_uuid_string(namespace, name), a registered UUID v5 function
+	t.functionMap["UUID_STRING"] = FunctionTranslator{
+		Handler: func(fn *sqlparser.FuncExpr) sqlparser.Expr {
+			if len(fn.Exprs) == 0 {
+				fn.Name = sqlparser.NewColIdent("__UUID_STRING__")
+			} else {
+				fn.Name = sqlparser.NewColIdent("snowflake_uuid_string")
+			}
+			return fn
+		},
+	}
+}
+
+// registerGeneratorFunctions registers translations for Snowflake data generation
+// functions. They map to the functions registered by the executor's configureGenerators.
+func (t *Translator) registerGeneratorFunctions() {
+	for name, function := range generatorFunctions {
+		t.functionMap[name] = FunctionTranslator{Name: function}
+	}
+}
+
+// registerStringMatchFunctions registers translations for Snowflake string matching and similarity functions.
+func (t *Translator) registerStringMatchFunctions() {
+	t.functionMap["CONTAINS"] = FunctionTranslator{Name: "contains"}
+	t.functionMap["STARTSWITH"] = FunctionTranslator{Name: "starts_with"}
+	t.functionMap["ENDSWITH"] = FunctionTranslator{Name: "ends_with"}
+
+	// EDITDISTANCE(a, b [, max_distance]) → levenshtein(a, b), capped at max_distance
+	t.functionMap["EDITDISTANCE"] = markFunction("__EDITDISTANCE__")
+
+	// JAROWINKLER_SIMILARITY(a, b) → jaro_winkler_similarity(a, b) scaled to an integer 0-100
+	t.functionMap["JAROWINKLER_SIMILARITY"] = markFunction("__JAROWINKLER_SIMILARITY__")
+}
+
+// registerConditionalFunctions registers translations for Snowflake conditional expression functions.
+func (t *Translator) registerConditionalFunctions() {
+	// DECODE(expr, search1, result1, ... [, default]) → CASE expression
+	t.functionMap["DECODE"] = markFunction("__DECODE__")
+
+	// BOOLAND/BOOLOR/BOOLXOR/BOOLNOT take numeric arguments: zero is FALSE, non-zero is TRUE
+	t.functionMap["BOOLAND"] = markFunction("__BOOLAND__")
+	t.functionMap["BOOLOR"] = markFunction("__BOOLOR__")
+	t.functionMap["BOOLXOR"] = markFunction("__BOOLXOR__")
+	t.functionMap["BOOLNOT"] = markFunction("__BOOLNOT__")
+
+	// GREATEST/LEAST return NULL if any argument is NULL in Snowflake, while DuckDB skips NULLs
+	t.functionMap["GREATEST"] = markFunction("__GREATEST__")
+	t.functionMap["LEAST"] = markFunction("__LEAST__")
+	t.functionMap["GREATEST_IGNORE_NULLS"] = FunctionTranslator{Name: "greatest"}
+	t.functionMap["LEAST_IGNORE_NULLS"] = FunctionTranslator{Name: "least"}
+}
+
+// registerNumericFunctions registers translations for Snowflake numeric functions.
+func (t *Translator) registerNumericFunctions() {
+	// ROUND(x [, scale [, rounding_mode]]): DuckDB's round() already rounds half away
+	// from zero like Snowflake's default; 'HALF_TO_EVEN' rounds ties to even digits
+	t.functionMap["ROUND"] = markFunction("__ROUND__")
+
+	// TO_NUMBER and its synonyms cast to DECIMAL, with an optional format model
+	for _, name := range []string{"TO_NUMBER", "TO_DECIMAL", "TO_NUMERIC"} {
+		t.functionMap[name] = markFunction("__TO_NUMBER__")
+		t.functionMap["TRY_"+name] = markFunction("__TRY_TO_NUMBER__")
+	}
+
+	// ZEROIFNULL and SQUARE have no DuckDB equivalent
+	for _, name := range []string{"ZEROIFNULL", "SQUARE"} {
+		t.functionMap[name] = markFunction("__" + name + "__")
+	}
+
+	// DIV0(a, b) and DIV0NULL(a, b) get a / b as a third argument, so that the
+	// quotient is rewritten like any division
+	for _, name := range []string{"DIV0", "DIV0NULL"} {
+		marker := "__" + name + "__"
+		t.functionMap[name] = FunctionTranslator{
+			Handler: func(fn *sqlparser.FuncExpr) sqlparser.Expr {
+				fn.Name = sqlparser.NewColIdent(marker)
+				if len(fn.Exprs) != 2 {
+					return fn
+				}
+				dividend, ok := fn.Exprs[0].(*sqlparser.AliasedExpr)
+				divisor, ok2 := fn.Exprs[1].(*sqlparser.AliasedExpr)
+				if ok && ok2 {
+					quotient := &sqlparser.BinaryExpr{Operator: sqlparser.DivStr, Left: dividend.Expr, Right: divisor.Expr}
+					fn.Exprs = append(fn.Exprs, &sqlparser.AliasedExpr{Expr: quotient})
+				}
+				return fn
+			},
+		}
+	}
+
+	// TRUNCATE and TRUNC truncate numbers, or dates to a date part
+	t.functionMap["TRUNCATE"] = markFunction("__TRUNCATE__")
+	t.functionMap["TRUNC"] = markFunction("__TRUNCATE__")
 }
 
-// Translate converts Snowflake SQL to DuckDB-compatible SQL.
+// registerWeekFunctions registers translations for week-based date functions.
+// Their results depend on the WEEK_START and WEEK_OF_YEAR_POLICY session parameters,
+// so they are resolved per statement in transformWeekFunctions.
+func (t *Translator) registerWeekFunctions() {
+	for _, name := range []string{"DAYOFWEEK", "WEEK", "WEEKOFYEAR", "DATE_TRUNC"} {
+		t.functionMap[name] = markFunction("__" + name + "__")
+	}
+	t.functionMap["DAYOFWEEKISO"] = FunctionTranslator{Name: "isodow"}
+	t.functionMap["WEEKISO"] = FunctionTranslator{Name: "week"}
+	t.functionMap["YEAROFWEEKISO"] = FunctionTranslator{Name: "isoyear"}
+}
+
+// variantTypePredicates maps the IS_<type> functions to the TYPEOF results they accept.
+// As in Snowflake, integers are also decimals, and both are also doubles.
+var variantTypePredicates = map[string][]string{
+	"IS_ARRAY":      {"ARRAY"},
+	"IS_OBJECT":     {"OBJECT"},
+	"IS_BOOLEAN":    {"BOOLEAN"},
+	"IS_INTEGER":    {"INTEGER"},
+	"IS_DECIMAL":    {"INTEGER", "DECIMAL"},
+	"IS_DOUBLE":     {"INTEGER", "DECIMAL", "DOUBLE"},
+	"IS_REAL":       {"INTEGER", "DECIMAL", "DOUBLE"},
+	"IS_VARCHAR":    {"VARCHAR"},
+	"IS_CHAR":       {"VARCHAR"},
+	"IS_NULL_VALUE": {"NULL_VALUE"},
+}
+
+// registerVariantTypeFunctions registers TYPEOF and the IS_<type> functions, which
+// inspect the type of the value held by a VARIANT. They are resolved from DuckDB's
+// json_type() in transformVariantTypes.
+func (t *Translator) registerVariantTypeFunctions() {
+	t.functionMap["TYPEOF"] = markFunction("__TYPEOF__")
+	for name := range variantTypePredicates {
+		t.functionMap[name] = markFunction("__" + name + "__")
+	}
+}
+
+// Functions lists the names of the Snowflake functions the translator rewrites.
+func (t *Translator) Functions() []string {
+	names := make([]string, 0, len(t.functionMap))
+	for name := range t.functionMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// markFunction returns a FunctionTranslator that renames the function to marker
+// so it can be rewritten in handleComplexTransformations.
+func markFunction(marker string) FunctionTranslator {
+	return FunctionTranslator{
+		Handler: func(fn *sqlparser.FuncExpr) sqlparser.Expr {
+			fn.Name = sqlparser.NewColIdent(marker)
+			return fn
+		},
+	}
+}
+
+// Translate converts Snowflake SQL to DuckDB-compatible SQL using default session parameters.
 func (t *Translator) Translate(sql string) (string, error) {
+	return t.TranslateWithParameters(sql, SessionParameters{})
+}
+
+// TranslateWithParameters converts Snowflake SQL to DuckDB-compatible SQL, applying
+// session parameters such as WEEK_START that change function semantics.
+func (t *Translator) TranslateWithParameters(sql string, params SessionParameters) (string, error) {
+	translated, _, err := t.translateReportingFallback(sql, params)
+	return translated, err
+}
+
+// translateReportingFallback translates sql like TranslateWithParameters. When
+// the parser rejected the statement, or the query of a view it creates, so
+// that it was passed to DuckDB untranslated, it also returns the parser's
+// error as fallback.
+func (t *Translator) translateReportingFallback(sql string, params SessionParameters) (translated string, fallback, err error) {
 	if sql == "" {
-		return "", fmt.Errorf("empty SQL statement")
+		return "", nil, fmt.Errorf("empty SQL statement")
 	}
 
+	// Leading comments are kept, but statements are recognized without them
+	comments, sql := splitLeadingComments(rewriteLineComments(sql))
+
+	// Supported Cortex functions are DuckDB functions calling the Cortex backend
+	sql = rewriteCortexFunctions(sql)
+
 	// Trim whitespace
 	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return "", nil, nil
+	}
+
+	translated, fallback, err = t.translateStatement(sql, params)
+	if err != nil {
+		return "", nil, err
+	}
+	return comments + translated, fallback, nil
+}
+
+// translateStatement translates a statement that starts with its first token.
+// The parser's error is returned as fallback when the statement is passed to
+// DuckDB untranslated because the parser rejected it.
+func (t *Translator) translateStatement(sql string, params SessionParameters) (translated string, fallback, err error) {
+	// Semi-structured paths such as v:a.b[0] become JSON functions, which the
+	// parser accepts, also in CREATE TABLE ... AS SELECT
+	sql = rewriteVariantPaths(sql)
 
 	// Skip AST transformation for DDL statements - they don't need function translation
 	// and the sqlparser adds unwanted backticks when serializing back to string
 	// Also skip SHOW/DESCRIBE/EXPLAIN which cause vitess-sqlparser to panic
+	// Column types are still mapped to the DuckDB types that store them
 	upperSQL := strings.ToUpper(sql)
-	if strings.HasPrefix(upperSQL, "CREATE ") ||
-		strings.HasPrefix(upperSQL, "DROP ") ||
-		strings.HasPrefix(upperSQL, "ALTER ") ||
+	if strings.HasPrefix(upperSQL, "CREATE ") || strings.HasPrefix(upperSQL, "ALTER ") {
+		// The query of a view is translated like any other query
+		if stmt, ok := parseCreateStatement(sql); ok && stmt.Kind == "VIEW" && stmt.Query != "" && strings.HasSuffix(sql, stmt.Query) {
+			query, fallback, err := t.translateReportingFallback(stmt.Query, params)
+			if err != nil {
+				return "", nil, err
+			}
+			return sql[:len(sql)-len(stmt.Query)] + query, fallback, nil
+		}
+		return t.transformCast(translateColumnTypes(sql), "__CAST__", "CAST"), nil, nil
+	}
+	if strings.HasPrefix(upperSQL, "DROP ") ||
 		strings.HasPrefix(upperSQL, "TRUNCATE ") ||
 		strings.HasPrefix(upperSQL, "SHOW ") ||
 		strings.HasPrefix(upperSQL, "DESCRIBE ") ||
 		strings.HasPrefix(upperSQL, "DESC ") ||
 		strings.HasPrefix(upperSQL, "EXPLAIN ") {
-		return sql, nil
+		return sql, nil, nil
 	}
 
-	// Parse the SQL statement into an AST
-	stmt, err := sqlparser.Parse(sql)
+	// :: casts become markers the parser accepts
+	sql = rewriteCastOperators(sql)
+
+	// Map cast target types such as NUMBER or VARIANT to DuckDB types
+	sql = translateCastTypes(sql)
+
+	// Rewrite INTERVAL '1 day, 3 hours' literals into DuckDB's interval strings
+	sql = translateIntervalLiterals(sql)
+
+	// Parse the SQL statement into an AST, with EXTRACT(part FROM expr),
+	// INTERVAL literals, lambdas, WITHIN GROUP orderings, the POSITION and
+	// INSERT functions, and || operators in forms the parser accepts
+	stmt, err := sqlparser.Parse(markConcatOperators(markStringCalls(markWithinGroup(markLambdas(markIntervalLiterals(rewriteExtractFrom(sql)))))))
 	if err != nil {
 		// If parsing fails, return original SQL
 		// DuckDB might handle some Snowflake syntax directly
 		// This provides graceful degradation for unsupported syntax
-		return sql, nil
+		// NULL ordering is still aligned since it covers window functions the parser rejects
+		// Casts of semi-structured paths are still resolved
+		// Lambdas of FILTER, TRANSFORM, and REDUCE are still translated, since
+		// the parser rejects array literals such as [1, 2, 3]
+		return alignNullOrdering(t.transformCast(t.transformFallbackLambdas(sql), "__CAST__", "CAST")), err, nil
 	}
 
 	// Walk the AST and transform functions in-place
 	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		// Replace division operands before the walk descends into them
+		replaceChildExprs(node, t.rewriteDivision)
+		replaceChildExprs(node, markCast)
+		if n, ok := node.(*sqlparser.BinaryExpr); ok && n.Operator == sqlparser.BitOrStr {
+			n.Operator = "||"
+		}
+		if n, ok := node.(*sqlparser.BinaryExpr); ok && t.implicitCasting && isArithmeticOperator(n.Operator) {
+			n.Left = coerceNumericLiteral(n.Left)
+			n.Right = coerceNumericLiteral(n.Right)
+		}
 		if n, ok := node.(*sqlparser.FuncExpr); ok {
+			markTryCast(n)
 			funcName := strings.ToUpper(n.Name.String())
 			if translator, exists := t.functionMap[funcName]; exists {
 				if translator.Handler != nil {
@@ -144,12 +506,37 @@ func (t *Translator) Translate(sql string) (string, error) {
 	}, stmt)
 
 	// Convert AST back to string
-	result := sqlparser.String(stmt)
+	result := formatSQL(stmt)
 
 	// Apply post-processing for transformations that couldn't be done in-place
 	result = t.handleComplexTransformations(result)
+	result = t.transformWeekFunctions(result, params)
+
+	// Match Snowflake's default NULL placement in ORDER BY
+	result = alignNullOrdering(result)
 
-	return result, nil
+	return result, nil, nil
+}
+
+// formatSQL converts an AST back to SQL like sqlparser.String, but writes string
+// literals with standard quoting instead of MySQL backslash escapes, which DuckDB
+// does not interpret. Literals containing backslashes are written as E'...' strings.
+func formatSQL(node sqlparser.SQLNode) string {
+	buf := sqlparser.NewTrackedBuffer(func(buf *sqlparser.TrackedBuffer, node sqlparser.SQLNode) {
+		val, ok := node.(*sqlparser.SQLVal)
+		if !ok || val.Type != sqlparser.StrVal {
+			node.Format(buf)
+			return
+		}
+		literal := string(val.Val)
+		if strings.Contains(literal, `\`) {
+			buf.WriteString("E" + quoteLiteral(strings.ReplaceAll(literal, `\`, `\\`)))
+			return
+		}
+		buf.WriteString(quoteLiteral(literal))
+	})
+	buf.Myprintf("%v", node)
+	return buf.String()
 }
 
 // handleComplexTransformations handles transformations that require more than simple renames.
@@ -162,6 +549,10 @@ func (t *Translator) handleComplexTransformations(sql string) string {
 	sql = strings.ReplaceAll(sql, "current_timestamp()", "CURRENT_TIMESTAMP")
 	sql = strings.ReplaceAll(sql, "current_date()", "CURRENT_DATE")
 
+	// Handle casts: __CAST__(x, 'type') → CAST(x AS type), and likewise for TRY_CAST
+	sql = t.transformCast(sql, "__CAST__", "CAST")
+	sql = t.transformCast(sql, "__TRY_CAST__", "TRY_CAST")
+
 	// Handle TO_VARIANT: __TO_VARIANT__(x) → CAST(x AS JSON)
 	sql = t.transformMarkedFunction(sql, "__TO_VARIANT__", func(args string) string {
 		return fmt.Sprintf("CAST(%s AS JSON)", args)
@@ -172,12 +563,131 @@ func (t *Translator) handleComplexTransformations(sql string) string {
 		return fmt.Sprintf("CAST(%s AS JSON)", args)
 	})
 
+	// Handle TRY_PARSE_JSON: __TRY_PARSE_JSON__(x) → NULL for malformed JSON instead of an error
+	sql = t.transformMarkedFunction(sql, "__TRY_PARSE_JSON__", func(args string) string {
+		value := strings.TrimSpace(splitFunctionArgs(args, 2)[0])
+		return fmt.Sprintf("CASE WHEN json_valid(%s) THEN CAST(%s AS JSON) END", value, value)
+	})
+
+	// Handle CHECK_JSON: __CHECK_JSON__(x) → NULL for valid JSON or NULL, else an error message
+	sql = t.transformMarkedFunction(sql, "__CHECK_JSON__", func(args string) string {
+		return fmt.Sprintf("CASE WHEN NOT json_valid(%s) THEN 'invalid JSON' END", args)
+	})
+
+	// Handle TYPEOF and IS_<type> over VARIANT values
+	sql = t.transformVariantTypes(sql)
+
 	// Handle DATEADD: __DATEADD__(part, n, date) → (CAST(date AS DATE) + interval n part)
 	sql = t.transformDATEADD(sql)
 
 	// Handle DATEDIFF: __DATEDIFF__(part, start, end) → DATE_DIFF('part', start, end)
 	sql = t.transformDATEDIFF(sql)
 
+	// Handle EXTRACT, DATE_PART, and the *_FROM_PARTS functions
+	sql = t.transformDateParts(sql)
+
+	// Handle LAST_DAY, TIME_SLICE, ADD_MONTHS, CONVERT_TIMEZONE, and the other date functions
+	sql = t.transformDateFunctions(sql)
+
+	// Handle INTERVAL literals: __INTERVAL__('spec') → INTERVAL 'spec'
+	sql = t.transformIntervals(sql)
+
+	// Handle TO_CHAR and TO_VARCHAR with format models
+	sql = t.transformToChar(sql)
+
+	// Handle WITHIN GROUP orderings, before the aggregates they order
+	sql = t.transformWithinGroup(sql)
+
+	// Handle the array and object functions
+	sql = t.transformSemiStructured(sql)
+
+	// Handle HASH and HASH_AGG
+	sql = t.transformHASH(sql)
+
+	// Handle BOOLXOR_AGG: TRUE when exactly one non-NULL value is TRUE, and NULL
+	// when all values are NULL
+	sql = t.transformMarkedFunction(sql, "__BOOLXOR_AGG__", func(args string) string {
+		value := strings.TrimSpace(args)
+		return fmt.Sprintf("CASE WHEN count(%s) = 0 THEN NULL ELSE count(%s) FILTER (WHERE CAST(%s AS BOOLEAN)) = 1 END", value, value, value)
+	})
+
+	// Handle ARRAY_UNIQUE_AGG and OBJECT_AGG, which skip NULLs as Snowflake does
+	sql = t.transformMarkedFunction(sql, "__ARRAY_UNIQUE_AGG__", func(args string) string {
+		value := strings.TrimSpace(args)
+		return fmt.Sprintf("COALESCE(to_json(list(DISTINCT %s) FILTER (WHERE %s IS NOT NULL)), CAST('[]' AS JSON))", value, value)
+	})
+	sql = t.transformMarkedFunction(sql, "__OBJECT_AGG__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "json_group_object(" + args + ")"
+		}
+		// json_group_object() is a macro, which takes no FILTER, so the object is
+		// built from a map, which also rejects duplicate keys as Snowflake does
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		return fmt.Sprintf("COALESCE(to_json(map_from_entries(list({'k': CAST(%s AS VARCHAR), 'v': %s}) FILTER (WHERE %s IS NOT NULL AND %s IS NOT NULL))), CAST('{}' AS JSON))",
+			key, value, key, value)
+	})
+
+	// Handle ARRAY_UNION_AGG with Snowflake's multiset semantics: a value occurs
+	// in the union as often as in the array holding it most often. Each element
+	// is tagged with the number of equal elements before it in its array, and
+	// each distinct tagged element is kept at its first position.
+	sql = t.transformMarkedFunction(sql, "__ARRAY_UNION_AGG__", func(args string) string {
+		tagged := fmt.Sprintf("flatten(list_transform(list(%s), __a -> list_transform(__a, (__e, __i) -> {'v': __e, 'n': len(list_filter(__a[1:__i - 1], __x -> __x = __e))})))",
+			jsonList(args))
+		return fmt.Sprintf("COALESCE(to_json(list_transform([%s], __t -> list_transform(list_filter(__t, (__s, __i) -> list_position(__t, __s) = __i), __s -> __s.v))[1]), CAST('[]' AS JSON))",
+			tagged)
+	})
+
+	// Handle hashing and encoding functions
+	sql = t.transformCrypto(sql)
+
+	// Handle ROUND: __ROUND__(x, n, 'HALF_TO_EVEN') rounds x half to even
+	sql = t.transformMarkedFunction(sql, "__ROUND__", func(args string) string {
+		parts := trimmedArgs(args, 3)
+		if len(parts) != 3 {
+			return "round(" + args + ")"
+		}
+		if strings.EqualFold(parts[2], "'HALF_TO_EVEN'") {
+			return roundHalfToEven(parts[0], parts[1])
+		}
+		return fmt.Sprintf("round(%s, %s)", parts[0], parts[1])
+	})
+
+	// Handle division: __DIV__(a, b, scale) divides exactly unless an operand is
+	// a floating-point number
+	sql = t.transformMarkedFunction(sql, "__DIV__", func(args string) string {
+		parts := trimmedArgs(args, 3)
+		if len(parts) != 3 {
+			return "__DIV__(" + args + ")"
+		}
+		return exactDivision(parenthesize(parts[0]), parenthesize(parts[1]), parts[2])
+	})
+
+	// Handle TO_NUMBER, DIV0, TRUNCATE, and the other numeric functions
+	sql = t.transformNumericFunctions(sql)
+
+	// Handle SPLIT, STRTOK, the REGEXP functions, and the other string functions
+	sql = t.transformStringFunctions(sql)
+
+	// Handle EDITDISTANCE: __EDITDISTANCE__(a, b [, max]) → LEAST(levenshtein(a, b), max)
+	sql = t.transformMarkedFunction(sql, "__EDITDISTANCE__", func(args string) string {
+		parts := splitFunctionArgs(args, 3)
+		if len(parts) != 3 {
+			return fmt.Sprintf("levenshtein(%s)", args)
+		}
+		return fmt.Sprintf("LEAST(levenshtein(%s, %s), %s)",
+			strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2]))
+	})
+
+	// Handle DECODE, BOOL* and GREATEST/LEAST
+	sql = t.transformConditional(sql)
+
+	// Handle JAROWINKLER_SIMILARITY: Snowflake returns an integer between 0 and 100
+	sql = t.transformMarkedFunction(sql, "__JAROWINKLER_SIMILARITY__", func(args string) string {
+		return fmt.Sprintf("CAST(round(jaro_winkler_similarity(%s) * 100) AS INTEGER)", args)
+	})
+
 	return sql
 }
 
@@ -199,6 +709,8 @@ func (t *Translator) transformMarkedFunction(sql, marker string, transformer fun
 				depth++
 			case ')':
 				depth--
+			case '\'':
+				end = skipQuoted(sql, end, '\'')
 			}
 			end++
 		}
@@ -214,6 +726,37 @@ func (t *Translator) transformMarkedFunction(sql, marker string, transformer fun
 	return sql
 }
 
+// transformVariantTypes transforms the markers registered by registerVariantTypeFunctions.
+//
+//	__TYPEOF__(v)     → CASE json_type(v) WHEN 'NULL' THEN 'NULL_VALUE' WHEN 'BIGINT' THEN 'INTEGER' ... END
+//	__IS_INTEGER__(v) → (<TYPEOF(v)> IN ('INTEGER'))
+//
+// DuckDB reads JSON numbers with a fraction as DOUBLE, while Snowflake keeps them as
+// DECIMAL unless they have an exponent, so the JSON text decides between the two.
+// A SQL NULL argument gives NULL, and a JSON null gives NULL_VALUE.
+func (t *Translator) transformVariantTypes(sql string) string {
+	typeOf := func(arg string) string {
+		arg = strings.TrimSpace(arg)
+		return fmt.Sprintf("CASE json_type(%[1]s) WHEN 'NULL' THEN 'NULL_VALUE' WHEN 'BIGINT' THEN 'INTEGER' WHEN 'UBIGINT' THEN 'INTEGER' "+
+			"WHEN 'DOUBLE' THEN CASE WHEN contains(lower(CAST(%[1]s AS VARCHAR)), 'e') THEN 'DOUBLE' ELSE 'DECIMAL' END "+
+			"ELSE json_type(%[1]s) END", arg)
+	}
+
+	for name, accepted := range variantTypePredicates {
+		types := make([]string, len(accepted))
+		for i, typ := range accepted {
+			types[i] = "'" + typ + "'"
+		}
+		sql = t.transformMarkedFunction(sql, "__"+name+"__", func(args string) string {
+			return fmt.Sprintf("((%s) IN (%s))", typeOf(args), strings.Join(types, ", "))
+		})
+	}
+
+	return t.transformMarkedFunction(sql, "__TYPEOF__", func(args string) string {
+		return "(" + typeOf(args) + ")"
+	})
+}
+
 // transformDATEADD transforms DATEADD: __DATEADD__(part, n, date) → (CAST(date AS DATE) + interval n part)
 func (t *Translator) transformDATEADD(sql string) string {
 	return t.transformMarkedFunction(sql, "__DATEADD__", func(args string) string {
@@ -244,6 +787,460 @@ func (t *Translator) transformDATEDIFF(sql string) string {
 	})
 }
 
+// transformHASH transforms HASH and HASH_AGG markers into the configured hash function.
+//
+// Snowflake returns HASH values as signed 64-bit integers, while DuckDB's hash() is unsigned.
+// The unsigned value is shifted by 2^63 so results are stable and fit in a BIGINT:
+//
+//	__HASH__(args)     → CAST(CAST(hash(args) AS HUGEINT) - 2^63 AS BIGINT)
+//	__HASH_AGG__(args) → CAST((COALESCE(SUM(hash(args)), 0) % 2^64) - 2^63 AS BIGINT)
+//
+// HASH(*) and HASH_AGG(*) hash every column of the row via *COLUMNS(*).
+// Values are stable across runs but do not match Snowflake's hash values.
+func (t *Translator) transformHASH(sql string) string {
+	hashArgs := func(args string) string {
+		if strings.TrimSpace(args) == "*" {
+			args = "*COLUMNS(*)"
+		}
+		return fmt.Sprintf("%s(%s)", t.hashFunction, args)
+	}
+
+	sql = t.transformMarkedFunction(sql, "__HASH_AGG__", func(args string) string {
+		return fmt.Sprintf("CAST((COALESCE(SUM(CAST(%s AS HUGEINT)), 0) %% 18446744073709551616) - 9223372036854775808 AS BIGINT)", hashArgs(args))
+	})
+
+	return t.transformMarkedFunction(sql, "__HASH__", func(args string) string {
+		return fmt.Sprintf("CAST(CAST(%s AS HUGEINT) - 9223372036854775808 AS BIGINT)", hashArgs(args))
+	})
+}
+
+// transformCrypto transforms the hashing and encoding markers registered by registerCryptoFunctions.
+//
+// Snowflake accepts VARCHAR input for the BASE64 encoders and returns VARCHAR from the
+// *_STRING decoders, so strings are converted to and from BLOB with encode()/decode().
+func (t *Translator) transformCrypto(sql string) string {
+	firstArg := func(args string) string {
+		parts := splitFunctionArgs(args, 1)
+		if len(parts) == 0 {
+			return ""
+		}
+		return strings.TrimSpace(parts[0])
+	}
+
+	// SHA2(x [, digest_size]): DuckDB's sha256() computes the default 256-bit
+	// digest, and the executor's snowflake_sha2() the others
+	sha2 := func(marker string, wrap func(digest string) string) {
+		sql = t.transformMarkedFunction(sql, marker, func(args string) string {
+			parts := splitFunctionArgs(args, 2)
+			if len(parts) == 2 && strings.TrimSpace(parts[1]) != "256" {
+				return wrap(fmt.Sprintf("snowflake_sha2(%s, %s)", firstArg(args), strings.TrimSpace(parts[1])))
+			}
+			return wrap(fmt.Sprintf("sha256(%s)", firstArg(args)))
+		})
+	}
+	sha2("__SHA2__", func(digest string) string { return digest })
+	sha2("__SHA2_HEX__", func(digest string) string { return digest })
+	sha2("__SHA2_BINARY__", func(digest string) string { return "unhex(" + digest + ")" })
+
+	templates := []struct {
+		marker   string
+		template string
+	}{
+		{"__MD5_BINARY__", "unhex(md5(%s))"},
+		{"__SHA1_BINARY__", "unhex(sha1(%s))"},
+		{"__BASE64_ENCODE__", "to_base64(encode(%s))"},
+		{"__BASE64_DECODE_STRING__", "decode(from_base64(%s))"},
+		{"__BASE64_DECODE_BINARY__", "from_base64(%s)"},
+		{"__TRY_BASE64_DECODE_STRING__", "TRY(decode(from_base64(%s)))"},
+		{"__TRY_BASE64_DECODE_BINARY__", "TRY(from_base64(%s))"},
+		{"__HEX_DECODE_STRING__", "decode(unhex(%s))"},
+		{"__HEX_DECODE_BINARY__", "unhex(%s)"},
+		{"__TRY_HEX_DECODE_STRING__", "TRY(decode(unhex(%s)))"},
+		{"__TRY_HEX_DECODE_BINARY__", "TRY(unhex(%s))"},
+	}
+	for _, tmpl := range templates {
+		sql = t.transformMarkedFunction(sql, tmpl.marker, func(args string) string {
+			return fmt.Sprintf(tmpl.template, firstArg(args))
+		})
+	}
+
+	// HEX_ENCODE(x [, case]): Snowflake defaults to uppercase; case 0 selects lowercase
+	sql = t.transformMarkedFunction(sql, "__HEX_ENCODE__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		encoded := fmt.Sprintf("hex(%s)", firstArg(args))
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) == "0" {
+			return "lower(" + encoded + ")"
+		}
+		return encoded
+	})
+
+	return strings.ReplaceAll(sql, "__UUID_STRING__()", "CAST(uuid() AS VARCHAR)")
+}
+
+// transformConditional transforms the markers registered by registerConditionalFunctions.
+//
+//	__DECODE__(e, s1, r1, d)  → CASE WHEN e IS NOT DISTINCT FROM s1 THEN r1 ELSE d END
+//	__BOOLAND__(a, b)         → (CAST(a AS BOOLEAN) AND CAST(b AS BOOLEAN))
+//	__GREATEST__(a, b)        → CASE WHEN (a) IS NULL OR (b) IS NULL THEN NULL ELSE greatest(a, b) END
+//
+// DECODE matches NULL against NULL, unlike a simple CASE expression.
+func (t *Translator) transformConditional(sql string) string {
+	trimmedArgs := func(args string) []string {
+		parts := splitFunctionArgs(args, 2)
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		return parts
+	}
+
+	sql = t.transformMarkedFunction(sql, "__DECODE__", func(args string) string {
+		parts := trimmedArgs(args)
+		if len(parts) < 3 {
+			return "decode(" + args + ")"
+		}
+		var b strings.Builder
+		b.WriteString("CASE")
+		expr := parts[0]
+		i := 1
+		for ; i+1 < len(parts); i += 2 {
+			fmt.Fprintf(&b, " WHEN %s IS NOT DISTINCT FROM %s THEN %s", expr, parts[i], parts[i+1])
+		}
+		if i < len(parts) {
+			fmt.Fprintf(&b, " ELSE %s", parts[i])
+		}
+		b.WriteString(" END")
+		return b.String()
+	})
+
+	for _, b := range []struct{ marker, op string }{
+		{"__BOOLAND__", "AND"}, {"__BOOLOR__", "OR"}, {"__BOOLXOR__", "!="},
+	} {
+		sql = t.transformMarkedFunction(sql, b.marker, func(args string) string {
+			parts := trimmedArgs(args)
+			if len(parts) != 2 {
+				return strings.ToLower(strings.Trim(b.marker, "_")) + "(" + args + ")"
+			}
+			return fmt.Sprintf("(CAST(%s AS BOOLEAN) %s CAST(%s AS BOOLEAN))", parts[0], b.op, parts[1])
+		})
+	}
+	sql = t.transformMarkedFunction(sql, "__BOOLNOT__", func(args string) string {
+		return fmt.Sprintf("(NOT CAST(%s AS BOOLEAN))", strings.TrimSpace(args))
+	})
+
+	for _, g := range []struct{ marker, fn string }{
+		{"__GREATEST__", "greatest"}, {"__LEAST__", "least"},
+	} {
+		sql = t.transformMarkedFunction(sql, g.marker, func(args string) string {
+			parts := trimmedArgs(args)
+			conditions := make([]string, len(parts))
+			for i, part := range parts {
+				conditions[i] = "(" + part + ") IS NULL"
+			}
+			return fmt.Sprintf("CASE WHEN %s THEN NULL ELSE %s(%s) END", strings.Join(conditions, " OR "), g.fn, strings.Join(parts, ", "))
+		})
+	}
+
+	return sql
+}
+
+// Snowflake division result scale bounds: the result scale is the dividend's scale
+// plus divisionExtraScale, capped at divisionMaxScale (but never below the dividend's scale).
+const (
+	divisionExtraScale = 6
+	divisionMaxScale   = 12
+)
+
+// rewriteDivision marks exact numeric divisions for post-processing.
+//
+// DuckDB's / operator always returns DOUBLE, while Snowflake divides NUMBER values
+// exactly, e.g. 1/3 returns 0.333333 as NUMBER(38,6). Divisions are rewritten to
+// __DIV__(a, b, scale) unless an operand is a floating-point literal or cast.
+// Column scales are unknown at translation time, so columns are assumed to be
+// integers (scale 0), which gives the common NUMBER(38,6) result.
+func (t *Translator) rewriteDivision(expr sqlparser.Expr) sqlparser.Expr {
+	div, ok := expr.(*sqlparser.BinaryExpr)
+	if !ok || div.Operator != sqlparser.DivStr {
+		return expr
+	}
+	// Rewrite nested divisions first so their result scale carries over, e.g. 1/2/3
+	left, right := t.rewriteDivision(div.Left), div.Right
+	if t.implicitCasting {
+		left, right = coerceNumericLiteral(left), coerceNumericLiteral(right)
+	}
+	if isFloatExpr(left) || isFloatExpr(right) {
+		div.Left, div.Right = left, right
+		return div
+	}
+
+	scale := exprScale(left)
+	resultScale := max(scale, min(scale+divisionExtraScale, divisionMaxScale))
+	return &sqlparser.FuncExpr{
+		Name: sqlparser.NewColIdent("__DIV__"),
+		Exprs: sqlparser.SelectExprs{
+			&sqlparser.AliasedExpr{Expr: left},
+			&sqlparser.AliasedExpr{Expr: right},
+			&sqlparser.AliasedExpr{Expr: sqlparser.NewIntVal([]byte(strconv.Itoa(resultScale)))},
+		},
+	}
+}
+
+// exactDivision returns the division of a by b for DuckDB. Whether an operand
+// is a floating-point number is only known once DuckDB binds the statement, so
+// the operands are bound once, as the fields of the single element of a list,
+// and typeof() picks the DOUBLE quotient or the exact quotient as a DECIMAL
+// with scale digits while binding; the other is NULL. Both snowflake_divide
+// functions fail dividing by zero, as Snowflake does. NULL operands are typed,
+// since DuckDB only picks a quotient for operands of known types.
+func exactDivision(a, b, scale string) string {
+	a, b = typedNull(a), typedNull(b)
+	isFloat := "typeof(__d.a) IN ('DOUBLE', 'FLOAT') OR typeof(__d.b) IN ('DOUBLE', 'FLOAT')"
+	return fmt.Sprintf("list_transform([{'a': %s, 'b': %s}], lambda __d: struct_extract({"+
+		"'double': CASE WHEN %[3]s THEN snowflake_divide(CAST(__d.a AS DOUBLE), CAST(__d.b AS DOUBLE)) END, "+
+		"'number': CASE WHEN %[3]s THEN NULL ELSE CAST(snowflake_divide(__d.a, __d.b, %[4]s) AS DECIMAL(38, %[4]s)) END}, "+
+		"CASE WHEN %[3]s THEN 'double' ELSE 'number' END))[1]", a, b, isFloat, scale)
+}
+
+// typedNull returns an integer NULL for a NULL operand, and other operands
+// unchanged.
+func typedNull(operand string) string {
+	if strings.EqualFold(strings.Trim(operand, "() "), "NULL") {
+		return "CAST(NULL AS INTEGER)"
+	}
+	return operand
+}
+
+// roundHalfToEven returns ROUND(x, digits, 'HALF_TO_EVEN') for DuckDB. Its
+// round_even() returns DOUBLE, so a NUMBER is rounded with round() except for
+// the ties whose truncated value is even, which are truncated instead.
+func roundHalfToEven(x, digits string) string {
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return fmt.Sprintf("round_even(%s, %s)", x, digits)
+	}
+	factor := "1" + strings.Repeat("0", max(n, 0))
+	if n < 0 {
+		factor = "0." + strings.Repeat("0", -n-1) + "1"
+	}
+	x = parenthesize(x)
+	scaled := fmt.Sprintf("%s * %s", x, factor)
+	return fmt.Sprintf("CASE WHEN abs(%s - trunc(%s)) = 0.5 AND trunc(%s) %% 2 = 0 THEN trunc(%s, %d) ELSE round(%s, %d) END",
+		scaled, scaled, scaled, x, n, x, n)
+}
+
+// isFloatExpr reports whether expr is syntactically a floating-point value:
+// a literal in exponent notation or a cast to a floating-point type.
+func isFloatExpr(expr sqlparser.Expr) bool {
+	switch e := expr.(type) {
+	case *sqlparser.SQLVal:
+		return e.Type == sqlparser.FloatVal && strings.ContainsAny(string(e.Val), "eE")
+	case *sqlparser.ConvertExpr:
+		if e.Type == nil {
+			return false
+		}
+		switch strings.ToUpper(e.Type.Type) {
+		case "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL":
+			return true
+		}
+	case *sqlparser.ParenExpr:
+		return isFloatExpr(e.Expr)
+	}
+	return false
+}
+
+// exprScale returns the known scale of a decimal literal or a rewritten division, or 0.
+func exprScale(expr sqlparser.Expr) int {
+	switch e := expr.(type) {
+	case *sqlparser.SQLVal:
+		if e.Type != sqlparser.FloatVal {
+			return 0
+		}
+		literal := string(e.Val)
+		if idx := strings.IndexByte(literal, '.'); idx >= 0 {
+			return len(literal) - idx - 1
+		}
+	case *sqlparser.FuncExpr:
+		if e.Name.String() != "__DIV__" || len(e.Exprs) != 3 {
+			return 0
+		}
+		if aliased, ok := e.Exprs[2].(*sqlparser.AliasedExpr); ok {
+			if val, ok := aliased.Expr.(*sqlparser.SQLVal); ok {
+				scale, _ := strconv.Atoi(string(val.Val))
+				return scale
+			}
+		}
+	case *sqlparser.ParenExpr:
+		return exprScale(e.Expr)
+	}
+	return 0
+}
+
+// parenthesize wraps a post-processed operand in parentheses unless it is a single
+// token or already parenthesized.
+func parenthesize(operand string) string {
+	operand = strings.TrimSpace(operand)
+	if idx := strings.IndexByte(operand, '('); idx >= 0 && matchingParen(operand[idx:]) == len(operand)-idx-1 &&
+		!strings.ContainsAny(operand[:idx], " \t\n") {
+		// Already parenthesized, or a single function call
+		return operand
+	}
+	if strings.ContainsAny(operand, " \t\n") {
+		return "(" + operand + ")"
+	}
+	return operand
+}
+
+// matchingParen returns the index of the parenthesis closing s[0], or -1.
+func matchingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'':
+			i = skipQuoted(s, i, '\'')
+		}
+	}
+	return -1
+}
+
+// exprType is the reflect.Type of the sqlparser.Expr interface.
+var exprType = reflect.TypeOf((*sqlparser.Expr)(nil)).Elem()
+
+// replaceChildExprs applies fn to every expression held directly by node, either as a
+// struct field or as an element of an expression slice, and stores the result in place.
+// sqlparser.Walk cannot replace a node with one of a different type, so rewrites that
+// change node types are applied from the parent before the walk descends.
+func replaceChildExprs(node sqlparser.SQLNode, fn func(sqlparser.Expr) sqlparser.Expr) {
+	v := reflect.ValueOf(node)
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			replaceExprValue(v.Field(i), fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			replaceExprValue(v.Index(i), fn)
+		}
+	}
+}
+
+// replaceExprValue replaces v with fn(v) if v is a settable, non-nil sqlparser.Expr.
+func replaceExprValue(v reflect.Value, fn func(sqlparser.Expr) sqlparser.Expr) {
+	if v.Type() != exprType || !v.CanSet() || v.IsNil() {
+		return
+	}
+	expr, ok := v.Interface().(sqlparser.Expr)
+	if !ok {
+		return
+	}
+	v.Set(reflect.ValueOf(fn(expr)))
+}
+
+// transformWeekFunctions transforms the week function markers according to WEEK_START
+// and WEEK_OF_YEAR_POLICY. With the default parameters DuckDB's functions already match:
+// DAYOFWEEK counts from Sunday = 0 and weeks follow ISO 8601.
+//
+// For WEEK_START = N (1 = Monday ... 7 = Sunday), the offset of a date within its week is
+// (isodow(d) - N + 7) % 7. DAYOFWEEK becomes that offset + 1, DATE_TRUNC('week') buckets
+// values into weeks starting on day N, keeping their type, and WEEK numbers weeks either like ISO (policy 0, week 1 has at least 4 days of the year)
+// or from January 1 (policy 1). DATE_TRUNC also resolves Snowflake's abbreviated
+// part names, such as 'mm' and 'hh', and unquoted ones, such as MONTH.
+func (t *Translator) transformWeekFunctions(sql string, params SessionParameters) string {
+	weekStart := params.WeekStart
+	// WEEK_START = 0 keeps legacy behavior, which uses Monday-based weeks
+	effectiveStart := max(weekStart, 1)
+
+	dateArg := func(args string) string {
+		return fmt.Sprintf("CAST(%s AS DATE)", strings.TrimSpace(args))
+	}
+	weekOffset := func(date string, start int) string {
+		return fmt.Sprintf("CAST((isodow(%s) - %d + 7) %% 7 AS INTEGER)", date, start)
+	}
+
+	sql = t.transformMarkedFunction(sql, "__DAYOFWEEK__", func(args string) string {
+		date := dateArg(args)
+		if weekStart == 0 {
+			return fmt.Sprintf("dayofweek(%s)", date)
+		}
+		return fmt.Sprintf("(%s + 1)", weekOffset(date, weekStart))
+	})
+
+	week := func(args string) string {
+		date := dateArg(args)
+		switch {
+		case params.WeekOfYearPolicy == 1:
+			yearStart := fmt.Sprintf("date_trunc('year', %s)", date)
+			return fmt.Sprintf("((dayofyear(%s) - 1 + %s) // 7 + 1)", date, weekOffset(yearStart, effectiveStart))
+		case effectiveStart == 1:
+			return fmt.Sprintf("week(%s)", date)
+		default:
+			// The week belongs to the year holding its 4th day, like ISO weeks do with Thursday
+			return fmt.Sprintf("((dayofyear(%s - %s + 3) - 1) // 7 + 1)", date, weekOffset(date, effectiveStart))
+		}
+	}
+	sql = t.transformMarkedFunction(sql, "__WEEK__", week)
+	sql = t.transformMarkedFunction(sql, "__WEEKOFYEAR__", week)
+
+	return t.transformMarkedFunction(sql, "__DATE_TRUNC__", func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return "date_trunc(" + args + ")"
+		}
+		part, ok := datePartArg(parts[0])
+		if !ok {
+			return "date_trunc(" + args + ")"
+		}
+		part = truncatePart(part)
+		value := strings.TrimSpace(parts[1])
+		if part != "week" {
+			return fmt.Sprintf("date_trunc(%s, %s)", quoteLiteral(part), value)
+		}
+		// DuckDB truncates timestamps to weeks as DATEs, while time_bucket
+		// keeps the value's type. Its week buckets start on Mondays, so the
+		// offset moves them to the session's first day of the week.
+		if strings.HasPrefix(value, "'") {
+			// Snowflake reads a string as a timestamp
+			value = fmt.Sprintf("CAST(%s AS TIMESTAMP)", value)
+		}
+		return fmt.Sprintf("time_bucket(INTERVAL '7 days', %s, INTERVAL '%d days')", value, effectiveStart-1)
+	})
+}
+
+// isArithmeticOperator reports whether op is a numeric arithmetic operator.
+func isArithmeticOperator(op string) bool {
+	switch op {
+	case sqlparser.PlusStr, sqlparser.MinusStr, sqlparser.MultStr, sqlparser.DivStr, sqlparser.IntDivStr, sqlparser.ModStr:
+		return true
+	}
+	return false
+}
+
+// coerceNumericLiteral converts a string literal holding a number into a numeric literal.
+// Other expressions are returned unchanged.
+func coerceNumericLiteral(expr sqlparser.Expr) sqlparser.Expr {
+	val, ok := expr.(*sqlparser.SQLVal)
+	if !ok || val.Type != sqlparser.StrVal {
+		return expr
+	}
+	literal := strings.TrimSpace(string(val.Val))
+	if !numericLiteralRegex.MatchString(literal) {
+		return expr
+	}
+	if strings.ContainsAny(literal, ".eE") {
+		return sqlparser.NewFloatVal([]byte(literal))
+	}
+	return sqlparser.NewIntVal([]byte(literal))
+}
+
 // removeDualSuffix removes " from dual" suffix (case-insensitive) without regex.
 func removeDualSuffix(sql string) string {
 	// Trim trailing whitespace first
@@ -258,7 +1255,20 @@ func removeDualSuffix(sql string) string {
 	return sql
 }
 
-// splitFunctionArgs splits function arguments respecting parentheses nesting.
+// indexFold returns the index of the first case-insensitive occurrence of the
+// ASCII string substr in s, or -1. Unlike searching strings.ToUpper(s), the
+// index is always valid in s, which may contain invalid UTF-8.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitFunctionArgs splits function arguments respecting the nesting of parentheses, list
+// and struct literals, and string literals.
 // expectedCount is a hint for the expected number of arguments.
 func splitFunctionArgs(args string, expectedCount int) []string {
 	result := make([]string, 0, expectedCount)
@@ -267,10 +1277,12 @@ func splitFunctionArgs(args string, expectedCount int) []string {
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
-		case '(':
+		case '(', '[', '{':
 			depth++
-		case ')':
+		case ')', ']', '}':
 			depth--
+		case '\'':
+			i = skipQuoted(args, i, '\'')
 		case ',':
 			if depth == 0 {
 				result = append(result, args[start:i])
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -505,7 +506,7 @@ func TestTranslator_PARSE_JSON(t *testing.T) {
 		{
 			name:     "PARSE_JSONWithLiteral",
 			input:    `SELECT PARSE_JSON('{"key": "value"}') FROM dual`,
-			expected: `select CAST('{\"key\": \"value\"}' AS JSON)`,
+			expected: `select CAST('{"key": "value"}' AS JSON)`,
 			wantErr:  false,
 		},
 		{
@@ -541,6 +542,43 @@ func TestTranslator_PARSE_JSON(t *testing.T) {
 	}
 }
 
+// TestTranslator_TRY_PARSE_JSON tests TRY_PARSE_JSON and CHECK_JSON translation.
+func TestTranslator_TRY_PARSE_JSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "TRY_PARSE_JSON",
+			input:    "SELECT TRY_PARSE_JSON(raw) FROM test",
+			expected: "select CASE WHEN json_valid(raw) THEN CAST(raw AS JSON) END from test",
+		},
+		{
+			name:     "TRY_PARSE_JSONWithDuplicateKeys",
+			input:    "SELECT TRY_PARSE_JSON(raw, 'd') FROM test",
+			expected: "select CASE WHEN json_valid(raw) THEN CAST(raw AS JSON) END from test",
+		},
+		{
+			name:     "CHECK_JSON",
+			input:    "SELECT raw FROM test WHERE CHECK_JSON(raw) IS NOT NULL",
+			expected: "select raw from test where CASE WHEN NOT json_valid(raw) THEN 'invalid JSON' END is not null",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTranslator().Translate(tt.input)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 // TestTranslator_DATEADD tests DATEADD function translation.
 // DATEADD(part, n, date) → (CAST(date AS DATE) + INTERVAL n part) for DuckDB
 func TestTranslator_DATEADD(t *testing.T) {
@@ -551,39 +589,629 @@ func TestTranslator_DATEADD(t *testing.T) {
 		wantErr  bool
 	}{
 		{
-			name:     "DATEADDDays",
-			input:    "SELECT DATEADD(day, 7, order_date) FROM orders",
-			expected: "select (CAST(order_date AS DATE) + interval 7 day) from orders",
+			name:     "DATEADDDays",
+			input:    "SELECT DATEADD(day, 7, order_date) FROM orders",
+			expected: "select (CAST(order_date AS DATE) + interval 7 day) from orders",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEADDMonths",
+			input:    "SELECT DATEADD(month, 1, start_date) FROM subscriptions",
+			expected: "select (CAST(start_date AS DATE) + interval 1 month) from subscriptions",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEADDYears",
+			input:    "SELECT DATEADD(year, 5, birth_date) FROM users",
+			expected: "select (CAST(birth_date AS DATE) + interval 5 year) from users",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEADDNegative",
+			input:    "SELECT DATEADD(day, -30, CURRENT_DATE()) FROM dual",
+			expected: "select (CAST(CURRENT_DATE AS DATE) + interval -30 day)",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEADDHours",
+			input:    "SELECT DATEADD(hour, 24, created_at) FROM events",
+			expected: "select (CAST(created_at AS DATE) + interval 24 hour) from events",
+			wantErr:  false,
+		},
+		{
+			name:     "MultipleDATEADD",
+			input:    "SELECT DATEADD(day, 1, date1), DATEADD(month, 2, date2) FROM test",
+			expected: "select (CAST(date1 AS DATE) + interval 1 day), (CAST(date2 AS DATE) + interval 2 month) from test",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_DATEDIFF tests DATEDIFF function translation.
+// DATEDIFF(part, start, end) → DATE_DIFF('part', start, end) for DuckDB
+func TestTranslator_DATEDIFF(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "DATEDIFFDays",
+			input:    "SELECT DATEDIFF(day, start_date, end_date) FROM events",
+			expected: "select DATE_DIFF('day', CAST(start_date AS DATE), CAST(end_date AS DATE)) from events",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEDIFFMonths",
+			input:    "SELECT DATEDIFF(month, hire_date, CURRENT_DATE()) FROM employees",
+			expected: "select DATE_DIFF('month', CAST(hire_date AS DATE), CAST(CURRENT_DATE AS DATE)) from employees",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEDIFFYears",
+			input:    "SELECT DATEDIFF(year, birth_date, CURRENT_DATE()) FROM users",
+			expected: "select DATE_DIFF('year', CAST(birth_date AS DATE), CAST(CURRENT_DATE AS DATE)) from users",
+			wantErr:  false,
+		},
+		{
+			name:     "DATEDIFFInWHERE",
+			input:    "SELECT * FROM orders WHERE DATEDIFF(day, order_date, ship_date) > 5",
+			expected: "select * from orders where DATE_DIFF('day', CAST(order_date AS DATE), CAST(ship_date AS DATE)) > 5",
+			wantErr:  false,
+		},
+		{
+			name:     "MultipleDATEDIFF",
+			input:    "SELECT DATEDIFF(day, a, b), DATEDIFF(month, c, d) FROM test",
+			expected: "select DATE_DIFF('day', CAST(a AS DATE), CAST(b AS DATE)), DATE_DIFF('month', CAST(c AS DATE), CAST(d AS DATE)) from test",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_OBJECT_CONSTRUCT tests OBJECT_CONSTRUCT function translation.
+// OBJECT_CONSTRUCT('key1', val1, ...) → json_object('key1', val1, ...) for DuckDB
+func TestTranslator_OBJECT_CONSTRUCT(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "SimpleOBJECT_CONSTRUCT",
+			input:    "SELECT OBJECT_CONSTRUCT('key', value) FROM test",
+			expected: "select json_object('key', value) from test",
+			wantErr:  false,
+		},
+		{
+			name:     "OBJECT_CONSTRUCTMultipleKeys",
+			input:    "SELECT OBJECT_CONSTRUCT('name', name, 'age', age) FROM users",
+			expected: "select json_object('name', name, 'age', age) from users",
+			wantErr:  false,
+		},
+		{
+			name:     "OBJECT_CONSTRUCTWithLiterals",
+			input:    "SELECT OBJECT_CONSTRUCT('status', 'active', 'count', 42) FROM dual",
+			expected: "select json_object('status', 'active', 'count', 42)",
+			wantErr:  false,
+		},
+		{
+			name:     "MultipleOBJECT_CONSTRUCT",
+			input:    "SELECT OBJECT_CONSTRUCT('a', 1), OBJECT_CONSTRUCT('b', 2) FROM test",
+			expected: "select json_object('a', 1), json_object('b', 2) from test",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_FLATTEN tests FLATTEN function translation.
+// Note: FLATTEN with => syntax is not supported by vitess-sqlparser,
+// so it falls back to original SQL (graceful degradation).
+// Simple FLATTEN(array) → UNNEST(array) for DuckDB
+func TestTranslator_FLATTEN(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "SimpleFLATTEN",
+			input:    "SELECT FLATTEN(array_col) FROM test",
+			expected: "select UNNEST(array_col) from test",
+			wantErr:  false,
+		},
+		{
+			name:     "FLATTENWithNamedParam_GracefulDegradation",
+			input:    "SELECT * FROM TABLE(FLATTEN(input => array_col))",
+			expected: "SELECT * FROM TABLE(FLATTEN(input => array_col))", // Parser fails on => syntax
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_LISTAGG tests LISTAGG function translation.
+// LISTAGG(col, sep) → STRING_AGG(col, sep)
+func TestTranslator_LISTAGG(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "SimpleLISTAGG",
+			input:    "SELECT LISTAGG(name, ',') FROM test GROUP BY category",
+			expected: "select STRING_AGG(name, ',') from test group by category",
+			wantErr:  false,
+		},
+		{
+			name:     "LISTAGGWithSpace",
+			input:    "SELECT LISTAGG(name, ', ') FROM test GROUP BY category",
+			expected: "select STRING_AGG(name, ', ') from test group by category",
+			wantErr:  false,
+		},
+		{
+			name:     "MultipleLISTAGG",
+			input:    "SELECT LISTAGG(a, '-'), LISTAGG(b, '|') FROM test GROUP BY c",
+			expected: "select STRING_AGG(a, '-'), STRING_AGG(b, '|') from test group by c",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_AggregateFunctions tests translation of newer Snowflake aggregates.
+// MIN_BY/MAX_BY → ARG_MIN_NULL/ARG_MAX_NULL, OBJECT_AGG → a JSON object, etc.
+func TestTranslator_AggregateFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "MIN_BY",
+			input:    "SELECT MIN_BY(name, salary) FROM employees",
+			expected: "select arg_min_null(name, salary) from employees",
+			wantErr:  false,
+		},
+		{
+			name:     "MAX_BY",
+			input:    "SELECT dept, MAX_BY(name, salary) FROM employees GROUP BY dept",
+			expected: "select dept, arg_max_null(name, salary) from employees group by dept",
+			wantErr:  false,
+		},
+		{
+			name:     "OBJECT_AGG",
+			input:    "SELECT OBJECT_AGG(k, v) FROM kv",
+			expected: "select COALESCE(to_json(map_from_entries(list({'k': CAST(k AS VARCHAR), 'v': v}) FILTER (WHERE k IS NOT NULL AND v IS NOT NULL))), CAST('{}' AS JSON)) from kv",
+			wantErr:  false,
+		},
+		{
+			name:     "ARRAY_UNIQUE_AGG",
+			input:    "SELECT ARRAY_UNIQUE_AGG(tag) FROM tags",
+			expected: "select COALESCE(to_json(list(DISTINCT tag) FILTER (WHERE tag IS NOT NULL)), CAST('[]' AS JSON)) from tags",
+			wantErr:  false,
+		},
+		{
+			name:     "ARRAY_UNION_AGG",
+			input:    "SELECT ARRAY_UNION_AGG(tags) FROM posts",
+			expected: "select COALESCE(to_json(list_transform([flatten(list_transform(list(CAST(CAST(tags AS JSON) AS JSON[])), __a -> list_transform(__a, (__e, __i) -> {'v': __e, 'n': len(list_filter(__a[1:__i - 1], __x -> __x = __e))})))], __t -> list_transform(list_filter(__t, (__s, __i) -> list_position(__t, __s) = __i), __s -> __s.v))[1]), CAST('[]' AS JSON)) from posts",
+			wantErr:  false,
+		},
+		{
+			name:     "BitwiseAggregates",
+			input:    "SELECT BITAND_AGG(flags), BITOR_AGG(flags), BITXOR_AGG(flags) FROM t",
+			expected: "select bit_and(flags), bit_or(flags), bit_xor(flags) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "BooleanAggregates",
+			input:    "SELECT BOOLAND_AGG(active), BOOLOR_AGG(active) FROM users",
+			expected: "select bool_and(active), bool_or(active) from users",
+			wantErr:  false,
+		},
+		{
+			name:     "BOOLXOR_AGG",
+			input:    "SELECT BOOLXOR_AGG(active) FROM users",
+			expected: "select CASE WHEN count(active) = 0 THEN NULL ELSE count(active) FILTER (WHERE CAST(active AS BOOLEAN)) = 1 END from users",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_HASH tests translation of HASH and HASH_AGG functions.
+// HASH(args) → signed 64-bit value derived from DuckDB hash(args)
+// HASH_AGG(args) → order-independent aggregate of HASH(args)
+func TestTranslator_HASH(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []TranslatorOption
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "HashColumns",
+			input:    "SELECT HASH(a, b) FROM t",
+			expected: "select CAST(CAST(hash(a, b) AS HUGEINT) - 9223372036854775808 AS BIGINT) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "HashStar",
+			input:    "SELECT HASH(*) FROM t",
+			expected: "select CAST(CAST(hash(*COLUMNS(*)) AS HUGEINT) - 9223372036854775808 AS BIGINT) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "HashAgg",
+			input:    "SELECT HASH_AGG(a) FROM t GROUP BY c",
+			expected: "select CAST((COALESCE(SUM(CAST(hash(a) AS HUGEINT)), 0) % 18446744073709551616) - 9223372036854775808 AS BIGINT) from t group by c",
+			wantErr:  false,
+		},
+		{
+			name:     "HashAggStar",
+			input:    "SELECT HASH_AGG(*) FROM t",
+			expected: "select CAST((COALESCE(SUM(CAST(hash(*COLUMNS(*)) AS HUGEINT)), 0) % 18446744073709551616) - 9223372036854775808 AS BIGINT) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "CustomHashFunction",
+			input:    "SELECT HASH(a) FROM t",
+			opts:     []TranslatorOption{WithHashFunction("my_hash")},
+			expected: "select CAST(CAST(my_hash(a) AS HUGEINT) - 9223372036854775808 AS BIGINT) from t",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator(tt.opts...)
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_CryptoFunctions tests hashing and encoding function translations.
+func TestTranslator_CryptoFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "MD5AndSHA1",
+			input:    "SELECT MD5(a), MD5_HEX(a), SHA1(a), SHA1_HEX(a) FROM t",
+			expected: "select md5(a), md5(a), sha1(a), sha1(a) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "BinaryDigests",
+			input:    "SELECT MD5_BINARY(a), SHA1_BINARY(a), SHA2_BINARY(a) FROM t",
+			expected: "select unhex(md5(a)), unhex(sha1(a)), unhex(sha256(a)) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "SHA2DefaultDigest",
+			input:    "SELECT SHA2('a,b'), SHA2_HEX(a, 256) FROM t",
+			expected: "select sha256('a,b'), sha256(a) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "SHA2OtherDigests",
+			input:    "SELECT SHA2(a, 512), SHA2_HEX(a, 224), SHA2_BINARY(a, 384) FROM t",
+			expected: "select snowflake_sha2(a, 512), snowflake_sha2(a, 224), unhex(snowflake_sha2(a, 384)) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "Base64",
+			input:    "SELECT BASE64_ENCODE(a), BASE64_DECODE_STRING(b), BASE64_DECODE_BINARY(b) FROM t",
+			expected: "select to_base64(encode(a)), decode(from_base64(b)), from_base64(b) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "TryBase64",
+			input:    "SELECT TRY_BASE64_DECODE_STRING(b) FROM t",
+			expected: "select TRY(decode(from_base64(b))) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "Hex",
+			input:    "SELECT HEX_ENCODE(a), HEX_ENCODE(a, 0), HEX_DECODE_STRING(h), TRY_HEX_DECODE_BINARY(h) FROM t",
+			expected: "select hex(a), lower(hex(a)), decode(unhex(h)), TRY(unhex(h)) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "EncryptDecrypt",
+			input:    "SELECT DECRYPT(ENCRYPT(a, 'passphrase'), 'passphrase'), TRY_DECRYPT(b, 'passphrase', 'aad', 'AES-GCM') FROM t",
+			expected: "select snowflake_decrypt(snowflake_encrypt(a, 'passphrase'), 'passphrase'), snowflake_try_decrypt(b, 'passphrase', 'aad', 'AES-GCM') from t",
+			wantErr:  false,
+		},
+		{
+			name:     "UUIDString",
+			input:    "SELECT UUID_STRING()",
+			expected: "select CAST(uuid() AS VARCHAR)",
+			wantErr:  false,
+		},
+		{
+			name:     "UUIDStringV5",
+			input:    "SELECT UUID_STRING('fe971b24-9572-4005-b22f-351e9c09274d', name) FROM t",
+			expected: "select snowflake_uuid_string('fe971b24-9572-4005-b22f-351e9c09274d', name) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "Generators",
+			input:    "SELECT RANDOM(), RANDOM(42), UNIFORM(1, 10, RANDOM()), NORMAL(0, 1, RANDOM(7)), ZIPF(1, 100, 3) FROM t",
+			expected: "select snowflake_random(), snowflake_random(42), snowflake_uniform(1, 10, snowflake_random()), snowflake_normal(0, 1, snowflake_random(7)), snowflake_zipf(1, 100, 3) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "ExtractFrom",
+			input:    "SELECT EXTRACT(epoch_second FROM ts), DATE_PART('yyyy', ts) FROM t",
+			expected: "select CAST(floor(epoch(ts)) AS BIGINT), date_part('year', ts) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "DateFromParts",
+			input:    "SELECT DATE_FROM_PARTS(y, m, d) FROM t",
+			expected: "select CAST((make_date(CAST(y AS BIGINT), 1, 1) + to_months(CAST(m AS INTEGER) - 1) + to_days(CAST(d AS INTEGER) - 1)) AS DATE) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "IntervalLiteral",
+			input:    "SELECT IFF(a, ts + INTERVAL '1 day, 3 hours', ts - INTERVAL '2 months, -3 d') FROM t",
+			expected: "select IF(a, ts + INTERVAL '1 day 3 hour', ts - INTERVAL '2 month -3 day') from t",
+			wantErr:  false,
+		},
+		{
+			name:     "ArraySlice",
+			input:    "SELECT ARRAY_SLICE(arr, 0, -1) FROM t",
+			expected: "select to_json(list_slice(CAST(CAST(arr AS JSON) AS JSON[]), 1, -2)) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "ToCharDateFormat",
+			input:    "SELECT TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS.FF3'), TO_VARCHAR(id) FROM t",
+			expected: "select strftime(created_at, '%Y-%m-%d %H:%M:%S.%g'), CAST(id AS VARCHAR) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "ParenthesisInLiteral",
+			input:    "SELECT MD5_BINARY(')')",
+			expected: "select unhex(md5(')'))",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_StringMatchFunctions tests string matching and similarity function translations.
+func TestTranslator_StringMatchFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "ContainsStartsWithEndsWith",
+			input:    "SELECT * FROM t WHERE CONTAINS(name, 'x') AND STARTSWITH(name, 'a') AND ENDSWITH(name, 'z')",
+			expected: "select * from t where contains(name, 'x') and starts_with(name, 'a') and ends_with(name, 'z')",
 			wantErr:  false,
 		},
 		{
-			name:     "DATEADDMonths",
-			input:    "SELECT DATEADD(month, 1, start_date) FROM subscriptions",
-			expected: "select (CAST(start_date AS DATE) + interval 1 month) from subscriptions",
+			name:     "EditDistance",
+			input:    "SELECT EDITDISTANCE(a, b) FROM t",
+			expected: "select levenshtein(a, b) from t",
 			wantErr:  false,
 		},
 		{
-			name:     "DATEADDYears",
-			input:    "SELECT DATEADD(year, 5, birth_date) FROM users",
-			expected: "select (CAST(birth_date AS DATE) + interval 5 year) from users",
+			name:     "EditDistanceWithMax",
+			input:    "SELECT EDITDISTANCE(a, b, 2) FROM t",
+			expected: "select LEAST(levenshtein(a, b), 2) from t",
 			wantErr:  false,
 		},
 		{
-			name:     "DATEADDNegative",
-			input:    "SELECT DATEADD(day, -30, CURRENT_DATE()) FROM dual",
-			expected: "select (CAST(CURRENT_DATE AS DATE) + interval -30 day)",
+			name:     "JaroWinklerSimilarity",
+			input:    "SELECT JAROWINKLER_SIMILARITY(a, b) FROM t",
+			expected: "select CAST(round(jaro_winkler_similarity(a, b) * 100) AS INTEGER) from t",
+			wantErr:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			translator := NewTranslator()
+			result, err := translator.Translate(tt.input)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if diff := cmp.Diff(tt.expected, result); diff != "" {
+					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+// TestTranslator_ConditionalFunctions tests DECODE, BOOL* and GREATEST/LEAST translations.
+func TestTranslator_ConditionalFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "DecodeWithDefault",
+			input:    "SELECT DECODE(x, 1, 'one', 2, 'two', 'other') FROM t",
+			expected: "select CASE WHEN x IS NOT DISTINCT FROM 1 THEN 'one' WHEN x IS NOT DISTINCT FROM 2 THEN 'two' ELSE 'other' END from t",
 			wantErr:  false,
 		},
 		{
-			name:     "DATEADDHours",
-			input:    "SELECT DATEADD(hour, 24, created_at) FROM events",
-			expected: "select (CAST(created_at AS DATE) + interval 24 hour) from events",
+			name:     "DecodeWithoutDefault",
+			input:    "SELECT DECODE(x, NULL, 'none') FROM t",
+			expected: "select CASE WHEN x IS NOT DISTINCT FROM null THEN 'none' END from t",
 			wantErr:  false,
 		},
 		{
-			name:     "MultipleDATEADD",
-			input:    "SELECT DATEADD(day, 1, date1), DATEADD(month, 2, date2) FROM test",
-			expected: "select (CAST(date1 AS DATE) + interval 1 day), (CAST(date2 AS DATE) + interval 2 month) from test",
+			name:     "BoolFunctions",
+			input:    "SELECT BOOLAND(a, b), BOOLOR(a, b), BOOLXOR(a, b), BOOLNOT(a) FROM t",
+			expected: "select (CAST(a AS BOOLEAN) AND CAST(b AS BOOLEAN)), (CAST(a AS BOOLEAN) OR CAST(b AS BOOLEAN)), (CAST(a AS BOOLEAN) != CAST(b AS BOOLEAN)), (NOT CAST(a AS BOOLEAN)) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "GreatestLeastNullSemantics",
+			input:    "SELECT GREATEST(a, b), LEAST(a, 1) FROM t",
+			expected: "select CASE WHEN (a) IS NULL OR (b) IS NULL THEN NULL ELSE greatest(a, b) END, CASE WHEN (a) IS NULL OR (1) IS NULL THEN NULL ELSE least(a, 1) END from t",
+			wantErr:  false,
+		},
+		{
+			name:     "IgnoreNulls",
+			input:    "SELECT GREATEST_IGNORE_NULLS(a, b), LEAST_IGNORE_NULLS(a, b) FROM t",
+			expected: "select greatest(a, b), least(a, b) from t",
 			wantErr:  false,
 		},
 	}
@@ -607,50 +1235,90 @@ func TestTranslator_DATEADD(t *testing.T) {
 	}
 }
 
-// TestTranslator_DATEDIFF tests DATEDIFF function translation.
-// DATEDIFF(part, start, end) → DATE_DIFF('part', start, end) for DuckDB
-func TestTranslator_DATEDIFF(t *testing.T) {
+// TestTranslator_VariantTypeFunctions tests TYPEOF and IS_<type> translations.
+func TestTranslator_VariantTypeFunctions(t *testing.T) {
+	typeOf := "CASE json_type(v) WHEN 'NULL' THEN 'NULL_VALUE' WHEN 'BIGINT' THEN 'INTEGER' WHEN 'UBIGINT' THEN 'INTEGER' WHEN 'DOUBLE' THEN CASE WHEN contains(lower(CAST(v AS VARCHAR)), 'e') THEN 'DOUBLE' ELSE 'DECIMAL' END ELSE json_type(v) END"
 	tests := []struct {
 		name     string
 		input    string
 		expected string
-		wantErr  bool
 	}{
 		{
-			name:     "DATEDIFFDays",
-			input:    "SELECT DATEDIFF(day, start_date, end_date) FROM events",
-			expected: "select DATE_DIFF('day', CAST(start_date AS DATE), CAST(end_date AS DATE)) from events",
-			wantErr:  false,
+			name:     "Typeof",
+			input:    "SELECT TYPEOF(v) FROM t",
+			expected: "select (" + typeOf + ") from t",
 		},
 		{
-			name:     "DATEDIFFMonths",
-			input:    "SELECT DATEDIFF(month, hire_date, CURRENT_DATE()) FROM employees",
-			expected: "select DATE_DIFF('month', CAST(hire_date AS DATE), CAST(CURRENT_DATE AS DATE)) from employees",
+			name:     "IsInteger",
+			input:    "SELECT IS_INTEGER(v) FROM t",
+			expected: "select ((" + typeOf + ") IN ('INTEGER')) from t",
+		},
+		{
+			name:     "IsDoubleAcceptsNumbers",
+			input:    "SELECT id FROM t WHERE IS_DOUBLE(v)",
+			expected: "select id from t where ((" + typeOf + ") IN ('INTEGER', 'DECIMAL', 'DOUBLE'))",
+		},
+		{
+			name:     "IsNullValue",
+			input:    "SELECT IS_NULL_VALUE(v) FROM t",
+			expected: "select ((" + typeOf + ") IN ('NULL_VALUE')) from t",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTranslator().Translate(tt.input)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestTranslator_ImplicitCasting tests numeric string literal coercion in arithmetic.
+func TestTranslator_ImplicitCasting(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []TranslatorOption
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Disabled",
+			input:    "SELECT '5' + 1",
+			expected: "select '5' + 1",
 			wantErr:  false,
 		},
 		{
-			name:     "DATEDIFFYears",
-			input:    "SELECT DATEDIFF(year, birth_date, CURRENT_DATE()) FROM users",
-			expected: "select DATE_DIFF('year', CAST(birth_date AS DATE), CAST(CURRENT_DATE AS DATE)) from users",
+			name:     "IntegerLiteral",
+			input:    "SELECT '5' + 1, a * '-2' FROM t",
+			opts:     []TranslatorOption{WithImplicitCasting()},
+			expected: "select 5 + 1, a * -2 from t",
 			wantErr:  false,
 		},
 		{
-			name:     "DATEDIFFInWHERE",
-			input:    "SELECT * FROM orders WHERE DATEDIFF(day, order_date, ship_date) > 5",
-			expected: "select * from orders where DATE_DIFF('day', CAST(order_date AS DATE), CAST(ship_date AS DATE)) > 5",
+			name:     "DecimalLiteral",
+			input:    "SELECT price - '1.5e2' FROM t",
+			opts:     []TranslatorOption{WithImplicitCasting()},
+			expected: "select price - 1.5e2 from t",
 			wantErr:  false,
 		},
 		{
-			name:     "MultipleDATEDIFF",
-			input:    "SELECT DATEDIFF(day, a, b), DATEDIFF(month, c, d) FROM test",
-			expected: "select DATE_DIFF('day', CAST(a AS DATE), CAST(b AS DATE)), DATE_DIFF('month', CAST(c AS DATE), CAST(d AS DATE)) from test",
+			name:     "NonNumericLiteralUnchanged",
+			input:    "SELECT d + 'abc', name = '5' FROM t",
+			opts:     []TranslatorOption{WithImplicitCasting()},
+			expected: "select d + 'abc', name = '5' from t",
 			wantErr:  false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			translator := NewTranslator()
+			translator := NewTranslator(tt.opts...)
 			result, err := translator.Translate(tt.input)
 
 			if (err != nil) != tt.wantErr {
@@ -667,9 +1335,8 @@ func TestTranslator_DATEDIFF(t *testing.T) {
 	}
 }
 
-// TestTranslator_OBJECT_CONSTRUCT tests OBJECT_CONSTRUCT function translation.
-// OBJECT_CONSTRUCT('key1', val1, ...) → json_object('key1', val1, ...) for DuckDB
-func TestTranslator_OBJECT_CONSTRUCT(t *testing.T) {
+// TestTranslator_NullOrdering tests that ORDER BY items get Snowflake's default NULL placement.
+func TestTranslator_NullOrdering(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -677,27 +1344,45 @@ func TestTranslator_OBJECT_CONSTRUCT(t *testing.T) {
 		wantErr  bool
 	}{
 		{
-			name:     "SimpleOBJECT_CONSTRUCT",
-			input:    "SELECT OBJECT_CONSTRUCT('key', value) FROM test",
-			expected: "select json_object('key', value) from test",
+			name:     "AscAndDesc",
+			input:    "SELECT a FROM t ORDER BY a, b DESC",
+			expected: "select a from t order by a asc NULLS LAST, b desc NULLS FIRST",
 			wantErr:  false,
 		},
 		{
-			name:     "OBJECT_CONSTRUCTMultipleKeys",
-			input:    "SELECT OBJECT_CONSTRUCT('name', name, 'age', age) FROM users",
-			expected: "select json_object('name', name, 'age', age) from users",
+			name:     "WithLimit",
+			input:    "SELECT a FROM t ORDER BY a DESC LIMIT 10",
+			expected: "select a from t order by a desc NULLS FIRST limit 10",
 			wantErr:  false,
 		},
 		{
-			name:     "OBJECT_CONSTRUCTWithLiterals",
-			input:    "SELECT OBJECT_CONSTRUCT('status', 'active', 'count', 42) FROM dual",
-			expected: "select json_object('status', 'active', 'count', 42)",
+			name:     "Subquery",
+			input:    "SELECT a FROM (SELECT a FROM t ORDER BY a) x",
+			expected: "select a from (select a from t order by a asc NULLS LAST) as x",
 			wantErr:  false,
 		},
 		{
-			name:     "MultipleOBJECT_CONSTRUCT",
-			input:    "SELECT OBJECT_CONSTRUCT('a', 1), OBJECT_CONSTRUCT('b', 2) FROM test",
-			expected: "select json_object('a', 1), json_object('b', 2) from test",
+			name:     "WindowFunction",
+			input:    "SELECT ROW_NUMBER() OVER (PARTITION BY a ORDER BY b DESC) FROM t",
+			expected: "SELECT ROW_NUMBER() OVER (PARTITION BY a ORDER BY b DESC NULLS FIRST) FROM t",
+			wantErr:  false,
+		},
+		{
+			name:     "WindowFrame",
+			input:    "SELECT SUM(x) OVER (ORDER BY d ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) FROM t",
+			expected: "SELECT SUM(x) OVER (ORDER BY d NULLS LAST ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) FROM t",
+			wantErr:  false,
+		},
+		{
+			name:     "ExplicitNullOrderingKept",
+			input:    "SELECT a FROM t ORDER BY a DESC NULLS LAST, b NULLS FIRST",
+			expected: "SELECT a FROM t ORDER BY a DESC NULLS LAST, b NULLS FIRST",
+			wantErr:  false,
+		},
+		{
+			name:     "OrderByInStringLiteral",
+			input:    "SELECT 'order by x' FROM t",
+			expected: "select 'order by x' from t",
 			wantErr:  false,
 		},
 	}
@@ -721,11 +1406,8 @@ func TestTranslator_OBJECT_CONSTRUCT(t *testing.T) {
 	}
 }
 
-// TestTranslator_FLATTEN tests FLATTEN function translation.
-// Note: FLATTEN with => syntax is not supported by vitess-sqlparser,
-// so it falls back to original SQL (graceful degradation).
-// Simple FLATTEN(array) → UNNEST(array) for DuckDB
-func TestTranslator_FLATTEN(t *testing.T) {
+// TestTranslator_Division tests exact decimal division and ROUND mode translations.
+func TestTranslator_Division(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -733,15 +1415,45 @@ func TestTranslator_FLATTEN(t *testing.T) {
 		wantErr  bool
 	}{
 		{
-			name:     "SimpleFLATTEN",
-			input:    "SELECT FLATTEN(array_col) FROM test",
-			expected: "select UNNEST(array_col) from test",
+			name:     "IntegerOperands",
+			input:    "SELECT a / b FROM t",
+			expected: "select " + exactDivisionSQL("a", "b", 6) + " from t",
 			wantErr:  false,
 		},
 		{
-			name:     "FLATTENWithNamedParam_GracefulDegradation",
-			input:    "SELECT * FROM TABLE(FLATTEN(input => array_col))",
-			expected: "SELECT * FROM TABLE(FLATTEN(input => array_col))", // Parser fails on => syntax
+			name:     "DecimalLiteralScale",
+			input:    "SELECT 1.00 / 3",
+			expected: "select " + exactDivisionSQL("1.00", "3", 8),
+			wantErr:  false,
+		},
+		{
+			name:     "NestedDivisionScale",
+			input:    "SELECT 1 / 2 / 3",
+			expected: "select " + exactDivisionSQL(exactDivisionSQL("1", "2", 6), "3", 12),
+			wantErr:  false,
+		},
+		{
+			name:     "ParenthesizedOperand",
+			input:    "SELECT (a + 1) / 2 FROM t WHERE a / 2 > 1",
+			expected: "select " + exactDivisionSQL("(a + 1)", "2", 6) + " from t where " + exactDivisionSQL("a", "2", 6) + " > 1",
+			wantErr:  false,
+		},
+		{
+			name:     "NullOperand",
+			input:    "SELECT a / NULL FROM t",
+			expected: "select " + exactDivisionSQL("a", "CAST(NULL AS INTEGER)", 6) + " from t",
+			wantErr:  false,
+		},
+		{
+			name:     "FloatOperandUnchanged",
+			input:    "SELECT a / 1e2 FROM t",
+			expected: "select a / 1e2 from t",
+			wantErr:  false,
+		},
+		{
+			name:     "RoundHalfToEven",
+			input:    "SELECT ROUND(x, 2, 'HALF_TO_EVEN'), ROUND(x, 2, 'HALF_AWAY_FROM_ZERO'), ROUND(x) FROM t",
+			expected: "select CASE WHEN abs(x * 100 - trunc(x * 100)) = 0.5 AND trunc(x * 100) % 2 = 0 THEN trunc(x, 2) ELSE round(x, 2) END, round(x, 2), round(x) from t",
 			wantErr:  false,
 		},
 	}
@@ -765,31 +1477,84 @@ func TestTranslator_FLATTEN(t *testing.T) {
 	}
 }
 
-// TestTranslator_LISTAGG tests LISTAGG function translation.
-// LISTAGG(col, sep) → STRING_AGG(col, sep)
-func TestTranslator_LISTAGG(t *testing.T) {
+// TestTranslator_NestedDivisionSize tests that each operand of nested
+// divisions is written once, so that the translation grows linearly with the
+// number of divisions.
+func TestTranslator_NestedDivisionSize(t *testing.T) {
+	translator := NewTranslator()
+	single, err := translator.Translate("SELECT op00 / op01 FROM t")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+
+	operands := []string{"op00"}
+	for i := 1; i <= 20; i++ {
+		operands = append(operands, fmt.Sprintf("op%02d", i))
+	}
+	result, err := translator.Translate("SELECT " + strings.Join(operands, " / ") + " FROM t")
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	for _, operand := range operands {
+		if n := strings.Count(result, operand); n != 1 {
+			t.Errorf("operand %s written %d times, want once", operand, n)
+		}
+	}
+	if limit := 20 * len(single); len(result) > limit {
+		t.Errorf("translation of 20 nested divisions is %d characters, want at most %d", len(result), limit)
+	}
+}
+
+// exactDivisionSQL returns the translation of the exact division of a by b.
+func exactDivisionSQL(a, b string, scale int) string {
+	isFloat := "typeof(__d.a) IN ('DOUBLE', 'FLOAT') OR typeof(__d.b) IN ('DOUBLE', 'FLOAT')"
+	return fmt.Sprintf("list_transform([{'a': %s, 'b': %s}], lambda __d: struct_extract({"+
+		"'double': CASE WHEN %[3]s THEN snowflake_divide(CAST(__d.a AS DOUBLE), CAST(__d.b AS DOUBLE)) END, "+
+		"'number': CASE WHEN %[3]s THEN NULL ELSE CAST(snowflake_divide(__d.a, __d.b, %[4]d) AS DECIMAL(38, %[4]d)) END}, "+
+		"CASE WHEN %[3]s THEN 'double' ELSE 'number' END))[1]", a, b, isFloat, scale)
+}
+
+// TestTranslator_WeekFunctions tests week function translations under WEEK_START and WEEK_OF_YEAR_POLICY.
+func TestTranslator_WeekFunctions(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
+		params   SessionParameters
 		expected string
 		wantErr  bool
 	}{
 		{
-			name:     "SimpleLISTAGG",
-			input:    "SELECT LISTAGG(name, ',') FROM test GROUP BY category",
-			expected: "select STRING_AGG(name, ',') from test group by category",
+			name:     "Defaults",
+			input:    "SELECT DAYOFWEEK(d), WEEK(d), DATE_TRUNC('week', d) FROM t",
+			expected: "select dayofweek(CAST(d AS DATE)), week(CAST(d AS DATE)), time_bucket(INTERVAL '7 days', d, INTERVAL '0 days') from t",
 			wantErr:  false,
 		},
 		{
-			name:     "LISTAGGWithSpace",
-			input:    "SELECT LISTAGG(name, ', ') FROM test GROUP BY category",
-			expected: "select STRING_AGG(name, ', ') from test group by category",
+			name:     "ISOFunctions",
+			input:    "SELECT DAYOFWEEKISO(d), WEEKISO(d), YEAROFWEEKISO(d) FROM t",
+			params:   SessionParameters{WeekStart: 7},
+			expected: "select isodow(d), week(d), isoyear(d) from t",
 			wantErr:  false,
 		},
 		{
-			name:     "MultipleLISTAGG",
-			input:    "SELECT LISTAGG(a, '-'), LISTAGG(b, '|') FROM test GROUP BY c",
-			expected: "select STRING_AGG(a, '-'), STRING_AGG(b, '|') from test group by c",
+			name:     "DayOfWeekSundayStart",
+			input:    "SELECT DAYOFWEEK(d) FROM t",
+			params:   SessionParameters{WeekStart: 7},
+			expected: "select (CAST((isodow(CAST(d AS DATE)) - 7 + 7) % 7 AS INTEGER) + 1) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "DateTruncWeekSundayStart",
+			input:    "SELECT DATE_TRUNC('week', d), DATE_TRUNC('month', d) FROM t",
+			params:   SessionParameters{WeekStart: 7},
+			expected: "select time_bucket(INTERVAL '7 days', d, INTERVAL '6 days'), date_trunc('month', d) from t",
+			wantErr:  false,
+		},
+		{
+			name:     "WeekOfYearPolicy",
+			input:    "SELECT WEEKOFYEAR(d) FROM t",
+			params:   SessionParameters{WeekStart: 1, WeekOfYearPolicy: 1},
+			expected: "select ((dayofyear(CAST(d AS DATE)) - 1 + CAST((isodow(date_trunc('year', CAST(d AS DATE))) - 1 + 7) % 7 AS INTEGER)) // 7 + 1) from t",
 			wantErr:  false,
 		},
 	}
@@ -797,16 +1562,16 @@ func TestTranslator_LISTAGG(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			translator := NewTranslator()
-			result, err := translator.Translate(tt.input)
+			result, err := translator.TranslateWithParameters(tt.input, tt.params)
 
 			if (err != nil) != tt.wantErr {
-				t.Errorf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("TranslateWithParameters() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
 			if !tt.wantErr {
 				if diff := cmp.Diff(tt.expected, result); diff != "" {
-					t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+					t.Errorf("TranslateWithParameters() mismatch (-want +got):\n%s", diff)
 				}
 			}
 		})
@@ -1024,7 +1789,7 @@ func TestTranslator_EdgeCases(t *testing.T) {
 		{
 			name:     "FunctionInORDERBY",
 			input:    "SELECT * FROM users ORDER BY IFF(premium, 1, 2), NVL(name, 'ZZZ')",
-			expected: "select * from users order by IF(premium, 1, 2) asc, COALESCE(name, 'ZZZ') asc", // Parser adds ASC
+			expected: "select * from users order by IF(premium, 1, 2) asc NULLS LAST, COALESCE(name, 'ZZZ') asc NULLS LAST", // Parser adds ASC
 			wantErr:  false,
 		},
 		{
@@ -1075,6 +1840,122 @@ func TestTranslator_EdgeCases(t *testing.T) {
 	}
 }
 
+// TestTranslator_TypeMapping tests that Snowflake types in casts and DDL map to DuckDB types.
+func TestTranslator_TypeMapping(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "CastToNumber",
+			input:    "SELECT CAST(a AS NUMBER(10,2)) FROM t",
+			expected: "select CAST(a AS DECIMAL(10, 2)) from t",
+		},
+		{
+			name:     "CastToVariant",
+			input:    "SELECT CAST(a AS VARIANT), CAST(b AS TIMESTAMP_LTZ) FROM t",
+			expected: "select CAST(a AS JSON), CAST(b AS TIMESTAMPTZ) from t",
+		},
+		{
+			name:     "TryCast",
+			input:    "SELECT TRY_CAST(a AS FLOAT) FROM t",
+			expected: "select TRY_CAST(a AS double) from t",
+		},
+		{
+			name:     "DoubleColonCast",
+			input:    "SELECT a::NUMBER(10, 2), b::timestamp_ntz, c::STRING FROM t",
+			expected: "select CAST(a AS DECIMAL(10,2)), CAST(b AS TIMESTAMP), CAST(c AS VARCHAR) from t",
+		},
+		{
+			name:     "DoubleColonCastInFunction",
+			input:    "SELECT ARRAY_CONTAINS('b'::VARIANT, arr), IFF(x::INT > 1, 'a', 'b') FROM t",
+			expected: "select list_contains(CAST(CAST(arr AS JSON) AS JSON[]), to_json(to_json('b'))), IF(CAST(x AS BIGINT) > 1, 'a', 'b') from t",
+		},
+		{
+			name:     "StringLiteralsUseStandardQuoting",
+			input:    `SELECT 'it''s', 'say "hi"', 'a\\b'`,
+			expected: `select 'it''s', 'say "hi"', E'a\\b'`,
+		},
+		{
+			name: "CreateTableColumns",
+			input: "CREATE TABLE t (id NUMBER(10,0) NOT NULL, n NUMBER, f FLOAT, ts TIMESTAMP_NTZ(9), " +
+				"tz TIMESTAMP WITH TIME ZONE, v VARIANT, b BINARY, g GEOGRAPHY, s STRING DEFAULT 'a,b', PRIMARY KEY (id))",
+			expected: "CREATE TABLE t (id DECIMAL(10,0) NOT NULL, n DECIMAL(38,0), f DOUBLE, ts TIMESTAMP, " +
+				"tz TIMESTAMPTZ, v JSON, b BLOB, g VARCHAR, s VARCHAR DEFAULT 'a,b', PRIMARY KEY (id))",
+		},
+		{
+			name:     "CreateTableAsSelect",
+			input:    "CREATE TABLE t2 AS SELECT a::NUMBER FROM t",
+			expected: "CREATE TABLE t2 AS SELECT a::DECIMAL(38,0) FROM t",
+		},
+		{
+			name:     "AlterTableAddColumn",
+			input:    "ALTER TABLE t ADD COLUMN x NUMBER(5,2)",
+			expected: "ALTER TABLE t ADD COLUMN x DECIMAL(5,2)",
+		},
+		{
+			name:     "AlterColumnType",
+			input:    "ALTER TABLE t ALTER COLUMN x SET DATA TYPE VARIANT",
+			expected: "ALTER TABLE t ALTER COLUMN x SET DATA TYPE JSON",
+		},
+		{
+			name:     "NiladicDefault",
+			input:    "CREATE TABLE t (ts TIMESTAMP_LTZ(9) DEFAULT CURRENT_TIMESTAMP(), d DATE DEFAULT current_date ( ))",
+			expected: "CREATE TABLE t (ts TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, d DATE DEFAULT current_date)",
+		},
+		{
+			name:     "UnknownTypeUnchanged",
+			input:    "CREATE TABLE t (id HUGEINT)",
+			expected: "CREATE TABLE t (id HUGEINT)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewTranslator().Translate(tt.input)
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("Translate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRewriteCastOperators(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "Column", sql: "SELECT t.a::NUMBER(10, 2) FROM t", want: "SELECT __CAST__(t.a, 'DECIMAL(10,2)') FROM t"},
+		{name: "Spaces", sql: "SELECT a :: INT FROM t", want: "SELECT __CAST__(a, 'BIGINT') FROM t"},
+		{name: "StringToVariant", sql: "SELECT ARRAY_CONTAINS('b'::VARIANT, arr)", want: "SELECT ARRAY_CONTAINS(to_json('b'), arr)"},
+		{name: "GetPath", sql: "SELECT GET_PATH(v, 'a.b')::STRING, get(v, 0)::INT, GET(v, 'a')::OBJECT FROM t", want: "SELECT __CAST__(json_extract_string(GET_PATH(v, 'a.b'), '$'), 'VARCHAR'), __CAST__(json_extract_string(get(v, 0), '$'), 'BIGINT'), __CAST__(GET(v, 'a'), 'JSON') FROM t"},
+		{name: "Number", sql: "SELECT 1.5::FLOAT", want: "SELECT __CAST__(1.5, 'DOUBLE')"},
+		{name: "FunctionCall", sql: "SELECT UPPER(a || ')')::STRING FROM t", want: "SELECT __CAST__(UPPER(a || ')'), 'VARCHAR') FROM t"},
+		{name: "Parenthesized", sql: "SELECT (a + b)::INT FROM t", want: "SELECT __CAST__((a + b), 'BIGINT') FROM t"},
+		{name: "Bracketed", sql: `SELECT "Arr"[0]::STRING FROM t`, want: `SELECT __CAST__("Arr"[0], 'VARCHAR') FROM t`},
+		{name: "Chained", sql: "SELECT a::NUMBER::STRING FROM t", want: "SELECT __CAST__(__CAST__(a, 'DECIMAL(38,0)'), 'VARCHAR') FROM t"},
+		{name: "TypedLiteral", sql: "SELECT DATE '2024-01-01'::TIMESTAMP", want: "SELECT __CAST__(DATE '2024-01-01', 'TIMESTAMP')"},
+		{name: "CaseEnd", sql: "SELECT CASE WHEN a THEN 1 END::STRING FROM t", want: "SELECT CASE WHEN a THEN 1 END::STRING FROM t"},
+		{name: "Placeholder", sql: "SELECT :1::INT, ?::INT", want: "SELECT :1::INT, ?::INT"},
+		{name: "UnknownType", sql: "SELECT a::HUGEINT FROM t", want: "SELECT a::HUGEINT FROM t"},
+		{name: "Literal", sql: "SELECT 'a::INT' FROM t", want: "SELECT 'a::INT' FROM t"},
+		{name: "Comment", sql: "SELECT /* a::INT */ 1", want: "SELECT /* a::INT */ 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, rewriteCastOperators(tt.sql)); diff != "" {
+				t.Errorf("rewriteCastOperators(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
 // normalizeWhitespace removes extra whitespace and newlines for comparison.
 func normalizeWhitespace(s string) string {
 	// Simple normalization: replace multiple whitespace with single space
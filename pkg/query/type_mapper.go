@@ -3,7 +3,10 @@ package query
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
 	"github.com/nnnkkk7/snowflake-emulator/server/types"
 )
 
@@ -14,94 +17,76 @@ const (
 )
 
 // TypeMapper provides DuckDB to Snowflake type mapping functionality.
-type TypeMapper struct {
-	typeMapping map[string]string
-}
+// Mappings come from the authoritative table in the types package.
+type TypeMapper struct{}
 
 // NewTypeMapper creates a new type mapper with default mappings.
 func NewTypeMapper() *TypeMapper {
-	return &TypeMapper{
-		typeMapping: map[string]string{
-			"BIGINT":       "NUMBER",
-			"INTEGER":      "NUMBER",
-			"INT":          "NUMBER",
-			"SMALLINT":     "NUMBER",
-			"TINYINT":      "NUMBER",
-			"HUGEINT":      "NUMBER",
-			"DOUBLE":       "FLOAT",
-			"FLOAT":        "FLOAT",
-			"REAL":         "FLOAT",
-			"VARCHAR":      TypeText,
-			"TEXT":         TypeText,
-			"STRING":       TypeText,
-			"TIMESTAMP":    "TIMESTAMP_NTZ",
-			"TIMESTAMP_NS": "TIMESTAMP_NTZ",
-			"TIMESTAMP_MS": "TIMESTAMP_NTZ",
-			"TIMESTAMP_S":  "TIMESTAMP_NTZ",
-			"TIMESTAMPTZ":  "TIMESTAMP_TZ",
-			"DATE":         "DATE",
-			"TIME":         "TIME",
-			"BOOLEAN":      "BOOLEAN",
-			"BOOL":         "BOOLEAN",
-			"DECIMAL":      "NUMBER",
-			"NUMERIC":      "NUMBER",
-			"BLOB":         "BINARY",
-			"BYTEA":        "BINARY",
-			"UUID":         TypeText,
-			"INTERVAL":     TypeText,
-			"JSON":         "VARIANT",
-			"LIST":         "ARRAY",
-			"STRUCT":       "OBJECT",
-			"MAP":          "OBJECT",
-		},
-	}
+	return &TypeMapper{}
 }
 
-// MapDuckDBType converts a DuckDB type to its Snowflake equivalent.
+// MapDuckDBType converts a DuckDB type to the Snowflake row type reported to clients,
+// such as fixed, real, or text.
 func (m *TypeMapper) MapDuckDBType(duckType string) string {
-	if sfType, ok := m.typeMapping[duckType]; ok {
-		return sfType
-	}
-	return TypeText // Default fallback
+	return sftypes.FromDuckDBType(duckType).RowType
 }
 
 // InferRowType generates column metadata from column names and optional sql.Rows.
 func (m *TypeMapper) InferRowType(columns []string, rows *sql.Rows) []types.ColumnMetadata {
 	rowType := make([]types.ColumnMetadata, len(columns))
 
+	var columnTypes []*sql.ColumnType
+	if rows != nil {
+		columnTypes, _ = rows.ColumnTypes()
+	}
 	for i, col := range columns {
-		meta := types.ColumnMetadata{
-			Name:     col,
-			Type:     TypeText, // Default type
-			Nullable: true,
-		}
-
-		// If we have rows, try to infer types from column types
-		if rows != nil {
-			columnTypes, err := rows.ColumnTypes()
-			if err == nil && i < len(columnTypes) {
-				dbType := columnTypes[i].DatabaseTypeName()
-				meta.Type = m.MapDuckDBType(dbType)
-
-				if length, ok := columnTypes[i].Length(); ok {
-					meta.Length = length
-				}
-				if precision, scale, ok := columnTypes[i].DecimalSize(); ok {
-					meta.Precision = precision
-					meta.Scale = scale
-				}
-				if nullable, ok := columnTypes[i].Nullable(); ok {
-					meta.Nullable = nullable
-				}
+		if i >= len(columnTypes) {
+			rowType[i] = types.ColumnMetadata{
+				Name:     col,
+				Type:     strings.ToLower(TypeText), // Default type
+				Nullable: true,
 			}
+			continue
 		}
 
+		meta := m.ColumnMetadata(col, columnTypes[i].DatabaseTypeName())
+		if length, ok := columnTypes[i].Length(); ok {
+			meta.Length = length
+		}
+		if p, s, ok := columnTypes[i].DecimalSize(); ok {
+			meta.Precision = p
+			meta.Scale = s
+		}
+		if nullable, ok := columnTypes[i].Nullable(); ok {
+			meta.Nullable = nullable
+		}
 		rowType[i] = meta
 	}
 
 	return rowType
 }
 
+// ColumnMetadata generates the metadata of a result column from its DuckDB
+// type name, as reported by the driver.
+func (m *TypeMapper) ColumnMetadata(name, duckType string) types.ColumnMetadata {
+	mapping := sftypes.FromDuckDBType(duckType)
+	meta := types.ColumnMetadata{
+		Name:     name,
+		Type:     mapping.RowType,
+		Nullable: true,
+	}
+	var precision, scale int64
+	if _, err := fmt.Sscanf(duckType, "DECIMAL(%d,%d)", &precision, &scale); err == nil {
+		// The DuckDB driver only reports decimal sizes in the type name
+		meta.Precision = precision
+		meta.Scale = scale
+	} else if mapping.Type == sftypes.TypeNumber {
+		// Snowflake reports integers as NUMBER(38,0)
+		meta.Precision = 38
+	}
+	return meta
+}
+
 // defaultTypeMapper is the package-level type mapper instance.
 // Prefer using convenience functions MapDuckDBTypeToSnowflake and InferColumnMetadata.
 var defaultTypeMapper = NewTypeMapper()
@@ -119,3 +104,17 @@ func MapDuckDBTypeToSnowflake(duckType string) string {
 func InferColumnMetadata(columns []string, rows *sql.Rows) []types.ColumnMetadata {
 	return defaultTypeMapper.InferRowType(columns, rows)
 }
+
+// ColumnTypeName returns the Snowflake type declaration for result column
+// metadata, such as NUMBER(38,0) for a fixed column or TIMESTAMP_NTZ.
+func ColumnTypeName(col types.ColumnMetadata) string {
+	switch col.Type {
+	case "fixed":
+		return fmt.Sprintf("NUMBER(%d,%d)", col.Precision, col.Scale)
+	case "real":
+		return string(sftypes.TypeFloat)
+	case "text", "":
+		return string(sftypes.TypeVarchar)
+	}
+	return strings.ToUpper(col.Type)
+}
@@ -1,7 +1,17 @@
 package query
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -13,28 +23,30 @@ func TestTypeMapper_MapDuckDBType(t *testing.T) {
 		duckType     string
 		expectedType string
 	}{
-		{"BIGINT", "NUMBER"},
-		{"INTEGER", "NUMBER"},
-		{"INT", "NUMBER"},
-		{"SMALLINT", "NUMBER"},
-		{"DOUBLE", "FLOAT"},
-		{"FLOAT", "FLOAT"},
-		{"VARCHAR", "TEXT"},
-		{"TEXT", "TEXT"},
-		{"STRING", "TEXT"},
-		{"TIMESTAMP", "TIMESTAMP_NTZ"},
-		{"TIMESTAMPTZ", "TIMESTAMP_TZ"},
-		{"DATE", "DATE"},
-		{"TIME", "TIME"},
-		{"BOOLEAN", "BOOLEAN"},
-		{"BOOL", "BOOLEAN"},
-		{"DECIMAL", "NUMBER"},
-		{"BLOB", "BINARY"},
-		{"JSON", "VARIANT"},
-		{"LIST", "ARRAY"},
-		{"STRUCT", "OBJECT"},
-		{"MAP", "OBJECT"},
-		{"UNKNOWN_TYPE", "TEXT"}, // fallback
+		{"BIGINT", "fixed"},
+		{"INTEGER", "fixed"},
+		{"INT", "fixed"},
+		{"SMALLINT", "fixed"},
+		{"DOUBLE", "real"},
+		{"FLOAT", "real"},
+		{"VARCHAR", "text"},
+		{"TEXT", "text"},
+		{"STRING", "text"},
+		{"TIMESTAMP", "timestamp_ntz"},
+		{"TIMESTAMPTZ", "timestamp_ltz"},
+		{"DATE", "date"},
+		{"TIME", "time"},
+		{"BOOLEAN", "boolean"},
+		{"BOOL", "boolean"},
+		{"DECIMAL", "fixed"},
+		{"DECIMAL(10,2)", "fixed"},
+		{"BLOB", "binary"},
+		{"JSON", "variant"},
+		{"LIST", "array"},
+		{"INTEGER[]", "array"},
+		{"STRUCT", "object"},
+		{"MAP", "object"},
+		{"UNKNOWN_TYPE", "text"}, // fallback
 	}
 
 	for _, tc := range testCases {
@@ -55,16 +67,133 @@ func TestInferColumnMetadata_WithoutRows(t *testing.T) {
 		t.Fatalf("expected 3 columns, got %d", len(result))
 	}
 
-	// Without rows, all types default to TEXT
+	// Without rows, all types default to text
 	for i, col := range result {
 		if col.Name != columns[i] {
 			t.Errorf("column %d: expected name %s, got %s", i, columns[i], col.Name)
 		}
-		if col.Type != "TEXT" {
-			t.Errorf("column %d: expected type TEXT (default), got %s", i, col.Type)
+		if col.Type != "text" {
+			t.Errorf("column %d: expected type text (default), got %s", i, col.Type)
 		}
 		if !col.Nullable {
 			t.Errorf("column %d: expected nullable true, got false", i)
 		}
 	}
 }
+
+// TestTypeMapping_ValueRoundTrip checks that random values bound as each Snowflake type,
+// stored in a column declared with that type, and read back are unchanged and reported
+// with the type's row type.
+func TestTypeMapping_ValueRoundTrip(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	// roundTrip stores one bound value in a column of type decl and reads it back
+	roundTrip := func(decl, wantRowType string, b *QueryBindingValue) (any, bool) {
+		if _, err := executor.Execute(ctx, "CREATE OR REPLACE TABLE round_trip (v "+decl+")"); err != nil {
+			t.Errorf("CREATE TABLE (v %s) error = %v", decl, err)
+			return nil, false
+		}
+		if _, err := executor.ExecuteWithBindings(ctx, "INSERT INTO round_trip VALUES (:1)", map[string]*QueryBindingValue{"1": b}); err != nil {
+			t.Errorf("INSERT %s %q error = %v", b.Type, b.Value, err)
+			return nil, false
+		}
+		result, err := executor.Query(ctx, "SELECT v FROM round_trip")
+		if err != nil || len(result.Rows) != 1 {
+			t.Errorf("SELECT error = %v, rows = %v", err, result)
+			return nil, false
+		}
+		if got := result.ColumnTypes[0].Type; got != wantRowType {
+			t.Errorf("row type of %s = %q, want %q", decl, got, wantRowType)
+			return nil, false
+		}
+		return result.Rows[0][0], true
+	}
+
+	// sameNumber compares a stored decimal with its literal
+	sameNumber := func(got any, want string) bool {
+		gotRat, ok1 := new(big.Rat).SetString(fmt.Sprint(got))
+		wantRat, ok2 := new(big.Rat).SetString(want)
+		return ok1 && ok2 && gotRat.Cmp(wantRat) == 0
+	}
+
+	tests := []struct {
+		name     string
+		property any
+	}{
+		{name: "NUMBER", property: func(n int64) bool {
+			value := strconv.FormatInt(n, 10)
+			got, ok := roundTrip("NUMBER", "fixed", &QueryBindingValue{Type: "FIXED", Value: value})
+			return ok && sameNumber(got, value)
+		}},
+		{name: "NUMBER(10,2)", property: func(cents int32) bool {
+			value := new(big.Rat).SetFrac64(int64(cents), 100).FloatString(2)
+			got, ok := roundTrip("NUMBER(10,2)", "fixed", &QueryBindingValue{Type: "FIXED", Value: value})
+			return ok && sameNumber(got, value)
+		}},
+		{name: "INT", property: func(n int64) bool {
+			got, ok := roundTrip("INT", "fixed", &QueryBindingValue{Type: "FIXED", Value: strconv.FormatInt(n, 10)})
+			return ok && got == n
+		}},
+		{name: "FLOAT", property: func(f float64) bool {
+			got, ok := roundTrip("FLOAT", "real", &QueryBindingValue{Type: "REAL", Value: strconv.FormatFloat(f, 'g', -1, 64)})
+			return ok && got == f
+		}},
+		{name: "VARCHAR", property: func(s string) bool {
+			// Quotes and backslashes must survive binding and translation
+			s = `it's a \"quoted\" \n ` + strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+			got, ok := roundTrip("VARCHAR(100)", "text", &QueryBindingValue{Type: "TEXT", Value: s})
+			return ok && got == s
+		}},
+		{name: "BOOLEAN", property: func(b bool) bool {
+			got, ok := roundTrip("BOOLEAN", "boolean", &QueryBindingValue{Type: "BOOLEAN", Value: strconv.FormatBool(b)})
+			return ok && got == b
+		}},
+		{name: "DATE", property: func(days int16) bool {
+			want := time.Unix(int64(days)*86400, 0).UTC()
+			got, ok := roundTrip("DATE", "date", &QueryBindingValue{Type: "DATE", Value: strconv.FormatInt(want.UnixMilli(), 10)})
+			return ok && want.Equal(got.(time.Time))
+		}},
+		{name: "TIMESTAMP_NTZ", property: func(sec int32, micros uint16) bool {
+			want := time.Unix(int64(sec), int64(micros)*1000).UTC()
+			got, ok := roundTrip("TIMESTAMP_NTZ(9)", "timestamp_ntz", &QueryBindingValue{Type: "TIMESTAMP_NTZ", Value: strconv.FormatInt(want.UnixNano(), 10)})
+			return ok && want.Equal(got.(time.Time))
+		}},
+		{name: "TIMESTAMP_LTZ", property: func(sec int32, micros uint16) bool {
+			want := time.Unix(int64(sec), int64(micros)*1000)
+			got, ok := roundTrip("TIMESTAMP_LTZ", "timestamp_ltz", &QueryBindingValue{Type: "TIMESTAMP_LTZ", Value: strconv.FormatInt(want.UnixNano(), 10)})
+			return ok && want.Equal(got.(time.Time))
+		}},
+		{name: "BINARY", property: func(b []byte) bool {
+			got, ok := roundTrip("BINARY", "binary", &QueryBindingValue{Type: "BINARY", Value: hex.EncodeToString(b)})
+			return ok && bytes.Equal(got.([]byte), b)
+		}},
+		{name: "VARIANT", property: func(m map[string]int32) bool {
+			// The driver decodes JSON numbers as float64, so values are kept within its precision
+			encoded, _ := json.Marshal(m)
+			got, ok := roundTrip("VARIANT", "variant", &QueryBindingValue{Type: "VARIANT", Value: string(encoded)})
+			if !ok {
+				return false
+			}
+			reencoded, _ := json.Marshal(got)
+			var decoded map[string]float64
+			if err := json.Unmarshal(reencoded, &decoded); err != nil || len(decoded) != len(m) {
+				return false
+			}
+			for k, v := range m {
+				if decoded[k] != float64(v) {
+					return false
+				}
+			}
+			return true
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := quick.Check(tt.property, &quick.Config{MaxCount: 50}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,577 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blastrain/vitess-sqlparser/sqlparser"
+	sftypes "github.com/nnnkkk7/snowflake-emulator/pkg/types"
+)
+
+// maxTypeWords is the number of words in the longest multiword type name,
+// TIMESTAMP WITH LOCAL TIME ZONE.
+const maxTypeWords = 5
+
+// columnConstraintKeywords start table constraints rather than column
+// definitions in a CREATE TABLE column list.
+var columnConstraintKeywords = map[string]bool{
+	"CONSTRAINT": true,
+	"PRIMARY":    true,
+	"UNIQUE":     true,
+	"FOREIGN":    true,
+	"CHECK":      true,
+}
+
+// typeReplacement replaces sql[start:end] with a DuckDB type declaration.
+type typeReplacement struct {
+	start, end int
+	duckType   string
+}
+
+// translateCastTypes rewrites the target types of CAST, TRY_CAST, and :: casts to
+// the DuckDB types that store them, e.g. x::NUMBER(10,2) becomes x::DECIMAL(10,2)
+// and CAST(x AS VARIANT) becomes CAST(x AS JSON). Unknown types are left unchanged.
+func translateCastTypes(sql string) string {
+	var replacements []typeReplacement
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			i++
+			if r, ok := typeDeclAt(sql, i+1); ok {
+				replacements = append(replacements, r)
+			}
+		case keywordAt(sql, i, "CAST") || keywordAt(sql, i, "TRY_CAST"):
+			if r, ok := castTypeAt(sql, i); ok {
+				replacements = append(replacements, r)
+			}
+		}
+	}
+	return applyTypeReplacements(sql, replacements)
+}
+
+// castTypeAt finds the target type of the CAST or TRY_CAST call starting at sql[start].
+func castTypeAt(sql string, start int) (typeReplacement, bool) {
+	open := start
+	for open < len(sql) && sql[open] != '(' {
+		if c := sql[open]; !isIdentChar(c) && !isSpace(c) {
+			return typeReplacement{}, false
+		}
+		open++
+	}
+	if open == len(sql) {
+		return typeReplacement{}, false
+	}
+	closing := matchingParen(sql[open:])
+	if closing < 0 {
+		return typeReplacement{}, false
+	}
+	closing += open
+
+	depth := 0
+	for i := open + 1; i < closing; i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && keywordAt(sql, i, "AS"):
+			declStart := i + len("AS")
+			for declStart < closing && isSpace(sql[declStart]) {
+				declStart++
+			}
+			declEnd := closing
+			for declEnd > declStart && isSpace(sql[declEnd-1]) {
+				declEnd--
+			}
+			duckType, ok := sftypes.DuckDBTypeFor(sql[declStart:declEnd])
+			if !ok {
+				return typeReplacement{}, false
+			}
+			return typeReplacement{start: declStart, end: declEnd, duckType: duckType}, true
+		}
+	}
+	return typeReplacement{}, false
+}
+
+// translateColumnTypes rewrites the column types of a CREATE TABLE column list, and
+// of ALTER TABLE ... ADD COLUMN and ALTER COLUMN ... SET DATA TYPE, to the DuckDB
+// types that store them. Cast types are rewritten as well, so that a
+// CREATE TABLE ... AS SELECT keeps Snowflake's types.
+func translateColumnTypes(sql string) string {
+	var replacements []typeReplacement
+	upper := strings.ToUpper(sql)
+	switch {
+	case strings.HasPrefix(upper, "CREATE "):
+		if stmt, ok := parseCreateStatement(sql); ok && stmt.Kind == "TABLE" {
+			replacements = createTableTypes(sql)
+		}
+	case strings.HasPrefix(upper, "ALTER TABLE "):
+		replacements = alterTableTypes(sql)
+	}
+	return removeNiladicParens(translateCastTypes(applyTypeReplacements(sql, replacements)))
+}
+
+// niladicFunctions are the functions DuckDB only accepts without parentheses.
+var niladicFunctions = []string{"CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME"}
+
+// removeNiladicParens rewrites CURRENT_TIMESTAMP() and the like as DuckDB
+// accepts them, without parentheses, such as in the column defaults of DDL,
+// which is not parsed into an AST. Literals and quoted identifiers are left
+// alone.
+func removeNiladicParens(sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\'' || c == '"' {
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i : end+1])
+			i = end
+			continue
+		}
+		rewritten := false
+		for _, name := range niladicFunctions {
+			if !keywordAt(sql, i, name) {
+				continue
+			}
+			j := i + len(name)
+			for j < len(sql) && isSpace(sql[j]) {
+				j++
+			}
+			if j < len(sql) && sql[j] == '(' {
+				if closing := matchingParen(sql[j:]); closing >= 0 && strings.TrimSpace(sql[j+1:j+closing]) == "" {
+					b.WriteString(sql[i : i+len(name)])
+					i = j + closing
+					rewritten = true
+				}
+			}
+			break
+		}
+		if !rewritten {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// createTableTypes finds the column types in the column list of a CREATE TABLE.
+func createTableTypes(sql string) []typeReplacement {
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case keywordAt(sql, i, "AS"):
+			// CREATE TABLE ... AS SELECT without a column list
+			return nil
+		case c == '(':
+			closing := matchingParen(sql[i:])
+			if closing < 0 {
+				return nil
+			}
+			return columnListTypes(sql, i+1, i+closing)
+		}
+	}
+	return nil
+}
+
+// columnListTypes finds the column types in the comma-separated column
+// definitions in sql[start:end]. Table constraints are skipped.
+func columnListTypes(sql string, start, end int) []typeReplacement {
+	var replacements []typeReplacement
+	segmentStart := start
+	depth := 0
+	for i := start; i <= end; i++ {
+		if i < end {
+			switch c := sql[i]; c {
+			case '\'', '"':
+				i = skipQuoted(sql, i, c)
+				continue
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		if r, ok := columnDefType(sql, segmentStart, i); ok {
+			replacements = append(replacements, r)
+		}
+		segmentStart = i + 1
+	}
+	return replacements
+}
+
+// columnDefType finds the type of the column definition "name type ..." in sql[start:end].
+func columnDefType(sql string, start, end int) (typeReplacement, bool) {
+	i := start
+	for i < end && isSpace(sql[i]) {
+		i++
+	}
+	nameStart := i
+	if i < end && sql[i] == '"' {
+		i = skipQuoted(sql, i, '"') + 1
+	} else {
+		for i < end && isIdentChar(sql[i]) {
+			i++
+		}
+		if columnConstraintKeywords[strings.ToUpper(sql[nameStart:i])] {
+			return typeReplacement{}, false
+		}
+	}
+	if i == nameStart || i >= end {
+		return typeReplacement{}, false
+	}
+	r, ok := typeDeclAt(sql[:end], i)
+	return r, ok
+}
+
+// alterTableTypes finds the column types of ADD [COLUMN] and [SET DATA] TYPE clauses
+// in an ALTER TABLE statement.
+func alterTableTypes(sql string) []typeReplacement {
+	var replacements []typeReplacement
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case keywordAt(sql, i, "TYPE"):
+			if r, ok := typeDeclAt(sql, i+len("TYPE")); ok {
+				replacements = append(replacements, r)
+			}
+		case keywordAt(sql, i, "ADD"):
+			start := i + len("ADD")
+			for _, keyword := range []string{"COLUMN", "IF NOT EXISTS"} {
+				if next := skipKeywords(sql, start, keyword); next > start {
+					start = next
+				}
+			}
+			replacements = append(replacements, columnListTypes(sql, start, len(sql))...)
+			return replacements
+		}
+	}
+	return replacements
+}
+
+// skipKeywords returns the index just past the space-separated keywords that
+// follow sql[start], or start if they do not follow it.
+func skipKeywords(sql string, start int, keywords string) int {
+	i := start
+	for _, keyword := range strings.Fields(keywords) {
+		for i < len(sql) && isSpace(sql[i]) {
+			i++
+		}
+		if !keywordAt(sql, i, keyword) {
+			return start
+		}
+		i += len(keyword)
+	}
+	return i
+}
+
+// typeDeclAt reads the type declaration that follows sql[start], taking the longest
+// run of words that names a known type, e.g. TIMESTAMP WITH TIME ZONE, and its
+// parenthesized parameters.
+func typeDeclAt(sql string, start int) (typeReplacement, bool) {
+	i := start
+	for i < len(sql) && isSpace(sql[i]) {
+		i++
+	}
+	declStart := i
+
+	nameEnd := -1
+	for words := 0; words < maxTypeWords && i < len(sql); words++ {
+		wordStart := i
+		for i < len(sql) && isIdentChar(sql[i]) && sql[i] != '.' {
+			i++
+		}
+		if i == wordStart {
+			break
+		}
+		if _, ok := sftypes.LookupType(sql[declStart:i]); ok {
+			nameEnd = i
+		}
+		for i < len(sql) && isSpace(sql[i]) {
+			i++
+		}
+	}
+	if nameEnd < 0 {
+		return typeReplacement{}, false
+	}
+
+	declEnd := nameEnd
+	i = nameEnd
+	for i < len(sql) && isSpace(sql[i]) {
+		i++
+	}
+	if i < len(sql) && sql[i] == '(' {
+		if closing := matchingParen(sql[i:]); closing >= 0 {
+			declEnd = i + closing + 1
+		}
+	}
+
+	duckType, ok := sftypes.DuckDBTypeFor(sql[declStart:declEnd])
+	if !ok {
+		return typeReplacement{}, false
+	}
+	return typeReplacement{start: declStart, end: declEnd, duckType: duckType}, true
+}
+
+// applyTypeReplacements applies non-overlapping replacements to sql.
+func applyTypeReplacements(sql string, replacements []typeReplacement) string {
+	if len(replacements) == 0 {
+		return sql
+	}
+	sort.Slice(replacements, func(i, j int) bool { return replacements[i].start < replacements[j].start })
+
+	var b strings.Builder
+	copied := 0
+	for _, r := range replacements {
+		if r.start < copied {
+			continue
+		}
+		b.WriteString(sql[copied:r.start])
+		b.WriteString(r.duckType)
+		copied = r.end
+	}
+	b.WriteString(sql[copied:])
+	return b.String()
+}
+
+// variantFunctions are the functions returning VARIANT values, whose casts to
+// other types read strings without their JSON quotes.
+var variantFunctions = map[string]bool{
+	"GET":            true,
+	"GET_PATH":       true,
+	"PARSE_JSON":     true,
+	"TRY_PARSE_JSON": true,
+	"TO_VARIANT":     true,
+}
+
+// typedLiteralKeywords prefix string literals of a type, as in DATE '2024-01-01'.
+var typedLiteralKeywords = map[string]bool{
+	"DATE":      true,
+	"TIME":      true,
+	"TIMESTAMP": true,
+	"INTERVAL":  true,
+}
+
+// rewriteCastOperators rewrites x::type casts, which the parser rejects, to
+// the __CAST__(x, 'type') markers markCast leaves for transformCast, so that
+// the functions of statements with :: casts are translated too.
+//
+//	ARRAY_CONTAINS('b'::VARIANT, a)  → ARRAY_CONTAINS(to_json('b'), a)
+//	GET_PATH(v, 'a.b')::STRING       → __CAST__(json_extract_string(GET_PATH(v, 'a.b'), '$'), 'VARCHAR')
+//	x::NUMBER(10,2)::STRING          → __CAST__(__CAST__(x, 'DECIMAL(10,2)'), 'VARCHAR')
+//
+// Casts to VARIANT keep strings as strings, as Snowflake does, where casting
+// them to JSON would parse them. Casts of the values of VARIANT functions to
+// other types read strings without their JSON quotes, like those of paths.
+// The operand is the column, literal, function call, or parenthesized or
+// bracketed expression before the ::. Casts of other operands, such as
+// CASE ... END or :N placeholders, and to unknown types are left to DuckDB.
+func rewriteCastOperators(sql string) string {
+	for from := 0; ; {
+		colon := nextCastOperator(sql, from)
+		if colon < 0 {
+			return sql
+		}
+		rewritten, end, ok := rewriteCastAt(sql, colon)
+		if !ok {
+			from = colon + 2
+			continue
+		}
+		sql, from = rewritten, end
+	}
+}
+
+// nextCastOperator returns the index of the first :: at or after sql[from]
+// outside literals, quoted identifiers, dollar-quoted bodies, and comments,
+// or -1 if there is none.
+func nextCastOperator(sql string, from int) int {
+	for i := from; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case strings.HasPrefix(sql[i:], "$$"):
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				return -1
+			}
+			i += end + 3
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return -1
+			}
+			i += end + 3
+		case strings.HasPrefix(sql[i:], "::"):
+			return i
+		}
+	}
+	return -1
+}
+
+// rewriteCastAt rewrites the cast whose :: is at sql[colon]. It returns the
+// rewritten statement and the end of the rewritten cast in it.
+func rewriteCastAt(sql string, colon int) (string, int, bool) {
+	start, end := castOperandSpan(sql, colon)
+	if start < 0 {
+		return "", 0, false
+	}
+	r, ok := typeDeclAt(sql, colon+2)
+	if !ok {
+		return "", 0, false
+	}
+	operand := sql[start:end]
+	var cast string
+	switch {
+	case strings.EqualFold(sql[r.start:r.end], "VARIANT"):
+		cast = "to_json(" + operand + ")"
+	case r.duckType != "JSON" && variantFunctions[calledFunction(operand)]:
+		cast = "__CAST__(json_extract_string(" + operand + ", '$'), '" + r.duckType + "')"
+	default:
+		cast = "__CAST__(" + operand + ", '" + r.duckType + "')"
+	}
+	return sql[:start] + cast + sql[r.end:], start + len(cast), true
+}
+
+// castOperandSpan returns the span of the operand of the cast whose :: is at
+// sql[colon], or -1 if it has none the cast can be rewritten with.
+func castOperandSpan(sql string, colon int) (int, int) {
+	// Find where the parenthesized, bracketed, and quoted spans before the
+	// cast start
+	openAt := make(map[int]int)
+	var opens []int
+	for i := 0; i < colon; i++ {
+		switch c := sql[i]; {
+		case c == '\'' || c == '"':
+			end := skipQuoted(sql, i, c)
+			openAt[end] = i
+			i = end
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			}
+		case c == '(' || c == '[':
+			opens = append(opens, i)
+		case c == ')' || c == ']':
+			if len(opens) > 0 {
+				openAt[i] = opens[len(opens)-1]
+				opens = opens[:len(opens)-1]
+			}
+		}
+	}
+
+	end := colon
+	for end > 0 && isSpace(sql[end-1]) {
+		end--
+	}
+	start := end
+	for start > 0 {
+		c := sql[start-1]
+		if c == ')' || c == ']' || c == '"' || c == '\'' {
+			open, ok := openAt[start-1]
+			if !ok {
+				return -1, -1
+			}
+			start = open
+			continue
+		}
+		if !isIdentChar(c) {
+			break
+		}
+		start--
+	}
+	if start == end || strings.EqualFold(sql[start:end], "END") {
+		return -1, -1
+	}
+	if start > 0 && (sql[start-1] == ':' || sql[start-1] == '?') {
+		return -1, -1
+	}
+	// A typed literal includes its type
+	if sql[start] == '\'' {
+		word := start
+		for word > 0 && isSpace(sql[word-1]) {
+			word--
+		}
+		wordEnd := word
+		for word > 0 && isIdentChar(sql[word-1]) {
+			word--
+		}
+		if typedLiteralKeywords[strings.ToUpper(sql[word:wordEnd])] {
+			start = word
+		}
+	}
+	return start, end
+}
+
+// calledFunction returns the upper-case name of the function expr calls, or
+// "" if expr is not a function call.
+func calledFunction(expr string) string {
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 || expr[len(expr)-1] != ')' || matchingParen(expr[open:]) != len(expr)-open-1 {
+		return ""
+	}
+	name := strings.TrimSpace(expr[:open])
+	for i := 0; i < len(name); i++ {
+		if !isIdentChar(name[i]) {
+			return ""
+		}
+	}
+	return strings.ToUpper(name)
+}
+
+// markCast replaces a CAST, which the parser reads as MySQL's CONVERT and would
+// print as convert(x, type), with a __CAST__(x, 'type') marker for post-processing.
+func markCast(expr sqlparser.Expr) sqlparser.Expr {
+	convert, ok := expr.(*sqlparser.ConvertExpr)
+	if !ok || convert.Type == nil {
+		return expr
+	}
+	return &sqlparser.FuncExpr{
+		Name: sqlparser.NewColIdent("__CAST__"),
+		Exprs: sqlparser.SelectExprs{
+			&sqlparser.AliasedExpr{Expr: convert.Expr},
+			&sqlparser.AliasedExpr{Expr: sqlparser.NewStrVal([]byte(sqlparser.String(convert.Type)))},
+		},
+	}
+}
+
+// markTryCast marks TRY_CAST(x AS type), which the parser reads as a function
+// whose argument is aliased as type, as __TRY_CAST__(x, 'type').
+func markTryCast(fn *sqlparser.FuncExpr) {
+	if !strings.EqualFold(fn.Name.String(), "TRY_CAST") || len(fn.Exprs) != 1 {
+		return
+	}
+	arg, ok := fn.Exprs[0].(*sqlparser.AliasedExpr)
+	if !ok || arg.As.IsEmpty() {
+		return
+	}
+	fn.Name = sqlparser.NewColIdent("__TRY_CAST__")
+	fn.Exprs = sqlparser.SelectExprs{
+		&sqlparser.AliasedExpr{Expr: arg.Expr},
+		&sqlparser.AliasedExpr{Expr: sqlparser.NewStrVal([]byte(arg.As.String()))},
+	}
+}
+
+// transformCast turns marker(x, 'type') back into fn(x AS type).
+func (t *Translator) transformCast(sql, marker, fn string) string {
+	return t.transformMarkedFunction(sql, marker, func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return marker + "(" + args + ")"
+		}
+		duckType := strings.Trim(strings.TrimSpace(parts[1]), "'")
+		return fmt.Sprintf("%s(%s AS %s)", fn, strings.TrimSpace(parts[0]), duckType)
+	})
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// issueTracker is where support for functions without a tracking issue can be requested.
+const issueTracker = "https://github.com/nnnkkk7/snowflake-emulator/issues"
+
+// UnsupportedFunction is a Snowflake function the emulator knows of but does
+// not implement.
+type UnsupportedFunction struct {
+	Name     string
+	Category string
+	// Reason says why the function is not emulated.
+	Reason string
+	// Issue is the URL of the issue tracking support for the function, if any.
+	Issue string
+}
+
+// UnsupportedFunctionError reports a statement that failed because it calls a
+// function of the unsupported function registry.
+type UnsupportedFunctionError struct {
+	Function UnsupportedFunction
+	// Err is the error DuckDB reported.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *UnsupportedFunctionError) Error() string {
+	tracking := "request support at " + issueTracker
+	if e.Function.Issue != "" {
+		tracking = "tracked in " + e.Function.Issue
+	}
+	return fmt.Sprintf("function %s is not supported by the emulator: %s (%s)", e.Function.Name, e.Function.Reason, tracking)
+}
+
+// Unwrap returns the error DuckDB reported.
+func (e *UnsupportedFunctionError) Unwrap() error {
+	return e.Err
+}
+
+// unsupportedFunctions is the registry of known but unsupported functions,
+// keyed by their upper-case, possibly qualified, names.
+var unsupportedFunctions = newUnsupportedFunctions()
+
+// newUnsupportedFunctions builds the unsupported function registry.
+func newUnsupportedFunctions() map[string]UnsupportedFunction {
+	functions := make(map[string]UnsupportedFunction)
+	register := func(category, reason string, names ...string) {
+		for _, name := range names {
+			functions[name] = UnsupportedFunction{Name: name, Category: category, Reason: reason}
+		}
+	}
+
+	register("Search", "search optimization and full-text search indexes are not emulated",
+		"SEARCH", "SEARCH_IP", "SYSTEM$ESTIMATE_SEARCH_OPTIMIZATION_COSTS")
+	register("AI", "AI SQL functions need Snowflake-hosted models",
+		"AI_AGG", "AI_CLASSIFY", "AI_COMPLETE", "AI_COUNT_TOKENS", "AI_EMBED", "AI_EXTRACT", "AI_FILTER",
+		"AI_PARSE_DOCUMENT", "AI_REDACT", "AI_SENTIMENT", "AI_SIMILARITY", "AI_SUMMARIZE_AGG", "AI_TRANSCRIBE", "AI_TRANSLATE")
+	register("Cortex", "Cortex functions need Snowflake-hosted models",
+		"SNOWFLAKE.CORTEX.CLASSIFY_TEXT", "SNOWFLAKE.CORTEX.COUNT_TOKENS",
+		"SNOWFLAKE.CORTEX.EMBED_TEXT_768", "SNOWFLAKE.CORTEX.EMBED_TEXT_1024", "SNOWFLAKE.CORTEX.EXTRACT_ANSWER",
+		"SNOWFLAKE.CORTEX.PARSE_DOCUMENT", "SNOWFLAKE.CORTEX.SEARCH_PREVIEW",
+		"SNOWFLAKE.CORTEX.SPLIT_TEXT_RECURSIVE_CHARACTER", "SNOWFLAKE.CORTEX.TRANSLATE")
+	register("Semi-structured", "XML values are not emulated; only JSON is",
+		"PARSE_XML", "TRY_PARSE_XML", "CHECK_XML", "XMLGET")
+	register("Encryption", "encryption with raw keys is not emulated; returning values unencrypted would leak plaintext",
+		"ENCRYPT_RAW", "DECRYPT_RAW", "TRY_DECRYPT_RAW")
+	register("System", "the emulator has no micro-partitions or clustering",
+		"SYSTEM$CLUSTERING_DEPTH", "SYSTEM$CLUSTERING_INFORMATION")
+	return functions
+}
+
+// UnsupportedFunctions lists the registry of known but unsupported functions,
+// by name.
+func UnsupportedFunctions() []UnsupportedFunction {
+	functions := make([]UnsupportedFunction, 0, len(unsupportedFunctions))
+	for _, function := range unsupportedFunctions {
+		functions = append(functions, function)
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions
+}
+
+// withUnsupportedFunction returns an UnsupportedFunctionError wrapping err when
+// sql calls a function of the registry, so that the failure names the function
+// rather than DuckDB's "function does not exist". Otherwise it returns err.
+func withUnsupportedFunction(sql string, err error) error {
+	if function, ok := unsupportedFunctionCall(sql); ok {
+		return &UnsupportedFunctionError{Function: function, Err: err}
+	}
+	return err
+}
+
+// unsupportedFunctionCall returns the first function of the registry that sql calls.
+func unsupportedFunctionCall(sql string) (UnsupportedFunction, bool) {
+	var found UnsupportedFunction
+	var ok bool
+	scanFunctionCalls(sql, func(name string, _, _ int) bool {
+		found, ok = unsupportedFunctions[name]
+		return !ok
+	})
+	return found, ok
+}
+
+// scanFunctionCalls calls visit with the upper-case, possibly qualified, name of
+// each function call in sql and the bounds of the name, until visit returns
+// false. Names inside literals, quoted identifiers, and comments are skipped.
+func scanFunctionCalls(sql string, visit func(name string, start, end int) bool) {
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			i = skipQuoted(sql, i, c)
+		case isVariableStart(c) && (i == 0 || (!isIdentChar(sql[i-1]) && sql[i-1] != ':')):
+			end := i
+			for end < len(sql) && isIdentChar(sql[end]) {
+				end++
+			}
+			next := end
+			for next < len(sql) && isSpace(sql[next]) {
+				next++
+			}
+			if next < len(sql) && sql[next] == '(' && !visit(strings.ToUpper(sql[i:end]), i, end) {
+				return
+			}
+			i = end - 1
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				i = end - 1
+			}
+		}
+	}
+}
+
+// Capabilities describes the Snowflake functions the emulator translates to
+// DuckDB and those it knows of but does not support. Functions in neither list
+// are passed to DuckDB under their own names.
+type Capabilities struct {
+	TranslatedFunctions  []string
+	UnsupportedFunctions []UnsupportedFunction
+}
+
+// Capabilities returns the functions the executor's translator handles and the
+// unsupported function registry.
+func (e *Executor) Capabilities() Capabilities {
+	translated := e.translator.Functions()
+	for name := range cortexFunctions {
+		translated = append(translated, name)
+	}
+	sort.Strings(translated)
+	return Capabilities{
+		TranslatedFunctions:  translated,
+		UnsupportedFunctions: UnsupportedFunctions(),
+	}
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnsupportedFunctionCall(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "Search", sql: "SELECT * FROM docs WHERE SEARCH(body, 'snow')", want: "SEARCH"},
+		{name: "LowerCase", sql: "select ai_complete('model', prompt) from t", want: "AI_COMPLETE"},
+		{name: "QualifiedCortex", sql: "SELECT SNOWFLAKE.CORTEX.TRANSLATE (review, 'de', 'en') FROM reviews", want: "SNOWFLAKE.CORTEX.TRANSLATE"},
+		{name: "XML", sql: "SELECT TRY_PARSE_XML(doc) FROM t", want: "TRY_PARSE_XML"},
+		{name: "RawEncryption", sql: "SELECT DECRYPT_RAW(v, k, iv) FROM t", want: "DECRYPT_RAW"},
+		{name: "Encryption", sql: "SELECT DECRYPT(ENCRYPT(a, 'passphrase'), 'passphrase') FROM t"},
+		{name: "SystemFunction", sql: "SELECT SYSTEM$CLUSTERING_INFORMATION('t')", want: "SYSTEM$CLUSTERING_INFORMATION"},
+		{name: "ColumnNamedLikeFunction", sql: "SELECT search FROM t"},
+		{name: "InLiteral", sql: "SELECT 'SEARCH(x)' FROM t"},
+		{name: "InComment", sql: "SELECT 1 -- SEARCH(x)\n"},
+		{name: "QuotedIdentifier", sql: `SELECT "SEARCH"(x) FROM t`},
+		{name: "SuffixOfOtherName", sql: "SELECT my_search(x) FROM t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function, ok := unsupportedFunctionCall(tt.sql)
+			if ok != (tt.want != "") || function.Name != tt.want {
+				t.Errorf("unsupportedFunctionCall(%q) = %q, %v, want %q", tt.sql, function.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+// TestExecutor_UnsupportedFunction tests that calls of known but unsupported
+// functions fail with an error naming the function.
+func TestExecutor_UnsupportedFunction(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+	if _, err := executor.Execute(ctx, "CREATE TABLE docs (body VARCHAR)"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	_, err := executor.Query(ctx, "SELECT body FROM docs WHERE SEARCH(body, 'snow')")
+	var unsupported *UnsupportedFunctionError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Query() error = %v, want an UnsupportedFunctionError", err)
+	}
+	if !strings.HasPrefix(err.Error(), "function SEARCH is not supported by the emulator: ") || !strings.Contains(err.Error(), issueTracker) {
+		t.Errorf("Query() error = %q", err.Error())
+	}
+
+	_, err = executor.Execute(ctx, "INSERT INTO docs SELECT AI_COMPLETE('model', 'hello')")
+	if !errors.As(err, &unsupported) || unsupported.Function.Name != "AI_COMPLETE" {
+		t.Errorf("Execute() error = %v, want an UnsupportedFunctionError for AI_COMPLETE", err)
+	}
+
+	// Other failures keep DuckDB's error
+	_, err = executor.Query(ctx, "SELECT missing_function(body) FROM docs")
+	if err == nil || errors.As(err, &unsupported) {
+		t.Errorf("Query() error = %v, want DuckDB's error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SessionUpdater records the current database, schema, warehouse, and role
+// that USE statements select for a session, and the parameters ALTER SESSION
+// sets. session.Manager implements it.
+type SessionUpdater interface {
+	UpdateSessionUse(ctx context.Context, id int64, database, schema, warehouse, role string) error
+	AlterSessionParameters(ctx context.Context, id int64, set map[string]interface{}, unset []string) error
+}
+
+// WithSessionUpdater makes USE and ALTER SESSION statements change their
+// session through updater. Without one, they are checked but change nothing.
+func WithSessionUpdater(updater SessionUpdater) ExecutorOption {
+	return func(e *Executor) {
+		e.sessions = updater
+	}
+}
+
+// useStatement is a parsed USE statement.
+type useStatement struct {
+	// Object is DATABASE, SCHEMA, WAREHOUSE, ROLE, or SECONDARY ROLES.
+	Object string
+	// Name is the object's name, as written.
+	Name string
+}
+
+// parseUseStatement parses USE [DATABASE | SCHEMA | WAREHOUSE | ROLE] name and
+// USE SECONDARY ROLES. USE name selects a database. It reports false for other
+// statements.
+func parseUseStatement(sql string) (*useStatement, bool, error) {
+	fields := strings.Fields(strings.TrimRight(stripLeadingComments(sql), "; \t\r\n"))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "USE") {
+		return nil, false, nil
+	}
+	fields = fields[1:]
+	if len(fields) == 0 {
+		return nil, true, fmt.Errorf("USE requires an object name")
+	}
+
+	stmt := &useStatement{Object: "DATABASE"}
+	switch keyword := strings.ToUpper(fields[0]); keyword {
+	case "DATABASE", "SCHEMA", "WAREHOUSE", "ROLE":
+		stmt.Object, fields = keyword, fields[1:]
+	case "SECONDARY":
+		if len(fields) < 2 || !strings.EqualFold(fields[1], "ROLES") {
+			return nil, true, fmt.Errorf("invalid USE SECONDARY ROLES statement: %s", sql)
+		}
+		return &useStatement{Object: "SECONDARY ROLES", Name: strings.Join(fields[2:], " ")}, true, nil
+	}
+	if len(fields) != 1 {
+		return nil, true, fmt.Errorf("invalid USE %s statement: %s", stmt.Object, sql)
+	}
+	stmt.Name = fields[0]
+	return stmt, true, nil
+}
+
+// executeUse checks that the object a USE statement selects exists and makes
+// it current in the statement's session. USE DATABASE also selects the
+// database's PUBLIC schema, and USE SCHEMA db.schema the database. Secondary
+// roles are accepted and ignored, as the emulator does not check privileges.
+func (e *Executor) executeUse(ctx context.Context, stmt *useStatement) (*ExecResult, error) {
+	info := SessionInfoFromContext(ctx)
+	var database, schema, warehouseName, role string
+	switch stmt.Object {
+	case "DATABASE":
+		db, err := e.repo.GetDatabaseByName(ctx, objectName(stmt.Name))
+		if err != nil {
+			return nil, fmt.Errorf("database '%s' does not exist or not authorized", objectName(stmt.Name))
+		}
+		database, schema = db.Name, publicSchema
+	case "SCHEMA":
+		parts, _ := objectNameParts(stmt.Name, 0)
+		database = info.Database
+		if len(parts) == 2 {
+			database = objectName(parts[0])
+		}
+		if database == "" {
+			return nil, fmt.Errorf("cannot perform USE SCHEMA: this session does not have a current database")
+		}
+		db, err := e.repo.GetDatabaseByName(ctx, database)
+		if err != nil {
+			return nil, fmt.Errorf("database '%s' does not exist or not authorized", database)
+		}
+		name := objectName(parts[len(parts)-1])
+		schemas, err := e.databaseSchemas(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range schemas {
+			if strings.EqualFold(s.Name, name) {
+				database, schema = db.Name, s.Name
+			}
+		}
+		if schema == "" {
+			return nil, fmt.Errorf("schema '%s.%s' does not exist or not authorized", db.Name, name)
+		}
+	case "WAREHOUSE":
+		warehouseName = objectName(stmt.Name)
+		if e.warehouses != nil {
+			if _, err := e.warehouses.GetWarehouse(ctx, warehouseName); err != nil {
+				return nil, fmt.Errorf("warehouse '%s' does not exist or not authorized", warehouseName)
+			}
+		}
+	case "ROLE":
+		role = objectName(stmt.Name)
+	case "SECONDARY ROLES":
+		return &ExecResult{}, nil
+	}
+
+	if e.sessions == nil {
+		return &ExecResult{}, nil
+	}
+	id, err := strconv.ParseInt(info.ID, 10, 64)
+	if err != nil {
+		return &ExecResult{}, nil
+	}
+	if err := e.sessions.UpdateSessionUse(ctx, id, database, schema, warehouseName, role); err != nil {
+		return nil, fmt.Errorf("failed to use %s %s: %w", strings.ToLower(stmt.Object), stmt.Name, err)
+	}
+	return &ExecResult{}, nil
+}
@@ -0,0 +1,169 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
+)
+
+// recordingSessions records the session changes of USE and ALTER SESSION
+// statements.
+type recordingSessions struct {
+	updates [][]interface{}
+	alters  [][]interface{}
+}
+
+func (r *recordingSessions) UpdateSessionUse(_ context.Context, id int64, database, schema, warehouse, role string) error {
+	r.updates = append(r.updates, []interface{}{id, database, schema, warehouse, role})
+	return nil
+}
+
+func (r *recordingSessions) AlterSessionParameters(_ context.Context, id int64, set map[string]interface{}, unset []string) error {
+	r.alters = append(r.alters, []interface{}{id, set, unset})
+	return nil
+}
+
+// TestExecutor_Use tests that USE statements check the objects they select
+// and change the context of their session.
+func TestExecutor_Use(t *testing.T) {
+	warehouses := warehouse.NewManager()
+	if _, err := warehouses.CreateWarehouse(context.Background(), "COMPUTE_WH", "", ""); err != nil {
+		t.Fatalf("CreateWarehouse() error = %v", err)
+	}
+	sessions := &recordingSessions{}
+	executor, _ := setupTestExecutor(t, WithWarehouseManager(warehouses), WithSessionUpdater(sessions))
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "7", Database: "SALES", Schema: "PUBLIC"})
+	for _, sql := range []string{"CREATE DATABASE SALES", "CREATE SCHEMA SALES.STAGING", "CREATE DATABASE MARKETING"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		sql     string
+		want    []interface{}
+		wantErr bool
+	}{
+		{name: "Database", sql: "USE DATABASE marketing", want: []interface{}{int64(7), "MARKETING", "PUBLIC", "", ""}},
+		{name: "DatabaseWithoutKeyword", sql: "use sales;", want: []interface{}{int64(7), "SALES", "PUBLIC", "", ""}},
+		{name: "Schema", sql: "USE SCHEMA staging", want: []interface{}{int64(7), "SALES", "STAGING", "", ""}},
+		{name: "QualifiedSchema", sql: "USE SCHEMA MARKETING.PUBLIC", want: []interface{}{int64(7), "MARKETING", "PUBLIC", "", ""}},
+		{name: "Warehouse", sql: "USE WAREHOUSE compute_wh", want: []interface{}{int64(7), "", "", "COMPUTE_WH", ""}},
+		{name: "Role", sql: "USE ROLE sysadmin", want: []interface{}{int64(7), "", "", "", "SYSADMIN"}},
+		{name: "SecondaryRoles", sql: "USE SECONDARY ROLES ALL"},
+		{name: "MissingDatabase", sql: "USE DATABASE missing", wantErr: true},
+		{name: "MissingSchema", sql: "USE SCHEMA missing", wantErr: true},
+		{name: "MissingWarehouse", sql: "USE WAREHOUSE missing", wantErr: true},
+		{name: "NoName", sql: "USE SCHEMA", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions.updates = nil
+			_, err := executor.Execute(ctx, tt.sql)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute(%q) error = %v, wantErr %v", tt.sql, err, tt.wantErr)
+			}
+			var want [][]interface{}
+			if tt.want != nil {
+				want = [][]interface{}{tt.want}
+			}
+			if diff := cmp.Diff(want, sessions.updates); diff != "" {
+				t.Errorf("session updates mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_SessionNames tests that unqualified names resolve against the
+// session's current schema, for tables created with SQL and with the REST API.
+func TestExecutor_SessionNames(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+	for _, sql := range []string{"CREATE DATABASE SALES", "CREATE SCHEMA SALES.STAGING"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	db, err := repo.GetDatabaseByName(ctx, "SALES")
+	if err != nil {
+		t.Fatalf("GetDatabaseByName() error = %v", err)
+	}
+	public, err := repo.CreateSchema(ctx, db.ID, "PUBLIC", "")
+	if err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+	if _, err := repo.CreateTable(ctx, public.ID, "CUSTOMERS", []metadata.ColumnDef{{Name: "NAME", Type: "VARCHAR"}}, ""); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+
+	staging := ContextWithSessionInfo(ctx, SessionInfo{ID: "1", Database: "SALES", Schema: "STAGING"})
+	for _, sql := range []string{
+		"CREATE TABLE ORDERS (id INTEGER) COMMENT = 'Staged orders'",
+		"INSERT INTO orders VALUES (1), (2)",
+		"CREATE VIEW ORDER_IDS AS SELECT id FROM orders",
+		"UPDATE orders SET id = 3 WHERE id = 2",
+	} {
+		if _, err := executor.Execute(staging, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	publicCtx := ContextWithSessionInfo(ctx, SessionInfo{ID: "1", Database: "SALES", Schema: "PUBLIC"})
+	if _, err := executor.Execute(publicCtx, "INSERT INTO customers VALUES ('Ada')"); err != nil {
+		t.Fatalf("Execute() of an insert into a REST API table error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		sql  string
+		want [][]interface{}
+	}{
+		{
+			name: "Table",
+			ctx:  staging,
+			sql:  "SELECT id FROM orders ORDER BY id",
+			want: [][]interface{}{{int64(1)}, {int64(3)}},
+		},
+		{
+			name: "QualifiedTable",
+			ctx:  ctx,
+			sql:  "SELECT COUNT(*) FROM SALES.STAGING.ORDERS",
+			want: [][]interface{}{{int64(2)}},
+		},
+		{
+			name: "ViewWithCTE",
+			ctx:  staging,
+			sql:  "WITH o AS (SELECT * FROM order_ids) SELECT COUNT(*) FROM o JOIN orders ON o.id = orders.id",
+			want: [][]interface{}{{int64(2)}},
+		},
+		{
+			name: "RESTTable",
+			ctx:  publicCtx,
+			sql:  "SELECT name FROM customers",
+			want: [][]interface{}{{"Ada"}},
+		},
+		{
+			name: "Comment",
+			ctx:  staging,
+			sql:  "SELECT TABLE_NAME, COMMENT FROM SALES.INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = 'STAGING' ORDER BY TABLE_NAME",
+			want: [][]interface{}{{"ORDERS", "Staged orders"}, {"ORDER_IDS", nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(tt.ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, result.Rows); diff != "" {
+				t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
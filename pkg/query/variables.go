@@ -0,0 +1,324 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// sessionVariable is the value of a SQL variable set with SET.
+type sessionVariable struct {
+	Value interface{}
+	Type  types.ColumnMetadata
+	// CreatedOn and UpdatedOn are when the variable was first and last set.
+	CreatedOn time.Time
+	UpdatedOn time.Time
+}
+
+// variableRegistry holds the SQL variables of each session, keyed by session ID
+// and then variable name.
+type variableRegistry struct {
+	mu        sync.Mutex
+	bySession map[string]map[string]sessionVariable
+}
+
+// variableStatement is a parsed SET or UNSET statement.
+type variableStatement struct {
+	Unset bool
+	Names []string
+	// Exprs are the expressions assigned to Names by SET, in order.
+	Exprs []string
+}
+
+// parseVariableStatement parses SET name = expr, SET (name, ...) = (expr, ...),
+// UNSET name, and UNSET (name, ...). It reports false for other statements.
+func parseVariableStatement(sql string) (*variableStatement, bool, error) {
+	statement := strings.TrimRight(stripLeadingComments(sql), "; \t\r\n")
+
+	var stmt variableStatement
+	var rest string
+	switch {
+	case keywordAt(statement, 0, "SET"):
+		rest = statement[len("SET"):]
+	case keywordAt(statement, 0, "UNSET"):
+		stmt.Unset = true
+		rest = statement[len("UNSET"):]
+	default:
+		return nil, false, nil
+	}
+
+	rest = strings.TrimSpace(rest)
+	parenthesized := strings.HasPrefix(rest, "(")
+	names, rest, ok := parseVariableList(rest)
+	if !ok {
+		return nil, true, fmt.Errorf("invalid variable name in %q", sql)
+	}
+	for _, name := range names {
+		stmt.Names = append(stmt.Names, variableName(name))
+	}
+
+	if stmt.Unset {
+		if strings.TrimSpace(rest) != "" {
+			return nil, true, fmt.Errorf("unexpected %q after UNSET", strings.TrimSpace(rest))
+		}
+		return &stmt, true, nil
+	}
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return nil, true, fmt.Errorf("expected '=' after variable name in %q", sql)
+	}
+	rest = strings.TrimSpace(rest[1:])
+
+	if !parenthesized {
+		stmt.Exprs = []string{rest}
+	} else if end := matchingParen(rest); strings.HasPrefix(rest, "(") && end == len(rest)-1 {
+		stmt.Exprs = splitFunctionArgs(rest[1:end], len(stmt.Names))
+	}
+	if len(stmt.Exprs) != len(stmt.Names) {
+		return nil, true, fmt.Errorf("SET assigns %d variables but %d values", len(stmt.Names), len(stmt.Exprs))
+	}
+	for i, expr := range stmt.Exprs {
+		stmt.Exprs[i] = strings.TrimSpace(expr)
+		if stmt.Exprs[i] == "" {
+			return nil, true, fmt.Errorf("missing value for variable %s", stmt.Names[i])
+		}
+	}
+	return &stmt, true, nil
+}
+
+// parseVariableList parses a variable name or a parenthesized list of names at
+// the start of s and returns the names and the rest of s.
+func parseVariableList(s string) (names []string, rest string, ok bool) {
+	if strings.HasPrefix(s, "(") {
+		end := matchingParen(s)
+		if end < 0 {
+			return nil, "", false
+		}
+		for _, name := range strings.Split(s[1:end], ",") {
+			name = strings.TrimSpace(name)
+			if !isVariableName(name) {
+				return nil, "", false
+			}
+			names = append(names, name)
+		}
+		return names, s[end+1:], true
+	}
+
+	end := 0
+	if strings.HasPrefix(s, `"`) {
+		end = skipQuoted(s, 0, '"') + 1
+	} else {
+		for end < len(s) && isIdentChar(s[end]) && s[end] != '.' {
+			end++
+		}
+	}
+	if !isVariableName(s[:end]) {
+		return nil, "", false
+	}
+	return []string{s[:end]}, s[end:], true
+}
+
+// isVariableName reports whether s is an unquoted or quoted variable name.
+func isVariableName(s string) bool {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return true
+	}
+	if s == "" || (s[0] >= '0' && s[0] <= '9') || s[0] == '$' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentChar(s[i]) || s[i] == '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// variableName normalizes a variable name: unquoted names are case-insensitive
+// and stored uppercase, quoted names keep their case.
+func variableName(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		return unquoteIdentifier(name)
+	}
+	return strings.ToUpper(name)
+}
+
+// executeVariableStatement sets or unsets SQL variables of the session. SET
+// evaluates its expressions in a single query, so they may reference other
+// variables or be subqueries.
+func (e *Executor) executeVariableStatement(ctx context.Context, stmt *variableStatement) (*ExecResult, error) {
+	sessionID := SessionInfoFromContext(ctx).ID
+
+	if stmt.Unset {
+		e.variables.mu.Lock()
+		defer e.variables.mu.Unlock()
+		for _, name := range stmt.Names {
+			if _, ok := e.variables.bySession[sessionID][name]; !ok {
+				return nil, fmt.Errorf("session variable '$%s' does not exist", name)
+			}
+		}
+		for _, name := range stmt.Names {
+			delete(e.variables.bySession[sessionID], name)
+		}
+		return &ExecResult{RowsAffected: 0}, nil
+	}
+
+	result, err := e.Query(ctx, "SELECT "+strings.Join(stmt.Exprs, ", "))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rows) > 1 {
+		return nil, fmt.Errorf("SET expression returned %d rows, expected at most one", len(result.Rows))
+	}
+
+	e.variables.mu.Lock()
+	defer e.variables.mu.Unlock()
+	if e.variables.bySession == nil {
+		e.variables.bySession = make(map[string]map[string]sessionVariable)
+	}
+	if e.variables.bySession[sessionID] == nil {
+		e.variables.bySession[sessionID] = make(map[string]sessionVariable)
+	}
+	now := time.Now()
+	for i, name := range stmt.Names {
+		variable := sessionVariable{Type: result.ColumnTypes[i], CreatedOn: now, UpdatedOn: now}
+		if existing, ok := e.variables.bySession[sessionID][name]; ok {
+			variable.CreatedOn = existing.CreatedOn
+		}
+		if len(result.Rows) == 1 {
+			variable.Value = result.Rows[0][i]
+		}
+		e.variables.bySession[sessionID][name] = variable
+	}
+	return &ExecResult{RowsAffected: 0}, nil
+}
+
+// clearVariables drops the SQL variables of a session.
+func (e *Executor) clearVariables(sessionID string) {
+	e.variables.mu.Lock()
+	defer e.variables.mu.Unlock()
+	delete(e.variables.bySession, sessionID)
+}
+
+// lookupVariable returns the value of a SQL variable of the session.
+func (e *Executor) lookupVariable(ctx context.Context, name string) (sessionVariable, error) {
+	e.variables.mu.Lock()
+	defer e.variables.mu.Unlock()
+	variable, ok := e.variables.bySession[SessionInfoFromContext(ctx).ID][name]
+	if !ok {
+		return sessionVariable{}, fmt.Errorf("session variable '$%s' does not exist", name)
+	}
+	return variable, nil
+}
+
+// substituteVariables replaces $name references to SQL variables with the
+// variables' values as literals, and IDENTIFIER('name') and IDENTIFIER($name)
+// with the object name they hold. References inside literals, quoted
+// identifiers, comments, and $$ bodies are left alone, as are positional
+// references such as $1.
+func (e *Executor) substituteVariables(ctx context.Context, sql string) (string, error) {
+	if !strings.Contains(sql, "$") && indexFold(sql, "IDENTIFIER") < 0 {
+		return sql, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i : end+1])
+			i = end
+		case c == '$' && i+1 < len(sql) && sql[i+1] == '$':
+			end := strings.Index(sql[i+2:], "$$")
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String(), nil
+			}
+			b.WriteString(sql[i : i+2+end+2])
+			i += 2 + end + 1
+		case c == '$' && (i == 0 || !isIdentChar(sql[i-1])) && i+1 < len(sql) && isVariableStart(sql[i+1]):
+			end := variableEnd(sql, i+1)
+			variable, err := e.lookupVariable(ctx, strings.ToUpper(sql[i+1:end]))
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(variableLiteral(variable))
+			i = end - 1
+		case keywordAt(sql, i, "IDENTIFIER"):
+			identifier, end, ok, err := e.resolveIdentifier(ctx, sql, i)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				b.WriteByte(c)
+				continue
+			}
+			b.WriteString(identifier)
+			i = end - 1
+		default:
+			if end, ok := commentEnd(sql, i); ok {
+				b.WriteString(sql[i:end])
+				i = end - 1
+				continue
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// isVariableStart reports whether c may start a variable name.
+func isVariableStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// variableEnd returns the index after the variable name starting at sql[i].
+func variableEnd(sql string, i int) int {
+	for i < len(sql) && isIdentChar(sql[i]) && sql[i] != '.' && sql[i] != '$' {
+		i++
+	}
+	return i
+}
+
+// variableLiteral renders a variable's value as a Snowflake SQL literal of its type.
+func variableLiteral(variable sessionVariable) string {
+	switch v := variable.Value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteLiteral(v)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		switch variable.Type.Type {
+		case "date":
+			return quoteLiteral(v.Format("2006-01-02")) + "::DATE"
+		case "time":
+			return quoteLiteral(v.Format("15:04:05.999999999")) + "::TIME"
+		case "timestamp_ltz", "timestamp_tz":
+			return quoteLiteral(v.Format("2006-01-02 15:04:05.999999999 -07:00")) + "::" + strings.ToUpper(variable.Type.Type)
+		default:
+			return quoteLiteral(v.Format("2006-01-02 15:04:05.999999999")) + "::TIMESTAMP_NTZ"
+		}
+	}
+	switch variable.Type.Type {
+	case "fixed", "real":
+		// Negative numbers are parenthesized so that e.g. x-$v does not become a comment
+		number := fmt.Sprint(variable.Value)
+		if strings.HasPrefix(number, "-") {
+			return "(" + number + ")"
+		}
+		return number
+	default:
+		return quoteLiteral(fmt.Sprint(variable.Value))
+	}
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVariableStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    *variableStatement
+		wantOK  bool
+		wantErr bool
+	}{
+		{name: "set", sql: "SET my_var = 42", want: &variableStatement{Names: []string{"MY_VAR"}, Exprs: []string{"42"}}, wantOK: true},
+		{name: "set expression", sql: "set tbl = CONCAT('db.s.', 't');", want: &variableStatement{Names: []string{"TBL"}, Exprs: []string{"CONCAT('db.s.', 't')"}}, wantOK: true},
+		{name: "set list", sql: "SET (a, \"b\") = (1, (SELECT 2))", want: &variableStatement{Names: []string{"A", "b"}, Exprs: []string{"1", "(SELECT 2)"}}, wantOK: true},
+		{name: "unset", sql: "-- cleanup\nUNSET my_var", want: &variableStatement{Unset: true, Names: []string{"MY_VAR"}}, wantOK: true},
+		{name: "unset list", sql: "UNSET (a, b)", want: &variableStatement{Unset: true, Names: []string{"A", "B"}}, wantOK: true},
+		{name: "count mismatch", sql: "SET (a, b) = (1)", wantOK: true, wantErr: true},
+		{name: "missing value", sql: "SET a =", wantOK: true, wantErr: true},
+		{name: "invalid name", sql: "SET 1a = 1", wantOK: true, wantErr: true},
+		{name: "alter session", sql: "ALTER SESSION SET TIMEZONE = 'UTC'", wantOK: false},
+		{name: "update", sql: "UPDATE t SET a = 1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseVariableStatement(tt.sql)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("parseVariableStatement(%q) = (%v, %v, %v), want ok %v, error %v", tt.sql, got, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseVariableStatement(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+func TestExecutor_Variables(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "1"})
+
+	statements := []string{
+		"CREATE TABLE products (id INTEGER, name VARCHAR)",
+		"INSERT INTO products VALUES (1, 'apple'), (2, 'pear'), (3, 'plum')",
+		"SET min_id = 2",
+		"SET (tbl, prefix) = ('products', 'p')",
+		"SET pattern = CONCAT($prefix, '%')",
+		"SET offset = -1",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sql  string
+		want [][]interface{}
+	}{
+		{name: "number", sql: "SELECT $min_id", want: [][]interface{}{{int32(2)}}},
+		{name: "case insensitive", sql: "SELECT $Prefix", want: [][]interface{}{{"p"}}},
+		{name: "negative after minus", sql: "SELECT 1-$offset", want: [][]interface{}{{int32(2)}}},
+		{name: "in predicate", sql: "SELECT name FROM products WHERE id >= $min_id AND name LIKE $pattern ORDER BY id", want: [][]interface{}{{"pear"}, {"plum"}}},
+		{name: "identifier", sql: "SELECT COUNT(*) FROM IDENTIFIER($tbl)", want: [][]interface{}{{int64(3)}}},
+		{name: "literals and comments left alone", sql: "SELECT '$min_id' -- $nope", want: [][]interface{}{{"$min_id"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if diff := cmp.Diff(tt.want, result.Rows); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+
+	// Variables work in DML and belong to their session
+	if _, err := executor.Execute(ctx, "DELETE FROM IDENTIFIER($tbl) WHERE id < $min_id"); err != nil {
+		t.Fatalf("DELETE error = %v", err)
+	}
+	other := ContextWithSessionInfo(context.Background(), SessionInfo{ID: "2"})
+	if _, err := executor.Query(other, "SELECT $min_id"); err == nil {
+		t.Error("variable of another session should not resolve")
+	}
+
+	if _, err := executor.Execute(ctx, "UNSET (min_id, tbl)"); err != nil {
+		t.Fatalf("UNSET error = %v", err)
+	}
+	if _, err := executor.Query(ctx, "SELECT $min_id"); err == nil {
+		t.Error("unset variable should not resolve")
+	}
+	if _, err := executor.Execute(ctx, "UNSET min_id"); err == nil {
+		t.Error("UNSET of a missing variable should fail")
+	}
+
+	if err := executor.EndSession(context.Background(), "1"); err != nil {
+		t.Fatalf("EndSession() error = %v", err)
+	}
+	if _, err := executor.Query(ctx, "SELECT $prefix"); err == nil {
+		t.Error("variables should be dropped when the session ends")
+	}
+}
@@ -0,0 +1,270 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// variantPathElement is an element of a semi-structured path: an object key,
+// or an array index when isIndex is set.
+type variantPathElement struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// rewriteVariantPaths rewrites Snowflake's path syntax on VARIANT, OBJECT, and
+// ARRAY values, which are stored as DuckDB JSON, to DuckDB's JSON functions.
+//
+//	v:a.b[0]              → json_extract(v, '$."a"."b"[0]')
+//	t.v:"Key"['x']::INT   → __CAST__(json_extract_string(t.v, '$."Key"."x"'), 'BIGINT')
+//	v:kind = 'click'      → json_extract_string(v, '$."kind"') = 'click'
+//
+// A path keeps its JSON value, as a VARIANT does, unless it is cast to a type
+// other than VARIANT, OBJECT, or ARRAY or compared with a string literal: then
+// strings are read without their JSON quotes, as Snowflake converts them. Keys
+// are case-sensitive, quoted or not. The path's base may be a column, a
+// qualified column, a quoted identifier, a function call, or a bracketed
+// element such as f.value['a']. Colons inside literals, dollar-quoted bodies,
+// comments, and brackets, and :: casts and :N placeholders are left alone.
+func rewriteVariantPaths(sql string) string {
+	if !strings.Contains(sql, ":") {
+		return sql
+	}
+
+	// Find where the parenthesized, bracketed, and quoted spans that may end a
+	// path's base start
+	openAt := make(map[int]int)
+	var opens []int
+	var b strings.Builder
+	last := 0
+	brackets := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'':
+			i = skipQuoted(sql, i, c)
+		case c == '"':
+			end := skipQuoted(sql, i, c)
+			openAt[end] = i
+			i = end
+		case strings.HasPrefix(sql[i:], "$$"):
+			if end := strings.Index(sql[i+2:], "$$"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(sql)
+			}
+		case c == '(' || c == '[':
+			opens = append(opens, i)
+			if c == '[' {
+				brackets++
+			}
+		case c == ')' || c == ']':
+			if len(opens) > 0 {
+				openAt[i] = opens[len(opens)-1]
+				opens = opens[:len(opens)-1]
+			}
+			if c == ']' && brackets > 0 {
+				brackets--
+			}
+		case c == ':' && i+1 < len(sql) && sql[i+1] == ':':
+			i++
+		case c == ':' && brackets == 0:
+			start := variantBaseStart(sql, i, openAt)
+			if start < last || start == i {
+				continue
+			}
+			path, end := parseVariantPath(sql, i+1)
+			if len(path) == 0 {
+				continue
+			}
+			b.WriteString(sql[last:start])
+			b.WriteString(variantPathExpr(sql[start:i], path, sql, &end))
+			last = end
+			i = end - 1
+		}
+	}
+	if last == 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
+
+// variantBaseStart returns the start of the expression that ends right before
+// the colon at sql[colon], or colon if there is none. Identifiers may not
+// start with a digit, so that the colons of slices such as [1:2] are not
+// taken for paths.
+func variantBaseStart(sql string, colon int, openAt map[int]int) int {
+	start := colon
+	for start > 0 {
+		c := sql[start-1]
+		if c == ')' || c == ']' || c == '"' {
+			open, ok := openAt[start-1]
+			if !ok {
+				return colon
+			}
+			start = open
+			continue
+		}
+		if !isIdentChar(c) {
+			break
+		}
+		start--
+	}
+	if start == colon {
+		return colon
+	}
+	if c := sql[start]; (c >= '0' && c <= '9') || c == '.' {
+		return colon
+	}
+	return start
+}
+
+// parseVariantPath parses the path that starts at sql[start], right after
+// the colon: a key, then keys after dots and indexes or keys in brackets. It
+// returns the elements and the end of the path.
+func parseVariantPath(sql string, start int) ([]variantPathElement, int) {
+	var path []variantPathElement
+	i := start
+	for i < len(sql) {
+		// Keys follow the colon or a dot
+		if i == start || sql[i] == '.' {
+			if i > start {
+				i++
+			}
+			key, end, ok := variantPathKey(sql, i)
+			if !ok {
+				break
+			}
+			path = append(path, variantPathElement{key: key})
+			i = end
+			continue
+		}
+		if sql[i] != '[' {
+			break
+		}
+		element, end, ok := variantPathBracket(sql, i)
+		if !ok {
+			break
+		}
+		path = append(path, element)
+		i = end
+	}
+	if len(path) == 0 {
+		return nil, start
+	}
+	// A dot that starts no key is not part of the path
+	if i > start && sql[i-1] == '.' {
+		i--
+	}
+	return path, i
+}
+
+// variantPathKey reads a key at sql[start], a word or a double-quoted name.
+func variantPathKey(sql string, start int) (string, int, bool) {
+	if start >= len(sql) {
+		return "", start, false
+	}
+	if sql[start] == '"' {
+		end := strings.IndexByte(sql[start+1:], '"')
+		if end < 0 {
+			return "", start, false
+		}
+		return sql[start+1 : start+1+end], start + end + 2, true
+	}
+	if c := sql[start]; !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+		return "", start, false
+	}
+	end := start
+	for end < len(sql) && isIdentChar(sql[end]) && sql[end] != '.' {
+		end++
+	}
+	return sql[start:end], end, true
+}
+
+// variantPathBracket reads an index or a quoted key in brackets at
+// sql[start].
+func variantPathBracket(sql string, start int) (variantPathElement, int, bool) {
+	closing := strings.IndexByte(sql[start:], ']')
+	if closing < 0 {
+		return variantPathElement{}, start, false
+	}
+	content := strings.TrimSpace(sql[start+1 : start+closing])
+	end := start + closing + 1
+	if n, err := strconv.Atoi(content); err == nil && n >= 0 {
+		return variantPathElement{index: n, isIndex: true}, end, true
+	}
+	if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+		key := content[1 : len(content)-1]
+		if content[0] == '\'' {
+			key = strings.ReplaceAll(key, "''", "'")
+		}
+		return variantPathElement{key: key}, end, true
+	}
+	return variantPathElement{}, start, false
+}
+
+// variantPathExpr returns the DuckDB expression reading path from base. A
+// :: cast that follows the path at sql[*end] becomes a __CAST__ marker, as
+// markCast leaves casts for transformCast, since the parser accepts neither
+// :: nor most types in CAST, and *end is moved past it.
+func variantPathExpr(base string, path []variantPathElement, sql string, end *int) string {
+	extract := "(" + base + ", '" + jsonPathLiteral(path) + "')"
+	if strings.HasPrefix(sql[*end:], "::") {
+		if r, ok := typeDeclAt(sql, *end+2); ok {
+			*end = r.end
+			if r.duckType == "JSON" {
+				// The path is already a VARIANT
+				return "json_extract" + extract
+			}
+			return "__CAST__(json_extract_string" + extract + ", '" + r.duckType + "')"
+		}
+		return "json_extract_string" + extract
+	}
+	if variantPathComparedWithString(sql, *end) {
+		return "json_extract_string" + extract
+	}
+	return "json_extract" + extract
+}
+
+// variantPathComparedWithString reports whether the path ending at sql[end]
+// is compared with a string literal, which Snowflake compares with the
+// string the path holds.
+func variantPathComparedWithString(sql string, end int) bool {
+	i := end
+	for i < len(sql) && isSpace(sql[i]) {
+		i++
+	}
+	for _, op := range []string{"=", "<>", "!=", "LIKE", "ILIKE", "IN"} {
+		if !strings.HasPrefix(strings.ToUpper(sql[i:]), op) {
+			continue
+		}
+		j := i + len(op)
+		for j < len(sql) && (isSpace(sql[j]) || (op == "IN" && sql[j] == '(')) {
+			j++
+		}
+		return j < len(sql) && sql[j] == '\''
+	}
+	return false
+}
+
+// jsonPathLiteral renders a path as the body of a string literal holding a
+// DuckDB JSON path, with every key quoted so that it may hold any character.
+func jsonPathLiteral(path []variantPathElement) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, element := range path {
+		if element.isIndex {
+			b.WriteString("[" + strconv.Itoa(element.index) + "]")
+			continue
+		}
+		b.WriteString(`."` + strings.ReplaceAll(element.key, `"`, `\"`) + `"`)
+	}
+	return strings.ReplaceAll(b.String(), "'", "''")
+}
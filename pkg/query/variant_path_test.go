@@ -0,0 +1,104 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRewriteVariantPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{name: "Key", sql: "SELECT v:name FROM t", want: `SELECT json_extract(v, '$."name"') FROM t`},
+		{name: "NestedKeysAndIndex", sql: "SELECT v:a.b[0] FROM t", want: `SELECT json_extract(v, '$."a"."b"[0]') FROM t`},
+		{name: "BracketKeys", sql: `SELECT v:a['b c']["d"] FROM t`, want: `SELECT json_extract(v, '$."a"."b c"."d"') FROM t`},
+		{name: "QuotedKeyKeepsCase", sql: `SELECT v:"Name" FROM t`, want: `SELECT json_extract(v, '$."Name"') FROM t`},
+		{name: "QualifiedBase", sql: "SELECT t.v:a FROM t", want: `SELECT json_extract(t.v, '$."a"') FROM t`},
+		{name: "QuotedBase", sql: `SELECT "Raw":a FROM t`, want: `SELECT json_extract("Raw", '$."a"') FROM t`},
+		{name: "FunctionBase", sql: "SELECT PARSE_JSON(s):a FROM t", want: `SELECT json_extract(PARSE_JSON(s), '$."a"') FROM t`},
+		{name: "BracketBase", sql: "SELECT f.value[0]:a FROM t", want: `SELECT json_extract(f.value[0], '$."a"') FROM t`},
+		{name: "StringCast", sql: "SELECT v:a::string FROM t", want: `SELECT __CAST__(json_extract_string(v, '$."a"'), 'VARCHAR') FROM t`},
+		{name: "NumberCast", sql: "SELECT v:a::NUMBER(10,2) + 1 FROM t", want: `SELECT __CAST__(json_extract_string(v, '$."a"'), 'DECIMAL(10,2)') + 1 FROM t`},
+		{name: "VariantCast", sql: "SELECT v:a::VARIANT FROM t", want: `SELECT json_extract(v, '$."a"') FROM t`},
+		{name: "ComparedWithString", sql: "SELECT 1 FROM t WHERE v:kind = 'click' OR v:kind IN ('view')", want: `SELECT 1 FROM t WHERE json_extract_string(v, '$."kind"') = 'click' OR json_extract_string(v, '$."kind"') IN ('view')`},
+		{name: "ComparedWithNumber", sql: "SELECT 1 FROM t WHERE v:n = 1", want: `SELECT 1 FROM t WHERE json_extract(v, '$."n"') = 1`},
+		{name: "KeyWithQuote", sql: `SELECT v:"it's" FROM t`, want: `SELECT json_extract(v, '$."it''s"') FROM t`},
+		{name: "Cast", sql: "SELECT x::INT FROM t", want: "SELECT x::INT FROM t"},
+		{name: "Placeholder", sql: "SELECT * FROM t WHERE id = :1 AND name=:2", want: "SELECT * FROM t WHERE id = :1 AND name=:2"},
+		{name: "Literal", sql: "SELECT 'a:b', '10:30:00'::TIME", want: "SELECT 'a:b', '10:30:00'::TIME"},
+		{name: "Slice", sql: "SELECT l[i:j], l[1:2] FROM t", want: "SELECT l[i:j], l[1:2] FROM t"},
+		{name: "Assignment", sql: "LET x:=1", want: "LET x:=1"},
+		{name: "DollarQuotedBody", sql: "CREATE PROCEDURE p() RETURNS INT AS $$ SELECT v:a $$", want: "CREATE PROCEDURE p() RETURNS INT AS $$ SELECT v:a $$"},
+		{name: "Comment", sql: "SELECT /* v:a */ 1", want: "SELECT /* v:a */ 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, rewriteVariantPaths(tt.sql)); diff != "" {
+				t.Errorf("rewriteVariantPaths(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
+
+// TestExecutor_VariantPaths tests path syntax on VARIANT, OBJECT, and ARRAY
+// columns against DuckDB.
+func TestExecutor_VariantPaths(t *testing.T) {
+	executor, _ := setupTestExecutor(t)
+	ctx := context.Background()
+
+	statements := []string{
+		"CREATE TABLE events (id INTEGER, payload VARIANT, attrs OBJECT, tags ARRAY)",
+		`INSERT INTO events SELECT 1, PARSE_JSON('{"kind": "click", "user": {"id": 42, "Name": "Al"}, "items": [{"sku": "a", "qty": 2}, {"sku": "b", "qty": 1.5}], "none": null}'),
+			PARSE_JSON('{"color": "red"}'), PARSE_JSON('["x", "y"]')`,
+		"CREATE TABLE clicks AS SELECT payload:user.id::NUMBER AS user_id, payload:user.\"Name\"::STRING AS name FROM events WHERE payload:kind = 'click'",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		sql  string
+		want interface{}
+	}{
+		{name: "Object", sql: "SELECT payload:user FROM events", want: map[string]interface{}{"id": float64(42), "Name": "Al"}},
+		{name: "StringWithoutQuotes", sql: "SELECT payload:kind::STRING FROM events", want: "click"},
+		{name: "IndexThenKey", sql: "SELECT payload:items[1].sku::VARCHAR FROM events", want: "b"},
+		{name: "NumberCast", sql: "SELECT payload:items[0].qty::NUMBER * 10 FROM events", want: "20"},
+		{name: "FloatCast", sql: "SELECT payload:items[1].qty::FLOAT FROM events", want: 1.5},
+		{name: "CaseSensitiveKey", sql: "SELECT payload:user.name FROM events", want: nil},
+		{name: "MissingKey", sql: "SELECT payload:missing.deeper::STRING FROM events", want: nil},
+		{name: "JSONNullCastIsNull", sql: "SELECT payload:none::STRING IS NULL FROM events", want: true},
+		{name: "ObjectColumn", sql: "SELECT attrs:color::STRING FROM events", want: "red"},
+		{name: "ArrayColumn", sql: "SELECT tags[1] FROM events", want: "y"},
+		{name: "WithTranslatedFunctions", sql: "SELECT IFF(payload:user.id::INT > 40, NVL(payload:user.\"Name\"::STRING, 'n/a'), 'low') FROM events", want: "Al"},
+		{name: "Filter", sql: "SELECT id FROM events WHERE payload:kind = 'click' AND payload:items[0].sku::STRING LIKE 'a%'", want: int64(1)},
+		{name: "CreateTableAsSelect", sql: "SELECT CONCAT(name, user_id) FROM clicks", want: "Al42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Query(ctx, tt.sql)
+			if err != nil {
+				t.Fatalf("Query(%q) error = %v", tt.sql, err)
+			}
+			if len(result.Rows) != 1 || len(result.Rows[0]) != 1 {
+				t.Fatalf("Query(%q) rows = %v, want one value", tt.sql, result.Rows)
+			}
+			got := result.Rows[0][0]
+			if s, ok := got.(interface{ String() string }); ok {
+				got = s.String()
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Query(%q) mismatch (-want +got):\n%s", tt.sql, diff)
+			}
+		})
+	}
+}
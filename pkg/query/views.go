@@ -0,0 +1,244 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// dropMaterializedView matches the start of a DROP MATERIALIZED VIEW statement.
+var dropMaterializedView = regexp.MustCompile(`(?i)^(\s*DROP\s+)MATERIALIZED\s+VIEW`)
+
+// viewColumns infers the columns of a view, or of any table, from the result
+// metadata of a query over it that returns no rows.
+func (e *Executor) viewColumns(ctx context.Context, name string) ([]metadata.ColumnDef, error) {
+	result, err := e.Query(ctx, "SELECT * FROM "+name+" LIMIT 0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to infer columns of %s: %w", name, err)
+	}
+
+	columns := make([]metadata.ColumnDef, len(result.ColumnTypes))
+	for i, col := range result.ColumnTypes {
+		columns[i] = metadata.ColumnDef{
+			Name:     col.Name,
+			Type:     ColumnTypeName(col),
+			Nullable: col.Nullable,
+		}
+	}
+	return columns, nil
+}
+
+// registerView records a view created with SQL, with the column types inferred
+// from its query and whether it is secure or materialized, in the metadata
+// store so that catalog listings and SHOW VIEWS show it. Views are registered
+// when their schema is registered, or is the PUBLIC schema, like tables.
+// Temporary views are not registered.
+func (e *Executor) registerView(ctx context.Context, stmt *createStatement) error {
+	if stmt.Temporary {
+		return nil
+	}
+	schema, name, err := e.objectSchema(ctx, stmt.Name, true)
+	if err != nil {
+		return nil
+	}
+	if stmt.IfNotExists {
+		if _, err := e.repo.GetTableByName(ctx, schema.ID, name); err == nil {
+			return nil
+		}
+	}
+
+	columns, err := e.viewColumns(ctx, stmt.Name)
+	if err != nil {
+		return err
+	}
+	comment := ""
+	if stmt.Comment != nil {
+		comment = *stmt.Comment
+	}
+	view, err := e.repo.CreateView(ctx, schema.ID, name, columns, comment)
+	if err != nil {
+		return fmt.Errorf("failed to register view: %w", err)
+	}
+	if stmt.Secure || stmt.Materialized {
+		if err := e.repo.SetViewFlags(ctx, view.ID, stmt.Secure, stmt.Materialized); err != nil {
+			return fmt.Errorf("failed to register view: %w", err)
+		}
+	}
+	return nil
+}
+
+// executeDropView drops a view and removes it from the metadata store.
+// Materialized views are DuckDB views, so DROP MATERIALIZED VIEW drops a view.
+func (e *Executor) executeDropView(ctx context.Context, sql, name string) (*ExecResult, error) {
+	result, err := e.executeRaw(ctx, dropMaterializedView.ReplaceAllString(stripLeadingComments(sql), "${1}VIEW"))
+	if err != nil {
+		return nil, err
+	}
+
+	schema, viewName, ok := e.lookupObjectSchema(ctx, name)
+	if !ok {
+		return result, nil
+	}
+	view, err := e.repo.GetTableByName(ctx, schema.ID, viewName)
+	if err != nil || view.TableType != "VIEW" {
+		return result, nil
+	}
+	if err := e.repo.DropTable(ctx, view.ID); err != nil {
+		return nil, fmt.Errorf("failed to unregister view: %w", err)
+	}
+	return result, nil
+}
+
+// lookupObjectSchema resolves a possibly qualified object name against the
+// session's current database and schema. It returns the object's registered
+// schema and its unqualified name, or false if the schema is not registered.
+func (e *Executor) lookupObjectSchema(ctx context.Context, name string) (*metadata.Schema, string, bool) {
+	database, schemaName, objectName := splitObjectName(ctx, name)
+	if database == "" || schemaName == "" {
+		return nil, "", false
+	}
+	db, err := e.repo.GetDatabaseByName(ctx, database)
+	if err != nil {
+		return nil, "", false
+	}
+	schema, err := e.repo.GetSchemaByName(ctx, db.ID, schemaName)
+	if err != nil {
+		return nil, "", false
+	}
+	return schema, objectName, true
+}
+
+// splitObjectName splits a possibly qualified object name into its database,
+// schema, and object names. Missing parts default to the session's current
+// database and schema.
+func splitObjectName(ctx context.Context, name string) (database, schema, object string) {
+	info := SessionInfoFromContext(ctx)
+	database, schema = info.Database, info.Schema
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = unquoteIdentifier(strings.TrimSpace(part))
+	}
+	object = parts[len(parts)-1]
+	if len(parts) >= 2 {
+		schema = parts[len(parts)-2]
+	}
+	if len(parts) >= 3 {
+		database = parts[len(parts)-3]
+	}
+	return database, schema, object
+}
+
+// dropViewName returns the name of the view dropped by a DROP [MATERIALIZED]
+// VIEW statement.
+func dropViewName(sql string) (string, bool) {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+	if len(fields) >= 4 && strings.EqualFold(fields[1], "MATERIALIZED") {
+		fields = append(fields[:1], fields[2:]...)
+	}
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "DROP") || !strings.EqualFold(fields[1], "VIEW") {
+		return "", false
+	}
+	fields = fields[2:]
+	if len(fields) >= 3 && strings.EqualFold(fields[0], "IF") && strings.EqualFold(fields[1], "EXISTS") {
+		fields = fields[2:]
+	}
+	return fields[0], true
+}
+
+// showViews lists the views of the schemas of a SHOW VIEWS statement: the
+// DuckDB views of the schemas, with whether they are secure or materialized
+// from their registration. Their text is the translated query DuckDB runs.
+func (e *Executor) showViews(ctx context.Context, stmt *showStatement) ([]showRow, error) {
+	databases, err := e.scopeDatabases(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	var rows []showRow
+	for _, db := range databases {
+		schemas, err := e.scopeSchemas(ctx, stmt, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, schema := range schemas {
+			views, err := e.schemaViews(ctx, db, schema)
+			if err != nil {
+				return nil, err
+			}
+			for _, view := range views {
+				var createdOn interface{}
+				owner, secure, materialized := "", false, false
+				if view.Registered != nil {
+					createdOn = view.Registered.CreatedAt
+					owner, secure, materialized = view.Registered.Owner, view.Registered.Secure, view.Registered.Materialized
+				}
+				kind := "VIEW"
+				if materialized {
+					kind = "MATERIALIZED_VIEW"
+				}
+				rows = append(rows, showRow{
+					Database:  db.Name,
+					Schema:    schema,
+					Name:      view.Name,
+					Kind:      kind,
+					CreatedOn: createdOn,
+					Values: []interface{}{
+						createdOn, view.Name, "", db.Name, schema, owner, view.Comment, view.Text,
+						strconv.FormatBool(secure), strconv.FormatBool(materialized), "ROLE", "OFF",
+					},
+				})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// schemaView is a view of a schema of a database.
+type schemaView struct {
+	Name    string
+	Text    string
+	Comment string
+	// Registered is the view's metadata, for views registered when created.
+	Registered *metadata.Table
+}
+
+// schemaViews lists the views of a schema of db, which are created in the
+// DuckDB schema of the same name, or in main for PUBLIC.
+func (e *Executor) schemaViews(ctx context.Context, db *metadata.Database, schema string) ([]*schemaView, error) {
+	rows, err := e.manager(ctx).Query(ctx, `SELECT view_name, sql, comment FROM duckdb_views()
+		WHERE database_name = current_database() AND lower(schema_name) = lower(?) AND NOT internal AND NOT temporary
+		ORDER BY view_name`, physicalSchemaName(schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list views of schema %s: %w", schema, err)
+	}
+	var views []*schemaView
+	for rows.Next() {
+		var name string
+		var text, comment sql.NullString
+		if err := rows.Scan(&name, &text, &comment); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		views = append(views, &schemaView{Name: name, Text: text.String, Comment: comment.String})
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	registered, err := e.repo.GetSchemaByName(ctx, db.ID, schema)
+	if err != nil {
+		return views, nil
+	}
+	for _, view := range views {
+		if table, err := e.repo.GetTableByName(ctx, registered.ID, view.Name); err == nil && table.TableType == "VIEW" {
+			view.Registered = table
+		}
+	}
+	return views, nil
+}
@@ -0,0 +1,204 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+)
+
+// TestExecutor_CreateView tests that views over translated SQL can be queried and
+// are registered with the column types inferred from their query.
+func TestExecutor_CreateView(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	db, err := repo.CreateDatabase(ctx, "TEST_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	schema, err := repo.CreateSchema(ctx, db.ID, "PUBLIC", "")
+	if err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "TEST_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE TABLE orders (id INTEGER, amount NUMBER(10,2), note VARCHAR)",
+		"INSERT INTO orders VALUES (1, 12.50, NULL), (2, 99.99, 'rush')",
+		"CREATE VIEW order_labels COMMENT = 'Labeled orders' AS " +
+			"SELECT id, amount, IFF(amount > 50, 'large', 'small') AS size, NVL(note, 'none') AS note FROM orders",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	result, err := executor.Query(ctx, "SELECT size, note FROM order_labels ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"small", "none"}, {"large", "rush"}}, result.Rows); diff != "" {
+		t.Errorf("view rows mismatch (-want +got):\n%s", diff)
+	}
+
+	view, err := repo.GetTableByName(ctx, schema.ID, "ORDER_LABELS")
+	if err != nil {
+		t.Fatalf("GetTableByName() error = %v", err)
+	}
+	if view.TableType != "VIEW" || view.Comment != "Labeled orders" {
+		t.Errorf("view type, comment = %q, %q, want VIEW, %q", view.TableType, view.Comment, "Labeled orders")
+	}
+	expected := []metadata.ColumnDef{
+		{Name: "id", Type: "NUMBER(38,0)", Nullable: true},
+		{Name: "amount", Type: "NUMBER(10,2)", Nullable: true},
+		{Name: "size", Type: "VARCHAR", Nullable: true},
+		{Name: "note", Type: "VARCHAR", Nullable: true},
+	}
+	if diff := cmp.Diff(expected, metadata.ParseColumnDefs(view.ColumnDefinitions)); diff != "" {
+		t.Errorf("view columns mismatch (-want +got):\n%s", diff)
+	}
+
+	// Replacing the view updates its columns
+	if _, err := executor.Execute(ctx, "CREATE OR REPLACE VIEW order_labels AS SELECT id FROM orders"); err != nil {
+		t.Fatalf("Execute() replace error = %v", err)
+	}
+	view, err = repo.GetTableByName(ctx, schema.ID, "ORDER_LABELS")
+	if err != nil {
+		t.Fatalf("GetTableByName() error = %v", err)
+	}
+	if got := len(metadata.ParseColumnDefs(view.ColumnDefinitions)); got != 1 {
+		t.Errorf("replaced view has %d columns, want 1", got)
+	}
+
+	if _, err := executor.Execute(ctx, "DROP VIEW order_labels"); err != nil {
+		t.Fatalf("Execute() drop error = %v", err)
+	}
+	if _, err := repo.GetTableByName(ctx, schema.ID, "ORDER_LABELS"); err == nil {
+		t.Error("GetTableByName() after DROP VIEW error = nil, want error")
+	}
+}
+
+// TestExecutor_SecureAndMaterializedViews tests that secure and materialized
+// views are created as DuckDB views and listed by SHOW VIEWS and
+// INFORMATION_SCHEMA.VIEWS.
+func TestExecutor_SecureAndMaterializedViews(t *testing.T) {
+	executor, repo := setupTestExecutor(t)
+	ctx := context.Background()
+
+	db, err := repo.CreateDatabase(ctx, "TEST_DB", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	ctx = ContextWithSessionInfo(ctx, SessionInfo{Database: "TEST_DB", Schema: "PUBLIC"})
+
+	statements := []string{
+		"CREATE TABLE orders (id INTEGER, amount NUMBER(10,2))",
+		"INSERT INTO orders VALUES (1, 12.50), (2, 99.99)",
+		"CREATE OR REPLACE SECURE VIEW large_orders AS SELECT id FROM orders WHERE amount > 50",
+		"CREATE MATERIALIZED VIEW IF NOT EXISTS order_totals COMMENT = 'Totals' AS SELECT SUM(amount) AS total FROM orders",
+		"CREATE VIEW order_ids AS SELECT id FROM orders",
+	}
+	for _, sql := range statements {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	// A materialized view is never stale
+	if _, err := executor.Execute(ctx, "INSERT INTO orders VALUES (3, 100)"); err != nil {
+		t.Fatalf("Execute() insert error = %v", err)
+	}
+	result, err := executor.Query(ctx, "SELECT total::VARCHAR FROM order_totals")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{"212.49"}}, result.Rows); diff != "" {
+		t.Errorf("materialized view rows mismatch (-want +got):\n%s", diff)
+	}
+
+	schema, err := repo.GetSchemaByName(ctx, db.ID, "PUBLIC")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	totals, err := repo.GetTableByName(ctx, schema.ID, "ORDER_TOTALS")
+	if err != nil || totals.TableType != "VIEW" || !totals.Materialized || totals.Secure {
+		t.Errorf("GetTableByName() = %+v, %v, want a materialized VIEW", totals, err)
+	}
+
+	result, err = executor.Query(ctx, "SHOW VIEWS LIKE '%order%'")
+	if err != nil {
+		t.Fatalf("Query() SHOW VIEWS error = %v", err)
+	}
+	var views [][]interface{}
+	for _, row := range result.Rows {
+		// name, schema_name, comment, is_secure, is_materialized
+		views = append(views, []interface{}{row[1], row[4], row[6], row[8], row[9]})
+	}
+	want := [][]interface{}{
+		{"large_orders", "PUBLIC", "", "true", "false"},
+		{"order_ids", "PUBLIC", "", "false", "false"},
+		{"order_totals", "PUBLIC", "Totals", "false", "true"},
+	}
+	if diff := cmp.Diff(want, views); diff != "" {
+		t.Errorf("SHOW VIEWS mismatch (-want +got):\n%s", diff)
+	}
+	result, err = executor.Query(ctx, "SHOW TERSE VIEWS IN SCHEMA public")
+	if err != nil {
+		t.Fatalf("Query() SHOW TERSE VIEWS error = %v", err)
+	}
+	var kinds [][]interface{}
+	for _, row := range result.Rows {
+		kinds = append(kinds, []interface{}{row[1], row[2]})
+	}
+	wantKinds := [][]interface{}{{"large_orders", "VIEW"}, {"order_ids", "VIEW"}, {"order_totals", "MATERIALIZED_VIEW"}}
+	if diff := cmp.Diff(wantKinds, kinds); diff != "" {
+		t.Errorf("SHOW TERSE VIEWS mismatch (-want +got):\n%s", diff)
+	}
+
+	result, err = executor.Query(ctx, "SELECT TABLE_CATALOG, TABLE_SCHEMA, TABLE_NAME, IS_SECURE, COMMENT FROM TEST_DB.INFORMATION_SCHEMA.VIEWS ORDER BY TABLE_NAME")
+	if err != nil {
+		t.Fatalf("Query() INFORMATION_SCHEMA.VIEWS error = %v", err)
+	}
+	want = [][]interface{}{
+		{"TEST_DB", "PUBLIC", "large_orders", "YES", nil},
+		{"TEST_DB", "PUBLIC", "order_ids", "NO", nil},
+		{"TEST_DB", "PUBLIC", "order_totals", "NO", "Totals"},
+	}
+	if diff := cmp.Diff(want, result.Rows); diff != "" {
+		t.Errorf("INFORMATION_SCHEMA.VIEWS mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := executor.Execute(ctx, "DROP MATERIALIZED VIEW order_totals"); err != nil {
+		t.Fatalf("Execute() drop error = %v", err)
+	}
+	if _, err := repo.GetTableByName(ctx, schema.ID, "ORDER_TOTALS"); err == nil {
+		t.Error("GetTableByName() after DROP MATERIALIZED VIEW error = nil, want error")
+	}
+}
+
+// TestDropViewName tests extracting the view name from DROP VIEW statements.
+func TestDropViewName(t *testing.T) {
+	tests := []struct {
+		sql    string
+		want   string
+		wantOK bool
+	}{
+		{sql: "DROP VIEW v", want: "v", wantOK: true},
+		{sql: "drop view if exists db.s.v;", want: "db.s.v", wantOK: true},
+		{sql: "DROP MATERIALIZED VIEW IF EXISTS mv", want: "mv", wantOK: true},
+		{sql: "DROP TABLE t", wantOK: false},
+		{sql: "DROP VIEW", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			got, ok := dropViewName(tt.sql)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("dropViewName(%q) = %q, %v, want %q, %v", tt.sql, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
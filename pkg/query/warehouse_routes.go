@@ -0,0 +1,34 @@
+package query
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+)
+
+// WithWarehouseRoute runs the statements of sessions using warehouse on the
+// DuckDB instance of mgr, instead of the executor's own. Use this in embedded
+// setups to isolate a workload, such as a heavy-load warehouse, on its own
+// instance. Metadata stays on the executor's instance, so the routed instance
+// must hold the DuckDB schemas its statements use. Warehouse names are matched
+// case-insensitively.
+func WithWarehouseRoute(warehouse string, mgr *connection.Manager) ExecutorOption {
+	return func(e *Executor) {
+		if e.warehouseRoutes == nil {
+			e.warehouseRoutes = make(map[string]*connection.Manager)
+		}
+		e.warehouseRoutes[strings.ToUpper(warehouse)] = mgr
+	}
+}
+
+// manager returns the connection manager statements of the session carried by
+// ctx run on: the one routed for the session's warehouse, or the executor's.
+func (e *Executor) manager(ctx context.Context) *connection.Manager {
+	if warehouse := SessionInfoFromContext(ctx).Warehouse; warehouse != "" {
+		if mgr, ok := e.warehouseRoutes[strings.ToUpper(warehouse)]; ok {
+			return mgr
+		}
+	}
+	return e.mgr
+}
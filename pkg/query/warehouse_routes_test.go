@@ -0,0 +1,49 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+)
+
+func TestExecutor_WarehouseRoute(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	executor, _ := setupTestExecutor(t, WithWarehouseRoute("heavy_wh", connection.NewManager(db)))
+	heavy := ContextWithSessionInfo(context.Background(), SessionInfo{Warehouse: "HEAVY_WH"})
+	other := ContextWithSessionInfo(context.Background(), SessionInfo{Warehouse: "COMPUTE_WH"})
+
+	if _, err := executor.Execute(heavy, "CREATE TABLE heavy_rows (id INTEGER)"); err != nil {
+		t.Fatalf("Execute() on routed warehouse error = %v", err)
+	}
+	if _, err := executor.Execute(heavy, "INSERT INTO heavy_rows VALUES (1), (2)"); err != nil {
+		t.Fatalf("Execute() on routed warehouse error = %v", err)
+	}
+
+	result, err := executor.Query(heavy, "SELECT COUNT(*) FROM heavy_rows")
+	if err != nil {
+		t.Fatalf("Query() on routed warehouse error = %v", err)
+	}
+	if diff := cmp.Diff([][]interface{}{{int64(2)}}, result.Rows); diff != "" {
+		t.Errorf("Query() rows mismatch (-want +got):\n%s", diff)
+	}
+
+	// The routed instance holds the table; the executor's own does not
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM heavy_rows").Scan(&count); err != nil || count != 2 {
+		t.Errorf("routed instance has %d rows, err = %v, want 2 rows", count, err)
+	}
+	if _, err := executor.Query(other, "SELECT COUNT(*) FROM heavy_rows"); err == nil {
+		t.Error("Query() on an unrouted warehouse found the routed table, want error")
+	}
+	if _, err := executor.Query(context.Background(), "SELECT COUNT(*) FROM heavy_rows"); err == nil {
+		t.Error("Query() without a warehouse found the routed table, want error")
+	}
+}
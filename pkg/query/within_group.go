@@ -0,0 +1,119 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// withinGroupMarker marks the ordering of an aggregate, fn(args) WITHIN GROUP
+// (ORDER BY ...), which the parser rejects, as
+// __WITHIN_GROUP__(fn(args), 'ORDER BY ...').
+const withinGroupMarker = "__WITHIN_GROUP__"
+
+// orderedAggregates are the aggregates whose WITHIN GROUP clause orders the
+// values they collect. DuckDB takes the ordering inside their arguments. Other
+// aggregates, such as PERCENTILE_CONT, are left to DuckDB, which reads their
+// WITHIN GROUP clause itself.
+var orderedAggregates = []string{"ARRAY_AGG", "ARRAYAGG", "LISTAGG"}
+
+// markWithinGroup rewrites the WITHIN GROUP clauses of the ordered aggregates
+// of sql into __WITHIN_GROUP__ calls, which the parser accepts. Clauses inside
+// strings, quoted identifiers, and comments are left alone.
+func markWithinGroup(sql string) string {
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\'' || c == '"' {
+			i = skipQuoted(sql, i, c)
+			continue
+		}
+		if end, ok := commentEnd(sql, i); ok {
+			i = end - 1
+			continue
+		}
+		name := orderedAggregateAt(sql, i)
+		if name == "" {
+			continue
+		}
+		open := i + len(name)
+		for open < len(sql) && isSpace(sql[open]) {
+			open++
+		}
+		if open == len(sql) || sql[open] != '(' {
+			continue
+		}
+		closing := matchingParen(sql[open:])
+		if closing < 0 {
+			return sql
+		}
+		closing += open
+		orderStart, orderEnd, ok := withinGroupAt(sql, closing+1)
+		if !ok {
+			continue
+		}
+		// Aggregates inside the arguments are marked first
+		call := markWithinGroup(sql[i : closing+1])
+		order := strings.TrimSpace(sql[orderStart:orderEnd])
+		replacement := fmt.Sprintf("%s(%s, %s)", withinGroupMarker, call, quoteLiteral(order))
+		sql = sql[:i] + replacement + sql[orderEnd+1:]
+		i += len(replacement) - 1
+	}
+	return sql
+}
+
+// orderedAggregateAt returns the name of the ordered aggregate that starts at
+// sql[i], or "" if there is none.
+func orderedAggregateAt(sql string, i int) string {
+	for _, name := range orderedAggregates {
+		if keywordAt(sql, i, name) {
+			return sql[i : i+len(name)]
+		}
+	}
+	return ""
+}
+
+// withinGroupAt reads a WITHIN GROUP (ORDER BY ...) clause after sql[start],
+// and returns the bounds of its ORDER BY and the index of the parenthesis
+// closing it.
+func withinGroupAt(sql string, start int) (orderStart, orderEnd int, ok bool) {
+	i := start
+	for _, keyword := range []string{"WITHIN", "GROUP"} {
+		for i < len(sql) && isSpace(sql[i]) {
+			i++
+		}
+		if !keywordAt(sql, i, keyword) {
+			return 0, 0, false
+		}
+		i += len(keyword)
+	}
+	for i < len(sql) && isSpace(sql[i]) {
+		i++
+	}
+	if i == len(sql) || sql[i] != '(' {
+		return 0, 0, false
+	}
+	closing := matchingParen(sql[i:])
+	if closing < 0 {
+		return 0, 0, false
+	}
+	return i + 1, i + closing, true
+}
+
+// transformWithinGroup resolves the __WITHIN_GROUP__ markers, moving the
+// ORDER BY into the arguments of the aggregate: ARRAY_AGG(x) WITHIN GROUP
+// (ORDER BY y) becomes ARRAY_AGG(x ORDER BY y). It runs before the markers of
+// the aggregates themselves are resolved.
+func (t *Translator) transformWithinGroup(sql string) string {
+	return t.transformMarkedFunction(sql, withinGroupMarker, func(args string) string {
+		parts := splitFunctionArgs(args, 2)
+		if len(parts) != 2 {
+			return args
+		}
+		call, literal := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !strings.HasSuffix(call, ")") || len(literal) < 2 || literal[0] != '\'' || literal[len(literal)-1] != '\'' {
+			return call
+		}
+		order := strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+		order = strings.ReplaceAll(order, `\'`, "'")
+		return call[:len(call)-1] + " " + order + ")"
+	})
+}
@@ -5,7 +5,10 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,14 +19,23 @@ type Session struct {
 	Token                   string
 	MasterToken             string
 	Username                string
+	Role                    string
 	Database                string
 	CurrentSchema           string
+	Warehouse               string
 	CreatedAt               time.Time
 	LastAccessedAt          time.Time
 	ExpiresAt               time.Time
 	ValidityInSeconds       int64
 	MasterValidityInSeconds int64
 	Parameters              map[string]interface{}
+	// ClientAppID and ClientAppVersion identify the driver that logged in,
+	// such as Go 1.11.0.
+	ClientAppID      string
+	ClientAppVersion string
+	// ClientEnvironment is the CLIENT_ENVIRONMENT the driver sent at login,
+	// such as its APPLICATION, OS, and OS_VERSION.
+	ClientEnvironment map[string]interface{}
 }
 
 // Manager manages Snowflake sessions.
@@ -33,15 +45,113 @@ type Manager struct {
 	sessionTimeout time.Duration
 	mu             sync.RWMutex
 	store          *Store // optional persistent storage
+	instanceID     string
+	onClose        []func(*Session)
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithStore keeps sessions in store, so that replicas sharing it accept the
+// tokens each of them issues. The store is the source of truth: sessions
+// are looked up in it on every use, and their changes saved to it.
+func WithStore(store *Store) ManagerOption {
+	return func(m *Manager) {
+		m.store = store
+	}
+}
+
+// WithInstanceID prefixes the tokens the Manager issues with id and a dot,
+// so that a load balancer can route each request to the replica that issued
+// its token.
+func WithInstanceID(id string) ManagerOption {
+	return func(m *Manager) {
+		m.instanceID = id
+	}
 }
 
 // NewManager creates a new session manager.
-func NewManager(sessionTimeout time.Duration) *Manager {
-	return &Manager{
+func NewManager(sessionTimeout time.Duration, opts ...ManagerOption) *Manager {
+	m := &Manager{
 		sessions:       make(map[string]*Session),
 		masterTokens:   make(map[string]*Session),
 		sessionTimeout: sessionTimeout,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// InstanceID returns the ID prefixing the tokens the Manager issues, or ""
+// when they have none.
+func (m *Manager) InstanceID() string {
+	return m.instanceID
+}
+
+// InstanceOf returns the instance ID a token was issued with, or "" when it
+// has none.
+func InstanceOf(token string) string {
+	if i := strings.LastIndexByte(token, '.'); i > 0 {
+		return token[:i]
+	}
+	return ""
+}
+
+// newToken generates a token, prefixed with the Manager's instance ID.
+func (m *Manager) newToken() (string, error) {
+	token, err := generateToken()
+	if err != nil || m.instanceID == "" {
+		return token, err
+	}
+	return m.instanceID + "." + token, nil
+}
+
+// lookupLocked returns the session of token, loading it from the store when
+// there is one. It must be called with m.mu held for writing.
+func (m *Manager) lookupLocked(ctx context.Context, token string) (*Session, error) {
+	if m.store == nil {
+		session, exists := m.sessions[token]
+		if !exists {
+			return nil, ErrSessionNotFound
+		}
+		return session, nil
+	}
+	session, err := m.store.Load(ctx, token)
+	if err != nil {
+		if cached, exists := m.sessions[token]; exists && errors.Is(err, ErrSessionNotFound) {
+			// Closed or renewed by another replica
+			delete(m.sessions, token)
+			delete(m.masterTokens, cached.MasterToken)
+		}
+		return nil, err
+	}
+	return m.cacheLocked(session), nil
+}
+
+// cacheLocked keeps a session loaded from the store in memory, for
+// GetSessionByID and the OnClose hooks, and returns it. It must be called with
+// m.mu held for writing.
+func (m *Manager) cacheLocked(session *Session) *Session {
+	session.ValidityInSeconds = int64(m.sessionTimeout.Seconds())
+	session.MasterValidityInSeconds = int64(m.sessionTimeout.Seconds()) * 4
+	if cached, exists := m.masterTokens[session.MasterToken]; exists && cached.Token != session.Token {
+		delete(m.sessions, cached.Token)
+	}
+	m.sessions[session.Token] = session
+	m.masterTokens[session.MasterToken] = session
+	return session
+}
+
+// saveLocked saves a changed session to the store, when there is one.
+func (m *Manager) saveLocked(ctx context.Context, session *Session) error {
+	if m.store == nil {
+		return nil
+	}
+	if err := m.store.Save(ctx, session); err != nil {
+		return fmt.Errorf("failed to persist session: %w", err)
+	}
+	return nil
 }
 
 // CreateSession creates a new session with a unique token.
@@ -60,13 +170,13 @@ func (m *Manager) CreateSession(ctx context.Context, username, database, schema
 	sessionID := time.Now().UnixNano()
 
 	// Generate secure random token
-	token, err := generateToken()
+	token, err := m.newToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Generate master token
-	masterToken, err := generateToken()
+	masterToken, err := m.newToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate master token: %w", err)
 	}
@@ -109,7 +219,7 @@ func (m *Manager) CreateSession(ctx context.Context, username, database, schema
 
 // ValidateSession validates a session token and returns the session if valid.
 // It also updates the LastAccessedAt timestamp.
-func (m *Manager) ValidateSession(_ context.Context, token string) (*Session, error) {
+func (m *Manager) ValidateSession(ctx context.Context, token string) (*Session, error) {
 	if token == "" {
 		return nil, fmt.Errorf("token cannot be empty")
 	}
@@ -117,10 +227,13 @@ func (m *Manager) ValidateSession(_ context.Context, token string) (*Session, er
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	session, exists := m.sessions[token]
-	if !exists {
+	session, err := m.lookupLocked(ctx, token)
+	if errors.Is(err, ErrSessionNotFound) {
 		return nil, fmt.Errorf("invalid session token")
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if session is expired
 	if time.Now().After(session.ExpiresAt) {
@@ -135,18 +248,59 @@ func (m *Manager) ValidateSession(_ context.Context, token string) (*Session, er
 	return session.Copy(), nil
 }
 
+// GetSessionByID returns the unexpired session with the given ID, without
+// updating its LastAccessedAt timestamp. Sessions of other replicas are
+// found in the store.
+func (m *Manager) GetSessionByID(ctx context.Context, id int64) (*Session, error) {
+	sessions, err := m.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		if session.ID == id {
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("session %d not found", id)
+}
+
+// OnClose registers a function called with each session that is closed or
+// removed after expiring, after the Manager has forgotten it.
+func (m *Manager) OnClose(hook func(*Session)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onClose = append(m.onClose, hook)
+}
+
+// closed calls the OnClose hooks for sessions. It must be called without m.mu held.
+func (m *Manager) closed(sessions ...*Session) {
+	m.mu.RLock()
+	hooks := m.onClose
+	m.mu.RUnlock()
+	for _, session := range sessions {
+		for _, hook := range hooks {
+			hook(session)
+		}
+	}
+}
+
 // CloseSession closes a session (logout).
 func (m *Manager) CloseSession(ctx context.Context, token string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	// Get session to find master token
-	session, exists := m.sessions[token]
+	session, err := m.lookupLocked(ctx, token)
+	exists := err == nil
 	if exists {
 		// Delete both session token and master token
 		delete(m.sessions, token)
 		delete(m.masterTokens, session.MasterToken)
 	}
+	m.mu.Unlock()
+
+	if exists {
+		m.closed(session)
+	}
 
 	// Delete from store if available
 	if m.store != nil {
@@ -159,14 +313,17 @@ func (m *Manager) CloseSession(ctx context.Context, token string) error {
 }
 
 // UpdateSessionContext updates the database and/or schema for a session.
-func (m *Manager) UpdateSessionContext(_ context.Context, token, database, schema string) error {
+func (m *Manager) UpdateSessionContext(ctx context.Context, token, database, schema string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	session, exists := m.sessions[token]
-	if !exists {
+	session, err := m.lookupLocked(ctx, token)
+	if errors.Is(err, ErrSessionNotFound) {
 		return fmt.Errorf("invalid session token")
 	}
+	if err != nil {
+		return err
+	}
 
 	// Update database if provided
 	if database != "" {
@@ -180,29 +337,220 @@ func (m *Manager) UpdateSessionContext(_ context.Context, token, database, schem
 
 	session.LastAccessedAt = time.Now()
 
-	return nil
+	return m.saveLocked(ctx, session)
 }
 
-// CleanupExpiredSessions removes all expired sessions and returns the count.
-func (m *Manager) CleanupExpiredSessions(_ context.Context) int {
+// UpdateSessionRole sets the session's current role.
+// Role names are stored uppercase, matching Snowflake's unquoted identifiers.
+func (m *Manager) UpdateSessionRole(ctx context.Context, token, role string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	session, err := m.lookupLocked(ctx, token)
+	if errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("invalid session token")
+	}
+	if err != nil {
+		return err
+	}
+
+	session.Role = strings.ToUpper(role)
+	session.LastAccessedAt = time.Now()
+
+	return m.saveLocked(ctx, session)
+}
+
+// UpdateSessionWarehouse sets the session's current warehouse.
+// Warehouse names are stored uppercase, matching Snowflake's unquoted identifiers.
+func (m *Manager) UpdateSessionWarehouse(ctx context.Context, token, warehouse string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.lookupLocked(ctx, token)
+	if errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("invalid session token")
+	}
+	if err != nil {
+		return err
+	}
+
+	session.Warehouse = strings.ToUpper(warehouse)
+	session.LastAccessedAt = time.Now()
+
+	return m.saveLocked(ctx, session)
+}
+
+// UpdateSessionUse sets the current database, schema, warehouse, and role of
+// the session with the given ID, as USE statements select them. Empty values
+// are left unchanged.
+func (m *Manager) UpdateSessionUse(ctx context.Context, id int64, database, schema, warehouse, role string) error {
+	found, err := m.GetSessionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.lookupLocked(ctx, found.Token)
+	if errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("session %d not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if database != "" {
+		session.Database = database
+	}
+	if schema != "" {
+		session.CurrentSchema = schema
+	}
+	if warehouse != "" {
+		session.Warehouse = strings.ToUpper(warehouse)
+	}
+	if role != "" {
+		session.Role = strings.ToUpper(role)
+	}
+	session.LastAccessedAt = time.Now()
+
+	return m.saveLocked(ctx, session)
+}
+
+// UpdateSessionParameters sets session parameters for a session.
+// Parameter names are stored uppercase, matching Snowflake's case-insensitive names.
+func (m *Manager) UpdateSessionParameters(ctx context.Context, token string, params map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.lookupLocked(ctx, token)
+	if errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("invalid session token")
+	}
+	if err != nil {
+		return err
+	}
+
+	for name, value := range params {
+		session.Parameters[strings.ToUpper(name)] = value
+	}
+
+	session.LastAccessedAt = time.Now()
+
+	return m.saveLocked(ctx, session)
+}
+
+// AlterSessionParameters sets and unsets parameters of the session with the
+// given ID, as ALTER SESSION does. Unset parameters return to their defaults.
+func (m *Manager) AlterSessionParameters(ctx context.Context, id int64, set map[string]interface{}, unset []string) error {
+	found, err := m.GetSessionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.lookupLocked(ctx, found.Token)
+	if errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("session %d not found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	for name, value := range set {
+		session.Parameters[strings.ToUpper(name)] = value
+	}
+	for _, name := range unset {
+		delete(session.Parameters, strings.ToUpper(name))
+	}
+	session.LastAccessedAt = time.Now()
+
+	return m.saveLocked(ctx, session)
+}
+
+// UpdateSessionClient records the driver that logged in to a session and the
+// environment it reported.
+func (m *Manager) UpdateSessionClient(ctx context.Context, token, appID, appVersion string, environment map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.lookupLocked(ctx, token)
+	if errors.Is(err, ErrSessionNotFound) {
+		return fmt.Errorf("invalid session token")
+	}
+	if err != nil {
+		return err
+	}
+
+	session.ClientAppID = appID
+	session.ClientAppVersion = appVersion
+	session.ClientEnvironment = make(map[string]interface{}, len(environment))
+	for name, value := range environment {
+		session.ClientEnvironment[name] = value
+	}
+
+	return m.saveLocked(ctx, session)
+}
+
+// ListSessions returns the unexpired sessions, ordered by ID. Sessions of
+// other replicas are listed from the store.
+func (m *Manager) ListSessions(ctx context.Context) ([]*Session, error) {
+	var sessions []*Session
+	if m.store != nil {
+		stored, err := m.store.ListAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sessions = stored
+	} else {
+		m.mu.RLock()
+		for _, session := range m.sessions {
+			sessions = append(sessions, session.Copy())
+		}
+		m.mu.RUnlock()
+	}
+
 	now := time.Now()
-	count := 0
+	active := sessions[:0]
+	for _, session := range sessions {
+		if now.Before(session.ExpiresAt) {
+			active = append(active, session)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	return active, nil
+}
+
+// CleanupExpiredSessions removes all expired sessions and returns the count.
+// Expired sessions of other replicas are removed from the store.
+func (m *Manager) CleanupExpiredSessions(ctx context.Context) int {
+	m.mu.Lock()
+
+	now := time.Now()
+	var expired []*Session
 
 	for token, session := range m.sessions {
 		if now.After(session.ExpiresAt) {
 			delete(m.sessions, token)
-			count++
+			expired = append(expired, session)
+		}
+	}
+	m.mu.Unlock()
+
+	if m.store != nil {
+		if _, err := m.store.DeleteExpired(ctx); err != nil {
+			return len(expired)
 		}
 	}
 
-	return count
+	m.closed(expired...)
+	return len(expired)
 }
 
 // RenewToken generates a new session token using master token
-func (m *Manager) RenewToken(_ context.Context, masterToken string) (*Session, string, error) {
+func (m *Manager) RenewToken(ctx context.Context, masterToken string) (*Session, string, error) {
 	if masterToken == "" {
 		return nil, "", fmt.Errorf("master token cannot be empty")
 	}
@@ -211,6 +559,17 @@ func (m *Manager) RenewToken(_ context.Context, masterToken string) (*Session, s
 	defer m.mu.Unlock()
 
 	session, exists := m.masterTokens[masterToken]
+	if m.store != nil {
+		stored, err := m.store.LoadByMasterToken(ctx, masterToken)
+		switch {
+		case err == nil:
+			session, exists = m.cacheLocked(stored), true
+		case errors.Is(err, ErrSessionNotFound):
+			exists = false
+		default:
+			return nil, "", err
+		}
+	}
 	if !exists {
 		return nil, "", fmt.Errorf("invalid master token")
 	}
@@ -223,26 +582,36 @@ func (m *Manager) RenewToken(_ context.Context, masterToken string) (*Session, s
 		return nil, "", fmt.Errorf("master token expired")
 	}
 
-	// Revoke old session token
-	delete(m.sessions, session.Token)
-
 	// Generate new session token
-	newToken, err := generateToken()
+	newToken, err := m.newToken()
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to generate new token: %w", err)
 	}
 
+	// Revoke old session token
+	oldToken := session.Token
+	delete(m.sessions, oldToken)
+
 	session.Token = newToken
 	session.LastAccessedAt = time.Now()
 	session.ExpiresAt = time.Now().Add(m.sessionTimeout)
 
 	m.sessions[newToken] = session
 
+	if m.store != nil {
+		if err := m.store.Delete(ctx, oldToken); err != nil {
+			return nil, "", fmt.Errorf("failed to delete session from store: %w", err)
+		}
+		if err := m.saveLocked(ctx, session); err != nil {
+			return nil, "", err
+		}
+	}
+
 	return session.Copy(), newToken, nil
 }
 
 // UpdateLastAccessed updates the last accessed time for a session (heartbeat)
-func (m *Manager) UpdateLastAccessed(_ context.Context, token string) error {
+func (m *Manager) UpdateLastAccessed(ctx context.Context, token string) error {
 	if token == "" {
 		return fmt.Errorf("token cannot be empty")
 	}
@@ -250,9 +619,9 @@ func (m *Manager) UpdateLastAccessed(_ context.Context, token string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	session, exists := m.sessions[token]
-	if !exists {
-		return fmt.Errorf("session not found")
+	session, err := m.lookupLocked(ctx, token)
+	if err != nil {
+		return err
 	}
 
 	// Check if session is expired
@@ -277,20 +646,32 @@ func (s *Session) Copy() *Session {
 	for k, v := range s.Parameters {
 		params[k] = v
 	}
+	var environment map[string]interface{}
+	if s.ClientEnvironment != nil {
+		environment = make(map[string]interface{}, len(s.ClientEnvironment))
+		for k, v := range s.ClientEnvironment {
+			environment[k] = v
+		}
+	}
 
 	return &Session{
 		ID:                      s.ID,
 		Token:                   s.Token,
 		MasterToken:             s.MasterToken,
 		Username:                s.Username,
+		Role:                    s.Role,
 		Database:                s.Database,
 		CurrentSchema:           s.CurrentSchema,
+		Warehouse:               s.Warehouse,
 		CreatedAt:               s.CreatedAt,
 		LastAccessedAt:          s.LastAccessedAt,
 		ExpiresAt:               s.ExpiresAt,
 		ValidityInSeconds:       s.ValidityInSeconds,
 		MasterValidityInSeconds: s.MasterValidityInSeconds,
 		Parameters:              params,
+		ClientAppID:             s.ClientAppID,
+		ClientAppVersion:        s.ClientAppVersion,
+		ClientEnvironment:       environment,
 	}
 }
 
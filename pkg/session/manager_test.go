@@ -326,6 +326,203 @@ func TestManager_UpdateSessionContext(t *testing.T) {
 	}
 }
 
+// TestManager_UpdateSessionParameters tests setting session parameters.
+func TestManager_UpdateSessionParameters(t *testing.T) {
+	mgr := NewManager(1 * time.Hour)
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	err = mgr.UpdateSessionParameters(ctx, session.Token, map[string]interface{}{"week_start": "7"})
+	if err != nil {
+		t.Fatalf("UpdateSessionParameters() error = %v", err)
+	}
+
+	updatedSession, err := mgr.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("Failed to validate session: %v", err)
+	}
+	if updatedSession.Parameters["WEEK_START"] != "7" {
+		t.Errorf("Expected WEEK_START 7, got %v", updatedSession.Parameters["WEEK_START"])
+	}
+
+	err = mgr.UpdateSessionParameters(ctx, "invalid-token", map[string]interface{}{"WEEK_START": "1"})
+	if err == nil {
+		t.Error("Expected error for invalid token")
+	}
+}
+
+// TestManager_UpdateSessionRole tests setting the session role.
+func TestManager_UpdateSessionRole(t *testing.T) {
+	mgr := NewManager(1 * time.Hour)
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := mgr.UpdateSessionRole(ctx, session.Token, "analyst"); err != nil {
+		t.Fatalf("UpdateSessionRole() error = %v", err)
+	}
+
+	updatedSession, err := mgr.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("Failed to validate session: %v", err)
+	}
+	if updatedSession.Role != "ANALYST" {
+		t.Errorf("Expected role ANALYST, got %q", updatedSession.Role)
+	}
+
+	if err := mgr.UpdateSessionRole(ctx, "invalid-token", "ANALYST"); err == nil {
+		t.Error("Expected error for invalid token")
+	}
+}
+
+// TestManager_UpdateSessionWarehouse tests setting the session warehouse.
+func TestManager_UpdateSessionWarehouse(t *testing.T) {
+	mgr := NewManager(1 * time.Hour)
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := mgr.UpdateSessionWarehouse(ctx, session.Token, "heavy_wh"); err != nil {
+		t.Fatalf("UpdateSessionWarehouse() error = %v", err)
+	}
+
+	updatedSession, err := mgr.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("Failed to validate session: %v", err)
+	}
+	if updatedSession.Warehouse != "HEAVY_WH" {
+		t.Errorf("Expected warehouse HEAVY_WH, got %q", updatedSession.Warehouse)
+	}
+
+	if err := mgr.UpdateSessionWarehouse(ctx, "invalid-token", "HEAVY_WH"); err == nil {
+		t.Error("Expected error for invalid token")
+	}
+}
+
+// TestManager_UpdateSessionUse tests setting the session context by session ID.
+func TestManager_UpdateSessionUse(t *testing.T) {
+	mgr := NewManager(1 * time.Hour)
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := mgr.UpdateSessionUse(ctx, session.ID, "SALES", "RAW", "", "analyst"); err != nil {
+		t.Fatalf("UpdateSessionUse() error = %v", err)
+	}
+	if err := mgr.UpdateSessionUse(ctx, session.ID, "", "", "heavy_wh", ""); err != nil {
+		t.Fatalf("UpdateSessionUse() error = %v", err)
+	}
+
+	updated, err := mgr.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("Failed to validate session: %v", err)
+	}
+	got := []string{updated.Database, updated.CurrentSchema, updated.Warehouse, updated.Role}
+	if diff := cmp.Diff([]string{"SALES", "RAW", "HEAVY_WH", "ANALYST"}, got); diff != "" {
+		t.Errorf("session context mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := mgr.UpdateSessionUse(ctx, session.ID+1, "SALES", "", "", ""); err == nil {
+		t.Error("Expected error for an unknown session")
+	}
+}
+
+// TestManager_AlterSessionParameters tests setting and unsetting session
+// parameters by session ID.
+func TestManager_AlterSessionParameters(t *testing.T) {
+	mgr := NewManager(1 * time.Hour)
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	set := map[string]interface{}{"query_tag": "etl", "TIMEZONE": "Asia/Tokyo"}
+	if err := mgr.AlterSessionParameters(ctx, session.ID, set, nil); err != nil {
+		t.Fatalf("AlterSessionParameters() error = %v", err)
+	}
+	if err := mgr.AlterSessionParameters(ctx, session.ID, nil, []string{"timezone"}); err != nil {
+		t.Fatalf("AlterSessionParameters() error = %v", err)
+	}
+
+	updated, err := mgr.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("Failed to validate session: %v", err)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"QUERY_TAG": "etl"}, updated.Parameters); diff != "" {
+		t.Errorf("session parameters mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := mgr.AlterSessionParameters(ctx, session.ID+1, set, nil); err == nil {
+		t.Error("Expected error for an unknown session")
+	}
+}
+
+func TestManager_GetSessionByID(t *testing.T) {
+	mgr := NewManager(1 * time.Hour)
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	found, err := mgr.GetSessionByID(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetSessionByID() error = %v", err)
+	}
+	if found.Token != session.Token || found.Username != "user1" {
+		t.Errorf("GetSessionByID() = %s (%s), want %s (user1)", found.Token, found.Username, session.Token)
+	}
+
+	if err := mgr.CloseSession(ctx, session.Token); err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if _, err := mgr.GetSessionByID(ctx, session.ID); err == nil {
+		t.Error("Expected error for a closed session")
+	}
+}
+
+// TestManager_WithInstanceID tests that tokens carry the issuing instance.
+func TestManager_WithInstanceID(t *testing.T) {
+	mgr := NewManager(1*time.Hour, WithInstanceID("replica-2"))
+	ctx := context.Background()
+
+	session, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	_, renewed, err := mgr.RenewToken(ctx, session.MasterToken)
+	if err != nil {
+		t.Fatalf("RenewToken() error = %v", err)
+	}
+
+	got := []string{InstanceOf(session.Token), InstanceOf(session.MasterToken), InstanceOf(renewed), mgr.InstanceID()}
+	if diff := cmp.Diff([]string{"replica-2", "replica-2", "replica-2", "replica-2"}, got); diff != "" {
+		t.Errorf("instance mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := mgr.ValidateSession(ctx, renewed); err != nil {
+		t.Errorf("ValidateSession() error = %v", err)
+	}
+	if instance := InstanceOf(session.Token[len("replica-2."):]); instance != "" {
+		t.Errorf("InstanceOf() of a token without instance = %q, want empty", instance)
+	}
+}
+
 // TestManager_ConcurrentSessions tests concurrent session operations.
 func TestManager_ConcurrentSessions(t *testing.T) {
 	mgr := NewManager(1 * time.Hour)
@@ -430,6 +627,39 @@ func TestManager_CleanupExpiredSessions(t *testing.T) {
 	}
 }
 
+// TestManager_OnClose tests that close hooks see closed and expired sessions.
+func TestManager_OnClose(t *testing.T) {
+	mgr := NewManager(100 * time.Millisecond)
+	ctx := context.Background()
+
+	var closed []int64
+	mgr.OnClose(func(session *Session) {
+		closed = append(closed, session.ID)
+	})
+
+	loggedOut, err := mgr.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	expired, err := mgr.CreateSession(ctx, "user2", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := mgr.CloseSession(ctx, loggedOut.Token); err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if err := mgr.CloseSession(ctx, "non-existent-token"); err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	mgr.CleanupExpiredSessions(ctx)
+
+	if diff := cmp.Diff([]int64{loggedOut.ID, expired.ID}, closed); diff != "" {
+		t.Errorf("closed sessions mismatch (-want +got):\n%s", diff)
+	}
+}
+
 // TestSession_Copy tests session deep copy functionality.
 func TestSession_Copy(t *testing.T) {
 	original := &Session{
@@ -439,6 +669,7 @@ func TestSession_Copy(t *testing.T) {
 		Username:                "user1",
 		Database:                "DB1",
 		CurrentSchema:           "SCHEMA1",
+		Warehouse:               "COMPUTE_WH",
 		CreatedAt:               time.Now(),
 		LastAccessedAt:          time.Now(),
 		ExpiresAt:               time.Now().Add(1 * time.Hour),
@@ -4,13 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 )
 
-// Store provides persistent storage for sessions using DuckDB.
+// ErrSessionNotFound is returned when a session is not in the store.
+var ErrSessionNotFound = errors.New("session not found")
+
+// sessionColumns are the columns of _sessions read into a Session by scanSession.
+const sessionColumns = `token, id, master_token, username, role, database_name, current_schema,
+	warehouse, created_at, last_accessed_at, expires_at, parameters,
+	client_app_id, client_app_version, client_environment`
+
+// Store provides persistent storage for sessions using DuckDB. Replicas
+// sharing a DuckDB target share the sessions of its store, so a token issued
+// by one replica is accepted by the others.
 type Store struct {
 	mgr *connection.Manager
 }
@@ -29,7 +40,8 @@ func NewStore(mgr *connection.Manager) (*Store, error) {
 	return store, nil
 }
 
-// initTable creates the sessions table if it doesn't exist.
+// initTable creates the sessions table if it doesn't exist, and adds the
+// columns later versions store to a table created by an earlier one.
 func (s *Store) initTable(ctx context.Context) error {
 	createTableSQL := `
 		CREATE TABLE IF NOT EXISTS _sessions (
@@ -45,8 +57,15 @@ func (s *Store) initTable(ctx context.Context) error {
 		)
 	`
 
-	_, err := s.mgr.Exec(ctx, createTableSQL)
-	return err
+	if _, err := s.mgr.Exec(ctx, createTableSQL); err != nil {
+		return err
+	}
+	for _, column := range []string{"master_token", "role", "warehouse", "client_app_id", "client_app_version", "client_environment"} {
+		if _, err := s.mgr.Exec(ctx, "ALTER TABLE _sessions ADD COLUMN IF NOT EXISTS "+column+" VARCHAR"); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Save saves a session to persistent storage.
@@ -56,25 +75,36 @@ func (s *Store) Save(ctx context.Context, session *Session) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal parameters: %w", err)
 	}
+	environmentJSON, err := json.Marshal(session.ClientEnvironment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal client environment: %w", err)
+	}
 
 	// Use INSERT OR REPLACE to handle both insert and update
 	insertSQL := `
 		INSERT OR REPLACE INTO _sessions (
-			token, id, username, database_name, current_schema,
-			created_at, last_accessed_at, expires_at, parameters
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			token, id, master_token, username, role, database_name, current_schema,
+			warehouse, created_at, last_accessed_at, expires_at, parameters,
+			client_app_id, client_app_version, client_environment
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = s.mgr.Exec(ctx, insertSQL,
 		session.Token,
 		session.ID,
+		session.MasterToken,
 		session.Username,
+		session.Role,
 		session.Database,
 		session.CurrentSchema,
+		session.Warehouse,
 		session.CreatedAt,
 		session.LastAccessedAt,
 		session.ExpiresAt,
 		string(paramsJSON),
+		session.ClientAppID,
+		session.ClientAppVersion,
+		string(environmentJSON),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
@@ -85,40 +115,75 @@ func (s *Store) Save(ctx context.Context, session *Session) error {
 
 // Load loads a session from persistent storage.
 func (s *Store) Load(ctx context.Context, token string) (*Session, error) {
-	selectSQL := `
-		SELECT id, username, database_name, current_schema,
-			   created_at, last_accessed_at, expires_at, parameters
-		FROM _sessions
-		WHERE token = ?
-	`
+	return s.loadWhere(ctx, "token", token)
+}
+
+// LoadByMasterToken loads the session with the given master token from
+// persistent storage.
+func (s *Store) LoadByMasterToken(ctx context.Context, masterToken string) (*Session, error) {
+	return s.loadWhere(ctx, "master_token", masterToken)
+}
+
+// loadWhere loads the session whose column has the given value.
+func (s *Store) loadWhere(ctx context.Context, column, value string) (*Session, error) {
+	selectSQL := "SELECT " + sessionColumns + " FROM _sessions WHERE " + column + " = ?"
+
+	session, err := scanSession(s.mgr.QueryRow(ctx, selectSQL, value))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return session, nil
+}
 
+// scanSession scans a row of sessionColumns. Columns added after the table
+// was created are NULL in the rows saved before.
+func scanSession(row interface{ Scan(...any) error }) (*Session, error) {
 	var session Session
+	var masterToken, role, warehouse sql.NullString
+	var clientAppID, clientAppVersion, environmentJSON sql.NullString
 	var paramsJSON string
 
-	err := s.mgr.QueryRow(ctx, selectSQL, token).Scan(
+	err := row.Scan(
+		&session.Token,
 		&session.ID,
+		&masterToken,
 		&session.Username,
+		&role,
 		&session.Database,
 		&session.CurrentSchema,
+		&warehouse,
 		&session.CreatedAt,
 		&session.LastAccessedAt,
 		&session.ExpiresAt,
 		&paramsJSON,
+		&clientAppID,
+		&clientAppVersion,
+		&environmentJSON,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("session not found")
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to load session: %w", err)
+		return nil, err
+	}
+	session.MasterToken = masterToken.String
+	session.Role = role.String
+	session.Warehouse = warehouse.String
+	session.ClientAppID = clientAppID.String
+	session.ClientAppVersion = clientAppVersion.String
+	if environmentJSON.Valid {
+		if err := json.Unmarshal([]byte(environmentJSON.String), &session.ClientEnvironment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal client environment: %w", err)
+		}
 	}
-
-	session.Token = token
 
 	// Deserialize parameters
 	if err := json.Unmarshal([]byte(paramsJSON), &session.Parameters); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
 	}
+	if session.Parameters == nil {
+		session.Parameters = make(map[string]interface{})
+	}
 
 	return &session, nil
 }
@@ -152,12 +217,7 @@ func (s *Store) DeleteExpired(ctx context.Context) (int, error) {
 
 // ListAll returns all sessions from storage.
 func (s *Store) ListAll(ctx context.Context) ([]*Session, error) {
-	selectSQL := `
-		SELECT token, id, username, database_name, current_schema,
-			   created_at, last_accessed_at, expires_at, parameters
-		FROM _sessions
-		ORDER BY created_at DESC
-	`
+	selectSQL := "SELECT " + sessionColumns + " FROM _sessions ORDER BY created_at DESC"
 
 	rows, err := s.mgr.Query(ctx, selectSQL)
 	if err != nil {
@@ -167,30 +227,11 @@ func (s *Store) ListAll(ctx context.Context) ([]*Session, error) {
 
 	var sessions []*Session
 	for rows.Next() {
-		var session Session
-		var paramsJSON string
-
-		err := rows.Scan(
-			&session.Token,
-			&session.ID,
-			&session.Username,
-			&session.Database,
-			&session.CurrentSchema,
-			&session.CreatedAt,
-			&session.LastAccessedAt,
-			&session.ExpiresAt,
-			&paramsJSON,
-		)
+		session, err := scanSession(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session: %w", err)
 		}
-
-		// Deserialize parameters
-		if err := json.Unmarshal([]byte(paramsJSON), &session.Parameters); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
-		}
-
-		sessions = append(sessions, &session)
+		sessions = append(sessions, session)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -202,7 +243,5 @@ func (s *Store) ListAll(ctx context.Context) ([]*Session, error) {
 
 // NewManagerWithStore creates a session manager that uses persistent storage.
 func NewManagerWithStore(sessionTimeout time.Duration, store *Store) *Manager {
-	mgr := NewManager(sessionTimeout)
-	mgr.store = store
-	return mgr
+	return NewManager(sessionTimeout, WithStore(store))
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/google/go-cmp/cmp"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 )
 
@@ -411,3 +412,77 @@ func TestManagerWithStore_Integration(t *testing.T) {
 		t.Error("Session should be removed from store")
 	}
 }
+
+// TestManagerWithStore_SharedAcrossReplicas tests that managers sharing a
+// store accept each other's tokens and see each other's changes.
+func TestManagerWithStore_SharedAcrossReplicas(t *testing.T) {
+	store := setupTestStore(t)
+	replicaA := NewManager(1*time.Hour, WithStore(store))
+	replicaB := NewManager(1*time.Hour, WithStore(store))
+	ctx := context.Background()
+
+	session, err := replicaA.CreateSession(ctx, "user1", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	// A token issued by one replica is valid on the other
+	validated, err := replicaB.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("ValidateSession() on another replica error = %v", err)
+	}
+	if validated.ID != session.ID || validated.Username != "user1" {
+		t.Errorf("ValidateSession() = %d (%s), want %d (user1)", validated.ID, validated.Username, session.ID)
+	}
+
+	// Context changes made through one replica are seen by the other
+	if err := replicaB.UpdateSessionContext(ctx, session.Token, "OTHER_DB", "STAGING"); err != nil {
+		t.Fatalf("UpdateSessionContext() error = %v", err)
+	}
+	if err := replicaB.UpdateSessionRole(ctx, session.Token, "analyst"); err != nil {
+		t.Fatalf("UpdateSessionRole() error = %v", err)
+	}
+	if err := replicaB.UpdateSessionWarehouse(ctx, session.Token, "compute_wh"); err != nil {
+		t.Fatalf("UpdateSessionWarehouse() error = %v", err)
+	}
+	if err := replicaB.UpdateSessionParameters(ctx, session.Token, map[string]interface{}{"timezone": "UTC"}); err != nil {
+		t.Fatalf("UpdateSessionParameters() error = %v", err)
+	}
+	if err := replicaB.UpdateSessionClient(ctx, session.Token, "Go", "1.11.0", map[string]interface{}{"OS": "linux"}); err != nil {
+		t.Fatalf("UpdateSessionClient() error = %v", err)
+	}
+	updated, err := replicaA.ValidateSession(ctx, session.Token)
+	if err != nil {
+		t.Fatalf("ValidateSession() error = %v", err)
+	}
+	got := []string{
+		updated.Database, updated.CurrentSchema, updated.Role, updated.Warehouse, updated.Parameters["TIMEZONE"].(string),
+		updated.ClientAppID, updated.ClientAppVersion, updated.ClientEnvironment["OS"].(string),
+	}
+	if diff := cmp.Diff([]string{"OTHER_DB", "STAGING", "ANALYST", "COMPUTE_WH", "UTC", "Go", "1.11.0", "linux"}, got); diff != "" {
+		t.Errorf("session mismatch (-want +got):\n%s", diff)
+	}
+	if found, err := replicaB.GetSessionByID(ctx, session.ID); err != nil || found.Token != session.Token {
+		t.Errorf("GetSessionByID() = %v, %v, want token %s", found, err, session.Token)
+	}
+
+	// Renewing through one replica revokes the old token on the other
+	_, newToken, err := replicaB.RenewToken(ctx, session.MasterToken)
+	if err != nil {
+		t.Fatalf("RenewToken() error = %v", err)
+	}
+	if _, err := replicaA.ValidateSession(ctx, session.Token); err == nil {
+		t.Error("Expected the renewed token to be rejected")
+	}
+	if _, err := replicaA.ValidateSession(ctx, newToken); err != nil {
+		t.Errorf("ValidateSession() of the new token error = %v", err)
+	}
+
+	// Closing through one replica closes the session on the other
+	if err := replicaA.CloseSession(ctx, newToken); err != nil {
+		t.Fatalf("CloseSession() error = %v", err)
+	}
+	if _, err := replicaB.ValidateSession(ctx, newToken); err == nil {
+		t.Error("Expected a closed session to be rejected")
+	}
+}
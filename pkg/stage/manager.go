@@ -3,6 +3,7 @@ package stage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,14 @@ import (
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 )
 
+// Errors of the file operations of a stage.
+var (
+	// ErrFileNotFound is returned for a file that is not in the stage.
+	ErrFileNotFound = errors.New("file not found")
+	// ErrInvalidFileName is returned for a file name outside the stage's directory.
+	ErrInvalidFileName = errors.New("invalid file name")
+)
+
 // StageFile represents a file in a stage.
 type StageFile struct {
 	Name         string
@@ -22,12 +31,12 @@ type StageFile struct {
 
 // Manager manages stage file operations.
 type Manager struct {
-	repo     *metadata.Repository
+	repo     metadata.Store
 	stageDir string // Base directory for internal stages
 }
 
 // NewManager creates a new stage manager.
-func NewManager(repo *metadata.Repository, stageDir string) *Manager {
+func NewManager(repo metadata.Store, stageDir string) *Manager {
 	if stageDir == "" {
 		stageDir = "./stages"
 	}
@@ -104,7 +113,7 @@ func (m *Manager) PutFile(ctx context.Context, schemaID, stageName, fileName str
 	// Sanitize file name to prevent directory traversal
 	cleanName := filepath.Clean(fileName)
 	if strings.HasPrefix(cleanName, "..") || filepath.IsAbs(cleanName) {
-		return fmt.Errorf("invalid file name: %s", fileName)
+		return fmt.Errorf("%w: %s", ErrInvalidFileName, fileName)
 	}
 
 	filePath := filepath.Join(stageDir, cleanName)
@@ -129,7 +138,8 @@ func (m *Manager) PutFile(ctx context.Context, schemaID, stageName, fileName str
 	return nil
 }
 
-// GetFile retrieves a file from a stage.
+// GetFile retrieves a file from a stage. Reads fail with the context's error
+// once it is done, so that canceled statements stop reading large files.
 func (m *Manager) GetFile(ctx context.Context, schemaID, stageName, fileName string) (io.ReadCloser, error) {
 	stage, err := m.repo.GetStageByName(ctx, schemaID, stageName)
 	if err != nil {
@@ -145,7 +155,7 @@ func (m *Manager) GetFile(ctx context.Context, schemaID, stageName, fileName str
 	// Sanitize file name to prevent directory traversal
 	cleanName := filepath.Clean(fileName)
 	if strings.HasPrefix(cleanName, "..") || filepath.IsAbs(cleanName) {
-		return nil, fmt.Errorf("invalid file name: %s", fileName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, fileName)
 	}
 
 	filePath := filepath.Join(stageDir, cleanName)
@@ -153,12 +163,25 @@ func (m *Manager) GetFile(ctx context.Context, schemaID, stageName, fileName str
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("file %s not found in stage %s", fileName, stageName)
+			return nil, fmt.Errorf("%w: %s in stage %s", ErrFileNotFound, fileName, stageName)
 		}
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
-	return file, nil
+	return contextReader{ctx: ctx, ReadCloser: file}, nil
+}
+
+// contextReader is a file whose reads fail once its context is done.
+type contextReader struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
 }
 
 // ListFiles lists files in a stage, optionally filtered by pattern.
@@ -233,14 +256,14 @@ func (m *Manager) RemoveFile(ctx context.Context, schemaID, stageName, fileName
 	// Sanitize file name to prevent directory traversal
 	cleanName := filepath.Clean(fileName)
 	if strings.HasPrefix(cleanName, "..") || filepath.IsAbs(cleanName) {
-		return fmt.Errorf("invalid file name: %s", fileName)
+		return fmt.Errorf("%w: %s", ErrInvalidFileName, fileName)
 	}
 
 	filePath := filepath.Join(stageDir, cleanName)
 
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("file %s not found in stage %s", fileName, stageName)
+			return fmt.Errorf("%w: %s in stage %s", ErrFileNotFound, fileName, stageName)
 		}
 		return fmt.Errorf("failed to remove file: %w", err)
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -161,6 +162,35 @@ func TestManager_PutAndGetFile(t *testing.T) {
 	}
 }
 
+// TestManager_GetFileCanceled tests that reads of a staged file fail once the
+// context of the statement reading it is canceled.
+func TestManager_GetFileCanceled(t *testing.T) {
+	mgr, repo, _, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	db, _ := repo.CreateDatabase(ctx, "TEST_DB", "")
+	schema, _ := repo.CreateSchema(ctx, db.ID, "TEST_SCHEMA", "")
+	if _, err := mgr.CreateStage(ctx, schema.ID, "FILE_STAGE", "INTERNAL", "", ""); err != nil {
+		t.Fatalf("Failed to create stage: %v", err)
+	}
+	if err := mgr.PutFile(ctx, schema.ID, "FILE_STAGE", "test.txt", bytes.NewReader([]byte("data"))); err != nil {
+		t.Fatalf("PutFile failed: %v", err)
+	}
+
+	readCtx, cancel := context.WithCancel(ctx)
+	reader, err := mgr.GetFile(readCtx, schema.ID, "FILE_STAGE", "test.txt")
+	if err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	defer reader.Close()
+	cancel()
+
+	if _, err := io.ReadAll(reader); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReadAll() error = %v, want context.Canceled", err)
+	}
+}
+
 func TestManager_PutFileNestedPath(t *testing.T) {
 	mgr, repo, _, cleanup := setupTestManager(t)
 	defer cleanup()
@@ -0,0 +1,205 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Additional Snowflake types that only differ from the core types in how they are reported.
+const (
+	TypeTimestampNTZ SnowflakeType = "TIMESTAMP_NTZ"
+	TypeGeometry     SnowflakeType = "GEOMETRY"
+)
+
+// TypeMapping describes how a Snowflake type is stored in DuckDB and reported to clients.
+type TypeMapping struct {
+	// Type is the canonical Snowflake type.
+	Type SnowflakeType
+	// DuckDBType is the DuckDB column type that stores values of Type.
+	DuckDBType string
+	// RowType is the type name reported in result set metadata, as the Snowflake
+	// wire protocols and drivers expect it: fixed, real, text, and so on.
+	RowType string
+}
+
+// typeMappings is the authoritative Snowflake to DuckDB type mapping. DDL and CAST
+// translation, result set metadata, and parameter bindings are all derived from it.
+var typeMappings = map[SnowflakeType]TypeMapping{
+	TypeNumber:       {Type: TypeNumber, DuckDBType: "DECIMAL(38,0)", RowType: "fixed"},
+	TypeInteger:      {Type: TypeInteger, DuckDBType: "BIGINT", RowType: "fixed"},
+	TypeFloat:        {Type: TypeFloat, DuckDBType: "DOUBLE", RowType: "real"},
+	TypeVarchar:      {Type: TypeVarchar, DuckDBType: "VARCHAR", RowType: "text"},
+	TypeBoolean:      {Type: TypeBoolean, DuckDBType: "BOOLEAN", RowType: "boolean"},
+	TypeDate:         {Type: TypeDate, DuckDBType: "DATE", RowType: "date"},
+	TypeTime:         {Type: TypeTime, DuckDBType: "TIME", RowType: "time"},
+	TypeTimestamp:    {Type: TypeTimestamp, DuckDBType: "TIMESTAMP", RowType: "timestamp_ntz"},
+	TypeTimestampNTZ: {Type: TypeTimestampNTZ, DuckDBType: "TIMESTAMP", RowType: "timestamp_ntz"},
+	TypeTimestampLTZ: {Type: TypeTimestampLTZ, DuckDBType: "TIMESTAMPTZ", RowType: "timestamp_ltz"},
+	TypeTimestampTZ:  {Type: TypeTimestampTZ, DuckDBType: "TIMESTAMPTZ", RowType: "timestamp_tz"},
+	TypeVariant:      {Type: TypeVariant, DuckDBType: "JSON", RowType: "variant"},
+	TypeObject:       {Type: TypeObject, DuckDBType: "JSON", RowType: "object"},
+	// DuckDB LIST requires an element type, so arrays are stored as JSON for flexibility
+	TypeArray:  {Type: TypeArray, DuckDBType: "JSON", RowType: "array"},
+	TypeBinary: {Type: TypeBinary, DuckDBType: "BLOB", RowType: "binary"},
+	// Spatial values are stored as WKT (Well-Known Text) and reported as text
+	TypeGeography: {Type: TypeGeography, DuckDBType: "VARCHAR", RowType: "text"},
+	TypeGeometry:  {Type: TypeGeometry, DuckDBType: "VARCHAR", RowType: "text"},
+}
+
+// typeAliases maps Snowflake type synonyms, and the row type names drivers use
+// for bindings, to their canonical type.
+var typeAliases = map[string]SnowflakeType{
+	"DECIMAL":                        TypeNumber,
+	"NUMERIC":                        TypeNumber,
+	"FIXED":                          TypeNumber,
+	"INT":                            TypeInteger,
+	"BIGINT":                         TypeInteger,
+	"SMALLINT":                       TypeInteger,
+	"TINYINT":                        TypeInteger,
+	"BYTEINT":                        TypeInteger,
+	"FLOAT4":                         TypeFloat,
+	"FLOAT8":                         TypeFloat,
+	"DOUBLE":                         TypeFloat,
+	"DOUBLE PRECISION":               TypeFloat,
+	"REAL":                           TypeFloat,
+	"STRING":                         TypeVarchar,
+	"TEXT":                           TypeVarchar,
+	"CHAR":                           TypeVarchar,
+	"CHARACTER":                      TypeVarchar,
+	"CHAR VARYING":                   TypeVarchar,
+	"NCHAR":                          TypeVarchar,
+	"NCHAR VARYING":                  TypeVarchar,
+	"NVARCHAR":                       TypeVarchar,
+	"NVARCHAR2":                      TypeVarchar,
+	"VARBINARY":                      TypeBinary,
+	"DATETIME":                       TypeTimestampNTZ,
+	"TIMESTAMPNTZ":                   TypeTimestampNTZ,
+	"TIMESTAMP WITHOUT TIME ZONE":    TypeTimestampNTZ,
+	"TIMESTAMPLTZ":                   TypeTimestampLTZ,
+	"TIMESTAMP WITH LOCAL TIME ZONE": TypeTimestampLTZ,
+	"TIMESTAMPTZ":                    TypeTimestampTZ,
+	"TIMESTAMP WITH TIME ZONE":       TypeTimestampTZ,
+}
+
+// duckDBTypes maps DuckDB type names, without parameters, to the Snowflake type
+// their values are reported as. TIMESTAMPTZ holds instants without their original
+// offset, which matches TIMESTAMP_LTZ rather than TIMESTAMP_TZ.
+var duckDBTypes = map[string]SnowflakeType{
+	"TINYINT":                  TypeNumber,
+	"SMALLINT":                 TypeNumber,
+	"INTEGER":                  TypeNumber,
+	"INT":                      TypeNumber,
+	"BIGINT":                   TypeNumber,
+	"HUGEINT":                  TypeNumber,
+	"UTINYINT":                 TypeNumber,
+	"USMALLINT":                TypeNumber,
+	"UINTEGER":                 TypeNumber,
+	"UBIGINT":                  TypeNumber,
+	"UHUGEINT":                 TypeNumber,
+	"DECIMAL":                  TypeNumber,
+	"NUMERIC":                  TypeNumber,
+	"DOUBLE":                   TypeFloat,
+	"FLOAT":                    TypeFloat,
+	"REAL":                     TypeFloat,
+	"VARCHAR":                  TypeVarchar,
+	"TEXT":                     TypeVarchar,
+	"STRING":                   TypeVarchar,
+	"UUID":                     TypeVarchar,
+	"INTERVAL":                 TypeVarchar,
+	"ENUM":                     TypeVarchar,
+	"BIT":                      TypeVarchar,
+	"BOOLEAN":                  TypeBoolean,
+	"BOOL":                     TypeBoolean,
+	"DATE":                     TypeDate,
+	"TIME":                     TypeTime,
+	"TIMESTAMP":                TypeTimestampNTZ,
+	"TIMESTAMP_NS":             TypeTimestampNTZ,
+	"TIMESTAMP_MS":             TypeTimestampNTZ,
+	"TIMESTAMP_S":              TypeTimestampNTZ,
+	"TIMESTAMPTZ":              TypeTimestampLTZ,
+	"TIMESTAMP WITH TIME ZONE": TypeTimestampLTZ,
+	"BLOB":                     TypeBinary,
+	"BYTEA":                    TypeBinary,
+	"JSON":                     TypeVariant,
+	"LIST":                     TypeArray,
+	"ARRAY":                    TypeArray,
+	"STRUCT":                   TypeObject,
+	"MAP":                      TypeObject,
+}
+
+// LookupType returns the mapping for a Snowflake type name, a synonym such as
+// INT or DATETIME, or a binding type such as FIXED. Parameters like the
+// precision in NUMBER(10,2) are ignored. It reports false for unknown types.
+func LookupType(name string) (TypeMapping, bool) {
+	base, _ := splitTypeParams(name)
+	t := SnowflakeType(base)
+	if alias, ok := typeAliases[base]; ok {
+		t = alias
+	}
+	m, ok := typeMappings[t]
+	return m, ok
+}
+
+// DuckDBTypeFor converts a Snowflake type declaration such as NUMBER(10,2) or
+// TIMESTAMP_NTZ(9) to the DuckDB type declaration that stores it. Precision and
+// scale are kept for fixed-point types; lengths and fractional-second precision
+// are dropped because DuckDB does not support them. It reports false for
+// unknown types.
+func DuckDBTypeFor(decl string) (string, bool) {
+	m, ok := LookupType(decl)
+	if !ok {
+		return "", false
+	}
+	_, params := splitTypeParams(decl)
+	if m.Type != TypeNumber {
+		return m.DuckDBType, true
+	}
+
+	// NUMBER defaults to NUMBER(38,0), and NUMBER(p) to NUMBER(p,0)
+	switch len(params) {
+	case 1:
+		return fmt.Sprintf("DECIMAL(%s,0)", params[0]), true
+	case 2:
+		return fmt.Sprintf("DECIMAL(%s,%s)", params[0], params[1]), true
+	default:
+		return m.DuckDBType, true
+	}
+}
+
+// FromDuckDBType returns the mapping of the Snowflake type that values of a
+// DuckDB column type are reported as. Unknown DuckDB types are reported as VARCHAR.
+func FromDuckDBType(duckType string) TypeMapping {
+	base, _ := splitTypeParams(duckType)
+	switch {
+	case strings.HasSuffix(strings.TrimSpace(duckType), "]"):
+		// Lists are written as INTEGER[] and fixed-size arrays as INTEGER[3]
+		return typeMappings[TypeArray]
+	case strings.HasPrefix(base, "STRUCT"):
+		return typeMappings[TypeObject]
+	case strings.HasPrefix(base, "MAP"):
+		return typeMappings[TypeObject]
+	case strings.HasPrefix(base, "ENUM"):
+		return typeMappings[TypeVarchar]
+	}
+	if t, ok := duckDBTypes[base]; ok {
+		return typeMappings[t]
+	}
+	return typeMappings[TypeVarchar]
+}
+
+// splitTypeParams splits a type declaration into its uppercase name, with runs
+// of whitespace collapsed, and its parenthesized parameters.
+func splitTypeParams(decl string) (string, []string) {
+	decl = strings.ToUpper(strings.TrimSpace(decl))
+	name, rest, found := strings.Cut(decl, "(")
+	name = strings.Join(strings.Fields(name), " ")
+	if !found {
+		return name, nil
+	}
+	args, _, _ := strings.Cut(rest, ")")
+	var params []string
+	for _, arg := range strings.Split(args, ",") {
+		params = append(params, strings.TrimSpace(arg))
+	}
+	return name, params
+}
@@ -0,0 +1,146 @@
+package types
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/quick"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestDuckDBTypeFor tests converting Snowflake type declarations to DuckDB types.
+func TestDuckDBTypeFor(t *testing.T) {
+	tests := []struct {
+		decl   string
+		want   string
+		wantOK bool
+	}{
+		{decl: "NUMBER", want: "DECIMAL(38,0)", wantOK: true},
+		{decl: "number(10, 2)", want: "DECIMAL(10,2)", wantOK: true},
+		{decl: "NUMERIC(12)", want: "DECIMAL(12,0)", wantOK: true},
+		{decl: "INT", want: "BIGINT", wantOK: true},
+		{decl: "BYTEINT", want: "BIGINT", wantOK: true},
+		{decl: "FLOAT", want: "DOUBLE", wantOK: true},
+		{decl: "DOUBLE  PRECISION", want: "DOUBLE", wantOK: true},
+		{decl: "VARCHAR(100)", want: "VARCHAR", wantOK: true},
+		{decl: "NVARCHAR2", want: "VARCHAR", wantOK: true},
+		{decl: "VARBINARY(16)", want: "BLOB", wantOK: true},
+		{decl: "TIME(9)", want: "TIME", wantOK: true},
+		{decl: "DATETIME", want: "TIMESTAMP", wantOK: true},
+		{decl: "TIMESTAMP_NTZ(9)", want: "TIMESTAMP", wantOK: true},
+		{decl: "TIMESTAMP_LTZ", want: "TIMESTAMPTZ", wantOK: true},
+		{decl: "TIMESTAMP WITH TIME ZONE", want: "TIMESTAMPTZ", wantOK: true},
+		{decl: "VARIANT", want: "JSON", wantOK: true},
+		{decl: "OBJECT", want: "JSON", wantOK: true},
+		{decl: "ARRAY", want: "JSON", wantOK: true},
+		{decl: "GEOGRAPHY", want: "VARCHAR", wantOK: true},
+		{decl: "HUGEINT", want: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.decl, func(t *testing.T) {
+			got, ok := DuckDBTypeFor(tt.decl)
+			if ok != tt.wantOK {
+				t.Fatalf("DuckDBTypeFor(%q) ok = %v, want %v", tt.decl, ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DuckDBTypeFor(%q) mismatch (-want +got):\n%s", tt.decl, diff)
+			}
+		})
+	}
+}
+
+// TestFromDuckDBType tests reporting DuckDB column types as Snowflake types.
+func TestFromDuckDBType(t *testing.T) {
+	tests := []struct {
+		duckType string
+		want     string
+	}{
+		{duckType: "BIGINT", want: "fixed"},
+		{duckType: "HUGEINT", want: "fixed"},
+		{duckType: "DECIMAL(18,3)", want: "fixed"},
+		{duckType: "DOUBLE", want: "real"},
+		{duckType: "FLOAT", want: "real"},
+		{duckType: "VARCHAR", want: "text"},
+		{duckType: "UUID", want: "text"},
+		{duckType: "BOOLEAN", want: "boolean"},
+		{duckType: "DATE", want: "date"},
+		{duckType: "TIME", want: "time"},
+		{duckType: "TIMESTAMP", want: "timestamp_ntz"},
+		{duckType: "TIMESTAMP_NS", want: "timestamp_ntz"},
+		{duckType: "TIMESTAMP WITH TIME ZONE", want: "timestamp_ltz"},
+		{duckType: "BLOB", want: "binary"},
+		{duckType: "JSON", want: "variant"},
+		{duckType: "INTEGER[]", want: "array"},
+		{duckType: "DECIMAL(10,2)[3]", want: "array"},
+		{duckType: "STRUCT(a INTEGER)", want: "object"},
+		{duckType: "MAP(VARCHAR, INTEGER)", want: "object"},
+		{duckType: "ENUM('a', 'b')", want: "text"},
+		{duckType: "UNKNOWN_TYPE", want: "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.duckType, func(t *testing.T) {
+			if got := FromDuckDBType(tt.duckType).RowType; got != tt.want {
+				t.Errorf("FromDuckDBType(%q).RowType = %q, want %q", tt.duckType, got, tt.want)
+			}
+		})
+	}
+}
+
+// typeDecl is a random Snowflake type declaration for property tests.
+type typeDecl string
+
+// Generate implements quick.Generator.
+func (typeDecl) Generate(r *rand.Rand, _ int) reflect.Value {
+	names := make([]string, 0, len(typeMappings)+len(typeAliases))
+	for t := range typeMappings {
+		names = append(names, string(t))
+	}
+	for alias := range typeAliases {
+		names = append(names, alias)
+	}
+	// Sort so that the choice only depends on the seeded source
+	sort.Strings(names)
+	decl := names[r.Intn(len(names))]
+	switch r.Intn(3) {
+	case 1:
+		decl += fmt.Sprintf("(%d)", 1+r.Intn(38))
+	case 2:
+		precision := 1 + r.Intn(38)
+		decl += fmt.Sprintf("(%d, %d)", precision, r.Intn(precision+1))
+	}
+	return reflect.ValueOf(typeDecl(decl))
+}
+
+// TestTypeMapping_RoundTrip checks that a value stored in the DuckDB column type
+// chosen for a Snowflake type is reported back as that Snowflake type. VARIANT,
+// OBJECT, and ARRAY share DuckDB's JSON type and are all reported as VARIANT,
+// and TIMESTAMP_TZ loses its offsets in TIMESTAMPTZ and is reported as TIMESTAMP_LTZ.
+func TestTypeMapping_RoundTrip(t *testing.T) {
+	property := func(decl typeDecl) bool {
+		want, ok := LookupType(string(decl))
+		if !ok {
+			return false
+		}
+		duckType, ok := DuckDBTypeFor(string(decl))
+		if !ok {
+			return false
+		}
+		got := FromDuckDBType(duckType)
+		switch {
+		case want.DuckDBType == "JSON":
+			return got.Type == TypeVariant
+		case want.Type == TypeTimestampTZ:
+			return got.Type == TypeTimestampLTZ
+		}
+		return got.RowType == want.RowType
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
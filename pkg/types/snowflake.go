@@ -43,36 +43,10 @@ const (
 //   - DuckDB type name as string
 //   - For unknown types, returns "VARCHAR" as a safe default
 func (t SnowflakeType) ToDuckDBType() string {
-	switch t {
-	case TypeNumber:
-		return "DOUBLE"
-	case TypeInteger:
-		return "BIGINT"
-	case TypeFloat:
-		return "DOUBLE"
-	case TypeVarchar:
-		return "VARCHAR"
-	case TypeBoolean:
-		return "BOOLEAN"
-	case TypeDate:
-		return "DATE"
-	case TypeTime:
-		return "TIME"
-	case TypeTimestamp:
-		return "TIMESTAMP"
-	case TypeTimestampLTZ, TypeTimestampTZ:
-		return "TIMESTAMPTZ"
-	case TypeVariant, TypeObject:
-		return "JSON"
-	case TypeArray:
-		return "JSON" // DuckDB LIST requires element type, so we use JSON for flexibility
-	case TypeBinary:
-		return "BLOB"
-	case TypeGeography:
-		return "VARCHAR" // Store WKT (Well-Known Text) format
-	default:
-		return "VARCHAR" // Safe default for unknown types
+	if m, ok := typeMappings[t]; ok {
+		return m.DuckDBType
 	}
+	return "VARCHAR" // Safe default for unknown types
 }
 
 // IsNumeric returns true if the type is a numeric type (NUMBER, INTEGER, FLOAT).
@@ -93,7 +67,7 @@ func (t SnowflakeType) IsString() bool {
 // IsTemporal returns true if the type is a date/time type.
 func (t SnowflakeType) IsTemporal() bool {
 	switch t {
-	case TypeDate, TypeTime, TypeTimestamp, TypeTimestampLTZ, TypeTimestampTZ:
+	case TypeDate, TypeTime, TypeTimestamp, TypeTimestampNTZ, TypeTimestampLTZ, TypeTimestampTZ:
 		return true
 	default:
 		return false
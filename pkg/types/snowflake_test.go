@@ -14,7 +14,7 @@ func TestSnowflakeType_ToDuckDBType(t *testing.T) {
 		want string
 	}{
 		// Core types
-		{name: "NUMBER", in: TypeNumber, want: "DOUBLE"},
+		{name: "NUMBER", in: TypeNumber, want: "DECIMAL(38,0)"},
 		{name: "INTEGER", in: TypeInteger, want: "BIGINT"},
 		{name: "FLOAT", in: TypeFloat, want: "DOUBLE"},
 		{name: "VARCHAR", in: TypeVarchar, want: "VARCHAR"},
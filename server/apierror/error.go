@@ -18,6 +18,16 @@ const (
 	CodeSQLCompilationError = "001003"
 	CodeSQLExecutionError   = "001007"
 
+	// Concurrency Errors (000625)
+	CodeLockTimeout = "000625"
+
+	// Cancellation Errors (00060x)
+	CodeQueryCanceled     = "000604"
+	CodeQueryNotExecuting = "000605"
+
+	// Resource Errors (000603)
+	CodeResourceExhausted = "000603"
+
 	// Object Errors (002xxx)
 	CodeObjectNotFound      = "002003"
 	CodeObjectAlreadyExists = "002043"
@@ -36,6 +46,9 @@ const (
 	SQLStateDataException        = "22000"
 	SQLStateNoData               = "02000"
 	SQLStateTableExists          = "42S01"
+	SQLStateQueryCanceled        = "57014"
+	SQLStateDiskFull             = "53100"
+	SQLStateOutOfMemory          = "53200"
 	SQLStateGeneralError         = "HY000"
 )
 
@@ -49,6 +62,8 @@ func GetSQLState(code string) string {
 		CodeSQLExecutionError:    SQLStateDataException,
 		CodeObjectNotFound:       SQLStateNoData,
 		CodeObjectAlreadyExists:  SQLStateTableExists,
+		CodeLockTimeout:          SQLStateQueryCanceled,
+		CodeQueryCanceled:        SQLStateQueryCanceled,
 	}
 
 	if state, ok := mapping[code]; ok {
@@ -180,6 +195,65 @@ func NewSQLCompilationError(message string) *SnowflakeError {
 	}
 }
 
+// NewSyntaxError creates a SQL compilation error locating a syntax error, in
+// Snowflake's "syntax error line 3 at position 12" form. The line is 1-based
+// and the position is the 0-based offset in the line.
+func NewSyntaxError(line, position int, unexpected string) *SnowflakeError {
+	return &SnowflakeError{
+		Code:     CodeSQLCompilationError,
+		Message:  fmt.Sprintf("SQL compilation error:\nsyntax error line %d at position %d unexpected '%s'.", line, position, unexpected),
+		SQLState: SQLStateSyntaxError,
+		Data: map[string]interface{}{
+			"line":     line,
+			"position": position,
+		},
+	}
+}
+
+// NewLockTimeoutError creates a lock timeout error, returned when a statement
+// cannot acquire a lock held by a concurrent transaction.
+func NewLockTimeoutError(message string) *SnowflakeError {
+	return &SnowflakeError{
+		Code:     CodeLockTimeout,
+		Message:  message,
+		SQLState: SQLStateQueryCanceled,
+		Data:     make(map[string]interface{}),
+	}
+}
+
+// NewQueryCanceledError creates the error of a statement canceled while it
+// ran, such as by an abort request.
+func NewQueryCanceledError() *SnowflakeError {
+	return &SnowflakeError{
+		Code:     CodeQueryCanceled,
+		Message:  "SQL execution canceled",
+		SQLState: SQLStateQueryCanceled,
+		Data:     make(map[string]interface{}),
+	}
+}
+
+// NewOutOfMemoryError creates the error of a statement that needed more memory
+// than the instance allows.
+func NewOutOfMemoryError() *SnowflakeError {
+	return &SnowflakeError{
+		Code:     CodeResourceExhausted,
+		Message:  "SQL execution internal error: Statement ran out of memory.",
+		SQLState: SQLStateOutOfMemory,
+		Data:     make(map[string]interface{}),
+	}
+}
+
+// NewDiskFullError creates the error of a statement that ran out of disk space,
+// such as for spilling intermediate results.
+func NewDiskFullError() *SnowflakeError {
+	return &SnowflakeError{
+		Code:     CodeResourceExhausted,
+		Message:  "SQL execution internal error: Statement ran out of disk space.",
+		SQLState: SQLStateDiskFull,
+		Data:     make(map[string]interface{}),
+	}
+}
+
 // NewInternalError creates an internal error.
 func NewInternalError(message string) *SnowflakeError {
 	return &SnowflakeError{
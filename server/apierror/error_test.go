@@ -114,6 +114,22 @@ func TestNewError(t *testing.T) {
 			expectedCode: CodeSQLCompilationError,
 			expectedMsg:  "Syntax error at line 1",
 		},
+		{
+			name: "SyntaxError",
+			createFunc: func() *SnowflakeError {
+				return NewSyntaxError(3, 12, "FROM")
+			},
+			expectedCode: CodeSQLCompilationError,
+			expectedMsg:  "SQL compilation error:\nsyntax error line 3 at position 12 unexpected 'FROM'.",
+		},
+		{
+			name: "LockTimeoutError",
+			createFunc: func() *SnowflakeError {
+				return NewLockTimeoutError("Statement was aborted because of a lock conflict")
+			},
+			expectedCode: CodeLockTimeout,
+			expectedMsg:  "Statement was aborted because of a lock conflict",
+		},
 		{
 			name: "InternalError",
 			createFunc: func() *SnowflakeError {
@@ -176,6 +192,7 @@ func TestErrorCodes(t *testing.T) {
 		{"CodeInvalidParameter", CodeInvalidParameter},
 		{"CodeSessionExpired", CodeSessionExpired},
 		{"CodePermissionDenied", CodePermissionDenied},
+		{"CodeLockTimeout", CodeLockTimeout},
 	}
 
 	for _, tc := range codes {
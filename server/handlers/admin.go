@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// AdminHandler exposes emulator-side state for tests and debugging.
+type AdminHandler struct {
+	executor *query.Executor
+	// exportRoot is the directory exports are written under, or "" when
+	// exports are disabled.
+	exportRoot string
+}
+
+// AdminHandlerOption configures an AdminHandler.
+type AdminHandlerOption func(*AdminHandler)
+
+// WithExportRoot enables ExportDatabase, writing exports to directories under
+// root.
+func WithExportRoot(root string) AdminHandlerOption {
+	return func(h *AdminHandler) {
+		h.exportRoot = root
+	}
+}
+
+// NewAdminHandler creates a new admin handler.
+func NewAdminHandler(executor *query.Executor, opts ...AdminHandlerOption) *AdminHandler {
+	h := &AdminHandler{executor: executor}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ListTransactions handles GET /admin/transactions. It lists the open
+// transactions of all sessions and the tables each one holds.
+func (h *AdminHandler) ListTransactions(w http.ResponseWriter, _ *http.Request) {
+	transactions := h.executor.Transactions()
+
+	resp := types.ListTransactionsResponse{Transactions: make([]types.TransactionResponse, len(transactions))}
+	for i, tx := range transactions {
+		locks := make([]types.LockResponse, len(tx.Locks))
+		for j, lock := range tx.Locks {
+			locks[j] = types.LockResponse{
+				Resource:   lock.Resource,
+				AcquiredOn: lock.AcquiredAt.Format(time.RFC3339Nano),
+				QueryID:    lock.QueryID,
+			}
+		}
+		resp.Transactions[i] = types.TransactionResponse{
+			ID:        tx.ID,
+			SessionID: tx.SessionID,
+			User:      tx.User,
+			StartedOn: tx.StartedAt.Format(time.RFC3339Nano),
+			Locks:     locks,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// MetadataCache handles GET /admin/metadata-cache. It reports the hit rate of
+// the cache of databases and schemas looked up by name.
+func (h *AdminHandler) MetadataCache(w http.ResponseWriter, _ *http.Request) {
+	stats, enabled := h.executor.MetadataCacheStats()
+
+	resp := types.MetadataCacheResponse{
+		Enabled:       enabled,
+		Hits:          stats.Hits,
+		Misses:        stats.Misses,
+		HitRate:       stats.HitRate(),
+		Invalidations: stats.Invalidations,
+		Entries:       stats.Entries,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ExportDatabase handles POST /admin/databases/{database}/export. It writes the
+// database's DDL, table data, and a Snowflake load script to a directory under
+// the export root, for promoting a locally built schema to a real Snowflake
+// account. Without an export root, exports are disabled.
+func (h *AdminHandler) ExportDatabase(w http.ResponseWriter, r *http.Request) {
+	if h.exportRoot == "" {
+		sendAdminError(w, http.StatusForbidden, "Exports are disabled; set EXPORT_DIR to the directory exports are written under")
+		return
+	}
+	var req types.ExportDatabaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendAdminError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Directory == "" {
+		sendAdminError(w, http.StatusBadRequest, "Export directory is required")
+		return
+	}
+	dir, err := query.ResolveExportDir(h.exportRoot, req.Directory)
+	if err != nil {
+		sendAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := query.ParseExportFormat(req.Format)
+	if err != nil {
+		sendAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transforms := make(map[string]map[string]query.ColumnTransform, len(req.Transforms))
+	for table, columns := range req.Transforms {
+		transforms[table] = make(map[string]query.ColumnTransform, len(columns))
+		for column, name := range columns {
+			if transforms[table][column], err = query.ParseColumnTransform(name); err != nil {
+				sendAdminError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+
+	opts := []query.ExportOption{query.WithColumnTransforms(transforms)}
+	if req.HashSalt != "" {
+		opts = append(opts, query.WithHashSalt(req.HashSalt))
+	}
+
+	export, err := h.executor.ExportDatabase(r.Context(), chi.URLParam(r, "database"), dir, format, opts...)
+	if err != nil {
+		sendAdminError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp := types.ExportDatabaseResponse{
+		Database:  export.Database,
+		Directory: export.Directory,
+		Format:    string(export.Format),
+		Tables:    make([]types.ExportedTableResponse, len(export.Tables)),
+		Skipped:   export.Skipped,
+	}
+	for i, table := range export.Tables {
+		resp.Tables[i] = types.ExportedTableResponse{Schema: table.Schema, Name: table.Name, File: table.File, Rows: table.Rows}
+	}
+	if resp.Skipped == nil {
+		resp.Skipped = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// Capabilities handles GET /admin/capabilities. It lists the Snowflake
+// functions the emulator translates and those it knows of but does not
+// support, with the reason and tracking issue.
+func (h *AdminHandler) Capabilities(w http.ResponseWriter, _ *http.Request) {
+	capabilities := h.executor.Capabilities()
+
+	resp := types.CapabilitiesResponse{
+		TranslatedFunctions:  capabilities.TranslatedFunctions,
+		UnsupportedFunctions: make([]types.UnsupportedFunctionResponse, len(capabilities.UnsupportedFunctions)),
+	}
+	for i, function := range capabilities.UnsupportedFunctions {
+		resp.UnsupportedFunctions[i] = types.UnsupportedFunctionResponse{
+			Name:     function.Name,
+			Category: function.Category,
+			Reason:   function.Reason,
+			Issue:    function.Issue,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// CompatReport handles GET /admin/compat-report. It returns the statements
+// that hit translation fallbacks or failed on unsupported features since the
+// emulator started, as JSON, or as Markdown with ?format=markdown.
+func (h *AdminHandler) CompatReport(w http.ResponseWriter, r *http.Request) {
+	report := h.executor.CompatReport()
+	if report == nil {
+		sendAdminError(w, http.StatusNotFound, "The compatibility report is not enabled; set COMPAT_REPORT")
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = report.WriteJSON(w)
+	case "markdown", "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_ = report.WriteMarkdown(w)
+	default:
+		sendAdminError(w, http.StatusBadRequest, fmt.Sprintf("Unknown format %q: use json or markdown", format))
+	}
+}
+
+// EvaluateDataMetrics handles POST /admin/data-metrics/evaluate. It evaluates
+// the data metric functions added to the requested table, or to all tables,
+// and records the measurements in SNOWFLAKE.LOCAL.DATA_QUALITY_MONITORING_RESULTS,
+// as a DATA_METRIC_SCHEDULE would. The table name is fully qualified.
+func (h *AdminHandler) EvaluateDataMetrics(w http.ResponseWriter, r *http.Request) {
+	var req types.EvaluateDataMetricsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendAdminError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	results, err := h.executor.EvaluateDataMetrics(r.Context(), req.Table)
+	if err != nil {
+		sendAdminError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	resp := types.EvaluateDataMetricsResponse{Results: make([]types.DataMetricResultResponse, len(results))}
+	for i, result := range results {
+		resp.Results[i] = types.DataMetricResultResponse{
+			MeasurementTime: result.MeasurementTime.Format(time.RFC3339Nano),
+			TableDatabase:   result.TableDatabase,
+			TableSchema:     result.TableSchema,
+			TableName:       result.TableName,
+			MetricDatabase:  result.MetricDatabase,
+			MetricSchema:    result.MetricSchema,
+			MetricName:      result.MetricName,
+			ArgumentNames:   result.ArgumentNames,
+			Value:           result.Value,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ListSentEmails handles GET /admin/notifications. It lists the emails sent
+// with SYSTEM$SEND_EMAIL, oldest first, so tests can check the notifications
+// of their tasks and alerts.
+func (h *AdminHandler) ListSentEmails(w http.ResponseWriter, r *http.Request) {
+	emails, err := h.executor.SentEmails(r.Context())
+	if err != nil {
+		sendAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := types.SentEmailsResponse{Emails: make([]types.SentEmailResponse, len(emails))}
+	for i, email := range emails {
+		resp.Emails[i] = types.SentEmailResponse{
+			SentAt:      email.SentAt.Format(time.RFC3339Nano),
+			Integration: email.Integration,
+			Recipients:  email.Recipients,
+			Subject:     email.Subject,
+			Body:        email.Body,
+			ContentType: email.ContentType,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// DiffResults handles POST /admin/diff. It runs the left statement and either
+// the right statement or takes the expected rows given inline, and reports the
+// columns and rows that differ, for data migration checks.
+func (h *AdminHandler) DiffResults(w http.ResponseWriter, r *http.Request) {
+	var req types.DiffRequest
+	decoder := json.NewDecoder(r.Body)
+	// Expected numbers keep their exact digits
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		sendAdminError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Left == "" || (req.Right == "") == (req.Expected == nil) {
+		sendAdminError(w, http.StatusBadRequest, "A left statement and either a right statement or expected rows are required")
+		return
+	}
+
+	ctx := query.ContextWithSessionInfo(r.Context(), query.SessionInfo{Database: req.Database, Schema: req.Schema})
+	left, err := h.executor.Query(ctx, req.Left)
+	if err != nil {
+		sendAdminError(w, http.StatusUnprocessableEntity, "left statement failed: "+err.Error())
+		return
+	}
+
+	var right *query.Result
+	if req.Expected != nil {
+		right = &query.Result{Columns: req.Expected.Columns, Rows: req.Expected.Rows}
+		if len(right.Columns) == 0 {
+			right.Columns = left.Columns
+		}
+		for i, row := range right.Rows {
+			if len(row) != len(right.Columns) {
+				sendAdminError(w, http.StatusBadRequest, fmt.Sprintf("expected row %d has %d values for %d columns", i, len(row), len(right.Columns)))
+				return
+			}
+		}
+	} else {
+		right, err = h.executor.Query(ctx, req.Right)
+		if err != nil {
+			sendAdminError(w, http.StatusUnprocessableEntity, "right statement failed: "+err.Error())
+			return
+		}
+	}
+
+	diff, err := query.DiffResults(left, right, query.DiffOptions{Key: req.Key, Ordered: req.Ordered})
+	if err != nil {
+		sendAdminError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := types.DiffResponse{
+		Equal:          diff.Equal(),
+		LeftColumns:    diff.LeftColumns,
+		RightColumns:   diff.RightColumns,
+		MissingColumns: append([]string{}, diff.MissingColumns...),
+		ExtraColumns:   append([]string{}, diff.ExtraColumns...),
+		LeftRowCount:   diff.LeftRows,
+		RightRowCount:  diff.RightRows,
+		Removed:        diffRowsResponse(diff.Removed),
+		Added:          diffRowsResponse(diff.Added),
+		Changed:        make([]types.ChangedRowResponse, len(diff.Changed)),
+	}
+	for i, row := range diff.Changed {
+		cells := make([]types.CellDiffResponse, len(row.Cells))
+		for j, cell := range row.Cells {
+			cells[j] = types.CellDiffResponse{Column: cell.Column, Left: cell.Left, Right: cell.Right}
+		}
+		resp.Changed[i] = types.ChangedRowResponse{LeftIndex: row.LeftIndex, RightIndex: row.RightIndex, Key: row.Key, Cells: cells}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// diffRowsResponse converts the rows of a diff, as an empty list when there are none.
+func diffRowsResponse(rows []query.DiffRow) []types.DiffRowResponse {
+	resp := make([]types.DiffRowResponse, len(rows))
+	for i, row := range rows {
+		resp[i] = types.DiffRowResponse{Index: row.Index, Values: row.Values}
+	}
+	return resp
+}
+
+// sendAdminError sends an admin API error with the given HTTP status.
+func sendAdminError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(types.AdminErrorResponse{Message: message})
+}
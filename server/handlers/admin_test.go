@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// TestAdminHandler_ListTransactions tests listing open transactions and their locks.
+func TestAdminHandler_ListTransactions(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(mgr, repo)
+	handler := NewAdminHandler(executor)
+
+	ctx := query.ContextWithSessionInfo(context.Background(), query.SessionInfo{ID: "7", User: "TESTER"})
+	for _, sql := range []string{"CREATE TABLE orders (id INTEGER)", "BEGIN", "INSERT INTO orders VALUES (1)"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+	t.Cleanup(func() { _, _ = executor.Execute(ctx, "ROLLBACK") })
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/transactions", nil)
+	w := httptest.NewRecorder()
+	handler.ListTransactions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp types.ListTransactionsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Transactions) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(resp.Transactions))
+	}
+	tx := resp.Transactions[0]
+	if tx.SessionID != "7" || tx.User != "TESTER" {
+		t.Errorf("Expected session 7 of TESTER, got session %q of %q", tx.SessionID, tx.User)
+	}
+	if len(tx.Locks) != 1 || tx.Locks[0].Resource != "ORDERS" {
+		t.Errorf("Expected a lock on ORDERS, got %+v", tx.Locks)
+	}
+}
+
+// TestAdminHandler_ExportDatabase tests exporting a database through the admin API.
+func TestAdminHandler_ExportDatabase(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(mgr, repo)
+	root := t.TempDir()
+	r := chi.NewRouter()
+	r.Post("/admin/databases/{database}/export", NewAdminHandler(executor, WithExportRoot(root)).ExportDatabase)
+
+	ctx := context.Background()
+	if _, err := repo.CreateDatabase(ctx, "SALES", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for _, sql := range []string{"CREATE TABLE orders (id INTEGER)", "INSERT INTO orders VALUES (1)"} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	dir := filepath.Join(root, "sales")
+	outside := t.TempDir()
+	tests := []struct {
+		name       string
+		database   string
+		body       string
+		wantStatus int
+	}{
+		{name: "CSV", database: "SALES", body: `{"directory": "sales"}`, wantStatus: http.StatusOK},
+		{name: "MissingDirectory", database: "SALES", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "AbsoluteDirectory", database: "SALES", body: `{"directory": "` + outside + `"}`, wantStatus: http.StatusBadRequest},
+		{name: "DirectoryOutsideRoot", database: "SALES", body: `{"directory": "../escaped"}`, wantStatus: http.StatusBadRequest},
+		{name: "UnknownFormat", database: "SALES", body: `{"directory": "sales", "format": "avro"}`, wantStatus: http.StatusBadRequest},
+		{name: "Transforms", database: "SALES", body: `{"directory": "sales", "transforms": {"ORDERS": {"id": "hash"}}}`, wantStatus: http.StatusOK},
+		{name: "TransformsWithSalt", database: "SALES", body: `{"directory": "sales", "transforms": {"ORDERS": {"id": "hash"}}, "hash_salt": "pepper"}`, wantStatus: http.StatusOK},
+		{name: "UnknownTransform", database: "SALES", body: `{"directory": "sales", "transforms": {"ORDERS": {"ID": "redact"}}}`, wantStatus: http.StatusBadRequest},
+		{name: "UnknownTransformColumn", database: "SALES", body: `{"directory": "sales", "transforms": {"ORDERS": {"TOTAL": "mask"}}}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "UnknownDatabase", database: "MISSING", body: `{"directory": "sales"}`, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/databases/"+tt.database+"/export", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp types.ExportDatabaseResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Database != "SALES" || resp.Format != "CSV" || len(resp.Tables) != 1 || resp.Tables[0].Rows != 1 {
+				t.Errorf("Unexpected export %+v", resp)
+			}
+			for _, name := range []string{"ddl.sql", "load.sql", resp.Tables[0].File} {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("Expected %s to be written: %v", name, err)
+				}
+			}
+		})
+	}
+
+	// Nothing is written outside the export root
+	if entries, _ := os.ReadDir(outside); len(entries) > 0 {
+		t.Errorf("Expected nothing written to %s, got %d entries", outside, len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "escaped")); !os.IsNotExist(err) {
+		t.Errorf("Expected no directory outside the export root, got %v", err)
+	}
+
+	// Without an export root, exports are disabled
+	disabled := chi.NewRouter()
+	disabled.Post("/admin/databases/{database}/export", NewAdminHandler(executor).ExportDatabase)
+	req := httptest.NewRequest(http.MethodPost, "/admin/databases/SALES/export", bytes.NewBufferString(`{"directory": "sales"}`))
+	w := httptest.NewRecorder()
+	disabled.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d without an export root, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
+
+// TestAdminHandler_Capabilities tests listing translated and unsupported functions.
+func TestAdminHandler_Capabilities(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	handler := NewAdminHandler(query.NewExecutor(mgr, repo))
+
+	w := httptest.NewRecorder()
+	handler.Capabilities(w, httptest.NewRequest(http.MethodGet, "/admin/capabilities", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var resp types.CapabilitiesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !slices.Contains(resp.TranslatedFunctions, "IFF") {
+		t.Errorf("translatedFunctions does not contain IFF: %v", resp.TranslatedFunctions)
+	}
+	index := slices.IndexFunc(resp.UnsupportedFunctions, func(f types.UnsupportedFunctionResponse) bool { return f.Name == "SEARCH" })
+	if index < 0 {
+		t.Fatalf("unsupportedFunctions does not contain SEARCH: %v", resp.UnsupportedFunctions)
+	}
+	if search := resp.UnsupportedFunctions[index]; search.Category != "Search" || search.Reason == "" {
+		t.Errorf("SEARCH = %+v, want a Search function with a reason", search)
+	}
+}
+
+// TestAdminHandler_EvaluateDataMetrics tests evaluating the data metric
+// functions added to a table.
+func TestAdminHandler_EvaluateDataMetrics(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(mgr, repo)
+	handler := NewAdminHandler(executor)
+
+	ctx := context.Background()
+	for _, sql := range []string{
+		"CREATE TABLE orders (id INTEGER)",
+		"INSERT INTO orders VALUES (1), (2)",
+		"ALTER TABLE orders ADD DATA METRIC FUNCTION SNOWFLAKE.CORE.ROW_COUNT ON ()",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCount  int
+	}{
+		{name: "AllTables", wantStatus: http.StatusOK, wantCount: 1},
+		{name: "Table", body: `{"table": "orders"}`, wantStatus: http.StatusOK, wantCount: 1},
+		{name: "TableWithoutMetrics", body: `{"table": "customers"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "InvalidBody", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/data-metrics/evaluate", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler.EvaluateDataMetrics(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp types.EvaluateDataMetricsResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Results) != tt.wantCount {
+				t.Fatalf("got %d results, want %d", len(resp.Results), tt.wantCount)
+			}
+			if got := resp.Results[0]; got.MetricName != "ROW_COUNT" || got.Value == nil || *got.Value != 2 {
+				t.Errorf("result = %+v, want ROW_COUNT of 2", got)
+			}
+		})
+	}
+}
+
+// TestAdminHandler_ListSentEmails tests listing the emails sent with
+// SYSTEM$SEND_EMAIL.
+func TestAdminHandler_ListSentEmails(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(mgr, repo)
+	handler := NewAdminHandler(executor)
+
+	ctx := context.Background()
+	if _, err := executor.Execute(ctx, "CREATE NOTIFICATION INTEGRATION ops_email TYPE = EMAIL ENABLED = TRUE"); err != nil {
+		t.Fatalf("CREATE NOTIFICATION INTEGRATION error = %v", err)
+	}
+	if _, err := executor.Query(ctx, "CALL SYSTEM$SEND_EMAIL('ops_email', 'ops@example.com', 'Report', 'done')"); err != nil {
+		t.Fatalf("CALL SYSTEM$SEND_EMAIL error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/notifications", nil)
+	w := httptest.NewRecorder()
+	handler.ListSentEmails(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp types.SentEmailsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Emails) != 1 {
+		t.Fatalf("got %d emails, want 1", len(resp.Emails))
+	}
+	got := resp.Emails[0]
+	got.SentAt = ""
+	want := types.SentEmailResponse{
+		Integration: "OPS_EMAIL",
+		Recipients:  []string{"ops@example.com"},
+		Subject:     "Report",
+		Body:        "done",
+		ContentType: "text/plain",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("email mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdminHandler_MetadataCache(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	handler := NewAdminHandler(query.NewExecutor(mgr, repo))
+
+	ctx := context.Background()
+	if _, err := repo.CreateDatabase(ctx, "CACHED_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	for range 4 {
+		if _, err := repo.GetDatabaseByName(ctx, "CACHED_DB"); err != nil {
+			t.Fatalf("GetDatabaseByName() error = %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/metadata-cache", nil)
+	w := httptest.NewRecorder()
+	handler.MetadataCache(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var got types.MetadataCacheResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := types.MetadataCacheResponse{Enabled: true, Hits: 3, Misses: 1, HitRate: 0.75, Entries: 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestAdminHandler_CompatReport tests returning the compatibility report as
+// JSON and Markdown, and that it is only served when enabled.
+func TestAdminHandler_CompatReport(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(mgr, repo, query.WithCompatReport(query.NewCompatReport()))
+	if _, err := executor.Query(context.Background(), "SELECT * FROM range(2)"); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	handler := NewAdminHandler(executor)
+
+	tests := []struct {
+		name       string
+		handler    *AdminHandler
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "JSON", handler: handler, target: "/admin/compat-report", wantStatus: http.StatusOK, wantBody: `"statement": "SELECT * FROM range(2)"`},
+		{name: "Markdown", handler: handler, target: "/admin/compat-report?format=markdown", wantStatus: http.StatusOK, wantBody: "## Translation fallbacks (1)"},
+		{name: "UnknownFormat", handler: handler, target: "/admin/compat-report?format=xml", wantStatus: http.StatusBadRequest, wantBody: "Unknown format"},
+		{name: "Disabled", handler: NewAdminHandler(query.NewExecutor(mgr, repo)), target: "/admin/compat-report", wantStatus: http.StatusNotFound, wantBody: "COMPAT_REPORT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.CompatReport(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestAdminHandler_DiffResults tests comparing the results of two statements,
+// and of a statement with expected rows.
+func TestAdminHandler_DiffResults(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	mgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(mgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(mgr, repo)
+	handler := NewAdminHandler(executor)
+
+	ctx := context.Background()
+	for _, sql := range []string{
+		"CREATE TABLE source_orders (id INTEGER, amount NUMBER(10,2))",
+		"CREATE TABLE target_orders (id INTEGER, amount NUMBER(10,2))",
+		"INSERT INTO source_orders VALUES (1, 10.00), (2, 20.00), (3, 30.00)",
+		"INSERT INTO target_orders VALUES (1, 10.00), (2, 25.00), (4, 40.00)",
+	} {
+		if _, err := executor.Execute(ctx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       types.DiffResponse
+	}{
+		{
+			name:       "StatementsByKey",
+			body:       `{"left": "SELECT * FROM source_orders", "right": "SELECT * FROM target_orders", "key": ["ID"]}`,
+			wantStatus: http.StatusOK,
+			want: types.DiffResponse{
+				LeftColumns: []string{"id", "amount"}, RightColumns: []string{"id", "amount"},
+				MissingColumns: []string{}, ExtraColumns: []string{},
+				LeftRowCount: 3, RightRowCount: 3,
+				Removed: []types.DiffRowResponse{{Index: 2, Values: []interface{}{"3", "30.00"}}},
+				Added:   []types.DiffRowResponse{{Index: 2, Values: []interface{}{"4", "40.00"}}},
+				Changed: []types.ChangedRowResponse{{
+					LeftIndex: 1, RightIndex: 1, Key: []interface{}{"2"},
+					Cells: []types.CellDiffResponse{{Column: "amount", Left: "20.00", Right: "25.00"}},
+				}},
+			},
+		},
+		{
+			name:       "ExpectedRows",
+			body:       `{"left": "SELECT id, amount FROM source_orders", "expected": {"rows": [[3, 30], [1, 10], [2, "20.0"]]}}`,
+			wantStatus: http.StatusOK,
+			want: types.DiffResponse{
+				Equal:       true,
+				LeftColumns: []string{"id", "amount"}, RightColumns: []string{"id", "amount"},
+				MissingColumns: []string{}, ExtraColumns: []string{},
+				LeftRowCount: 3, RightRowCount: 3,
+				Removed: []types.DiffRowResponse{}, Added: []types.DiffRowResponse{}, Changed: []types.ChangedRowResponse{},
+			},
+		},
+		{name: "MissingRight", body: `{"left": "SELECT 1"}`, wantStatus: http.StatusBadRequest},
+		{name: "ShortExpectedRow", body: `{"left": "SELECT 1 AS a, 2 AS b", "expected": {"rows": [[1]]}}`, wantStatus: http.StatusBadRequest},
+		{name: "UnknownKey", body: `{"left": "SELECT 1 AS a", "right": "SELECT 1 AS a", "key": ["b"]}`, wantStatus: http.StatusBadRequest},
+		{name: "FailedStatement", body: `{"left": "SELECT * FROM missing", "right": "SELECT 1"}`, wantStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/diff", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler.DiffResults(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got types.DiffResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("response mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
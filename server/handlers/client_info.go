@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SessionHandlerOption configures a SessionHandler.
+type SessionHandlerOption func(*SessionHandler)
+
+// WithMinClientVersions rejects logins from drivers older than the minimum
+// version of their CLIENT_APP_ID, such as {"Go": "1.10.0"}. Drivers without a
+// minimum are accepted.
+func WithMinClientVersions(versions map[string]string) SessionHandlerOption {
+	return func(h *SessionHandler) {
+		h.minClientVersions = make(map[string]string, len(versions))
+		for appID, version := range versions {
+			h.minClientVersions[strings.ToUpper(appID)] = version
+		}
+	}
+}
+
+// ParseMinClientVersions parses a comma-separated list of CLIENT_APP_ID=version
+// pairs, such as "Go=1.10.0,JDBC=3.14.0".
+func ParseMinClientVersions(s string) (map[string]string, error) {
+	versions := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		appID, version, ok := strings.Cut(pair, "=")
+		appID, version = strings.TrimSpace(appID), strings.TrimSpace(version)
+		if !ok || appID == "" {
+			return nil, fmt.Errorf("invalid client version %q: must be CLIENT_APP_ID=version", pair)
+		}
+		if _, err := parseVersion(version); err != nil {
+			return nil, fmt.Errorf("invalid version of client %s: %w", appID, err)
+		}
+		versions[appID] = version
+	}
+	return versions, nil
+}
+
+// checkClientVersion returns an error if a driver's version is below the
+// minimum for its CLIENT_APP_ID. Missing and unparsable versions are below
+// every minimum.
+func (h *SessionHandler) checkClientVersion(appID, version string) error {
+	minimum, ok := h.minClientVersions[strings.ToUpper(appID)]
+	if !ok {
+		return nil
+	}
+	if compareVersions(version, minimum) < 0 {
+		return fmt.Errorf("client %s version %s is not supported: the minimum supported version is %s", appID, version, minimum)
+	}
+	return nil
+}
+
+// compareVersions compares dotted numeric versions, such as 1.9.0 and 1.10.0.
+// A version that cannot be parsed is lower than any other.
+func compareVersions(a, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseVersion parses a dotted numeric version. A suffix after a hyphen, such
+// as -SNAPSHOT, is ignored.
+func parseVersion(version string) ([]int, error) {
+	version, _, _ = strings.Cut(strings.TrimSpace(version), "-")
+	if version == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+	parts := strings.Split(version, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
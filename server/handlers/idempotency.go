@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// idempotencyKeyHeader is the header carrying a client's idempotency key.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentReplayHeader marks responses replayed for a repeated idempotency key.
+const idempotentReplayHeader = "Idempotent-Replayed"
+
+// idempotencyKey returns the idempotency key of a statement submission and
+// whether it may repeat an earlier submission. The key is the Idempotency-Key
+// header, whose submissions may always be repeated, or the SQL API's
+// requestId query parameter, whose submissions are retries only with
+// retry=true, as Snowflake's client SDKs send them.
+func idempotencyKey(r *http.Request) (string, bool, error) {
+	if key := r.Header.Get(idempotencyKeyHeader); key != "" {
+		return "header:" + key, true, nil
+	}
+	params := r.URL.Query()
+	requestID := params.Get("requestId")
+	if requestID == "" {
+		return "", false, nil
+	}
+	retry := false
+	if value := params.Get("retry"); value != "" {
+		var err error
+		retry, err = strconv.ParseBool(value)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid retry parameter %q", value)
+		}
+	}
+	return "requestId:" + requestID, retry, nil
+}
+
+// idempotencyScope returns the scope of the idempotency keys of a request:
+// its authorization token, which identifies the client's session or user. Keys
+// repeated by other clients thus submit new statements, as Snowflake scopes
+// request IDs, instead of replaying another client's statement and results.
+func idempotencyScope(r *http.Request) string {
+	return extractToken(r)
+}
+
+// replayStatement responds to a repeated submission of an idempotency key
+// with the status of the statement the key's first submission created, as
+// GetStatement reports it, without running the statement again. A repeated
+// requestId without retry=true, and a key repeated with other SQL, are
+// rejected.
+func (h *RestAPIv2Handler) replayStatement(w http.ResponseWriter, stmt *query.Statement, sqlText string, retry bool) {
+	if stmt.SQLText != sqlText {
+		h.sendError(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("Idempotency key already used by statement %s with different SQL", stmt.Handle), types.SQLState42000)
+		return
+	}
+	if !retry {
+		h.sendError(w, http.StatusConflict,
+			fmt.Sprintf("Request ID already used by statement %s; set retry=true to retry it", stmt.Handle), types.SQLState42000)
+		return
+	}
+
+	resp, err := h.statementStatusResponse(stmt, 0)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+		return
+	}
+	status := http.StatusOK
+	if resp.Code == types.ResponseCodeStatementPending {
+		status = http.StatusAccepted
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(idempotentReplayHeader, "true")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
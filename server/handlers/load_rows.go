@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// loadContentTypes maps the content types of uploaded rows to their formats.
+var loadContentTypes = map[string]string{
+	"text/csv":                  query.LoadFormatCSV,
+	"text/tab-separated-values": query.LoadFormatTSV,
+	"application/x-ndjson":      query.LoadFormatNDJSON,
+	"application/jsonl":         query.LoadFormatNDJSON,
+}
+
+// loadExtensions maps the extensions of uploaded file names to their formats.
+var loadExtensions = map[string]string{
+	".csv":    query.LoadFormatCSV,
+	".tsv":    query.LoadFormatTSV,
+	".ndjson": query.LoadFormatNDJSON,
+	".jsonl":  query.LoadFormatNDJSON,
+}
+
+// LoadRows handles POST /api/v2/databases/{database}/schemas/{schema}/tables/{table}/rows,
+// loading CSV, TSV, or NDJSON rows into a table without staging them (see
+// query.Executor.LoadRows). The rows are the request body, or the first file
+// of a multipart/form-data body. The format query parameter selects csv, tsv,
+// or ndjson; otherwise the format follows from the content type, or from the
+// extension of the uploaded file's name.
+func (h *RestAPIv2Handler) LoadRows(w http.ResponseWriter, r *http.Request) {
+	dbName := chi.URLParam(r, "database")
+	schemaName := chi.URLParam(r, "schema")
+	tableName := chi.URLParam(r, "table")
+
+	body, contentType, fileName, err := loadRowsBody(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error(), types.SQLState42000)
+		return
+	}
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = loadContentTypes[contentType]
+	}
+	if format == "" {
+		format = loadExtensions[strings.ToLower(path.Ext(fileName))]
+	}
+	if format == "" {
+		h.sendError(w, http.StatusBadRequest, "Unknown rows format: set the format parameter to csv, tsv, or ndjson", types.SQLState42000)
+		return
+	}
+
+	loaded, err := h.executor.LoadRows(r.Context(), dbName, schemaName, tableName, format, body)
+	if errors.Is(err, query.ErrTableNotFound) {
+		h.sendError(w, http.StatusNotFound, "Table not found", types.SQLState02000)
+		return
+	}
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error(), types.SQLState22000)
+		return
+	}
+
+	resp := types.LoadRowsResponse{
+		Database:   dbName,
+		Schema:     schemaName,
+		Table:      tableName,
+		RowsLoaded: loaded,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// loadRowsBody returns the rows of a LoadRows request, with their content type
+// and file name: the request body, or the first file part of a multipart body.
+func loadRowsBody(r *http.Request) (io.Reader, string, string, error) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "multipart/form-data" {
+		return r.Body, contentType, "", nil
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid multipart body: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", "", errors.New("multipart body has no file")
+		}
+		if err != nil {
+			return nil, "", "", fmt.Errorf("invalid multipart body: %w", err)
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		return part, partType, part.FileName(), nil
+	}
+}
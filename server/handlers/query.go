@@ -2,15 +2,24 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/go-chi/chi/v5"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
 	"github.com/nnnkkk7/snowflake-emulator/server/apierror"
@@ -21,14 +30,84 @@ import (
 type QueryHandler struct {
 	executor   *query.Executor
 	sessionMgr *session.Manager
+	// chunks splits large results into chunks drivers download, or is nil to
+	// return results whole.
+	chunks *query.ChunkStore
+	// running are the statements being executed, which abort requests cancel.
+	running runningRequests
+}
+
+// runningRequests tracks the statements of query requests being executed,
+// keyed by session ID and the requestId of the request.
+type runningRequests struct {
+	mu      sync.Mutex
+	cancels map[requestKey]context.CancelFunc
+}
+
+// requestKey identifies a query request of a session.
+type requestKey struct {
+	sessionID int64
+	requestID string
+}
+
+// track registers the statement of a query request, so that abort cancels it.
+// The returned function must be called when the statement finishes.
+func (r *runningRequests) track(ctx context.Context, sessionID int64, requestID string) (context.Context, func()) {
+	if requestID == "" {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey{sessionID: sessionID, requestID: requestID}
+	r.mu.Lock()
+	if r.cancels == nil {
+		r.cancels = make(map[requestKey]context.CancelFunc)
+	}
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, key)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// abort cancels the statement of a session's query request, and reports
+// whether it was running.
+func (r *runningRequests) abort(sessionID int64, requestID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := requestKey{sessionID: sessionID, requestID: requestID}
+	cancel, ok := r.cancels[key]
+	if ok {
+		cancel()
+		delete(r.cancels, key)
+	}
+	return ok
+}
+
+// QueryHandlerOption configures a QueryHandler.
+type QueryHandlerOption func(*QueryHandler)
+
+// WithResultChunks returns large results in chunks kept by store, which
+// drivers download from DownloadChunk.
+func WithResultChunks(store *query.ChunkStore) QueryHandlerOption {
+	return func(h *QueryHandler) {
+		h.chunks = store
+	}
 }
 
 // NewQueryHandler creates a new query handler.
-func NewQueryHandler(executor *query.Executor, sessionMgr *session.Manager) *QueryHandler {
-	return &QueryHandler{
+func NewQueryHandler(executor *query.Executor, sessionMgr *session.Manager, opts ...QueryHandlerOption) *QueryHandler {
+	h := &QueryHandler{
 		executor:   executor,
 		sessionMgr: sessionMgr,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // ExecuteQuery handles query execution requests with gosnowflake protocol.
@@ -49,6 +128,11 @@ func (h *QueryHandler) ExecuteQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sessionID := sess.ID
+	ctx = withSession(ctx, sess)
+	ctx = detachFromClient(ctx)
+	// Drivers whose context is canceled send an abort request naming this one
+	ctx, done := h.running.track(ctx, sessionID, r.URL.Query().Get("requestId"))
+	defer done()
 
 	// Parse request using new gosnowflake protocol
 	var req types.QueryRequest
@@ -62,18 +146,81 @@ func (h *QueryHandler) ExecuteQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A QUERY_TAG sent with the statement overrides the session's
+	if tag := queryTag(req.Parameters); tag != "" {
+		ctx = withQueryTag(ctx, tag)
+	}
+
 	// Classify the SQL statement
 	classification := query.ClassifySQL(req.SQLText)
 
+	// Drivers send ? and :N parameters as bindings rather than interpolating them
+	bindings, err := requestBindings(req.Bindings)
+	if err != nil {
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeInvalidParameter, err.Error()))
+		return
+	}
+	sqlText, err := h.executor.BindParameters(req.SQLText, bindings)
+	if err != nil {
+		var limit *query.BindingLimitError
+		if errors.As(err, &limit) {
+			sendError(w, apierror.NewSQLCompilationError("SQL compilation error:\n"+limit.Error()))
+			return
+		}
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeInvalidParameter, err.Error()))
+		return
+	}
+
 	if classification.IsQuery {
-		h.executeQuery(w, ctx, sessionID, req.SQLText)
+		h.executeQuery(w, ctx, sessionID, sqlText, chunkBaseURL(r))
 	} else {
-		h.executeDML(w, ctx, sessionID, req.SQLText)
+		h.executeDML(w, ctx, sessionID, sqlText)
 	}
 }
 
-// executeQuery executes a SELECT query with gosnowflake protocol.
-func (h *QueryHandler) executeQuery(w http.ResponseWriter, ctx context.Context, sessionID int64, sqlText string) { //nolint:revive // context-as-argument: keeping w first for handler consistency
+// requestBindings converts the bindings of a gosnowflake query request, each
+// {"type": ..., "value": ...} with a null value for NULL, to query bindings.
+// Array bindings, whose value is a list, bind bulk inserts once per element.
+func requestBindings(raw map[string]interface{}) (map[string]*query.BindingValue, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	bindings := make(map[string]*query.BindingValue, len(raw))
+	for key, v := range raw {
+		param, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid binding %s", key)
+		}
+		typeName, _ := param["type"].(string)
+		switch value := param["value"].(type) {
+		case nil:
+			bindings[key] = &query.BindingValue{Type: query.ValueNull}
+		case string:
+			bindings[key] = &query.BindingValue{Type: typeName, Value: value}
+		case []interface{}:
+			values := make([]*string, len(value))
+			for i, element := range value {
+				switch element := element.(type) {
+				case nil:
+				case string:
+					values[i] = &element
+				default:
+					s := fmt.Sprint(element)
+					values[i] = &s
+				}
+			}
+			bindings[key] = &query.BindingValue{Type: typeName, Values: values}
+		default:
+			bindings[key] = &query.BindingValue{Type: typeName, Value: fmt.Sprint(value)}
+		}
+	}
+	return bindings, nil
+}
+
+// executeQuery executes a SELECT query with gosnowflake protocol. Results
+// larger than a chunk return their first chunk, and list the others with
+// URLs under baseURL.
+func (h *QueryHandler) executeQuery(w http.ResponseWriter, ctx context.Context, sessionID int64, sqlText, baseURL string) { //nolint:revive // context-as-argument: keeping w first for handler consistency
 	// Generate unique query ID
 	queryID := generateQueryID()
 
@@ -82,15 +229,30 @@ func (h *QueryHandler) executeQuery(w http.ResponseWriter, ctx context.Context,
 	if err != nil {
 		// Use apierror for error classification
 		// Include the underlying error in the message for debugging
-		sendError(w, apierror.WrapError(apierror.CodeSQLExecutionError, fmt.Sprintf("query execution failed: %v", err), err))
+		err = canceledError(ctx, err)
+		sendError(w, executionError(queryID, fmt.Sprintf("query execution failed: %v", err), err))
 		return
 	}
 
 	// Use column types captured from actual query result
 	rowType := result.ColumnTypes
 
-	// Convert all values to strings for gosnowflake protocol
-	rowSet := convertRowsToStrings(result.Rows)
+	rows := result.Rows
+	var chunks []types.ResultChunk
+	if h.chunks != nil {
+		var infos []query.ChunkInfo
+		rows, infos = h.chunks.Split(queryID, result)
+		for i, info := range infos {
+			chunks = append(chunks, types.ResultChunk{
+				URL:              fmt.Sprintf("%s/%s/%d", baseURL, queryID, i),
+				RowCount:         info.RowCount,
+				UncompressedSize: info.Size,
+			})
+		}
+	}
+
+	// Convert all values to strings for gosnowflake protocol; NULLs stay null
+	rowSet := convertRowsToStrings(rows, rowType)
 
 	// Build success response
 	resp := types.QueryResponse{
@@ -104,6 +266,8 @@ func (h *QueryHandler) executeQuery(w http.ResponseWriter, ctx context.Context,
 			Total:             int64(len(result.Rows)),
 			Returned:          int64(len(result.Rows)),
 			QueryResultFormat: config.QueryResultFormatJSON,
+			Chunks:            chunks,
+			Warnings:          result.Warnings,
 		},
 	}
 
@@ -120,13 +284,19 @@ func (h *QueryHandler) executeDML(w http.ResponseWriter, ctx context.Context, se
 	// Execute with history tracking
 	result, err := h.executor.ExecuteWithHistory(ctx, fmt.Sprintf("%d", sessionID), queryID, sqlText)
 	if err != nil {
-		sendError(w, apierror.WrapError(apierror.CodeSQLExecutionError, "statement execution failed", err))
+		sendError(w, executionError(queryID, "statement execution failed", canceledError(ctx, err)))
 		return
 	}
 
 	// Get statement type ID using the classifier
 	stmtTypeID := query.GetStatementTypeID(sqlText)
 
+	// Return the session's parameters, which the statement may have altered
+	var parameters []types.ParameterBinding
+	if sess, err := h.sessionMgr.GetSessionByID(ctx, sessionID); err == nil {
+		parameters = sessionParameters(sess)
+	}
+
 	// Build success response
 	resp := types.QueryResponse{
 		Success: true,
@@ -137,6 +307,8 @@ func (h *QueryHandler) executeDML(w http.ResponseWriter, ctx context.Context, se
 			Total:             result.RowsAffected,
 			Returned:          0,
 			QueryResultFormat: config.QueryResultFormatJSON,
+			Warnings:          result.Warnings,
+			Parameters:        parameters,
 		},
 	}
 
@@ -145,18 +317,80 @@ func (h *QueryHandler) executeDML(w http.ResponseWriter, ctx context.Context, se
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// AbortQuery handles query abort requests.
+// chunkPath is the path of the chunk downloads of query results.
+const chunkPath = "/queries/v1/chunks"
+
+// chunkBaseURL returns the absolute URL chunks of results are downloaded
+// under, on the host the driver sent its request to.
+func chunkBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + r.Host + chunkPath
+}
+
+// DownloadChunk handles GET /queries/v1/chunks/{queryId}/{index}. It sends a
+// chunk of a large result as Snowflake does: JSON arrays of the row values
+// separated by commas, which the driver wraps in brackets. Chunks are
+// addressed by their unguessable query ID rather than the session token,
+// since drivers download them without it.
+func (h *QueryHandler) DownloadChunk(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil || h.chunks == nil {
+		http.NotFound(w, r)
+		return
+	}
+	rows, rowType, ok := h.chunks.Chunk(chi.URLParam(r, "queryId"), index)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	buffered := bufio.NewWriter(w)
+	for i, row := range convertRowsToStrings(rows, rowType) {
+		if i > 0 {
+			_ = buffered.WriteByte(',')
+		}
+		encoded, _ := json.Marshal(row)
+		_, _ = buffered.Write(encoded)
+	}
+	_ = buffered.Flush()
+}
+
+// AbortQuery handles query abort requests, canceling the statement of the
+// query request they name.
 func (h *QueryHandler) AbortQuery(w http.ResponseWriter, r *http.Request) {
+	token := extractToken(r)
+	if token == "" {
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeSessionNotFound, "Authorization token required"))
+		return
+	}
+	sess, err := h.sessionMgr.ValidateSession(r.Context(), token)
+	if err != nil {
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeSessionExpired, "Session expired or invalid"))
+		return
+	}
+
 	var req types.AbortRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		sendError(w, apierror.NewSnowflakeError(apierror.CodeInvalidParameter, "Invalid request body"))
 		return
 	}
 
-	// TODO: Implement query cancellation tracking
-	resp := types.AbortResponse{
-		Success: true,
-		Message: "Query abort requested (not yet implemented)",
+	// Statements that already finished are reported as Snowflake does, which
+	// drivers take as the abort having nothing left to do
+	resp := types.AbortResponse{Success: true}
+	if !h.running.abort(sess.ID, req.RequestID) {
+		resp = types.AbortResponse{
+			Code:    apierror.CodeQueryNotExecuting,
+			Message: "Identified SQL statement is not currently executing.",
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -164,6 +398,74 @@ func (h *QueryHandler) AbortQuery(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// detachFromClient returns ctx unaffected by the client disconnecting, unless
+// the session's ABORT_DETACHED_QUERY is set. Like Snowflake, statements keep
+// running when their client goes away.
+func detachFromClient(ctx context.Context) context.Context {
+	if query.SessionParametersFromContext(ctx).AbortDetachedQuery {
+		return ctx
+	}
+	return context.WithoutCancel(ctx)
+}
+
+// canceledError returns err marked with the context's error when the
+// statement's context was canceled, as DuckDB reports interrupted statements
+// with errors of its own.
+func canceledError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	return err
+}
+
+// executionError converts a statement execution failure into a Snowflake error.
+// Write conflicts that persisted through retries are reported as lock timeouts,
+// and calls of known but unsupported functions and syntax errors as compilation
+// errors.
+func executionError(statementID, message string, err error) *apierror.SnowflakeError {
+	if errors.Is(err, context.Canceled) {
+		return apierror.NewQueryCanceledError().WithData("originalError", err.Error())
+	}
+	if sfErr := resourceError(err); sfErr != nil {
+		return sfErr.WithData("originalError", err.Error())
+	}
+	if errors.Is(err, connection.ErrTransactionConflict) {
+		return apierror.NewLockTimeoutError(lockTimeoutMessage(statementID, err)).WithData("originalError", err.Error())
+	}
+	var unsupported *query.UnsupportedFunctionError
+	if errors.As(err, &unsupported) {
+		return apierror.NewSQLCompilationError(unsupported.Error()).WithData("originalError", unsupported.Err.Error())
+	}
+	var syntax *query.TranslationError
+	if errors.As(err, &syntax) {
+		return apierror.NewSyntaxError(syntax.Line(), syntax.Position(), syntax.Construct).WithData("originalError", syntax.Err.Error())
+	}
+	return apierror.WrapError(apierror.CodeSQLExecutionError, message, err)
+}
+
+// resourceError returns the Snowflake error of a statement that ran out of
+// memory or disk space, or nil if err is not one.
+func resourceError(err error) *apierror.SnowflakeError {
+	switch {
+	case connection.IsDiskFullError(err):
+		return apierror.NewDiskFullError()
+	case connection.IsOutOfMemoryError(err):
+		return apierror.NewOutOfMemoryError()
+	}
+	return nil
+}
+
+// lockTimeoutMessage formats Snowflake's lock wait timeout message, hiding the
+// DuckDB-specific conflict error.
+func lockTimeoutMessage(statementID string, err error) string {
+	var waited time.Duration
+	var lockErr *connection.LockTimeoutError
+	if errors.As(err, &lockErr) {
+		waited = lockErr.Waited
+	}
+	return fmt.Sprintf("Statement '%s' was aborted after waiting %.3f seconds for a lock held by another transaction (LOCK_TIMEOUT).", statementID, waited.Seconds())
+}
+
 // generateQueryID generates a unique query ID.
 func generateQueryID() string {
 	bytes := make([]byte, 8)
@@ -176,18 +478,107 @@ func generateQueryID() string {
 }
 
 // convertRowsToStrings converts all values in rows to strings for gosnowflake protocol.
-func convertRowsToStrings(rows [][]interface{}) [][]string {
-	result := make([][]string, len(rows))
+// Values are rendered in the wire format the driver decodes for each column's row type.
+// NULLs are nil so that they are sent as JSON null rather than as empty strings.
+func convertRowsToStrings(rows [][]interface{}, rowType []types.ColumnMetadata) [][]*string {
+	result := make([][]*string, len(rows))
 	for i, row := range rows {
-		strRow := make([]string, len(row))
+		strRow := make([]*string, len(row))
 		for j, val := range row {
 			if val == nil {
-				strRow[j] = ""
-			} else {
-				strRow[j] = fmt.Sprintf("%v", val)
+				continue
 			}
+			var column types.ColumnMetadata
+			if j < len(rowType) {
+				column = rowType[j]
+			}
+			str := formatWireValue(val, column)
+			strRow[j] = &str
 		}
 		result[i] = strRow
 	}
 	return result
 }
+
+// formatWireValue renders a value as the driver expects it for the column's row type:
+// dates as days since the epoch, times and timestamps as seconds with nanoseconds,
+// TIMESTAMP_TZ with its offset in minutes plus 1440, binary values as hex, and
+// semi-structured values as JSON.
+func formatWireValue(val interface{}, column types.ColumnMetadata) string {
+	switch v := val.(type) {
+	case time.Time:
+		return formatWireTime(v, column.Type)
+	case []byte:
+		return strings.ToUpper(hex.EncodeToString(v))
+	case map[string]interface{}, []interface{}:
+		if encoded, err := json.Marshal(v); err == nil {
+			return string(encoded)
+		}
+	}
+	if number, ok := formatNumber(val); ok {
+		return number
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// formatNumber renders a numeric value as an exact decimal string, as Snowflake
+// sends numbers to avoid float precision loss. Decimals keep their full scale,
+// e.g. 1.50 for a NUMBER(10,2). It reports false for non-numeric values.
+func formatNumber(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case *big.Int:
+		if v == nil {
+			return "", false
+		}
+		return v.String(), true
+	case duckdb.Decimal:
+		return formatDecimal(v), true
+	}
+	return "", false
+}
+
+// formatDecimal renders a DuckDB decimal with exactly its scale's fractional digits.
+func formatDecimal(d duckdb.Decimal) string {
+	if d.Value == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(d.Value).String()
+	scale := int(d.Scale)
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if d.Value.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// formatWireTime renders a temporal value for the given row type.
+func formatWireTime(t time.Time, rowType string) string {
+	// Wall clock values are stored without a zone, so read them as UTC
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	switch rowType {
+	case "date":
+		return strconv.FormatInt(wall.Unix()/86400, 10)
+	case "time":
+		sinceMidnight := t.Hour()*3600 + t.Minute()*60 + t.Second()
+		return fmt.Sprintf("%d.%09d", sinceMidnight, t.Nanosecond())
+	case "timestamp_ntz":
+		return fmt.Sprintf("%d.%09d", wall.Unix(), t.Nanosecond())
+	case "timestamp_ltz":
+		return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+	case "timestamp_tz":
+		_, offset := t.Zone()
+		return fmt.Sprintf("%d.%09d %d", t.Unix(), t.Nanosecond(), offset/60+1440)
+	}
+	return t.String()
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// historyStatuses are the statuses recorded in the query history.
+var historyStatuses = map[string]bool{"RUNNING": true, "SUCCESS": true, "FAILED": true}
+
+// ListQueries handles GET /api/v2/queries, listing the recorded query history
+// most recent first. The session_id, status, and query_text (a substring of
+// the SQL, matched case-insensitively) query parameters filter the entries,
+// as do query_tag and any number of label parameters of the form key=value,
+// since and until bound their start time (RFC 3339), and limit caps their
+// number (100 by default).
+func (h *RestAPIv2Handler) ListQueries(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	filter := metadata.QueryHistoryFilter{
+		SessionID:   params.Get("session_id"),
+		Status:      strings.ToUpper(params.Get("status")),
+		SQLContains: params.Get("query_text"),
+	}
+	if filter.Status != "" && !historyStatuses[filter.Status] {
+		h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid status: %s", params.Get("status")), types.SQLState42000)
+		return
+	}
+	filter.QueryTag = params.Get("query_tag")
+	for _, label := range params["label"] {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid label: %s: must be key=value", label), types.SQLState42000)
+			return
+		}
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		filter.Labels[key] = value
+	}
+	for name, bound := range map[string]*time.Time{"since": &filter.StartedAfter, "until": &filter.StartedBefore} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s: must be an RFC 3339 time", name), types.SQLState42000)
+			return
+		}
+		*bound = t
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			h.sendError(w, http.StatusBadRequest, "Invalid limit", types.SQLState42000)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.repo.FindQueryHistory(r.Context(), filter)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+		return
+	}
+
+	resp := make(types.ListQueriesResponse, len(entries))
+	for i, entry := range entries {
+		resp[i] = queryHistoryResponse(entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GetQuery handles GET /api/v2/queries/{queryId}, returning the recorded
+// history entry of a query.
+func (h *RestAPIv2Handler) GetQuery(w http.ResponseWriter, r *http.Request) {
+	queryID := chi.URLParam(r, "queryId")
+
+	entries, err := h.repo.FindQueryHistory(r.Context(), metadata.QueryHistoryFilter{QueryID: queryID, Limit: 1})
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+		return
+	}
+	if len(entries) == 0 {
+		h.sendError(w, http.StatusNotFound, "Query not found", types.SQLState02000)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(queryHistoryResponse(entries[0]))
+}
+
+// queryHistoryResponse converts a query history entry to its API response.
+func queryHistoryResponse(entry *metadata.QueryHistoryEntry) types.QueryHistoryResponse {
+	resp := types.QueryHistoryResponse{
+		QueryID:          entry.QueryID,
+		SessionID:        entry.SessionID,
+		QueryText:        entry.SQLText,
+		ExecutionStatus:  entry.Status,
+		StartTime:        entry.StartedAt.Format(time.RFC3339Nano),
+		TotalElapsedTime: entry.ExecutionTimeMs,
+		RowsProduced:     entry.RowsAffected,
+		ErrorMessage:     entry.ErrorMessage,
+		QueryTag:         entry.QueryTag,
+		Labels:           entry.Labels,
+	}
+	if entry.CompletedAt != nil {
+		resp.EndTime = entry.CompletedAt.Format(time.RFC3339Nano)
+	}
+	return resp
+}
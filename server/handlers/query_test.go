@@ -5,12 +5,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
-	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/duckdb/duckdb-go/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
@@ -439,3 +444,366 @@ func TestQueryHandler_QueryResultFormat(t *testing.T) {
 		t.Errorf("Expected queryResultFormat 'json', got %s", resp.Data.QueryResultFormat)
 	}
 }
+
+// TestQueryHandler_NullValues tests that NULLs are sent as JSON null and empty strings as "".
+func TestQueryHandler_NullValues(t *testing.T) {
+	handler, sessionMgr, _ := setupTestQueryHandler(t)
+
+	sess, err := sessionMgr.CreateSession(context.Background(), "testuser", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	body, _ := json.Marshal(types.QueryRequest{
+		SQLText: "SELECT NULL::VARCHAR AS n, '' AS e, NULL::INTEGER AS i, NULL::BINARY AS b, 'NULL' AS s",
+	})
+	httpReq := httptest.NewRequest(http.MethodPost, "/queries/v1/query-request", bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Snowflake Token=\""+sess.Token+"\"")
+	rr := httptest.NewRecorder()
+	handler.ExecuteQuery(rr, httpReq)
+
+	if !bytes.Contains(rr.Body.Bytes(), []byte(`"rowset":[[null,"",null,null,"NULL"]]`)) {
+		t.Errorf("Expected NULLs as JSON null in rowset, got %s", rr.Body.String())
+	}
+}
+
+// TestQueryHandler_SessionParameters tests that statement responses carry
+// the session's parameters, for drivers to see what ALTER SESSION changed.
+func TestQueryHandler_SessionParameters(t *testing.T) {
+	handler, sessionMgr, _ := setupTestQueryHandler(t)
+	ctx := context.Background()
+
+	sess, err := sessionMgr.CreateSession(ctx, "testuser", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := sessionMgr.AlterSessionParameters(ctx, sess.ID, map[string]interface{}{"QUERY_TAG": "etl"}, nil); err != nil {
+		t.Fatalf("AlterSessionParameters() error = %v", err)
+	}
+
+	body, _ := json.Marshal(types.QueryRequest{SQLText: "ALTER SESSION SET TIMEZONE = 'UTC'"})
+	httpReq := httptest.NewRequest(http.MethodPost, "/queries/v1/query-request", bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Snowflake Token=\""+sess.Token+"\"")
+	rr := httptest.NewRecorder()
+	handler.ExecuteQuery(rr, httpReq)
+
+	var resp types.QueryResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got %s", resp.Message)
+	}
+	got := make(map[string]string)
+	for _, param := range resp.Data.Parameters {
+		got[param.Name] = param.Value
+	}
+	if got["QUERY_TAG"] != "etl" || got["TIMEZONE"] != "UTC" {
+		t.Errorf("Expected QUERY_TAG etl and the default TIMEZONE UTC, got %v", resp.Data.Parameters)
+	}
+}
+
+// TestQueryHandler_ChunkedResults tests that large results return their first
+// chunk and list the others, which are downloaded from DownloadChunk.
+func TestQueryHandler_ChunkedResults(t *testing.T) {
+	handler, sessionMgr, _ := setupTestQueryHandler(t)
+	handler.chunks = query.NewChunkStore(time.Hour, query.WithChunkLimits(2, 0))
+
+	sess, err := sessionMgr.CreateSession(context.Background(), "testuser", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	body, _ := json.Marshal(types.QueryRequest{SQLText: "SELECT range AS n, NULLIF(range, 3) AS m FROM range(5) ORDER BY n"})
+	httpReq := httptest.NewRequest(http.MethodPost, "/queries/v1/query-request", bytes.NewReader(body))
+	httpReq.Host = "emulator:8080"
+	httpReq.Header.Set("Authorization", "Snowflake Token=\""+sess.Token+"\"")
+	rr := httptest.NewRecorder()
+	handler.ExecuteQuery(rr, httpReq)
+
+	var resp types.QueryResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Query failed: %s", resp.Message)
+	}
+	one, two := "1", "0"
+	if diff := cmp.Diff([][]*string{{&two, &two}, {&one, &one}}, resp.Data.RowSet); diff != "" {
+		t.Errorf("rowset mismatch (-want +got):\n%s", diff)
+	}
+	if resp.Data.Total != 5 || len(resp.Data.Chunks) != 2 {
+		t.Fatalf("total = %d, chunks = %+v, want 5 rows and 2 chunks", resp.Data.Total, resp.Data.Chunks)
+	}
+	wantURL := "http://emulator:8080/queries/v1/chunks/" + resp.Data.QueryID + "/0"
+	if chunk := resp.Data.Chunks[0]; chunk.URL != wantURL || chunk.RowCount != 2 {
+		t.Errorf("chunk 0 = %+v, want 2 rows at %s", chunk, wantURL)
+	}
+
+	router := chi.NewRouter()
+	router.Get("/queries/v1/chunks/{queryId}/{index}", handler.DownloadChunk)
+	tests := []struct {
+		index      string
+		wantStatus int
+		wantBody   string
+	}{
+		{index: "0", wantStatus: http.StatusOK, wantBody: `["2","2"],["3",null]`},
+		{index: "1", wantStatus: http.StatusOK, wantBody: `["4","4"]`},
+		{index: "2", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/queries/v1/chunks/"+resp.Data.QueryID+"/"+tt.index, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != tt.wantStatus {
+			t.Errorf("chunk %s: status = %d, want %d", tt.index, rr.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus == http.StatusOK && rr.Body.String() != tt.wantBody {
+			t.Errorf("chunk %s = %s, want %s", tt.index, rr.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+// TestFormatNumber tests rendering numbers as exact decimal strings.
+func TestFormatNumber(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		name     string
+		val      interface{}
+		expected string
+	}{
+		{name: "Int64", val: int64(-42), expected: "-42"},
+		{name: "Int32", val: int32(7), expected: "7"},
+		{name: "Float", val: 1.5, expected: "1.5"},
+		{name: "LargeFloat", val: 1e21, expected: "1e+21"},
+		{name: "BigInt", val: huge, expected: "123456789012345678901234567890"},
+		{name: "Decimal", val: duckdb.Decimal{Width: 10, Scale: 2, Value: big.NewInt(150)}, expected: "1.50"},
+		{name: "SmallDecimal", val: duckdb.Decimal{Width: 10, Scale: 3, Value: big.NewInt(-5)}, expected: "-0.005"},
+		{name: "WideDecimal", val: duckdb.Decimal{Width: 38, Scale: 3, Value: huge}, expected: "123456789012345678901234567.890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := formatNumber(tt.val)
+			if !ok || got != tt.expected {
+				t.Errorf("formatNumber(%v) = %q, %v, want %q", tt.val, got, ok, tt.expected)
+			}
+		})
+	}
+
+	if _, ok := formatNumber("1"); ok {
+		t.Error("formatNumber(string) reported a number")
+	}
+}
+
+// TestRequestBindings tests converting gosnowflake request bindings.
+func TestRequestBindings(t *testing.T) {
+	a, seven := "a", "7"
+	var raw map[string]interface{}
+	body := `{"1": {"type": "FIXED", "value": "42"}, "2": {"type": "TEXT", "value": null}, "3": {"type": "BOOLEAN", "value": "true"},
+		"4": {"type": "TEXT", "value": ["a", null, 7]}}`
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	got, err := requestBindings(raw)
+	if err != nil {
+		t.Fatalf("requestBindings() error = %v", err)
+	}
+	expected := map[string]*query.BindingValue{
+		"1": {Type: "FIXED", Value: "42"},
+		"2": {Type: query.ValueNull},
+		"3": {Type: "BOOLEAN", Value: "true"},
+		"4": {Type: "TEXT", Values: []*string{&a, nil, &seven}},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("requestBindings() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestExecutionError tests that persistent write conflicts are reported as lock
+// timeouts, unsupported functions as compilation errors, and exhausted memory or
+// disk as resource errors.
+func TestExecutionError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantCode     string
+		wantSQLState string
+		wantMessage  string
+		// wantOriginal is the originalError data, if not the error's message
+		wantOriginal string
+	}{
+		{
+			name:         "ExecutionFailure",
+			err:          errors.New("Catalog Error: Table with name missing does not exist!"),
+			wantCode:     apierror.CodeSQLExecutionError,
+			wantSQLState: apierror.SQLStateGeneralError,
+			wantMessage:  "statement execution failed",
+		},
+		{
+			name: "TransactionConflict",
+			err: fmt.Errorf("execution error: %w", &connection.LockTimeoutError{
+				Waited:   1500 * time.Millisecond,
+				Attempts: 3,
+				Err:      errors.New("TransactionContext Error: Conflict on update!"),
+			}),
+			wantCode:     apierror.CodeLockTimeout,
+			wantSQLState: apierror.SQLStateQueryCanceled,
+			wantMessage:  "Statement '01abc' was aborted after waiting 1.500 seconds for a lock held by another transaction (LOCK_TIMEOUT).",
+		},
+		{
+			name: "UnsupportedFunction",
+			err: &query.UnsupportedFunctionError{
+				Function: query.UnsupportedFunction{Name: "SEARCH", Reason: "not emulated", Issue: "https://example.com/issues/1"},
+				Err:      errors.New("query execution error: Catalog Error: Scalar Function with name search does not exist!"),
+			},
+			wantCode:     apierror.CodeSQLCompilationError,
+			wantSQLState: apierror.SQLStateSyntaxError,
+			wantMessage:  "function SEARCH is not supported by the emulator: not emulated (tracked in https://example.com/issues/1)",
+			wantOriginal: "query execution error: Catalog Error: Scalar Function with name search does not exist!",
+		},
+		{
+			name: "SyntaxError",
+			err: &query.TranslationError{
+				SQL:       "SELECT a,\n  b FROM t WHERE x = 1 AND AND y",
+				Construct: "AND",
+				Offset:    37,
+				Err:       errors.New(`execution error: Parser Error: syntax error at or near "AND"`),
+			},
+			wantCode:     apierror.CodeSQLCompilationError,
+			wantSQLState: apierror.SQLStateSyntaxError,
+			wantMessage:  "SQL compilation error:\nsyntax error line 2 at position 27 unexpected 'AND'.",
+			wantOriginal: `execution error: Parser Error: syntax error at or near "AND"`,
+		},
+		{
+			name:         "OutOfMemory",
+			err:          fmt.Errorf("execution error: %w", &duckdb.Error{Type: duckdb.ErrorTypeOutOfMemory, Msg: "Out of Memory Error: failed to allocate data of size 32.0 MiB (32.0 MiB/61.0 MiB used)"}),
+			wantCode:     apierror.CodeResourceExhausted,
+			wantSQLState: apierror.SQLStateOutOfMemory,
+			wantMessage:  "SQL execution internal error: Statement ran out of memory.",
+		},
+		{
+			name:         "DiskFull",
+			err:          &duckdb.Error{Type: duckdb.ErrorTypeIO, Msg: "IO Error: Could not write file: No space left on device"},
+			wantCode:     apierror.CodeResourceExhausted,
+			wantSQLState: apierror.SQLStateDiskFull,
+			wantMessage:  "SQL execution internal error: Statement ran out of disk space.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sfErr := executionError("01abc", "statement execution failed", tt.err)
+			if sfErr.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", sfErr.Code, tt.wantCode)
+			}
+			if sfErr.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", sfErr.Message, tt.wantMessage)
+			}
+			if sfErr.SQLState != tt.wantSQLState {
+				t.Errorf("SQLState = %s, want %s", sfErr.SQLState, tt.wantSQLState)
+			}
+			wantOriginal := tt.wantOriginal
+			if wantOriginal == "" {
+				wantOriginal = tt.err.Error()
+			}
+			if sfErr.Data["originalError"] != wantOriginal {
+				t.Errorf("originalError = %v, want %q", sfErr.Data["originalError"], wantOriginal)
+			}
+		})
+	}
+}
+
+// TestQueryHandler_AbortQuery tests that abort requests cancel the statement
+// of the query request they name, as drivers send them when their context is
+// canceled.
+func TestQueryHandler_AbortQuery(t *testing.T) {
+	handler, sessionMgr, _ := setupTestQueryHandler(t)
+	sess, err := sessionMgr.CreateSession(context.Background(), "testuser", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	post := func(handle http.HandlerFunc, path string, body interface{}) map[string]interface{} {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		httpReq := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		httpReq.Header.Set("Authorization", "Snowflake Token=\""+sess.Token+"\"")
+		rr := httptest.NewRecorder()
+		handle(rr, httpReq)
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+	running := func() int {
+		handler.running.mu.Lock()
+		defer handler.running.mu.Unlock()
+		return len(handler.running.cancels)
+	}
+
+	responses := make(chan map[string]interface{}, 1)
+	go func() {
+		responses <- post(handler.ExecuteQuery, "/queries/v1/query-request?requestId=req-1",
+			types.QueryRequest{SQLText: "SELECT COUNT(*) FROM range(100000000000) a"})
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for running() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("query was not tracked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if resp := post(handler.AbortQuery, "/queries/v1/abort-request", types.AbortRequest{RequestID: "req-1"}); resp["success"] != true {
+		t.Errorf("AbortQuery() = %v, want success", resp)
+	}
+	select {
+	case resp := <-responses:
+		if resp["code"] != apierror.CodeQueryCanceled {
+			t.Errorf("aborted query code = %v, want %s", resp["code"], apierror.CodeQueryCanceled)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("query was not aborted")
+	}
+	if got := running(); got != 0 {
+		t.Errorf("running requests after abort = %d, want 0", got)
+	}
+
+	// Requests that are no longer running are reported as not executing
+	resp := post(handler.AbortQuery, "/queries/v1/abort-request", types.AbortRequest{RequestID: "req-1"})
+	if resp["success"] != false || resp["code"] != apierror.CodeQueryNotExecuting {
+		t.Errorf("AbortQuery() of a finished request = %v, want code %s", resp, apierror.CodeQueryNotExecuting)
+	}
+}
+
+// TestDetachFromClient tests that statements outlive their client unless ABORT_DETACHED_QUERY is set.
+func TestDetachFromClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        query.SessionParameters
+		wantCancelled bool
+	}{
+		{name: "default keeps running", params: query.SessionParameters{}, wantCancelled: false},
+		{name: "abort detached query", params: query.SessionParameters{AbortDetachedQuery: true}, wantCancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, disconnect := context.WithCancel(query.ContextWithSessionParameters(context.Background(), tt.params))
+			ctx := detachFromClient(client)
+			disconnect()
+
+			if cancelled := ctx.Err() != nil; cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
@@ -1,13 +1,24 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/duckdb/duckdb-go/v2"
 	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/warehouse"
 	"github.com/nnnkkk7/snowflake-emulator/server/apierror"
 	"github.com/nnnkkk7/snowflake-emulator/server/types"
@@ -17,31 +28,55 @@ import (
 type RestAPIv2Handler struct {
 	executor     *query.Executor
 	stmtMgr      *query.StatementManager
-	repo         *metadata.Repository
+	repo         metadata.Store
 	warehouseMgr *warehouse.Manager
+	sessionMgr   *session.Manager
+	jsonNumbers  bool
 }
 
-// NewRestAPIv2Handler creates a new REST API v2 handler.
-func NewRestAPIv2Handler(executor *query.Executor, stmtMgr *query.StatementManager, repo *metadata.Repository) *RestAPIv2Handler {
-	return &RestAPIv2Handler{
-		executor:     executor,
-		stmtMgr:      stmtMgr,
-		repo:         repo,
-		warehouseMgr: warehouse.NewManager(),
+// RestAPIv2Option configures a RestAPIv2Handler.
+type RestAPIv2Option func(*RestAPIv2Handler)
+
+// WithJSONNumbers sends numbers in result data as JSON numbers instead of the
+// strings Snowflake sends. Clients that decode them into float64 lose precision
+// beyond 2^53, so this is only meant for clients that cannot parse strings.
+func WithJSONNumbers() RestAPIv2Option {
+	return func(h *RestAPIv2Handler) {
+		h.jsonNumbers = true
+	}
+}
+
+// WithSessionManager sets the session manager whose sessions GetSession
+// reports.
+func WithSessionManager(mgr *session.Manager) RestAPIv2Option {
+	return func(h *RestAPIv2Handler) {
+		h.sessionMgr = mgr
 	}
 }
 
+// NewRestAPIv2Handler creates a new REST API v2 handler.
+func NewRestAPIv2Handler(executor *query.Executor, stmtMgr *query.StatementManager, repo metadata.Store, opts ...RestAPIv2Option) *RestAPIv2Handler {
+	return NewRestAPIv2HandlerWithWarehouse(executor, stmtMgr, repo, warehouse.NewManager(), opts...)
+}
+
 // NewRestAPIv2HandlerWithWarehouse creates a new REST API v2 handler with warehouse manager.
-func NewRestAPIv2HandlerWithWarehouse(executor *query.Executor, stmtMgr *query.StatementManager, repo *metadata.Repository, warehouseMgr *warehouse.Manager) *RestAPIv2Handler {
-	return &RestAPIv2Handler{
+func NewRestAPIv2HandlerWithWarehouse(executor *query.Executor, stmtMgr *query.StatementManager, repo metadata.Store, warehouseMgr *warehouse.Manager, opts ...RestAPIv2Option) *RestAPIv2Handler {
+	h := &RestAPIv2Handler{
 		executor:     executor,
 		stmtMgr:      stmtMgr,
 		repo:         repo,
 		warehouseMgr: warehouseMgr,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// SubmitStatement handles POST /api/v2/statements.
+// SubmitStatement handles POST /api/v2/statements. With the async=true query
+// parameter, the statement is queued for the statement manager's workers and
+// the handler returns 202 with its handle, to be polled with GetStatement.
+// Submissions with an idempotency key run at most once (see idempotencyKey).
 func (h *RestAPIv2Handler) SubmitStatement(w http.ResponseWriter, r *http.Request) {
 	var req types.SubmitStatementRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -53,13 +88,155 @@ func (h *RestAPIv2Handler) SubmitStatement(w http.ResponseWriter, r *http.Reques
 		h.sendError(w, http.StatusBadRequest, "Statement is required", types.SQLState42000)
 		return
 	}
+	if _, ok := req.Labels[""]; ok {
+		h.sendError(w, http.StatusBadRequest, "Label names cannot be empty", types.SQLState42000)
+		return
+	}
 
-	// Create statement record
-	stmt := h.stmtMgr.CreateStatement(req.Statement, req.Database, req.Schema, req.Warehouse)
+	async := false
+	if value := r.URL.Query().Get("async"); value != "" {
+		var err error
+		async, err = strconv.ParseBool(value)
+		if err != nil {
+			h.sendError(w, http.StatusBadRequest, "Invalid async parameter", types.SQLState42000)
+			return
+		}
+	}
+
+	key, retry, err := idempotencyKey(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error(), types.SQLState42000)
+		return
+	}
+
+	// Create statement record. A submission repeating an idempotency key
+	// replays the response of the statement it created instead.
+	var stmt *query.Statement
+	if key == "" {
+		stmt = h.stmtMgr.CreateStatement(req.Statement, req.Database, req.Schema, req.Warehouse)
+	} else {
+		var existing bool
+		stmt, existing = h.stmtMgr.CreateIdempotentStatement(idempotencyScope(r), key, req.Statement, req.Database, req.Schema, req.Warehouse)
+		if existing {
+			h.replayStatement(w, stmt, req.Statement, retry)
+			return
+		}
+	}
+
+	role := roleOrDefault(req.Role)
+	params := query.ParseSessionParameters(req.Parameters)
+	h.stmtMgr.SetParameters(stmt.Handle, params)
+	h.stmtMgr.SetLabels(stmt.Handle, queryTag(req.Parameters), req.Labels)
+	ctx := query.ContextWithSessionParameters(r.Context(), params)
+	ctx = query.ContextWithSessionInfo(ctx, query.SessionInfo{
+		Role:       role,
+		Database:   req.Database,
+		Schema:     req.Schema,
+		Warehouse:  strings.ToUpper(req.Warehouse),
+		Parameters: req.Parameters,
+	})
+	ctx = metadata.ContextWithOwner(ctx, role)
+
+	if async {
+		// Asynchronous statements outlive the request that submitted them
+		err := h.stmtMgr.Submit(context.WithoutCancel(ctx), stmt.Handle, func(ctx context.Context) {
+			h.runStatement(ctx, stmt, &req)
+		})
+		if err != nil {
+			sfErr := apierror.NewSnowflakeError(apierror.CodeInternalError, err.Error())
+			sfErr.SQLState = types.SQLState42000
+			h.stmtMgr.SetError(stmt.Handle, sfErr)
+			h.sendError(w, http.StatusServiceUnavailable, err.Error(), types.SQLState42000)
+			return
+		}
+		resp := types.StatementResponse{
+			StatementHandle:    stmt.Handle,
+			Code:               types.ResponseCodeStatementPending,
+			SQLState:           types.SQLState00000,
+			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
+			Message:            asyncExecutionMessage,
+			CreatedOn:          stmt.CreatedOn.UnixMilli(),
+			QueryTag:           stmt.QueryTag,
+			Labels:             stmt.Labels,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	// Execute the statement synchronously; CancelStatement cancels its context
 	h.stmtMgr.UpdateStatus(stmt.Handle, query.StatementStatusRunning)
+	ctx, cancel := context.WithCancel(detachFromClient(ctx))
+	defer cancel()
+	h.stmtMgr.SetCancelFunc(stmt.Handle, cancel)
 
-	// Execute the statement synchronously
-	ctx := r.Context()
+	outcome := h.runStatement(ctx, stmt, &req)
+	if outcome.err != nil {
+		resp := types.StatementResponse{
+			StatementHandle:    stmt.Handle,
+			Code:               outcome.err.Code,
+			SQLState:           outcome.sqlState,
+			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
+			Message:            outcome.err.Message,
+			CreatedOn:          stmt.CreatedOn.UnixMilli(),
+			QueryTag:           stmt.QueryTag,
+			Labels:             stmt.Labels,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	// Build response based on statement type
+	var resp types.StatementResponse
+	if outcome.execResult == nil {
+		var err error
+		resp, err = h.buildStatementResponse(stmt, 0)
+		if err != nil {
+			h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+			return
+		}
+	} else {
+		// Build response for DDL/DML
+		resp = h.buildExecResponse(stmt, outcome.execResult)
+	}
+	resp.QueryTag, resp.Labels = stmt.QueryTag, stmt.Labels
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// asyncExecutionMessage is the message of the response to an asynchronous
+// statement submission, as Snowflake words it.
+const asyncExecutionMessage = "Asynchronous execution in progress. Use provided query id to perform query monitoring and management."
+
+// statementOutcome is the outcome of running a submitted statement.
+type statementOutcome struct {
+	// execResult is the result of a DDL or DML statement, or nil for a query.
+	execResult *query.ExecResult
+	// err and sqlState describe a failed statement.
+	err      *apierror.SnowflakeError
+	sqlState string
+}
+
+// runStatement executes a submitted statement and records its result or error
+// in the statement manager. The rows affected by a DDL or DML statement are
+// recorded as a one-row result, so that GetStatement reports them.
+func (h *RestAPIv2Handler) runStatement(ctx context.Context, stmt *query.Statement, req *types.SubmitStatementRequest) statementOutcome {
+	// Statements are recorded in the query history under their handles
+	started := time.Now()
+	entry, err := h.repo.RecordQueryStart(ctx, "", stmt.Handle, req.Statement)
+	if err != nil {
+		log.Printf("Failed to record query start: %v", err)
+	}
+	if tag := queryTag(req.Parameters); entry != nil && (tag != "" || len(req.Labels) > 0) {
+		if err := h.repo.RecordQueryLabels(ctx, entry.ID, tag, req.Labels); err != nil {
+			log.Printf("Failed to record query labels: %v", err)
+		}
+	}
 
 	// Classify the SQL statement to determine routing
 	classification := query.ClassifySQL(req.Statement)
@@ -69,14 +246,15 @@ func (h *RestAPIv2Handler) SubmitStatement(w http.ResponseWriter, r *http.Reques
 
 	var result *query.Result
 	var execResult *query.ExecResult
-	var err error
 
 	if classification.IsQuery {
-		// Handle SELECT, SHOW, DESCRIBE, EXPLAIN
+		// Handle SELECT, SHOW, DESCRIBE, EXPLAIN, spilling large results while
+		// they are read
+		queryCtx := h.stmtMgr.ContextWithResultSpill(ctx, stmt.Handle)
 		if len(bindings) > 0 {
-			result, err = h.executor.QueryWithBindings(ctx, req.Statement, bindings)
+			result, err = h.executor.QueryWithBindings(queryCtx, req.Statement, bindings)
 		} else {
-			result, err = h.executor.Query(ctx, req.Statement)
+			result, err = h.executor.Query(queryCtx, req.Statement)
 		}
 	} else {
 		// Handle DDL (CREATE, DROP, ALTER) and DML (INSERT, UPDATE, DELETE)
@@ -88,40 +266,91 @@ func (h *RestAPIv2Handler) SubmitStatement(w http.ResponseWriter, r *http.Reques
 	}
 
 	if err != nil {
-		sfErr := apierror.NewSnowflakeError(apierror.CodeSQLExecutionError, err.Error())
-		h.stmtMgr.SetError(stmt.Handle, sfErr)
-
-		resp := types.StatementResponse{
-			StatementHandle:    stmt.Handle,
-			Code:               apierror.CodeSQLExecutionError,
-			SQLState:           types.SQLState42000,
-			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
-			Message:            err.Error(),
-			CreatedOn:          stmt.CreatedOn.UnixMilli(),
+		h.recordHistory(ctx, entry, started, 0, err)
+		code, sqlState, message := apierror.CodeSQLExecutionError, types.SQLState42000, err.Error()
+		var unsupported *query.UnsupportedFunctionError
+		var syntax *query.TranslationError
+		var limit *query.BindingLimitError
+		switch {
+		case errors.Is(canceledError(ctx, err), context.Canceled):
+			canceled := apierror.NewQueryCanceledError()
+			code, sqlState, message = canceled.Code, canceled.SQLState, canceled.Message
+		case connection.IsResourceError(err):
+			resource := resourceError(err)
+			code, sqlState, message = resource.Code, resource.SQLState, resource.Message
+		case errors.Is(err, connection.ErrTransactionConflict):
+			code, sqlState = apierror.CodeLockTimeout, apierror.SQLStateQueryCanceled
+			message = lockTimeoutMessage(stmt.Handle, err)
+		case errors.As(err, &unsupported):
+			code, sqlState = apierror.CodeSQLCompilationError, apierror.SQLStateSyntaxError
+			message = unsupported.Error()
+		case errors.As(err, &syntax):
+			code, sqlState = apierror.CodeSQLCompilationError, apierror.SQLStateSyntaxError
+			message = apierror.NewSyntaxError(syntax.Line(), syntax.Position(), syntax.Construct).Message
+		case errors.As(err, &limit):
+			code, sqlState = apierror.CodeSQLCompilationError, apierror.SQLStateSyntaxError
+			message = "SQL compilation error:\n" + limit.Error()
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(resp)
-		return
+		sfErr := apierror.NewSnowflakeError(code, message)
+		// Polling the failed statement reports the same SQLSTATE
+		sfErr.SQLState = sqlState
+		h.stmtMgr.SetError(stmt.Handle, sfErr)
+		return statementOutcome{err: sfErr, sqlState: sqlState}
 	}
 
-	// Build response based on statement type
-	var resp types.StatementResponse
 	if classification.IsQuery {
-		// Store result for queries
+		h.recordHistory(ctx, entry, started, int64(result.RowCount()), nil)
+		// Store result for queries, which may spill it to disk
 		h.stmtMgr.SetResult(stmt.Handle, result)
-		resp = h.buildStatementResponse(stmt, result)
-	} else {
-		// Build response for DDL/DML
-		resp = h.buildExecResponse(stmt, execResult)
+		return statementOutcome{}
 	}
+	h.recordHistory(ctx, entry, started, execResult.RowsAffected, nil)
+	h.stmtMgr.SetResult(stmt.Handle, rowsAffectedResult(execResult))
+	return statementOutcome{execResult: execResult}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
+// queryTag returns the QUERY_TAG among a statement's session parameters,
+// whose names are case-insensitive.
+func queryTag(params map[string]string) string {
+	for name, value := range params {
+		if strings.EqualFold(name, string(config.ParamQueryTag)) {
+			return value
+		}
+	}
+	return ""
+}
+
+// recordHistory records the end of a statement in its query history entry,
+// which is nil when its start was not recorded.
+func (h *RestAPIv2Handler) recordHistory(ctx context.Context, entry *metadata.QueryHistoryEntry, started time.Time, rows int64, execErr error) {
+	if entry == nil {
+		return
+	}
+	// A canceled statement's context no longer runs queries
+	ctx = context.WithoutCancel(ctx)
+	executionTimeMs := time.Since(started).Milliseconds()
+	if execErr != nil {
+		_ = h.repo.RecordQueryFailure(ctx, entry.ID, execErr.Error(), executionTimeMs)
+		return
+	}
+	_ = h.repo.RecordQuerySuccess(ctx, entry.ID, rows, executionTimeMs)
 }
 
-// GetStatement handles GET /api/v2/statements/{handle}.
+// rowsAffectedResult returns the result of a DDL or DML statement: one row
+// holding the number of rows it affected.
+func rowsAffectedResult(execResult *query.ExecResult) *query.Result {
+	return &query.Result{
+		Columns: []string{"number of rows affected"},
+		ColumnTypes: []types.ColumnMetadata{
+			{Name: "number of rows affected", Type: query.MapDuckDBTypeToSnowflake("BIGINT"), Precision: 19},
+		},
+		Rows:     [][]interface{}{{execResult.RowsAffected}},
+		Warnings: execResult.Warnings,
+	}
+}
+
+// GetStatement handles GET /api/v2/statements/{handle}. The partition query
+// parameter selects the partition of a result to return, the first by default.
 func (h *RestAPIv2Handler) GetStatement(w http.ResponseWriter, r *http.Request) {
 	handle := chi.URLParam(r, "handle")
 
@@ -131,42 +360,72 @@ func (h *RestAPIv2Handler) GetStatement(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var resp types.StatementResponse
+	partition := 0
+	if value := r.URL.Query().Get("partition"); value != "" {
+		var err error
+		partition, err = strconv.Atoi(value)
+		if err != nil || partition < 0 {
+			h.sendError(w, http.StatusBadRequest, "Invalid partition", types.SQLState42000)
+			return
+		}
+	}
+
+	resp, err := h.statementStatusResponse(stmt, partition)
+	if err != nil {
+		h.sendError(w, http.StatusUnprocessableEntity, err.Error(), types.SQLState42000)
+		return
+	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// statementStatusResponse builds the response reporting a statement's status:
+// a partition of its result once it succeeded, or its error or cancellation,
+// along with the QUERY_TAG and labels it was submitted with.
+func (h *RestAPIv2Handler) statementStatusResponse(stmt *query.Statement, partition int) (types.StatementResponse, error) {
+	resp, err := h.statementStatus(stmt, partition)
+	resp.QueryTag, resp.Labels = stmt.QueryTag, stmt.Labels
+	return resp, err
+}
+
+// statementStatus builds the response reporting a statement's status.
+func (h *RestAPIv2Handler) statementStatus(stmt *query.Statement, partition int) (types.StatementResponse, error) {
 	switch stmt.Status {
-	case query.StatementStatusRunning, query.StatementStatusPending:
-		resp = types.StatementResponse{
-			StatementHandle:    stmt.Handle,
-			Code:               types.ResponseCodeStatementPending,
-			SQLState:           types.SQLState00000,
-			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
-			CreatedOn:          stmt.CreatedOn.UnixMilli(),
-		}
 	case query.StatementStatusSuccess:
-		resp = h.buildStatementResponse(stmt, stmt.Result)
+		return h.buildStatementResponse(stmt, partition)
 	case query.StatementStatusFailed:
-		resp = types.StatementResponse{
+		sqlState := stmt.Error.SQLState
+		if sqlState == "" {
+			sqlState = types.SQLState42000
+		}
+		return types.StatementResponse{
 			StatementHandle:    stmt.Handle,
 			Code:               stmt.Error.Code,
-			SQLState:           types.SQLState42000,
+			SQLState:           sqlState,
 			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
 			Message:            stmt.Error.Message,
 			CreatedOn:          stmt.CreatedOn.UnixMilli(),
-		}
+		}, nil
 	case query.StatementStatusCanceled:
-		resp = types.StatementResponse{
+		return types.StatementResponse{
 			StatementHandle:    stmt.Handle,
 			Code:               types.ResponseCodeStatementCanceled,
 			SQLState:           types.SQLState00000,
 			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
 			Message:            "Statement canceled",
 			CreatedOn:          stmt.CreatedOn.UnixMilli(),
-		}
+		}, nil
+	default:
+		return types.StatementResponse{
+			StatementHandle:    stmt.Handle,
+			Code:               types.ResponseCodeStatementPending,
+			SQLState:           types.SQLState00000,
+			StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
+			CreatedOn:          stmt.CreatedOn.UnixMilli(),
+		}, nil
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
 }
 
 // CancelStatement handles POST /api/v2/statements/{handle}/cancel.
@@ -210,33 +469,50 @@ func (h *RestAPIv2Handler) buildExecResponse(stmt *query.Statement, execResult *
 			Format:  "jsonv2",
 			RowType: []types.RowTypeField{
 				{
-					Name: "number of rows affected",
-					Type: "FIXED",
+					Name:      "number of rows affected",
+					Type:      query.MapDuckDBTypeToSnowflake("BIGINT"),
+					Precision: 19,
 				},
 			},
 		},
-		Data: [][]interface{}{{execResult.RowsAffected}},
+		Data:     [][]interface{}{{h.formatValue(stmt.Parameters, execResult.RowsAffected, "fixed")}},
+		Warnings: execResult.Warnings,
 	}
 }
 
-// buildStatementResponse builds a success response from a query result.
-func (h *RestAPIv2Handler) buildStatementResponse(stmt *query.Statement, result *query.Result) types.StatementResponse {
-	// Convert row type
-	rowType := make([]types.RowTypeField, len(result.ColumnTypes))
-	for i, col := range result.ColumnTypes {
-		rowType[i] = types.RowTypeField{
-			Name:      col.Name,
-			Type:      col.Type,
-			Length:    col.Length,
-			Precision: col.Precision,
-			Scale:     col.Scale,
-			Nullable:  col.Nullable,
+// buildStatementResponse builds a success response from a partition of a
+// statement's result. Results of several partitions list them in the metadata,
+// for clients to fetch the others.
+func (h *RestAPIv2Handler) buildStatementResponse(stmt *query.Statement, partition int) (types.StatementResponse, error) {
+	result := stmt.Result
+	rows, err := stmt.PartitionRows(partition)
+	if err != nil {
+		return types.StatementResponse{}, err
+	}
+
+	rowType := rowTypeFields(result.ColumnTypes)
+
+	var numRows int64
+	var partitionInfo []types.PartitionInfo
+	partitions := stmt.Partitions()
+	for _, info := range partitions {
+		numRows += int64(info.RowCount)
+		if len(partitions) > 1 {
+			partitionInfo = append(partitionInfo, types.PartitionInfo{RowCount: int64(info.RowCount), UncompressedSize: info.Size})
 		}
 	}
 
-	// Convert data to interface{} slice
-	data := make([][]interface{}, len(result.Rows))
-	copy(data, result.Rows)
+	data := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		data[i] = make([]interface{}, len(row))
+		for j, val := range row {
+			rowType := ""
+			if j < len(result.ColumnTypes) {
+				rowType = result.ColumnTypes[j].Type
+			}
+			data[i][j] = h.formatValue(stmt.Parameters, val, rowType)
+		}
+	}
 
 	return types.StatementResponse{
 		StatementHandle:    stmt.Handle,
@@ -245,12 +521,49 @@ func (h *RestAPIv2Handler) buildStatementResponse(stmt *query.Statement, result
 		StatementStatusURL: "/api/v2/statements/" + stmt.Handle,
 		CreatedOn:          stmt.CreatedOn.UnixMilli(),
 		ResultSetMetaData: &types.ResultSetMetaData{
-			NumRows: int64(len(result.Rows)),
-			Format:  "jsonv2",
-			RowType: rowType,
+			NumRows:       numRows,
+			Format:        "jsonv2",
+			RowType:       rowType,
+			PartitionInfo: partitionInfo,
 		},
-		Data: data,
+		Data:     data,
+		Warnings: result.Warnings,
+	}, nil
+}
+
+// rowTypeFields converts the column metadata of a result to its row type.
+func rowTypeFields(columns []types.ColumnMetadata) []types.RowTypeField {
+	rowType := make([]types.RowTypeField, len(columns))
+	for i, col := range columns {
+		rowType[i] = types.RowTypeField{
+			Name:      col.Name,
+			Type:      col.Type,
+			Length:    col.Length,
+			Precision: col.Precision,
+			Scale:     col.Scale,
+			Nullable:  col.Nullable,
+		}
 	}
+	return rowType
+}
+
+// formatValue renders a result value for the Data payload: temporal values with
+// the output formats of the session parameters, and numbers as exact decimal
+// strings unless the handler sends JSON numbers.
+func (h *RestAPIv2Handler) formatValue(params query.SessionParameters, val interface{}, rowType string) interface{} {
+	val = params.FormatOutputValue(val, rowType)
+	number, ok := formatNumber(val)
+	if !ok {
+		return val
+	}
+	if !h.jsonNumbers {
+		return number
+	}
+	if _, isDecimal := val.(duckdb.Decimal); isDecimal {
+		// Decimals would otherwise be encoded as a struct
+		return json.Number(number)
+	}
+	return val
 }
 
 // sendError sends an error response.
@@ -266,6 +579,39 @@ func (h *RestAPIv2Handler) sendError(w http.ResponseWriter, statusCode int, mess
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// protectDrop applies the executor's drop protection to dropping a database,
+// schema, or table, named by parts. It reports whether the object is protected,
+// after answering the request with 403 in reject mode, or with the dry run's
+// warning in dry run mode.
+func (h *RestAPIv2Handler) protectDrop(w http.ResponseWriter, r *http.Request, kind string, parts ...string) bool {
+	warning, protected, err := h.executor.ProtectDrop(r.Context(), kind, parts...)
+	if !protected {
+		return false
+	}
+	if err != nil {
+		resp := types.StatementResponse{
+			Code:     apierror.CodePermissionDenied,
+			SQLState: types.SQLState42000,
+			Message:  err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(resp)
+		return true
+	}
+
+	resp := types.StatementResponse{
+		Code:     types.ResponseCodeSuccess,
+		SQLState: types.SQLState00000,
+		Message:  warning,
+		Warnings: []string{warning},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+	return true
+}
+
 // Resource Management Handlers
 
 // ListDatabases handles GET /api/v2/databases.
@@ -329,7 +675,7 @@ func (h *RestAPIv2Handler) CreateDatabase(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	ctx := r.Context()
+	ctx := metadata.ContextWithOwner(r.Context(), config.DefaultRole)
 
 	db, err := h.repo.CreateDatabase(ctx, req.Name, req.Comment)
 	if err != nil {
@@ -361,6 +707,10 @@ func (h *RestAPIv2Handler) DeleteDatabase(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if h.protectDrop(w, r, "DATABASE", db.Name) {
+		return
+	}
+
 	// Now drop using the ID
 	if err := h.repo.DropDatabase(ctx, db.ID); err != nil {
 		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
@@ -436,7 +786,7 @@ func (h *RestAPIv2Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
 
 // CreateSchema handles POST /api/v2/databases/{database}/schemas.
 func (h *RestAPIv2Handler) CreateSchema(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := metadata.ContextWithOwner(r.Context(), config.DefaultRole)
 	dbName := chi.URLParam(r, "database")
 
 	var req types.SchemaRequest
@@ -493,6 +843,10 @@ func (h *RestAPIv2Handler) DeleteSchema(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if h.protectDrop(w, r, "SCHEMA", db.Name, schema.Name) {
+		return
+	}
+
 	if err := h.repo.DropSchema(ctx, schema.ID); err != nil {
 		h.sendError(w, http.StatusNotFound, err.Error(), types.SQLState02000)
 		return
@@ -608,6 +962,10 @@ func (h *RestAPIv2Handler) DeleteTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.protectDrop(w, r, "TABLE", db.Name, schema.Name, table.Name) {
+		return
+	}
+
 	if err := h.repo.DropTable(ctx, table.ID); err != nil {
 		h.sendError(w, http.StatusNotFound, err.Error(), types.SQLState02000)
 		return
@@ -662,7 +1020,7 @@ func (h *RestAPIv2Handler) AlterDatabase(w http.ResponseWriter, r *http.Request)
 
 // CreateTable handles POST /api/v2/databases/{database}/schemas/{schema}/tables.
 func (h *RestAPIv2Handler) CreateTable(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	ctx := metadata.ContextWithOwner(r.Context(), config.DefaultRole)
 	dbName := chi.URLParam(r, "database")
 	schemaName := chi.URLParam(r, "schema")
 
@@ -788,6 +1146,99 @@ func (h *RestAPIv2Handler) AlterTable(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// Catalog Handlers
+
+// GetCatalog handles GET /api/v2/catalog.
+// It returns all databases with their schemas, tables, and columns in one response.
+// The response carries an ETag; requests with a matching If-None-Match get 304 Not Modified.
+func (h *RestAPIv2Handler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+	catalog, err := h.repo.GetCatalog(r.Context())
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+		return
+	}
+
+	resp := types.CatalogResponse{Databases: make([]types.CatalogDatabaseResponse, len(catalog))}
+	for i, db := range catalog {
+		dbResp := types.CatalogDatabaseResponse{
+			DatabaseResponse: types.DatabaseResponse{
+				Name:      db.Name,
+				Comment:   db.Comment,
+				Owner:     db.Owner,
+				CreatedOn: db.CreatedAt.Format(time.RFC3339),
+			},
+			Schemas: make([]types.CatalogSchemaResponse, len(db.Schemas)),
+		}
+		for j, schema := range db.Schemas {
+			schemaResp := types.CatalogSchemaResponse{
+				SchemaResponse: types.SchemaResponse{
+					Name:         schema.Name,
+					DatabaseName: db.Name,
+					Comment:      schema.Comment,
+					Owner:        schema.Owner,
+					CreatedOn:    schema.CreatedAt.Format(time.RFC3339),
+				},
+				Tables: make([]types.CatalogTableResponse, len(schema.Tables)),
+			}
+			for k, table := range schema.Tables {
+				columns := make([]types.ColumnDef, len(table.Columns))
+				for c, col := range table.Columns {
+					columns[c] = types.ColumnDef{
+						Name:       col.Name,
+						Type:       col.Type,
+						Nullable:   col.Nullable,
+						Default:    col.Default,
+						PrimaryKey: col.PrimaryKey,
+					}
+				}
+				schemaResp.Tables[k] = types.CatalogTableResponse{
+					TableResponse: types.TableResponse{
+						Name:      table.Name,
+						Database:  db.Name,
+						Schema:    schema.Name,
+						TableType: table.TableType,
+						Comment:   table.Comment,
+						Owner:     table.Owner,
+						CreatedOn: table.CreatedAt.Format(time.RFC3339),
+					},
+					Columns: columns,
+				}
+			}
+			dbResp.Schemas[j] = schemaResp
+		}
+		resp.Databases[i] = dbResp
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 // Warehouse Management Handlers
 
 // ListWarehouses handles GET /api/v2/warehouses.
@@ -5,22 +5,31 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/server/apierror"
 	"github.com/nnnkkk7/snowflake-emulator/server/types"
 )
 
 // setupRestAPIv2Handler creates a test handler with dependencies.
-func setupRestAPIv2Handler(t *testing.T) (*RestAPIv2Handler, *chi.Mux) {
+func setupRestAPIv2Handler(t *testing.T, opts ...query.ExecutorOption) (*RestAPIv2Handler, *chi.Mux) {
 	t.Helper()
 
 	db, err := sql.Open("duckdb", "")
@@ -40,10 +49,10 @@ func setupRestAPIv2Handler(t *testing.T) (*RestAPIv2Handler, *chi.Mux) {
 		t.Fatalf("failed to create repository: %v", err)
 	}
 
-	executor := query.NewExecutor(connMgr, repo)
+	executor := query.NewExecutor(connMgr, repo, opts...)
 	stmtMgr := query.NewStatementManager(1 * time.Hour)
 
-	handler := NewRestAPIv2Handler(executor, stmtMgr, repo)
+	handler := NewRestAPIv2Handler(executor, stmtMgr, repo, WithSessionManager(session.NewManager(1*time.Hour)))
 
 	// Setup router
 	r := chi.NewRouter()
@@ -51,6 +60,16 @@ func setupRestAPIv2Handler(t *testing.T) (*RestAPIv2Handler, *chi.Mux) {
 		r.Post("/statements", handler.SubmitStatement)
 		r.Get("/statements/{handle}", handler.GetStatement)
 		r.Post("/statements/{handle}/cancel", handler.CancelStatement)
+		r.Get("/statements/{handle}/download", handler.DownloadStatement)
+		r.Get("/queries", handler.ListQueries)
+		r.Get("/queries/{queryId}", handler.GetQuery)
+		r.Get("/sessions", handler.ListSessions)
+		r.Get("/sessions/{sessionId}", handler.GetSession)
+		r.Post("/databases/{database}/schemas/{schema}/tables/{table}/rows", handler.LoadRows)
+		r.Get("/databases/{database}/schemas/{schema}/tables/{table}/preview", handler.PreviewTable)
+		r.Delete("/databases/{database}", handler.DeleteDatabase)
+		r.Delete("/databases/{database}/schemas/{schema}", handler.DeleteSchema)
+		r.Delete("/databases/{database}/schemas/{schema}/tables/{table}", handler.DeleteTable)
 	})
 
 	return handler, r
@@ -103,6 +122,199 @@ func TestRestAPIv2Handler_SubmitStatement_Sync(t *testing.T) {
 	}
 }
 
+// TestRestAPIv2Handler_SubmitStatement_Async tests queuing statements with
+// async=true and polling them until they finish.
+func TestRestAPIv2Handler_SubmitStatement_Async(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	submit := func(statement string) types.StatementResponse {
+		t.Helper()
+		body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement})
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/statements?async=true", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+		}
+		var resp types.StatementResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if resp.Code != types.ResponseCodeStatementPending || resp.StatementHandle == "" {
+			t.Fatalf("Expected code %s with a handle, got %s %q", types.ResponseCodeStatementPending, resp.Code, resp.StatementHandle)
+		}
+		return resp
+	}
+	poll := func(handle string) types.StatementResponse {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/statements/"+handle, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			var resp types.StatementResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if resp.Code != types.ResponseCodeStatementPending {
+				return resp
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Statement %s still running after 10s", handle)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		statement string
+		wantCode  string
+		wantData  [][]interface{}
+	}{
+		{name: "DDL", statement: "CREATE TABLE async_rows (id INTEGER)", wantCode: types.ResponseCodeSuccess, wantData: [][]interface{}{{"0"}}},
+		{name: "DML", statement: "INSERT INTO async_rows VALUES (1), (2)", wantCode: types.ResponseCodeSuccess, wantData: [][]interface{}{{"2"}}},
+		{name: "Query", statement: "SELECT COUNT(*) AS n FROM async_rows", wantCode: types.ResponseCodeSuccess, wantData: [][]interface{}{{"2"}}},
+		{name: "Error", statement: "SELECT * FROM missing_async_rows", wantCode: apierror.CodeSQLExecutionError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := poll(submit(tt.statement).StatementHandle)
+			if resp.Code != tt.wantCode {
+				t.Fatalf("Expected code %s, got %s: %s", tt.wantCode, resp.Code, resp.Message)
+			}
+			if diff := cmp.Diff(tt.wantData, resp.Data); diff != "" {
+				t.Errorf("Data mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Invalid async values are rejected
+	body, _ := json.Marshal(types.SubmitStatementRequest{Statement: "SELECT 1"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/statements?async=maybe", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+// TestRestAPIv2Handler_SubmitStatement_Idempotency tests that submissions
+// repeating an idempotency key run once and replay the first response.
+func TestRestAPIv2Handler_SubmitStatement_Idempotency(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	submit := func(statement, query, key string) (*httptest.ResponseRecorder, types.StatementResponse) {
+		t.Helper()
+		body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement})
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/statements"+query, bytes.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp types.StatementResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+
+	submit("CREATE TABLE idempotent_rows (id INTEGER)", "", "")
+	insert := "INSERT INTO idempotent_rows VALUES (1)"
+	_, first := submit(insert, "", "key-1")
+	_, fromRequestID := submit(insert, "?requestId=request-1", "")
+
+	tests := []struct {
+		name       string
+		statement  string
+		query      string
+		key        string
+		wantStatus int
+		wantHandle string
+	}{
+		{name: "HeaderRetry", statement: insert, key: "key-1", wantStatus: http.StatusOK, wantHandle: first.StatementHandle},
+		{name: "RequestIDRetry", statement: insert, query: "?requestId=request-1&retry=true", wantStatus: http.StatusOK, wantHandle: fromRequestID.StatementHandle},
+		{name: "RequestIDWithoutRetry", statement: insert, query: "?requestId=request-1", wantStatus: http.StatusConflict},
+		{name: "DifferentSQL", statement: "INSERT INTO idempotent_rows VALUES (2)", key: "key-1", wantStatus: http.StatusUnprocessableEntity},
+		{name: "InvalidRetry", statement: insert, query: "?requestId=request-2&retry=sometimes", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, resp := submit(tt.statement, tt.query, tt.key)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if resp.StatementHandle != tt.wantHandle || resp.Code != types.ResponseCodeSuccess {
+				t.Errorf("replayed handle, code = %s, %s, want %s, %s", resp.StatementHandle, resp.Code, tt.wantHandle, types.ResponseCodeSuccess)
+			}
+			if rr.Header().Get("Idempotent-Replayed") != "true" {
+				t.Error("Expected the Idempotent-Replayed header on a replayed response")
+			}
+		})
+	}
+
+	// Only the first submissions of each key inserted a row
+	_, count := submit("SELECT COUNT(*) FROM idempotent_rows", "", "")
+	if diff := cmp.Diff([][]interface{}{{"2"}}, count.Data); diff != "" {
+		t.Errorf("row count mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestRestAPIv2Handler_SubmitStatement_IdempotencySessions tests that
+// clients with different sessions sharing an idempotency key each run their
+// own statement.
+func TestRestAPIv2Handler_SubmitStatement_IdempotencySessions(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	submit := func(statement, query, token, key string) (*httptest.ResponseRecorder, types.StatementResponse) {
+		t.Helper()
+		body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement})
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/statements"+query, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp types.StatementResponse
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr, resp
+	}
+
+	submit("CREATE TABLE shared_key_rows (id INTEGER)", "", "session-a", "")
+	insert := "INSERT INTO shared_key_rows VALUES (1)"
+	for _, query := range []string{"", "?requestId=request-1&retry=true"} {
+		key := ""
+		if query == "" {
+			key = "shared-key"
+		}
+		_, first := submit(insert, query, "session-a", key)
+		rr, second := submit(insert, query, "session-b", key)
+		if rr.Code != http.StatusOK || rr.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("second session's submission status = %d, replayed = %q, want a new statement",
+				rr.Code, rr.Header().Get("Idempotent-Replayed"))
+		}
+		if second.StatementHandle == first.StatementHandle {
+			t.Errorf("second session's statement handle = %s, want a handle other than the first session's", second.StatementHandle)
+		}
+
+		// Each session still replays its own statement
+		rr, again := submit(insert, query, "session-b", key)
+		if rr.Header().Get("Idempotent-Replayed") != "true" || again.StatementHandle != second.StatementHandle {
+			t.Errorf("retry of the second session = %s, replayed = %q, want %s replayed",
+				again.StatementHandle, rr.Header().Get("Idempotent-Replayed"), second.StatementHandle)
+		}
+	}
+
+	_, count := submit("SELECT COUNT(*) FROM shared_key_rows", "", "session-a", "")
+	if diff := cmp.Diff([][]interface{}{{"4"}}, count.Data); diff != "" {
+		t.Errorf("row count mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestRestAPIv2Handler_SubmitStatement_WithBindings(t *testing.T) {
 	_, router := setupRestAPIv2Handler(t)
 
@@ -148,6 +360,157 @@ func TestRestAPIv2Handler_SubmitStatement_WithBindings(t *testing.T) {
 	}
 }
 
+func TestRestAPIv2Handler_SubmitStatement_WithParameters(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	// 2017-01-01 is a Sunday: day 0 by default, day 1 when weeks start on Sunday
+	reqBody := types.SubmitStatementRequest{
+		Statement:  "SELECT DAYOFWEEK('2017-01-01') AS dow",
+		Parameters: map[string]string{"WEEK_START": "7"},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	var resp types.StatementResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if resp.Code != types.ResponseCodeSuccess {
+		t.Fatalf("Expected code %s, got %s. Message: %s", types.ResponseCodeSuccess, resp.Code, resp.Message)
+	}
+
+	if len(resp.Data) != 1 || fmt.Sprint(resp.Data[0][0]) != "1" {
+		t.Errorf("Expected DAYOFWEEK 1 with WEEK_START=7, got %v", resp.Data)
+	}
+}
+
+func TestRestAPIv2Handler_SubmitStatement_OutputFormats(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	statement := "SELECT DATE '2024-03-05' AS d, TIME '13:04:05.123456' AS t, TIMESTAMP '2024-03-05 13:04:05.5' AS ts"
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		expected   []string
+	}{
+		{
+			name:     "Defaults",
+			expected: []string{"2024-03-05", "13:04:05", "2024-03-05 13:04:05"},
+		},
+		{
+			name: "SessionFormats",
+			parameters: map[string]string{
+				"DATE_OUTPUT_FORMAT":          "DD/MM/YYYY",
+				"time_output_format":          "HH12:MI AM",
+				"TIMESTAMP_NTZ_OUTPUT_FORMAT": "MON DD, YYYY HH24:MI:SS.FF3",
+			},
+			expected: []string{"05/03/2024", "01:04 PM", "Mar 05, 2024 13:04:05.500"},
+		},
+		{
+			name:       "TimestampOutputFormatFallback",
+			parameters: map[string]string{"TIMESTAMP_OUTPUT_FORMAT": "YYYY-MM-DD\"T\"HH24:MI:SS"},
+			expected:   []string{"2024-03-05", "13:04:05", "2024-03-05T13:04:05"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement, Parameters: tt.parameters})
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			var resp types.StatementResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if resp.Code != types.ResponseCodeSuccess {
+				t.Fatalf("Expected code %s, got %s. Message: %s", types.ResponseCodeSuccess, resp.Code, resp.Message)
+			}
+			if len(resp.Data) != 1 {
+				t.Fatalf("Expected 1 row, got %v", resp.Data)
+			}
+			for i, want := range tt.expected {
+				if got := fmt.Sprint(resp.Data[0][i]); got != want {
+					t.Errorf("column %d = %q, want %q", i, got, want)
+				}
+			}
+
+			// Fetching the statement again renders it the same way
+			getReq := httptest.NewRequest(http.MethodGet, "/api/v2/statements/"+resp.StatementHandle, nil)
+			getRR := httptest.NewRecorder()
+			router.ServeHTTP(getRR, getReq)
+
+			var getResp types.StatementResponse
+			if err := json.Unmarshal(getRR.Body.Bytes(), &getResp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(getResp.Data) != 1 || fmt.Sprint(getResp.Data[0][0]) != tt.expected[0] {
+				t.Errorf("GetStatement data = %v, want first value %q", getResp.Data, tt.expected[0])
+			}
+		})
+	}
+}
+
+func TestRestAPIv2Handler_SubmitStatement_NullValues(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	body, _ := json.Marshal(types.SubmitStatementRequest{
+		Statement: "SELECT NULL::VARCHAR AS n, '' AS e, NULL::INTEGER AS i, NULL::DATE AS d, NULL::BINARY AS b",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if !strings.Contains(rr.Body.String(), `"data":[[null,"",null,null,null]]`) {
+		t.Errorf("Expected NULLs as JSON null in data, got %s", rr.Body.String())
+	}
+}
+
+func TestRestAPIv2Handler_SubmitStatement_NumbersAsStrings(t *testing.T) {
+	statement := "SELECT 12345678901234567890123456789.123::NUMBER(38,3) AS d, 9007199254740993 AS i, 1.5::FLOAT AS f"
+	tests := []struct {
+		name     string
+		opts     []RestAPIv2Option
+		expected string
+	}{
+		{
+			name:     "Strings",
+			expected: `"data":[["12345678901234567890123456789.123","9007199254740993","1.5"]]`,
+		},
+		{
+			name:     "JSONNumbers",
+			opts:     []RestAPIv2Option{WithJSONNumbers()},
+			expected: `"data":[[12345678901234567890123456789.123,9007199254740993,1.5]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := setupRestAPIv2Handler(t)
+			handler := NewRestAPIv2Handler(base.executor, base.stmtMgr, base.repo, tt.opts...)
+
+			body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement})
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.SubmitStatement(rr, req)
+
+			if !strings.Contains(rr.Body.String(), tt.expected) {
+				t.Errorf("Expected %s in response, got %s", tt.expected, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestRestAPIv2Handler_SubmitStatement_EmptyStatement(t *testing.T) {
 	_, router := setupRestAPIv2Handler(t)
 
@@ -242,6 +605,70 @@ func TestRestAPIv2Handler_GetStatement(t *testing.T) {
 	_ = handler // Use handler to avoid unused warning
 }
 
+// TestRestAPIv2Handler_GetStatement_Partitions tests fetching the partitions of
+// a result spilled to disk.
+func TestRestAPIv2Handler_GetStatement_Partitions(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	connMgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	stmtMgr := query.NewStatementManager(1*time.Hour, query.WithResultSpill(t.TempDir(), 10, 10))
+	handler := NewRestAPIv2Handler(query.NewExecutor(connMgr, repo), stmtMgr, repo)
+	router := chi.NewRouter()
+	router.Post("/api/v2/statements", handler.SubmitStatement)
+	router.Get("/api/v2/statements/{handle}", handler.GetStatement)
+
+	body, _ := json.Marshal(types.SubmitStatementRequest{Statement: "SELECT range AS n FROM range(25)"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var submitResp types.StatementResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &submitResp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	meta := submitResp.ResultSetMetaData
+	if meta == nil || meta.NumRows != 25 || len(meta.PartitionInfo) != 3 || len(submitResp.Data) != 10 {
+		t.Fatalf("submit response = %+v, want 25 rows in 3 partitions with the first 10 rows", submitResp)
+	}
+
+	tests := []struct {
+		partition  string
+		wantStatus int
+		wantFirst  interface{}
+		wantRows   int
+	}{
+		{partition: "2", wantStatus: http.StatusOK, wantFirst: "20", wantRows: 5},
+		{partition: "3", wantStatus: http.StatusUnprocessableEntity},
+		{partition: "x", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/statements/"+submitResp.StatementHandle+"?partition="+tt.partition, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != tt.wantStatus {
+			t.Errorf("partition %s: status = %d, want %d", tt.partition, rr.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var resp types.StatementResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(resp.Data) != tt.wantRows || resp.Data[0][0] != tt.wantFirst {
+			t.Errorf("partition %s data = %v, want %d rows from %v", tt.partition, resp.Data, tt.wantRows, tt.wantFirst)
+		}
+	}
+}
+
 func TestRestAPIv2Handler_GetStatement_NotFound(t *testing.T) {
 	_, router := setupRestAPIv2Handler(t)
 
@@ -256,6 +683,643 @@ func TestRestAPIv2Handler_GetStatement_NotFound(t *testing.T) {
 	}
 }
 
+// TestRestAPIv2Handler_GetStatement_FailedSQLState tests that polling a failed
+// statement reports the SQLSTATE it failed with.
+func TestRestAPIv2Handler_GetStatement_FailedSQLState(t *testing.T) {
+	handler, router := setupRestAPIv2Handler(t)
+
+	get := func(handle string) types.StatementResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/statements/"+handle, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp types.StatementResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return resp
+	}
+
+	// A statement that failed while submitted synchronously reports the same SQLSTATE when polled
+	body, _ := json.Marshal(types.SubmitStatementRequest{Statement: "SELECT * FROM missing_rows"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var submitted types.StatementResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &submitted); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if got := get(submitted.StatementHandle); got.Code != submitted.Code || got.SQLState != submitted.SQLState {
+		t.Errorf("GetStatement() = %s %s, want %s %s", got.Code, got.SQLState, submitted.Code, submitted.SQLState)
+	}
+
+	tests := []struct {
+		name         string
+		err          *apierror.SnowflakeError
+		wantSQLState string
+	}{
+		{name: "LockTimeout", err: apierror.NewSnowflakeError(apierror.CodeLockTimeout, "lock timeout"), wantSQLState: apierror.SQLStateQueryCanceled},
+		{name: "QueryCanceled", err: apierror.NewQueryCanceledError(), wantSQLState: apierror.SQLStateQueryCanceled},
+		{name: "NoSQLState", err: &apierror.SnowflakeError{Code: apierror.CodeSQLExecutionError, Message: "failed"}, wantSQLState: types.SQLState42000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := handler.stmtMgr.CreateStatement("SELECT 1", "TEST_DB", "PUBLIC", "")
+			handler.stmtMgr.SetError(stmt.Handle, tt.err)
+			got := get(stmt.Handle)
+			if got.Code != tt.err.Code || got.SQLState != tt.wantSQLState {
+				t.Errorf("GetStatement() = %s %s, want %s %s", got.Code, got.SQLState, tt.err.Code, tt.wantSQLState)
+			}
+		})
+	}
+}
+
+// TestRestAPIv2Handler_DownloadStatement tests downloading a statement's
+// result as CSV, TSV, and NDJSON.
+func TestRestAPIv2Handler_DownloadStatement(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	statement := `SELECT * FROM (VALUES
+		(1, 'plain', 1.50::NUMBER(10,2), DATE '2024-01-02'),
+		(2, 'comma, "quote"', NULL, NULL),
+		(3, e'tab\there\nnewline', -2.00::NUMBER(10,2), DATE '2024-12-31')
+	) AS t(id, "label", amount, day) ORDER BY id`
+	body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement})
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var submitResp types.StatementResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &submitResp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v. Body: %s", err, rr.Body.String())
+	}
+	handle := submitResp.StatementHandle
+
+	tests := []struct {
+		format          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			format:          "",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "id,label,amount,day\n" +
+				"1,plain,1.50,2024-01-02\n" +
+				"2,\"comma, \"\"quote\"\"\",,\n" +
+				"3,\"tab\there\nnewline\",-2.00,2024-12-31\n",
+		},
+		{
+			format:          "tsv",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/tab-separated-values; charset=utf-8",
+			wantBody: "id\tlabel\tamount\tday\n" +
+				"1\tplain\t1.50\t2024-01-02\n" +
+				"2\t\"comma, \"\"quote\"\"\"\t\t\n" +
+				"3\t\"tab\there\nnewline\"\t-2.00\t2024-12-31\n",
+		},
+		{
+			format:          "NDJSON",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantBody: `{"id":"1","label":"plain","amount":"1.50","day":"2024-01-02"}` + "\n" +
+				`{"id":"2","label":"comma, \"quote\"","amount":null,"day":null}` + "\n" +
+				`{"id":"3","label":"tab\there\nnewline","amount":"-2.00","day":"2024-12-31"}` + "\n",
+		},
+		{format: "xml", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/statements/"+handle+"/download?format="+tt.format, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if diff := cmp.Diff(tt.wantBody, rr.Body.String()); diff != "" {
+				t.Errorf("body mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/statements/missing/download", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("missing statement: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+// TestRestAPIv2Handler_Queries tests listing and fetching the query history
+// of submitted statements.
+func TestRestAPIv2Handler_LoadRows(t *testing.T) {
+	handler, router := setupRestAPIv2Handler(t)
+
+	ctx := context.Background()
+	if _, err := handler.repo.CreateDatabase(ctx, "FIXTURES", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	body, _ := json.Marshal(types.SubmitStatementRequest{
+		Statement: "CREATE TABLE users (id INTEGER, name VARCHAR)",
+		Database:  "FIXTURES",
+		Schema:    "PUBLIC",
+	})
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CREATE TABLE status = %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	multipartBody := func(fileName, data string) (string, *bytes.Buffer) {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		_ = writer.WriteField("comment", "fixtures")
+		part, _ := writer.CreateFormFile("file", fileName)
+		_, _ = part.Write([]byte(data))
+		_ = writer.Close()
+		return writer.FormDataContentType(), &buf
+	}
+
+	ndjsonType, ndjson := multipartBody("users.ndjson", "{\"id\": 3, \"name\": \"carol\"}\n")
+	tsvType, tsv := multipartBody("users.txt", "id\tname\n4\tdave\n")
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        *bytes.Buffer
+		wantStatus  int
+		wantRows    int64
+	}{
+		{name: "CSV", path: "/fixtures/schemas/public/tables/users/rows", contentType: "text/csv", body: bytes.NewBufferString("id,name\n1,alice\n2,bob\n"), wantStatus: http.StatusOK, wantRows: 2},
+		{name: "MultipartNDJSON", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/rows", contentType: ndjsonType, body: ndjson, wantStatus: http.StatusOK, wantRows: 1},
+		{name: "FormatParameter", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/rows?format=tsv", contentType: tsvType, body: tsv, wantStatus: http.StatusOK, wantRows: 1},
+		{name: "UnknownFormat", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/rows", contentType: "text/plain", body: bytes.NewBufferString("id\n5\n"), wantStatus: http.StatusBadRequest},
+		{name: "InvalidValue", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/rows", contentType: "text/csv", body: bytes.NewBufferString("id\nfive\n"), wantStatus: http.StatusBadRequest},
+		{name: "MissingTable", path: "/FIXTURES/schemas/PUBLIC/tables/MISSING/rows", contentType: "text/csv", body: bytes.NewBufferString("id\n5\n"), wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/databases"+tt.path, tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp types.LoadRowsResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if resp.RowsLoaded != tt.wantRows {
+				t.Errorf("RowsLoaded = %d, want %d", resp.RowsLoaded, tt.wantRows)
+			}
+		})
+	}
+
+	result, err := handler.executor.Query(query.ContextWithSessionInfo(ctx, query.SessionInfo{Database: "FIXTURES", Schema: "PUBLIC"}),
+		"SELECT id, name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatalf("SELECT error = %v", err)
+	}
+	want := [][]interface{}{{int64(1), "alice"}, {int64(2), "bob"}, {int64(3), "carol"}, {int64(4), "dave"}}
+	if diff := cmp.Diff(want, result.Rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestRestAPIv2Handler_PreviewTable tests previewing the first rows of a table.
+func TestRestAPIv2Handler_PreviewTable(t *testing.T) {
+	handler, router := setupRestAPIv2Handler(t)
+
+	ctx := context.Background()
+	if _, err := handler.repo.CreateDatabase(ctx, "FIXTURES", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	sessionCtx := query.ContextWithSessionInfo(ctx, query.SessionInfo{Database: "FIXTURES", Schema: "PUBLIC"})
+	for _, sql := range []string{
+		"CREATE TABLE users (id INTEGER, name VARCHAR)",
+		"INSERT INTO users VALUES (1, 'alice'), (2, NULL), (3, 'carol')",
+	} {
+		if _, err := handler.executor.Execute(sessionCtx, sql); err != nil {
+			t.Fatalf("Execute(%q) error = %v", sql, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantData   [][]interface{}
+	}{
+		{name: "DefaultLimit", path: "/fixtures/schemas/public/tables/users/preview", wantStatus: http.StatusOK,
+			wantData: [][]interface{}{{"1", "alice"}, {"2", nil}, {"3", "carol"}}},
+		{name: "Limit", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/preview?limit=1", wantStatus: http.StatusOK,
+			wantData: [][]interface{}{{"1", "alice"}}},
+		{name: "InvalidLimit", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/preview?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "LimitTooLarge", path: "/FIXTURES/schemas/PUBLIC/tables/USERS/preview?limit=1001", wantStatus: http.StatusBadRequest},
+		{name: "MissingTable", path: "/FIXTURES/schemas/PUBLIC/tables/MISSING/preview", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v2/databases"+tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp types.TablePreviewResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantData, resp.Data); diff != "" {
+				t.Errorf("Data mismatch (-want +got):\n%s", diff)
+			}
+			if resp.NumRows != int64(len(tt.wantData)) || len(resp.RowType) != 2 || resp.RowType[1].Name != "name" {
+				t.Errorf("NumRows, RowType = %d, %+v, want %d rows of id and name", resp.NumRows, resp.RowType, len(tt.wantData))
+			}
+		})
+	}
+}
+
+// TestRestAPIv2Handler_StageFiles tests staging, listing, downloading, and
+// removing the files of an internal stage, and loading a staged file with COPY.
+func TestRestAPIv2Handler_StageFiles(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	connMgr := connection.NewManager(db)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	stageMgr := stage.NewManager(repo, t.TempDir())
+	executor := query.NewExecutor(connMgr, repo, query.WithStageManager(stageMgr))
+	executor.Configure(query.WithCopyProcessor(query.NewCopyProcessor(stageMgr, repo, executor)))
+	handler := NewRestAPIv2Handler(executor, query.NewStatementManager(time.Hour), repo)
+
+	r := chi.NewRouter()
+	r.Route("/api/v2/databases/{database}/schemas/{schema}/stages/{stage}/files", func(r chi.Router) {
+		r.Get("/", handler.ListStageFiles)
+		r.Get("/*", handler.GetStageFile)
+		r.Put("/*", handler.PutStageFile)
+		r.Delete("/*", handler.DeleteStageFile)
+	})
+	r.Route("/api/v2/stages/{stage}/files", func(r chi.Router) {
+		r.Get("/", handler.ListStageFiles)
+		r.Get("/*", handler.GetStageFile)
+		r.Put("/*", handler.PutStageFile)
+		r.Delete("/*", handler.DeleteStageFile)
+	})
+
+	ctx := context.Background()
+	if _, err := repo.CreateDatabase(ctx, "FIXTURES", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	sessionCtx := query.ContextWithSessionInfo(ctx, query.SessionInfo{Database: "FIXTURES", Schema: "PUBLIC"})
+	if _, err := executor.Execute(sessionCtx, "CREATE STAGE fixtures_stage"); err != nil {
+		t.Fatalf("CREATE STAGE error = %v", err)
+	}
+	fixturesDB, _ := repo.GetDatabaseByName(ctx, "FIXTURES")
+	schema, err := repo.GetSchemaByName(ctx, fixturesDB.ID, "PUBLIC")
+	if err != nil {
+		t.Fatalf("GetSchemaByName() error = %v", err)
+	}
+	columns := []metadata.ColumnDef{{Name: "ID", Type: "INTEGER"}, {Name: "NAME", Type: "VARCHAR"}}
+	if _, err := repo.CreateTable(ctx, schema.ID, "USERS", columns, ""); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+
+	const base = "/api/v2/databases/FIXTURES/schemas/PUBLIC/stages/FIXTURES_STAGE/files"
+	serve := func(method, path, contentType string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var multipartBuf bytes.Buffer
+	writer := multipart.NewWriter(&multipartBuf)
+	part, _ := writer.CreateFormFile("file", "more.csv")
+	_, _ = part.Write([]byte("3,carol\n"))
+	_ = writer.Close()
+
+	uploads := []struct {
+		name        string
+		path        string
+		contentType string
+		body        io.Reader
+		wantStatus  int
+		wantName    string
+	}{
+		{name: "Raw", path: base + "/users.csv", contentType: "text/csv", body: strings.NewReader("1,alice\n2,bob\n"),
+			wantStatus: http.StatusCreated, wantName: "fixtures_stage/users.csv"},
+		{name: "MultipartIntoDirectory", path: base + "/batch/", contentType: writer.FormDataContentType(), body: &multipartBuf,
+			wantStatus: http.StatusCreated, wantName: "fixtures_stage/batch/more.csv"},
+		{name: "LowercasePath", path: "/api/v2/databases/fixtures/schemas/public/stages/fixtures_stage/files/notes.txt", body: strings.NewReader("notes"),
+			wantStatus: http.StatusCreated, wantName: "fixtures_stage/notes.txt"},
+		{name: "Traversal", path: base + "/..%2F..%2Fescape.csv", body: strings.NewReader("x"), wantStatus: http.StatusBadRequest},
+		{name: "MissingPath", path: base + "/", body: strings.NewReader("x"), wantStatus: http.StatusBadRequest},
+		{name: "MissingStage", path: "/api/v2/databases/FIXTURES/schemas/PUBLIC/stages/MISSING/files/a.csv", body: strings.NewReader("x"),
+			wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range uploads {
+		t.Run("Put"+tt.name, func(t *testing.T) {
+			rr := serve(http.MethodPut, tt.path, tt.contentType, tt.body)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var resp types.StageFileResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if resp.Name != tt.wantName || resp.Size == 0 || len(resp.MD5) != 32 || resp.LastModified == "" {
+				t.Errorf("response = %+v, want a file named %s", resp, tt.wantName)
+			}
+		})
+	}
+
+	listed := func(query string) []string {
+		t.Helper()
+		rr := serve(http.MethodGet, base+query, "", nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("list status = %d. Body: %s", rr.Code, rr.Body.String())
+		}
+		var resp []types.StageFileResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		names := make([]string, len(resp))
+		for i, file := range resp {
+			names[i] = file.Name
+		}
+		return names
+	}
+	if diff := cmp.Diff([]string{"fixtures_stage/batch/more.csv", "fixtures_stage/notes.txt", "fixtures_stage/users.csv"}, listed("")); diff != "" {
+		t.Errorf("listed files mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"fixtures_stage/batch/more.csv", "fixtures_stage/users.csv"}, listed("?pattern=.*%5C.csv")); diff != "" {
+		t.Errorf("listed CSV files mismatch (-want +got):\n%s", diff)
+	}
+
+	rr := serve(http.MethodGet, base+"/batch/more.csv", "", nil)
+	if rr.Code != http.StatusOK || rr.Body.String() != "3,carol\n" {
+		t.Errorf("download = %d %q, want 200 \"3,carol\\n\"", rr.Code, rr.Body.String())
+	}
+	if rr := serve(http.MethodGet, base+"/missing.csv", "", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("download of a missing file status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	if rr := serve(http.MethodDelete, base+"/notes.txt", "", nil); rr.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want %d. Body: %s", rr.Code, http.StatusNoContent, rr.Body.String())
+	}
+	if rr := serve(http.MethodDelete, base+"/notes.txt", "", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+
+	if _, err := executor.Execute(sessionCtx, "COPY INTO FIXTURES.PUBLIC.users FROM @fixtures_stage FILE_FORMAT = (TYPE = CSV)"); err != nil {
+		t.Fatalf("COPY INTO error = %v", err)
+	}
+	result, err := executor.Query(sessionCtx, "SELECT ID, NAME FROM FIXTURES.PUBLIC.USERS ORDER BY ID")
+	if err != nil {
+		t.Fatalf("SELECT error = %v", err)
+	}
+	want := [][]interface{}{{int32(1), "alice"}, {int32(2), "bob"}, {int32(3), "carol"}}
+	if diff := cmp.Diff(want, result.Rows); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+
+	// The short paths take the database and schema from the query parameters,
+	// by default the session defaults
+	if rr := serve(http.MethodPut, "/api/v2/stages/fixtures_stage/files/extra.txt?database=FIXTURES&schema=PUBLIC", "", strings.NewReader("extra")); rr.Code != http.StatusCreated {
+		t.Fatalf("short path upload status = %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if diff := cmp.Diff([]string{"fixtures_stage/extra.txt"}, listed("?pattern=.*%5C.txt")); diff != "" {
+		t.Errorf("files uploaded through the short path mismatch (-want +got):\n%s", diff)
+	}
+	if rr := serve(http.MethodGet, "/api/v2/stages/fixtures_stage/files?database=fixtures", "", nil); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "fixtures_stage/extra.txt") {
+		t.Errorf("short path list = %d %s, want the uploaded file", rr.Code, rr.Body.String())
+	}
+
+	if _, err := repo.CreateDatabase(ctx, "TEST_DB", ""); err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	defaultCtx := query.ContextWithSessionInfo(ctx, query.SessionInfo{Database: "TEST_DB", Schema: "PUBLIC"})
+	if _, err := executor.Execute(defaultCtx, "CREATE STAGE default_stage"); err != nil {
+		t.Fatalf("CREATE STAGE error = %v", err)
+	}
+	if rr := serve(http.MethodPut, "/api/v2/stages/default_stage/files/a.csv", "", strings.NewReader("1,a\n")); rr.Code != http.StatusCreated {
+		t.Fatalf("default location upload status = %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(http.MethodGet, "/api/v2/stages/default_stage/files/a.csv", "", nil); rr.Code != http.StatusOK || rr.Body.String() != "1,a\n" {
+		t.Errorf("default location download = %d %q, want 200 \"1,a\\n\"", rr.Code, rr.Body.String())
+	}
+	if rr := serve(http.MethodDelete, "/api/v2/stages/default_stage/files/a.csv", "", nil); rr.Code != http.StatusNoContent {
+		t.Errorf("default location delete status = %d, want %d", rr.Code, http.StatusNoContent)
+	}
+	if rr := serve(http.MethodGet, "/api/v2/stages/fixtures_stage/files", "", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("list of a stage outside the default location status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRestAPIv2Handler_Queries(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	var handles []string
+	for _, statement := range []string{
+		"CREATE TABLE history_rows (id INTEGER)",
+		"INSERT INTO history_rows VALUES (1), (2), (3)",
+		"SELECT * FROM history_rows",
+		"SELECT * FROM missing_history_rows",
+	} {
+		body, _ := json.Marshal(types.SubmitStatementRequest{Statement: statement})
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp types.StatementResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		handles = append(handles, resp.StatementHandle)
+	}
+
+	type entry struct {
+		QueryID string
+		Status  string
+		Rows    int64
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []entry
+	}{
+		{
+			name:       "All",
+			wantStatus: http.StatusOK,
+			want: []entry{
+				{handles[3], "FAILED", 0},
+				{handles[2], "SUCCESS", 3},
+				{handles[1], "SUCCESS", 3},
+				{handles[0], "SUCCESS", 0},
+			},
+		},
+		{name: "Status", query: "?status=failed", wantStatus: http.StatusOK, want: []entry{{handles[3], "FAILED", 0}}},
+		{name: "QueryText", query: "?query_text=insert+into&limit=5", wantStatus: http.StatusOK, want: []entry{{handles[1], "SUCCESS", 3}}},
+		{name: "Limit", query: "?limit=1", wantStatus: http.StatusOK, want: []entry{{handles[3], "FAILED", 0}}},
+		{name: "Until", query: "?until=2000-01-01T00:00:00Z", wantStatus: http.StatusOK, want: []entry{}},
+		{name: "InvalidStatus", query: "?status=done", wantStatus: http.StatusBadRequest},
+		{name: "InvalidSince", query: "?since=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "InvalidLimit", query: "?limit=0", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/queries"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp types.ListQueriesResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			got := []entry{}
+			for _, query := range resp {
+				got = append(got, entry{query.QueryID, query.ExecutionStatus, query.RowsProduced})
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("queries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/queries/"+handles[3], nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var query types.QueryHistoryResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &query); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v. Body: %s", err, rr.Body.String())
+	}
+	if query.QueryText != "SELECT * FROM missing_history_rows" || query.EndTime == "" || !strings.Contains(query.ErrorMessage, "missing_history_rows") {
+		t.Errorf("GetQuery() = %+v, want the failed query with its end time and error", query)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/queries/missing", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("missing query: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRestAPIv2Handler_StatementLabels(t *testing.T) {
+	_, router := setupRestAPIv2Handler(t)
+
+	submit := func(body types.SubmitStatementRequest) (int, types.StatementResponse) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/statements", bytes.NewReader(data))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var resp types.StatementResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return rr.Code, resp
+	}
+
+	checkout := map[string]string{"suite": "checkout", "test": "TestPay"}
+	_, tagged := submit(types.SubmitStatementRequest{
+		Statement:  "SELECT 1",
+		Parameters: map[string]string{"query_tag": "nightly"},
+		Labels:     checkout,
+	})
+	if tagged.QueryTag != "nightly" || !cmp.Equal(tagged.Labels, checkout) {
+		t.Errorf("SubmitStatement() tag = %q, labels = %v, want nightly, %v", tagged.QueryTag, tagged.Labels, checkout)
+	}
+	_, failed := submit(types.SubmitStatementRequest{Statement: "SELECT * FROM missing_labels", Labels: map[string]string{"suite": "cart"}})
+	_, untagged := submit(types.SubmitStatementRequest{Statement: "SELECT 2"})
+	if untagged.QueryTag != "" || untagged.Labels != nil {
+		t.Errorf("SubmitStatement() without labels = %q, %v, want none", untagged.QueryTag, untagged.Labels)
+	}
+	if code, _ := submit(types.SubmitStatementRequest{Statement: "SELECT 3", Labels: map[string]string{"": "x"}}); code != http.StatusBadRequest {
+		t.Errorf("empty label name: status = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/statements/"+tagged.StatementHandle, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var status types.StatementResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if status.QueryTag != "nightly" || !cmp.Equal(status.Labels, checkout) {
+		t.Errorf("GetStatement() tag = %q, labels = %v, want nightly, %v", status.QueryTag, status.Labels, checkout)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{name: "QueryTag", query: "?query_tag=nightly", wantStatus: http.StatusOK, want: []string{tagged.StatementHandle}},
+		{name: "Label", query: "?label=suite=checkout", wantStatus: http.StatusOK, want: []string{tagged.StatementHandle}},
+		{name: "Labels", query: "?label=suite=checkout&label=test=TestCart", wantStatus: http.StatusOK, want: []string{}},
+		{name: "FailedLabel", query: "?label=suite=cart&status=failed", wantStatus: http.StatusOK, want: []string{failed.StatementHandle}},
+		{name: "InvalidLabel", query: "?label=suite", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/queries"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp types.ListQueriesResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			got := []string{}
+			for _, query := range resp {
+				got = append(got, query.QueryID)
+				if query.QueryID == tagged.StatementHandle && (query.QueryTag != "nightly" || !cmp.Equal(query.Labels, checkout)) {
+					t.Errorf("query tag = %q, labels = %v, want nightly, %v", query.QueryTag, query.Labels, checkout)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("queries mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestRestAPIv2Handler_CancelStatement(t *testing.T) {
 	handler, router := setupRestAPIv2Handler(t)
 
@@ -322,3 +1386,254 @@ func TestRestAPIv2Handler_InvalidJSON(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
 	}
 }
+
+func TestRestAPIv2Handler_GetCatalog(t *testing.T) {
+	ctx := context.Background()
+	store := metadata.NewMemoryStore()
+	handler := NewRestAPIv2Handler(nil, query.NewStatementManager(1*time.Hour), store)
+
+	db, err := store.CreateDatabase(ctx, "ANALYTICS", "")
+	if err != nil {
+		t.Fatalf("CreateDatabase() error = %v", err)
+	}
+	schema, err := store.CreateSchema(ctx, db.ID, "PUBLIC", "")
+	if err != nil {
+		t.Fatalf("CreateSchema() error = %v", err)
+	}
+	columns := []metadata.ColumnDef{{Name: "ID", Type: "INTEGER", PrimaryKey: true}}
+	if _, err := store.CreateTable(ctx, schema.ID, "USERS", columns, ""); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+
+	getCatalog := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/catalog", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		handler.GetCatalog(rr, req)
+		return rr
+	}
+
+	rr := getCatalog("")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header")
+	}
+
+	var resp types.CatalogResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(resp.Databases) != 1 || len(resp.Databases[0].Schemas) != 1 || len(resp.Databases[0].Schemas[0].Tables) != 1 {
+		t.Fatalf("Unexpected catalog tree: %s", rr.Body.String())
+	}
+	table := resp.Databases[0].Schemas[0].Tables[0]
+	if table.Name != "USERS" || len(table.Columns) != 1 || table.Columns[0].Name != "ID" {
+		t.Errorf("Unexpected table: %+v", table)
+	}
+
+	// Unchanged catalog returns 304 for a matching ETag
+	if rr := getCatalog(etag); rr.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", http.StatusNotModified, rr.Code)
+	}
+
+	// Any catalog change produces a new ETag
+	if _, err := store.CreateTable(ctx, schema.ID, "ORDERS", columns, ""); err != nil {
+		t.Fatalf("CreateTable() error = %v", err)
+	}
+	rr = getCatalog(etag)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("ETag") == etag {
+		t.Error("Expected ETag to change after catalog change")
+	}
+}
+
+func TestRestAPIv2Handler_GetSession(t *testing.T) {
+	handler, router := setupRestAPIv2Handler(t)
+	ctx := context.Background()
+
+	sess, err := handler.sessionMgr.CreateSession(ctx, "tester", "TEST_DB", "PUBLIC")
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	if err := handler.sessionMgr.UpdateSessionWarehouse(ctx, sess.Token, "compute_wh"); err != nil {
+		t.Fatalf("UpdateSessionWarehouse() error = %v", err)
+	}
+	if err := handler.sessionMgr.UpdateSessionParameters(ctx, sess.Token, map[string]interface{}{"week_start": 1}); err != nil {
+		t.Fatalf("UpdateSessionParameters() error = %v", err)
+	}
+	if err := handler.sessionMgr.UpdateSessionClient(ctx, sess.Token, "Go", "1.11.0", map[string]interface{}{"APPLICATION": "dbt", "OS": "linux"}); err != nil {
+		t.Fatalf("UpdateSessionClient() error = %v", err)
+	}
+	sessionID := strconv.FormatInt(sess.ID, 10)
+	if _, err := handler.executor.Execute(query.ContextWithSessionInfo(ctx, query.SessionInfo{ID: sessionID}), "SET region = 'eu'"); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+		want       *types.SessionResponse
+	}{
+		{
+			name:       "Found",
+			id:         sessionID,
+			wantStatus: http.StatusOK,
+			want: &types.SessionResponse{
+				SessionID:  sess.ID,
+				User:       "tester",
+				Role:       "SYSADMIN",
+				Database:   "TEST_DB",
+				Schema:     "PUBLIC",
+				Warehouse:  "COMPUTE_WH",
+				Parameters: map[string]string{"WEEK_START": "1"},
+				Variables:  map[string]any{"REGION": "eu"},
+
+				ClientAppID:       "Go",
+				ClientAppVersion:  "1.11.0",
+				ClientEnvironment: map[string]any{"APPLICATION": "dbt", "OS": "linux"},
+			},
+		},
+		{name: "Unknown", id: "999999", wantStatus: http.StatusNotFound},
+		{name: "Invalid", id: "abc", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/sessions/"+tt.id, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d. Body: %s", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if tt.want == nil {
+				return
+			}
+			var got types.SessionResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(*tt.want, got, cmpopts.IgnoreFields(types.SessionResponse{}, "CreatedOn", "LastAccessedOn")); diff != "" {
+				t.Errorf("GetSession() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRestAPIv2Handler_ListSessions(t *testing.T) {
+	handler, router := setupRestAPIv2Handler(t)
+	ctx := context.Background()
+
+	var want []string
+	for _, user := range []string{"first", "second"} {
+		sess, err := handler.sessionMgr.CreateSession(ctx, user, "TEST_DB", "PUBLIC")
+		if err != nil {
+			t.Fatalf("CreateSession() error = %v", err)
+		}
+		if err := handler.sessionMgr.UpdateSessionClient(ctx, sess.Token, "Go", "1.11.0", map[string]interface{}{"APPLICATION": user}); err != nil {
+			t.Fatalf("UpdateSessionClient() error = %v", err)
+		}
+		want = append(want, user+" Go 1.11.0 "+user)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/sessions", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d. Body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	var sessions types.ListSessionsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var got []string
+	for _, sess := range sessions {
+		got = append(got, fmt.Sprintf("%s %s %s %v", sess.User, sess.ClientAppID, sess.ClientAppVersion, sess.ClientEnvironment["APPLICATION"]))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ListSessions() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRestAPIv2Handler_DeleteDropProtection(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        query.DropProtection
+		wantStatus  int
+		wantDropped bool
+		wantMessage string
+	}{
+		{name: "Off", mode: query.DropProtectionOff, wantStatus: http.StatusNoContent, wantDropped: true},
+		{name: "Reject", mode: query.DropProtectionReject, wantStatus: http.StatusForbidden, wantMessage: "rejected by drop protection"},
+		{name: "DryRun", mode: query.DropProtectionDryRun, wantStatus: http.StatusOK, wantMessage: "Dry run, nothing was changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, router := setupRestAPIv2Handler(t, query.WithDropProtection(tt.mode))
+			ctx := context.Background()
+
+			db, err := handler.repo.CreateDatabase(ctx, "SEED_DB", "")
+			if err != nil {
+				t.Fatalf("CreateDatabase() error = %v", err)
+			}
+			schema, err := handler.repo.CreateSchema(ctx, db.ID, "RAW", "")
+			if err != nil {
+				t.Fatalf("CreateSchema() error = %v", err)
+			}
+			columns := []metadata.ColumnDef{{Name: "ID", Type: "INTEGER", Nullable: true}}
+			if _, err := handler.repo.CreateTable(ctx, schema.ID, "EVENTS", columns, ""); err != nil {
+				t.Fatalf("CreateTable() error = %v", err)
+			}
+
+			exists := map[string]func() error{
+				"/api/v2/databases/SEED_DB/schemas/RAW/tables/EVENTS": func() error {
+					_, err := handler.repo.GetTableByName(ctx, schema.ID, "EVENTS")
+					return err
+				},
+				"/api/v2/databases/SEED_DB/schemas/RAW": func() error {
+					_, err := handler.repo.GetSchemaByName(ctx, db.ID, "RAW")
+					return err
+				},
+				"/api/v2/databases/SEED_DB": func() error {
+					_, err := handler.repo.GetDatabaseByName(ctx, "SEED_DB")
+					return err
+				},
+			}
+			// Drop the table first so that each drop finds its object, whether
+			// or not the previous one was dropped.
+			for _, path := range []string{
+				"/api/v2/databases/SEED_DB/schemas/RAW/tables/EVENTS",
+				"/api/v2/databases/SEED_DB/schemas/RAW",
+				"/api/v2/databases/SEED_DB",
+			} {
+				req := httptest.NewRequest(http.MethodDelete, path, nil)
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				if rr.Code != tt.wantStatus {
+					t.Fatalf("DELETE %s status = %d, want %d. Body: %s", path, rr.Code, tt.wantStatus, rr.Body.String())
+				}
+				if tt.wantMessage != "" {
+					var resp types.StatementResponse
+					if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+						t.Fatalf("failed to decode response: %v", err)
+					}
+					if !strings.Contains(resp.Message, tt.wantMessage) {
+						t.Errorf("DELETE %s message = %q, want it to contain %q", path, resp.Message, tt.wantMessage)
+					}
+				}
+				if err := exists[path](); (err != nil) != tt.wantDropped {
+					t.Errorf("DELETE %s: lookup error = %v, want dropped %v", path, err, tt.wantDropped)
+				}
+			}
+		})
+	}
+}
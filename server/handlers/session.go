@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
 	"github.com/nnnkkk7/snowflake-emulator/server/apierror"
 	"github.com/nnnkkk7/snowflake-emulator/server/types"
@@ -16,7 +20,10 @@ import (
 // SessionHandler handles session-related HTTP requests.
 type SessionHandler struct {
 	sessionMgr *session.Manager
-	repo       *metadata.Repository
+	repo       metadata.Store
+	// minClientVersions are the oldest driver versions accepted at login, by
+	// upper-case CLIENT_APP_ID.
+	minClientVersions map[string]string
 }
 
 // RenewSessionRequest represents a session renewal request (legacy).
@@ -42,11 +49,12 @@ type LogoutResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-// UseContextRequest represents a USE DATABASE/SCHEMA request.
+// UseContextRequest represents a USE DATABASE/SCHEMA/WAREHOUSE request.
 type UseContextRequest struct {
-	Token    string `json:"token"`
-	Database string `json:"database,omitempty"`
-	Schema   string `json:"schema,omitempty"`
+	Token     string `json:"token"`
+	Database  string `json:"database,omitempty"`
+	Schema    string `json:"schema,omitempty"`
+	Warehouse string `json:"warehouse,omitempty"`
 }
 
 // UseContextResponse represents a USE context response.
@@ -56,11 +64,15 @@ type UseContextResponse struct {
 }
 
 // NewSessionHandler creates a new session handler.
-func NewSessionHandler(sessionMgr *session.Manager, repo *metadata.Repository) *SessionHandler {
-	return &SessionHandler{
+func NewSessionHandler(sessionMgr *session.Manager, repo metadata.Store, opts ...SessionHandlerOption) *SessionHandler {
+	h := &SessionHandler{
 		sessionMgr: sessionMgr,
 		repo:       repo,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Login handles login requests with gosnowflake protocol.
@@ -76,6 +88,10 @@ func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
 		sendError(w, apierror.NewSnowflakeError(apierror.CodeAuthenticationFailed, "Username and password are required"))
 		return
 	}
+	if err := h.checkClientVersion(req.Data.ClientAppID, req.Data.ClientAppVersion); err != nil {
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeAuthenticationFailed, err.Error()))
+		return
+	}
 
 	// Set default database/schema if not provided
 	database := req.Data.DatabaseName
@@ -88,7 +104,8 @@ func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
 		schema = config.DefaultSchema
 	}
 
-	ctx := r.Context()
+	role := roleOrDefault(req.Data.RoleName)
+	ctx := metadata.ContextWithOwner(r.Context(), role)
 
 	// Ensure database exists (try to get it, create if not found)
 	_, err := h.repo.GetDatabaseByName(ctx, database)
@@ -107,34 +124,49 @@ func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
 		sendError(w, apierror.NewSnowflakeError(apierror.CodeInternalError, "Failed to create session"))
 		return
 	}
+	if err := h.sessionMgr.UpdateSessionRole(ctx, sess.Token, role); err != nil {
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeInternalError, "Failed to set session role"))
+		return
+	}
+	if req.Data.WarehouseName != "" {
+		if err := h.sessionMgr.UpdateSessionWarehouse(ctx, sess.Token, req.Data.WarehouseName); err != nil {
+			sendError(w, apierror.NewSnowflakeError(apierror.CodeInternalError, "Failed to set session warehouse"))
+			return
+		}
+	}
+	if err := h.sessionMgr.UpdateSessionClient(ctx, sess.Token, req.Data.ClientAppID, req.Data.ClientAppVersion, req.Data.ClientEnvironment); err != nil {
+		sendError(w, apierror.NewSnowflakeError(apierror.CodeInternalError, "Failed to record client information"))
+		return
+	}
 
 	// Build parameter bindings from default session parameters
 	defaultParams := config.DefaultSessionParameters()
 	parameters := []types.ParameterBinding{
 		{Name: string(config.ParamTimezone), Value: defaultParams[config.ParamTimezone]},
 		{Name: string(config.ParamTimestampOutputFormat), Value: defaultParams[config.ParamTimestampOutputFormat]},
+		{Name: string(config.ParamDateOutputFormat), Value: defaultParams[config.ParamDateOutputFormat]},
+		{Name: string(config.ParamTimeOutputFormat), Value: defaultParams[config.ParamTimeOutputFormat]},
 		{Name: string(config.ParamClientSessionKeepAlive), Value: defaultParams[config.ParamClientSessionKeepAlive]},
 		{Name: string(config.ParamQueryTag), Value: defaultParams[config.ParamQueryTag]},
 		{Name: string(config.ParamGoQueryResultFormat), Value: defaultParams[config.ParamGoQueryResultFormat]},
+		{Name: string(config.ParamWeekStart), Value: defaultParams[config.ParamWeekStart]},
+		{Name: string(config.ParamWeekOfYearPolicy), Value: defaultParams[config.ParamWeekOfYearPolicy]},
+		{Name: string(config.ParamLockTimeout), Value: defaultParams[config.ParamLockTimeout]},
+		{Name: string(config.ParamAutocommit), Value: defaultParams[config.ParamAutocommit]},
+		{Name: string(config.ParamAbortDetachedQuery), Value: defaultParams[config.ParamAbortDetachedQuery]},
 	}
 
 	// Add user-provided session parameters
 	for k, v := range req.Data.SessionParams {
-		// Convert any type to string for the response
-		var strValue string
-		switch val := v.(type) {
-		case string:
-			strValue = val
-		case bool:
-			if val {
-				strValue = "true"
-			} else {
-				strValue = "false"
-			}
-		default:
-			strValue = fmt.Sprintf("%v", v)
+		parameters = append(parameters, types.ParameterBinding{Name: k, Value: parameterString(v)})
+	}
+
+	// Keep user-provided parameters on the session so they apply to its queries
+	if len(req.Data.SessionParams) > 0 {
+		if err := h.sessionMgr.UpdateSessionParameters(ctx, sess.Token, req.Data.SessionParams); err != nil {
+			sendError(w, apierror.NewSnowflakeError(apierror.CodeInternalError, "Failed to set session parameters"))
+			return
 		}
-		parameters = append(parameters, types.ParameterBinding{Name: k, Value: strValue})
 	}
 
 	// Build success response
@@ -151,7 +183,7 @@ func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
 				DatabaseName:  database,
 				SchemaName:    schema,
 				WarehouseName: req.Data.WarehouseName,
-				RoleName:      req.Data.RoleName,
+				RoleName:      role,
 			},
 		},
 	}
@@ -252,7 +284,7 @@ func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-// UseContext handles USE DATABASE/SCHEMA requests.
+// UseContext handles USE DATABASE/SCHEMA/WAREHOUSE requests.
 func (h *SessionHandler) UseContext(w http.ResponseWriter, r *http.Request) {
 	var req UseContextRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -267,6 +299,12 @@ func (h *SessionHandler) UseContext(w http.ResponseWriter, r *http.Request) {
 		sendError(w, apierror.NewSnowflakeError(apierror.CodeInvalidParameter, "Failed to update session context"))
 		return
 	}
+	if req.Warehouse != "" {
+		if err := h.sessionMgr.UpdateSessionWarehouse(ctx, req.Token, req.Warehouse); err != nil {
+			sendError(w, apierror.NewSnowflakeError(apierror.CodeInvalidParameter, "Failed to update session context"))
+			return
+		}
+	}
 
 	// Write success response
 	resp := UseContextResponse{
@@ -353,3 +391,81 @@ func extractToken(r *http.Request) string {
 
 	return ""
 }
+
+// parameterString converts a session parameter value to its string form.
+func parameterString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case bool:
+		if val {
+			return "true"
+		}
+		return "false"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// sessionParameters returns the parameters of a session as statement
+// responses carry them: the defaults the emulator knows, overridden by those
+// the session set, ordered by name. Parameters ALTER SESSION unset are thus
+// returned to drivers with their defaults.
+func sessionParameters(sess *session.Session) []types.ParameterBinding {
+	values := make(map[string]string)
+	for name, value := range config.DefaultSessionParameters() {
+		values[string(name)] = value
+	}
+	for name, value := range sess.Parameters {
+		values[strings.ToUpper(name)] = parameterString(value)
+	}
+	parameters := make([]types.ParameterBinding, 0, len(values))
+	for name, value := range values {
+		parameters = append(parameters, types.ParameterBinding{Name: name, Value: value})
+	}
+	sort.Slice(parameters, func(i, j int) bool { return parameters[i].Name < parameters[j].Name })
+	return parameters
+}
+
+// withQueryTag returns a copy of ctx whose session has the given QUERY_TAG.
+func withQueryTag(ctx context.Context, tag string) context.Context {
+	info := query.SessionInfoFromContext(ctx)
+	params := make(map[string]string, len(info.Parameters)+1)
+	for name, value := range info.Parameters {
+		if !strings.EqualFold(name, string(config.ParamQueryTag)) {
+			params[name] = value
+		}
+	}
+	params[string(config.ParamQueryTag)] = tag
+	info.Parameters = params
+	return query.ContextWithSessionInfo(ctx, info)
+}
+
+// withSession returns a copy of ctx carrying the session's translation parameters,
+// its user and role, and its role as the owner of objects it creates.
+func withSession(ctx context.Context, sess *session.Session) context.Context {
+	params := make(map[string]string, len(sess.Parameters))
+	for name, value := range sess.Parameters {
+		params[name] = parameterString(value)
+	}
+	role := roleOrDefault(sess.Role)
+	ctx = query.ContextWithSessionParameters(ctx, query.ParseSessionParameters(params))
+	ctx = query.ContextWithSessionInfo(ctx, query.SessionInfo{
+		ID:         strconv.FormatInt(sess.ID, 10),
+		User:       sess.Username,
+		Role:       role,
+		Database:   sess.Database,
+		Schema:     sess.CurrentSchema,
+		Warehouse:  sess.Warehouse,
+		Parameters: params,
+	})
+	return metadata.ContextWithOwner(ctx, role)
+}
+
+// roleOrDefault returns role uppercased, or the default role if it is empty.
+func roleOrDefault(role string) string {
+	if role == "" {
+		return config.DefaultRole
+	}
+	return strings.ToUpper(role)
+}
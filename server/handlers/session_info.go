@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// ListSessions handles GET /api/v2/sessions, returning the active driver
+// sessions, oldest first, with the clients that opened them.
+func (h *RestAPIv2Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	resp := types.ListSessionsResponse{}
+	if h.sessionMgr != nil {
+		sessions, err := h.sessionMgr.ListSessions(r.Context())
+		if err != nil {
+			h.sendError(w, http.StatusInternalServerError, "Failed to list sessions", types.SQLState42000)
+			return
+		}
+		for _, sess := range sessions {
+			resp = append(resp, h.sessionResponse(sess))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GetSession handles GET /api/v2/sessions/{sessionId}, returning the context
+// of a driver session: its user, role, current database, schema, and
+// warehouse, the parameters it set, its SQL variables, and its client.
+func (h *RestAPIv2Handler) GetSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "sessionId"), 10, 64)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, "Invalid session ID", types.SQLState42000)
+		return
+	}
+	if h.sessionMgr == nil {
+		h.sendError(w, http.StatusNotFound, "Session not found", types.SQLState02000)
+		return
+	}
+	sess, err := h.sessionMgr.GetSessionByID(r.Context(), id)
+	if err != nil {
+		h.sendError(w, http.StatusNotFound, "Session not found", types.SQLState02000)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(h.sessionResponse(sess))
+}
+
+// sessionResponse renders a session's context.
+func (h *RestAPIv2Handler) sessionResponse(sess *session.Session) types.SessionResponse {
+	params := make(map[string]string, len(sess.Parameters))
+	for name, value := range sess.Parameters {
+		params[name] = parameterString(value)
+	}
+	return types.SessionResponse{
+		SessionID:         sess.ID,
+		User:              sess.Username,
+		Role:              roleOrDefault(sess.Role),
+		Database:          sess.Database,
+		Schema:            sess.CurrentSchema,
+		Warehouse:         sess.Warehouse,
+		Parameters:        params,
+		Variables:         h.executor.SessionVariables(strconv.FormatInt(sess.ID, 10)),
+		CreatedOn:         sess.CreatedAt.Format(time.RFC3339Nano),
+		LastAccessedOn:    sess.LastAccessedAt.Format(time.RFC3339Nano),
+		ClientAppID:       sess.ClientAppID,
+		ClientAppVersion:  sess.ClientAppVersion,
+		ClientEnvironment: sess.ClientEnvironment,
+	}
+}
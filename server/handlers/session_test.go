@@ -11,6 +11,8 @@ import (
 	"time"
 
 	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
@@ -19,7 +21,7 @@ import (
 )
 
 // setupTestHandler creates a test handler with dependencies.
-func setupTestHandler(t *testing.T) *SessionHandler {
+func setupTestHandler(t *testing.T, opts ...SessionHandlerOption) *SessionHandler {
 	t.Helper()
 
 	db, err := sql.Open("duckdb", "")
@@ -53,7 +55,89 @@ func setupTestHandler(t *testing.T) *SessionHandler {
 		t.Fatalf("failed to create schema: %v", err)
 	}
 
-	return NewSessionHandler(sessionMgr, repo)
+	return NewSessionHandler(sessionMgr, repo, opts...)
+}
+
+// TestSessionHandler_LoginClientInfo tests that logins record their client
+// and that drivers below the minimum version are rejected.
+func TestSessionHandler_LoginClientInfo(t *testing.T) {
+	handler := setupTestHandler(t, WithMinClientVersions(map[string]string{"go": "1.10.0"}))
+	environment := map[string]any{"APPLICATION": "dbt", "OS": "linux", "OS_VERSION": "6.1"}
+
+	tests := []struct {
+		name        string
+		appID       string
+		appVersion  string
+		wantSuccess bool
+	}{
+		{name: "Newer", appID: "Go", appVersion: "1.11.2", wantSuccess: true},
+		{name: "Minimum", appID: "Go", appVersion: "1.10.0", wantSuccess: true},
+		{name: "Older", appID: "Go", appVersion: "1.9.5", wantSuccess: false},
+		{name: "MissingVersion", appID: "Go", wantSuccess: false},
+		{name: "OtherClient", appID: "JDBC", appVersion: "3.0.0", wantSuccess: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(types.LoginRequest{Data: types.LoginRequestData{
+				ClientAppID:       tt.appID,
+				ClientAppVersion:  tt.appVersion,
+				LoginName:         "testuser",
+				Password:          "testpass",
+				ClientEnvironment: environment,
+			}})
+			req := httptest.NewRequest(http.MethodPost, "/session/v1/login-request", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+			handler.Login(rr, req)
+
+			var resp types.LoginResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("Login() success = %v, want %v: %s", resp.Success, tt.wantSuccess, resp.Message)
+			}
+			if !resp.Success {
+				if resp.Code != apierror.CodeAuthenticationFailed {
+					t.Errorf("Login() code = %s, want %s", resp.Code, apierror.CodeAuthenticationFailed)
+				}
+				return
+			}
+
+			sess, err := handler.sessionMgr.ValidateSession(context.Background(), resp.Data.Token)
+			if err != nil {
+				t.Fatalf("ValidateSession() error = %v", err)
+			}
+			got := []any{sess.ClientAppID, sess.ClientAppVersion, sess.ClientEnvironment}
+			if diff := cmp.Diff([]any{tt.appID, tt.appVersion, environment}, got); diff != "" {
+				t.Errorf("session client mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestParseMinClientVersions tests parsing of MIN_CLIENT_VERSIONS.
+func TestParseMinClientVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "Pairs", value: "Go=1.10.0, JDBC=3.14.0", want: map[string]string{"Go": "1.10.0", "JDBC": "3.14.0"}},
+		{name: "MissingVersion", value: "Go", wantErr: true},
+		{name: "InvalidVersion", value: "Go=latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMinClientVersions(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMinClientVersions(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseMinClientVersions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
 }
 
 // TestSessionHandler_LoginRequest tests the login endpoint.
@@ -179,6 +263,48 @@ func TestSessionHandler_LoginRequest(t *testing.T) {
 	}
 }
 
+// TestSessionHandler_Login_MemoryStore tests that login works against the in-memory
+// metadata store and auto-creates the requested database there.
+func TestSessionHandler_Login_MemoryStore(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	handler := NewSessionHandler(session.NewManager(1*time.Hour), store)
+
+	body, err := json.Marshal(types.LoginRequest{
+		Data: types.LoginRequestData{
+			LoginName:    "testuser",
+			Password:     "testpass",
+			DatabaseName: "new_db",
+			SchemaName:   "PUBLIC",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/session/v1/login-request", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.Login(w, req)
+
+	var resp types.LoginResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Login() failed: %s", resp.Message)
+	}
+
+	db, err := store.GetDatabaseByName(context.Background(), "NEW_DB")
+	if err != nil {
+		t.Fatalf("GetDatabaseByName() error = %v", err)
+	}
+	if db.Owner != config.DefaultRole {
+		t.Errorf("Owner = %q, want %q", db.Owner, config.DefaultRole)
+	}
+	if resp.Data.SessionInfo.RoleName != config.DefaultRole {
+		t.Errorf("RoleName = %q, want %q", resp.Data.SessionInfo.RoleName, config.DefaultRole)
+	}
+}
+
 // TestSessionHandler_TokenRequest tests session token renewal with master token.
 func TestSessionHandler_TokenRequest(t *testing.T) {
 	handler := setupTestHandler(t)
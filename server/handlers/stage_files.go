@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/config"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/stage"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// ListStageFiles handles GET /api/v2/databases/{database}/schemas/{schema}/stages/{stage}/files
+// and GET /api/v2/stages/{stage}/files, listing the files of an internal stage as LIST does. The pattern query
+// parameter is a regular expression the listed names must match in full.
+func (h *RestAPIv2Handler) ListStageFiles(w http.ResponseWriter, r *http.Request) {
+	database, schema := stageFileLocation(r)
+	files, err := h.executor.ListStageFiles(r.Context(), database, schema, chi.URLParam(r, "stage"), r.URL.Query().Get("pattern"))
+	if err != nil {
+		h.sendStageFileError(w, err)
+		return
+	}
+
+	resp := make([]types.StageFileResponse, len(files))
+	for i, file := range files {
+		resp[i] = stageFileResponse(file)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// PutStageFile handles PUT /api/v2/databases/{database}/schemas/{schema}/stages/{stage}/files/{path}
+// and PUT /api/v2/stages/{stage}/files/{path},
+// writing the request body, or the first file of a multipart/form-data body,
+// to the file at path of an internal stage. A path ending in / is a directory
+// that the uploaded file's name is appended to.
+func (h *RestAPIv2Handler) PutStageFile(w http.ResponseWriter, r *http.Request) {
+	body, _, fileName, err := loadRowsBody(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error(), types.SQLState42000)
+		return
+	}
+	filePath := chi.URLParam(r, "*")
+	if fileName != "" && (filePath == "" || strings.HasSuffix(filePath, "/")) {
+		filePath += path.Base(fileName)
+	}
+	if filePath == "" || strings.HasSuffix(filePath, "/") {
+		h.sendError(w, http.StatusBadRequest, "File path is required", types.SQLState42000)
+		return
+	}
+
+	database, schema := stageFileLocation(r)
+	file, err := h.executor.PutStageFile(r.Context(), database, schema, chi.URLParam(r, "stage"), filePath, body)
+	if err != nil {
+		h.sendStageFileError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(stageFileResponse(*file))
+}
+
+// GetStageFile handles GET /api/v2/databases/{database}/schemas/{schema}/stages/{stage}/files/{path}
+// and GET /api/v2/stages/{stage}/files/{path},
+// returning the contents of the file at path of an internal stage.
+func (h *RestAPIv2Handler) GetStageFile(w http.ResponseWriter, r *http.Request) {
+	database, schema := stageFileLocation(r)
+	reader, err := h.executor.GetStageFile(r.Context(), database, schema, chi.URLParam(r, "stage"), chi.URLParam(r, "*"))
+	if err != nil {
+		h.sendStageFileError(w, err)
+		return
+	}
+	defer func() { _ = reader.Close() }()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, reader)
+}
+
+// DeleteStageFile handles DELETE /api/v2/databases/{database}/schemas/{schema}/stages/{stage}/files/{path}
+// and DELETE /api/v2/stages/{stage}/files/{path},
+// removing the file at path from an internal stage.
+func (h *RestAPIv2Handler) DeleteStageFile(w http.ResponseWriter, r *http.Request) {
+	database, schema := stageFileLocation(r)
+	err := h.executor.RemoveStageFile(r.Context(), database, schema, chi.URLParam(r, "stage"), chi.URLParam(r, "*"))
+	if err != nil {
+		h.sendStageFileError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stageFileLocation returns the database and schema of the stage of a stage
+// file request: those of the path, or, for /api/v2/stages/{stage}/files, the
+// database and schema query parameters, defaulting to the session defaults.
+func stageFileLocation(r *http.Request) (database, schema string) {
+	database, schema = chi.URLParam(r, "database"), chi.URLParam(r, "schema")
+	if database == "" {
+		database = r.URL.Query().Get("database")
+	}
+	if schema == "" {
+		schema = r.URL.Query().Get("schema")
+	}
+	if database == "" {
+		database = config.DefaultDatabase
+	}
+	if schema == "" {
+		schema = config.DefaultSchema
+	}
+	return database, schema
+}
+
+// sendStageFileError sends the error of a stage file operation: 404 for a
+// missing stage or file, and 400 otherwise.
+func (h *RestAPIv2Handler) sendStageFileError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, query.ErrStageNotFound):
+		h.sendError(w, http.StatusNotFound, "Stage not found", types.SQLState02000)
+	case errors.Is(err, stage.ErrFileNotFound):
+		h.sendError(w, http.StatusNotFound, "File not found", types.SQLState02000)
+	default:
+		h.sendError(w, http.StatusBadRequest, err.Error(), types.SQLState42000)
+	}
+}
+
+// stageFileResponse returns the response for a stage file.
+func stageFileResponse(file query.StageFile) types.StageFileResponse {
+	return types.StageFileResponse{
+		Name:         file.Name,
+		Size:         file.Size,
+		MD5:          file.MD5,
+		LastModified: file.LastModified.UTC().Format(http.TimeFormat),
+	}
+}
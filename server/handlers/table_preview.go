@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/server/types"
+)
+
+// Number of rows PreviewTable returns by default, and at most.
+const (
+	defaultPreviewLimit = 10
+	maxPreviewLimit     = 1000
+)
+
+// PreviewTable handles GET /api/v2/databases/{database}/schemas/{schema}/tables/{table}/preview,
+// returning the first rows of a table with the row type of a statement result,
+// without running a statement. The limit query parameter sets the number of
+// rows, 10 by default and at most 1000.
+func (h *RestAPIv2Handler) PreviewTable(w http.ResponseWriter, r *http.Request) {
+	dbName := chi.URLParam(r, "database")
+	schemaName := chi.URLParam(r, "schema")
+	tableName := chi.URLParam(r, "table")
+
+	limit := defaultPreviewLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxPreviewLimit {
+			h.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: must be between 1 and %d", maxPreviewLimit), types.SQLState42000)
+			return
+		}
+		limit = n
+	}
+
+	result, err := h.executor.PreviewTable(r.Context(), dbName, schemaName, tableName, limit)
+	if errors.Is(err, query.ErrTableNotFound) {
+		h.sendError(w, http.StatusNotFound, "Table not found", types.SQLState02000)
+		return
+	}
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error(), types.SQLState42000)
+		return
+	}
+
+	var params query.SessionParameters
+	data := make([][]interface{}, len(result.Rows))
+	for i, row := range result.Rows {
+		data[i] = make([]interface{}, len(row))
+		for j, val := range row {
+			data[i][j] = h.formatValue(params, val, result.ColumnTypes[j].Type)
+		}
+	}
+	resp := types.TablePreviewResponse{
+		Database: dbName,
+		Schema:   schemaName,
+		Table:    tableName,
+		NumRows:  int64(len(data)),
+		RowType:  rowTypeFields(result.ColumnTypes),
+		Data:     data,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
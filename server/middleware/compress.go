@@ -0,0 +1,37 @@
+// Package middleware provides the HTTP middleware of the emulator's server.
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultCompressionLevel is the compression level used when none is configured.
+const DefaultCompressionLevel = 5
+
+// compressibleTypes are the content types of the responses that are compressed:
+// the JSON of the query and REST API v2 handlers.
+var compressibleTypes = []string{"application/json", "text/plain"}
+
+// Compress returns a middleware compressing JSON responses with zstd, gzip, or
+// deflate, as negotiated through the request's Accept-Encoding header. zstd is
+// preferred when a client accepts several encodings. level is a flate
+// compression level from 1 (fastest) to 9 (smallest), which is mapped to the
+// closest zstd level.
+func Compress(level int) func(http.Handler) http.Handler {
+	compressor := chimiddleware.NewCompressor(level, compressibleTypes...)
+	compressor.SetEncoder("zstd", encoderZstd)
+	return compressor.Handler
+}
+
+// encoderZstd creates a zstd encoder writing to w.
+func encoderZstd(w io.Writer, level int) io.Writer {
+	encoder, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return nil
+	}
+	return encoder
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompress(t *testing.T) {
+	body := `{"data":{"rowset":[` + strings.Repeat(`["1","a"],`, 1000) + `["2","b"]]},"success":true}`
+	handler := Compress(DefaultCompressionLevel)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, body)
+	}))
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantEncoding   string
+		decode         func(io.Reader) (io.Reader, error)
+	}{
+		{
+			name:         "Identity",
+			decode:       func(r io.Reader) (io.Reader, error) { return r, nil },
+			wantEncoding: "",
+		},
+		{
+			name:           "Gzip",
+			acceptEncoding: "gzip",
+			wantEncoding:   "gzip",
+			decode:         func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			name:           "ZstdPreferred",
+			acceptEncoding: "gzip, deflate, zstd",
+			wantEncoding:   "zstd",
+			decode: func(r io.Reader) (io.Reader, error) {
+				decoder, err := zstd.NewReader(r)
+				if err != nil {
+					return nil, err
+				}
+				return decoder.IOReadCloser(), nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/queries/v1/query-request", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if tt.wantEncoding != "" && w.Body.Len() >= len(body) {
+				t.Errorf("compressed body is %d bytes, want fewer than %d", w.Body.Len(), len(body))
+			}
+			reader, err := tt.decode(w.Body)
+			if err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if string(got) != body {
+				t.Errorf("decoded body = %.40q..., want the original body", got)
+			}
+		})
+	}
+}
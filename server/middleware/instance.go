@@ -0,0 +1,19 @@
+package middleware
+
+import "net/http"
+
+// InstanceHeader is the response header naming the replica that served a
+// request.
+const InstanceHeader = "X-Snowflake-Emulator-Instance"
+
+// Instance returns a middleware setting the InstanceHeader of every response
+// to id, the routing hint a load balancer can pin a client's later requests
+// with. Tokens issued by the replica carry the same ID as their prefix.
+func Instance(id string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(InstanceHeader, id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package types
+
+// Admin API Types
+
+// TransactionResponse describes a session's open transaction.
+type TransactionResponse struct {
+	ID        int64          `json:"id"`
+	SessionID string         `json:"sessionId"`
+	User      string         `json:"user,omitempty"`
+	StartedOn string         `json:"startedOn"`
+	Locks     []LockResponse `json:"locks"`
+}
+
+// LockResponse describes a table held by an open transaction.
+type LockResponse struct {
+	Resource   string `json:"resource"`
+	AcquiredOn string `json:"acquiredOn"`
+	QueryID    string `json:"queryId,omitempty"`
+}
+
+// ListTransactionsResponse lists the open transactions of all sessions.
+type ListTransactionsResponse struct {
+	Transactions []TransactionResponse `json:"transactions"`
+}
+
+// ExportDatabaseRequest asks for a database to be exported to a directory of
+// the emulator's filesystem.
+type ExportDatabaseRequest struct {
+	// Directory is the export's directory, relative to the server's EXPORT_DIR.
+	Directory string `json:"directory"`
+	// Format is the format of the table data files: CSV (gzipped) or PARQUET.
+	Format string `json:"format,omitempty"`
+	// Transforms maps tables, named TABLE or SCHEMA.TABLE, to the transforms
+	// anonymizing their columns' data: HASH, MASK, or NULL.
+	Transforms map[string]map[string]string `json:"transforms,omitempty"`
+	// HashSalt salts the hashed values; each export uses a random salt by default.
+	HashSalt string `json:"hash_salt,omitempty"`
+}
+
+// ExportDatabaseResponse describes an exported database.
+type ExportDatabaseResponse struct {
+	Database  string                  `json:"database"`
+	Directory string                  `json:"directory"`
+	Format    string                  `json:"format"`
+	Tables    []ExportedTableResponse `json:"tables"`
+	Skipped   []string                `json:"skipped"`
+}
+
+// ExportedTableResponse describes an exported table and its data file.
+type ExportedTableResponse struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+}
+
+// CapabilitiesResponse lists the Snowflake functions the emulator translates
+// and those it knows of but does not support.
+type CapabilitiesResponse struct {
+	TranslatedFunctions  []string                      `json:"translatedFunctions"`
+	UnsupportedFunctions []UnsupportedFunctionResponse `json:"unsupportedFunctions"`
+}
+
+// UnsupportedFunctionResponse describes a known but unsupported function.
+type UnsupportedFunctionResponse struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+	Issue    string `json:"issue,omitempty"`
+}
+
+// EvaluateDataMetricsRequest asks for the data metric functions added to a
+// table, or to all tables when Table is empty, to be evaluated.
+type EvaluateDataMetricsRequest struct {
+	Table string `json:"table,omitempty"`
+}
+
+// EvaluateDataMetricsResponse lists the recorded data metric measurements.
+type EvaluateDataMetricsResponse struct {
+	Results []DataMetricResultResponse `json:"results"`
+}
+
+// DataMetricResultResponse describes a measurement of a data metric function.
+type DataMetricResultResponse struct {
+	MeasurementTime string   `json:"measurementTime"`
+	TableDatabase   string   `json:"tableDatabase,omitempty"`
+	TableSchema     string   `json:"tableSchema,omitempty"`
+	TableName       string   `json:"tableName"`
+	MetricDatabase  string   `json:"metricDatabase,omitempty"`
+	MetricSchema    string   `json:"metricSchema,omitempty"`
+	MetricName      string   `json:"metricName"`
+	ArgumentNames   []string `json:"argumentNames"`
+	Value           *float64 `json:"value"`
+}
+
+// SentEmailsResponse lists the emails sent with SYSTEM$SEND_EMAIL.
+type SentEmailsResponse struct {
+	Emails []SentEmailResponse `json:"emails"`
+}
+
+// SentEmailResponse describes an email sent with SYSTEM$SEND_EMAIL.
+type SentEmailResponse struct {
+	SentAt      string   `json:"sentAt"`
+	Integration string   `json:"integration"`
+	Recipients  []string `json:"recipients"`
+	Subject     string   `json:"subject"`
+	Body        string   `json:"body"`
+	ContentType string   `json:"contentType"`
+}
+
+// MetadataCacheResponse reports the use of the cache of databases and schemas
+// looked up by name.
+type MetadataCacheResponse struct {
+	Enabled       bool    `json:"enabled"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRate       float64 `json:"hitRate"`
+	Invalidations int64   `json:"invalidations"`
+	Entries       int     `json:"entries"`
+}
+
+// AdminErrorResponse is the body of a failed admin request.
+type AdminErrorResponse struct {
+	Message string `json:"message"`
+}
+
+// DiffRequest asks for the result of a statement to be compared with the
+// result of another statement or with expected rows.
+type DiffRequest struct {
+	Left string `json:"left"`
+	// Right is the statement to compare with; Expected gives the rows inline
+	// instead.
+	Right    string        `json:"right,omitempty"`
+	Expected *ExpectedRows `json:"expected,omitempty"`
+	// Key names the columns matching rows, which are then reported as changed
+	// cell by cell. Ordered compares rows by position. Rows are otherwise
+	// compared ignoring their order.
+	Key      []string `json:"key,omitempty"`
+	Ordered  bool     `json:"ordered,omitempty"`
+	Database string   `json:"database,omitempty"`
+	Schema   string   `json:"schema,omitempty"`
+}
+
+// ExpectedRows are rows given inline for a diff. Columns default to those of
+// the left statement's result.
+type ExpectedRows struct {
+	Columns []string        `json:"columns,omitempty"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// DiffResponse describes the difference between two results.
+type DiffResponse struct {
+	Equal          bool                 `json:"equal"`
+	LeftColumns    []string             `json:"leftColumns"`
+	RightColumns   []string             `json:"rightColumns"`
+	MissingColumns []string             `json:"missingColumns"`
+	ExtraColumns   []string             `json:"extraColumns"`
+	LeftRowCount   int                  `json:"leftRowCount"`
+	RightRowCount  int                  `json:"rightRowCount"`
+	Removed        []DiffRowResponse    `json:"removed"`
+	Added          []DiffRowResponse    `json:"added"`
+	Changed        []ChangedRowResponse `json:"changed"`
+}
+
+// DiffRowResponse is a row only one of the results has.
+type DiffRowResponse struct {
+	Index  int           `json:"index"`
+	Values []interface{} `json:"values"`
+}
+
+// ChangedRowResponse is a pair of matched rows whose cells differ.
+type ChangedRowResponse struct {
+	LeftIndex  int                `json:"leftIndex"`
+	RightIndex int                `json:"rightIndex"`
+	Key        []interface{}      `json:"key,omitempty"`
+	Cells      []CellDiffResponse `json:"cells"`
+}
+
+// CellDiffResponse is a cell that differs between matched rows.
+type CellDiffResponse struct {
+	Column string      `json:"column"`
+	Left   interface{} `json:"left"`
+	Right  interface{} `json:"right"`
+}
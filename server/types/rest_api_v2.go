@@ -14,6 +14,7 @@ type SubmitStatementRequest struct {
 	Role       string                   `json:"role,omitempty"`       // Role context
 	Bindings   map[string]*BindingValue `json:"bindings,omitempty"`   // Parameter bindings
 	Parameters map[string]string        `json:"parameters,omitempty"` // Session parameters
+	Labels     map[string]string        `json:"labels,omitempty"`     // Free-form labels echoed with the statement
 }
 
 // BindingValue represents a parameter binding value.
@@ -33,6 +34,9 @@ type StatementResponse struct {
 	StatementHandle    string             `json:"statementHandle"`
 	Message            string             `json:"message,omitempty"`
 	CreatedOn          int64              `json:"createdOn,omitempty"`
+	Warnings           []string           `json:"warnings,omitempty"`
+	QueryTag           string             `json:"queryTag,omitempty"`
+	Labels             map[string]string  `json:"labels,omitempty"`
 }
 
 // ResultSetMetaData contains metadata about the result set.
@@ -152,6 +156,56 @@ type TableResponse struct {
 // ListTablesResponse represents a list of tables.
 type ListTablesResponse []TableResponse
 
+// LoadRowsResponse represents the result of loading rows into a table.
+type LoadRowsResponse struct {
+	Database   string `json:"database_name"`
+	Schema     string `json:"schema_name"`
+	Table      string `json:"name"`
+	RowsLoaded int64  `json:"rows_loaded"`
+}
+
+// StageFileResponse represents a file of an internal stage, as LIST reports it.
+type StageFileResponse struct {
+	Name         string `json:"name"`
+	Size         int64  `json:"size"`
+	MD5          string `json:"md5"`
+	LastModified string `json:"last_modified"`
+}
+
+// TablePreviewResponse represents the first rows of a table with its column
+// metadata.
+type TablePreviewResponse struct {
+	Database string          `json:"database_name"`
+	Schema   string          `json:"schema_name"`
+	Table    string          `json:"name"`
+	NumRows  int64           `json:"numRows"`
+	RowType  []RowTypeField  `json:"rowType"`
+	Data     [][]interface{} `json:"data"`
+}
+
+// CatalogResponse represents the whole catalog tree returned by GET /api/v2/catalog.
+type CatalogResponse struct {
+	Databases []CatalogDatabaseResponse `json:"databases"`
+}
+
+// CatalogDatabaseResponse represents a database with its schemas.
+type CatalogDatabaseResponse struct {
+	DatabaseResponse
+	Schemas []CatalogSchemaResponse `json:"schemas"`
+}
+
+// CatalogSchemaResponse represents a schema with its tables.
+type CatalogSchemaResponse struct {
+	SchemaResponse
+	Tables []CatalogTableResponse `json:"tables"`
+}
+
+// CatalogTableResponse represents a table with its columns.
+type CatalogTableResponse struct {
+	TableResponse
+	Columns []ColumnDef `json:"columns"`
+}
+
 // WarehouseRequest represents a request to create/alter a warehouse.
 type WarehouseRequest struct {
 	Name        string `json:"name"`
@@ -177,6 +231,49 @@ type WarehouseResponse struct {
 // ListWarehousesResponse represents a list of warehouses.
 type ListWarehousesResponse []WarehouseResponse
 
+// QueryHistoryResponse represents a recorded query execution.
+type QueryHistoryResponse struct {
+	QueryID          string `json:"query_id"`
+	SessionID        string `json:"session_id,omitempty"`
+	QueryText        string `json:"query_text"`
+	ExecutionStatus  string `json:"execution_status"` // RUNNING, SUCCESS, FAILED
+	StartTime        string `json:"start_time"`
+	EndTime          string `json:"end_time,omitempty"`
+	TotalElapsedTime int64  `json:"total_elapsed_time"` // Milliseconds
+	RowsProduced     int64  `json:"rows_produced"`
+	ErrorMessage     string `json:"error_message,omitempty"`
+
+	QueryTag string            `json:"query_tag,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// ListQueriesResponse represents a list of recorded query executions.
+type ListQueriesResponse []QueryHistoryResponse
+
+// SessionResponse represents the context of a session, for debugging the
+// state tests leave behind.
+type SessionResponse struct {
+	SessionID      int64             `json:"session_id"`
+	User           string            `json:"user"`
+	Role           string            `json:"role"`
+	Database       string            `json:"database,omitempty"`
+	Schema         string            `json:"schema,omitempty"`
+	Warehouse      string            `json:"warehouse,omitempty"`
+	Parameters     map[string]string `json:"parameters"`
+	Variables      map[string]any    `json:"variables"`
+	CreatedOn      string            `json:"created_on"`
+	LastAccessedOn string            `json:"last_accessed_on"`
+
+	// ClientAppID, ClientAppVersion, and ClientEnvironment describe the
+	// driver that logged in, as it reported them.
+	ClientAppID       string         `json:"client_app_id,omitempty"`
+	ClientAppVersion  string         `json:"client_app_version,omitempty"`
+	ClientEnvironment map[string]any `json:"client_environment,omitempty"`
+}
+
+// ListSessionsResponse represents a list of active sessions.
+type ListSessionsResponse []SessionResponse
+
 // Common response wrapper for REST API v2
 type RESTAPIV2Response struct {
 	Code    string      `json:"code"`
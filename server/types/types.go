@@ -112,10 +112,25 @@ type QuerySuccessData struct {
 	SQLState          string           `json:"sqlState,omitempty"`
 	StatementTypeID   int64            `json:"statementTypeId"`
 	RowType           []ColumnMetadata `json:"rowtype,omitempty"`
-	RowSet            [][]string       `json:"rowset,omitempty"`
+	RowSet            [][]*string      `json:"rowset,omitempty"`
 	Total             int64            `json:"total"`
 	Returned          int64            `json:"returned"`
 	QueryResultFormat string           `json:"queryResultFormat"`
+	// Chunks lists the chunks of a large result after the first, which is
+	// the RowSet, for the driver to download.
+	Chunks   []ResultChunk `json:"chunks,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+	// Parameters are the session's parameters after a statement, which
+	// drivers keep, so that they see what ALTER SESSION changed.
+	Parameters []ParameterBinding `json:"parameters,omitempty"`
+}
+
+// ResultChunk describes a chunk of a large result and where to download it.
+type ResultChunk struct {
+	URL              string `json:"url"`
+	RowCount         int    `json:"rowCount"`
+	UncompressedSize int64  `json:"uncompressedSize"`
+	CompressedSize   int64  `json:"compressedSize"`
 }
 
 // ColumnMetadata describes a result column's type information.
@@ -128,9 +143,11 @@ type ColumnMetadata struct {
 	Nullable  bool   `json:"nullable"`
 }
 
-// AbortRequest for query cancellation
+// AbortRequest for query cancellation. gosnowflake names the statement to
+// cancel by the requestId of its query request.
 type AbortRequest struct {
-	QueryID string `json:"queryId"`
+	QueryID   string `json:"queryId"`
+	RequestID string `json:"requestId"`
 }
 
 // AbortResponse is the response to a query abort request.
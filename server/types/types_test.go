@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -99,9 +100,9 @@ func TestQueryResponseJSON(t *testing.T) {
 				{Name: "ID", Type: "NUMBER", Nullable: false},
 				{Name: "NAME", Type: "TEXT", Nullable: true},
 			},
-			RowSet: [][]string{
-				{"1", "Alice"},
-				{"2", "Bob"},
+			RowSet: [][]*string{
+				{stringPtr("1"), stringPtr("Alice")},
+				{stringPtr("2"), nil},
 			},
 			Total:             2,
 			Returned:          2,
@@ -125,4 +126,14 @@ func TestQueryResponseJSON(t *testing.T) {
 	if decoded.Data.Total != 2 {
 		t.Errorf("Expected Total=2, got %d", decoded.Data.Total)
 	}
+	if !strings.Contains(string(data), `["2",null]`) {
+		t.Errorf("Expected NULL to be encoded as JSON null, got %s", data)
+	}
+	if decoded.Data.RowSet[1][1] != nil {
+		t.Errorf("Expected NULL to decode as nil, got %q", *decoded.Data.RowSet[1][1])
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
 }
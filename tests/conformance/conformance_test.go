@@ -0,0 +1,294 @@
+// tests/conformance/conformance_test.go - emulator vs. Snowflake conformance suite
+//
+// These tests run the SQL corpus against the emulator through the gosnowflake
+// driver and, when SNOWFLAKE_CONFORMANCE_DSN holds a gosnowflake DSN for a real
+// account, against Snowflake as well, diffing the column types and values of
+// each result. Set CONFORMANCE_REPORT to a file path to write a Markdown
+// compatibility report.
+package conformance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
+	"github.com/nnnkkk7/snowflake-emulator/server/handlers"
+	_ "github.com/snowflakedb/gosnowflake"
+)
+
+// Case statuses in the compatibility report.
+const (
+	statusMatch          = "match"
+	statusMismatch       = "mismatch"
+	statusEmulatorError  = "emulator error"
+	statusSnowflakeError = "snowflake error"
+	statusEmulatorOnly   = "emulator only"
+)
+
+// column is a result column as the driver reports it.
+type column struct {
+	Name string
+	Type string
+}
+
+// outcome is the result of running a statement, with values rendered as the
+// driver converts them to strings and NULL as "NULL".
+type outcome struct {
+	Columns []column
+	Rows    [][]string
+	Err     error `cmp:"-"`
+}
+
+// caseResult is the report entry of one corpus case.
+type caseResult struct {
+	Case   corpusCase
+	Status string
+	Detail string
+}
+
+// setupEmulator starts an in-process emulator and returns a connection to it
+// through the gosnowflake driver.
+func setupEmulator(t *testing.T) *sql.DB {
+	t.Helper()
+
+	duck, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = duck.Close() })
+
+	connMgr := connection.NewManager(duck)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(connMgr, repo)
+	executor.Configure(query.WithMergeProcessor(query.NewMergeProcessor(executor)))
+
+	sessionMgr := session.NewManager(1 * time.Hour)
+	sessionHandler := handlers.NewSessionHandler(sessionMgr, repo)
+	queryHandler := handlers.NewQueryHandler(executor, sessionMgr)
+
+	r := chi.NewRouter()
+	r.Post("/session/v1/login-request", sessionHandler.Login)
+	r.Post("/session/heartbeat", sessionHandler.Heartbeat)
+	r.Post("/session", sessionHandler.CloseSession)
+	r.Post("/queries/v1/query-request", queryHandler.ExecuteQuery)
+	r.Post("/telemetry/send", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	dsn := fmt.Sprintf("conformance:conformance@%s/CONFORMANCE_DB/PUBLIC?account=conformance&protocol=http&loginTimeout=5",
+		strings.TrimPrefix(server.URL, "http://"))
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("failed to open emulator connection: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// runStatement runs a statement and collects its columns and rows.
+func runStatement(ctx context.Context, db *sql.DB, statement string) outcome {
+	rows, err := db.QueryContext(ctx, statement)
+	if err != nil {
+		return outcome{Err: err}
+	}
+	defer func() { _ = rows.Close() }()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return outcome{Err: err}
+	}
+	var out outcome
+	for _, ct := range columnTypes {
+		typeName := ct.DatabaseTypeName()
+		if precision, scale, ok := ct.DecimalSize(); ok {
+			typeName = fmt.Sprintf("%s(%d,%d)", typeName, precision, scale)
+		}
+		out.Columns = append(out.Columns, column{Name: ct.Name(), Type: typeName})
+	}
+
+	for rows.Next() {
+		values := make([]sql.NullString, len(columnTypes))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return outcome{Err: err}
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = "NULL"
+			if v.Valid {
+				row[i] = v.String
+			}
+		}
+		out.Rows = append(out.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return outcome{Err: err}
+	}
+	return out
+}
+
+// compare classifies the emulator's outcome of a case against Snowflake's.
+// A nil snowflake outcome means no Snowflake account is configured.
+func compare(c corpusCase, emulator outcome, snowflake *outcome) caseResult {
+	result := caseResult{Case: c}
+	switch {
+	case emulator.Err != nil:
+		result.Status, result.Detail = statusEmulatorError, emulator.Err.Error()
+	case snowflake == nil:
+		result.Status = statusEmulatorOnly
+	case snowflake.Err != nil:
+		result.Status, result.Detail = statusSnowflakeError, snowflake.Err.Error()
+	default:
+		if diff := cmp.Diff(*snowflake, emulator); diff != "" {
+			result.Status, result.Detail = statusMismatch, "(-snowflake +emulator)\n"+diff
+		} else {
+			result.Status = statusMatch
+		}
+	}
+	return result
+}
+
+// writeReport writes the results as a Markdown compatibility report.
+func writeReport(path string, results []caseResult) error {
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	var b strings.Builder
+	b.WriteString("# Snowflake Emulator Compatibility Report\n\n")
+	for _, status := range []string{statusMatch, statusMismatch, statusEmulatorError, statusSnowflakeError, statusEmulatorOnly} {
+		if counts[status] > 0 {
+			fmt.Fprintf(&b, "- %s: %d\n", status, counts[status])
+		}
+	}
+	b.WriteString("\n| Case | SQL | Status |\n|------|-----|--------|\n")
+	for _, r := range results {
+		fmt.Fprintf(&b, "| %s | `%s` | %s |\n", r.Case.Name, strings.ReplaceAll(r.Case.SQL, "|", "\\|"), r.Status)
+	}
+	for _, r := range results {
+		if r.Detail != "" {
+			fmt.Fprintf(&b, "\n## %s\n\n```\n%s\n```\n", r.Case.Name, r.Detail)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+// TestConformance runs the corpus. Without a Snowflake account, every statement
+// must run on the emulator; with one, results must match Snowflake's.
+func TestConformance(t *testing.T) {
+	emulator := setupEmulator(t)
+
+	var snowflake *sql.DB
+	if dsn := os.Getenv("SNOWFLAKE_CONFORMANCE_DSN"); dsn != "" {
+		db, err := sql.Open("snowflake", dsn)
+		if err != nil {
+			t.Fatalf("failed to open Snowflake connection: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+		snowflake = db
+	} else {
+		t.Log("SNOWFLAKE_CONFORMANCE_DSN not set; running the corpus against the emulator only")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	results := make([]caseResult, 0, len(corpus))
+	for _, c := range corpus {
+		t.Run(c.Name, func(t *testing.T) {
+			var want *outcome
+			if snowflake != nil {
+				out := runStatement(ctx, snowflake, c.SQL)
+				want = &out
+			}
+			result := compare(c, runStatement(ctx, emulator, c.SQL), want)
+			results = append(results, result)
+
+			switch result.Status {
+			case statusEmulatorError, statusMismatch:
+				t.Errorf("%s: %s\n%s", c.SQL, result.Status, result.Detail)
+			case statusSnowflakeError:
+				t.Logf("%s: %s", c.SQL, result.Detail)
+			}
+		})
+	}
+
+	if path := os.Getenv("CONFORMANCE_REPORT"); path != "" {
+		if err := writeReport(path, results); err != nil {
+			t.Fatalf("failed to write report: %v", err)
+		}
+		t.Logf("compatibility report written to %s", path)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	c := corpusCase{Name: "case", SQL: "SELECT 1"}
+	one := outcome{Columns: []column{{Name: "N", Type: "FIXED(1,0)"}}, Rows: [][]string{{"1"}}}
+	two := outcome{Columns: []column{{Name: "N", Type: "FIXED(1,0)"}}, Rows: [][]string{{"2"}}}
+	failed := outcome{Err: fmt.Errorf("boom")}
+
+	tests := []struct {
+		name      string
+		emulator  outcome
+		snowflake *outcome
+		want      string
+	}{
+		{name: "match", emulator: one, snowflake: &one, want: statusMatch},
+		{name: "mismatch", emulator: one, snowflake: &two, want: statusMismatch},
+		{name: "emulator error", emulator: failed, snowflake: &one, want: statusEmulatorError},
+		{name: "snowflake error", emulator: one, snowflake: &failed, want: statusSnowflakeError},
+		{name: "emulator only", emulator: one, snowflake: nil, want: statusEmulatorOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compare(c, tt.emulator, tt.snowflake).Status; got != tt.want {
+				t.Errorf("compare() status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	path := t.TempDir() + "/report.md"
+	results := []caseResult{
+		{Case: corpusCase{Name: "ok", SQL: "SELECT 1"}, Status: statusMatch},
+		{Case: corpusCase{Name: "bad", SQL: "SELECT 'a|b'"}, Status: statusMismatch, Detail: "diff"},
+	}
+	if err := writeReport(path, results); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, want := range []string{"- match: 1", "- mismatch: 1", "| bad | `SELECT 'a\\|b'` | mismatch |", "## bad"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report missing %q:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package conformance
+
+// corpusCase is one statement of the conformance corpus. Statements only read
+// constants so that they run unchanged against any Snowflake account.
+type corpusCase struct {
+	Name string
+	SQL  string
+}
+
+// corpus is the SQL run against the emulator and, when configured, a real
+// Snowflake account. Every statement is expected to produce the same column
+// types and values in both.
+var corpus = []corpusCase{
+	// Literals and types
+	{Name: "integer literal", SQL: "SELECT 1 AS n"},
+	{Name: "decimal literal", SQL: "SELECT 1.50 AS n"},
+	{Name: "number cast", SQL: "SELECT CAST(12.345 AS NUMBER(10,2)) AS n"},
+	{Name: "float cast", SQL: "SELECT CAST(1.5 AS FLOAT) AS f"},
+	{Name: "string literal", SQL: "SELECT 'hello' AS s"},
+	{Name: "boolean literal", SQL: "SELECT TRUE AS b"},
+	{Name: "null literal", SQL: "SELECT NULL AS n"},
+	{Name: "date cast", SQL: "SELECT '2024-01-15'::DATE AS d"},
+	{Name: "timestamp cast", SQL: "SELECT '2024-01-15 10:30:00'::TIMESTAMP_NTZ AS ts"},
+
+	// Arithmetic
+	{Name: "integer division", SQL: "SELECT 7 / 2 AS q"},
+	{Name: "round half away from zero", SQL: "SELECT ROUND(2.5) AS r, ROUND(-2.5) AS n"},
+	{Name: "modulo", SQL: "SELECT MOD(10, 3) AS m"},
+
+	// Conditional functions
+	{Name: "iff", SQL: "SELECT IFF(1 > 0, 'yes', 'no') AS r"},
+	{Name: "nvl", SQL: "SELECT NVL(NULL, 'default') AS r"},
+	{Name: "nvl2", SQL: "SELECT NVL2('x', 'set', 'unset') AS r"},
+	{Name: "decode", SQL: "SELECT DECODE(2, 1, 'one', 2, 'two', 'other') AS r"},
+
+	// Semi-structured type checks
+	{Name: "typeof", SQL: "SELECT TYPEOF(PARSE_JSON('1.5')) AS d, TYPEOF(PARSE_JSON('null')) AS n"},
+	{Name: "is type", SQL: "SELECT IS_INTEGER(PARSE_JSON('7')) AS i, IS_VARCHAR(PARSE_JSON('7')) AS v"},
+	{Name: "variant path", SQL: `SELECT PARSE_JSON('{"a": {"b": [10, "x"]}}'):a.b[1]::STRING AS s, PARSE_JSON('{"a": {"b": [10, "x"]}}'):a.b[0]::NUMBER AS n`},
+
+	// String functions
+	{Name: "concat", SQL: "SELECT CONCAT('a', 'b', 'c') AS r"},
+	{Name: "upper lower", SQL: "SELECT UPPER('abc') AS u, LOWER('ABC') AS l"},
+	{Name: "substr", SQL: "SELECT SUBSTR('snowflake', 1, 4) AS r"},
+	{Name: "split part", SQL: "SELECT SPLIT_PART('a,b,c', ',', 2) AS r"},
+	{Name: "contains", SQL: "SELECT CONTAINS('snowflake', 'flake') AS r"},
+	{Name: "startswith", SQL: "SELECT STARTSWITH('snowflake', 'snow') AS r"},
+
+	// Date functions
+	{Name: "dateadd", SQL: "SELECT DATEADD(day, 1, CAST('2024-01-31' AS DATE)) AS d"},
+	{Name: "datediff", SQL: "SELECT DATEDIFF(day, CAST('2024-01-01' AS DATE), CAST('2024-03-01' AS DATE)) AS n"},
+	{Name: "date trunc", SQL: "SELECT DATE_TRUNC('month', '2024-01-15'::DATE) AS d"},
+
+	// Aggregates and ordering
+	{Name: "aggregate over values", SQL: "SELECT COUNT(*) AS c, SUM(v) AS s FROM (VALUES (1), (2), (3)) AS t(v)"},
+	{Name: "null ordering asc", SQL: "SELECT v FROM (VALUES (1), (NULL), (2)) AS t(v) ORDER BY v"},
+	{Name: "null ordering desc", SQL: "SELECT v FROM (VALUES (1), (NULL), (2)) AS t(v) ORDER BY v DESC"},
+
+	// Common table expressions and comments
+	{Name: "cte", SQL: "WITH x AS (SELECT 1 AS a) SELECT a FROM x"},
+	{Name: "leading comment", SQL: "/* conformance */ SELECT 1 AS n"},
+}
@@ -10,6 +10,7 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -23,7 +24,7 @@ import (
 	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
 	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
 	"github.com/nnnkkk7/snowflake-emulator/server/handlers"
-	_ "github.com/snowflakedb/gosnowflake"
+	"github.com/snowflakedb/gosnowflake"
 )
 
 // capturedLoginRequest stores the last login request body for debugging
@@ -33,7 +34,7 @@ var capturedLoginRequest []byte
 var capturedQueryRequest []byte
 
 // setupTestEmulator creates an in-process emulator server for testing.
-func setupTestEmulator(t *testing.T) *httptest.Server {
+func setupTestEmulator(t *testing.T, opts ...handlers.QueryHandlerOption) *httptest.Server {
 	t.Helper()
 
 	// Reset captured requests for each test
@@ -62,10 +63,10 @@ func setupTestEmulator(t *testing.T) *httptest.Server {
 
 	// Initialize MERGE processor for MERGE INTO support
 	mergeProcessor := query.NewMergeProcessor(executor)
-	executor.Configure(query.WithMergeProcessor(mergeProcessor))
+	executor.Configure(query.WithMergeProcessor(mergeProcessor), query.WithSessionUpdater(sessionMgr))
 
 	sessionHandler := handlers.NewSessionHandler(sessionMgr, repo)
-	queryHandler := handlers.NewQueryHandler(executor, sessionMgr)
+	queryHandler := handlers.NewQueryHandler(executor, sessionMgr, opts...)
 
 	r := chi.NewRouter()
 
@@ -100,6 +101,8 @@ func setupTestEmulator(t *testing.T) *httptest.Server {
 		req.Body = io.NopCloser(bytes.NewReader(body))
 		queryHandler.ExecuteQuery(w, req)
 	})
+	r.Post("/queries/v1/abort-request", queryHandler.AbortQuery)
+	r.Get("/queries/v1/chunks/{queryId}/{index}", queryHandler.DownloadChunk)
 
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -422,6 +425,322 @@ func TestHTTPAPI_SessionClose(t *testing.T) {
 	t.Log("POST /session?delete=true: OK")
 }
 
+// TestGosnowflake_NullValues tests that the driver reads NULLs as NULL and
+// empty strings as empty strings.
+func TestGosnowflake_NullValues(t *testing.T) {
+	server := setupTestEmulator(t)
+	hostPort := server.URL[7:] // Remove "http://"
+
+	dsn := fmt.Sprintf("testuser:testpass@%s/TEST_DB/PUBLIC?account=testaccount&protocol=http&loginTimeout=5", hostPort)
+
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var (
+		nullText, emptyText sql.NullString
+		nullNumber          sql.NullInt64
+		nullTimestamp       sql.NullTime
+	)
+	err = db.QueryRowContext(ctx, "SELECT NULL::VARCHAR, '', NULL::INTEGER, NULL::TIMESTAMP_NTZ").
+		Scan(&nullText, &emptyText, &nullNumber, &nullTimestamp)
+	if err != nil {
+		logCapturedRequests(t)
+		t.Fatalf("SELECT with NULLs failed: %v", err)
+	}
+
+	if nullText.Valid {
+		t.Errorf("Expected NULL VARCHAR, got %q", nullText.String)
+	}
+	if !emptyText.Valid || emptyText.String != "" {
+		t.Errorf("Expected empty string, got %+v", emptyText)
+	}
+	if nullNumber.Valid {
+		t.Errorf("Expected NULL INTEGER, got %d", nullNumber.Int64)
+	}
+	if nullTimestamp.Valid {
+		t.Errorf("Expected NULL TIMESTAMP_NTZ, got %v", nullTimestamp.Time)
+	}
+}
+
+// TestGosnowflake_UseSchema tests that USE SCHEMA changes the schema that
+// later statements of the session resolve unqualified names against.
+func TestGosnowflake_UseSchema(t *testing.T) {
+	db, ctx := openMigrationDB(t)
+
+	// A connection is one session, so USE applies to its later statements
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	for _, stmt := range []string{
+		"CREATE DATABASE IF NOT EXISTS TEST_DB",
+		"CREATE SCHEMA TEST_DB.STAGING",
+		"USE SCHEMA STAGING",
+		"CREATE TABLE EVENTS (ID INTEGER)",
+		"INSERT INTO EVENTS VALUES (1), (2)",
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			logCapturedRequests(t)
+			t.Fatalf("%s failed: %v", stmt, err)
+		}
+	}
+
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM TEST_DB.STAGING.EVENTS").Scan(&count); err != nil {
+		t.Fatalf("SELECT from the qualified table failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 rows in TEST_DB.STAGING.EVENTS, got %d", count)
+	}
+
+	var database, schema string
+	if err := conn.QueryRowContext(ctx, "SELECT CURRENT_DATABASE(), CURRENT_SCHEMA()").Scan(&database, &schema); err != nil {
+		t.Fatalf("SELECT CURRENT_DATABASE(), CURRENT_SCHEMA() failed: %v", err)
+	}
+	if database != "TEST_DB" || schema != "STAGING" {
+		t.Errorf("Expected the current schema TEST_DB.STAGING, got %s.%s", database, schema)
+	}
+
+	if _, err := conn.ExecContext(ctx, "USE SCHEMA MISSING"); err == nil {
+		t.Error("Expected USE SCHEMA of a missing schema to fail")
+	}
+}
+
+// TestGosnowflake_AlterSession tests that ALTER SESSION sets parameters that
+// SHOW PARAMETERS lists and query history records, and UNSET resets them.
+func TestGosnowflake_AlterSession(t *testing.T) {
+	db, ctx := openMigrationDB(t)
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	parameter := func(name string) (value, level string) {
+		t.Helper()
+		var key, def, description, typ string
+		row := conn.QueryRowContext(ctx, fmt.Sprintf("SHOW PARAMETERS LIKE '%s' IN SESSION", name))
+		if err := row.Scan(&key, &value, &def, &level, &description, &typ); err != nil {
+			t.Fatalf("SHOW PARAMETERS LIKE '%s' failed: %v", name, err)
+		}
+		return value, level
+	}
+
+	for _, stmt := range []string{
+		"ALTER SESSION SET QUERY_TAG = 'nightly_load' TIMEZONE = 'America/Los_Angeles'",
+		"CREATE TABLE TAGGED (ID INTEGER)",
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			logCapturedRequests(t)
+			t.Fatalf("%s failed: %v", stmt, err)
+		}
+	}
+	if value, level := parameter("TIMEZONE"); value != "America/Los_Angeles" || level != "SESSION" {
+		t.Errorf("Expected TIMEZONE America/Los_Angeles at SESSION level, got %q at %q", value, level)
+	}
+
+	var tag string
+	err = conn.QueryRowContext(ctx,
+		"SELECT QUERY_TAG FROM SNOWFLAKE.ACCOUNT_USAGE.QUERY_HISTORY WHERE QUERY_TEXT = 'CREATE TABLE TAGGED (ID INTEGER)'").Scan(&tag)
+	if err != nil {
+		t.Fatalf("SELECT from QUERY_HISTORY failed: %v", err)
+	}
+	if tag != "nightly_load" {
+		t.Errorf("Expected the query tag nightly_load in query history, got %q", tag)
+	}
+
+	if _, err := conn.ExecContext(ctx, "ALTER SESSION UNSET QUERY_TAG"); err != nil {
+		t.Fatalf("ALTER SESSION UNSET failed: %v", err)
+	}
+	if value, level := parameter("QUERY_TAG"); value != "" || level != "" {
+		t.Errorf("Expected QUERY_TAG reset to its default, got %q at %q", value, level)
+	}
+
+	if _, err := conn.ExecContext(ctx, "ALTER SESSION SET WEEK_START = 'monday'"); err == nil {
+		t.Error("Expected an invalid WEEK_START to fail")
+	}
+}
+
+// TestGosnowflake_ExactDecimals tests that large decimals round-trip exactly
+// through the driver's higher precision mode.
+func TestGosnowflake_ExactDecimals(t *testing.T) {
+	server := setupTestEmulator(t)
+	hostPort := server.URL[7:] // Remove "http://"
+
+	dsn := fmt.Sprintf("testuser:testpass@%s/TEST_DB/PUBLIC?account=testaccount&protocol=http&loginTimeout=5", hostPort)
+
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = gosnowflake.WithHigherPrecision(ctx)
+
+	var decimal, integer any
+	err = db.QueryRowContext(ctx, "SELECT 12345678901234567890123456789.123::NUMBER(38,3), 98765432109876543210::NUMBER(38,0)").
+		Scan(&decimal, &integer)
+	if err != nil {
+		logCapturedRequests(t)
+		t.Fatalf("SELECT with decimals failed: %v", err)
+	}
+
+	f, ok := decimal.(big.Float)
+	if !ok {
+		t.Fatalf("Expected big.Float, got %T", decimal)
+	}
+	if got := f.Text('f', 3); got != "12345678901234567890123456789.123" {
+		t.Errorf("Expected exact decimal, got %s", got)
+	}
+	i, ok := integer.(big.Int)
+	if !ok {
+		t.Fatalf("Expected big.Int, got %T", integer)
+	}
+	if got := i.String(); got != "98765432109876543210" {
+		t.Errorf("Expected exact integer, got %s", got)
+	}
+}
+
+// TestGosnowflake_ArrayBindings tests bulk inserts of array bindings, which
+// insert a row per element.
+func TestGosnowflake_ArrayBindings(t *testing.T) {
+	server := setupTestEmulator(t)
+	hostPort := server.URL[7:] // Remove "http://"
+
+	dsn := fmt.Sprintf("testuser:testpass@%s/TEST_DB/PUBLIC?account=testaccount&protocol=http&loginTimeout=5", hostPort)
+
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, "CREATE TABLE bulk_rows (id INTEGER, name VARCHAR)"); err != nil {
+		t.Fatalf("CREATE TABLE failed: %v", err)
+	}
+	ids := []int{1, 2, 3}
+	names := []string{"a", "b", "c"}
+	if _, err := db.ExecContext(ctx, "INSERT INTO bulk_rows (id, name) VALUES (?, ?)", gosnowflake.Array(&ids), gosnowflake.Array(&names)); err != nil {
+		logCapturedRequests(t)
+		t.Fatalf("INSERT with array bindings failed: %v", err)
+	}
+
+	var got string
+	if err := db.QueryRowContext(ctx, "SELECT LISTAGG(CONCAT(id, name), ',') WITHIN GROUP (ORDER BY id) FROM bulk_rows").Scan(&got); err != nil {
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	if got != "1a,2b,3c" {
+		t.Errorf("Expected 1a,2b,3c, got %s", got)
+	}
+}
+
+// TestGosnowflake_ContextCancellation tests that a statement whose context the
+// client cancels stops running in the emulator, as the driver sends an abort
+// request for it.
+func TestGosnowflake_ContextCancellation(t *testing.T) {
+	server := setupTestEmulator(t)
+	hostPort := server.URL[7:] // Remove "http://"
+
+	dsn := fmt.Sprintf("testuser:testpass@%s/TEST_DB/PUBLIC?account=testaccount&protocol=http&loginTimeout=5", hostPort)
+
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	slowCtx, cancelSlow := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancelSlow()
+	var n int64
+	if err := db.QueryRowContext(slowCtx, "SELECT COUNT(*) FROM range(100000000000) slow_query").Scan(&n); err == nil {
+		t.Fatal("Expected the canceled query to fail")
+	}
+
+	// The statement ends, failed, once the emulator receives the abort request
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var status string
+		err := db.QueryRowContext(ctx, `SELECT EXECUTION_STATUS FROM SNOWFLAKE.ACCOUNT_USAGE.QUERY_HISTORY
+			WHERE QUERY_TEXT LIKE '%slow_query' AND QUERY_TEXT NOT LIKE '%QUERY_HISTORY%'`).Scan(&status)
+		if err != nil {
+			t.Fatalf("QUERY_HISTORY failed: %v", err)
+		}
+		if status != "RUNNING" {
+			if status != "FAIL" {
+				t.Errorf("Expected the canceled query to fail, got status %s", status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Canceled query kept running")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// TestGosnowflake_ChunkedResults tests that the driver downloads the chunks of
+// results larger than a chunk.
+func TestGosnowflake_ChunkedResults(t *testing.T) {
+	chunks := query.NewChunkStore(time.Hour, query.WithChunkLimits(1000, 0))
+	server := setupTestEmulator(t, handlers.WithResultChunks(chunks))
+	hostPort := server.URL[7:] // Remove "http://"
+
+	dsn := fmt.Sprintf("testuser:testpass@%s/TEST_DB/PUBLIC?account=testaccount&protocol=http&loginTimeout=5", hostPort)
+
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT range AS n, 'row ' || range::VARCHAR AS label, NULL AS missing FROM range(5500) ORDER BY n")
+	if err != nil {
+		logCapturedRequests(t)
+		t.Fatalf("SELECT failed: %v", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var n int
+		var label string
+		var missing sql.NullString
+		if err := rows.Scan(&n, &label, &missing); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if n != count || label != fmt.Sprintf("row %d", count) || missing.Valid {
+			t.Fatalf("row %d = (%d, %q, %v), want (%d, %q, NULL)", count, n, label, missing, count, fmt.Sprintf("row %d", count))
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Rows failed: %v", err)
+	}
+	if count != 5500 {
+		t.Errorf("Expected 5500 rows, got %d", count)
+	}
+}
+
 // TestGosnowflake_MergeStatement tests MERGE INTO statement via gosnowflake driver.
 // This test verifies that MERGE operations work correctly through the emulator.
 func TestGosnowflake_MergeStatement(t *testing.T) {
@@ -0,0 +1,352 @@
+// tests/e2e/migration_tools_test.go - database migration tool compatibility tests
+//
+// These tests replay the statements schemachange and Flyway issue against
+// Snowflake, using the migrations in example/migrations, so that migration
+// pipelines can be tested in CI against the emulator.
+package e2e
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	schemachangeDir   = "../../example/migrations/schemachange"
+	flywayDir         = "../../example/migrations/flyway/sql"
+	changeHistoryName = "METADATA.SCHEMACHANGE.CHANGE_HISTORY"
+)
+
+// openMigrationDB connects to a fresh emulator with gosnowflake.
+func openMigrationDB(t *testing.T) (*sql.DB, context.Context) {
+	t.Helper()
+	server := setupTestEmulator(t)
+	dsn := fmt.Sprintf("testuser:testpass@%s/TEST_DB/PUBLIC?account=testaccount&protocol=http&loginTimeout=5", server.URL[7:])
+	db, err := sql.Open("snowflake", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open connection: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	t.Cleanup(cancel)
+	if err := db.PingContext(ctx); err != nil {
+		logCapturedRequests(t)
+		t.Fatalf("Connection failed: %v", err)
+	}
+	return db, ctx
+}
+
+// migrationScript is a schemachange change script.
+type migrationScript struct {
+	Name        string
+	Type        string // V (versioned), R (repeatable), or A (always)
+	Version     string
+	Description string
+	Body        string
+}
+
+// readSchemachangeScripts reads the change scripts of dir in the order
+// schemachange applies them: versioned by version, then repeatable, then always.
+func readSchemachangeScripts(t *testing.T, dir string) []migrationScript {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+
+	var scripts []migrationScript
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		prefix, description, _ := strings.Cut(strings.TrimSuffix(name, ".sql"), "__")
+		script := migrationScript{Name: name, Type: prefix[:1], Description: strings.ReplaceAll(description, "_", " "), Body: string(body)}
+		if script.Type == "V" {
+			script.Version = prefix[1:]
+		}
+		scripts = append(scripts, script)
+	}
+
+	order := map[string]int{"V": 0, "R": 1, "A": 2}
+	sort.Slice(scripts, func(i, j int) bool {
+		a, b := scripts[i], scripts[j]
+		if a.Type != b.Type {
+			return order[a.Type] < order[b.Type]
+		}
+		if a.Type == "V" {
+			return compareVersions(a.Version, b.Version) < 0
+		}
+		return a.Name < b.Name
+	})
+	return scripts
+}
+
+// compareVersions compares dotted versions such as 1.2 and 1.10 numerically.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// splitScript splits a change script into statements as the Snowflake
+// connectors do client-side, keeping literals and $$ blocks whole.
+func splitScript(script string) []string {
+	var statements []string
+	start := 0
+	for i := 0; i < len(script); i++ {
+		switch {
+		case script[i] == '\'':
+			for i++; i < len(script) && script[i] != '\''; i++ {
+			}
+		case strings.HasPrefix(script[i:], "$$"):
+			if end := strings.Index(script[i+2:], "$$"); end >= 0 {
+				i += 2 + end + 1
+			}
+		case strings.HasPrefix(script[i:], "--"):
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+		case script[i] == ';':
+			statements = append(statements, script[start:i])
+			start = i + 1
+		}
+	}
+	statements = append(statements, script[start:])
+
+	var nonEmpty []string
+	for _, statement := range statements {
+		if strings.TrimSpace(statement) != "" {
+			nonEmpty = append(nonEmpty, strings.TrimSpace(statement))
+		}
+	}
+	return nonEmpty
+}
+
+// schemachangeDeploy replays a schemachange deploy with
+// --create-change-history-table and returns the scripts it applied.
+func schemachangeDeploy(ctx context.Context, t *testing.T, db *sql.DB, scripts []migrationScript) []string {
+	t.Helper()
+	query := func(sql string) *sql.Rows {
+		t.Helper()
+		rows, err := db.QueryContext(ctx, sql)
+		if err != nil {
+			t.Fatalf("Query(%q) failed: %v", sql, err)
+		}
+		return rows
+	}
+	exec := func(sql string) {
+		t.Helper()
+		if _, err := db.ExecContext(ctx, sql); err != nil {
+			t.Fatalf("Exec(%q) failed: %v", sql, err)
+		}
+	}
+
+	// Look up the change history table, creating it on first deploy
+	rows := query(`SELECT CREATED, LAST_ALTERED FROM METADATA.INFORMATION_SCHEMA.TABLES ` +
+		`WHERE TABLE_SCHEMA = REPLACE('SCHEMACHANGE','"','') AND TABLE_NAME = REPLACE('CHANGE_HISTORY','"','')`)
+	exists := rows.Next()
+	_ = rows.Close()
+	if !exists {
+		exec("CREATE SCHEMA IF NOT EXISTS METADATA.SCHEMACHANGE")
+		exec("CREATE TABLE IF NOT EXISTS " + changeHistoryName + ` (
+			VERSION VARCHAR,
+			DESCRIPTION VARCHAR,
+			SCRIPT VARCHAR,
+			SCRIPT_TYPE VARCHAR,
+			CHECKSUM VARCHAR,
+			EXECUTION_TIME NUMBER,
+			STATUS VARCHAR,
+			INSTALLED_BY VARCHAR,
+			INSTALLED_ON TIMESTAMP_LTZ
+		)`)
+	}
+
+	maxVersion := ""
+	rows = query("SELECT VERSION FROM " + changeHistoryName + " WHERE SCRIPT_TYPE = 'V' ORDER BY INSTALLED_ON DESC LIMIT 1")
+	if rows.Next() {
+		if err := rows.Scan(&maxVersion); err != nil {
+			t.Fatalf("Scan version failed: %v", err)
+		}
+	}
+	_ = rows.Close()
+
+	checksums := map[string]string{}
+	rows = query("SELECT DISTINCT SCRIPT AS SCRIPT_NAME, FIRST_VALUE(CHECKSUM) OVER (PARTITION BY SCRIPT ORDER BY INSTALLED_ON DESC) AS CHECKSUM " +
+		"FROM " + changeHistoryName + " WHERE SCRIPT_TYPE = 'R' AND STATUS = 'Success'")
+	for rows.Next() {
+		var script, checksum string
+		if err := rows.Scan(&script, &checksum); err != nil {
+			t.Fatalf("Scan checksum failed: %v", err)
+		}
+		checksums[script] = checksum
+	}
+	_ = rows.Close()
+
+	var applied []string
+	for _, script := range scripts {
+		sum := sha256.Sum224([]byte(script.Body))
+		checksum := hex.EncodeToString(sum[:])
+		switch {
+		case script.Type == "V" && maxVersion != "" && compareVersions(script.Version, maxVersion) <= 0:
+			continue
+		case script.Type == "R" && checksums[script.Name] == checksum:
+			continue
+		}
+
+		started := time.Now()
+		for _, statement := range splitScript(script.Body) {
+			exec(statement)
+		}
+		exec(fmt.Sprintf("INSERT INTO %s (VERSION, DESCRIPTION, SCRIPT, SCRIPT_TYPE, CHECKSUM, EXECUTION_TIME, STATUS, INSTALLED_BY, INSTALLED_ON) "+
+			"VALUES ('%s', '%s', '%s', '%s', '%s', %d, 'Success', 'TESTUSER', CURRENT_TIMESTAMP)",
+			changeHistoryName, script.Version, script.Description, script.Name, script.Type, checksum, int(time.Since(started).Seconds())))
+		applied = append(applied, script.Name)
+	}
+	return applied
+}
+
+// TestSchemachange_Deploy deploys example/migrations/schemachange twice, as a
+// CI pipeline would, and checks that only always scripts run again.
+func TestSchemachange_Deploy(t *testing.T) {
+	db, ctx := openMigrationDB(t)
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS METADATA"); err != nil {
+		t.Fatalf("CREATE DATABASE failed: %v", err)
+	}
+	scripts := readSchemachangeScripts(t, schemachangeDir)
+
+	applied := schemachangeDeploy(ctx, t, db, scripts)
+	want := []string{"V1.1__create_customers.sql", "V1.2__create_orders.sql", "R__customer_orders.sql", "A__grants.sql"}
+	if strings.Join(applied, ",") != strings.Join(want, ",") {
+		t.Errorf("first deploy applied %v, want %v", applied, want)
+	}
+
+	applied = schemachangeDeploy(ctx, t, db, scripts)
+	if strings.Join(applied, ",") != "A__grants.sql" {
+		t.Errorf("second deploy applied %v, want only the always script", applied)
+	}
+
+	// The migrations ran, including the anonymous block of V1.2
+	var orders int
+	var total string
+	if err := db.QueryRowContext(ctx, "SELECT SUM(order_count), SUM(total) FROM customer_orders").Scan(&orders, &total); err != nil {
+		t.Fatalf("SELECT from view failed: %v", err)
+	}
+	if orders != 2 || total != "21.00" {
+		t.Errorf("customer_orders = (%d, %s), want (2, 21.00)", orders, total)
+	}
+
+	var history int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+changeHistoryName+" WHERE STATUS = 'Success'").Scan(&history); err != nil {
+		t.Fatalf("SELECT from change history failed: %v", err)
+	}
+	if history != 5 {
+		t.Errorf("change history rows = %d, want 5", history)
+	}
+}
+
+// TestFlyway_Migrate replays Flyway's schema history table statements for a
+// migrate into the FLYWAY schema of example/migrations/flyway/flyway.conf.
+func TestFlyway_Migrate(t *testing.T) {
+	db, ctx := openMigrationDB(t)
+	const history = `"TEST_DB"."FLYWAY"."flyway_schema_history"`
+
+	statements := []string{
+		`CREATE SCHEMA "TEST_DB"."FLYWAY"`,
+		`CREATE TABLE ` + history + ` (
+			"installed_rank" NUMBER(38,0) NOT NULL,
+			"version" VARCHAR(50),
+			"description" VARCHAR(200),
+			"type" VARCHAR(20) NOT NULL,
+			"script" VARCHAR(1000) NOT NULL,
+			"checksum" NUMBER(38,0),
+			"installed_by" VARCHAR(100) NOT NULL,
+			"installed_on" TIMESTAMP_LTZ(9) NOT NULL DEFAULT CURRENT_TIMESTAMP(),
+			"execution_time" NUMBER(38,0) NOT NULL,
+			"success" BOOLEAN NOT NULL,
+			constraint "flyway_schema_history_pk" primary key ("installed_rank")
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("Exec(%q) failed: %v", statement, err)
+		}
+	}
+
+	insert := `INSERT INTO ` + history + ` ("installed_rank", "version", "description", "type", "script", "checksum", "installed_by", "execution_time", "success") ` +
+		`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := db.ExecContext(ctx, insert, 1, nil, "<< Flyway Schema Creation >>", "SCHEMA", `"FLYWAY"`, nil, "testuser", 0, true); err != nil {
+		t.Fatalf("INSERT schema marker failed: %v", err)
+	}
+
+	for i, name := range []string{"V1__create_products.sql", "V2__seed_products.sql"} {
+		body, err := os.ReadFile(filepath.Join(flywayDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		for _, statement := range splitScript(string(body)) {
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				t.Fatalf("%s: Exec(%q) failed: %v", name, statement, err)
+			}
+		}
+		version := strconv.Itoa(i + 1)
+		if _, err := db.ExecContext(ctx, insert, i+2, version, name, "SQL", name, 12345, "testuser", 3, true); err != nil {
+			t.Fatalf("INSERT history row for %s failed: %v", name, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT "installed_rank", "version", "type", "success" FROM `+history+
+		` WHERE "installed_rank" > ? ORDER BY "installed_rank"`, 0)
+	if err != nil {
+		t.Fatalf("SELECT history failed: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var rank int
+		var version sql.NullString
+		var kind string
+		var success bool
+		if err := rows.Scan(&rank, &version, &kind, &success); err != nil {
+			t.Fatalf("Scan history failed: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%d:%s:%s:%v", rank, version.String, kind, success))
+	}
+	want := []string{"1::SCHEMA:true", "2:1:SQL:true", "3:2:SQL:true"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("history = %v, want %v", got, want)
+	}
+
+	var products int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products").Scan(&products); err != nil {
+		t.Fatalf("SELECT products failed: %v", err)
+	}
+	if products != 2 {
+		t.Errorf("products = %d, want 2", products)
+	}
+}
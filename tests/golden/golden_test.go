@@ -0,0 +1,321 @@
+// tests/golden/golden_test.go - protocol snapshot tests
+//
+// These tests replay HTTP exchanges of gosnowflake, recorded in
+// testdata/*.json, against the emulator and compare the shape of every
+// response field by field with the recorded one, so that protocol drift shows
+// up whenever the handlers change.
+//
+// The checked-in recordings were made from the emulator itself, so they are
+// snapshots: they catch changes between emulator versions, not differences
+// from Snowflake. Recording from a real account turns them into conformance
+// checks. Exchanges are recorded with the -record flag, from the account of the
+// gosnowflake DSN in SNOWFLAKE_GOLDEN_DSN, or from an in-process emulator when
+// it is not set:
+//
+//	SNOWFLAKE_GOLDEN_DSN='user:password@myaccount/MYDB/PUBLIC?warehouse=MYWH' go test ./tests/golden -record
+//
+// Credentials, tokens, the names of the account's objects, and details of the
+// recording machine are redacted before they are written.
+package golden
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/connection"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/metadata"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/query"
+	"github.com/nnnkkk7/snowflake-emulator/pkg/session"
+	"github.com/nnnkkk7/snowflake-emulator/server/handlers"
+	"github.com/snowflakedb/gosnowflake"
+)
+
+var record = flag.Bool("record", false, "record the golden exchanges instead of only replaying them")
+
+// Sources of recordings.
+const (
+	sourceSnowflake = "snowflake"
+	sourceEmulator  = "emulator"
+)
+
+// loginPath is the path of gosnowflake's login request, whose response holds
+// the session token of the requests after it.
+const loginPath = "/session/v1/login-request"
+
+// step is a statement a scenario runs, with its bind arguments.
+type step struct {
+	SQL  string
+	Args []interface{}
+}
+
+// scenario is a sequence of statements run in one session, recorded into
+// testdata/<Name>.json. Statements only create temporary objects so that they
+// run unchanged against any Snowflake account.
+type scenario struct {
+	Name  string
+	Steps []step
+}
+
+var scenarios = []scenario{
+	{Name: "select_types", Steps: []step{
+		{SQL: "SELECT 1 AS n, 1.50 AS d, CAST(1.5 AS FLOAT) AS f, 'a' AS s, TRUE AS b, NULL AS z, " +
+			"CAST('2024-01-15' AS DATE) AS dt, CAST('2024-01-15 10:30:00' AS TIMESTAMP_NTZ) AS ts"},
+	}},
+	{Name: "bindings", Steps: []step{
+		{SQL: "SELECT ? AS n, ? AS s", Args: []interface{}{1, "x"}},
+	}},
+	{Name: "dml", Steps: []step{
+		{SQL: "CREATE TEMPORARY TABLE golden_rows (id NUMBER, name VARCHAR)"},
+		{SQL: "INSERT INTO golden_rows VALUES (1, 'a'), (2, 'b')"},
+		{SQL: "UPDATE golden_rows SET name = 'c' WHERE id = 2"},
+		{SQL: "DELETE FROM golden_rows WHERE id = 1"},
+		{SQL: "DROP TABLE golden_rows"},
+	}},
+	{Name: "error", Steps: []step{
+		{SQL: "SELECT * FROM golden_missing_table"},
+	}},
+}
+
+// golden is a recording of a scenario.
+type golden struct {
+	// Source is where the exchanges were recorded, snowflake or emulator.
+	Source      string `json:"source"`
+	Gosnowflake string `json:"gosnowflake"`
+	// Ignore lists the fields known to differ, such as data.rowtype[].collation,
+	// which replays skip. It is kept when the scenario is recorded again.
+	Ignore    []string   `json:"ignore,omitempty"`
+	Exchanges []exchange `json:"exchanges"`
+}
+
+// setupEmulator starts an in-process emulator serving the gosnowflake protocol
+// and returns its URL.
+func setupEmulator(t *testing.T) string {
+	t.Helper()
+
+	duck, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("failed to open DuckDB: %v", err)
+	}
+	t.Cleanup(func() { _ = duck.Close() })
+
+	connMgr := connection.NewManager(duck)
+	repo, err := metadata.NewRepository(connMgr)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	executor := query.NewExecutor(connMgr, repo)
+
+	sessionMgr := session.NewManager(1 * time.Hour)
+	executor.Configure(query.WithSessionUpdater(sessionMgr))
+	sessionHandler := handlers.NewSessionHandler(sessionMgr, repo)
+	queryHandler := handlers.NewQueryHandler(executor, sessionMgr)
+
+	r := chi.NewRouter()
+	r.Post(loginPath, sessionHandler.Login)
+	r.Post("/session/heartbeat", sessionHandler.Heartbeat)
+	r.Post("/session/renew", sessionHandler.RenewSession)
+	r.Post("/session/logout", sessionHandler.Logout)
+	r.Post("/session", sessionHandler.CloseSession)
+	r.Post("/queries/v1/query-request", queryHandler.ExecuteQuery)
+	r.Post("/queries/v1/abort-request", queryHandler.AbortQuery)
+	r.Post("/telemetry/send", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true}`))
+	})
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// goldenPath returns the path of a scenario's recording.
+func goldenPath(name string) string {
+	return filepath.Join("testdata", name+".json")
+}
+
+// readGolden reads a scenario's recording.
+func readGolden(name string) (golden, error) {
+	var g golden
+	data, err := os.ReadFile(goldenPath(name))
+	if err != nil {
+		return g, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&g)
+	return g, err
+}
+
+// recordScenario runs a scenario through gosnowflake on the account of dsn,
+// recording its exchanges.
+func recordScenario(ctx context.Context, dsn string, sc scenario) ([]exchange, error) {
+	cfg, err := gosnowflake.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	// The emulator returns JSON result sets rather than Arrow ones
+	format := "json"
+	if cfg.Params == nil {
+		cfg.Params = map[string]*string{}
+	}
+	cfg.Params["GO_QUERY_RESULT_FORMAT"] = &format
+	rec := &recorder{base: http.DefaultTransport, host: cfg.Host}
+	cfg.Transporter = rec
+
+	db := sql.OpenDB(gosnowflake.NewConnector(gosnowflake.SnowflakeDriver{}, *cfg))
+	db.SetMaxOpenConns(1)
+	for _, s := range sc.Steps {
+		// Failing statements are recorded too, and the exchange of a statement
+		// is over once it returns, so its rows are not read
+		if rows, err := db.QueryContext(ctx, s.SQL, s.Args...); err == nil {
+			_ = rows.Close()
+		}
+	}
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.exchanges, nil
+}
+
+// recordGoldens records every scenario into testdata.
+func recordGoldens(ctx context.Context, t *testing.T) {
+	t.Helper()
+	source, dsn := sourceSnowflake, os.Getenv("SNOWFLAKE_GOLDEN_DSN")
+	if dsn == "" {
+		source = sourceEmulator
+		dsn = fmt.Sprintf("golden:golden@%s/GOLDEN_DB/PUBLIC?account=golden&protocol=http&loginTimeout=5",
+			strings.TrimPrefix(setupEmulator(t), "http://"))
+		t.Log("SNOWFLAKE_GOLDEN_DSN not set; recording from the emulator")
+	}
+
+	for _, sc := range scenarios {
+		exchanges, err := recordScenario(ctx, dsn, sc)
+		if err != nil {
+			t.Fatalf("failed to record %s: %v", sc.Name, err)
+		}
+		g := golden{Source: source, Gosnowflake: gosnowflake.SnowflakeGoDriverVersion, Exchanges: exchanges}
+		if previous, err := readGolden(sc.Name); err == nil {
+			g.Ignore = previous.Ignore
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(g); err != nil {
+			t.Fatalf("failed to encode %s: %v", sc.Name, err)
+		}
+		if err := os.WriteFile(goldenPath(sc.Name), buf.Bytes(), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", sc.Name, err)
+		}
+	}
+}
+
+// replay sends the recorded requests of g to the emulator at baseURL in order
+// and compares each response with the recorded one.
+func replay(ctx context.Context, t *testing.T, baseURL string, g golden) {
+	t.Helper()
+	ignored := ignoreFunc(g.Ignore)
+	var token string
+	for i, ex := range g.Exchanges {
+		name := fmt.Sprintf("exchange %d (%s %s)", i, ex.Request.Method, ex.Request.Path)
+
+		query := url.Values{}
+		for key, value := range ex.Request.Query {
+			if value == generated {
+				value = uuid.NewString()
+			}
+			query.Set(key, value)
+		}
+		var body []byte
+		switch b := ex.Request.Body.(type) {
+		case nil:
+		case string:
+			body = []byte(b)
+		default:
+			var err error
+			if body, err = json.Marshal(b); err != nil {
+				t.Fatalf("%s: failed to encode request: %v", name, err)
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, ex.Request.Method, baseURL+ex.Request.Path+"?"+query.Encode(), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: failed to create request: %v", name, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/snowflake")
+		if token != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Snowflake Token=%q", token))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", name, err)
+		}
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: failed to read response: %v", name, err)
+		}
+		got := decodeBody(buf.Bytes())
+
+		if ex.Request.Path == loginPath {
+			if data, ok := got.(map[string]interface{})["data"].(map[string]interface{}); ok {
+				token, _ = data["token"].(string)
+			}
+		}
+
+		if resp.StatusCode != ex.Response.Status {
+			t.Errorf("%s: status %d, want %d", name, resp.StatusCode, ex.Response.Status)
+		}
+		diffs, extra := shapeDiff("", ex.Response.Body, got, ignored)
+		for _, d := range diffs {
+			t.Errorf("%s: %s", name, d)
+		}
+		if len(extra) > 0 {
+			t.Logf("%s: fields not in the recording: %s", name, strings.Join(extra, ", "))
+		}
+	}
+}
+
+// TestGolden replays the recorded exchanges of every scenario against a fresh
+// emulator.
+func TestGolden(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if *record {
+		recordGoldens(ctx, t)
+	}
+
+	for _, sc := range scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			g, err := readGolden(sc.Name)
+			if err != nil {
+				t.Fatalf("failed to read the recording (record it with -record): %v", err)
+			}
+			if g.Source != sourceSnowflake {
+				t.Logf("replaying a snapshot recorded from the %s, which checks no conformance with Snowflake; record it from Snowflake with SNOWFLAKE_GOLDEN_DSN", g.Source)
+			}
+			replay(ctx, t, setupEmulator(t), g)
+		})
+	}
+}
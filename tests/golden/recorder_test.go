@@ -0,0 +1,217 @@
+package golden
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Placeholders written in place of sanitized values.
+const (
+	redacted = "<redacted>"
+	// generated marks request IDs, which are replaced with fresh ones on replay
+	// so that the emulator does not answer them as retries.
+	generated = "<generated>"
+)
+
+// redactedFields are the JSON fields whose values are credentials, tokens,
+// names of the account's objects, or details of the recording machine, such as
+// the stack traces with its file paths that gosnowflake's telemetry sends with
+// client errors, and its library loading logs. Their strings are replaced with <redacted> and their numbers
+// with 0, so that their shape is kept. Names are compared case-insensitively.
+var redactedFields = map[string]bool{
+	"password":           true,
+	"passcode":           true,
+	"token":              true,
+	"mastertoken":        true,
+	"idtoken":            true,
+	"mfatoken":           true,
+	"remmetoken":         true,
+	"sessiontoken":       true,
+	"privatekey":         true,
+	"login_name":         true,
+	"account_name":       true,
+	"client_environment": true,
+	"displayusername":    true,
+	"sessionid":          true,
+	"qrmk":               true,
+	"chunkheaders":       true,
+	"databasename":       true,
+	"schemaname":         true,
+	"warehousename":      true,
+	"rolename":           true,
+	"finaldatabasename":  true,
+	"finalschemaname":    true,
+	"finalwarehousename": true,
+	"finalrolename":      true,
+	"stacktrace":         true,
+	"minicorelogs":       true,
+}
+
+// generatedParams are the query parameters holding request IDs.
+var generatedParams = map[string]bool{"requestId": true, "request_guid": true}
+
+// contextParams are the query parameters of the login request naming the
+// session's database, schema, warehouse, and role, and the names recordings
+// use instead.
+var contextParams = map[string]string{
+	"databaseName": "GOLDEN_DB",
+	"schemaName":   "PUBLIC",
+	"warehouse":    "GOLDEN_WH",
+	"roleName":     "SYSADMIN",
+}
+
+// request is a recorded HTTP request. Bodies are decoded JSON, or strings when
+// they are not JSON.
+type request struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query,omitempty"`
+	Body   interface{}       `json:"body,omitempty"`
+}
+
+// response is a recorded HTTP response.
+type response struct {
+	Status int         `json:"status"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// exchange is a recorded request and the response it got.
+type exchange struct {
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+}
+
+// recorder is an http.RoundTripper that records the sanitized exchanges with
+// host while passing them on to base.
+type recorder struct {
+	base http.RoundTripper
+	host string
+
+	mu        sync.Mutex
+	exchanges []exchange
+}
+
+// RoundTrip sends the request through the base transport and records it.
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		reqBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil || req.URL.Hostname() != r.host {
+		return resp, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recorded := exchange{
+		Request: request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Body:   sanitize(decodeBody(reqBody)),
+		},
+		Response: response{Status: resp.StatusCode, Body: sanitize(decodeBody(respBody))},
+	}
+	for name, values := range req.URL.Query() {
+		if len(values) == 0 {
+			continue
+		}
+		if recorded.Request.Query == nil {
+			recorded.Request.Query = map[string]string{}
+		}
+		recorded.Request.Query[name] = sanitizeParam(name, values[0])
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, recorded)
+	return resp, nil
+}
+
+// decodeBody decodes a body, gzipped or not, as JSON with numbers kept as
+// json.Number. Bodies that are not JSON are returned as strings, and empty
+// ones as nil.
+func decodeBody(data []byte) interface{} {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		if zr, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+			if unzipped, err := io.ReadAll(zr); err == nil {
+				data = unzipped
+			}
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return string(data)
+	}
+	return v
+}
+
+// sanitize returns v with the values of redactedFields replaced.
+func sanitize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if redactedFields[strings.ToLower(key)] {
+				v[key] = redact(value)
+			} else {
+				v[key] = sanitize(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = sanitize(value)
+		}
+	}
+	return v
+}
+
+// redact replaces the strings and numbers of v, keeping its shape.
+func redact(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return redacted
+	case json.Number:
+		return json.Number("0")
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = redact(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redact(value)
+		}
+	}
+	return v
+}
+
+// sanitizeParam returns the recorded value of a query parameter.
+func sanitizeParam(name, value string) string {
+	if generatedParams[name] {
+		return generated
+	}
+	if placeholder, ok := contextParams[name]; ok && value != "" {
+		return placeholder
+	}
+	return value
+}
@@ -0,0 +1,211 @@
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// kind returns the JSON type of a decoded value.
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// shapeDiff compares the shape of the emulator's response body got with the
+// recorded body want, field by field: every field of want must be in got with
+// the same JSON type, and arrays must have the same length. Values are not
+// compared. It returns the fields that differ, and separately the fields only
+// got has. Fields matched by ignored are skipped.
+func shapeDiff(path string, want, got interface{}, ignored func(string) bool) (diffs, extra []string) {
+	if ignored(path) {
+		return nil, nil
+	}
+	if kind(want) != kind(got) {
+		return []string{fmt.Sprintf("%s: %s, want %s", displayPath(path), kind(got), kind(want))}, nil
+	}
+	switch want := want.(type) {
+	case map[string]interface{}:
+		got := got.(map[string]interface{})
+		for _, key := range sortedKeys(want) {
+			field := path + "." + key
+			value, ok := got[key]
+			if !ok {
+				if !ignored(field) {
+					diffs = append(diffs, displayPath(field)+": missing")
+				}
+				continue
+			}
+			d, e := shapeDiff(field, want[key], value, ignored)
+			diffs, extra = append(diffs, d...), append(extra, e...)
+		}
+		for _, key := range sortedKeys(got) {
+			if _, ok := want[key]; !ok && !ignored(path+"."+key) {
+				extra = append(extra, displayPath(path+"."+key))
+			}
+		}
+	case []interface{}:
+		got := got.([]interface{})
+		if len(want) != len(got) {
+			diffs = append(diffs, fmt.Sprintf("%s: %d elements, want %d", displayPath(path), len(got), len(want)))
+		}
+		for i := 0; i < len(want) && i < len(got); i++ {
+			d, e := shapeDiff(fmt.Sprintf("%s[%d]", path, i), want[i], got[i], ignored)
+			diffs, extra = append(diffs, d...), append(extra, e...)
+		}
+	}
+	return diffs, extra
+}
+
+// ignoreFunc returns a function reporting whether a field is matched by one
+// of patterns. A pattern is a field path such as data.rowtype[].collation,
+// with [] matching any index, and also matches the fields under it.
+func ignoreFunc(patterns []string) func(string) bool {
+	return func(path string) bool {
+		path = normalizeIndexes(displayPath(path))
+		for _, pattern := range patterns {
+			if path == pattern || strings.HasPrefix(path, pattern+".") || strings.HasPrefix(path, pattern+"[") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// displayPath strips the leading dot of a field path.
+func displayPath(path string) string {
+	if path == "" {
+		return "(body)"
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+// normalizeIndexes replaces the array indexes of a field path with [].
+func normalizeIndexes(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		b.WriteByte(path[i])
+		if path[i] == '[' {
+			for i+1 < len(path) && path[i+1] != ']' {
+				i++
+			}
+		}
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestShapeDiff(t *testing.T) {
+	want := map[string]interface{}{
+		"success": true,
+		"code":    nil,
+		"data": map[string]interface{}{
+			"rowtype": []interface{}{
+				map[string]interface{}{"name": "N", "precision": json.Number("1"), "collation": nil},
+			},
+			"rowset":     []interface{}{[]interface{}{"1"}},
+			"queryId":    "abc",
+			"parameters": []interface{}{map[string]interface{}{"name": "TIMEZONE"}},
+		},
+	}
+	got := map[string]interface{}{
+		"success": true,
+		"code":    nil,
+		"message": nil,
+		"data": map[string]interface{}{
+			"rowtype": []interface{}{
+				map[string]interface{}{"name": "N", "precision": "1", "collation": ""},
+			},
+			"rowset":  []interface{}{[]interface{}{"1"}, []interface{}{"2"}},
+			"queryId": "def",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		ignore    []string
+		wantDiffs []string
+		wantExtra []string
+	}{
+		{
+			name: "AllFields",
+			wantDiffs: []string{
+				"data.parameters: missing",
+				"data.rowset: 2 elements, want 1",
+				"data.rowtype[0].collation: string, want null",
+				"data.rowtype[0].precision: string, want number",
+			},
+			wantExtra: []string{"message"},
+		},
+		{
+			name:      "Ignored",
+			ignore:    []string{"data.parameters", "data.rowtype[].collation", "data.rowset", "message"},
+			wantDiffs: []string{"data.rowtype[0].precision: string, want number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, extra := shapeDiff("", want, got, ignoreFunc(tt.ignore))
+			sort.Strings(diffs)
+			if diff := cmp.Diff(tt.wantDiffs, diffs); diff != "" {
+				t.Errorf("shapeDiff() diffs mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantExtra, extra); diff != "" {
+				t.Errorf("shapeDiff() extra mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	body := decodeBody([]byte(`{"data": {"LOGIN_NAME": "alice", "PASSWORD": "secret", "CLIENT_APP_ID": "Go",
+		"token": "t0k3n", "sessionId": 12345, "sessionInfo": {"databaseName": "PROD", "roleName": null}, "chunkHeaders": {"x-amz-key": "k"}},
+		"logs": [{"message": {"ErrorNumber": "1007", "Stacktrace": "goroutine 8 [running]:\n\t/home/alice/go/pkg/mod/errors.go:44"}}]}`))
+	want := map[string]interface{}{"data": map[string]interface{}{
+		"LOGIN_NAME":    redacted,
+		"PASSWORD":      redacted,
+		"CLIENT_APP_ID": "Go",
+		"token":         redacted,
+		"sessionId":     json.Number("0"),
+		"sessionInfo":   map[string]interface{}{"databaseName": redacted, "roleName": nil},
+		"chunkHeaders":  map[string]interface{}{"x-amz-key": redacted},
+	}, "logs": []interface{}{map[string]interface{}{"message": map[string]interface{}{"ErrorNumber": "1007", "Stacktrace": redacted}}}}
+	if diff := cmp.Diff(want, sanitize(body)); diff != "" {
+		t.Errorf("sanitize() mismatch (-want +got):\n%s", diff)
+	}
+
+	params := map[string]string{"requestId": "3f2c", "databaseName": "PROD", "warehouse": "", "delete": "true"}
+	wantParams := map[string]string{"requestId": generated, "databaseName": "GOLDEN_DB", "warehouse": "", "delete": "true"}
+	for name, value := range params {
+		if got := sanitizeParam(name, value); got != wantParams[name] {
+			t.Errorf("sanitizeParam(%q, %q) = %q, want %q", name, value, got, wantParams[name])
+		}
+	}
+}